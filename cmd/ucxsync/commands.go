@@ -1,10 +1,15 @@
 package main
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/zangezia/UCXSync/internal/config"
 	"github.com/zangezia/UCXSync/internal/network"
+	syncService "github.com/zangezia/UCXSync/internal/sync"
 )
 
 func runMount(cmd *cobra.Command, args []string) {
@@ -31,6 +36,7 @@ func runMount(cmd *cobra.Command, args []string) {
 		cfg.Credentials.Password,
 	)
 	netService.SetBaseMountDir(cfg.Network.MountRoot)
+	netService.SetNodeShares(cfg.NodeShareMap())
 	netService.SetMountOptions(cfg.Network.MountOptions)
 
 	// Mount all shares
@@ -62,6 +68,7 @@ func runUnmount(cmd *cobra.Command, args []string) {
 		cfg.Credentials.Password,
 	)
 	netService.SetBaseMountDir(cfg.Network.MountRoot)
+	netService.SetNodeShares(cfg.NodeShareMap())
 	netService.SetMountOptions(cfg.Network.MountOptions)
 
 	// Unmount all shares
@@ -89,6 +96,8 @@ func runCheck(cmd *cobra.Command, args []string) {
 	log.Info().Int("shares", len(cfg.Shares)).Msg("Configured shares")
 	log.Info().Str("mount_root", cfg.Network.MountRoot).Msg("Configured mount root")
 
+	checkNodeClocks(cfg.Nodes)
+
 	// Check network requirements
 	if err := network.CheckRequirements(); err != nil {
 		log.Error().Err(err).Msg("✗ Network requirements not met")
@@ -105,3 +114,54 @@ func runCheck(cmd *cobra.Command, args []string) {
 	log.Info().Msg("  1. Mount shares: sudo ucxsync mount")
 	log.Info().Msg("  2. Start server: sudo ucxsync")
 }
+
+// checkNodeClocks reports each node's clock skew against this machine. Skew
+// beyond the mtime tolerance makes incremental copying miss or repeat files.
+func checkNodeClocks(nodes []string) {
+	const timeout = 3 * time.Second
+
+	ctx := context.Background()
+	readings := make([]network.ClockReading, len(nodes))
+	errs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node string) {
+			defer wg.Done()
+			readings[i], errs[i] = network.QueryNodeClock(ctx, node, timeout)
+		}(i, node)
+	}
+	wg.Wait()
+
+	skewed := 0
+	for i := range nodes {
+		if errs[i] != nil {
+			log.Warn().Str("node", nodes[i]).Err(errs[i]).Msg("Node clock could not be read")
+			continue
+		}
+		reading := readings[i]
+		skew := reading.Skew.Round(time.Millisecond)
+		if absDuration(reading.Skew) > syncService.DefaultMTimeTolerance {
+			skewed++
+			log.Warn().
+				Str("node", reading.Node).
+				Str("method", reading.Method).
+				Dur("skew", skew).
+				Dur("tolerance", syncService.DefaultMTimeTolerance).
+				Msg("✗ Node clock skew exceeds mtime tolerance")
+			continue
+		}
+		log.Info().Str("node", reading.Node).Str("method", reading.Method).Dur("skew", skew).Msg("✓ Node clock in sync")
+	}
+
+	if skewed > 0 {
+		log.Warn().Int("nodes", skewed).Msg("Synchronize node clocks (w32tm /resync) before copying, or incremental sync may skip changed files")
+	}
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
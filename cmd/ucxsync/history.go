@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zangezia/UCXSync/internal/config"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List past sync jobs of the running instance",
+	Long: `Lists the sync jobs recorded in the state database, newest first: when each
+Start/Stop cycle ran, the project and destination, and the files, bytes,
+failures and completed captures of the run. Talks to the running ucxsync web
+API.`,
+	Args: cobra.NoArgs,
+	Run:  runHistory,
+}
+
+func init() {
+	historyCmd.Flags().String("project", "", "only list jobs of this project")
+	historyCmd.Flags().Int("limit", 20, "number of jobs to list")
+	historyCmd.Flags().Bool("json", false, "print the jobs as JSON")
+	historyCmd.Flags().String("addr", "", "address of the running instance (default: 127.0.0.1:<web.port>)")
+}
+
+func runHistory(cmd *cobra.Command, args []string) {
+	addr, _ := cmd.Flags().GetString("addr")
+	if addr == "" {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+			os.Exit(1)
+		}
+		addr = fmt.Sprintf("127.0.0.1:%d", cfg.Web.Port)
+	}
+	project, _ := cmd.Flags().GetString("project")
+	limit, _ := cmd.Flags().GetInt("limit")
+	query := url.Values{"limit": {strconv.Itoa(limit)}}
+	if project != "" {
+		query.Set("project", project)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	resp, err := client.Get("http://" + addr + "/api/history?" + query.Encode())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Request failed: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		fmt.Fprintf(os.Stderr, "Error: %s\n", strings.TrimSpace(string(msg)))
+		os.Exit(1)
+	}
+
+	var jobs []models.JobRecord
+	if err := json.NewDecoder(resp.Body).Decode(&jobs); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		data, _ := json.MarshalIndent(jobs, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	if len(jobs) == 0 {
+		fmt.Println("No sync jobs recorded")
+		return
+	}
+	for _, job := range jobs {
+		printJobRecord(job)
+	}
+}
+
+func printJobRecord(job models.JobRecord) {
+	started := job.StartedAt.Local().Format("2006-01-02 15:04:05")
+	state := job.State
+	if job.Reason != "" {
+		state += " (" + job.Reason + ")"
+	}
+	duration := ""
+	if job.FinishedAt != nil {
+		duration = job.FinishedAt.Sub(job.StartedAt).Round(time.Second).String()
+	}
+	fmt.Printf("%s  %-10s %-20s %9s  %s\n", started, job.Project, state, duration, job.JobID)
+	if job.FinishedAt != nil {
+		totals := job.Totals
+		fmt.Printf("    %d files copied, %.1f GB, %d failed, %d captures completed (%d test)  → %s\n",
+			totals.CopiedFiles, gigabytes(totals.CopiedBytes), totals.FailedFiles,
+			totals.CompletedCaptures, totals.CompletedTestCaptures, job.Destination)
+	}
+}
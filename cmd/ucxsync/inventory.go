@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/zangezia/UCXSync/internal/config"
+	"github.com/zangezia/UCXSync/internal/storage"
+	syncService "github.com/zangezia/UCXSync/internal/sync"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+var inventoryCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "List what a project holds on the worker shares without copying",
+	Long: `Walks the project folder on every mounted node/share and reports file counts,
+sizes, captures and sessions per share. Nothing is copied. The full listing is
+saved as JSON for mission planning and space budgeting; mount the shares first
+with "ucxsync mount".`,
+	Run: runInventory,
+}
+
+func init() {
+	inventoryCmd.Flags().String("project", "", "project to inventory (default: sync.project)")
+	inventoryCmd.Flags().StringP("output", "o", "", `JSON file to write (default: inventory-<project>-<time>.json, "-" for stdout)`)
+}
+
+func runInventory(cmd *cobra.Command, args []string) {
+	setupLogging()
+
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load configuration")
+	}
+
+	project, _ := cmd.Flags().GetString("project")
+	if project == "" {
+		project = cfg.Sync.Project
+	}
+	if project == "" {
+		fmt.Fprintln(os.Stderr, "Error: --project is required")
+		os.Exit(1)
+	}
+
+	source, err := storage.NewSource(cfg.Storage.Source.Driver, cfg.Storage.Source.Params)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to open source storage")
+	}
+
+	if err := syncService.SetFileNamePatterns(syncService.FileNamePatterns{
+		Raw:           cfg.Sync.Naming.Raw,
+		Metadata:      cfg.Sync.Naming.Metadata,
+		Quality:       cfg.Sync.Naming.Quality,
+		VerifiedLevel: cfg.Sync.Naming.VerifiedLevel,
+	}); err != nil {
+		log.Fatal().Err(err).Msg("Invalid file name patterns")
+	}
+	svc := syncService.New(cfg.Nodes, cfg.Shares, cfg.Network.MountRoot)
+	svc.SetStorage(source, nil)
+	svc.SetNodeShares(cfg.NodeShareMap())
+	svc.SetShareSubpaths(cfg.ShareSubpaths)
+	svc.SetExclusions(models.Exclusions{
+		Directories: cfg.Exclusions.Directories,
+		Projects:    cfg.Exclusions.Projects,
+	})
+	svc.SetFileFilter(syncService.FileFilter{Include: cfg.Sync.Include, Exclude: cfg.Sync.Exclude})
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	log.Info().Str("project", project).Msg("Taking inventory of worker shares...")
+	inventory, err := svc.Inventory(ctx, project)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Inventory failed")
+	}
+
+	data, err := json.MarshalIndent(inventory, "", "  ")
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to encode inventory")
+	}
+
+	output, _ := cmd.Flags().GetString("output")
+	if output == "-" {
+		fmt.Println(string(data))
+		return
+	}
+	if output == "" {
+		output = fmt.Sprintf("inventory-%s-%s.json", project, inventory.GeneratedAt.Local().Format("20060102-150405"))
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		log.Fatal().Err(err).Msg("Failed to write inventory")
+	}
+
+	printInventory(inventory)
+	fmt.Printf("Inventory written to %s\n", output)
+}
+
+func printInventory(inventory models.Inventory) {
+	for _, share := range inventory.Shares {
+		if share.Error != "" {
+			fmt.Printf("✗ %-6s %-4s %s\n", share.Node, share.Share, share.Error)
+			continue
+		}
+		fmt.Printf("  %-6s %-4s %7d files %10.1f GB %6d captures %4d test\n",
+			share.Node, share.Share, share.Files, gigabytes(share.Bytes), share.Captures, share.TestCaptures)
+	}
+	for _, session := range inventory.Sessions {
+		fmt.Printf("  session %s: %d captures (%s-%s), %d test, %.1f GB\n",
+			session.SessionID, session.Captures, session.FirstCapture, session.LastCapture, session.TestCaptures, gigabytes(session.Bytes))
+	}
+	fmt.Printf("Total: %d files, %.1f GB, %d captures, %d test captures, %d sessions\n",
+		inventory.Files, gigabytes(inventory.Bytes), inventory.Captures, inventory.TestCaptures, len(inventory.Sessions))
+}
+
+func gigabytes(bytes int64) float64 {
+	return float64(bytes) / (1 << 30)
+}
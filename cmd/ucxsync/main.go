@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/zangezia/UCXSync/internal/config"
+	"github.com/zangezia/UCXSync/internal/logstorm"
+	"github.com/zangezia/UCXSync/internal/simulate"
 	"github.com/zangezia/UCXSync/internal/web"
 )
 
@@ -58,10 +61,17 @@ func init() {
 	rootCmd.Flags().String("dest", "", "destination directory")
 	rootCmd.Flags().Int("port", 8080, "web server port")
 	rootCmd.Flags().Int("parallelism", 8, "max parallel file operations")
+	rootCmd.Flags().Bool("simulate", false, "generate a fake project instead of mounting camera shares")
 
 	rootCmd.AddCommand(mountCmd)
 	rootCmd.AddCommand(unmountCmd)
 	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(selftestCmd)
+	rootCmd.AddCommand(maintenanceCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(inventoryCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(snapshotCmd)
 }
 
 func main() {
@@ -74,20 +84,50 @@ func main() {
 func applyCLIOverrides(cmd *cobra.Command, cfg *config.Config) {
 	if project, _ := cmd.Flags().GetString("project"); project != "" {
 		cfg.Sync.Project = project
+		cfg.SetSource("sync.project", config.SourceFlag)
 	}
 	if dest, _ := cmd.Flags().GetString("dest"); dest != "" {
 		cfg.Sync.Destination = dest
+		cfg.SetSource("sync.destination", config.SourceFlag)
 	}
 	if cmd.Flags().Changed("port") {
 		if port, _ := cmd.Flags().GetInt("port"); port != 0 {
 			cfg.Web.Port = port
+			cfg.SetSource("web.port", config.SourceFlag)
 		}
 	}
 	if cmd.Flags().Changed("parallelism") {
 		if parallelism, _ := cmd.Flags().GetInt("parallelism"); parallelism != 0 {
 			cfg.Sync.MaxParallelism = parallelism
+			cfg.SetSource("sync.max_parallelism", config.SourceFlag)
 		}
 	}
+	if simulateMode, _ := cmd.Flags().GetBool("simulate"); simulateMode {
+		cfg.Simulate.Enabled = true
+		cfg.SetSource("simulate.enabled", config.SourceFlag)
+	}
+}
+
+// prepareSimulation points the mount root (and, unless configured, the state
+// database) into the simulation directory.
+func prepareSimulation(cfg *config.Config) error {
+	if cfg.Simulate.Root == "" {
+		root, err := os.MkdirTemp("", "ucxsync-sim-")
+		if err != nil {
+			return fmt.Errorf("failed to create simulation directory: %w", err)
+		}
+		cfg.Simulate.Root = root
+	}
+
+	cfg.Network.MountRoot = filepath.Join(cfg.Simulate.Root, "ucmount")
+	cfg.SetSource("network.mount_root", config.SourceFlag)
+
+	if cfg.Source("database.path") == config.SourceDefault {
+		cfg.Database.Path = filepath.Join(cfg.Simulate.Root, "state.db")
+		cfg.SetSource("database.path", config.SourceFlag)
+	}
+
+	return os.MkdirAll(web.SimulatedDestination(cfg), 0755)
 }
 
 func runApp(cmd *cobra.Command, args []string) {
@@ -108,6 +148,13 @@ func runApp(cmd *cobra.Command, args []string) {
 	// Override config with command-line flags that were explicitly provided.
 	applyCLIOverrides(cmd, cfg)
 
+	if cfg.Simulate.Enabled {
+		if err := prepareSimulation(cfg); err != nil {
+			log.Fatal().Err(err).Msg("Failed to prepare simulation")
+		}
+		log.Warn().Str("root", cfg.Simulate.Root).Msg("Simulation mode: camera shares are generated locally")
+	}
+
 	// Display startup banner
 	log.Info().Msg("========================================")
 	log.Info().Msg("       UCXSync - File Synchronization   ")
@@ -121,6 +168,16 @@ func runApp(cmd *cobra.Command, args []string) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	storm := cfg.Logging.Storm
+	logStorm.Configure(logstorm.Options{
+		Enabled:    storm.Enabled,
+		Window:     storm.Window,
+		Burst:      storm.Burst,
+		Threshold:  storm.Threshold,
+		VerboseFor: storm.VerboseFor,
+	})
+	go logStorm.Run(ctx)
+
 	// Setup signal handling
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -130,11 +187,34 @@ func runApp(cmd *cobra.Command, args []string) {
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to initialize web server")
 	}
+	server.SetVersion(Version)
 
 	log.Info().
 		Str("address", fmt.Sprintf("http://%s:%d", cfg.Web.Host, cfg.Web.Port)).
 		Msg("Starting web interface...")
 
+	if cfg.Simulate.Enabled {
+		generator := simulate.New(simulate.Options{
+			Root:            cfg.Network.MountRoot,
+			Nodes:           cfg.Nodes,
+			Shares:          cfg.Shares,
+			NodeShares:      cfg.NodeShareMap(),
+			Project:         cfg.Simulate.Project,
+			CaptureInterval: cfg.Simulate.CaptureInterval,
+			RawFileSize:     cfg.Simulate.RawFileSize,
+			TestCaptures:    cfg.Simulate.TestCaptures,
+			Captures:        cfg.Simulate.Captures,
+		})
+		if err := generator.Prepare(); err != nil {
+			log.Fatal().Err(err).Msg("Failed to prepare simulated shares")
+		}
+		go func() {
+			if err := generator.Run(ctx); err != nil {
+				log.Error().Err(err).Msg("Simulation stopped")
+			}
+		}()
+	}
+
 	go func() {
 		if err := server.Start(ctx); err != nil {
 			log.Error().Err(err).Msg("Web server error")
@@ -152,14 +232,18 @@ func runApp(cmd *cobra.Command, args []string) {
 	cancel()
 }
 
+// logStorm collapses repeated log lines once the configuration enables it.
+var logStorm *logstorm.Writer
+
 func setupLogging() {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 
 	if debug {
 		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+		logStorm = logstorm.NewWriter(zerolog.ConsoleWriter{Out: os.Stderr})
 	} else {
 		zerolog.SetGlobalLevel(zerolog.InfoLevel)
-		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout})
+		logStorm = logstorm.NewWriter(zerolog.ConsoleWriter{Out: os.Stdout})
 	}
+	log.Logger = log.Output(logStorm)
 }
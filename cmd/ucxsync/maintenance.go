@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zangezia/UCXSync/internal/config"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+var maintenanceCmd = &cobra.Command{
+	Use:   "maintenance [node on|off]",
+	Short: "Show or change node maintenance mode on the running instance",
+	Long: `Marks a node as being serviced so its share failures and missing RAW files do
+not block capture completeness or raise alerts. Without arguments, lists the
+nodes currently in maintenance. Talks to the running ucxsync web API.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return nil
+		}
+		if len(args) != 2 || (args[1] != "on" && args[1] != "off") {
+			return fmt.Errorf("usage: ucxsync maintenance <node> on|off")
+		}
+		return nil
+	},
+	Run: runMaintenance,
+}
+
+func init() {
+	maintenanceCmd.Flags().String("reason", "", "why the node is being serviced")
+	maintenanceCmd.Flags().String("addr", "", "address of the running instance (default: 127.0.0.1:<web.port>)")
+}
+
+func runMaintenance(cmd *cobra.Command, args []string) {
+	addr, _ := cmd.Flags().GetString("addr")
+	if addr == "" {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+			os.Exit(1)
+		}
+		addr = fmt.Sprintf("127.0.0.1:%d", cfg.Web.Port)
+	}
+	url := "http://" + addr + "/api/nodes/maintenance"
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	var resp *http.Response
+	var err error
+	if len(args) == 0 {
+		resp, err = client.Get(url)
+	} else {
+		reason, _ := cmd.Flags().GetString("reason")
+		body, _ := json.Marshal(map[string]interface{}{
+			"node":        args[0],
+			"maintenance": args[1] == "on",
+			"reason":      reason,
+		})
+		resp, err = client.Post(url, "application/json", bytes.NewReader(body))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Request failed: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		fmt.Fprintf(os.Stderr, "Error: %s\n", strings.TrimSpace(string(msg)))
+		os.Exit(1)
+	}
+
+	var nodes []models.NodeMaintenance
+	if err := json.NewDecoder(resp.Body).Decode(&nodes); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(nodes) == 0 {
+		fmt.Println("No nodes in maintenance")
+		return
+	}
+	for _, node := range nodes {
+		line := fmt.Sprintf("%-6s since %s", node.Node, node.Since.Local().Format("2006-01-02 15:04:05"))
+		if node.Reason != "" {
+			line += "  " + node.Reason
+		}
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zangezia/UCXSync/internal/config"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save the runtime picture of the running instance for a trouble report",
+	Long: `Fetches /api/status/snapshot from the running ucxsync web API: status,
+metrics, mounts, alerts and the last 100 events as one JSON file to attach to
+a trouble report.`,
+	Args: cobra.NoArgs,
+	Run:  runSnapshot,
+}
+
+func init() {
+	snapshotCmd.Flags().String("addr", "", "address of the running instance (default: 127.0.0.1:<web.port>)")
+	snapshotCmd.Flags().StringP("output", "o", "", `file to write (default: ucxsync-snapshot-<time>.json, "-" for stdout)`)
+}
+
+func runSnapshot(cmd *cobra.Command, args []string) {
+	addr, _ := cmd.Flags().GetString("addr")
+	if addr == "" {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+			os.Exit(1)
+		}
+		addr = fmt.Sprintf("127.0.0.1:%d", cfg.Web.Port)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	resp, err := client.Get("http://" + addr + "/api/status/snapshot")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Request failed: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		fmt.Fprintf(os.Stderr, "Error: %s\n", strings.TrimSpace(string(msg)))
+		os.Exit(1)
+	}
+
+	output, _ := cmd.Flags().GetString("output")
+	if output == "-" {
+		io.Copy(os.Stdout, resp.Body)
+		return
+	}
+	if output == "" {
+		output = fmt.Sprintf("ucxsync-snapshot-%s.json", time.Now().Format("20060102-150405"))
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", output, err)
+		os.Exit(1)
+	}
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		file.Close()
+		fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", output, err)
+		os.Exit(1)
+	}
+	if err := file.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", output, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Snapshot saved to %s\n", output)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zangezia/UCXSync/internal/config"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Start a sync job on the running instance",
+	Long: `Starts a sync job through the running ucxsync web API, usually from one of
+the job templates of sync.templates:
+
+  ucxsync sync --template nightly-ingest
+
+Flags given alongside --template override the template's fields. With
+--list-templates, prints the configured templates instead.`,
+	Args: cobra.NoArgs,
+	Run:  runSync,
+}
+
+func init() {
+	syncCmd.Flags().String("template", "", "job template of sync.templates to start")
+	syncCmd.Flags().Bool("list-templates", false, "list the job templates of the running instance")
+	syncCmd.Flags().String("project", "", "project to sync (overrides the template)")
+	syncCmd.Flags().String("dest", "", "destination directory (overrides the template)")
+	syncCmd.Flags().Bool("force-full-resync", false, "reset the project's capture status and re-copy missing files")
+	syncCmd.Flags().String("addr", "", "address of the running instance (default: 127.0.0.1:<web.port>)")
+}
+
+func runSync(cmd *cobra.Command, args []string) {
+	addr, _ := cmd.Flags().GetString("addr")
+	if addr == "" {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+			os.Exit(1)
+		}
+		addr = fmt.Sprintf("127.0.0.1:%d", cfg.Web.Port)
+	}
+	client := &http.Client{Timeout: 2 * time.Minute}
+
+	if list, _ := cmd.Flags().GetBool("list-templates"); list {
+		listTemplates(client, "http://"+addr+"/api/sync/templates")
+		return
+	}
+
+	template, _ := cmd.Flags().GetString("template")
+	project, _ := cmd.Flags().GetString("project")
+	dest, _ := cmd.Flags().GetString("dest")
+	force, _ := cmd.Flags().GetBool("force-full-resync")
+	if template == "" && (project == "" || dest == "") {
+		fmt.Fprintln(os.Stderr, "Error: give --template, or both --project and --dest")
+		os.Exit(1)
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"template":          template,
+		"project":           project,
+		"destination":       dest,
+		"force_full_resync": force,
+	})
+	resp, err := client.Post("http://"+addr+"/api/sync/start", "application/json", bytes.NewReader(body))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Request failed: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		var startErr models.SyncStartError
+		if err := json.NewDecoder(resp.Body).Decode(&startErr); err == nil {
+			fmt.Fprintf(os.Stderr, "Start blocked: %s\n", startErr.Message)
+			for _, check := range startErr.Checks {
+				if check.Status != "ready" {
+					fmt.Fprintf(os.Stderr, "  %s: %s\n", check.Label, check.Message)
+				}
+			}
+			os.Exit(1)
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		fmt.Fprintf(os.Stderr, "Error: %s\n", strings.TrimSpace(string(msg)))
+		os.Exit(1)
+	}
+
+	var result struct {
+		Benchmark *models.DestinationBenchmark `json:"benchmark"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if template != "" {
+		fmt.Printf("Sync started from template %s\n", template)
+	} else {
+		fmt.Printf("Sync of %s started\n", project)
+	}
+	if b := result.Benchmark; b != nil {
+		line := fmt.Sprintf("Destination write speed: %.0f MB/s", b.WriteMBps)
+		if b.Slow {
+			line += fmt.Sprintf(" (slow, expected about %.0f MB/s)", b.ExpectedMBps)
+		}
+		fmt.Println(line)
+	}
+}
+
+func listTemplates(client *http.Client, url string) {
+	resp, err := client.Get(url)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Request failed: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		fmt.Fprintf(os.Stderr, "Error: %s\n", strings.TrimSpace(string(msg)))
+		os.Exit(1)
+	}
+	var templates []models.JobTemplate
+	if err := json.NewDecoder(resp.Body).Decode(&templates); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(templates) == 0 {
+		fmt.Println("No job templates configured")
+		return
+	}
+	for _, t := range templates {
+		line := fmt.Sprintf("%-20s %s -> %s", t.Name, t.Project, t.Destination)
+		if len(t.CompletionActions) > 0 {
+			line += "  then " + strings.Join(t.CompletionActions, ", ")
+		}
+		fmt.Println(line)
+	}
+}
@@ -4,23 +4,78 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/spf13/viper"
+	"github.com/zangezia/UCXSync/internal/i18n"
 )
 
 // Config holds all application configuration
 type Config struct {
-	Nodes       []string    `mapstructure:"nodes"`
-	Shares      []string    `mapstructure:"shares"`
-	Credentials Credentials `mapstructure:"credentials"`
-	Database    Database    `mapstructure:"database"`
-	Network     Network     `mapstructure:"network"`
-	Sync        Sync        `mapstructure:"sync"`
-	Web         Web         `mapstructure:"web"`
-	Monitoring  Monitoring  `mapstructure:"monitoring"`
-	Logging     Logging     `mapstructure:"logging"`
+	Nodes         []string            `mapstructure:"nodes"`
+	Shares        []string            `mapstructure:"shares"`
+	NodeShares    map[string][]string `mapstructure:"node_shares"`
+	ShareSubpaths map[string]string   `mapstructure:"share_subpaths"`
+	Credentials   Credentials         `mapstructure:"credentials"`
+	Database      Database            `mapstructure:"database"`
+	Network       Network             `mapstructure:"network"`
+	Sync          Sync                `mapstructure:"sync"`
+	Web           Web                 `mapstructure:"web"`
+	Monitoring    Monitoring          `mapstructure:"monitoring"`
+	Logging       Logging             `mapstructure:"logging"`
+	Simulate      Simulate            `mapstructure:"simulate"`
+	Exclusions    Exclusions          `mapstructure:"exclusions"`
+	Replication   Replication         `mapstructure:"replication"`
+	Storage       Storage             `mapstructure:"storage"`
+
+	configFile string
+	sources    map[string]string
+}
+
+// Storage selects the drivers used to read the shares and write the
+// destination. "local" works on mounted file systems.
+type Storage struct {
+	Source      StorageDriver `mapstructure:"source"`
+	Destination StorageDriver `mapstructure:"destination"`
+}
+
+// StorageDriver names a registered storage driver and its settings.
+type StorageDriver struct {
+	Driver string            `mapstructure:"driver"`
+	Params map[string]string `mapstructure:"params"`
+}
+
+// Exclusions overrides the folder names ignored on worker shares. An omitted
+// list keeps the built-in one.
+type Exclusions struct {
+	Directories []string `mapstructure:"directories"`
+	Projects    []string `mapstructure:"projects"`
+}
+
+// Replication lets a second UCXSync instance mirror this one's destination.
+type Replication struct {
+	Serve  ReplicationServe  `mapstructure:"serve"`
+	Mirror ReplicationMirror `mapstructure:"mirror"`
+}
+
+// ReplicationServe exposes the destination to peers holding Token.
+type ReplicationServe struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Token   string `mapstructure:"token"`
+	Root    string `mapstructure:"root"` // Empty = current sync destination
+}
+
+// ReplicationMirror pulls a peer's destination into Destination.
+type ReplicationMirror struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	PeerURL     string        `mapstructure:"peer_url"`
+	Token       string        `mapstructure:"token"`
+	Destination string        `mapstructure:"destination"`
+	Interval    time.Duration `mapstructure:"interval"`
 }
 
 // Credentials holds authentication information
@@ -42,19 +97,366 @@ type Network struct {
 
 // Sync holds synchronization settings
 type Sync struct {
-	Project               string        `mapstructure:"project"`
-	Destination           string        `mapstructure:"destination"`
-	MaxParallelism        int           `mapstructure:"max_parallelism"`
-	ServiceLoopInterval   time.Duration `mapstructure:"service_loop_interval"`
-	MinFreeDiskSpace      int64         `mapstructure:"min_free_disk_space"`
-	DiskSpaceSafetyMargin int64         `mapstructure:"disk_space_safety_margin"`
+	Project                  string           `mapstructure:"project"`
+	Destination              string           `mapstructure:"destination"`
+	MaxParallelism           int              `mapstructure:"max_parallelism"`
+	ServiceLoopInterval      time.Duration    `mapstructure:"service_loop_interval"`
+	DiscoveryInterval        time.Duration    `mapstructure:"discovery_interval"` // Background project discovery; 0 scans on every request
+	MinFreeDiskSpace         int64            `mapstructure:"min_free_disk_space"`
+	DiskSpaceSafetyMargin    int64            `mapstructure:"disk_space_safety_margin"`
+	StallTimeout             time.Duration    `mapstructure:"stall_timeout"`
+	RolloverThresholdPercent float64          `mapstructure:"rollover_threshold_percent"`
+	RolloverAutoSelect       bool             `mapstructure:"rollover_auto_select"`
+	LinkUnchanged            bool             `mapstructure:"link_unchanged"`
+	VerifyChecksums          bool             `mapstructure:"verify_checksums"` // Compare SHA-256 of source and copy after each file
+	CaptureOrder             bool             `mapstructure:"capture_order"`    // Copy files in capture number order, not listing order
+	TempDir                  string           `mapstructure:"temp_dir"`         // Where copies are written before the rename; empty is next to the file
+	EventLog                 bool             `mapstructure:"event_log"`        // Write the event stream as JSON lines into the run folder
+	JobLog                   bool             `mapstructure:"job_log"`          // Write each job's copy, verify and error events to a log of its own in the run folder
+	RunFolder                string           `mapstructure:"run_folder"`       // date: <destination>/<date>/<project>; job: <destination>/<project>/<date>_<job id>
+	Include                  []string         `mapstructure:"include"`          // File name patterns synced; empty syncs every file
+	Exclude                  []string         `mapstructure:"exclude"`          // File name patterns never synced, even when included
+	Naming                   SyncNaming       `mapstructure:"naming"`
+	Verify                   SyncVerify       `mapstructure:"verify"`
+	MaxCopyAttempts          int              `mapstructure:"max_copy_attempts"`
+	AdaptiveParallelism      SyncAdaptive     `mapstructure:"adaptive_parallelism"`
+	CircuitBreaker           SyncBreaker      `mapstructure:"circuit_breaker"`
+	IOBudget                 SyncIOBudget     `mapstructure:"io_budget"`
+	ChangeNotify             SyncNotify       `mapstructure:"change_notify"`
+	DestinationIndex         SyncDestIndex    `mapstructure:"destination_index"`
+	DeltaCopy                SyncDelta        `mapstructure:"delta_copy"`
+	Heartbeat                SyncHeartbeat    `mapstructure:"heartbeat"`
+	Resume                   SyncResume       `mapstructure:"resume"`
+	Retry                    SyncRetry        `mapstructure:"retry"`
+	Backpressure             SyncBackpressure `mapstructure:"backpressure"`
+	Stability                SyncStability    `mapstructure:"stability"`
+	Move                     SyncMove         `mapstructure:"move"`
+	SmallFiles               SyncSmallFiles   `mapstructure:"small_files"`
+	Pacing                   SyncPacing       `mapstructure:"pacing"`
+	SpotCheck                SyncSpotCheck    `mapstructure:"spot_check"`
+	Completion               SyncCompletion   `mapstructure:"completion"`
+	Memory                   SyncMemory       `mapstructure:"memory"`
+	DegradedCopy             SyncDegraded     `mapstructure:"degraded_copy"`
+	PostProcessing           PostProcessing   `mapstructure:"post_processing"`
+	Benchmark                SyncBenchmark    `mapstructure:"benchmark"`
+	Templates                []JobTemplate    `mapstructure:"templates"`
+	Quotas                   []SyncQuota      `mapstructure:"quotas"`
+}
+
+// SyncQuota caps what UCXSync may store on a destination, e.g. its share of a
+// NAS, regardless of the free space left there.
+type SyncQuota struct {
+	Destination string  `mapstructure:"destination"` // Destination path as chosen for a job
+	MaxGB       float64 `mapstructure:"max_gb"`
+}
+
+// JobTemplate is a named set of start options for a repetitive job, launched
+// with "ucxsync sync --template <name>" or from the UI. Empty fields fall back
+// to the sync settings, and a start request may still override any of them.
+type JobTemplate struct {
+	Name              string   `mapstructure:"name"`
+	Project           string   `mapstructure:"project"`
+	Destination       string   `mapstructure:"destination"`
+	MaxParallelism    int      `mapstructure:"max_parallelism"`
+	ForceFullResync   bool     `mapstructure:"force_full_resync"`
+	CompletionActions []string `mapstructure:"completion_actions"` // Unset = sync.completion.actions, [] = none
+	Benchmark         *bool    `mapstructure:"benchmark"`          // Unset = sync.benchmark.enabled
+}
+
+// Template returns the job template called name.
+func (s Sync) Template(name string) (JobTemplate, bool) {
+	for _, template := range s.Templates {
+		if template.Name == name {
+			return template, true
+		}
+	}
+	return JobTemplate{}, false
+}
+
+// SyncBenchmark is the quick write test of the destination offered when a
+// job starts.
+type SyncBenchmark struct {
+	Enabled   bool    `mapstructure:"enabled"`    // Run it unless the start request says otherwise
+	SizeMB    int     `mapstructure:"size_mb"`    // Amount of data written
+	WarnRatio float64 `mapstructure:"warn_ratio"` // Warn below this share of monitoring.max_disk_throughput_mbps
+}
+
+// PostProcessing is the pipeline run for every completed capture.
+type PostProcessing struct {
+	Workers   int               `mapstructure:"workers"`
+	QueueSize int               `mapstructure:"queue_size"`
+	Steps     []PostProcessStep `mapstructure:"steps"`
+}
+
+// PostProcessStep runs Command or posts the capture to URL.
+type PostProcessStep struct {
+	Name    string        `mapstructure:"name"`
+	Command []string      `mapstructure:"command"`
+	URL     string        `mapstructure:"url"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// SyncDegraded lets files with unreadable source sectors be copied with the
+// gaps zero-filled and recorded in a .badblocks.json report.
+type SyncDegraded struct {
+	Enabled   bool `mapstructure:"enabled"`
+	BlockSize int  `mapstructure:"block_size"`
+}
+
+// SyncMemory bounds the in-memory bookkeeping of long continuous runs.
+type SyncMemory struct {
+	CompactInterval    time.Duration `mapstructure:"compact_interval"`
+	CaptureMaxAge      time.Duration `mapstructure:"capture_max_age"`
+	MaxTrackedCaptures int           `mapstructure:"max_tracked_captures"`
+	MaxRunCopies       int           `mapstructure:"max_run_copies"`
+}
+
+// Completion actions, listed in the order they run after a sync run finishes.
+const (
+	CompletionVerify   = "verify"
+	CompletionManifest = "manifest"
+	CompletionNotify   = "notify"
+	CompletionEject    = "eject"
+	CompletionPowerOff = "poweroff"
+)
+
+var completionActionOrder = []string{
+	CompletionVerify,
+	CompletionManifest,
+	CompletionNotify,
+	CompletionEject,
+	CompletionPowerOff,
+}
+
+// SyncCompletion selects what happens unattended once a sync run finishes.
+type SyncCompletion struct {
+	Actions    []string      `mapstructure:"actions"`
+	NotifyURL  string        `mapstructure:"notify_url"`
+	IdleFinish time.Duration `mapstructure:"idle_finish"`
+}
+
+// NormalizeCompletionActions validates completion action names, drops
+// duplicates and returns them in execution order.
+func NormalizeCompletionActions(actions []string) ([]string, error) {
+	requested := make(map[string]bool, len(actions))
+	for _, action := range actions {
+		action = strings.ToLower(strings.TrimSpace(action))
+		known := false
+		for _, candidate := range completionActionOrder {
+			if action == candidate {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown completion action %q (use %s)", action, strings.Join(completionActionOrder, ", "))
+		}
+		requested[action] = true
+	}
+
+	normalized := make([]string, 0, len(requested))
+	for _, action := range completionActionOrder {
+		if requested[action] {
+			normalized = append(normalized, action)
+		}
+	}
+	return normalized, nil
+}
+
+// SyncSpotCheck configures periodic re-comparison of copied files with the source.
+type SyncSpotCheck struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	Interval    time.Duration `mapstructure:"interval"`
+	SampleBytes int64         `mapstructure:"sample_bytes"`
+	FullHash    bool          `mapstructure:"full_hash"`
+}
+
+// SyncAdaptive bounds the per-node copy concurrency tuned from observed throughput.
+type SyncAdaptive struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Min      int           `mapstructure:"min"`
+	Max      int           `mapstructure:"max"`
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// SyncBreaker stops dispatching copies to a node whose copy failure rate
+// over Window exceeds FailureRate, for OpenFor.
+type SyncBreaker struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	Window      time.Duration `mapstructure:"window"`
+	MinAttempts int           `mapstructure:"min_attempts"` // Copies in the window before the rate counts
+	FailureRate float64       `mapstructure:"failure_rate"`
+	OpenFor     time.Duration `mapstructure:"open_for"`
+}
+
+// SyncIOBudget caps the combined read rate of sync, export and verify jobs
+// running at the same time and splits it by weight among the busy ones.
+type SyncIOBudget struct {
+	MaxMBps      float64 `mapstructure:"max_mbps"` // 0 = unlimited
+	SyncWeight   float64 `mapstructure:"sync_weight"`
+	ExportWeight float64 `mapstructure:"export_weight"`
+	VerifyWeight float64 `mapstructure:"verify_weight"`
+}
+
+// SyncNotify lists only the source folders reported changed by directory
+// change notifications, with a full rescan every FullRescanInterval. With
+// Immediate a change starts a scan once changes have been quiet for Debounce.
+type SyncNotify struct {
+	Enabled            bool          `mapstructure:"enabled"`
+	FullRescanInterval time.Duration `mapstructure:"full_rescan_interval"`
+	Immediate          bool          `mapstructure:"immediate"`
+	Debounce           time.Duration `mapstructure:"debounce"`
+}
+
+// SyncNaming holds the file name patterns capture files are recognised by,
+// for camera firmware that names them differently. Empty patterns keep the
+// built-in UCX naming.
+type SyncNaming struct {
+	Raw           string `mapstructure:"raw"`            // Named groups capture and sensor required; level, test, project, session optional
+	Metadata      string `mapstructure:"metadata"`       // Named group capture required
+	Quality       string `mapstructure:"quality"`        // Named group capture required
+	VerifiedLevel string `mapstructure:"verified_level"` // level of a verified RAW file
+}
+
+// SyncVerify tunes the verify pass over a run's copies. SamplePercent below
+// 100 hashes only that share of the files, picked at random, plus every XML
+// metadata file.
+type SyncVerify struct {
+	SamplePercent float64 `mapstructure:"sample_percent"`
+}
+
+// SyncDestIndex keeps the copies found up to date in memory so scans skip
+// their destination stat, forgetting them every RefreshInterval.
+type SyncDestIndex struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+}
+
+// SyncDelta patches the earlier destination copy of a changed file in place,
+// writing only the blocks that differ.
+type SyncDelta struct {
+	Enabled   bool  `mapstructure:"enabled"`
+	MinSize   int64 `mapstructure:"min_size"`   // Smaller files are copied whole
+	BlockSize int   `mapstructure:"block_size"` // Bytes per compared block
+}
+
+// SyncHeartbeat writes a small status file into the run folder on the
+// destination disk every Interval.
+type SyncHeartbeat struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// SyncPacing sets a target completion time for every job, Target after it
+// starts, and warns when the throughput so far will not make it.
+type SyncPacing struct {
+	Target time.Duration `mapstructure:"target"` // 0 disables pacing
+	Grace  time.Duration `mapstructure:"grace"`  // Run time before the projection is judged
+}
+
+// SyncResume continues copies of large files cut short by a dropped share
+// or a restart from where they stopped.
+type SyncResume struct {
+	Enabled     bool  `mapstructure:"enabled"`
+	MinSize     int64 `mapstructure:"min_size"`     // Smaller files are copied again from the start
+	VerifyBytes int64 `mapstructure:"verify_bytes"` // Bytes before the resume point compared with the source
+}
+
+// SyncRetry spaces the retries of a failed file: initial_backoff after the
+// first failure, doubling after each further one up to max_backoff.
+type SyncRetry struct {
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+}
+
+// SyncStability leaves files the nodes may still be writing for a later
+// scan: a file is copied once its size and mtime are unchanged since the
+// previous scan, or once its mtime is min_age old.
+type SyncStability struct {
+	Enabled bool          `mapstructure:"enabled"`
+	MinAge  time.Duration `mapstructure:"min_age"` // 0 waits for an unchanged second scan only
+}
+
+// SyncMove frees node disk: once a capture is complete, each source file
+// this run copied is read back against its copy and then deleted, or renamed
+// into folder on the share root, and the outcome appended to
+// ucxsync-moves.jsonl in the run folder.
+type SyncMove struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Mode    string `mapstructure:"mode"`   // delete or rename
+	Folder  string `mapstructure:"folder"` // Hidden folder name rename moves into
+}
+
+// SyncBackpressure holds back scans while high_water or more files found
+// by earlier scans wait to be copied, until the queue drains to low_water or
+// max_delay passes.
+type SyncBackpressure struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	HighWater int           `mapstructure:"high_water"`
+	LowWater  int           `mapstructure:"low_water"`
+	MaxDelay  time.Duration `mapstructure:"max_delay"` // 0 holds scans until the queue drains
+}
+
+// SyncSmallFiles copies files up to max_size bytes (logs, quality files)
+// through workers slots of their own, next to max_parallelism, so they are
+// not queued behind multi-GB raws.
+type SyncSmallFiles struct {
+	Enabled bool  `mapstructure:"enabled"`
+	MaxSize int64 `mapstructure:"max_size"`
+	Workers int   `mapstructure:"workers"`
 }
 
 // Web holds web server settings
 type Web struct {
 	Host      string       `mapstructure:"host"`
 	Port      int          `mapstructure:"port"`
+	Language  string       `mapstructure:"language"` // Event and log messages: ru or en
 	Dashboard WebDashboard `mapstructure:"dashboard"`
+	Alerts    WebAlerts    `mapstructure:"alerts"`
+	Files     WebFiles     `mapstructure:"files"`
+	Guard     WebGuard     `mapstructure:"guard"`
+	Public    WebPublic    `mapstructure:"public"`
+}
+
+// WebPublic serves a read-only status page with the headline numbers on a
+// listener of its own, for wall monitors that must not reach the operator
+// controls.
+type WebPublic struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Host    string        `mapstructure:"host"`
+	Port    int           `mapstructure:"port"`
+	Refresh time.Duration `mapstructure:"refresh"` // How often the page reloads itself
+}
+
+// WebGuard protects the destructive endpoints (device mount and unmount,
+// shutdown, restart, deleting project history) from double-clicks and
+// scripts repeating them.
+type WebGuard struct {
+	RateLimit     int           `mapstructure:"rate_limit"` // Requests per minute per endpoint and client, 0 for no limit
+	Burst         int           `mapstructure:"burst"`
+	ConfirmTokens bool          `mapstructure:"confirm_tokens"` // Require a single-use token from /api/confirm
+	TokenTTL      time.Duration `mapstructure:"token_ttl"`
+}
+
+// WebFiles serves the synced files under /files/{project}/... to QC tools
+// holding Token, with Range support for previews.
+type WebFiles struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Token   string `mapstructure:"token"`
+}
+
+// WebAlerts controls the notification hints attached to WebSocket events.
+type WebAlerts struct {
+	BrowserNotifications bool                 `mapstructure:"browser_notifications"`
+	Sound                bool                 `mapstructure:"sound"`
+	Events               map[string]AlertRule `mapstructure:"events"`
+}
+
+// AlertRule overrides the built-in alert policy for one event type.
+type AlertRule struct {
+	Severity string `mapstructure:"severity"`
+	Notify   *bool  `mapstructure:"notify"`
+	Sound    *bool  `mapstructure:"sound"`
 }
 
 // WebDashboard holds optional multi-instance dashboard settings.
@@ -76,6 +478,27 @@ type Monitoring struct {
 	CPUSmoothingSamples       int           `mapstructure:"cpu_smoothing_samples"`
 	MaxDiskThroughputMBps     float64       `mapstructure:"max_disk_throughput_mbps"`
 	NetworkSpeedBps           int64         `mapstructure:"network_speed_bps"`
+	SourceSpace               SourceSpace   `mapstructure:"source_space"`
+	IdlePower                 IdlePower     `mapstructure:"idle_power"`
+}
+
+// IdlePower saves power on battery-run field kits: once no file has been
+// copied, no job has run and no UI has connected for IdleAfter, metrics are
+// collected every MonitorInterval and, with SpinDown, the destination disk is
+// put into standby.
+type IdlePower struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	IdleAfter       time.Duration `mapstructure:"idle_after"`
+	SpinDown        bool          `mapstructure:"spin_down"`
+	MonitorInterval time.Duration `mapstructure:"monitor_interval"`
+}
+
+// SourceSpace sets when a filling worker disk raises an alert. A share is low
+// once its free space drops below either threshold; zero disables one.
+type SourceSpace struct {
+	WarningFreePercent float64       `mapstructure:"warning_free_percent"`
+	WarningFreeGB      float64       `mapstructure:"warning_free_gb"`
+	CheckInterval      time.Duration `mapstructure:"check_interval"`
 }
 
 // Logging holds logging settings
@@ -85,6 +508,29 @@ type Logging struct {
 	MaxSize    int    `mapstructure:"max_size"`
 	MaxBackups int    `mapstructure:"max_backups"`
 	MaxAge     int    `mapstructure:"max_age"`
+
+	Storm LoggingStorm `mapstructure:"storm"`
+}
+
+// LoggingStorm collapses repeated log messages and turns on debug logging
+// for a while when errors spike.
+type LoggingStorm struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	Window     time.Duration `mapstructure:"window"`      // Period repeats are counted over
+	Burst      int           `mapstructure:"burst"`       // Identical messages shown per window
+	Threshold  int           `mapstructure:"threshold"`   // Errors per window that raise verbosity, 0 never
+	VerboseFor time.Duration `mapstructure:"verbose_for"` // How long debug logging stays on
+}
+
+// Simulate holds settings for the hardware-free demo mode (--simulate).
+type Simulate struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	Root            string        `mapstructure:"root"` // Empty = new temp directory
+	Project         string        `mapstructure:"project"`
+	CaptureInterval time.Duration `mapstructure:"capture_interval"`
+	RawFileSize     int64         `mapstructure:"raw_file_size"`
+	TestCaptures    int           `mapstructure:"test_captures"`
+	Captures        int           `mapstructure:"captures"` // 0 = unlimited
 }
 
 // Load reads configuration from file or uses defaults
@@ -122,6 +568,8 @@ func Load(cfgFile string) (*Config, error) {
 		return nil, fmt.Errorf("unable to decode config: %w", err)
 	}
 
+	cfg.recordSources(v)
+
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
@@ -154,13 +602,108 @@ func setDefaults(v *viper.Viper) {
 	// Sync defaults
 	v.SetDefault("sync.max_parallelism", 8)
 	v.SetDefault("sync.service_loop_interval", "10s")
+	v.SetDefault("sync.discovery_interval", "2m")
 	v.SetDefault("sync.min_free_disk_space", 52428800)       // 50 MB
 	v.SetDefault("sync.disk_space_safety_margin", 104857600) // 100 MB
+	v.SetDefault("sync.stall_timeout", "10m")
+	v.SetDefault("sync.rollover_threshold_percent", 0) // Disabled
+	v.SetDefault("sync.rollover_auto_select", false)
+	v.SetDefault("sync.link_unchanged", true)
+	v.SetDefault("sync.verify_checksums", false)
+	v.SetDefault("sync.capture_order", true)
+	v.SetDefault("sync.temp_dir", "")
+	v.SetDefault("sync.event_log", true)
+	v.SetDefault("sync.job_log", true)
+	v.SetDefault("sync.run_folder", "date")
+	v.SetDefault("sync.naming.raw", "")
+	v.SetDefault("sync.naming.metadata", "")
+	v.SetDefault("sync.naming.quality", "")
+	v.SetDefault("sync.naming.verified_level", "")
+	v.SetDefault("sync.verify.sample_percent", 100)
+	v.SetDefault("sync.max_copy_attempts", 3)
+	v.SetDefault("sync.adaptive_parallelism.enabled", false)
+	v.SetDefault("sync.adaptive_parallelism.min", 1)
+	v.SetDefault("sync.adaptive_parallelism.max", 4)
+	v.SetDefault("sync.adaptive_parallelism.interval", "10s")
+	v.SetDefault("sync.circuit_breaker.enabled", true)
+	v.SetDefault("sync.circuit_breaker.window", "5m")
+	v.SetDefault("sync.circuit_breaker.min_attempts", 10)
+	v.SetDefault("sync.circuit_breaker.failure_rate", 0.5)
+	v.SetDefault("sync.circuit_breaker.open_for", "2m")
+	v.SetDefault("sync.io_budget.max_mbps", 0)
+	v.SetDefault("sync.io_budget.sync_weight", 3)
+	v.SetDefault("sync.io_budget.export_weight", 1)
+	v.SetDefault("sync.io_budget.verify_weight", 1)
+	v.SetDefault("sync.change_notify.enabled", false)
+	v.SetDefault("sync.change_notify.full_rescan_interval", "5m")
+	v.SetDefault("sync.change_notify.immediate", true)
+	v.SetDefault("sync.change_notify.debounce", "2s")
+	v.SetDefault("sync.destination_index.enabled", true)
+	v.SetDefault("sync.destination_index.refresh_interval", "10m")
+	v.SetDefault("sync.heartbeat.enabled", true)
+	v.SetDefault("sync.heartbeat.interval", "1m")
+	v.SetDefault("sync.delta_copy.enabled", false)
+	v.SetDefault("sync.delta_copy.min_size", 16<<20)
+	v.SetDefault("sync.delta_copy.block_size", 64<<10)
+	v.SetDefault("sync.pacing.target", "0s")
+	v.SetDefault("sync.pacing.grace", "10m")
+	v.SetDefault("sync.resume.enabled", true)
+	v.SetDefault("sync.resume.min_size", 64<<20)
+	v.SetDefault("sync.resume.verify_bytes", 1<<20)
+	v.SetDefault("sync.retry.initial_backoff", "5s")
+	v.SetDefault("sync.retry.max_backoff", "5m")
+	v.SetDefault("sync.backpressure.enabled", true)
+	v.SetDefault("sync.backpressure.high_water", 200)
+	v.SetDefault("sync.backpressure.low_water", 50)
+	v.SetDefault("sync.backpressure.max_delay", "2m")
+	v.SetDefault("sync.stability.enabled", true)
+	v.SetDefault("sync.stability.min_age", "1m")
+	v.SetDefault("sync.move.enabled", false)
+	v.SetDefault("sync.move.mode", "delete")
+	v.SetDefault("sync.move.folder", ".ucxsync-synced")
+	v.SetDefault("sync.small_files.enabled", true)
+	v.SetDefault("sync.small_files.max_size", 1<<20)
+	v.SetDefault("sync.small_files.workers", 2)
+	v.SetDefault("sync.spot_check.enabled", true)
+	v.SetDefault("sync.spot_check.interval", "1m")
+	v.SetDefault("sync.spot_check.sample_bytes", 1048576) // 1 MB
+	v.SetDefault("sync.spot_check.full_hash", false)
+	v.SetDefault("sync.completion.actions", []string{})
+	v.SetDefault("sync.completion.notify_url", "")
+	v.SetDefault("sync.completion.idle_finish", "0s") // Run until stopped
+	v.SetDefault("sync.memory.compact_interval", "10m")
+	v.SetDefault("sync.memory.capture_max_age", "6h")
+	v.SetDefault("sync.memory.max_tracked_captures", 10000)
+	v.SetDefault("sync.memory.max_run_copies", 200000)
+	v.SetDefault("sync.degraded_copy.enabled", false)
+	v.SetDefault("sync.degraded_copy.block_size", 1048576)
+	v.SetDefault("sync.benchmark.enabled", false)
+	v.SetDefault("sync.benchmark.size_mb", 256)
+	v.SetDefault("sync.benchmark.warn_ratio", 0.5)
+	v.SetDefault("sync.templates", []map[string]any{})
+	v.SetDefault("sync.quotas", []map[string]any{})
+	v.SetDefault("storage.source.driver", "local")
+	v.SetDefault("storage.destination.driver", "local")
+	v.SetDefault("sync.post_processing.workers", 2)
+	v.SetDefault("sync.post_processing.queue_size", 1000)
 
 	// Web defaults
 	v.SetDefault("web.host", "localhost")
 	v.SetDefault("web.port", 8080)
+	v.SetDefault("web.language", i18n.Default)
 	v.SetDefault("web.dashboard.instances", []map[string]any{})
+	v.SetDefault("web.alerts.browser_notifications", true)
+	v.SetDefault("web.alerts.sound", true)
+	v.SetDefault("web.files.enabled", false)
+	v.SetDefault("web.files.token", "")
+	v.SetDefault("web.guard.rate_limit", 20)
+	v.SetDefault("web.guard.burst", 1)
+	v.SetDefault("web.guard.confirm_tokens", false)
+	v.SetDefault("web.guard.token_ttl", "1m")
+	v.SetDefault("web.public.enabled", false)
+	v.SetDefault("web.public.host", "0.0.0.0")
+	v.SetDefault("web.public.port", 8090)
+	v.SetDefault("web.public.refresh", "15s")
 
 	// Monitoring defaults
 	v.SetDefault("monitoring.performance_update_interval", "1s")
@@ -168,6 +711,13 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("monitoring.cpu_smoothing_samples", 3)
 	v.SetDefault("monitoring.max_disk_throughput_mbps", 200.0)
 	v.SetDefault("monitoring.network_speed_bps", 1000000000) // 1 Gbps
+	v.SetDefault("monitoring.source_space.warning_free_percent", 15.0)
+	v.SetDefault("monitoring.source_space.warning_free_gb", 0.0)
+	v.SetDefault("monitoring.source_space.check_interval", "1m")
+	v.SetDefault("monitoring.idle_power.enabled", false)
+	v.SetDefault("monitoring.idle_power.idle_after", "15m")
+	v.SetDefault("monitoring.idle_power.spin_down", true)
+	v.SetDefault("monitoring.idle_power.monitor_interval", "30s")
 
 	// Logging defaults
 	v.SetDefault("logging.level", "info")
@@ -175,6 +725,30 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("logging.max_size", 100)
 	v.SetDefault("logging.max_backups", 5)
 	v.SetDefault("logging.max_age", 30)
+	v.SetDefault("logging.storm.enabled", true)
+	v.SetDefault("logging.storm.window", "10s")
+	v.SetDefault("logging.storm.burst", 5)
+	v.SetDefault("logging.storm.threshold", 50)
+	v.SetDefault("logging.storm.verbose_for", "1m")
+
+	// Simulation defaults
+	v.SetDefault("simulate.enabled", false)
+	v.SetDefault("simulate.root", "")
+	v.SetDefault("simulate.project", "Sim_demo_project")
+	v.SetDefault("simulate.capture_interval", "5s")
+	v.SetDefault("simulate.raw_file_size", 4194304) // 4 MB
+	v.SetDefault("simulate.test_captures", 2)
+	v.SetDefault("simulate.captures", 0)
+
+	// Replication defaults
+	v.SetDefault("replication.serve.enabled", false)
+	v.SetDefault("replication.serve.token", "")
+	v.SetDefault("replication.serve.root", "")
+	v.SetDefault("replication.mirror.enabled", false)
+	v.SetDefault("replication.mirror.peer_url", "")
+	v.SetDefault("replication.mirror.token", "")
+	v.SetDefault("replication.mirror.destination", "")
+	v.SetDefault("replication.mirror.interval", "1m")
 }
 
 // Validate checks if the configuration is valid
@@ -183,8 +757,30 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("no nodes configured")
 	}
 
-	if len(c.Shares) == 0 {
-		return fmt.Errorf("no shares configured")
+	for pattern, shares := range c.NodeShares {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("node_shares: invalid node pattern %q: %w", pattern, err)
+		}
+		if len(shares) == 0 {
+			return fmt.Errorf("node_shares: %q lists no shares", pattern)
+		}
+		if !c.matchesAnyNode(pattern) {
+			return fmt.Errorf("node_shares: %q matches no configured node", pattern)
+		}
+	}
+	for _, node := range c.Nodes {
+		if _, err := c.nodeSharePattern(node); err != nil {
+			return err
+		}
+		if len(c.SharesFor(node)) == 0 {
+			return fmt.Errorf("no shares configured for node %s", node)
+		}
+	}
+
+	for share := range c.ShareSubpaths {
+		if !c.hasShare(share) {
+			return fmt.Errorf("share_subpaths: %q is not a configured share", share)
+		}
 	}
 
 	c.Network.MountRoot = path.Clean(strings.TrimSpace(c.Network.MountRoot))
@@ -219,9 +815,310 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("max_parallelism must be at least 1")
 	}
 
+	for _, patterns := range []struct {
+		key  string
+		list []string
+	}{{"sync.include", c.Sync.Include}, {"sync.exclude", c.Sync.Exclude}} {
+		for _, pattern := range patterns.list {
+			if strings.ContainsAny(pattern, `/\`) {
+				return fmt.Errorf("%s pattern %q must match file names, not paths", patterns.key, pattern)
+			}
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%s pattern %q is invalid: %w", patterns.key, pattern, err)
+			}
+		}
+	}
+
+	if c.Sync.MaxCopyAttempts < 1 {
+		return fmt.Errorf("sync.max_copy_attempts must be at least 1")
+	}
+
+	if adaptive := c.Sync.AdaptiveParallelism; adaptive.Enabled {
+		if adaptive.Min < 1 || adaptive.Max < adaptive.Min {
+			return fmt.Errorf("sync.adaptive_parallelism requires 1 <= min <= max")
+		}
+		if adaptive.Interval < time.Second {
+			return fmt.Errorf("sync.adaptive_parallelism.interval must be at least 1s")
+		}
+	}
+
+	if breaker := c.Sync.CircuitBreaker; breaker.Enabled {
+		if breaker.Window < time.Second || breaker.OpenFor < time.Second || breaker.MinAttempts < 1 {
+			return fmt.Errorf("sync.circuit_breaker requires window and open_for >= 1s and min_attempts >= 1")
+		}
+		if breaker.FailureRate <= 0 || breaker.FailureRate > 1 {
+			return fmt.Errorf("sync.circuit_breaker.failure_rate must be in (0, 1]")
+		}
+	}
+
+	if budget := c.Sync.IOBudget; budget.MaxMBps < 0 {
+		return fmt.Errorf("sync.io_budget.max_mbps must be >= 0")
+	} else if budget.SyncWeight <= 0 || budget.ExportWeight <= 0 || budget.VerifyWeight <= 0 {
+		return fmt.Errorf("sync.io_budget weights must be > 0")
+	}
+
+	if notify := c.Sync.ChangeNotify; notify.Enabled && notify.FullRescanInterval < time.Second {
+		return fmt.Errorf("sync.change_notify.full_rescan_interval must be >= 1s")
+	} else if notify.Debounce < 0 {
+		return fmt.Errorf("sync.change_notify.debounce must be >= 0")
+	}
+
+	if index := c.Sync.DestinationIndex; index.Enabled && index.RefreshInterval < time.Second {
+		return fmt.Errorf("sync.destination_index.refresh_interval must be >= 1s")
+	}
+
+	if heartbeat := c.Sync.Heartbeat; heartbeat.Enabled && heartbeat.Interval < 5*time.Second {
+		return fmt.Errorf("sync.heartbeat.interval must be >= 5s")
+	}
+
+	if storm := c.Logging.Storm; storm.Enabled {
+		if storm.Window < time.Second {
+			return fmt.Errorf("logging.storm.window must be >= 1s")
+		}
+		if storm.Burst < 1 {
+			return fmt.Errorf("logging.storm.burst must be >= 1")
+		}
+		if storm.Threshold < 0 {
+			return fmt.Errorf("logging.storm.threshold must be >= 0")
+		}
+		if storm.Threshold > 0 && storm.VerboseFor < time.Second {
+			return fmt.Errorf("logging.storm.verbose_for must be >= 1s")
+		}
+	}
+
+	if pacing := c.Sync.Pacing; pacing.Target < 0 || pacing.Grace < 0 {
+		return fmt.Errorf("sync.pacing.target and grace must not be negative")
+	}
+
+	if dir := c.Sync.TempDir; dir != "" && !filepath.IsAbs(dir) && !filepath.IsLocal(dir) {
+		return fmt.Errorf("sync.temp_dir must be absolute or a folder inside the destination")
+	}
+
+	if resume := c.Sync.Resume; resume.MinSize < 0 || resume.VerifyBytes < 0 {
+		return fmt.Errorf("sync.resume.min_size and verify_bytes must be >= 0")
+	}
+
+	if retry := c.Sync.Retry; retry.InitialBackoff <= 0 {
+		return fmt.Errorf("sync.retry.initial_backoff must be positive")
+	} else if retry.MaxBackoff < retry.InitialBackoff {
+		return fmt.Errorf("sync.retry.max_backoff must be at least initial_backoff")
+	}
+
+	if c.Sync.Stability.MinAge < 0 {
+		return fmt.Errorf("sync.stability.min_age must not be negative")
+	}
+
+	if c.Sync.RunFolder != "date" && c.Sync.RunFolder != "job" {
+		return fmt.Errorf("sync.run_folder must be date or job")
+	}
+
+	if percent := c.Sync.Verify.SamplePercent; percent <= 0 || percent > 100 {
+		return fmt.Errorf("sync.verify.sample_percent must be in (0, 100]")
+	}
+
+	if err := validateNamePattern("sync.naming.raw", c.Sync.Naming.Raw, "capture", "sensor"); err != nil {
+		return err
+	}
+	if err := validateNamePattern("sync.naming.metadata", c.Sync.Naming.Metadata, "capture"); err != nil {
+		return err
+	}
+	if err := validateNamePattern("sync.naming.quality", c.Sync.Naming.Quality, "capture"); err != nil {
+		return err
+	}
+
+	if move := c.Sync.Move; move.Enabled {
+		switch move.Mode {
+		case "delete":
+		case "rename":
+			// A hidden folder is never taken for a project by the scans.
+			if !strings.HasPrefix(move.Folder, ".") || move.Folder != filepath.Base(move.Folder) || move.Folder == "." || move.Folder == ".." {
+				return fmt.Errorf("sync.move.folder must be a hidden folder name such as .ucxsync-synced")
+			}
+		default:
+			return fmt.Errorf("sync.move.mode must be delete or rename")
+		}
+	}
+
+	if bp := c.Sync.Backpressure; bp.Enabled {
+		if bp.HighWater < 1 || bp.LowWater < 0 || bp.LowWater >= bp.HighWater {
+			return fmt.Errorf("sync.backpressure needs 0 <= low_water < high_water")
+		}
+		if bp.MaxDelay < 0 {
+			return fmt.Errorf("sync.backpressure.max_delay must not be negative")
+		}
+	}
+
+	if small := c.Sync.SmallFiles; small.Enabled && (small.MaxSize <= 0 || small.Workers < 1) {
+		return fmt.Errorf("sync.small_files needs a positive max_size and at least 1 worker")
+	}
+
+	if delta := c.Sync.DeltaCopy; delta.MinSize < 0 {
+		return fmt.Errorf("sync.delta_copy.min_size must be >= 0")
+	} else if delta.BlockSize < 4096 || delta.BlockSize > 16<<20 {
+		return fmt.Errorf("sync.delta_copy.block_size must be between 4096 and 16777216")
+	}
+
+	if spot := c.Sync.SpotCheck; spot.Enabled && (spot.Interval < time.Second || spot.SampleBytes < 1) {
+		return fmt.Errorf("sync.spot_check requires interval >= 1s and sample_bytes >= 1")
+	}
+
+	actions, err := NormalizeCompletionActions(c.Sync.Completion.Actions)
+	if err != nil {
+		return fmt.Errorf("sync.completion.actions: %w", err)
+	}
+	c.Sync.Completion.Actions = actions
+	c.Sync.Completion.NotifyURL = strings.TrimSpace(c.Sync.Completion.NotifyURL)
+	if url := c.Sync.Completion.NotifyURL; url != "" && !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return fmt.Errorf("sync.completion.notify_url must start with http:// or https://: %s", url)
+	}
+	if c.Sync.Completion.NotifyURL == "" && containsString(actions, CompletionNotify) {
+		return fmt.Errorf("sync.completion.actions: notify requires sync.completion.notify_url")
+	}
+	if c.Sync.DiscoveryInterval < 0 {
+		return fmt.Errorf("sync.discovery_interval must not be negative")
+	}
+	if c.Sync.Completion.IdleFinish < 0 {
+		return fmt.Errorf("sync.completion.idle_finish must not be negative")
+	}
+
+	if memory := c.Sync.Memory; memory.CompactInterval < time.Second || memory.CaptureMaxAge < time.Minute ||
+		memory.MaxTrackedCaptures < 1 || memory.MaxRunCopies < 1 {
+		return fmt.Errorf("sync.memory requires compact_interval >= 1s, capture_max_age >= 1m and positive limits")
+	}
+	if size := c.Sync.DegradedCopy.BlockSize; size < 4096 || size%4096 != 0 {
+		return fmt.Errorf("sync.degraded_copy.block_size must be a positive multiple of 4096, got %d", size)
+	}
+	if err := c.Sync.PostProcessing.validate(); err != nil {
+		return err
+	}
+	if benchmark := c.Sync.Benchmark; benchmark.SizeMB < 1 || benchmark.WarnRatio <= 0 || benchmark.WarnRatio > 1 {
+		return fmt.Errorf("sync.benchmark requires size_mb >= 1 and warn_ratio in (0, 1]")
+	}
+	seenTemplates := make(map[string]struct{}, len(c.Sync.Templates))
+	for i := range c.Sync.Templates {
+		template := &c.Sync.Templates[i]
+		template.Name = strings.TrimSpace(template.Name)
+		if template.Name == "" {
+			return fmt.Errorf("sync.templates[%d].name must not be empty", i)
+		}
+		if _, exists := seenTemplates[template.Name]; exists {
+			return fmt.Errorf("sync.templates[%d].name duplicates %q", i, template.Name)
+		}
+		seenTemplates[template.Name] = struct{}{}
+		if template.MaxParallelism < 0 {
+			return fmt.Errorf("sync.templates[%d].max_parallelism must not be negative", i)
+		}
+		if template.CompletionActions != nil {
+			actions, err := NormalizeCompletionActions(template.CompletionActions)
+			if err != nil {
+				return fmt.Errorf("sync.templates[%d].completion_actions: %w", i, err)
+			}
+			if c.Sync.Completion.NotifyURL == "" && containsString(actions, CompletionNotify) {
+				return fmt.Errorf("sync.templates[%d].completion_actions: notify requires sync.completion.notify_url", i)
+			}
+			template.CompletionActions = actions
+		}
+	}
+	seenQuotas := make(map[string]struct{}, len(c.Sync.Quotas))
+	for i := range c.Sync.Quotas {
+		quota := &c.Sync.Quotas[i]
+		quota.Destination = strings.TrimSpace(quota.Destination)
+		if quota.Destination == "" {
+			return fmt.Errorf("sync.quotas[%d].destination must not be empty", i)
+		}
+		quota.Destination = path.Clean(quota.Destination)
+		if _, exists := seenQuotas[quota.Destination]; exists {
+			return fmt.Errorf("sync.quotas[%d].destination duplicates %q", i, quota.Destination)
+		}
+		seenQuotas[quota.Destination] = struct{}{}
+		if quota.MaxGB <= 0 {
+			return fmt.Errorf("sync.quotas[%d].max_gb must be positive", i)
+		}
+	}
+	c.Storage.Source.Driver = strings.ToLower(strings.TrimSpace(c.Storage.Source.Driver))
+	c.Storage.Destination.Driver = strings.ToLower(strings.TrimSpace(c.Storage.Destination.Driver))
+	if c.Storage.Source.Driver == "" || c.Storage.Destination.Driver == "" {
+		return fmt.Errorf("storage.source.driver and storage.destination.driver must not be empty")
+	}
+
+	if c.Sync.RolloverThresholdPercent < 0 || c.Sync.RolloverThresholdPercent > 100 {
+		return fmt.Errorf("sync.rollover_threshold_percent must be between 0 and 100")
+	}
+
+	if serve := c.Replication.Serve; serve.Enabled && len(serve.Token) < minReplicationTokenLength {
+		return fmt.Errorf("replication.serve.token must be at least %d characters", minReplicationTokenLength)
+	}
+	if mirror := c.Replication.Mirror; mirror.Enabled {
+		if !strings.HasPrefix(mirror.PeerURL, "http://") && !strings.HasPrefix(mirror.PeerURL, "https://") {
+			return fmt.Errorf("replication.mirror.peer_url must start with http:// or https://: %s", mirror.PeerURL)
+		}
+		if mirror.Token == "" || mirror.Destination == "" {
+			return fmt.Errorf("replication.mirror requires token and destination")
+		}
+		if mirror.Interval < time.Second {
+			return fmt.Errorf("replication.mirror.interval must be at least 1s")
+		}
+		if c.Sync.Destination != "" && filepath.Clean(mirror.Destination) == filepath.Clean(c.Sync.Destination) {
+			return fmt.Errorf("replication.mirror.destination must not be the sync destination")
+		}
+	}
+
+	if space := c.Monitoring.SourceSpace; space.WarningFreePercent < 0 || space.WarningFreePercent >= 100 || space.WarningFreeGB < 0 {
+		return fmt.Errorf("monitoring.source_space requires warning_free_percent in [0, 100) and a non-negative warning_free_gb")
+	}
+	if c.Monitoring.SourceSpace.CheckInterval < time.Second {
+		return fmt.Errorf("monitoring.source_space.check_interval must be at least 1s")
+	}
+	if power := c.Monitoring.IdlePower; power.Enabled {
+		if power.IdleAfter < time.Minute {
+			return fmt.Errorf("monitoring.idle_power.idle_after must be at least 1m")
+		}
+		if power.MonitorInterval < c.Monitoring.PerformanceUpdateInterval {
+			return fmt.Errorf("monitoring.idle_power.monitor_interval must not be shorter than monitoring.performance_update_interval")
+		}
+	}
+
+	c.Web.Language = strings.ToLower(strings.TrimSpace(c.Web.Language))
+	if !i18n.Supported(c.Web.Language) {
+		return fmt.Errorf("web.language must be %s or %s: %s", i18n.Russian, i18n.English, c.Web.Language)
+	}
+
 	if c.Web.Port < 1 || c.Web.Port > 65535 {
 		return fmt.Errorf("invalid port: %d", c.Web.Port)
 	}
+	if files := c.Web.Files; files.Enabled && len(files.Token) < minReplicationTokenLength {
+		return fmt.Errorf("web.files.token must be at least %d characters", minReplicationTokenLength)
+	}
+
+	if public := c.Web.Public; public.Enabled {
+		if public.Port < 1 || public.Port > 65535 {
+			return fmt.Errorf("web.public.port is invalid: %d", public.Port)
+		}
+		if public.Port == c.Web.Port {
+			return fmt.Errorf("web.public.port must differ from web.port")
+		}
+		if public.Refresh < time.Second {
+			return fmt.Errorf("web.public.refresh must be >= 1s")
+		}
+	}
+
+	if guard := c.Web.Guard; guard.RateLimit < 0 {
+		return fmt.Errorf("web.guard.rate_limit must be >= 0")
+	} else if guard.RateLimit > 0 && guard.Burst < 1 {
+		return fmt.Errorf("web.guard.burst must be >= 1")
+	} else if guard.ConfirmTokens && guard.TokenTTL < time.Second {
+		return fmt.Errorf("web.guard.token_ttl must be >= 1s")
+	}
+
+	for event, rule := range c.Web.Alerts.Events {
+		rule.Severity = strings.ToLower(strings.TrimSpace(rule.Severity))
+		switch rule.Severity {
+		case "", "info", "success", "warning", "critical":
+		default:
+			return fmt.Errorf("web.alerts.events.%s.severity must be info, success, warning or critical", event)
+		}
+		c.Web.Alerts.Events[event] = rule
+	}
 
 	seenDashboardIDs := make(map[string]struct{}, len(c.Web.Dashboard.Instances))
 	for i := range c.Web.Dashboard.Instances {
@@ -296,3 +1193,136 @@ func LoadSettings() (project, destination string, parallelism int, err error) {
 		v.GetInt("parallelism"),
 		nil
 }
+
+// hasShare reports whether share names one of the configured shares. Viper
+// lower-cases map keys, so names compare case-insensitively and the trailing
+// $ is optional.
+func (c *Config) hasShare(share string) bool {
+	share = strings.TrimSuffix(strings.TrimSpace(share), "$")
+	configured := append([]string(nil), c.Shares...)
+	for _, shares := range c.NodeShares {
+		configured = append(configured, shares...)
+	}
+	for _, candidate := range configured {
+		if strings.EqualFold(strings.TrimSuffix(candidate, "$"), share) {
+			return true
+		}
+	}
+	return false
+}
+
+// SharesFor returns the shares mounted and scanned on node: its node_shares
+// entry, or the global shares list when no entry matches.
+func (c *Config) SharesFor(node string) []string {
+	pattern, err := c.nodeSharePattern(node)
+	if err != nil || pattern == "" {
+		return c.Shares
+	}
+	return c.NodeShares[pattern]
+}
+
+// NodeShareMap returns the shares of every configured node, for the services
+// that mount and scan them.
+func (c *Config) NodeShareMap() map[string][]string {
+	result := make(map[string][]string, len(c.Nodes))
+	for _, node := range c.Nodes {
+		result[node] = c.SharesFor(node)
+	}
+	return result
+}
+
+// nodeSharePattern returns the node_shares key applying to node. Viper
+// lower-cases map keys, so keys match case-insensitively. A key naming the
+// node exactly wins over glob patterns such as "wu*"; a node matched by
+// several patterns is an error.
+func (c *Config) nodeSharePattern(node string) (string, error) {
+	name := strings.ToLower(node)
+	var matched []string
+	for pattern := range c.NodeShares {
+		key := strings.ToLower(pattern)
+		if key == name {
+			return pattern, nil
+		}
+		if ok, _ := path.Match(key, name); ok {
+			matched = append(matched, pattern)
+		}
+	}
+	if len(matched) > 1 {
+		sort.Strings(matched)
+		return "", fmt.Errorf("node_shares: node %s matches several patterns (%s)", node, strings.Join(matched, ", "))
+	}
+	if len(matched) == 1 {
+		return matched[0], nil
+	}
+	return "", nil
+}
+
+func (c *Config) matchesAnyNode(pattern string) bool {
+	key := strings.ToLower(pattern)
+	for _, node := range c.Nodes {
+		if ok, _ := path.Match(key, strings.ToLower(node)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// minReplicationTokenLength keeps the shared replication secret out of
+// guessing range on an open field LAN.
+const minReplicationTokenLength = 16
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// validateNamePattern checks that a file name pattern, unless empty, is a
+// regular expression with the named groups required.
+func validateNamePattern(key, pattern string, groups ...string) error {
+	if pattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("%s is not a valid regular expression: %w", key, err)
+	}
+	for _, group := range groups {
+		if re.SubexpIndex(group) < 0 {
+			return fmt.Errorf("%s requires a (?P<%s>...) group", key, group)
+		}
+	}
+	return nil
+}
+
+func (p *PostProcessing) validate() error {
+	if p.Workers < 1 || p.QueueSize < 1 {
+		return fmt.Errorf("sync.post_processing requires positive workers and queue_size")
+	}
+	names := make(map[string]bool, len(p.Steps))
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		step.Name = strings.TrimSpace(step.Name)
+		step.URL = strings.TrimSpace(step.URL)
+		if step.Name == "" {
+			return fmt.Errorf("sync.post_processing.steps[%d] requires a name", i)
+		}
+		if names[step.Name] {
+			return fmt.Errorf("sync.post_processing step %q is defined twice", step.Name)
+		}
+		names[step.Name] = true
+		if (len(step.Command) == 0) == (step.URL == "") {
+			return fmt.Errorf("sync.post_processing step %q requires either command or url", step.Name)
+		}
+		if step.URL != "" && !strings.HasPrefix(step.URL, "http://") && !strings.HasPrefix(step.URL, "https://") {
+			return fmt.Errorf("sync.post_processing step %q url must start with http:// or https://: %s", step.Name, step.URL)
+		}
+		if step.Timeout < 0 {
+			return fmt.Errorf("sync.post_processing step %q timeout must not be negative", step.Name)
+		}
+	}
+	return nil
+}
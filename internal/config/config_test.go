@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadAppliesDefaultNetworkMountRoot(t *testing.T) {
@@ -200,3 +201,798 @@ func TestLoadRejectsDashboardInstanceWithoutHTTPURL(t *testing.T) {
 		t.Fatalf("expected dashboard validation error, got %v", err)
 	}
 }
+
+func TestLoadSupportsAlertEventOverrides(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+	configBody := strings.Join([]string{
+		"web:",
+		"  alerts:",
+		"    sound: false",
+		"    events:",
+		"      task_stalled:",
+		"        severity: Critical",
+		"        notify: true",
+	}, "\n") + "\n"
+	if err := os.WriteFile(configPath, []byte(configBody), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	if !cfg.Web.Alerts.BrowserNotifications || cfg.Web.Alerts.Sound {
+		t.Fatalf("unexpected alert switches: %+v", cfg.Web.Alerts)
+	}
+
+	rule, ok := cfg.Web.Alerts.Events["task_stalled"]
+	if !ok {
+		t.Fatal("expected task_stalled alert override")
+	}
+	if rule.Severity != "critical" || rule.Notify == nil || !*rule.Notify || rule.Sound != nil {
+		t.Fatalf("unexpected alert override: %+v", rule)
+	}
+}
+
+func TestLoadRejectsUnknownAlertSeverity(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+	configBody := strings.Join([]string{
+		"web:",
+		"  alerts:",
+		"    events:",
+		"      sync_failed:",
+		"        severity: loud",
+	}, "\n") + "\n"
+	if err := os.WriteFile(configPath, []byte(configBody), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "web.alerts.events") {
+		t.Fatalf("expected alert severity validation error, got %v", err)
+	}
+}
+
+func TestEffectiveReportsSourcesAndMasksSecrets(t *testing.T) {
+	t.Setenv("UCXSYNC_WEB.HOST", "0.0.0.0")
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+	configBody := strings.Join([]string{
+		"credentials:",
+		"  password: secret",
+		"web:",
+		"  port: 9090",
+	}, "\n") + "\n"
+	if err := os.WriteFile(configPath, []byte(configBody), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	cfg.Sync.MaxParallelism = 4
+	cfg.SetSource("sync.max_parallelism", SourceFlag)
+
+	effective := cfg.Effective()
+	if effective.ConfigFile != configPath {
+		t.Fatalf("ConfigFile = %q, want %q", effective.ConfigFile, configPath)
+	}
+
+	values := make(map[string]EffectiveValue, len(effective.Values))
+	for _, value := range effective.Values {
+		values[value.Key] = value
+	}
+
+	expect := map[string]struct {
+		value  interface{}
+		source string
+	}{
+		"web.host":                         {"0.0.0.0", SourceEnv},
+		"web.port":                         {9090, SourceFile},
+		"sync.max_parallelism":             {4, SourceFlag},
+		"sync.stall_timeout":               {"10m0s", SourceDefault},
+		"credentials.password":             {maskedValue, SourceFile},
+		"credentials.username":             {"Administrator", SourceDefault},
+		"web.alerts.browser_notifications": {true, SourceDefault},
+	}
+	for key, want := range expect {
+		got, ok := values[key]
+		if !ok {
+			t.Fatalf("effective config is missing %q", key)
+		}
+		if got.Value != want.value || got.Source != want.source {
+			t.Fatalf("%s = %v (%s), want %v (%s)", key, got.Value, got.Source, want.value, want.source)
+		}
+	}
+}
+
+func TestLoadValidatesShareSubpathsAgainstShares(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+	configBody := "shares: [E$, F$]\nshare_subpaths:\n  E$: 'UCX\\Data'\n"
+	if err := os.WriteFile(configPath, []byte(configBody), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if got := cfg.ShareSubpaths["e$"]; got != `UCX\Data` {
+		t.Fatalf("share_subpaths = %v, want e$ -> UCX\\Data", cfg.ShareSubpaths)
+	}
+
+	configBody = "shares: [E$]\nshare_subpaths:\n  G$: Data\n"
+	if err := os.WriteFile(configPath, []byte(configBody), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "share_subpaths") {
+		t.Fatalf("expected share_subpaths validation error, got %v", err)
+	}
+}
+
+func TestLoadNormalizesCompletionActions(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+	configBody := "sync:\n  completion:\n    actions: [poweroff, Verify, eject, verify]\n    idle_finish: 30m\n"
+	if err := os.WriteFile(configPath, []byte(configBody), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if got := strings.Join(cfg.Sync.Completion.Actions, ","); got != "verify,eject,poweroff" {
+		t.Fatalf("completion actions = %q, want verify,eject,poweroff", got)
+	}
+	if cfg.Sync.Completion.IdleFinish != 30*time.Minute {
+		t.Fatalf("idle_finish = %s, want 30m", cfg.Sync.Completion.IdleFinish)
+	}
+
+	configBody = "sync:\n  completion:\n    actions: [notify]\n"
+	if err := os.WriteFile(configPath, []byte(configBody), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "notify_url") {
+		t.Fatalf("expected notify_url validation error, got %v", err)
+	}
+
+	if _, err := NormalizeCompletionActions([]string{"reboot"}); err == nil {
+		t.Fatal("expected unknown completion action to be rejected")
+	}
+}
+
+func TestLoadValidatesReplication(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+	for body, want := range map[string]string{
+		"replication:\n  serve:\n    enabled: true\n    token: short\n":                                   "replication.serve.token",
+		"replication:\n  mirror:\n    enabled: true\n    peer_url: ucxsync-a:8080\n":                      "peer_url",
+		"replication:\n  mirror:\n    enabled: true\n    peer_url: http://ucxsync-a:8080\n    token: x\n": "token and destination",
+	} {
+		if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("Load(%q) error = %v, want %q", body, err, want)
+		}
+	}
+}
+
+func TestLoadValidatesPostProcessingSteps(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+	configBody := "sync:\n  post_processing:\n    steps:\n      - name: checksum\n        command: [sha256sum, \"{dir}\"]\n        timeout: 5m\n      - name: ingest\n        url: http://ingest.local/api\n"
+	if err := os.WriteFile(configPath, []byte(configBody), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	steps := cfg.Sync.PostProcessing.Steps
+	if len(steps) != 2 || steps[0].Timeout != 5*time.Minute || len(steps[0].Command) != 2 || steps[1].URL != "http://ingest.local/api" {
+		t.Fatalf("steps = %+v", steps)
+	}
+	if cfg.Sync.PostProcessing.Workers != 2 {
+		t.Fatalf("workers = %d, want default 2", cfg.Sync.PostProcessing.Workers)
+	}
+
+	configBody = "sync:\n  post_processing:\n    steps:\n      - name: both\n        command: [true]\n        url: http://ingest.local/api\n"
+	if err := os.WriteFile(configPath, []byte(configBody), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "either command or url") {
+		t.Fatalf("expected command/url validation error, got %v", err)
+	}
+}
+
+func TestLoadValidatesJobTemplates(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+	configBody := "sync:\n  templates:\n    - name: ' nightly-ingest '\n      project: ProjA\n      completion_actions: [manifest, Verify]\n      benchmark: true\n    - name: quick\n      completion_actions: []\n    - name: defaults\n"
+	if err := os.WriteFile(configPath, []byte(configBody), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	nightly, ok := cfg.Sync.Template("nightly-ingest")
+	if !ok || nightly.Project != "ProjA" || strings.Join(nightly.CompletionActions, ",") != "verify,manifest" || nightly.Benchmark == nil || !*nightly.Benchmark {
+		t.Fatalf("nightly-ingest template = %+v, %v", nightly, ok)
+	}
+	if quick, _ := cfg.Sync.Template("quick"); quick.CompletionActions == nil || len(quick.CompletionActions) != 0 {
+		t.Fatalf("quick completion actions = %#v, want an empty list", quick.CompletionActions)
+	}
+	if defaults, _ := cfg.Sync.Template("defaults"); defaults.CompletionActions != nil || defaults.Benchmark != nil {
+		t.Fatalf("defaults template = %+v, want unset overrides", defaults)
+	}
+
+	for body, want := range map[string]string{
+		"sync:\n  templates:\n    - project: ProjA\n":                              "name must not be empty",
+		"sync:\n  templates:\n    - name: a\n    - name: a\n":                      "duplicates",
+		"sync:\n  templates:\n    - name: a\n      completion_actions: [notify]\n": "notify_url",
+	} {
+		if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("Load(%q) error = %v, want %q", body, err, want)
+		}
+	}
+}
+
+func TestLoadValidatesDestinationQuotas(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+	configBody := "sync:\n  quotas:\n    - destination: ' /mnt/nas/ucx/ '\n      max_gb: 200\n"
+	if err := os.WriteFile(configPath, []byte(configBody), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(cfg.Sync.Quotas) != 1 || cfg.Sync.Quotas[0].Destination != "/mnt/nas/ucx" || cfg.Sync.Quotas[0].MaxGB != 200 {
+		t.Fatalf("quotas = %+v", cfg.Sync.Quotas)
+	}
+
+	for body, want := range map[string]string{
+		"sync:\n  quotas:\n    - max_gb: 10\n": "must not be empty",
+		"sync:\n  quotas:\n    - destination: /mnt/a\n      max_gb: 1\n    - destination: /mnt/a/\n      max_gb: 2\n": "duplicates",
+		"sync:\n  quotas:\n    - destination: /mnt/a\n":                                                               "max_gb must be positive",
+	} {
+		if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("Load(%q) error = %v, want %q", body, err, want)
+		}
+	}
+}
+
+func TestLoadValidatesSourceSpaceThresholds(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("monitoring:\n  source_space:\n    warning_free_gb: 20\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if space := cfg.Monitoring.SourceSpace; space.WarningFreePercent != 15 || space.WarningFreeGB != 20 || space.CheckInterval != time.Minute {
+		t.Fatalf("source_space = %+v, want 15%%, 20 GB and the 1m default interval", space)
+	}
+
+	for body, want := range map[string]string{
+		"monitoring:\n  source_space:\n    warning_free_percent: 100\n": "warning_free_percent",
+		"monitoring:\n  source_space:\n    check_interval: 10ms\n":      "check_interval",
+	} {
+		if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("Load(%q) error = %v, want %q", body, err, want)
+		}
+	}
+}
+
+func TestLoadValidatesCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+	for body, want := range map[string]string{
+		"sync:\n  circuit_breaker:\n    failure_rate: 0\n": "failure_rate",
+		"sync:\n  circuit_breaker:\n    min_attempts: 0\n": "min_attempts",
+		"sync:\n  circuit_breaker:\n    open_for: 100ms\n": "open_for",
+	} {
+		if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("Load(%q) error = %v, want %q", body, err, want)
+		}
+	}
+
+	if err := os.WriteFile(configPath, []byte("sync:\n  circuit_breaker:\n    enabled: false\n    failure_rate: 0\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := Load(configPath); err != nil {
+		t.Fatalf("Load with the breaker disabled returned error: %v", err)
+	}
+}
+
+func TestLoadResolvesNodeSharePatterns(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+	body := "nodes: [WU01, WU02, CU]\nshares: [E$, F$]\nnode_shares:\n  CU: [D$]\n  WU0*: [E$]\n  WU02: [F$]\n"
+	if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	shares := cfg.NodeShareMap()
+	if got := strings.Join(shares["CU"], ","); got != "D$" {
+		t.Fatalf("CU shares = %q, want D$", got)
+	}
+	if got := strings.Join(shares["WU01"], ","); got != "E$" {
+		t.Fatalf("WU01 shares = %q, want E$ from the WU0* pattern", got)
+	}
+	if got := strings.Join(shares["WU02"], ","); got != "F$" {
+		t.Fatalf("WU02 shares = %q, want F$ from its exact entry", got)
+	}
+
+	for body, want := range map[string]string{
+		"nodes: [WU01]\nnode_shares:\n  WU1*: [E$]\n":               "matches no configured node",
+		"nodes: [WU01]\nnode_shares:\n  WU*: [E$]\n  '*01': [F$]\n": "several patterns",
+		"nodes: [WU01]\nnode_shares:\n  WU01: []\n":                 "lists no shares",
+	} {
+		if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("Load(%q) error = %v, want %q", body, err, want)
+		}
+	}
+}
+
+func TestLoadValidatesIOBudget(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+	for body, want := range map[string]string{
+		"sync:\n  io_budget:\n    max_mbps: -1\n":     "max_mbps",
+		"sync:\n  io_budget:\n    export_weight: 0\n": "weights",
+	} {
+		if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("Load(%q) error = %v, want %q", body, err, want)
+		}
+	}
+}
+
+func TestLoadValidatesIdlePower(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+	for body, want := range map[string]string{
+		"monitoring:\n  idle_power:\n    enabled: true\n    idle_after: 30s\n":      "idle_after",
+		"monitoring:\n  idle_power:\n    enabled: true\n    monitor_interval: 0s\n": "monitor_interval",
+	} {
+		if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("Load(%q) error = %v, want %q", body, err, want)
+		}
+	}
+}
+
+func TestLoadValidatesDeltaCopy(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+	for body, want := range map[string]string{
+		"sync:\n  delta_copy:\n    min_size: -1\n":    "min_size",
+		"sync:\n  delta_copy:\n    block_size: 512\n": "block_size",
+	} {
+		if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("Load(%q) error = %v, want %q", body, err, want)
+		}
+	}
+}
+
+func TestLoadValidatesWebGuard(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+	for body, want := range map[string]string{
+		"web:\n  guard:\n    rate_limit: -1\n":                             "rate_limit",
+		"web:\n  guard:\n    burst: 0\n":                                   "burst",
+		"web:\n  guard:\n    confirm_tokens: true\n    token_ttl: 100ms\n": "token_ttl",
+	} {
+		if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("Load(%q) error = %v, want %q", body, err, want)
+		}
+	}
+}
+
+func TestLoadLeavesConfirmTokensOffByDefault(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("web:\n  port: 8080\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Web.Guard.ConfirmTokens {
+		t.Fatal("confirm_tokens on by default, existing API clients would get 428")
+	}
+}
+
+func TestLoadValidatesPublicStatusPage(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+	for body, want := range map[string]string{
+		"web:\n  public:\n    enabled: true\n    port: 0\n":        "web.public.port is invalid",
+		"web:\n  port: 8090\n  public:\n    enabled: true\n":       "must differ from web.port",
+		"web:\n  public:\n    enabled: true\n    refresh: 100ms\n": "web.public.refresh",
+	} {
+		if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("Load(%q) error = %v, want %q", body, err, want)
+		}
+	}
+
+	body := "web:\n  public:\n    enabled: true\n"
+	if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load(%q) error = %v", body, err)
+	}
+	if cfg.Web.Public.Port != 8090 || cfg.Web.Public.Refresh != 15*time.Second {
+		t.Fatalf("web.public = %+v, want port 8090 and 15s refresh", cfg.Web.Public)
+	}
+}
+
+func TestLoadValidatesHeartbeat(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	body := "sync:\n  heartbeat:\n    interval: 1s\n"
+	if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "heartbeat.interval") {
+		t.Fatalf("Load(%q) error = %v, want heartbeat.interval", body, err)
+	}
+}
+
+func TestLoadValidatesLogStorm(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	body := "logging:\n  storm:\n    burst: 0\n"
+	if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "logging.storm.burst") {
+		t.Fatalf("Load(%q) error = %v, want logging.storm.burst", body, err)
+	}
+}
+
+func TestLoadValidatesResume(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	body := "sync:\n  resume:\n    verify_bytes: -1\n"
+	if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "sync.resume") {
+		t.Fatalf("Load(%q) error = %v, want sync.resume", body, err)
+	}
+}
+
+func TestLoadValidatesRetry(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	body := "sync:\n  retry:\n    initial_backoff: 1m\n    max_backoff: 10s\n"
+	if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "sync.retry") {
+		t.Fatalf("Load(%q) error = %v, want sync.retry", body, err)
+	}
+}
+
+func TestLoadValidatesFilePatterns(t *testing.T) {
+	t.Parallel()
+
+	for _, body := range []string{
+		"sync:\n  include: [\"*.raw\", \"[\"]\n",
+		"sync:\n  exclude: [\"WU01/*.tmp\"]\n",
+	} {
+		configPath := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "pattern") {
+			t.Fatalf("Load(%q) error = %v, want a pattern error", body, err)
+		}
+	}
+}
+
+func TestLoadValidatesBackpressure(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	body := "sync:\n  backpressure:\n    high_water: 50\n    low_water: 50\n"
+	if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "sync.backpressure") {
+		t.Fatalf("Load(%q) error = %v, want sync.backpressure", body, err)
+	}
+}
+
+func TestLoadValidatesSmallFiles(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	body := "sync:\n  small_files:\n    workers: 0\n"
+	if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "sync.small_files") {
+		t.Fatalf("Load(%q) error = %v, want sync.small_files", body, err)
+	}
+}
+
+func TestLoadValidatesTempDir(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	body := "sync:\n  temp_dir: ../tmp\n"
+	if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "sync.temp_dir") {
+		t.Fatalf("Load(%q) error = %v, want sync.temp_dir", body, err)
+	}
+}
+
+func TestLoadValidatesStability(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	body := "sync:\n  stability:\n    min_age: -1s\n"
+	if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "sync.stability") {
+		t.Fatalf("Load(%q) error = %v, want sync.stability", body, err)
+	}
+}
+
+func TestLoadValidatesMove(t *testing.T) {
+	t.Parallel()
+
+	for _, body := range []string{
+		"sync:\n  move:\n    enabled: true\n    mode: shred\n",
+		"sync:\n  move:\n    enabled: true\n    mode: rename\n    folder: synced\n",
+		"sync:\n  move:\n    enabled: true\n    mode: rename\n    folder: ../.synced\n",
+	} {
+		configPath := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "sync.move") {
+			t.Fatalf("Load(%q) error = %v, want sync.move", body, err)
+		}
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	body := "sync:\n  move:\n    enabled: true\n    mode: rename\n"
+	if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load(%q) error = %v", body, err)
+	}
+	if cfg.Sync.Move.Folder != ".ucxsync-synced" {
+		t.Fatalf("Sync.Move.Folder = %q, want the .ucxsync-synced default", cfg.Sync.Move.Folder)
+	}
+}
+
+func TestLoadValidatesDestinationIndex(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	body := "sync:\n  destination_index:\n    enabled: true\n    refresh_interval: 100ms\n"
+	if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "sync.destination_index") {
+		t.Fatalf("Load(%q) error = %v, want sync.destination_index", body, err)
+	}
+
+	configPath = filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("sync:\n  max_parallelism: 4\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if index := cfg.Sync.DestinationIndex; !index.Enabled || index.RefreshInterval != 10*time.Minute {
+		t.Fatalf("Sync.DestinationIndex = %+v, want it on with a 10m refresh", index)
+	}
+}
+
+func TestLoadValidatesRunFolder(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	body := "sync:\n  run_folder: weekly\n"
+	if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "sync.run_folder") {
+		t.Fatalf("Load(%q) error = %v, want sync.run_folder", body, err)
+	}
+
+	body = "sync:\n  run_folder: job\n"
+	if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load(%q) error = %v", body, err)
+	}
+	if cfg.Sync.RunFolder != "job" {
+		t.Fatalf("Sync.RunFolder = %q, want job", cfg.Sync.RunFolder)
+	}
+}
+
+func TestLoadValidatesVerifySamplePercent(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	for _, body := range []string{
+		"sync:\n  verify:\n    sample_percent: 0\n",
+		"sync:\n  verify:\n    sample_percent: 150\n",
+	} {
+		if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "sync.verify.sample_percent") {
+			t.Fatalf("Load(%q) error = %v, want sync.verify.sample_percent", body, err)
+		}
+	}
+
+	body := "sync:\n  verify:\n    sample_percent: 10\n"
+	if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load(%q) error = %v", body, err)
+	}
+	if cfg.Sync.Verify.SamplePercent != 10 {
+		t.Fatalf("Sync.Verify.SamplePercent = %v, want 10", cfg.Sync.Verify.SamplePercent)
+	}
+}
+
+func TestLoadValidatesNaming(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	for _, tc := range []struct{ body, key string }{
+		{"sync:\n  naming:\n    raw: '^(?P<capture>\\d+'\n", "sync.naming.raw"},
+		{"sync:\n  naming:\n    raw: '^(?P<capture>\\d+)\\.raw$'\n", "sync.naming.raw"},
+		{"sync:\n  naming:\n    metadata: '^EAD-.*\\.xml$'\n", "sync.naming.metadata"},
+	} {
+		if err := os.WriteFile(configPath, []byte(tc.body), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), tc.key) {
+			t.Fatalf("Load(%q) error = %v, want %s", tc.body, err, tc.key)
+		}
+	}
+
+	body := "sync:\n  naming:\n    raw: '^(?P<capture>\\d+)-(?P<sensor>\\d+)\\.raw$'\n"
+	if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load(%q) error = %v", body, err)
+	}
+	if cfg.Sync.Naming.Raw != `^(?P<capture>\d+)-(?P<sensor>\d+)\.raw$` || cfg.Sync.Naming.Metadata != "" {
+		t.Fatalf("Sync.Naming = %+v", cfg.Sync.Naming)
+	}
+}
+
+func TestLoadValidatesChangeNotifyDebounce(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	body := "sync:\n  change_notify:\n    enabled: true\n    debounce: -1s\n"
+	if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "sync.change_notify.debounce") {
+		t.Fatalf("Load(%q) error = %v, want sync.change_notify.debounce", body, err)
+	}
+
+	body = "sync:\n  change_notify:\n    enabled: true\n"
+	if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load(%q) error = %v", body, err)
+	}
+	if notify := cfg.Sync.ChangeNotify; !notify.Immediate || notify.Debounce != 2*time.Second {
+		t.Fatalf("Sync.ChangeNotify = %+v, want immediate with a 2s debounce", notify)
+	}
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Value sources reported by Effective, from lowest to highest precedence.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+const maskedValue = "********"
+
+// EffectiveValue is one resolved configuration key.
+type EffectiveValue struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+}
+
+// EffectiveConfig is the fully merged configuration with secrets masked.
+type EffectiveConfig struct {
+	ConfigFile string           `json:"config_file,omitempty"`
+	Values     []EffectiveValue `json:"values"`
+}
+
+// SetSource records that key was overridden outside of viper, e.g. by a
+// command-line flag applied after Load.
+func (c *Config) SetSource(key, source string) {
+	if c.sources == nil {
+		c.sources = make(map[string]string)
+	}
+	c.sources[key] = source
+}
+
+// Effective returns every configuration key with its current value and the
+// source it came from.
+func (c *Config) Effective() EffectiveConfig {
+	var values []EffectiveValue
+	flattenConfig(reflect.ValueOf(*c), "", func(key string, value interface{}) {
+		if isSecretKey(key) {
+			if s, ok := value.(string); ok && s != "" {
+				value = maskedValue
+			}
+		}
+		values = append(values, EffectiveValue{Key: key, Value: value, Source: c.sourceOf(key)})
+	})
+
+	sort.Slice(values, func(i, j int) bool { return values[i].Key < values[j].Key })
+
+	return EffectiveConfig{ConfigFile: c.configFile, Values: values}
+}
+
+// Source reports where the value of key came from.
+func (c *Config) Source(key string) string {
+	return c.sourceOf(key)
+}
+
+func (c *Config) sourceOf(key string) string {
+	if source, ok := c.sources[key]; ok {
+		return source
+	}
+	return SourceDefault
+}
+
+// recordSources remembers where viper resolved each configuration key from.
+func (c *Config) recordSources(v *viper.Viper) {
+	c.configFile = v.ConfigFileUsed()
+	flattenConfig(reflect.ValueOf(*c), "", func(key string, _ interface{}) {
+		switch {
+		case envKeySet(key):
+			c.SetSource(key, SourceEnv)
+		case v.InConfig(key):
+			c.SetSource(key, SourceFile)
+		}
+	})
+}
+
+// envKeySet mirrors viper's AutomaticEnv lookup for the UCXSYNC prefix.
+func envKeySet(key string) bool {
+	_, ok := os.LookupEnv(strings.ToUpper("UCXSYNC_" + key))
+	return ok
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, marker := range []string{"password", "secret", "token"} {
+		if strings.Contains(key, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenConfig walks nested config structs and calls visit for every leaf
+// with its dotted mapstructure key.
+func flattenConfig(value reflect.Value, prefix string, visit func(string, interface{})) {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("mapstructure")
+		if tag == "" || !field.IsExported() {
+			continue
+		}
+
+		key := tag
+		if prefix != "" {
+			key = prefix + "." + tag
+		}
+
+		fieldValue := value.Field(i)
+		if fieldValue.Kind() == reflect.Struct {
+			flattenConfig(fieldValue, key, visit)
+			continue
+		}
+
+		if d, ok := fieldValue.Interface().(time.Duration); ok {
+			visit(key, d.String())
+			continue
+		}
+		visit(key, fieldValue.Interface())
+	}
+}
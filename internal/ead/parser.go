@@ -10,6 +10,9 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	syncservice "github.com/zangezia/UCXSync/internal/sync"
+	"github.com/zangezia/UCXSync/pkg/models"
 )
 
 var eadFileNameRegex = regexp.MustCompile(`(?i)^EAD-(\d+)(?:-T)?-.*\.xml$`)
@@ -175,6 +178,9 @@ func Parse(data []byte, sourcePath string) (Record, []Issue, error) {
 
 func normalizeCaptureNumber(sourcePath string, numbers ...int) string {
 	filename := filepath.Base(sourcePath)
+	if info := syncservice.ParseCaptureFile(filename); info != nil && info.CaptureNumber != "" {
+		return info.CaptureNumber
+	}
 	matches := eadFileNameRegex.FindStringSubmatch(filename)
 	if len(matches) == 2 {
 		return leftPadCaptureNumber(matches[1])
@@ -182,7 +188,7 @@ func normalizeCaptureNumber(sourcePath string, numbers ...int) string {
 
 	for _, number := range numbers {
 		if number > 0 {
-			return fmt.Sprintf("%05d", number)
+			return models.NormalizeCaptureNumber(strconv.Itoa(number))
 		}
 	}
 
@@ -190,14 +196,7 @@ func normalizeCaptureNumber(sourcePath string, numbers ...int) string {
 }
 
 func leftPadCaptureNumber(raw string) string {
-	raw = strings.TrimSpace(raw)
-	if raw == "" {
-		return ""
-	}
-	if len(raw) >= 5 {
-		return raw
-	}
-	return fmt.Sprintf("%05s", raw)
+	return models.NormalizeCaptureNumber(raw)
 }
 
 func parseTimestamp(dateValue, timeValue string) (time.Time, error) {
@@ -111,8 +111,16 @@ func (p *Processor) ProcessCopiedFile(_ context.Context, event syncservice.Copie
 		return err
 	}
 
+	opts, err := report.LoadOptions(p.store, event.Project)
+	if err != nil {
+		if processingErr != nil {
+			return fmt.Errorf("%w; %v", processingErr, err)
+		}
+		return err
+	}
+
 	reportPath := report.DefaultPath(event.DestinationRoot, event.Project)
-	payload := report.Build(event.Project, records)
+	payload := report.Build(event.Project, records, opts)
 	if err := report.WriteJSON(reportPath, payload); err != nil {
 		if processingErr != nil {
 			return fmt.Errorf("%w; write destination report failed: %v", processingErr, err)
@@ -125,11 +133,17 @@ func (p *Processor) ProcessCopiedFile(_ context.Context, event syncservice.Copie
 
 func isEADMetadataFile(path string) bool {
 	filename := filepath.Base(path)
+	if syncservice.IsMetadataFile(filename) {
+		return true
+	}
 	return strings.EqualFold(filepath.Ext(filename), ".xml") && eadMetadataPathRegex.MatchString(filename)
 }
 
 func parseCaptureNumber(path string) string {
 	filename := filepath.Base(path)
+	if info := syncservice.ParseCaptureFile(filename); info != nil && info.CaptureNumber != "" {
+		return info.CaptureNumber
+	}
 	for _, pattern := range []*regexp.Regexp{eadMetadataPathRegex, rawCapturePathRegex, rawQvPathRegex} {
 		matches := pattern.FindStringSubmatch(filename)
 		if len(matches) >= 2 {
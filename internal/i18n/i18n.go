@@ -0,0 +1,64 @@
+// Package i18n holds the catalog of user-facing event, alert and log
+// messages so crews can get WebSocket and API text in Russian or English.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Supported languages.
+const (
+	Russian = "ru"
+	English = "en"
+
+	// Default is used for an empty or zero Catalog, matching the web UI.
+	Default = Russian
+)
+
+// Key identifies one message of the catalog.
+type Key string
+
+// Catalog formats messages in one language. The zero value uses Default.
+type Catalog struct {
+	lang string
+}
+
+// New returns a catalog for lang; unknown languages fall back to Default.
+func New(lang string) Catalog {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if !Supported(lang) {
+		lang = Default
+	}
+	return Catalog{lang: lang}
+}
+
+// Supported reports whether lang has translations.
+func Supported(lang string) bool {
+	return lang == Russian || lang == English
+}
+
+// Language returns the catalog's language code.
+func (c Catalog) Language() string {
+	if c.lang == "" {
+		return Default
+	}
+	return c.lang
+}
+
+// Sprintf formats the message key with args. A message missing in the
+// catalog language falls back to English, then to the key itself.
+func (c Catalog) Sprintf(key Key, args ...interface{}) string {
+	translations, ok := messages[key]
+	if !ok {
+		return string(key)
+	}
+	format, ok := translations[c.Language()]
+	if !ok {
+		format = translations[English]
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,51 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+)
+
+var verbPattern = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z]`)
+
+func TestCatalogTranslationsMatchPlaceholders(t *testing.T) {
+	for key, translations := range messages {
+		english, ok := translations[English]
+		if !ok {
+			t.Errorf("%s has no English text", key)
+			continue
+		}
+		russian, ok := translations[Russian]
+		if !ok {
+			t.Errorf("%s has no Russian text", key)
+			continue
+		}
+
+		want := verbPattern.FindAllString(english, -1)
+		got := verbPattern.FindAllString(russian, -1)
+		if len(want) != len(got) {
+			t.Errorf("%s: Russian verbs %v, English verbs %v", key, got, want)
+			continue
+		}
+		for i := range want {
+			if want[i] != got[i] {
+				t.Errorf("%s: Russian verbs %v, English verbs %v", key, got, want)
+				break
+			}
+		}
+	}
+}
+
+func TestCatalogSprintf(t *testing.T) {
+	if got := New("EN").Sprintf(EventCaptureCompleted, "00042"); got != "Capture 00042 completed" {
+		t.Fatalf("English = %q", got)
+	}
+	if got := (Catalog{}).Sprintf(EventCaptureCompleted, "00042"); got != "Съёмка 00042 завершена" {
+		t.Fatalf("zero catalog = %q, want Russian", got)
+	}
+	if got := New("de").Language(); got != Default {
+		t.Fatalf("unsupported language = %q, want %q", got, Default)
+	}
+	if got := New(English).Sprintf(Key("custom_event")); got != "custom_event" {
+		t.Fatalf("unknown key = %q", got)
+	}
+}
@@ -0,0 +1,366 @@
+package i18n
+
+// Sync event messages.
+const (
+	EventSyncFinished             Key = "event.sync_finished"
+	EventSyncStopped              Key = "event.sync_stopped"
+	EventSyncFailed               Key = "event.sync_failed"
+	EventCaptureCompleted         Key = "event.capture_completed"
+	EventTestCaptureCompleted     Key = "event.test_capture_completed"
+	EventTaskStalled              Key = "event.task_stalled"
+	ReasonNoProgress              Key = "reason.no_progress"
+	EventDiskSpaceShort           Key = "event.disk_space_short"
+	EventDestinationFull          Key = "event.destination_full"
+	EventDestinationFullSwitching Key = "event.destination_full_switching"
+	EventDestinationFullNoSpare   Key = "event.destination_full_no_spare"
+	EventDestinationLost          Key = "event.destination_lost"
+	EventDestinationSwitched      Key = "event.destination_switched"
+	EventQuotaReached             Key = "event.quota_reached"
+	EventSourceSpaceLow           Key = "event.source_space_low"
+	EventNodeBreakerOpen          Key = "event.node_breaker_open"
+	EventFilesystemLimit          Key = "event.filesystem_limit"
+	EventFileIncompatible         Key = "event.file_incompatible"
+	EventIntegrityMismatch        Key = "event.integrity_mismatch"
+	EventFileQuarantined          Key = "event.file_quarantined"
+	EventFileDegraded             Key = "event.file_degraded"
+	EventProjectRenamed           Key = "event.project_renamed"
+	EventPostProcessFailed        Key = "event.post_process_failed"
+	EventShareMountFailed         Key = "event.share_mount_failed"
+	EventExportFinished           Key = "event.export_finished"
+	EventExportFailed             Key = "event.export_failed"
+	EventPacingBehind             Key = "event.pacing_behind"
+	EventPacingOnTrack            Key = "event.pacing_on_track"
+)
+
+// Alert notification titles.
+const (
+	AlertCaptureCompleted        Key = "alert.capture_completed"
+	AlertSyncFinished            Key = "alert.sync_finished"
+	AlertSyncStopped             Key = "alert.sync_stopped"
+	AlertSyncFailed              Key = "alert.sync_failed"
+	AlertTaskStalled             Key = "alert.task_stalled"
+	AlertDiskSpaceWarning        Key = "alert.disk_space_warning"
+	AlertDestinationFull         Key = "alert.destination_full"
+	AlertDestinationLost         Key = "alert.destination_lost"
+	AlertDestinationSwitched     Key = "alert.destination_switched"
+	AlertDestinationIncompatible Key = "alert.destination_incompatible"
+	AlertQuotaReached            Key = "alert.quota_reached"
+	AlertSourceSpaceLow          Key = "alert.source_space_low"
+	AlertNodeBreakerOpen         Key = "alert.node_breaker_open"
+	AlertIntegrityMismatch       Key = "alert.integrity_mismatch"
+	AlertFileQuarantined         Key = "alert.file_quarantined"
+	AlertFileDegraded            Key = "alert.file_degraded"
+	AlertProjectRenamed          Key = "alert.project_renamed"
+	AlertPostProcessFailed       Key = "alert.post_process_failed"
+	AlertShareMountFailed        Key = "alert.share_mount_failed"
+	AlertExportFinished          Key = "alert.export_finished"
+	AlertExportFailed            Key = "alert.export_failed"
+	AlertPacingBehind            Key = "alert.pacing_behind"
+	AlertPacingOnTrack           Key = "alert.pacing_on_track"
+)
+
+// Operator log messages broadcast to the UI.
+const (
+	LogSyncStarted           Key = "log.sync_started"
+	LogOnCompletion          Key = "log.on_completion"
+	LogSyncStopped           Key = "log.sync_stopped"
+	LogSyncPaused            Key = "log.sync_paused"
+	LogSyncResumed           Key = "log.sync_resumed"
+	LogStoppedForUnmount     Key = "log.stopped_for_unmount"
+	LogRescanInterval        Key = "log.rescan_interval"
+	LogExportStarted         Key = "log.export_started"
+	LogNodeMaintenanceOn     Key = "log.node_maintenance_on"
+	LogNodeMaintenanceOff    Key = "log.node_maintenance_off"
+	LogNodePaused            Key = "log.node_paused"
+	LogNodeResumed           Key = "log.node_resumed"
+	LogFilesRequeued         Key = "log.files_requeued"
+	LogDeviceMounted         Key = "log.device_mounted"
+	LogDeviceUnmounted       Key = "log.device_unmounted"
+	LogDeviceUnlocked        Key = "log.device_unlocked"
+	LogDeviceRemovedUnlocked Key = "log.device_removed_unlocked"
+	LogDeviceLockFailed      Key = "log.device_lock_failed"
+	LogSharesRemounted       Key = "log.shares_remounted"
+	LogCredentialsRotated    Key = "log.credentials_rotated"
+	LogCredentialsRejected   Key = "log.credentials_rejected"
+	LogCredentialsFailed     Key = "log.credentials_failed"
+	LogProjectHistoryCleared Key = "log.project_history_cleared"
+	LogDatabaseCleared       Key = "log.database_cleared"
+	LogProjectDeleted        Key = "log.project_deleted"
+	LogServiceRestart        Key = "log.service_restart"
+	LogHostTimeSynced        Key = "log.host_time_synced"
+	LogHostShutdown          Key = "log.host_shutdown"
+	LogCompletionAction      Key = "log.completion_action"
+	LogBenchmarkResult       Key = "log.benchmark_result"
+	LogBenchmarkSlow         Key = "log.benchmark_slow"
+	LogBenchmarkFailed       Key = "log.benchmark_failed"
+	LogProjectRemapped       Key = "log.project_remapped"
+	LogProjectsChanged       Key = "log.projects_changed"
+	LogPowerIdle             Key = "log.power_idle"
+	LogPowerIdleSpunDown     Key = "log.power_idle_spun_down"
+	LogPowerWake             Key = "log.power_wake"
+	LogRepeated              Key = "log.repeated"
+)
+
+// Completion action results.
+const (
+	CompletionSkipped      Key = "completion.skipped"
+	CompletionVerified     Key = "completion.verified"
+	CompletionSampled      Key = "completion.sampled"
+	CompletionManifest     Key = "completion.manifest"
+	CompletionNotified     Key = "completion.notified"
+	CompletionEjected      Key = "completion.ejected"
+	CompletionPoweringOff  Key = "completion.powering_off"
+	CompletionVerifyFailed Key = "completion.verify_failed"
+)
+
+// Labels of the public status page.
+const (
+	PublicTitle        Key = "public.title"
+	PublicProject      Key = "public.project"
+	PublicStateRunning Key = "public.state_running"
+	PublicStatePaused  Key = "public.state_paused"
+	PublicStateIdle    Key = "public.state_idle"
+	PublicCaptures     Key = "public.captures"
+	PublicLastCapture  Key = "public.last_capture"
+	PublicETA          Key = "public.eta"
+	PublicETAUnknown   Key = "public.eta_unknown"
+	PublicAlerts       Key = "public.alerts"
+	PublicNoAlerts     Key = "public.no_alerts"
+	PublicUpdated      Key = "public.updated"
+)
+
+var messages = map[Key]map[string]string{
+	EventSyncFinished: {
+		English: "Synchronization finished: %d files copied, %d failed, %d captures completed",
+		Russian: "Синхронизация завершена: скопировано файлов %d, ошибок %d, завершено съёмок %d",
+	},
+	EventSyncStopped: {
+		English: "Synchronization stopped: %d files copied, %d failed, %d captures completed",
+		Russian: "Синхронизация остановлена: скопировано файлов %d, ошибок %d, завершено съёмок %d",
+	},
+	EventSyncFailed: {
+		English: "Synchronization failed: %s → %s",
+		Russian: "Ошибка синхронизации: %s → %s",
+	},
+	EventCaptureCompleted: {
+		English: "Capture %s completed",
+		Russian: "Съёмка %s завершена",
+	},
+	EventTestCaptureCompleted: {
+		English: "Test capture %s completed",
+		Russian: "Тестовая съёмка %s завершена",
+	},
+	EventTaskStalled: {
+		English: "Task %s/%s stalled and was restarted",
+		Russian: "Задача %s/%s зависла и перезапущена",
+	},
+	ReasonNoProgress: {
+		English: "no progress for %s",
+		Russian: "нет прогресса %s",
+	},
+	EventDiskSpaceShort: {
+		English: "Destination %s is projected to run short by %.1f GB",
+		Russian: "На диске назначения %s по прогнозу не хватит %.1f ГБ",
+	},
+	EventDestinationFull: {
+		English: "Destination %s is full, select the next destination to continue",
+		Russian: "Диск назначения %s заполнен, выберите следующий диск для продолжения",
+	},
+	EventDestinationFullSwitching: {
+		English: "Destination %s is full, continuing on %s",
+		Russian: "Диск назначения %s заполнен, продолжаем на %s",
+	},
+	EventDestinationFullNoSpare: {
+		English: "Destination %s is full and no other destination is available",
+		Russian: "Диск назначения %s заполнен, другого диска нет",
+	},
+	EventDestinationLost: {
+		English: "Destination %s disappeared, synchronization paused",
+		Russian: "Диск назначения %s пропал, синхронизация приостановлена",
+	},
+	EventDestinationSwitched: {
+		English: "Synchronization continues on %s (was %s)",
+		Russian: "Синхронизация продолжается на %s (ранее %s)",
+	},
+	EventFilesystemLimit: {
+		English: "Destination %s is %s: files larger than 4 GB will be rejected",
+		Russian: "Диск назначения %s в формате %s: файлы больше 4 ГБ не поместятся",
+	},
+	EventFileIncompatible: {
+		English: "File %s cannot be copied to %s",
+		Russian: "Файл %s нельзя скопировать на %s",
+	},
+	EventIntegrityMismatch: {
+		English: "Integrity spot-check failed for %s",
+		Russian: "Выборочная проверка целостности не пройдена: %s",
+	},
+	EventFileQuarantined: {
+		English: "File %s quarantined after %d failed attempts",
+		Russian: "Файл %s помещён в карантин после %d неудачных попыток",
+	},
+	EventFileDegraded: {
+		English: "File %s copied with %d unreadable bytes in %d ranges, gaps zero-filled",
+		Russian: "Файл %s скопирован с нечитаемыми байтами (%d) в %d диапазонах, пропуски заполнены нулями",
+	},
+	EventQuotaReached: {
+		English: "Destination %s reached its quota (%.1f of %.1f GB), synchronization paused",
+		Russian: "Диск назначения %s исчерпал квоту (%.1f из %.1f ГБ), синхронизация приостановлена",
+	},
+	EventSourceSpaceLow: {
+		English: "Share %s on %s is running out of space: %.1f GB free (%.0f%%)",
+		Russian: "На ресурсе %s узла %s заканчивается место: свободно %.1f ГБ (%.0f%%)",
+	},
+	EventNodeBreakerOpen: {
+		English: "Node %s: %d of %d copies failed, copying from it suspended for %s",
+		Russian: "Узел %s: ошибок копирования %d из %d, копирование с него приостановлено на %s",
+	},
+	EventProjectRenamed: {
+		English: "Project folder %s appears to be renamed to %s on %s; remap the job to continue",
+		Russian: "Папка проекта %s, похоже, переименована в %s на %s; переключите задание, чтобы продолжить",
+	},
+	EventPostProcessFailed: {
+		English: "Post-processing step %s failed for capture %s",
+		Russian: "Шаг постобработки %s завершился ошибкой для съёмки %s",
+	},
+	EventShareMountFailed: {
+		English: "%d shares are unavailable and could not be remounted",
+		Russian: "Недоступно шар: %d, повторное монтирование не удалось",
+	},
+	EventExportFinished: {
+		English: "Delivery export finished: %d captures, %d files copied of %d",
+		Russian: "Экспорт для заказчика завершён: съёмок %d, скопировано файлов %d из %d",
+	},
+	EventExportFailed: {
+		English: "Delivery export failed",
+		Russian: "Ошибка экспорта для заказчика",
+	},
+	EventPacingBehind: {
+		English: "Projected to finish at %s, after the target %s (%.1f MB/s, %.1f MB/s needed)",
+		Russian: "По прогнозу синхронизация закончится в %s, позже срока %s (%.1f МБ/с, нужно %.1f МБ/с)",
+	},
+	EventPacingOnTrack: {
+		English: "Back on pace to finish by %s",
+		Russian: "Синхронизация снова успевает к сроку %s",
+	},
+
+	AlertCaptureCompleted:        {English: "Capture completed", Russian: "Съёмка завершена"},
+	AlertSyncFinished:            {English: "Synchronization finished", Russian: "Синхронизация завершена"},
+	AlertSyncStopped:             {English: "Synchronization stopped", Russian: "Синхронизация остановлена"},
+	AlertSyncFailed:              {English: "Synchronization failed", Russian: "Ошибка синхронизации"},
+	AlertTaskStalled:             {English: "Task stalled", Russian: "Задача зависла"},
+	AlertDiskSpaceWarning:        {English: "Running out of space", Russian: "Не хватает места"},
+	AlertDestinationFull:         {English: "Destination full", Russian: "Диск назначения заполнен"},
+	AlertDestinationLost:         {English: "Destination disconnected", Russian: "Диск назначения отключён"},
+	AlertDestinationSwitched:     {English: "Destination changed", Russian: "Диск назначения сменён"},
+	AlertDestinationIncompatible: {English: "Incompatible file system", Russian: "Несовместимая файловая система"},
+	AlertQuotaReached:            {English: "Destination quota reached", Russian: "Квота диска назначения исчерпана"},
+	AlertSourceSpaceLow:          {English: "Worker disk running out of space", Russian: "На диске узла заканчивается место"},
+	AlertNodeBreakerOpen:         {English: "Copying from node suspended", Russian: "Копирование с узла приостановлено"},
+	AlertIntegrityMismatch:       {English: "File on destination corrupted", Russian: "Файл на диске повреждён"},
+	AlertFileQuarantined:         {English: "File quarantined", Russian: "Файл помещён в карантин"},
+	AlertFileDegraded:            {English: "File copied with read errors", Russian: "Файл скопирован с ошибками чтения"},
+	AlertProjectRenamed:          {English: "Project folder renamed", Russian: "Папка проекта переименована"},
+	AlertPostProcessFailed:       {English: "Post-processing failed", Russian: "Ошибка постобработки"},
+	AlertShareMountFailed:        {English: "Shares not mounted", Russian: "Шары не смонтированы"},
+	AlertExportFinished:          {English: "Delivery export finished", Russian: "Экспорт для заказчика завершён"},
+	AlertExportFailed:            {English: "Delivery export failed", Russian: "Ошибка экспорта для заказчика"},
+	AlertPacingBehind:            {English: "Sync behind schedule", Russian: "Синхронизация не успевает к сроку"},
+	AlertPacingOnTrack:           {English: "Sync back on schedule", Russian: "Синхронизация снова успевает к сроку"},
+
+	LogSyncStarted: {
+		English: "Started synchronization: project=%s, destination=%s, full_resync=%t",
+		Russian: "Синхронизация запущена: проект=%s, назначение=%s, полная пересинхронизация=%t",
+	},
+	LogOnCompletion: {
+		English: ", on completion: %s",
+		Russian: ", по завершении: %s",
+	},
+	LogSyncStopped:       {English: "Synchronization stopped", Russian: "Синхронизация остановлена"},
+	LogSyncPaused:        {English: "Synchronization paused", Russian: "Синхронизация приостановлена"},
+	LogSyncResumed:       {English: "Synchronization resumed", Russian: "Синхронизация возобновлена"},
+	LogStoppedForUnmount: {English: "Synchronization stopped before unmounting the destination disk", Russian: "Синхронизация остановлена перед размонтированием диска назначения"},
+	LogRescanInterval:    {English: "Share rescan interval set to %s", Russian: "Интервал пересканирования шар: %s"},
+	LogExportStarted: {
+		English: "Delivery export of project %s started: %s",
+		Russian: "Экспорт проекта %s для заказчика запущен: %s",
+	},
+	LogNodeMaintenanceOn:  {English: "Node %s put into maintenance", Russian: "Узел %s переведён в режим обслуживания"},
+	LogNodeMaintenanceOff: {English: "Node %s taken out of maintenance", Russian: "Узел %s выведен из обслуживания"},
+	LogNodePaused:         {English: "Copying from node %s paused", Russian: "Копирование с узла %s приостановлено"},
+	LogNodeResumed:        {English: "Copying from node %s resumed", Russian: "Копирование с узла %s возобновлено"},
+	LogFilesRequeued:      {English: "Files re-queued: %d", Russian: "Повторно поставлено в очередь файлов: %d"},
+	LogDeviceMounted:      {English: "Device mounted: %s", Russian: "Устройство смонтировано: %s"},
+	LogDeviceUnmounted:    {English: "Device unmounted: %s", Russian: "Устройство размонтировано: %s"},
+	LogDeviceUnlocked: {
+		English: "Encrypted device %s unlocked and mounted at %s",
+		Russian: "Зашифрованное устройство %s разблокировано и смонтировано в %s",
+	},
+	LogDeviceRemovedUnlocked: {
+		English: "Encrypted device %s was removed while unlocked: its mount was detached and it was locked",
+		Russian: "Зашифрованное устройство %s извлечено разблокированным: монтирование снято, устройство заблокировано",
+	},
+	LogDeviceLockFailed: {
+		English: "Encrypted device %s was removed while unlocked and could not be locked: %s",
+		Russian: "Зашифрованное устройство %s извлечено разблокированным, заблокировать не удалось: %s",
+	},
+	LogSharesRemounted: {English: "Share mount retried", Russian: "Повторная попытка монтирования шар выполнена"},
+	LogCredentialsRotated: {
+		English: "Share credentials rotated from %s: %d of %d shares remounted",
+		Russian: "Учётные данные шар сменены с %s: перемонтировано шар: %d из %d",
+	},
+	LogCredentialsRejected: {
+		English: "Share credential rotation from %s rejected: wrong current password",
+		Russian: "Смена учётных данных шар с %s отклонена: неверный текущий пароль",
+	},
+	LogCredentialsFailed: {
+		English: "Share credential rotation from %s failed, old credentials kept: %s",
+		Russian: "Смена учётных данных шар с %s не удалась, оставлены прежние: %s",
+	},
+	LogProjectHistoryCleared: {English: "History of project '%s' cleared", Russian: "История проекта '%s' очищена"},
+	LogDatabaseCleared:       {English: "Project database cleared", Russian: "База проектов очищена"},
+	LogProjectDeleted:        {English: "Project '%s' deleted from database", Russian: "Проект '%s' удалён из базы"},
+	LogServiceRestart:        {English: "Restart of service %s requested", Russian: "Запрошен перезапуск службы %s"},
+	LogHostTimeSynced: {
+		English: "Host time synchronized from browser device, drift corrected: %s",
+		Russian: "Время хоста синхронизировано с браузером, исправлено расхождение: %s",
+	},
+	LogHostShutdown:     {English: "Host shutdown requested", Russian: "Запрошено выключение хоста"},
+	LogCompletionAction: {English: "Completion action %s: %s", Russian: "Действие по завершении %s: %s"},
+	LogBenchmarkResult:  {English: "Destination %s writes at %.0f MB/s", Russian: "Скорость записи на %s: %.0f МБ/с"},
+	LogBenchmarkSlow: {
+		English: "Destination %s writes at only %.0f MB/s, expected about %.0f MB/s",
+		Russian: "Скорость записи на %s всего %.0f МБ/с, ожидалось около %.0f МБ/с",
+	},
+	LogBenchmarkFailed: {English: "Destination benchmark failed: %s", Russian: "Тест скорости диска назначения не удался: %s"},
+	LogProjectRemapped: {English: "Job switched to renamed project folder %s; copied captures are kept", Russian: "Задание переключено на переименованную папку проекта %s; скопированные снимки сохранены"},
+	LogProjectsChanged: {English: "Project list on the shares changed: %d projects found", Russian: "Список проектов на шарах изменился: найдено проектов: %d"},
+	LogPowerIdle:       {English: "No activity for %s: power saving, monitoring slowed down", Russian: "Нет активности %s: режим энергосбережения, мониторинг замедлен"},
+	LogPowerIdleSpunDown: {
+		English: "No activity for %s: power saving, disk %s spun down",
+		Russian: "Нет активности %s: режим энергосбережения, диск %s остановлен",
+	},
+	LogPowerWake: {English: "Activity resumed, power saving off", Russian: "Активность возобновилась, энергосбережение выключено"},
+	LogRepeated:  {English: "%s (repeated %d more times in %s)", Russian: "%s (повторилось ещё %d раз за %s)"},
+
+	CompletionSkipped:      {English: "skipped after a failed completion action", Russian: "пропущено после ошибки предыдущего действия"},
+	CompletionVerified:     {English: "%d files match the source, %d skipped", Russian: "совпадают с источником файлов: %d, пропущено: %d"},
+	CompletionSampled:      {English: "%d sampled files match the source (%d not sampled), %d skipped", Russian: "совпадают с источником файлов выборки: %d (вне выборки: %d), пропущено: %d"},
+	CompletionManifest:     {English: "manifest written to %s", Russian: "манифест записан в %s"},
+	CompletionNotified:     {English: "notification sent", Russian: "уведомление отправлено"},
+	CompletionEjected:      {English: "destination ejected", Russian: "диск назначения извлечён"},
+	CompletionPoweringOff:  {English: "host powering off", Russian: "хост выключается"},
+	CompletionVerifyFailed: {English: "%d of %d files differ from the source, first: %s", Russian: "отличаются от источника файлов: %d из %d, первый: %s"},
+
+	PublicTitle:        {English: "UCXSync status", Russian: "Статус UCXSync"},
+	PublicProject:      {English: "Project", Russian: "Проект"},
+	PublicStateRunning: {English: "Copying", Russian: "Идёт копирование"},
+	PublicStatePaused:  {English: "Paused", Russian: "Приостановлено"},
+	PublicStateIdle:    {English: "Stopped", Russian: "Остановлено"},
+	PublicCaptures:     {English: "Captures complete", Russian: "Завершено съёмок"},
+	PublicLastCapture:  {English: "Last capture", Russian: "Последняя съёмка"},
+	PublicETA:          {English: "Estimated finish", Russian: "Ожидаемое завершение"},
+	PublicETAUnknown:   {English: "not known yet", Russian: "пока неизвестно"},
+	PublicAlerts:       {English: "Alerts", Russian: "Тревоги"},
+	PublicNoAlerts:     {English: "No alerts", Russian: "Тревог нет"},
+	PublicUpdated:      {English: "Updated %s", Russian: "Обновлено %s"},
+}
@@ -0,0 +1,119 @@
+// Package logstorm keeps logs readable during incidents. Repeated messages
+// (thousands of identical CIFS errors differing only in the file name) are
+// passed a few times per window and then counted, with one summary line at
+// the end of the window. A sudden spike of errors also switches on debug
+// logging for a while, so the first occurrences of a new failure come with
+// full detail.
+package logstorm
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options configures storm handling.
+type Options struct {
+	Enabled    bool
+	Window     time.Duration // Period repeats are counted over
+	Burst      int           // Identical messages passed per window before collapsing
+	Threshold  int           // Errors per window that start a storm, 0 never raises verbosity
+	VerboseFor time.Duration // How long debug logging stays on once a storm starts
+}
+
+// Summary reports the messages collapsed under one key during a window.
+type Summary struct {
+	Level      string
+	Sample     string // The first message of the window
+	Suppressed int
+	Since      time.Time
+}
+
+// Collapser decides which repeated messages pass.
+type Collapser struct {
+	mu      sync.Mutex
+	window  time.Duration
+	burst   int
+	entries map[string]*entry
+	pending []Summary // Ended windows not yet flushed
+}
+
+type entry struct {
+	start      time.Time
+	level      string
+	sample     string
+	count      int
+	suppressed int
+}
+
+// NewCollapser passes burst messages per key and window.
+func NewCollapser(window time.Duration, burst int) *Collapser {
+	return &Collapser{window: window, burst: burst, entries: make(map[string]*entry)}
+}
+
+// Allow reports whether a message under key may pass at now. sample and
+// level describe the message for the summary of the window.
+func (c *Collapser) Allow(key, level, sample string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := c.entries[key]
+	if e != nil && now.Sub(e.start) >= c.window {
+		// A new window; the ended one is summarized by the next Flush.
+		if e.suppressed > 0 {
+			c.pending = append(c.pending, e.summary())
+		}
+		e = nil
+	}
+	if e == nil {
+		e = &entry{start: now, level: level, sample: sample}
+		c.entries[key] = e
+	}
+	e.count++
+	if e.count <= c.burst {
+		return true
+	}
+	e.suppressed++
+	return false
+}
+
+// Flush returns the keys whose window ended with collapsed messages, oldest
+// first, and forgets every key whose window ended.
+func (c *Collapser) Flush(now time.Time) []Summary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	summaries := c.pending
+	c.pending = nil
+	for key, e := range c.entries {
+		if now.Sub(e.start) < c.window {
+			continue
+		}
+		if e.suppressed > 0 {
+			summaries = append(summaries, e.summary())
+		}
+		delete(c.entries, key)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Since.Before(summaries[j].Since) })
+	return summaries
+}
+
+func (e *entry) summary() Summary {
+	return Summary{Level: e.level, Sample: e.sample, Suppressed: e.suppressed, Since: e.start}
+}
+
+var (
+	pathPattern   = regexp.MustCompile(`(?:[A-Za-z]:)?(?:[/\\][^\s/\\:"',;)]+)+[/\\]?`)
+	numberPattern = regexp.MustCompile(`\d+`)
+)
+
+// Key is what makes two messages the same: their level and text with file
+// paths and numbers blanked out.
+func Key(level string, parts ...string) string {
+	text := strings.Join(parts, " | ")
+	text = pathPattern.ReplaceAllString(text, "<path>")
+	text = numberPattern.ReplaceAllString(text, "#")
+	return level + " " + text
+}
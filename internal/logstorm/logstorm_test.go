@@ -0,0 +1,104 @@
+package logstorm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestCollapserSummarizesRepeatsPerWindow(t *testing.T) {
+	start := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	c := NewCollapser(10*time.Second, 2)
+
+	passed := 0
+	for i := 0; i < 50; i++ {
+		key := Key("error", "Failed to copy file", fmt.Sprintf("open /mnt/ucx/WU01/E/raw_%04d.raw: host is down", i))
+		if c.Allow(key, "error", "Failed to copy file", start.Add(time.Duration(i)*100*time.Millisecond)) {
+			passed++
+		}
+	}
+	if passed != 2 {
+		t.Fatalf("passed %d messages, want the burst of 2", passed)
+	}
+	if summaries := c.Flush(start.Add(9 * time.Second)); len(summaries) != 0 {
+		t.Fatalf("Flush inside the window = %+v, want nothing", summaries)
+	}
+
+	summaries := c.Flush(start.Add(10 * time.Second))
+	if len(summaries) != 1 || summaries[0].Suppressed != 48 || !summaries[0].Since.Equal(start) {
+		t.Fatalf("Flush after the window = %+v, want one summary of 48 since %v", summaries, start)
+	}
+	if !c.Allow(Key("error", "Failed to copy file"), "error", "Failed to copy file", start.Add(11*time.Second)) {
+		t.Fatal("first message of a new window was held back")
+	}
+}
+
+func TestCollapserKeepsSummaryOfWindowRolledOverBeforeFlush(t *testing.T) {
+	start := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	c := NewCollapser(time.Second, 1)
+
+	c.Allow("k", "warn", "Share unavailable", start)
+	c.Allow("k", "warn", "Share unavailable", start)
+	if !c.Allow("k", "warn", "Share unavailable", start.Add(2*time.Second)) {
+		t.Fatal("first message of a new window was held back")
+	}
+
+	summaries := c.Flush(start.Add(2 * time.Second))
+	if len(summaries) != 1 || summaries[0].Suppressed != 1 {
+		t.Fatalf("Flush = %+v, want the summary of the ended window", summaries)
+	}
+}
+
+func TestWriterCollapsesRepeatsAndRaisesVerbosityDuringStorm(t *testing.T) {
+	defer zerolog.SetGlobalLevel(zerolog.GlobalLevel())
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+
+	now := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	var out bytes.Buffer
+	w := NewWriter(&out)
+	w.now = func() time.Time { return now }
+	w.Configure(Options{Enabled: true, Window: 10 * time.Second, Burst: 3, Threshold: 20, VerboseFor: time.Minute})
+	logger := zerolog.New(w)
+
+	for i := 0; i < 100; i++ {
+		logger.Error().Str("file", fmt.Sprintf("/mnt/ucx/WU01/E/raw_%04d.raw", i)).
+			Err(fmt.Errorf("read /mnt/ucx/WU01/E/raw_%04d.raw: host is down", i)).Msg("Failed to copy file")
+	}
+	if zerolog.GlobalLevel() != zerolog.DebugLevel {
+		t.Fatalf("global level during the storm = %v, want debug", zerolog.GlobalLevel())
+	}
+
+	now = now.Add(time.Minute)
+	w.Flush()
+	if zerolog.GlobalLevel() != zerolog.InfoLevel {
+		t.Fatalf("global level after verbose_for = %v, want info restored", zerolog.GlobalLevel())
+	}
+
+	var copyLines, stormLines int
+	var repeated int
+	for _, raw := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var line struct {
+			Message  string `json:"message"`
+			Repeated int    `json:"repeated"`
+		}
+		if err := json.Unmarshal([]byte(raw), &line); err != nil {
+			t.Fatalf("invalid log line %q: %v", raw, err)
+		}
+		switch {
+		case line.Repeated > 0:
+			repeated = line.Repeated
+		case strings.HasPrefix(line.Message, "Error storm"):
+			stormLines++
+		default:
+			copyLines++
+		}
+	}
+	if copyLines != 3 || stormLines != 1 || repeated != 97 {
+		t.Fatalf("got %d copy lines, %d storm lines, %d repeated, want 3, 1 and 97:\n%s", copyLines, stormLines, repeated, out.String())
+	}
+}
@@ -0,0 +1,167 @@
+package logstorm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Writer sits between zerolog and the log output. It passes everything
+// through until Configure enables it.
+type Writer struct {
+	next io.Writer
+
+	mu           sync.Mutex
+	opts         Options
+	collapser    *Collapser
+	errorsStart  time.Time // Window the errors are counted in
+	errors       int
+	verboseUntil time.Time
+	baseLevel    zerolog.Level // Global level to return to after a storm
+	now          func() time.Time
+}
+
+// NewWriter writes through to next.
+func NewWriter(next io.Writer) *Writer {
+	return &Writer{next: next, now: time.Now}
+}
+
+// Configure applies opts. Changing them ends a raised verbosity.
+func (w *Writer) Configure(opts Options) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.restoreLevelLocked()
+	w.opts = opts
+	w.collapser = nil
+	if opts.Enabled {
+		w.collapser = NewCollapser(opts.Window, opts.Burst)
+	}
+	w.errors = 0
+	w.errorsStart = time.Time{}
+}
+
+// line is the part of a zerolog JSON line storms are detected on.
+type line struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	Error   string `json:"error"`
+}
+
+// Write passes p on unless it repeats a message too often in the window.
+func (w *Writer) Write(p []byte) (int, error) {
+	var parsed line
+	if err := json.Unmarshal(p, &parsed); err != nil {
+		return w.next.Write(p)
+	}
+
+	w.mu.Lock()
+	collapser := w.collapser
+	if collapser == nil {
+		w.mu.Unlock()
+		return w.next.Write(p)
+	}
+	now := w.now()
+	storm := w.countErrorLocked(parsed.Level, now)
+	w.mu.Unlock()
+
+	if storm {
+		w.writeSummary(zerolog.WarnLevel, "Error storm, debug logging on for a while; repeated messages are collapsed", 0, now)
+	}
+
+	sample := parsed.Message
+	if parsed.Error != "" {
+		sample += ": " + parsed.Error
+	}
+	if !collapser.Allow(Key(parsed.Level, parsed.Message, parsed.Error), parsed.Level, sample, now) {
+		// Reported as written so zerolog does not log a write error.
+		return len(p), nil
+	}
+	return w.next.Write(p)
+}
+
+// countErrorLocked counts an error line and raises verbosity when errors
+// cross the threshold. It reports whether a storm just started.
+func (w *Writer) countErrorLocked(level string, now time.Time) bool {
+	if !w.verboseUntil.IsZero() && !now.Before(w.verboseUntil) {
+		w.restoreLevelLocked()
+	}
+	if level != zerolog.LevelErrorValue && level != zerolog.LevelFatalValue {
+		return false
+	}
+	if now.Sub(w.errorsStart) >= w.opts.Window {
+		w.errorsStart = now
+		w.errors = 0
+	}
+	w.errors++
+	if w.opts.Threshold <= 0 || w.errors != w.opts.Threshold || !w.verboseUntil.IsZero() {
+		return false
+	}
+
+	w.baseLevel = zerolog.GlobalLevel()
+	if w.baseLevel > zerolog.DebugLevel {
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	}
+	w.verboseUntil = now.Add(w.opts.VerboseFor)
+	return true
+}
+
+func (w *Writer) restoreLevelLocked() {
+	if w.verboseUntil.IsZero() {
+		return
+	}
+	zerolog.SetGlobalLevel(w.baseLevel)
+	w.verboseUntil = time.Time{}
+}
+
+// Run writes the summaries of collapsed messages and ends raised verbosity
+// on time until ctx is cancelled.
+func (w *Writer) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.Flush()
+			return
+		case <-ticker.C:
+			w.Flush()
+		}
+	}
+}
+
+// Flush writes a summary for every collapsed message whose window ended.
+func (w *Writer) Flush() {
+	w.mu.Lock()
+	now := w.now()
+	if !w.verboseUntil.IsZero() && !now.Before(w.verboseUntil) {
+		w.restoreLevelLocked()
+	}
+	collapser := w.collapser
+	w.mu.Unlock()
+
+	if collapser == nil {
+		return
+	}
+	for _, summary := range collapser.Flush(now) {
+		level, err := zerolog.ParseLevel(summary.Level)
+		if err != nil {
+			level = zerolog.WarnLevel
+		}
+		w.writeSummary(level, summary.Sample, summary.Suppressed, summary.Since)
+	}
+}
+
+func (w *Writer) writeSummary(level zerolog.Level, message string, repeated int, since time.Time) {
+	logger := zerolog.New(w.next)
+	event := logger.WithLevel(level).Timestamp()
+	if repeated > 0 {
+		event = event.Int("repeated", repeated).Time("since", since)
+	}
+	event.Msg(message)
+}
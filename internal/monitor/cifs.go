@@ -0,0 +1,174 @@
+package monitor
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+const defaultCIFSStatsPath = "/proc/fs/cifs/Stats"
+
+var (
+	cifsReconnectsRe = regexp.MustCompile(`^(\d+) session (\d+) share reconnects`)
+	cifsShareRe      = regexp.MustCompile(`^\d+\) (\\\\\S+)(.*)$`)
+	cifsSMBsRe       = regexp.MustCompile(`^SMBs: (\d+)`)
+	cifsBytesRe      = regexp.MustCompile(`^Bytes read: (\d+)\s+Bytes written: (\d+)`)
+	cifsFailedRe     = regexp.MustCompile(`(\d+) failed`)
+)
+
+// SetCIFSStatsPath overrides the kernel CIFS statistics file; "" disables
+// CIFS statistics.
+func (s *Service) SetCIFSStatsPath(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cifsStatsPath = path
+}
+
+// collectCIFSStats reads the kernel CIFS counters and derives per-share
+// request and byte rates from the previous sample. It returns nil when the
+// cifs module is not loaded.
+func (s *Service) collectCIFSStats(now time.Time) *models.CIFSStats {
+	s.mu.RLock()
+	path := s.cifsStatsPath
+	s.mu.RUnlock()
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	stats, err := parseCIFSStats(file)
+	if err != nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[string]struct{}, len(stats.Shares))
+	for i := range stats.Shares {
+		share := &stats.Shares[i]
+		seen[share.Target] = struct{}{}
+		if last, ok := s.lastCIFS[share.Target]; ok {
+			elapsed := now.Sub(last.at).Seconds()
+			if elapsed > 0 && uint64(share.Requests) >= last.bytes {
+				share.RequestsPerSec = float64(uint64(share.Requests)-last.bytes) / elapsed
+			}
+		}
+		s.lastCIFS[share.Target] = netSnapshot{bytes: uint64(share.Requests), at: now}
+
+		moved := uint64(share.BytesRead + share.BytesWritten)
+		if last, ok := s.lastCIFSBytes[share.Target]; ok {
+			elapsed := now.Sub(last.at).Seconds()
+			// A remount resets the counters; the share is left out until
+			// its next sample.
+			if elapsed > 0 && moved >= last.bytes {
+				share.BytesPerSec = float64(moved-last.bytes) / elapsed
+				stats.BytesPerSec += share.BytesPerSec
+				stats.Rated = true
+			}
+		}
+		s.lastCIFSBytes[share.Target] = netSnapshot{bytes: moved, at: now}
+	}
+	for target := range s.lastCIFS {
+		if _, ok := seen[target]; !ok {
+			delete(s.lastCIFS, target)
+			delete(s.lastCIFSBytes, target)
+		}
+	}
+
+	return &stats
+}
+
+// attributeNetwork narrows the network load to the traffic of the CIFS
+// mounts, the worker shares UCXSync reads from, so unrelated LAN chatter does
+// not count as sync load. The counters hold SMB payload, which exceeds the
+// bytes on the wire when SMB compression is on, so the sync share is capped
+// at the measured interface total. Without a CIFS rate the totals stand.
+func attributeNetwork(metrics *models.PerformanceMetrics, networkSpeedBps int64) {
+	total := metrics.NetworkBytesPerSec
+	metrics.NetworkTotalBytesPerSec = total
+	if metrics.CIFS == nil || !metrics.CIFS.Rated {
+		return
+	}
+
+	syncBytes := metrics.CIFS.BytesPerSec
+	if syncBytes > total {
+		syncBytes = total
+	}
+	metrics.NetworkAttributed = true
+	metrics.NetworkBytesPerSec = syncBytes
+	metrics.NetworkMBps = syncBytes / 1024.0 / 1024.0
+	metrics.NetworkPercent = networkPercent(syncBytes, networkSpeedBps)
+	metrics.NetworkOtherBytesPerSec = total - syncBytes
+}
+
+// parseCIFSStats parses /proc/fs/cifs/Stats. Every "N failed" counter of a
+// share is summed into FailedOps.
+func parseCIFSStats(r io.Reader) (models.CIFSStats, error) {
+	var stats models.CIFSStats
+	var current *models.CIFSShareStats
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if m := cifsReconnectsRe.FindStringSubmatch(line); m != nil {
+			stats.SessionReconnects, _ = strconv.ParseInt(m[1], 10, 64)
+			stats.ShareReconnects, _ = strconv.ParseInt(m[2], 10, 64)
+			continue
+		}
+
+		if m := cifsShareRe.FindStringSubmatch(line); m != nil {
+			stats.Shares = append(stats.Shares, newCIFSShareStats(m[1], m[2]))
+			current = &stats.Shares[len(stats.Shares)-1]
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if m := cifsSMBsRe.FindStringSubmatch(line); m != nil {
+			current.Requests, _ = strconv.ParseInt(m[1], 10, 64)
+		}
+		if m := cifsBytesRe.FindStringSubmatch(line); m != nil {
+			current.BytesRead, _ = strconv.ParseInt(m[1], 10, 64)
+			current.BytesWritten, _ = strconv.ParseInt(m[2], 10, 64)
+		}
+		for _, m := range cifsFailedRe.FindAllStringSubmatch(line, -1) {
+			failed, _ := strconv.ParseInt(m[1], 10, 64)
+			current.FailedOps += failed
+		}
+	}
+
+	return stats, scanner.Err()
+}
+
+// newCIFSShareStats splits a \\host\share target; hosts are UCX node names
+// since shares are mounted as //<node>/<share>.
+func newCIFSShareStats(target, rest string) models.CIFSShareStats {
+	share := models.CIFSShareStats{
+		Target:       target,
+		Disconnected: strings.Contains(strings.ToUpper(rest), "DISCONNECTED"),
+	}
+	parts := strings.SplitN(strings.TrimPrefix(target, `\\`), `\`, 2)
+	share.Node = strings.ToUpper(parts[0])
+	if len(parts) == 2 {
+		share.Share = parts[1]
+	}
+	return share
+}
@@ -0,0 +1,132 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+const sampleCIFSStats = `Resources in use
+CIFS Session: 2
+Share (unique mount targets): 3
+SMB Request/Response Buffer: 2 Pool size: 6
+SMB Small Req/Resp Buffer: 2 Pool size: 30
+Operations (MIDs): 0
+
+3 session 1 share reconnects
+Total vfs operations: 120 maximum at one time: 4
+
+Max requests in flight: 8
+1) \\WU01\E$
+SMBs: 1500
+Bytes read: 1048576  Bytes written: 0
+Open files: 2 total (local), 2 open on server
+TreeConnects: 1 total 0 failed
+Creates: 40 total 2 failed
+Reads: 300 total 1 failed
+Writes: 0 total 0 failed
+2) \\wu02\F$	DISCONNECTED 
+SMBs: 10
+Bytes read: 0  Bytes written: 0
+Creates: 3 total 3 failed
+`
+
+func TestParseCIFSStats(t *testing.T) {
+	t.Parallel()
+
+	stats, err := parseCIFSStats(strings.NewReader(sampleCIFSStats))
+	if err != nil {
+		t.Fatalf("parseCIFSStats returned error: %v", err)
+	}
+	if stats.SessionReconnects != 3 || stats.ShareReconnects != 1 {
+		t.Fatalf("reconnects = %d/%d, want 3/1", stats.SessionReconnects, stats.ShareReconnects)
+	}
+	if len(stats.Shares) != 2 {
+		t.Fatalf("len(Shares) = %d, want 2", len(stats.Shares))
+	}
+
+	first := stats.Shares[0]
+	if first.Node != "WU01" || first.Share != "E$" || first.Requests != 1500 || first.BytesRead != 1048576 || first.FailedOps != 3 || first.Disconnected {
+		t.Fatalf("first share = %+v", first)
+	}
+	second := stats.Shares[1]
+	if second.Node != "WU02" || second.Share != "F$" || !second.Disconnected || second.FailedOps != 3 {
+		t.Fatalf("second share = %+v", second)
+	}
+}
+
+func TestCollectCIFSStatsDerivesRequestRate(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "Stats")
+	write := func(smbs string) {
+		t.Helper()
+		body := "1) \\\\WU01\\E$\nSMBs: " + smbs + "\n"
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatalf("write stats: %v", err)
+		}
+	}
+
+	svc := New(time.Second, 1, 100, 1e9)
+	svc.SetCIFSStatsPath(path)
+	start := time.Now()
+
+	write("100")
+	if stats := svc.collectCIFSStats(start); stats == nil || stats.Shares[0].RequestsPerSec != 0 {
+		t.Fatalf("first sample = %+v, want zero rate", stats)
+	}
+	write("300")
+	stats := svc.collectCIFSStats(start.Add(2 * time.Second))
+	if stats == nil || stats.Shares[0].RequestsPerSec != 100 {
+		t.Fatalf("second sample = %+v, want 100 req/s", stats)
+	}
+
+	svc.SetCIFSStatsPath(filepath.Join(t.TempDir(), "missing"))
+	if stats := svc.collectCIFSStats(start); stats != nil {
+		t.Fatalf("missing stats file = %+v, want nil", stats)
+	}
+}
+
+func TestAttributeNetworkCountsOnlyCIFSTraffic(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "Stats")
+	write := func(read, written string) {
+		t.Helper()
+		body := "1) \\\\WU01\\E$\nSMBs: 1\nBytes read: " + read + "  Bytes written: " + written + "\n"
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatalf("write stats: %v", err)
+		}
+	}
+
+	svc := New(time.Second, 1, 100, 1e9)
+	svc.SetCIFSStatsPath(path)
+	start := time.Now()
+
+	write("0", "0")
+	metrics := models.PerformanceMetrics{NetworkBytesPerSec: 50e6, CIFS: svc.collectCIFSStats(start)}
+	attributeNetwork(&metrics, 1e9)
+	if metrics.NetworkAttributed || metrics.NetworkBytesPerSec != 50e6 || metrics.NetworkTotalBytesPerSec != 50e6 {
+		t.Fatalf("first sample = %+v, want the interface total unattributed", metrics)
+	}
+
+	// 20 MB/s of the 50 MB/s on the wire are share reads.
+	write("30000000", "10000000")
+	metrics = models.PerformanceMetrics{NetworkBytesPerSec: 50e6, CIFS: svc.collectCIFSStats(start.Add(2 * time.Second))}
+	attributeNetwork(&metrics, 1e9)
+	if !metrics.NetworkAttributed || metrics.NetworkBytesPerSec != 20e6 || metrics.NetworkOtherBytesPerSec != 30e6 || metrics.NetworkPercent != 16 {
+		t.Fatalf("attributed metrics = %+v, want 20 MB/s sync, 30 MB/s other, 16%%", metrics)
+	}
+
+	// Compressed SMB payload exceeds the wire: sync gets the whole wire.
+	write("130000000", "10000000")
+	metrics = models.PerformanceMetrics{NetworkBytesPerSec: 10e6, CIFS: svc.collectCIFSStats(start.Add(4 * time.Second))}
+	attributeNetwork(&metrics, 1e9)
+	if metrics.NetworkBytesPerSec != 10e6 || metrics.NetworkOtherBytesPerSec != 0 || metrics.CIFS.BytesPerSec != 50e6 {
+		t.Fatalf("compressed metrics = %+v, want the sync share capped at 10 MB/s", metrics)
+	}
+}
@@ -35,6 +35,11 @@ type Service struct {
 	lastDiskTime   time.Time
 	lastDiskBytes  uint64
 	targetDiskPath string
+	cifsStatsPath  string
+	lastCIFS       map[string]netSnapshot // share target -> SMB request count
+	lastCIFSBytes  map[string]netSnapshot // share target -> SMB bytes read and written
+
+	intervalChanged chan struct{}
 }
 
 // New creates a new monitoring service
@@ -46,9 +51,41 @@ func New(updateInterval time.Duration, cpuSamples int, maxDiskMBps float64, netw
 		networkSpeedBps:     networkSpeedBps,
 		cpuReadings:         make([]float64, 0, cpuSamples),
 		lastInterface:       make(map[string]netSnapshot),
+		cifsStatsPath:       defaultCIFSStatsPath,
+		lastCIFS:            make(map[string]netSnapshot),
+		lastCIFSBytes:       make(map[string]netSnapshot),
+		intervalChanged:     make(chan struct{}, 1),
 	}
 }
 
+// SetUpdateInterval changes how often metrics are collected, taking effect
+// on a running monitor at once.
+func (s *Service) SetUpdateInterval(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	changed := s.updateInterval != interval
+	s.updateInterval = interval
+	s.mu.Unlock()
+
+	if changed {
+		select {
+		case s.intervalChanged <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// UpdateInterval returns how often metrics are collected.
+func (s *Service) UpdateInterval() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.updateInterval
+}
+
 // SetTargetDisk sets the disk to monitor
 func (s *Service) SetTargetDisk(path string) {
 	s.mu.Lock()
@@ -63,13 +100,15 @@ func (s *Service) Start(ctx context.Context) <-chan models.PerformanceMetrics {
 	go func() {
 		defer close(metricsChan)
 
-		ticker := time.NewTicker(s.updateInterval)
+		ticker := time.NewTicker(s.UpdateInterval())
 		defer ticker.Stop()
 
 		for {
 			select {
 			case <-ctx.Done():
 				return
+			case <-s.intervalChanged:
+				ticker.Reset(s.UpdateInterval())
 			case <-ticker.C:
 				metrics := s.collectMetrics()
 				select {
@@ -233,6 +272,9 @@ func (s *Service) collectMetrics() models.PerformanceMetrics {
 		metrics.NetworkInterfaces = interfaceMetrics
 	}
 
+	metrics.CIFS = s.collectCIFSStats(time.Now())
+	attributeNetwork(&metrics, s.networkSpeedBps)
+
 	return metrics
 }
 
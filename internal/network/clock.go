@@ -0,0 +1,184 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+const (
+	smbPort = "445"
+	ntpPort = "123"
+
+	// Seconds between the FILETIME (1601) and Unix epochs.
+	fileTimeEpochOffset = 11644473600
+	// Seconds between the NTP (1900) and Unix epochs.
+	ntpEpochOffset = 2208988800
+)
+
+// ClockReading is a node's clock as seen from this machine.
+type ClockReading struct {
+	Node   string
+	Method string        // "smb" or "ntp"
+	Skew   time.Duration // Node clock minus local clock
+	RTT    time.Duration
+}
+
+// QueryNodeClock reads node's clock from the SMB negotiate response, falling
+// back to SNTP when the SMB port does not answer.
+func QueryNodeClock(ctx context.Context, node string, timeout time.Duration) (ClockReading, error) {
+	reading, smbErr := querySMBClock(ctx, net.JoinHostPort(node, smbPort), timeout)
+	if smbErr == nil {
+		reading.Node = node
+		return reading, nil
+	}
+
+	reading, ntpErr := queryNTPClock(ctx, net.JoinHostPort(node, ntpPort), timeout)
+	if ntpErr == nil {
+		reading.Node = node
+		return reading, nil
+	}
+
+	return ClockReading{Node: node}, fmt.Errorf("smb: %v; ntp: %v", smbErr, ntpErr)
+}
+
+// smbNegotiateRequest offers SMB1 and SMB2 dialects in a single SMB1
+// NEGOTIATE so both old and current Windows nodes answer with their time.
+func smbNegotiateRequest() []byte {
+	var dialects bytes.Buffer
+	for _, dialect := range []string{"NT LM 0.12", "SMB 2.002", "SMB 2.???"} {
+		dialects.WriteByte(0x02)
+		dialects.WriteString(dialect)
+		dialects.WriteByte(0)
+	}
+
+	var smb bytes.Buffer
+	smb.WriteString("\xffSMB")
+	smb.WriteByte(0x72)                                     // NEGOTIATE
+	smb.Write(make([]byte, 4))                              // Status
+	smb.WriteByte(0x18)                                     // Flags: canonical paths, case-insensitive
+	binary.Write(&smb, binary.LittleEndian, uint16(0xc801)) // Flags2: unicode, NT status, long names
+	smb.Write(make([]byte, 12))                             // PIDHigh, SecurityFeatures, Reserved
+	binary.Write(&smb, binary.LittleEndian, uint16(0xffff)) // TID
+	smb.Write(make([]byte, 6))                              // PIDLow, UID, MID
+	smb.WriteByte(0)                                        // WordCount
+	binary.Write(&smb, binary.LittleEndian, uint16(dialects.Len()))
+	smb.Write(dialects.Bytes())
+
+	packet := make([]byte, 4, 4+smb.Len())
+	binary.BigEndian.PutUint32(packet, uint32(smb.Len())) // NetBIOS session message
+	return append(packet, smb.Bytes()...)
+}
+
+func querySMBClock(ctx context.Context, addr string, timeout time.Duration) (ClockReading, error) {
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return ClockReading{}, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	sent := time.Now()
+	if _, err := conn.Write(smbNegotiateRequest()); err != nil {
+		return ClockReading{}, err
+	}
+
+	var header [4]byte
+	if _, err := io.ReadFull(conn, header[:]); err != nil {
+		return ClockReading{}, err
+	}
+	length := binary.BigEndian.Uint32(header[:]) & 0x00ffffff
+	if length > 64*1024 {
+		return ClockReading{}, fmt.Errorf("negotiate response too large: %d bytes", length)
+	}
+	response := make([]byte, length)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return ClockReading{}, err
+	}
+	received := time.Now()
+
+	serverTime, err := parseSMBNegotiateTime(response)
+	if err != nil {
+		return ClockReading{}, err
+	}
+	return newClockReading("smb", serverTime, sent, received), nil
+}
+
+// parseSMBNegotiateTime extracts SystemTime from an SMB1 (NT LM 0.12) or
+// SMB2 NEGOTIATE response.
+func parseSMBNegotiateTime(response []byte) (time.Time, error) {
+	var offset int
+	switch {
+	case bytes.HasPrefix(response, []byte("\xfeSMB")):
+		// 64-byte header, then SystemTime 40 bytes into the response body.
+		offset = 64 + 40
+	case bytes.HasPrefix(response, []byte("\xffSMB")):
+		// 32-byte header, WordCount, then SystemTime 23 bytes into the words.
+		if len(response) < 33 || response[32] < 17 {
+			return time.Time{}, fmt.Errorf("server did not accept the NT LM 0.12 dialect")
+		}
+		offset = 32 + 1 + 23
+	default:
+		return time.Time{}, fmt.Errorf("not an SMB response")
+	}
+	if len(response) < offset+8 {
+		return time.Time{}, fmt.Errorf("negotiate response truncated")
+	}
+
+	fileTime := binary.LittleEndian.Uint64(response[offset : offset+8])
+	if fileTime == 0 {
+		return time.Time{}, fmt.Errorf("server did not report its time")
+	}
+	seconds := int64(fileTime/10000000) - fileTimeEpochOffset
+	nanos := int64(fileTime%10000000) * 100
+	return time.Unix(seconds, nanos), nil
+}
+
+func queryNTPClock(ctx context.Context, addr string, timeout time.Duration) (ClockReading, error) {
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return ClockReading{}, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	request := make([]byte, 48)
+	request[0] = 0x1b // LI 0, version 3, client mode
+	sent := time.Now()
+	if _, err := conn.Write(request); err != nil {
+		return ClockReading{}, err
+	}
+
+	response := make([]byte, 48)
+	n, err := conn.Read(response)
+	if err != nil {
+		return ClockReading{}, err
+	}
+	received := time.Now()
+	if n < 48 {
+		return ClockReading{}, fmt.Errorf("ntp response truncated")
+	}
+
+	seconds := binary.BigEndian.Uint32(response[40:44])
+	fraction := binary.BigEndian.Uint32(response[44:48])
+	if seconds == 0 {
+		return ClockReading{}, fmt.Errorf("ntp server did not report its time")
+	}
+	nanos := (int64(fraction) * int64(time.Second)) >> 32
+	serverTime := time.Unix(int64(seconds)-ntpEpochOffset, nanos)
+	return newClockReading("ntp", serverTime, sent, received), nil
+}
+
+// newClockReading compares serverTime with the local midpoint of the
+// request, which cancels symmetric network delay.
+func newClockReading(method string, serverTime, sent, received time.Time) ClockReading {
+	rtt := received.Sub(sent)
+	midpoint := sent.Add(rtt / 2)
+	return ClockReading{Method: method, Skew: serverTime.Sub(midpoint), RTT: rtt}
+}
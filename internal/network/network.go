@@ -11,30 +11,48 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// defaultCredentialsPath is the mount.cifs credentials file the shares are
+// mounted with.
+const defaultCredentialsPath = "/etc/ucxsync/credentials"
+
 // Service manages network share mounting on Linux
 type Service struct {
-	nodes        []string
-	shares       []string
-	username     string
-	password     string
-	baseMountDir string
-	mountOptions []string
+	nodes           []string
+	shares          []string
+	nodeShares      map[string][]string // Per-node overrides of shares
+	username        string
+	password        string
+	baseMountDir    string
+	mountOptions    []string
+	credentialsPath string
 
 	mu      sync.Mutex
 	mounted map[string]bool // track mounted shares
+
+	rotateMu sync.Mutex // One credential rotation at a time
+
+	// Seams for tests; default to mount and umount -l.
+	mountFunc   func(uncPath, mountPoint, credFile string) error
+	detachFunc  func(mountPoint string) error
+	mountedFunc func(mountPoint string) bool
 }
 
 // New creates a new network service
 func New(nodes, shares []string, username, password string) *Service {
-	return &Service{
-		nodes:        nodes,
-		shares:       shares,
-		username:     username,
-		password:     password,
-		baseMountDir: "/ucmount",
-		mountOptions: nil,
-		mounted:      make(map[string]bool),
+	s := &Service{
+		nodes:           nodes,
+		shares:          shares,
+		username:        username,
+		password:        password,
+		baseMountDir:    "/ucmount",
+		mountOptions:    nil,
+		credentialsPath: defaultCredentialsPath,
+		mounted:         make(map[string]bool),
 	}
+	s.mountFunc = s.mountShare
+	s.detachFunc = detachShare
+	s.mountedFunc = s.isMounted
+	return s
 }
 
 // SetBaseMountDir sets the base directory for mounts
@@ -51,6 +69,27 @@ func (s *Service) SetMountOptions(options []string) {
 	s.mountOptions = append([]string(nil), options...)
 }
 
+// SetNodeShares sets the shares mounted on individual nodes. Nodes left out
+// mount the shares passed to New.
+func (s *Service) SetNodeShares(nodeShares map[string][]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodeShares = make(map[string][]string, len(nodeShares))
+	for node, shares := range nodeShares {
+		s.nodeShares[node] = append([]string(nil), shares...)
+	}
+}
+
+// sharesFor returns the shares mounted on node.
+func (s *Service) sharesFor(node string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if shares, ok := s.nodeShares[node]; ok {
+		return shares
+	}
+	return s.shares
+}
+
 // MountAll mounts all network shares
 func (s *Service) MountAll() error {
 	log.Info().Msg("Mounting network shares...")
@@ -61,7 +100,7 @@ func (s *Service) MountAll() error {
 	}
 
 	// Create credentials file
-	credFile := "/etc/ucxsync/credentials"
+	credFile := s.credentialsPath
 	if err := s.createCredentialsFile(credFile); err != nil {
 		log.Warn().Err(err).Msg("Failed to create credentials file, will use inline credentials")
 		credFile = ""
@@ -69,9 +108,12 @@ func (s *Service) MountAll() error {
 
 	var errors []string
 	mounted := 0
+	total := 0
 
 	for _, node := range s.nodes {
-		for _, share := range s.shares {
+		shares := s.sharesFor(node)
+		total += len(shares)
+		for _, share := range shares {
 			// Share name for mount point (without $)
 			shareNameClean := strings.TrimSuffix(share, "$")
 
@@ -117,7 +159,7 @@ func (s *Service) MountAll() error {
 
 	log.Info().
 		Int("mounted", mounted).
-		Int("total", len(s.nodes)*len(s.shares)).
+		Int("total", total).
 		Msg("Network share mounting completed")
 
 	if len(errors) > 0 {
@@ -262,8 +304,17 @@ func (s *Service) createCredentialsFile(path string) error {
 	}
 
 	// Write credentials file
+	s.mu.Lock()
 	content := fmt.Sprintf("username=%s\npassword=%s\n", s.username, s.password)
-	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+	s.mu.Unlock()
+	// Written aside and renamed so a share mounting meanwhile never reads
+	// half a file.
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(content), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
 		return err
 	}
 
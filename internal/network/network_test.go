@@ -1,8 +1,16 @@
 package network
 
 import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestBuildMountOptionsAddsDefaultSMBVersionWhenNotProvided(t *testing.T) {
@@ -38,3 +46,176 @@ func TestBuildMountOptionsKeepsExplicitVersion(t *testing.T) {
 		t.Fatalf("expected explicit vers=2.0 to be preserved, got %v", opts)
 	}
 }
+
+func TestParseSMBNegotiateTimeReadsSMB2SystemTime(t *testing.T) {
+	want := time.Date(2024, 5, 17, 10, 30, 15, 123456700, time.UTC)
+	response := make([]byte, 64+65)
+	copy(response, "\xfeSMB")
+	binary.LittleEndian.PutUint64(response[64+40:], toFileTime(want))
+
+	got, err := parseSMBNegotiateTime(response)
+	if err != nil {
+		t.Fatalf("parseSMBNegotiateTime() error = %v", err)
+	}
+	if !got.Equal(want) {
+		t.Fatalf("parseSMBNegotiateTime() = %v, want %v", got, want)
+	}
+}
+
+func TestParseSMBNegotiateTimeRejectsSMB1WithoutNTDialect(t *testing.T) {
+	response := make([]byte, 40)
+	copy(response, "\xffSMB")
+	response[32] = 1 // WordCount of a "no dialect accepted" answer
+
+	if _, err := parseSMBNegotiateTime(response); err == nil {
+		t.Fatal("expected an error for a core-dialect response")
+	}
+}
+
+func TestQuerySMBClockReportsSkew(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	const skew = 90 * time.Second
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var header [4]byte
+		if _, err := io.ReadFull(conn, header[:]); err != nil {
+			return
+		}
+		io.CopyN(io.Discard, conn, int64(binary.BigEndian.Uint32(header[:])))
+
+		response := make([]byte, 4+32+1+34)
+		binary.BigEndian.PutUint32(response, uint32(len(response)-4))
+		copy(response[4:], "\xffSMB")
+		response[4+32] = 17
+		binary.LittleEndian.PutUint64(response[4+32+1+23:], toFileTime(time.Now().Add(skew)))
+		conn.Write(response)
+	}()
+
+	reading, err := querySMBClock(context.Background(), listener.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatalf("querySMBClock() error = %v", err)
+	}
+	if reading.Method != "smb" {
+		t.Fatalf("Method = %q, want smb", reading.Method)
+	}
+	if diff := reading.Skew - skew; diff < -time.Second || diff > time.Second {
+		t.Fatalf("Skew = %v, want about %v", reading.Skew, skew)
+	}
+}
+
+func TestNewClockReadingUsesRoundTripMidpoint(t *testing.T) {
+	sent := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	received := sent.Add(200 * time.Millisecond)
+	serverTime := sent.Add(100*time.Millisecond + 5*time.Second)
+
+	reading := newClockReading("ntp", serverTime, sent, received)
+	if reading.Skew != 5*time.Second {
+		t.Fatalf("Skew = %v, want 5s", reading.Skew)
+	}
+	if reading.RTT != 200*time.Millisecond {
+		t.Fatalf("RTT = %v, want 200ms", reading.RTT)
+	}
+}
+
+func toFileTime(t time.Time) uint64 {
+	return uint64(t.UnixNano()/100) + fileTimeEpochOffset*10000000
+}
+
+// fakeMounts records remounts and accepts only the password in want.
+type fakeMounts struct {
+	svc      *Service
+	want     string
+	detached []string
+	mounted  []string
+	attached map[string]bool
+}
+
+func newRotationService(t *testing.T, want string) (*Service, *fakeMounts) {
+	t.Helper()
+
+	svc := New([]string{"WU01", "WU02"}, []string{"E$"}, "user", "old")
+	svc.credentialsPath = filepath.Join(t.TempDir(), "credentials")
+	svc.mounted["WU01/E$"] = true
+	svc.mounted["WU02/E$"] = true
+	fake := &fakeMounts{svc: svc, want: want, attached: map[string]bool{
+		svc.GetMountPoint("WU01", "E$"): true,
+		svc.GetMountPoint("WU02", "E$"): true,
+	}}
+	svc.mountedFunc = func(mountPoint string) bool { return fake.attached[mountPoint] }
+	svc.detachFunc = func(mountPoint string) error {
+		fake.detached = append(fake.detached, mountPoint)
+		fake.attached[mountPoint] = false
+		return nil
+	}
+	svc.mountFunc = func(uncPath, mountPoint, credFile string) error {
+		data, err := os.ReadFile(credFile)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(string(data), "password="+fake.want+"\n") {
+			return errors.New("permission denied")
+		}
+		fake.mounted = append(fake.mounted, uncPath)
+		fake.attached[mountPoint] = true
+		return nil
+	}
+	return svc, fake
+}
+
+func TestRotateCredentialsRemountsSharesOneByOne(t *testing.T) {
+	t.Parallel()
+
+	svc, fake := newRotationService(t, "new")
+	results, err := svc.RotateCredentials("old", "", "new")
+	if err != nil {
+		t.Fatalf("RotateCredentials() error = %v", err)
+	}
+	if len(results) != 2 || results[0].Err != nil || results[1].Err != nil {
+		t.Fatalf("results = %+v, want both shares remounted", results)
+	}
+	if want := []string{"//WU01/E$", "//WU02/E$"}; strings.Join(fake.mounted, " ") != strings.Join(want, " ") {
+		t.Fatalf("mounted = %v, want %v in order", fake.mounted, want)
+	}
+	data, err := os.ReadFile(svc.credentialsPath)
+	if err != nil || string(data) != "username=user\npassword=new\n" {
+		t.Fatalf("credentials file = %q, %v", data, err)
+	}
+}
+
+func TestRotateCredentialsRejectsWrongCurrentPassword(t *testing.T) {
+	t.Parallel()
+
+	svc, fake := newRotationService(t, "new")
+	if _, err := svc.RotateCredentials("guess", "", "new"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("RotateCredentials() error = %v, want ErrWrongPassword", err)
+	}
+	if len(fake.detached) != 0 {
+		t.Fatalf("detached %v after a rejected rotation", fake.detached)
+	}
+}
+
+func TestRotateCredentialsKeepsOldOnesWhenFirstShareRefuses(t *testing.T) {
+	t.Parallel()
+
+	// The shares still only take the old password.
+	svc, fake := newRotationService(t, "old")
+	if _, err := svc.RotateCredentials("old", "", "typo"); err == nil {
+		t.Fatal("RotateCredentials() succeeded although the share refused the new password")
+	}
+	if len(fake.detached) != 1 || len(fake.mounted) != 1 || fake.mounted[0] != "//WU01/E$" {
+		t.Fatalf("detached %v, mounted %v, want only the first share remounted with the old password", fake.detached, fake.mounted)
+	}
+	if _, err := svc.RotateCredentials("typo", "", "new"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("RotateCredentials() error = %v, want the failed password not taken", err)
+	}
+}
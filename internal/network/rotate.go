@@ -0,0 +1,127 @@
+package network
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrWrongPassword is returned when a credential rotation does not present
+// the password the shares are mounted with.
+var ErrWrongPassword = errors.New("current password does not match")
+
+// RemountResult is how one share took new credentials.
+type RemountResult struct {
+	Node  string
+	Share string
+	Err   error
+}
+
+// RotateCredentials replaces the credentials the shares are mounted with.
+// current must be the password in use. The credentials file is rewritten
+// and the mounted shares are remounted one at a time, so the others keep
+// serving the sync meanwhile; a copy already reading from a share finishes
+// on the detached old mount. If the first share refuses the new credentials
+// the old ones are restored and nothing else is touched. An empty username
+// keeps the current one.
+func (s *Service) RotateCredentials(current, username, password string) ([]RemountResult, error) {
+	if password == "" {
+		return nil, fmt.Errorf("new password must not be empty")
+	}
+
+	s.rotateMu.Lock()
+	defer s.rotateMu.Unlock()
+
+	s.mu.Lock()
+	if subtle.ConstantTimeCompare([]byte(current), []byte(s.password)) != 1 {
+		s.mu.Unlock()
+		return nil, ErrWrongPassword
+	}
+	if username == "" {
+		username = s.username
+	}
+	oldUsername, oldPassword := s.username, s.password
+	s.username, s.password = username, password
+	keys := make([]string, 0, len(s.mounted))
+	for key, mounted := range s.mounted {
+		if mounted {
+			keys = append(keys, key)
+		}
+	}
+	s.mu.Unlock()
+	sort.Strings(keys)
+
+	restore := func() {
+		s.mu.Lock()
+		s.username, s.password = oldUsername, oldPassword
+		s.mu.Unlock()
+		if err := s.createCredentialsFile(s.credentialsPath); err != nil {
+			log.Error().Err(err).Msg("Failed to restore credentials file")
+		}
+	}
+
+	if err := s.createCredentialsFile(s.credentialsPath); err != nil {
+		restore()
+		return nil, fmt.Errorf("failed to write credentials file: %w", err)
+	}
+
+	results := make([]RemountResult, 0, len(keys))
+	for i, key := range keys {
+		node, share, _ := strings.Cut(key, "/")
+		err := s.remount(node, share)
+		if err != nil && i == 0 {
+			restore()
+			if restoreErr := s.remount(node, share); restoreErr != nil {
+				log.Error().Err(restoreErr).Str("node", node).Str("share", share).Msg("Failed to remount share with the old credentials")
+			}
+			return nil, fmt.Errorf("%s refused the new credentials, old ones kept: %w", key, err)
+		}
+		if err != nil {
+			log.Warn().Err(err).Str("node", node).Str("share", share).Msg("Failed to remount share with new credentials")
+		} else {
+			log.Info().Str("node", node).Str("share", share).Msg("Share remounted with new credentials")
+		}
+		results = append(results, RemountResult{Node: node, Share: share, Err: err})
+	}
+
+	return results, nil
+}
+
+// remount detaches a share and mounts it again with the credentials file.
+// A share left unmounted is dropped from the mounted set so MountAll tries
+// it again.
+func (s *Service) remount(node, share string) error {
+	mountPoint := s.GetMountPoint(node, share)
+	if s.mountedFunc(mountPoint) {
+		if err := s.detachFunc(mountPoint); err != nil {
+			return err
+		}
+	}
+
+	key := fmt.Sprintf("%s/%s", node, share)
+	err := s.mountFunc(fmt.Sprintf("//%s/%s", node, share), mountPoint, s.credentialsPath)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		delete(s.mounted, key)
+		return err
+	}
+	s.mounted[key] = true
+	return nil
+}
+
+// detachShare lazily unmounts mountPoint: it disappears at once, files open
+// on it stay readable until closed.
+func detachShare(mountPoint string) error {
+	output, err := exec.Command("umount", "-l", mountPoint).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("unmount failed: %w (output: %s)", err, string(output))
+	}
+	return nil
+}
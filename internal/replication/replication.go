@@ -0,0 +1,387 @@
+// Package replication lets one UCXSync instance mirror the destination of
+// another over the LAN. The primary serves a manifest and the files of its
+// destination over authenticated HTTP; a Mirror on the second laptop polls the
+// manifest and downloads whatever is new or changed.
+package replication
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	syncservice "github.com/zangezia/UCXSync/internal/sync"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// Endpoints served by the primary.
+const (
+	ManifestPath = "/api/replication/manifest"
+	FilePath     = "/api/replication/file"
+)
+
+// tempSuffix marks a download in progress on the mirror. The manifest skips
+// it and the other temporary files UCXSync writes.
+const tempSuffix = ".ucxmirror"
+
+var skippedSuffixes = []string{tempSuffix, ".tmp"}
+
+// File is one file of the primary's destination.
+type File struct {
+	Path    string    `json:"path"` // Slash-separated, relative to the destination
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// Manifest lists every file the primary currently holds.
+type Manifest struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Files       []File    `json:"files"`
+}
+
+// BuildManifest lists the files under root, skipping hidden and temporary
+// files. A missing root yields an empty manifest.
+func BuildManifest(root string) (Manifest, error) {
+	manifest := Manifest{GeneratedAt: time.Now().UTC(), Files: []File{}}
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if path != root && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() || SkippedFile(info.Name()) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, File{
+			Path:    filepath.ToSlash(rel),
+			Size:    info.Size(),
+			ModTime: info.ModTime().UTC(),
+		})
+		return nil
+	})
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+	return manifest, nil
+}
+
+// SkippedFile reports whether name is a temporary file UCXSync or a mirror is
+// still writing.
+func SkippedFile(name string) bool {
+	if syncservice.IsTempFile(name) {
+		return true
+	}
+	for _, suffix := range skippedSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolvePath maps a manifest path onto root, rejecting paths that would
+// leave it.
+func ResolvePath(root, rel string) (string, error) {
+	if rel == "" || strings.HasPrefix(rel, "/") || strings.Contains(rel, "\\") {
+		return "", fmt.Errorf("invalid path %q", rel)
+	}
+	cleaned := filepath.Clean(filepath.FromSlash(rel))
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid path %q", rel)
+	}
+	return filepath.Join(root, cleaned), nil
+}
+
+// Authorized reports whether r carries the bearer token. An empty token
+// never authorizes.
+func Authorized(r *http.Request, token string) bool {
+	const prefix = "Bearer "
+	header := r.Header.Get("Authorization")
+	if token == "" || !strings.HasPrefix(header, prefix) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(header, prefix)), []byte(token)) == 1
+}
+
+// Options configures a Mirror.
+type Options struct {
+	PeerURL     string        // Base URL of the primary, e.g. http://ucxsync-a:8080
+	Token       string        // Bearer token of the primary's replication.serve
+	Destination string        // Local directory holding the mirror
+	Interval    time.Duration // Delay between manifest polls
+	Client      *http.Client
+}
+
+// Mirror keeps Destination in step with the primary. Files are only added or
+// replaced, never deleted, so a wiped primary disk cannot empty the mirror.
+// The one exception are half-written copies older primaries published.
+type Mirror struct {
+	opts Options
+
+	mu     sync.Mutex
+	status models.ReplicationStatus
+}
+
+// NewMirror creates a mirror; call Run to start polling.
+func NewMirror(opts Options) *Mirror {
+	if opts.Client == nil {
+		opts.Client = &http.Client{}
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	opts.PeerURL = strings.TrimRight(opts.PeerURL, "/")
+
+	return &Mirror{
+		opts: opts,
+		status: models.ReplicationStatus{
+			PeerURL:     opts.PeerURL,
+			Destination: opts.Destination,
+		},
+	}
+}
+
+// Run polls the primary until ctx is cancelled.
+func (m *Mirror) Run(ctx context.Context) {
+	log.Info().Str("peer", m.opts.PeerURL).Str("destination", m.opts.Destination).Msg("Mirroring peer sync kit")
+
+	for {
+		if err := m.SyncOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Warn().Err(err).Str("peer", m.opts.PeerURL).Msg("Mirror pass failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(m.opts.Interval):
+		}
+	}
+}
+
+// Status returns the progress of the current or last mirror pass.
+func (m *Mirror) Status() models.ReplicationStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.status
+}
+
+func (m *Mirror) update(fn func(*models.ReplicationStatus)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fn(&m.status)
+}
+
+// SyncOnce fetches the manifest and downloads every file that is missing or
+// differs in size or mtime.
+func (m *Mirror) SyncOnce(ctx context.Context) error {
+	m.update(func(status *models.ReplicationStatus) {
+		status.Running = true
+		status.PendingFiles = 0
+	})
+
+	copied, bytes, err := m.syncOnce(ctx)
+
+	now := time.Now().UTC()
+	m.update(func(status *models.ReplicationStatus) {
+		status.Running = false
+		status.LastPassAt = &now
+		status.CopiedFiles += copied
+		status.CopiedBytes += bytes
+		status.LastError = ""
+		if err != nil {
+			status.LastError = err.Error()
+		}
+	})
+	if copied > 0 {
+		log.Info().Int("files", copied).Int64("bytes", bytes).Str("peer", m.opts.PeerURL).Msg("Mirrored files from peer")
+	}
+	return err
+}
+
+func (m *Mirror) syncOnce(ctx context.Context) (int, int64, error) {
+	manifest, err := m.fetchManifest(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	m.removeTempFiles()
+
+	var pending []File
+	for _, file := range manifest.Files {
+		local, err := ResolvePath(m.opts.Destination, file.Path)
+		if err != nil {
+			return 0, 0, fmt.Errorf("manifest: %w", err)
+		}
+		if info, err := os.Stat(local); err == nil && info.Size() == file.Size && info.ModTime().Equal(file.ModTime) {
+			continue
+		}
+		pending = append(pending, file)
+	}
+	m.update(func(status *models.ReplicationStatus) {
+		status.ManifestFiles = len(manifest.Files)
+		status.PendingFiles = len(pending)
+	})
+
+	copied, failed := 0, 0
+	var bytes int64
+	var firstErr error
+	for _, file := range pending {
+		if err := ctx.Err(); err != nil {
+			return copied, bytes, err
+		}
+		if err := m.download(ctx, file); err != nil {
+			// One file still being written on the primary must not hold up
+			// the rest; it is retried on the next pass.
+			log.Debug().Err(err).Str("path", file.Path).Msg("Failed to mirror file")
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", file.Path, err)
+			}
+			failed++
+			continue
+		}
+		copied++
+		bytes += file.Size
+		m.update(func(status *models.ReplicationStatus) { status.PendingFiles-- })
+	}
+	if failed > 0 {
+		return copied, bytes, fmt.Errorf("%d files failed, first: %w", failed, firstErr)
+	}
+	return copied, bytes, nil
+}
+
+// removeTempFiles deletes the files a primary was still writing when it
+// listed them, mirrored before manifests left them out. The primary renames
+// them once complete, and the finished file is mirrored under its real name.
+func (m *Mirror) removeTempFiles() {
+	filepath.Walk(m.opts.Destination, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() || !syncservice.IsTempFile(info.Name()) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			log.Debug().Err(err).Str("path", path).Msg("Failed to remove mirrored temporary file")
+		}
+		return nil
+	})
+}
+
+func (m *Mirror) fetchManifest(ctx context.Context) (Manifest, error) {
+	req, err := m.newRequest(ctx, ManifestPath)
+	if err != nil {
+		return Manifest{}, err
+	}
+	resp, err := m.opts.Client.Do(req)
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Manifest{}, fmt.Errorf("manifest request answered %s", resp.Status)
+	}
+	var manifest Manifest
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return Manifest{}, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// download fetches file into a temporary file next to its final path,
+// resuming a partial download left by an earlier pass.
+func (m *Mirror) download(ctx context.Context, file File) error {
+	local, err := ResolvePath(m.opts.Destination, file.Path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+		return err
+	}
+
+	tmp := local + tempSuffix
+	var offset int64
+	if info, err := os.Stat(tmp); err == nil && info.Size() < file.Size {
+		offset = info.Size()
+	}
+
+	req, err := m.newRequest(ctx, FilePath+"?path="+url.QueryEscape(file.Path))
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		// If-Range makes the primary send the whole file again when it has
+		// changed since the partial download.
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", file.ModTime.UTC().Format(http.TimeFormat))
+	}
+	resp, err := m.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		flags = os.O_WRONLY | os.O_APPEND
+	case resp.StatusCode == http.StatusOK:
+		offset = 0
+	default:
+		return fmt.Errorf("file request answered %s", resp.Status)
+	}
+
+	dst, err := os.OpenFile(tmp, flags, 0644)
+	if err != nil {
+		return err
+	}
+	written, err := io.Copy(dst, resp.Body)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if offset+written != file.Size {
+		// The primary is still writing the file; the next pass picks it up.
+		os.Remove(tmp)
+		return fmt.Errorf("size changed during download: got %d bytes, manifest says %d", offset+written, file.Size)
+	}
+
+	if err := os.Chtimes(tmp, file.ModTime, file.ModTime); err != nil {
+		return err
+	}
+	return os.Rename(tmp, local)
+}
+
+func (m *Mirror) newRequest(ctx context.Context, path string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.opts.PeerURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+m.opts.Token)
+	return req, nil
+}
@@ -0,0 +1,106 @@
+package replication
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolvePathRejectsEscapes(t *testing.T) {
+	for _, rel := range []string{"", "/etc/passwd", "..", "../x", "a/../../x", `a\b`} {
+		if _, err := ResolvePath("/data", rel); err == nil {
+			t.Errorf("ResolvePath(%q) accepted a path outside the root", rel)
+		}
+	}
+
+	got, err := ResolvePath("/data", "2024-05-17/Proj/a.raw")
+	if err != nil || got != filepath.Join("/data", "2024-05-17", "Proj", "a.raw") {
+		t.Fatalf("ResolvePath() = %q, %v", got, err)
+	}
+}
+
+func TestBuildManifestSkipsFilesStillBeingWritten(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "2024-05-17", "Proj")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for _, name := range []string{"a.raw", "b.raw.ucxtmp", "c.raw.ucxexport", "d.raw.spotcheck", "e.raw" + tempSuffix} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("data"), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	manifest, err := BuildManifest(root)
+	if err != nil {
+		t.Fatalf("BuildManifest() error = %v", err)
+	}
+	if len(manifest.Files) != 1 || manifest.Files[0].Path != "2024-05-17/Proj/a.raw" {
+		t.Fatalf("manifest = %+v, want only the finished a.raw", manifest.Files)
+	}
+}
+
+func TestMirrorResumesPartialDownload(t *testing.T) {
+	content := strings.Repeat("0123456789", 100)
+	modTime := time.Date(2024, 5, 17, 10, 0, 0, 0, time.UTC)
+
+	var ranges []string
+	mux := http.NewServeMux()
+	mux.HandleFunc(ManifestPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"files":[{"path":"Proj/a.raw","size":1000,"mod_time":"2024-05-17T10:00:00Z"}]}`))
+	})
+	mux.HandleFunc(FilePath, func(w http.ResponseWriter, r *http.Request) {
+		if !Authorized(r, "secret-token-0123") {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "a.raw", modTime, strings.NewReader(content))
+	})
+	peer := httptest.NewServer(mux)
+	defer peer.Close()
+
+	dest := t.TempDir()
+	partial := filepath.Join(dest, "Proj", "a.raw"+tempSuffix)
+	if err := os.MkdirAll(filepath.Dir(partial), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(partial, []byte(content[:400]), 0644); err != nil {
+		t.Fatalf("write partial: %v", err)
+	}
+	// A half-written copy an older primary published.
+	published := filepath.Join(dest, "Proj", "b.raw.ucxtmp")
+	if err := os.WriteFile(published, []byte("half"), 0644); err != nil {
+		t.Fatalf("write published partial: %v", err)
+	}
+
+	mirror := NewMirror(Options{PeerURL: peer.URL + "/", Token: "secret-token-0123", Destination: dest})
+	if err := mirror.SyncOnce(context.Background()); err != nil {
+		t.Fatalf("SyncOnce() error = %v", err)
+	}
+
+	if len(ranges) != 1 || ranges[0] != "bytes=400-" {
+		t.Fatalf("ranges = %q, want a single resumed request", ranges)
+	}
+	info, err := os.Stat(filepath.Join(dest, "Proj", "a.raw"))
+	if err != nil {
+		t.Fatalf("stat mirrored file: %v", err)
+	}
+	if info.Size() != 1000 || !info.ModTime().Equal(modTime) {
+		t.Fatalf("mirrored file size=%d mtime=%v", info.Size(), info.ModTime())
+	}
+
+	if _, err := os.Stat(published); !os.IsNotExist(err) {
+		t.Fatalf("published partial copy kept on the mirror, stat error = %v", err)
+	}
+
+	// A second pass finds nothing to do.
+	if err := mirror.SyncOnce(context.Background()); err != nil || len(ranges) != 1 {
+		t.Fatalf("second pass: err=%v requests=%d", err, len(ranges))
+	}
+}
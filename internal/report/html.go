@@ -0,0 +1,435 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/zangezia/UCXSync/internal/state"
+)
+
+// Mission is what the HTML report shows about a project: the EAD report
+// plus the copy history, capture timing and node deliveries from the state
+// store.
+type Mission struct {
+	Report      DestinationReport
+	Destination string
+	Volumes     []state.CopyVolume
+	Captures    []state.CaptureSpan
+	Nodes       []state.NodeDelivery
+}
+
+// Collect gathers the mission report of project from store.
+func Collect(store *state.Store, project, destination string) (Mission, error) {
+	records, err := store.ListCompletedEADRecords(project)
+	if err != nil {
+		return Mission{}, err
+	}
+	opts, err := LoadOptions(store, project)
+	if err != nil {
+		return Mission{}, err
+	}
+	volumes, err := store.CopyVolumeByMinute(project)
+	if err != nil {
+		return Mission{}, err
+	}
+	captures, err := store.CaptureSpans(project)
+	if err != nil {
+		return Mission{}, err
+	}
+	nodes, err := store.NodeDeliveries(project)
+	if err != nil {
+		return Mission{}, err
+	}
+
+	return Mission{
+		Report:      Build(project, records, opts),
+		Destination: destination,
+		Volumes:     volumes,
+		Captures:    captures,
+		Nodes:       nodes,
+	}, nil
+}
+
+// HTMLFilename is the download name of the HTML report of project.
+func HTMLFilename(project string) string {
+	return fmt.Sprintf("%s-report.html", project)
+}
+
+// WriteHTML renders m as a single HTML file. The charts are inline SVG
+// drawn here, so the file needs neither scripts nor network access and can
+// be mailed as it is.
+func WriteHTML(w io.Writer, m Mission) error {
+	return htmlReport.Execute(w, newHTMLView(m))
+}
+
+// Chart geometry, in SVG user units.
+const (
+	chartWidth   = 720.0
+	chartHeight  = 200.0
+	chartPadLeft = 56.0
+	chartPadTop  = 12.0
+	chartPadBot  = 24.0
+	maxChartBars = 60
+)
+
+// throughputBuckets are the bar widths the throughput chart picks from.
+var throughputBuckets = []time.Duration{
+	time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+type htmlView struct {
+	Mission
+	GeneratedAt string
+	Files       int
+	Bytes       string
+	FirstCopy   string
+	LastCopy    string
+	AverageMBps string
+	PeakMBps    string
+	Throughput  *barChart
+	Timeline    *scatterChart
+	Spans       []spanRow
+}
+
+type chartAxis struct {
+	Top, Bottom, Left, Right string
+}
+
+type barChart struct {
+	chartAxis
+	Bucket string
+	Bars   []chartBar
+}
+
+type chartBar struct {
+	X, Y, Width, Height float64
+	Title               string
+}
+
+type scatterChart struct {
+	chartAxis
+	Points []chartPoint
+}
+
+type chartPoint struct {
+	X, Y  float64
+	Class string
+	Title string
+}
+
+type spanRow struct {
+	CaptureNumber string
+	Kind          string
+	FirstFileAt   string
+	LastCopiedAt  string
+	Lag           string
+	Tags          string
+}
+
+func newHTMLView(m Mission) htmlView {
+	view := htmlView{
+		Mission:     m,
+		GeneratedAt: formatReportTime(m.Report.GeneratedAt),
+		Throughput:  throughputChart(m.Volumes),
+		Timeline:    timelineChart(m.Captures),
+	}
+
+	var bytes int64
+	for _, volume := range m.Volumes {
+		view.Files += volume.Files
+		bytes += volume.Bytes
+	}
+	view.Bytes = formatBytes(bytes)
+	if len(m.Volumes) > 0 {
+		first := m.Volumes[0].Minute
+		last := m.Volumes[len(m.Volumes)-1].Minute.Add(time.Minute)
+		view.FirstCopy = formatReportTime(first)
+		view.LastCopy = formatReportTime(last)
+		view.AverageMBps = formatMBps(bytes, last.Sub(first))
+	}
+	if view.Throughput != nil {
+		view.PeakMBps = view.Throughput.Top
+	}
+
+	tags := make(map[string][]string, len(m.Report.Exposures))
+	for _, exposure := range m.Report.Exposures {
+		tags[exposure.CaptureNumber] = exposure.Tags
+	}
+	for _, span := range m.Captures {
+		row := spanRow{
+			CaptureNumber: span.CaptureNumber,
+			Kind:          captureKind(span),
+			FirstFileAt:   formatReportTime(span.FirstFileAt),
+			Tags:          strings.Join(tags[span.CaptureNumber], ", "),
+		}
+		if !span.LastCopiedAt.IsZero() {
+			row.LastCopiedAt = formatReportTime(span.LastCopiedAt)
+			row.Lag = captureLag(span).Round(time.Second).String()
+		}
+		view.Spans = append(view.Spans, row)
+	}
+
+	return view
+}
+
+// throughputChart draws the copied MB/s over the run in at most
+// maxChartBars bars.
+func throughputChart(volumes []state.CopyVolume) *barChart {
+	if len(volumes) == 0 {
+		return nil
+	}
+
+	start := volumes[0].Minute
+	end := volumes[len(volumes)-1].Minute.Add(time.Minute)
+	bucket := throughputBuckets[len(throughputBuckets)-1]
+	for _, candidate := range throughputBuckets {
+		if end.Sub(start) <= candidate*maxChartBars {
+			bucket = candidate
+			break
+		}
+	}
+	start = start.Truncate(bucket)
+	count := int((end.Sub(start) + bucket - 1) / bucket)
+	if count > maxChartBars {
+		count = maxChartBars
+	}
+
+	sums := make([]int64, count)
+	for _, volume := range volumes {
+		i := int(volume.Minute.Sub(start) / bucket)
+		if i >= count {
+			i = count - 1
+		}
+		sums[i] += volume.Bytes
+	}
+	var peak float64
+	rates := make([]float64, count)
+	for i, sum := range sums {
+		rates[i] = float64(sum) / 1024 / 1024 / bucket.Seconds()
+		if rates[i] > peak {
+			peak = rates[i]
+		}
+	}
+
+	chart := &barChart{
+		chartAxis: chartAxis{
+			Top:    fmt.Sprintf("%.1f MB/s", peak),
+			Bottom: "0",
+			Left:   formatReportTime(start),
+			Right:  formatReportTime(start.Add(bucket * time.Duration(count))),
+		},
+		Bucket: bucket.String(),
+	}
+	plotWidth := chartWidth - chartPadLeft
+	plotHeight := chartHeight - chartPadTop - chartPadBot
+	slot := plotWidth / float64(count)
+	for i, rate := range rates {
+		height := 0.0
+		if peak > 0 {
+			height = rate / peak * plotHeight
+		}
+		chart.Bars = append(chart.Bars, chartBar{
+			X:      chartPadLeft + float64(i)*slot + slot*0.1,
+			Y:      chartPadTop + plotHeight - height,
+			Width:  slot * 0.8,
+			Height: height,
+			Title:  fmt.Sprintf("%s: %.1f MB/s", formatReportTime(start.Add(bucket*time.Duration(i))), rate),
+		})
+	}
+	return chart
+}
+
+// timelineChart places every capture at the time its first file was written,
+// higher the longer it took until its last file was copied.
+func timelineChart(spans []state.CaptureSpan) *scatterChart {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	start, end := spans[0].FirstFileAt, spans[0].FirstFileAt
+	var maxLag time.Duration
+	for _, span := range spans {
+		if span.FirstFileAt.Before(start) {
+			start = span.FirstFileAt
+		}
+		if span.FirstFileAt.After(end) {
+			end = span.FirstFileAt
+		}
+		if lag := captureLag(span); lag > maxLag {
+			maxLag = lag
+		}
+	}
+	if !end.After(start) {
+		end = start.Add(time.Minute)
+	}
+	if maxLag <= 0 {
+		maxLag = time.Second
+	}
+
+	chart := &scatterChart{chartAxis: chartAxis{
+		Top:    maxLag.Round(time.Second).String(),
+		Bottom: "0s",
+		Left:   formatReportTime(start),
+		Right:  formatReportTime(end),
+	}}
+	plotWidth := chartWidth - chartPadLeft - 8
+	plotHeight := chartHeight - chartPadTop - chartPadBot
+	for _, span := range spans {
+		lag := captureLag(span)
+		chart.Points = append(chart.Points, chartPoint{
+			X:     chartPadLeft + 4 + float64(span.FirstFileAt.Sub(start))/float64(end.Sub(start))*plotWidth,
+			Y:     chartPadTop + plotHeight - float64(lag)/float64(maxLag)*plotHeight,
+			Class: captureKind(span),
+			Title: fmt.Sprintf("%s: %s", span.CaptureNumber, lag.Round(time.Second)),
+		})
+	}
+	return chart
+}
+
+func captureLag(span state.CaptureSpan) time.Duration {
+	if span.LastCopiedAt.IsZero() || span.LastCopiedAt.Before(span.FirstFileAt) {
+		return 0
+	}
+	return span.LastCopiedAt.Sub(span.FirstFileAt)
+}
+
+func captureKind(span state.CaptureSpan) string {
+	switch {
+	case span.IsTest:
+		return "test"
+	case !span.Completed:
+		return "incomplete"
+	default:
+		return "complete"
+	}
+}
+
+func formatReportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02 15:04 UTC")
+}
+
+func formatMBps(bytes int64, d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.1f MB/s", float64(bytes)/1024/1024/d.Seconds())
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value := float64(bytes)
+	suffixes := []string{"KiB", "MiB", "GiB", "TiB"}
+	for i, suffix := range suffixes {
+		value /= unit
+		if value < unit || i == len(suffixes)-1 {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+	}
+	return ""
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"coord": func(v float64) string { return fmt.Sprintf("%.1f", v) },
+	"join":  strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Report.Project}} — mission report</title>
+<style>
+body { font-family: Arial, Helvetica, sans-serif; color: #1f2933; margin: 24px; }
+h1 { font-size: 22px; margin-bottom: 4px; }
+h2 { font-size: 17px; margin-top: 28px; border-bottom: 1px solid #d9e2ec; padding-bottom: 4px; }
+.meta { color: #52606d; font-size: 13px; }
+table { border-collapse: collapse; font-size: 13px; margin-top: 8px; }
+th, td { border: 1px solid #d9e2ec; padding: 4px 8px; text-align: left; }
+th { background: #f0f4f8; }
+td.num { text-align: right; }
+svg { background: #fafbfc; border: 1px solid #d9e2ec; }
+svg text { font-size: 10px; fill: #52606d; }
+.bar { fill: #2f80ed; }
+.complete { fill: #27ae60; }
+.incomplete { fill: #eb5757; }
+.test { fill: #9aa5b1; }
+</style>
+</head>
+<body>
+<h1>{{.Report.Project}}{{with .Report.Registry}}{{with .Alias}} ({{.}}){{end}}{{end}}</h1>
+<div class="meta">
+{{with .Report.Registry}}{{with .Customer}}Customer: {{.}}<br>{{end}}{{with .Notes}}{{.}}<br>{{end}}{{end}}
+{{with .Destination}}Destination: {{.}}<br>{{end}}
+Generated {{.GeneratedAt}}
+</div>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Files copied</th><td class="num">{{.Files}}</td></tr>
+<tr><th>Data copied</th><td class="num">{{.Bytes}}</td></tr>
+<tr><th>Exposures</th><td class="num">{{.Report.RecordCount}}</td></tr>
+<tr><th>Captures</th><td class="num">{{len .Captures}}</td></tr>
+{{with .FirstCopy}}<tr><th>First copy</th><td>{{.}}</td></tr>{{end}}
+{{with .LastCopy}}<tr><th>Last copy</th><td>{{.}}</td></tr>{{end}}
+{{with .AverageMBps}}<tr><th>Average throughput</th><td class="num">{{.}}</td></tr>{{end}}
+{{with .PeakMBps}}<tr><th>Peak throughput</th><td class="num">{{.}}</td></tr>{{end}}
+</table>
+
+{{with .Report.Notes}}
+<h2>Operator notes</h2>
+<table>
+<tr><th>Time</th><th>Job</th><th>Note</th></tr>
+{{range .}}<tr><td>{{.CreatedAt.Format "2006-01-02 15:04 UTC"}}</td><td>{{.JobID}}</td><td>{{.Text}}</td></tr>
+{{end}}</table>
+{{end}}
+
+<h2>Throughput</h2>
+{{with .Throughput}}
+<div class="meta">Copied MB/s per {{.Bucket}}</div>
+<svg width="720" height="200" viewBox="0 0 720 200" xmlns="http://www.w3.org/2000/svg">
+<text x="4" y="20">{{.Top}}</text>
+<text x="4" y="176">{{.Bottom}}</text>
+<text x="56" y="194">{{.Left}}</text>
+<text x="716" y="194" text-anchor="end">{{.Right}}</text>
+{{range .Bars}}<rect class="bar" x="{{coord .X}}" y="{{coord .Y}}" width="{{coord .Width}}" height="{{coord .Height}}"><title>{{.Title}}</title></rect>
+{{end}}</svg>
+{{else}}<p class="meta">No copies recorded.</p>{{end}}
+
+<h2>Capture timeline</h2>
+{{with .Timeline}}
+<div class="meta">Each capture at the time its first file was written, higher the longer it took to copy. Green complete, red incomplete, grey test.</div>
+<svg width="720" height="200" viewBox="0 0 720 200" xmlns="http://www.w3.org/2000/svg">
+<text x="4" y="20">{{.Top}}</text>
+<text x="4" y="176">{{.Bottom}}</text>
+<text x="56" y="194">{{.Left}}</text>
+<text x="716" y="194" text-anchor="end">{{.Right}}</text>
+{{range .Points}}<circle class="{{.Class}}" cx="{{coord .X}}" cy="{{coord .Y}}" r="3"><title>{{.Title}}</title></circle>
+{{end}}</svg>
+{{else}}<p class="meta">No capture timing recorded.</p>{{end}}
+
+<h2>Nodes</h2>
+{{if .Nodes}}
+<table>
+<tr><th>Node</th><th>Files</th><th>RAW files</th><th>Captures</th><th>Sensors</th></tr>
+{{range .Nodes}}<tr><td>{{.Node}}</td><td class="num">{{.Files}}</td><td class="num">{{.RawFiles}}</td><td class="num">{{.Captures}}</td><td>{{join .Sensors ", "}}</td></tr>
+{{end}}</table>
+{{else}}<p class="meta">No node deliveries recorded.</p>{{end}}
+
+<h2>Captures</h2>
+{{if .Spans}}
+<table>
+<tr><th>Capture</th><th>State</th><th>First file</th><th>Last copied</th><th>Lag</th><th>Tags</th></tr>
+{{range .Spans}}<tr><td>{{.CaptureNumber}}</td><td>{{.Kind}}</td><td>{{.FirstFileAt}}</td><td>{{.LastCopiedAt}}</td><td class="num">{{.Lag}}</td><td>{{.Tags}}</td></tr>
+{{end}}</table>
+{{else}}<p class="meta">No captures recorded.</p>{{end}}
+</body>
+</html>
+`))
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/zangezia/UCXSync/internal/state"
+	"github.com/zangezia/UCXSync/pkg/models"
 )
 
 type Exposure struct {
@@ -25,19 +26,48 @@ type Exposure struct {
 	Longitude       float64 `json:"longitude"`
 	Altitude        float64 `json:"altitude"`
 	TrackOverGround float64 `json:"track_over_ground"`
+	// Tags are the operator's tags of the capture, e.g. "re-fly".
+	Tags []string `json:"tags,omitempty"`
 }
 
 type DestinationReport struct {
 	// Project is the project name from the shares/destination context. It is used
 	// for report grouping/pathing and is intentionally distinct from exposure
 	// project_name values parsed from the EAD payload.
-	Project     string     `json:"project"`
-	GeneratedAt time.Time  `json:"generated_at"`
-	RecordCount int        `json:"record_count"`
-	Exposures   []Exposure `json:"exposures"`
+	Project     string                       `json:"project"`
+	Registry    *models.ProjectRegistryEntry `json:"registry,omitempty"` // Alias, customer and notes of Project
+	Notes       []models.JobNote             `json:"notes,omitempty"`    // Operator notes of the project's sync jobs
+	GeneratedAt time.Time                    `json:"generated_at"`
+	RecordCount int                          `json:"record_count"`
+	Exposures   []Exposure                   `json:"exposures"`
 }
 
-func Build(shareProject string, records []state.EADRecord) DestinationReport {
+// Options are the optional inputs of a report; each may be left empty.
+type Options struct {
+	Tags     map[string][]string          // Capture tags keyed by capture number
+	Registry *models.ProjectRegistryEntry // The project's registry entry
+	Notes    []models.JobNote             // Operator notes of the project's jobs
+}
+
+// LoadOptions reads the optional report inputs of project from store.
+func LoadOptions(store *state.Store, project string) (Options, error) {
+	tags, err := store.CaptureTags(project)
+	if err != nil {
+		return Options{}, fmt.Errorf("load capture tags: %w", err)
+	}
+	registry, err := store.ProjectRegistryEntry(project)
+	if err != nil {
+		return Options{}, fmt.Errorf("load project registry: %w", err)
+	}
+	notes, err := store.ProjectJobNotes(project)
+	if err != nil {
+		return Options{}, fmt.Errorf("load job notes: %w", err)
+	}
+	return Options{Tags: tags, Registry: registry, Notes: notes}, nil
+}
+
+// Build assembles the report of shareProject.
+func Build(shareProject string, records []state.EADRecord, opts Options) DestinationReport {
 	exposures := make([]Exposure, 0, len(records))
 	for _, record := range records {
 		exposures = append(exposures, Exposure{
@@ -53,17 +83,43 @@ func Build(shareProject string, records []state.EADRecord) DestinationReport {
 			Longitude:       record.Longitude,
 			Altitude:        record.Altitude,
 			TrackOverGround: record.TrackOverGround,
+			Tags:            opts.Tags[record.CaptureNumber],
 		})
 	}
 
 	return DestinationReport{
 		Project:     shareProject,
+		Registry:    opts.Registry,
+		Notes:       opts.Notes,
 		GeneratedAt: time.Now().UTC(),
 		RecordCount: len(exposures),
 		Exposures:   exposures,
 	}
 }
 
+// Refresh rewrites the report of project under destinationRoot from store,
+// e.g. after its capture tags or registry entry changed. A report not written yet is left to
+// the EAD processor.
+func Refresh(store *state.Store, destinationRoot, project string) error {
+	path := DefaultPath(destinationRoot, project)
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	records, err := store.ListCompletedEADRecords(project)
+	if err != nil {
+		return err
+	}
+	opts, err := LoadOptions(store, project)
+	if err != nil {
+		return err
+	}
+	return WriteJSON(path, Build(project, records, opts))
+}
+
 func DefaultPath(destinationRoot, project string) string {
 	return filepath.Join(destinationRoot, fmt.Sprintf("%s-ead-report.json", project))
 }
@@ -1,6 +1,7 @@
 package report
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -26,7 +27,7 @@ func TestBuildKeepsShareProjectSeparateFromEADProjectName(t *testing.T) {
 			Altitude:        3438.5,
 			TrackOverGround: 200,
 		},
-	})
+	}, Options{})
 
 	if report.Project != "ShareProjA" {
 		t.Fatalf("report.Project = %q, want ShareProjA", report.Project)
@@ -45,3 +46,47 @@ func TestBuildKeepsShareProjectSeparateFromEADProjectName(t *testing.T) {
 		t.Fatalf("unexpected timestamp projection: date=%q time=%q", exposure.Date, exposure.Time)
 	}
 }
+
+func TestWriteHTMLDrawsChartsAndTables(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 9, 3, 4, 0, 0, 0, time.UTC)
+	mission := Mission{
+		Report:      Build("ProjA", nil, Options{}),
+		Destination: "/ucdata",
+		Volumes: []state.CopyVolume{
+			{Minute: start, Files: 10, Bytes: 60 << 20},
+			{Minute: start.Add(3 * time.Minute), Files: 5, Bytes: 30 << 20},
+		},
+		Captures: []state.CaptureSpan{
+			{CaptureNumber: "00001", Completed: true, FirstFileAt: start, LastCopiedAt: start.Add(90 * time.Second)},
+			{CaptureNumber: "00002", FirstFileAt: start.Add(time.Minute)},
+		},
+		Nodes: []state.NodeDelivery{{Node: "WU01", Files: 15, RawFiles: 12, Captures: 2, Sensors: []string{"00-00", "00-01"}}},
+	}
+
+	var out strings.Builder
+	if err := WriteHTML(&out, mission); err != nil {
+		t.Fatalf("WriteHTML() error = %v", err)
+	}
+	html := out.String()
+
+	if got := strings.Count(html, `<rect class="bar"`); got != 4 {
+		t.Fatalf("throughput bars = %d, want 4 one-minute bars", got)
+	}
+	if !strings.Contains(html, "1.0 MB/s") {
+		t.Fatal("report does not show the 1.0 MB/s peak")
+	}
+	if !strings.Contains(html, `<circle class="complete"`) || !strings.Contains(html, `<circle class="incomplete"`) {
+		t.Fatal("capture timeline does not mark complete and incomplete captures")
+	}
+	if !strings.Contains(html, "<td>WU01</td>") || !strings.Contains(html, "00-00, 00-01") {
+		t.Fatal("node table missing WU01 and its sensors")
+	}
+	if !strings.Contains(html, "1m30s") {
+		t.Fatal("capture table missing the lag of 00001")
+	}
+	if strings.Contains(html, "<script") {
+		t.Fatal("report must not need scripts")
+	}
+}
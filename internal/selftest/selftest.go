@@ -0,0 +1,279 @@
+// Package selftest runs a complete discover → sync → verify cycle against a
+// generated loopback share tree so the whole stack can be checked on site
+// without camera hardware.
+package selftest
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/zangezia/UCXSync/internal/simulate"
+	"github.com/zangezia/UCXSync/internal/state"
+	syncService "github.com/zangezia/UCXSync/internal/sync"
+)
+
+const project = "Selftest_project"
+
+// Options configures a self-test run.
+type Options struct {
+	Root        string // Working directory; created and removed by Run when empty
+	Nodes       []string
+	Shares      []string
+	Captures    int
+	RawFileSize int64
+	Parallelism int
+	Timeout     time.Duration // Limit for the sync step
+}
+
+// Step is the outcome of one stage of the self-test.
+type Step struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Detail   string        `json:"detail"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report collects the outcome of every step that ran.
+type Report struct {
+	Steps []Step `json:"steps"`
+}
+
+// Passed reports whether every step passed.
+func (r Report) Passed() bool {
+	if len(r.Steps) == 0 {
+		return false
+	}
+	for _, step := range r.Steps {
+		if !step.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Run executes the self-test. Steps after the first failure are skipped.
+func Run(ctx context.Context, opts Options) Report {
+	opts = withDefaults(opts)
+
+	var report Report
+	run := func(name string, fn func() (string, error)) bool {
+		started := time.Now()
+		detail, err := fn()
+		step := Step{Name: name, Passed: err == nil, Detail: detail, Duration: time.Since(started)}
+		if err != nil {
+			step.Detail = err.Error()
+		}
+		report.Steps = append(report.Steps, step)
+		return err == nil
+	}
+
+	root := opts.Root
+	if root == "" {
+		dir, err := os.MkdirTemp("", "ucxsync-selftest-")
+		if err != nil {
+			report.Steps = append(report.Steps, Step{Name: "prepare", Detail: err.Error()})
+			return report
+		}
+		defer os.RemoveAll(dir)
+		root = dir
+	}
+
+	mountRoot := filepath.Join(root, "ucmount")
+	destination := filepath.Join(root, "destination")
+
+	if !run("prepare", func() (string, error) {
+		gen := simulate.New(simulate.Options{
+			Root:        mountRoot,
+			Nodes:       opts.Nodes,
+			Shares:      opts.Shares,
+			Project:     project,
+			RawFileSize: opts.RawFileSize,
+		})
+		if err := gen.Prepare(); err != nil {
+			return "", err
+		}
+		for i := 0; i < opts.Captures; i++ {
+			if err := gen.WriteCapture(); err != nil {
+				return "", err
+			}
+		}
+		if err := os.MkdirAll(destination, 0755); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d captures in %s", opts.Captures, mountRoot), nil
+	}) {
+		return report
+	}
+
+	svc := syncService.New(opts.Nodes, opts.Shares, mountRoot)
+	// The loopback tree is plain folders, not mounts: the discover step checks
+	// that the shares can be read and hold the project.
+	svc.SetMountPointChecker(func(string) (bool, error) { return true, nil })
+	svc.SetServiceLoopInterval(500 * time.Millisecond)
+
+	if !run("discover", func() (string, error) {
+		if unavailable := svc.CheckSharesAvailability(); len(unavailable) > 0 {
+			return "", fmt.Errorf("%d share(s) unavailable, first: %s", len(unavailable), unavailable[0].Path)
+		}
+		projects, err := svc.FindProjects(ctx)
+		if err != nil {
+			return "", err
+		}
+		for _, p := range projects {
+			if p.Name == project {
+				return fmt.Sprintf("%d node share(s) available", len(opts.Nodes)*len(opts.Shares)), nil
+			}
+		}
+		return "", fmt.Errorf("project %s not discovered on shares", project)
+	}) {
+		return report
+	}
+
+	var destDir string
+	if !run("sync", func() (string, error) {
+		store, err := state.New(filepath.Join(root, "state.db"), "selftest")
+		if err != nil {
+			return "", err
+		}
+		defer store.Close()
+		if err := svc.SetStateStore(store); err != nil {
+			return "", err
+		}
+
+		if err := svc.Start(ctx, project, destination, opts.Parallelism, false); err != nil {
+			return "", err
+		}
+		defer svc.Stop()
+
+		deadline := time.Now().Add(opts.Timeout)
+		for {
+			status := svc.GetStatus()
+			done := status.CompletedCaptures + status.CompletedTestCaptures
+			if done >= opts.Captures {
+				destDir = svc.RunDir()
+				return fmt.Sprintf("%d captures completed", done), nil
+			}
+			if time.Now().After(deadline) {
+				return "", fmt.Errorf("timed out after %s with %d of %d captures completed", opts.Timeout, done, opts.Captures)
+			}
+
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(200 * time.Millisecond):
+			}
+		}
+	}) {
+		return report
+	}
+
+	run("verify", func() (string, error) {
+		return verifyTree(mountRoot, destDir)
+	})
+
+	return report
+}
+
+func withDefaults(opts Options) Options {
+	if len(opts.Nodes) == 0 {
+		opts.Nodes = []string{"WU01", "WU02", "CU"}
+	}
+	if len(opts.Shares) == 0 {
+		opts.Shares = []string{"E$", "F$"}
+	}
+	if opts.Captures <= 0 {
+		opts.Captures = 3
+	}
+	if opts.RawFileSize <= 0 {
+		opts.RawFileSize = 256 * 1024
+	}
+	if opts.Parallelism <= 0 {
+		opts.Parallelism = 4
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Minute
+	}
+	return opts
+}
+
+// verifyTree checks that every project file on every share exists in destDir
+// with identical content.
+func verifyTree(mountRoot, destDir string) (string, error) {
+	var files int
+	var bytes int64
+
+	err := filepath.Walk(mountRoot, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(mountRoot, path)
+		if err != nil {
+			return err
+		}
+		// <node>/<share>/<project>/<relative path>
+		parts := strings.SplitN(filepath.ToSlash(rel), "/", 4)
+		if len(parts) != 4 || parts[2] != project {
+			return nil
+		}
+
+		target := filepath.Join(destDir, filepath.FromSlash(parts[3]))
+		same, err := sameContent(path, target)
+		if err != nil {
+			return fmt.Errorf("%s: %w", parts[3], err)
+		}
+		if !same {
+			return fmt.Errorf("%s: content differs", parts[3])
+		}
+
+		files++
+		bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if files == 0 {
+		return "", fmt.Errorf("no source files found under %s", mountRoot)
+	}
+
+	return fmt.Sprintf("%d files, %d bytes verified by SHA-256", files, bytes), nil
+}
+
+func sameContent(a, b string) (bool, error) {
+	sumA, err := fileSHA256(a)
+	if err != nil {
+		return false, err
+	}
+	sumB, err := fileSHA256(b)
+	if err != nil {
+		return false, err
+	}
+	return sumA == sumB, nil
+}
+
+func fileSHA256(path string) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+
+	f, err := os.Open(path)
+	if err != nil {
+		return sum, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return sum, err
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
@@ -0,0 +1,53 @@
+package selftest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunPassesOnLoopbackTree(t *testing.T) {
+	t.Parallel()
+
+	report := Run(context.Background(), Options{
+		Root:        t.TempDir(),
+		Captures:    2,
+		RawFileSize: 1024,
+		Timeout:     30 * time.Second,
+	})
+
+	if !report.Passed() {
+		t.Fatalf("report = %+v, want all steps passed", report)
+	}
+
+	var names []string
+	for _, step := range report.Steps {
+		names = append(names, step.Name)
+	}
+	if got := strings.Join(names, ","); got != "prepare,discover,sync,verify" {
+		t.Fatalf("steps = %s, want prepare,discover,sync,verify", got)
+	}
+}
+
+func TestVerifyTreeDetectsModifiedCopy(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	source := filepath.Join(root, "ucmount", "WU01", "E", project, "a.raw")
+	dest := filepath.Join(root, "dest", "a.raw")
+	for path, content := range map[string]string{source: "original", dest: "modified"} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	if _, err := verifyTree(filepath.Join(root, "ucmount"), filepath.Join(root, "dest")); err == nil || !strings.Contains(err.Error(), "content differs") {
+		t.Fatalf("verifyTree() error = %v, want content mismatch", err)
+	}
+}
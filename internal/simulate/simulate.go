@@ -0,0 +1,246 @@
+// Package simulate generates a fake UltraCam project on disk so the sync
+// pipeline and web UI can be exercised without camera hardware.
+package simulate
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	mathrand "math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// sensorCodes lists the RAW sensors written for every capture, in the same
+// order the camera distributes them across the WU nodes.
+var sensorCodes = []string{
+	"00-00", "00-01", "00-02", "00-03",
+	"01-00", "01-01",
+	"02-00", "02-01",
+	"03-00",
+	"04-00", "05-00", "06-00", "07-00",
+}
+
+// Options configures a Generator.
+type Options struct {
+	Root            string              // Simulated mount root (<root>/<node>/<share>/<project>)
+	Nodes           []string            // Node names; CU receives the XML/DAT files
+	Shares          []string            // Share names with or without the trailing $
+	NodeShares      map[string][]string // Per-node overrides of Shares
+	Project         string              // Project directory and file name component
+	CaptureInterval time.Duration       // Delay between captures
+	RawFileSize     int64               // Size of each RAW file in bytes
+	TestCaptures    int                 // Leading captures written as test captures
+	Captures        int                 // Stop after this many captures; 0 = unlimited
+}
+
+// Generator writes captures with realistic UCX file names over time.
+type Generator struct {
+	opts    Options
+	session string
+	rng     *mathrand.Rand
+	next    int
+}
+
+// New creates a generator. Zero-valued options fall back to small demo values.
+func New(opts Options) *Generator {
+	if opts.CaptureInterval <= 0 {
+		opts.CaptureInterval = 5 * time.Second
+	}
+	if opts.RawFileSize <= 0 {
+		opts.RawFileSize = 4 * 1024 * 1024
+	}
+
+	return &Generator{
+		opts:    opts,
+		session: newSessionID(),
+		rng:     mathrand.New(mathrand.NewSource(time.Now().UnixNano())),
+		next:    1,
+	}
+}
+
+// Prepare creates the share directories so the sync service treats them as
+// available before the first capture is written.
+func (g *Generator) Prepare() error {
+	for _, node := range g.opts.Nodes {
+		for _, share := range g.sharesFor(node) {
+			dir := filepath.Join(g.opts.Root, node, strings.TrimSuffix(share, "$"), g.opts.Project)
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("failed to create simulated share %s: %w", dir, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Run writes one capture per interval until ctx is cancelled or the
+// configured number of captures has been produced.
+func (g *Generator) Run(ctx context.Context) error {
+	if err := g.Prepare(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(g.opts.CaptureInterval)
+	defer ticker.Stop()
+
+	for {
+		if g.opts.Captures > 0 && g.next > g.opts.Captures {
+			log.Info().Int("captures", g.opts.Captures).Msg("Simulation finished")
+			return nil
+		}
+
+		if err := g.WriteCapture(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// WriteCapture writes all RAW, EAD and RawQv files of the next capture.
+func (g *Generator) WriteCapture() error {
+	number := g.next
+	g.next++
+
+	isTest := number <= g.opts.TestCaptures
+	captureID := fmt.Sprintf("%05d", number)
+	if isTest {
+		captureID += "-T"
+	}
+
+	workers := g.workerNodes()
+	for i, sensor := range sensorCodes {
+		node := workers[i%len(workers)]
+		name := fmt.Sprintf("Lvl0X-%s-%s-%s-%s.raw", captureID, g.opts.Project, sensor, g.session)
+		if err := g.writeFile(node, g.shareFor(node, number), name, g.opts.RawFileSize); err != nil {
+			return err
+		}
+	}
+
+	cu := g.controlNode()
+	share := g.shareFor(cu, number)
+	ead := fmt.Sprintf("EAD-%s-%s-%s.xml", captureID, g.opts.Project, g.session)
+	if err := g.writeData(cu, share, ead, []byte(g.eadXML(number))); err != nil {
+		return err
+	}
+
+	rawQv := fmt.Sprintf("RawQv-%s-%s-%s.dat", captureID, g.opts.Project, g.session)
+	if err := g.writeFile(cu, share, rawQv, 64*1024); err != nil {
+		return err
+	}
+
+	log.Info().Int("capture", number).Bool("test", isTest).Msg("Simulated capture written")
+	return nil
+}
+
+// shareFor spreads captures over the shares of node.
+func (g *Generator) shareFor(node string, number int) string {
+	shares := g.sharesFor(node)
+	if len(shares) == 0 {
+		return "E"
+	}
+	return strings.TrimSuffix(shares[(number-1)%len(shares)], "$")
+}
+
+func (g *Generator) sharesFor(node string) []string {
+	if shares, ok := g.opts.NodeShares[node]; ok {
+		return shares
+	}
+	return g.opts.Shares
+}
+
+func (g *Generator) workerNodes() []string {
+	var workers []string
+	for _, node := range g.opts.Nodes {
+		if !strings.EqualFold(node, "CU") {
+			workers = append(workers, node)
+		}
+	}
+	if len(workers) == 0 {
+		return []string{g.controlNode()}
+	}
+	return workers
+}
+
+func (g *Generator) controlNode() string {
+	for _, node := range g.opts.Nodes {
+		if strings.EqualFold(node, "CU") {
+			return node
+		}
+	}
+	if len(g.opts.Nodes) > 0 {
+		return g.opts.Nodes[len(g.opts.Nodes)-1]
+	}
+	return "CU"
+}
+
+// writeFile writes size bytes of random data through a temporary name so the
+// sync service never sees a half-written file.
+func (g *Generator) writeFile(node, share, name string, size int64) error {
+	dir := filepath.Join(g.opts.Root, node, share, g.opts.Project)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".sim-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(tmp, g.rng, size); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
+
+func (g *Generator) writeData(node, share, name string, data []byte) error {
+	dir := filepath.Join(g.opts.Root, node, share, g.opts.Project)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(dir, ".sim-"+name)
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, name))
+}
+
+// eadXML renders a minimal exposure annotation record along a straight
+// flight line so reports show plausible positions.
+func (g *Generator) eadXML(number int) string {
+	now := time.Now().UTC()
+	lat := 59.27 + float64(number)*0.002
+	lon := 37.25 + float64(number)*0.001
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<exposure_annotation_data data_version="7"><image_number>%[1]d</image_number><record_guid>%[2]s</record_guid><software>UCXSync simulator</software><aperture description="F 8">1</aperture><exposure_time unit="s">0.002</exposure_time><exposure_annotation_info data_version="1"><fms_info data_version="1"><sensor_code>UCXp</sensor_code><exposure_number>%[1]d</exposure_number><project_name>%[3]s</project_name><area>Simulation</area><line_number>1</line_number><segment_number>1</segment_number><waypoint_number>%[1]d</waypoint_number></fms_info><gps_navigation_info data_version="3"><date unit="YYMMDD">%[4]s</date><time unit="hhmmss">%[5]s</time><latitude unit="degree">N%09.6[6]f</latitude><longitude unit="degree">E%010.6[7]f</longitude><altitude unit="m">1500.0</altitude><above_ground_level unit="m">1350.0</above_ground_level><track_over_ground unit="degree">45.0</track_over_ground><ground_speed unit="m/s">70.0</ground_speed></gps_navigation_info></exposure_annotation_info></exposure_annotation_data>
+`, number, strings.ReplaceAll(g.session, "_", "-"), g.opts.Project, now.Format("060102"), now.Format("150405"), lat, lon)
+}
+
+// newSessionID returns an upper-case GUID with underscores, as used in UCX
+// file names.
+func newSessionID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "00000000_0000_0000_0000_000000000000"
+	}
+	h := strings.ToUpper(hex.EncodeToString(buf))
+	return strings.Join([]string{h[0:8], h[8:12], h[12:16], h[16:20], h[20:32]}, "_")
+}
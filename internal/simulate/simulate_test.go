@@ -0,0 +1,114 @@
+package simulate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/zangezia/UCXSync/internal/ead"
+	syncService "github.com/zangezia/UCXSync/internal/sync"
+)
+
+func TestWriteCaptureLaysOutRealisticFiles(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	nodes := []string{"WU01", "WU02", "WU03", "CU"}
+	gen := New(Options{
+		Root:         root,
+		Nodes:        nodes,
+		Shares:       []string{"E$", "F$"},
+		Project:      "Sim_project",
+		RawFileSize:  128,
+		TestCaptures: 1,
+	})
+
+	if err := gen.Prepare(); err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+	if err := gen.WriteCapture(); err != nil {
+		t.Fatalf("WriteCapture(1) error = %v", err)
+	}
+	if err := gen.WriteCapture(); err != nil {
+		t.Fatalf("WriteCapture(2) error = %v", err)
+	}
+
+	rawPattern := regexp.MustCompile(`^Lvl0X-\d{5}(-T)?-Sim_project-\d\d-\d\d-[A-F0-9_]+\.raw$`)
+	count := func(node, share, pattern string) int {
+		t.Helper()
+		matches, err := filepath.Glob(filepath.Join(root, node, share, "Sim_project", pattern))
+		if err != nil {
+			t.Fatalf("Glob() error = %v", err)
+		}
+		for _, match := range matches {
+			if filepath.Ext(match) == ".raw" && !rawPattern.MatchString(filepath.Base(match)) {
+				t.Fatalf("unexpected RAW name %q", filepath.Base(match))
+			}
+		}
+		return len(matches)
+	}
+
+	// 13 sensors over three workers: 5 + 4 + 4; capture 1 on E, capture 2 on F.
+	if got := count("WU01", "E", "Lvl0X-00001-T-*.raw"); got != 5 {
+		t.Fatalf("WU01/E test RAW files = %d, want 5", got)
+	}
+	if got := count("WU02", "F", "Lvl0X-00002-Sim_project-*.raw"); got != 4 {
+		t.Fatalf("WU02/F RAW files = %d, want 4", got)
+	}
+	if got := count("CU", "F", "EAD-00002-Sim_project-*.xml"); got != 1 {
+		t.Fatalf("CU/F EAD files = %d, want 1", got)
+	}
+	if got := count("CU", "F", "RawQv-00002-Sim_project-*.dat"); got != 1 {
+		t.Fatalf("CU/F RawQv files = %d, want 1", got)
+	}
+	if got := count("CU", "F", ".sim-*"); got != 0 {
+		t.Fatalf("temporary files left behind: %d", got)
+	}
+
+	eads, _ := filepath.Glob(filepath.Join(root, "CU", "F", "Sim_project", "EAD-*.xml"))
+	record, issues, err := ead.ParseFile(eads[0])
+	if err != nil {
+		t.Fatalf("ead.ParseFile() error = %v", err)
+	}
+	if len(issues) != 0 || record.ProjectName != "Sim_project" {
+		t.Fatalf("ead record = %+v, issues = %+v", record, issues)
+	}
+
+	svc := syncService.New(nodes, []string{"E$", "F$"}, root)
+	projects, err := svc.FindProjects(context.Background())
+	if err != nil {
+		t.Fatalf("FindProjects() error = %v", err)
+	}
+	if len(projects) != 1 || projects[0].Name != "Sim_project" {
+		t.Fatalf("FindProjects() = %+v, want Sim_project", projects)
+	}
+}
+
+func TestRunStopsAfterConfiguredCaptures(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	gen := New(Options{
+		Root:            root,
+		Nodes:           []string{"WU01", "CU"},
+		Shares:          []string{"E$"},
+		Project:         "Sim_project",
+		CaptureInterval: 1,
+		RawFileSize:     16,
+		Captures:        3,
+	})
+
+	if err := gen.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(root, "CU", "E", "Sim_project"))
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 6 {
+		t.Fatalf("CU files = %d, want 6 (3 EAD + 3 RawQv)", len(entries))
+	}
+}
@@ -0,0 +1,234 @@
+package state
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// maxResolvedAlerts bounds the resolved alerts kept for the history view.
+const maxResolvedAlerts = 500
+
+// ErrAlertNotFound is returned when an alert id does not exist.
+var ErrAlertNotFound = errors.New("alert not found")
+
+const alertColumns = `id, alert_type, severity, category, title, message, reason, project_name, destination,
+	count, state, raised_at, last_seen_at, acknowledged_at, resolved_at`
+
+// alertKey groups repeats of the same condition into one alert.
+func alertKey(alert models.Alert) string {
+	return alert.Type + "|" + alert.Project + "|" + alert.Destination
+}
+
+// RaiseAlert records alert. When an unresolved alert with the same type,
+// project and destination exists it is updated with the latest message and
+// its count bumped, keeping its state; otherwise a new open alert is added.
+func (s *Store) RaiseAlert(alert models.Alert) (models.Alert, error) {
+	seenAt := alert.LastSeenAt.UTC()
+	if seenAt.IsZero() {
+		seenAt = time.Now().UTC()
+	}
+	key := alertKey(alert)
+
+	var id int64
+	err := s.withWriteTx(func(tx *sql.Tx) error {
+		err := tx.QueryRow(`
+			SELECT id FROM alerts
+			WHERE service_name = ? AND alert_key = ? AND state <> ?
+			ORDER BY id DESC LIMIT 1
+		`, s.serviceName, key, models.AlertResolved).Scan(&id)
+		switch {
+		case err == sql.ErrNoRows:
+			result, err := tx.Exec(`
+				INSERT INTO alerts (
+					service_name, alert_key, alert_type, severity, category, title, message, reason,
+					project_name, destination, count, state, raised_at, last_seen_at
+				) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, ?, ?, ?)
+			`, s.serviceName, key, alert.Type, alert.Severity, alert.Category, alert.Title, alert.Message, alert.Reason,
+				alert.Project, alert.Destination, models.AlertOpen, seenAt.Format(time.RFC3339Nano), seenAt.Format(time.RFC3339Nano))
+			if err != nil {
+				return err
+			}
+			id, err = result.LastInsertId()
+			return err
+		case err != nil:
+			return err
+		}
+
+		_, err = tx.Exec(`
+			UPDATE alerts
+			SET severity = ?, title = ?, message = ?, reason = ?, count = count + 1, last_seen_at = ?
+			WHERE id = ?
+		`, alert.Severity, alert.Title, alert.Message, alert.Reason, seenAt.Format(time.RFC3339Nano), id)
+		return err
+	})
+	if err != nil {
+		return models.Alert{}, err
+	}
+	return s.getAlert(id)
+}
+
+// ListAlerts returns unresolved alerts, plus resolved ones when
+// includeResolved is set, most recently seen first.
+func (s *Store) ListAlerts(includeResolved bool, limit int) ([]models.Alert, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := `SELECT ` + alertColumns + ` FROM alerts WHERE service_name = ?`
+	args := []any{s.serviceName}
+	if !includeResolved {
+		query += ` AND state <> ?`
+		args = append(args, models.AlertResolved)
+	}
+	query += ` ORDER BY last_seen_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := make([]models.Alert, 0)
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, rows.Err()
+}
+
+// AcknowledgeAlert marks an open alert as seen by the operator.
+func (s *Store) AcknowledgeAlert(id int64, at time.Time) (models.Alert, error) {
+	return s.setAlertState(id, models.AlertAcknowledged, `acknowledged_at`, at)
+}
+
+// ResolveAlert closes an alert; a repeat of its condition raises a new one.
+func (s *Store) ResolveAlert(id int64, at time.Time) (models.Alert, error) {
+	return s.setAlertState(id, models.AlertResolved, `resolved_at`, at)
+}
+
+// ResolveAlertsOfType closes every unresolved alert of alertType, e.g. once
+// the condition has cleared by itself, and returns the closed alerts.
+func (s *Store) ResolveAlertsOfType(alertType string, at time.Time) ([]models.Alert, error) {
+	rows, err := s.db.Query(`SELECT id FROM alerts WHERE service_name = ? AND alert_type = ? AND state <> ?`,
+		s.serviceName, alertType, models.AlertResolved)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	resolved := make([]models.Alert, 0, len(ids))
+	for _, id := range ids {
+		alert, err := s.ResolveAlert(id, at)
+		if err != nil {
+			return resolved, err
+		}
+		resolved = append(resolved, alert)
+	}
+	return resolved, nil
+}
+
+func (s *Store) setAlertState(id int64, alertState, column string, at time.Time) (models.Alert, error) {
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	err := s.withWriteTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`
+			UPDATE alerts SET state = ?, `+column+` = ?
+			WHERE service_name = ? AND id = ? AND state <> ?
+		`, alertState, at.UTC().Format(time.RFC3339Nano), s.serviceName, id, models.AlertResolved)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil || n > 0 {
+			return err
+		}
+		var exists int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM alerts WHERE service_name = ? AND id = ?`, s.serviceName, id).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return ErrAlertNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Alert{}, err
+	}
+
+	if alertState == models.AlertResolved {
+		if err := s.execWrite(`
+			DELETE FROM alerts
+			WHERE service_name = ? AND state = ? AND id NOT IN (
+				SELECT id FROM alerts WHERE service_name = ? AND state = ? ORDER BY id DESC LIMIT ?
+			)
+		`, s.serviceName, models.AlertResolved, s.serviceName, models.AlertResolved, maxResolvedAlerts); err != nil {
+			return models.Alert{}, err
+		}
+	}
+	return s.getAlert(id)
+}
+
+func (s *Store) getAlert(id int64) (models.Alert, error) {
+	alert, err := scanAlert(s.db.QueryRow(`SELECT `+alertColumns+` FROM alerts WHERE service_name = ? AND id = ?`, s.serviceName, id))
+	if err == sql.ErrNoRows {
+		return models.Alert{}, ErrAlertNotFound
+	}
+	return alert, err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAlert(row rowScanner) (models.Alert, error) {
+	var (
+		alert                                            models.Alert
+		raisedAt, lastSeenAt, acknowledgedAt, resolvedAt string
+	)
+	if err := row.Scan(&alert.ID, &alert.Type, &alert.Severity, &alert.Category, &alert.Title, &alert.Message, &alert.Reason,
+		&alert.Project, &alert.Destination, &alert.Count, &alert.State, &raisedAt, &lastSeenAt, &acknowledgedAt, &resolvedAt); err != nil {
+		return models.Alert{}, err
+	}
+
+	var err error
+	if alert.RaisedAt, err = time.Parse(time.RFC3339Nano, raisedAt); err != nil {
+		return models.Alert{}, err
+	}
+	if alert.LastSeenAt, err = time.Parse(time.RFC3339Nano, lastSeenAt); err != nil {
+		return models.Alert{}, err
+	}
+	for _, field := range []struct {
+		raw    string
+		target **time.Time
+	}{{acknowledgedAt, &alert.AcknowledgedAt}, {resolvedAt, &alert.ResolvedAt}} {
+		if field.raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, field.raw)
+		if err != nil {
+			return models.Alert{}, err
+		}
+		*field.target = &parsed
+	}
+	return alert, nil
+}
@@ -0,0 +1,88 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// RecordJobStarted adds a running job to the job history.
+func (s *Store) RecordJobStarted(job models.JobRecord) error {
+	return s.execWrite(`
+		INSERT OR REPLACE INTO sync_jobs (
+			job_id, service_name, project_name, destination, state, reason,
+			started_at, finished_at, totals
+		) VALUES (?, ?, ?, ?, ?, '', ?, '', '')
+	`, job.JobID, s.serviceName, job.Project, job.Destination, models.JobRunning, job.StartedAt.UTC().Format(time.RFC3339Nano))
+}
+
+// RecordJobFinished closes a job of the history in state, such as
+// models.JobFinished or models.JobFailed, with the totals of the run.
+func (s *Store) RecordJobFinished(jobID, state, reason string, finishedAt time.Time, totals models.SyncTotals) error {
+	encoded, err := json.Marshal(totals)
+	if err != nil {
+		return fmt.Errorf("failed to encode job totals: %w", err)
+	}
+	return s.execWrite(`
+		UPDATE sync_jobs
+		SET state = ?, reason = ?, finished_at = ?, totals = ?
+		WHERE job_id = ? AND service_name = ?
+	`, state, reason, finishedAt.UTC().Format(time.RFC3339Nano), string(encoded), jobID, s.serviceName)
+}
+
+// InterruptRunningJobs marks the jobs still running in the history as
+// interrupted. It is called on startup, when no job of this service can be
+// running any more.
+func (s *Store) InterruptRunningJobs() error {
+	return s.execWrite(`
+		UPDATE sync_jobs SET state = ?
+		WHERE state = ? AND service_name = ?
+	`, models.JobInterrupted, models.JobRunning, s.serviceName)
+}
+
+// ListJobs returns the most recent jobs of the history, newest first, only
+// those of project when it is not empty.
+func (s *Store) ListJobs(project string, limit int) ([]models.JobRecord, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := s.db.Query(`
+		SELECT job_id, project_name, destination, state, reason, started_at, finished_at, totals
+		FROM sync_jobs
+		WHERE service_name = ? AND (? = '' OR project_name = ?)
+		ORDER BY started_at DESC
+		LIMIT ?
+	`, s.serviceName, project, project, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []models.JobRecord{}
+	for rows.Next() {
+		var (
+			job                   models.JobRecord
+			startedAt, finishedAt string
+			totals                string
+		)
+		if err := rows.Scan(&job.JobID, &job.Project, &job.Destination, &job.State, &job.Reason, &startedAt, &finishedAt, &totals); err != nil {
+			return nil, err
+		}
+		job.StartedAt, _ = time.Parse(time.RFC3339Nano, startedAt)
+		if finishedAt != "" {
+			if parsed, err := time.Parse(time.RFC3339Nano, finishedAt); err == nil {
+				job.FinishedAt = &parsed
+			}
+		}
+		if totals != "" {
+			if err := json.Unmarshal([]byte(totals), &job.Totals); err != nil {
+				return nil, err
+			}
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
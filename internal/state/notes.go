@@ -0,0 +1,108 @@
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// maxJobNoteLength bounds one operator note, a few paragraphs at most.
+const maxJobNoteLength = 2000
+
+// NormalizeJobNote trims the fields of note and checks it names its job and
+// project and has text of a sane length.
+func NormalizeJobNote(note models.JobNote) (models.JobNote, error) {
+	note.JobID = strings.TrimSpace(note.JobID)
+	note.Project = strings.TrimSpace(note.Project)
+	note.Text = strings.TrimSpace(note.Text)
+
+	switch {
+	case note.JobID == "" || note.Project == "":
+		return note, fmt.Errorf("job and project are required")
+	case note.Text == "":
+		return note, fmt.Errorf("text is empty")
+	case utf8.RuneCountInString(note.Text) > maxJobNoteLength:
+		return note, fmt.Errorf("text is longer than %d characters", maxJobNoteLength)
+	}
+	return note, nil
+}
+
+// AddJobNote appends an operator note to a sync job and returns it with its
+// id and time.
+func (s *Store) AddJobNote(note models.JobNote) (models.JobNote, error) {
+	note, err := NormalizeJobNote(note)
+	if err != nil {
+		return note, err
+	}
+	note.CreatedAt = time.Now().UTC()
+
+	err = s.withWriteTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`
+			INSERT INTO job_notes (job_id, project_name, text, created_at)
+			VALUES (?, ?, ?, ?)
+		`, note.JobID, note.Project, note.Text, note.CreatedAt.Format(time.RFC3339Nano))
+		if err != nil {
+			return err
+		}
+		note.ID, err = result.LastInsertId()
+		return err
+	})
+	return note, err
+}
+
+// DeleteJobNote removes a note and returns the project it belonged to, ""
+// when there was no such note.
+func (s *Store) DeleteJobNote(id int64) (string, error) {
+	var project string
+	err := s.withWriteTx(func(tx *sql.Tx) error {
+		err := tx.QueryRow(`SELECT project_name FROM job_notes WHERE id = ?`, id).Scan(&project)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`DELETE FROM job_notes WHERE id = ?`, id)
+		return err
+	})
+	return project, err
+}
+
+// JobNotes returns the notes of a job, oldest first.
+func (s *Store) JobNotes(jobID string) ([]models.JobNote, error) {
+	return s.queryJobNotes(`WHERE job_id = ?`, jobID)
+}
+
+// ProjectJobNotes returns the notes of every job of a project, oldest first.
+func (s *Store) ProjectJobNotes(project string) ([]models.JobNote, error) {
+	return s.queryJobNotes(`WHERE project_name = ?`, project)
+}
+
+func (s *Store) queryJobNotes(where string, args ...interface{}) ([]models.JobNote, error) {
+	rows, err := s.db.Query(`
+		SELECT id, job_id, project_name, text, created_at
+		FROM job_notes
+		`+where+`
+		ORDER BY created_at, id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := []models.JobNote{}
+	for rows.Next() {
+		var note models.JobNote
+		var createdAt string
+		if err := rows.Scan(&note.ID, &note.JobID, &note.Project, &note.Text, &createdAt); err != nil {
+			return nil, err
+		}
+		note.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+		notes = append(notes, note)
+	}
+	return notes, rows.Err()
+}
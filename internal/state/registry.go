@@ -0,0 +1,128 @@
+package state
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// ErrProjectAliasTaken is returned when another project already has the alias.
+var ErrProjectAliasTaken = errors.New("alias is already used by another project")
+
+// Limits of the free-text registry fields.
+const (
+	maxProjectAliasLength    = 64
+	maxProjectCustomerLength = 128
+	maxProjectNotesLength    = 4000
+)
+
+// NormalizeProjectRegistryEntry trims the fields of entry and checks their
+// length. An entry needs a project and at least one of alias, customer and
+// notes.
+func NormalizeProjectRegistryEntry(entry models.ProjectRegistryEntry) (models.ProjectRegistryEntry, error) {
+	entry.Project = strings.TrimSpace(entry.Project)
+	entry.Alias = strings.TrimSpace(entry.Alias)
+	entry.Customer = strings.TrimSpace(entry.Customer)
+	entry.Notes = strings.TrimSpace(entry.Notes)
+
+	switch {
+	case entry.Project == "":
+		return entry, fmt.Errorf("project is empty")
+	case utf8.RuneCountInString(entry.Alias) > maxProjectAliasLength:
+		return entry, fmt.Errorf("alias is longer than %d characters", maxProjectAliasLength)
+	case utf8.RuneCountInString(entry.Customer) > maxProjectCustomerLength:
+		return entry, fmt.Errorf("customer is longer than %d characters", maxProjectCustomerLength)
+	case utf8.RuneCountInString(entry.Notes) > maxProjectNotesLength:
+		return entry, fmt.Errorf("notes are longer than %d characters", maxProjectNotesLength)
+	case entry.Alias == "" && entry.Customer == "" && entry.Notes == "":
+		return entry, fmt.Errorf("alias, customer or notes required")
+	}
+	return entry, nil
+}
+
+// SetProjectRegistryEntry creates or replaces the registry entry of
+// entry.Project. Another project may not use the same alias.
+func (s *Store) SetProjectRegistryEntry(entry models.ProjectRegistryEntry) (models.ProjectRegistryEntry, error) {
+	entry, err := NormalizeProjectRegistryEntry(entry)
+	if err != nil {
+		return entry, err
+	}
+	entry.UpdatedAt = time.Now().UTC()
+
+	err = s.withWriteTx(func(tx *sql.Tx) error {
+		if entry.Alias != "" {
+			var other string
+			err := tx.QueryRow(`
+				SELECT project_name FROM project_registry
+				WHERE alias = ? COLLATE NOCASE AND project_name <> ?
+			`, entry.Alias, entry.Project).Scan(&other)
+			if err == nil {
+				return fmt.Errorf("%w: %q belongs to %s", ErrProjectAliasTaken, entry.Alias, other)
+			}
+			if err != sql.ErrNoRows {
+				return err
+			}
+		}
+		_, err := tx.Exec(`
+			INSERT INTO project_registry (project_name, alias, customer, notes, updated_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(project_name) DO UPDATE SET
+				alias = excluded.alias,
+				customer = excluded.customer,
+				notes = excluded.notes,
+				updated_at = excluded.updated_at
+		`, entry.Project, entry.Alias, entry.Customer, entry.Notes, entry.UpdatedAt.Format(time.RFC3339Nano))
+		return err
+	})
+	return entry, err
+}
+
+// DeleteProjectRegistryEntry removes the registry entry of project.
+func (s *Store) DeleteProjectRegistryEntry(project string) error {
+	return s.execWrite(`DELETE FROM project_registry WHERE project_name = ?`, project)
+}
+
+// ProjectRegistryEntry returns the registry entry of project, nil when it
+// has none.
+func (s *Store) ProjectRegistryEntry(project string) (*models.ProjectRegistryEntry, error) {
+	entries, err := s.queryProjectRegistry(`WHERE project_name = ?`, project)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// ProjectRegistry returns every registry entry, sorted by project.
+func (s *Store) ProjectRegistry() ([]models.ProjectRegistryEntry, error) {
+	return s.queryProjectRegistry(``)
+}
+
+func (s *Store) queryProjectRegistry(where string, args ...interface{}) ([]models.ProjectRegistryEntry, error) {
+	rows, err := s.db.Query(`
+		SELECT project_name, alias, customer, notes, updated_at
+		FROM project_registry
+		`+where+`
+		ORDER BY project_name
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.ProjectRegistryEntry{}
+	for rows.Next() {
+		var entry models.ProjectRegistryEntry
+		var updatedAt string
+		if err := rows.Scan(&entry.Project, &entry.Alias, &entry.Customer, &entry.Notes, &updatedAt); err != nil {
+			return nil, err
+		}
+		entry.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAt)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,154 @@
+package state
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// CopyVolume is what was copied for a project in one minute.
+type CopyVolume struct {
+	Minute time.Time
+	Files  int
+	Bytes  int64
+}
+
+// CopyVolumeByMinute returns the files and bytes copied for project per
+// minute, oldest first. A file copied again counts at its last copy only.
+func (s *Store) CopyVolumeByMinute(project string) ([]CopyVolume, error) {
+	rows, err := s.db.Query(`
+		SELECT substr(copied_at, 1, 16) AS minute, COUNT(*), COALESCE(SUM(file_size), 0)
+		FROM copied_files
+		WHERE project_name = ? AND copied_at <> ''
+		GROUP BY minute
+		ORDER BY minute
+	`, project)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var volumes []CopyVolume
+	for rows.Next() {
+		var minute string
+		var volume CopyVolume
+		if err := rows.Scan(&minute, &volume.Files, &volume.Bytes); err != nil {
+			return nil, err
+		}
+		at, err := time.Parse("2006-01-02T15:04", minute)
+		if err != nil {
+			continue
+		}
+		volume.Minute = at.UTC()
+		volumes = append(volumes, volume)
+	}
+
+	return volumes, rows.Err()
+}
+
+// CaptureSpan is when a capture was taken and copied: from the first file
+// the camera wrote to the last file copied.
+type CaptureSpan struct {
+	CaptureNumber string
+	IsTest        bool
+	Completed     bool
+	FirstFileAt   time.Time
+	LastCopiedAt  time.Time
+}
+
+// CaptureSpans returns the captures of project whose first file time is
+// known, in capture order.
+func (s *Store) CaptureSpans(project string) ([]CaptureSpan, error) {
+	rows, err := s.db.Query(`
+		SELECT capture_number, is_test, completed, first_file_at, last_copied_at
+		FROM captures
+		WHERE service_name = ? AND project_name = ? AND first_file_at <> ''
+		ORDER BY CAST(capture_number AS INTEGER), capture_number
+	`, aggregateCaptureServiceName, project)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var spans []CaptureSpan
+	for rows.Next() {
+		var span CaptureSpan
+		var firstFileAt, lastCopiedAt string
+		if err := rows.Scan(&span.CaptureNumber, &span.IsTest, &span.Completed, &firstFileAt, &lastCopiedAt); err != nil {
+			return nil, err
+		}
+		first, err := time.Parse(time.RFC3339Nano, firstFileAt)
+		if err != nil {
+			continue
+		}
+		span.FirstFileAt = first.UTC()
+		if last, err := time.Parse(time.RFC3339Nano, lastCopiedAt); err == nil {
+			span.LastCopiedAt = last.UTC()
+		}
+		spans = append(spans, span)
+	}
+
+	return spans, rows.Err()
+}
+
+// NodeDelivery sums up what one node delivered for a project.
+type NodeDelivery struct {
+	Node     string
+	Files    int
+	RawFiles int
+	Captures int
+	Sensors  []string
+}
+
+// NodeDeliveries returns, per node, the files, RAW files and captures it
+// delivered for project and its sensor codes, sorted by node.
+func (s *Store) NodeDeliveries(project string) ([]NodeDelivery, error) {
+	rows, err := s.db.Query(`
+		SELECT node_name, capture_number, file_key
+		FROM capture_files
+		WHERE service_name = ? AND project_name = ? AND node_name <> ''
+	`, aggregateCaptureServiceName, project)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type tally struct {
+		delivery NodeDelivery
+		captures map[string]bool
+		sensors  map[string]bool
+	}
+	byNode := make(map[string]*tally)
+	for rows.Next() {
+		var node, captureNumber, fileKey string
+		if err := rows.Scan(&node, &captureNumber, &fileKey); err != nil {
+			return nil, err
+		}
+		t := byNode[node]
+		if t == nil {
+			t = &tally{delivery: NodeDelivery{Node: node}, captures: make(map[string]bool), sensors: make(map[string]bool)}
+			byNode[node] = t
+		}
+		t.delivery.Files++
+		t.captures[captureNumber] = true
+		if sensor, ok := strings.CutPrefix(fileKey, "raw:"); ok {
+			t.delivery.RawFiles++
+			t.sensors[sensor] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]NodeDelivery, 0, len(byNode))
+	for _, t := range byNode {
+		t.delivery.Captures = len(t.captures)
+		for sensor := range t.sensors {
+			t.delivery.Sensors = append(t.delivery.Sensors, sensor)
+		}
+		sort.Strings(t.delivery.Sensors)
+		deliveries = append(deliveries, t.delivery)
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].Node < deliveries[j].Node })
+	return deliveries, nil
+}
@@ -2,6 +2,7 @@ package state
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -37,6 +38,7 @@ type StatusSnapshot struct {
 	CompletedTestCaptures int
 	LastCaptureNumber     string
 	LastTestCaptureNumber string
+	BenchmarkWriteMBps    float64 // Destination write test of the current or last run, 0 if none
 }
 
 type CaptureObservation struct {
@@ -46,6 +48,11 @@ type CaptureObservation struct {
 	RequiredRawFiles int
 	RequireXML       bool
 	RequireDAT       bool
+	Destination      string
+	Node             string
+	FileModTime      time.Time     // Source mtime of the file, i.e. when the camera wrote it
+	CopiedAt         time.Time     // When the file was copied; zero when it was already present
+	Transfer         time.Duration // Time spent copying the file
 }
 
 type EADRecord struct {
@@ -224,6 +231,96 @@ func (s *Store) init() error {
 			processed_at TEXT NOT NULL,
 			PRIMARY KEY(project_name, relative_path)
 		);`,
+		`CREATE TABLE IF NOT EXISTS sync_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			service_name TEXT NOT NULL,
+			event_type TEXT NOT NULL,
+			project_name TEXT NOT NULL DEFAULT '',
+			destination TEXT NOT NULL DEFAULT '',
+			message TEXT NOT NULL DEFAULT '',
+			reason TEXT NOT NULL DEFAULT '',
+			totals TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS alerts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			service_name TEXT NOT NULL,
+			alert_key TEXT NOT NULL,
+			alert_type TEXT NOT NULL,
+			severity TEXT NOT NULL DEFAULT '',
+			category TEXT NOT NULL DEFAULT '',
+			title TEXT NOT NULL DEFAULT '',
+			message TEXT NOT NULL DEFAULT '',
+			reason TEXT NOT NULL DEFAULT '',
+			project_name TEXT NOT NULL DEFAULT '',
+			destination TEXT NOT NULL DEFAULT '',
+			count INTEGER NOT NULL DEFAULT 1,
+			state TEXT NOT NULL,
+			raised_at TEXT NOT NULL,
+			last_seen_at TEXT NOT NULL,
+			acknowledged_at TEXT NOT NULL DEFAULT '',
+			resolved_at TEXT NOT NULL DEFAULT ''
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_alerts_key ON alerts(service_name, alert_key, state);`,
+		`CREATE TABLE IF NOT EXISTS node_maintenance (
+			node_name TEXT PRIMARY KEY,
+			reason TEXT NOT NULL DEFAULT '',
+			since TEXT NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS verify_progress (
+			service_name TEXT PRIMARY KEY,
+			run_dir TEXT NOT NULL,
+			total INTEGER NOT NULL DEFAULT 0,
+			next_index INTEGER NOT NULL DEFAULT 0,
+			checked INTEGER NOT NULL DEFAULT 0,
+			skipped INTEGER NOT NULL DEFAULT 0,
+			mismatched TEXT NOT NULL DEFAULT '[]',
+			file_offset INTEGER NOT NULL DEFAULT 0,
+			source_hash BLOB,
+			dest_hash BLOB,
+			started_at TEXT NOT NULL,
+			updated_at TEXT NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS capture_tags (
+			project_name TEXT NOT NULL,
+			capture_number TEXT NOT NULL,
+			tag TEXT NOT NULL,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY(project_name, capture_number, tag)
+		);`,
+		`CREATE TABLE IF NOT EXISTS project_registry (
+			project_name TEXT PRIMARY KEY,
+			alias TEXT NOT NULL DEFAULT '',
+			customer TEXT NOT NULL DEFAULT '',
+			notes TEXT NOT NULL DEFAULT '',
+			updated_at TEXT NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS job_notes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			job_id TEXT NOT NULL,
+			project_name TEXT NOT NULL,
+			text TEXT NOT NULL,
+			created_at TEXT NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS sync_jobs (
+			job_id TEXT PRIMARY KEY,
+			service_name TEXT NOT NULL,
+			project_name TEXT NOT NULL,
+			destination TEXT NOT NULL DEFAULT '',
+			state TEXT NOT NULL,
+			reason TEXT NOT NULL DEFAULT '',
+			started_at TEXT NOT NULL,
+			finished_at TEXT NOT NULL DEFAULT '',
+			totals TEXT NOT NULL DEFAULT ''
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_sync_jobs_started ON sync_jobs(service_name, started_at);`,
+		`CREATE TABLE IF NOT EXISTS verify_files (
+			service_name TEXT NOT NULL,
+			seq INTEGER NOT NULL,
+			source_path TEXT NOT NULL,
+			dest_path TEXT NOT NULL,
+			PRIMARY KEY(service_name, seq)
+		);`,
 	}
 
 	for _, stmt := range ddl {
@@ -235,6 +332,39 @@ func (s *Store) init() error {
 	if err := s.ensureColumnExists("ead_records", "area", "TEXT NOT NULL DEFAULT ''"); err != nil {
 		return err
 	}
+	if err := s.ensureColumnExists("copied_files", "dest_dir", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.ensureColumnExists("capture_files", "node_name", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.ensureColumnExists("capture_files", "destination", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.ensureColumnExists("copied_files", "sha256", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.ensureColumnExists("captures", "first_file_at", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.ensureColumnExists("captures", "first_seen_at", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.ensureColumnExists("captures", "last_copied_at", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.ensureColumnExists("captures", "transfer_ms", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.ensureColumnExists("sync_status", "benchmark_write_mbps", "REAL NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.ensureColumnExists("sync_status", "benchmark_at", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.ensureColumnExists("verify_progress", "not_sampled", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
 
 	return s.ensureStatusRow()
 }
@@ -291,7 +421,7 @@ func (s *Store) LoadStatus() (StatusSnapshot, error) {
 	err := s.db.QueryRow(`
 		SELECT is_running, project, destination, max_parallelism,
 		       completed_captures, completed_test_captures,
-		       last_capture_number, last_test_capture_number
+		       last_capture_number, last_test_capture_number, benchmark_write_mbps
 		FROM sync_status
 		WHERE service_name = ?
 	`, s.serviceName).Scan(
@@ -303,6 +433,7 @@ func (s *Store) LoadStatus() (StatusSnapshot, error) {
 		&snapshot.CompletedTestCaptures,
 		&snapshot.LastCaptureNumber,
 		&snapshot.LastTestCaptureNumber,
+		&snapshot.BenchmarkWriteMBps,
 	)
 	if err != nil {
 		return StatusSnapshot{}, err
@@ -331,6 +462,8 @@ func (s *Store) StartRun(project, destination string, maxParallelism int) (Statu
 		    completed_test_captures = ?,
 		    last_capture_number = ?,
 		    last_test_capture_number = ?,
+		    benchmark_write_mbps = 0,
+		    benchmark_at = '',
 		    updated_at = ?
 		WHERE service_name = ?
 	`, project, destination, maxParallelism, stats.CompletedCaptures, stats.CompletedTestCaptures, stats.LastCaptureNumber, stats.LastTestCaptureNumber, time.Now().UTC().Format(time.RFC3339Nano), s.serviceName)
@@ -345,6 +478,25 @@ func (s *Store) StartRun(project, destination string, maxParallelism int) (Statu
 	return stats, nil
 }
 
+// RecordRunBenchmark keeps the destination write speed measured before the
+// running sync started.
+func (s *Store) RecordRunBenchmark(writeMBps float64, measuredAt time.Time) error {
+	return s.execWrite(`
+		UPDATE sync_status
+		SET benchmark_write_mbps = ?, benchmark_at = ?, updated_at = ?
+		WHERE service_name = ?
+	`, writeMBps, measuredAt.UTC().Format(time.RFC3339Nano), time.Now().UTC().Format(time.RFC3339Nano), s.serviceName)
+}
+
+// UpdateRunDestination records a destination change of the running sync.
+func (s *Store) UpdateRunDestination(destination string) error {
+	return s.execWrite(`
+		UPDATE sync_status
+		SET destination = ?, updated_at = ?
+		WHERE service_name = ?
+	`, destination, time.Now().UTC().Format(time.RFC3339Nano), s.serviceName)
+}
+
 func (s *Store) StopRun(snapshot StatusSnapshot) error {
 	if err := s.ensureStatusRow(); err != nil {
 		return err
@@ -501,20 +653,78 @@ func (s *Store) IsFileCopied(project, relativePath string, fileSize int64, modTi
 }
 
 func (s *Store) MarkFileCopied(project, relativePath string, fileSize int64, modTime time.Time) error {
+	return s.MarkFileCopiedTo(project, relativePath, fileSize, modTime, "")
+}
+
+// MarkFileCopiedTo records a copied file together with the destination
+// directory it was written to, so later snapshots can hardlink it. A
+// checksum recorded for an earlier copy is dropped.
+func (s *Store) MarkFileCopiedTo(project, relativePath string, fileSize int64, modTime time.Time, destDir string) error {
 	if strings.TrimSpace(project) == "" || strings.TrimSpace(relativePath) == "" {
 		return nil
 	}
 
 	relativePath = normalizeRelativePath(relativePath)
 	return s.execWrite(`
-		INSERT INTO copied_files (project_name, relative_path, file_size, mod_time_unix_ns, copied_at)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO copied_files (project_name, relative_path, file_size, mod_time_unix_ns, copied_at, dest_dir)
+		VALUES (?, ?, ?, ?, ?, ?)
 		ON CONFLICT(project_name, relative_path)
 		DO UPDATE SET
 			file_size = excluded.file_size,
 			mod_time_unix_ns = excluded.mod_time_unix_ns,
-			copied_at = excluded.copied_at
-	`, project, relativePath, fileSize, modTime.UTC().UnixNano(), time.Now().UTC().Format(time.RFC3339Nano))
+			copied_at = excluded.copied_at,
+			dest_dir = excluded.dest_dir,
+			sha256 = ''
+	`, project, relativePath, fileSize, modTime.UTC().UnixNano(), time.Now().UTC().Format(time.RFC3339Nano), destDir)
+}
+
+// RecordFileChecksum stores the hex SHA-256 a copied file was verified with.
+// Files not marked copied are ignored.
+func (s *Store) RecordFileChecksum(project, relativePath, sha256 string) error {
+	if strings.TrimSpace(project) == "" || strings.TrimSpace(relativePath) == "" {
+		return nil
+	}
+
+	return s.execWrite(`
+		UPDATE copied_files SET sha256 = ?
+		WHERE project_name = ? AND relative_path = ?
+	`, sha256, project, normalizeRelativePath(relativePath))
+}
+
+// CopiedFile is one ledger entry from copied_files.
+type CopiedFile struct {
+	RelativePath string
+	Size         int64
+	ModTime      time.Time
+	DestDir      string
+	SHA256       string // Hex digest the copy was verified with, "" when unverified
+}
+
+// ListCopiedFiles returns the ledger entries of a project that know their
+// destination directory.
+func (s *Store) ListCopiedFiles(project string) ([]CopiedFile, error) {
+	rows, err := s.db.Query(`
+		SELECT relative_path, file_size, mod_time_unix_ns, dest_dir, sha256
+		FROM copied_files
+		WHERE project_name = ? AND dest_dir <> ''
+	`, project)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []CopiedFile
+	for rows.Next() {
+		var file CopiedFile
+		var modTime int64
+		if err := rows.Scan(&file.RelativePath, &file.Size, &modTime, &file.DestDir, &file.SHA256); err != nil {
+			return nil, err
+		}
+		file.ModTime = time.Unix(0, modTime).UTC()
+		files = append(files, file)
+	}
+
+	return files, rows.Err()
 }
 
 func (s *Store) ResetCopiedFiles(project string) error {
@@ -591,6 +801,15 @@ func (s *Store) DeleteProject(project string) error {
 		if _, err := tx.Exec(`DELETE FROM ead_processing_status WHERE project_name = ?`, project); err != nil {
 			return err
 		}
+		if _, err := tx.Exec(`DELETE FROM capture_tags WHERE project_name = ?`, project); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM job_notes WHERE project_name = ?`, project); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM sync_jobs WHERE project_name = ?`, project); err != nil {
+			return err
+		}
 		_, err := tx.Exec(`
 			UPDATE sync_status
 			SET project = '', destination = '', max_parallelism = 0,
@@ -603,6 +822,30 @@ func (s *Store) DeleteProject(project string) error {
 	})
 }
 
+// RenameProject moves the history of project from to project to, so a job
+// remapped to a renamed project folder skips what it already copied. Rows
+// already recorded under to are kept.
+func (s *Store) RenameProject(from, to string) error {
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if from == "" || to == "" || from == to {
+		return nil
+	}
+
+	return s.withWriteTx(func(tx *sql.Tx) error {
+		for _, table := range []string{"projects", "captures", "capture_files", "copied_files", "ead_records", "ead_processing_status", "capture_tags", "project_registry", "job_notes"} {
+			if _, err := tx.Exec(`UPDATE OR IGNORE `+table+` SET project_name = ? WHERE project_name = ?`, to, from); err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+		}
+		_, err := tx.Exec(`
+			UPDATE sync_status
+			SET project = ?, updated_at = ?
+			WHERE project = ?
+		`, to, time.Now().UTC().Format(time.RFC3339Nano), from)
+		return err
+	})
+}
+
 func (s *Store) ClearDatabase() error {
 	return s.withWriteTx(func(tx *sql.Tx) error {
 		var running int
@@ -620,6 +863,12 @@ func (s *Store) ClearDatabase() error {
 			`DELETE FROM captures`,
 			`DELETE FROM ead_records`,
 			`DELETE FROM ead_processing_status`,
+			`DELETE FROM capture_tags`,
+			`DELETE FROM project_registry`,
+			`DELETE FROM job_notes`,
+			`DELETE FROM sync_jobs`,
+			`DELETE FROM sync_events`,
+			`DELETE FROM alerts`,
 		} {
 			if _, err := tx.Exec(query); err != nil {
 				return err
@@ -660,6 +909,30 @@ func (s *Store) IsCaptureDone(project, captureNumber string) (bool, error) {
 	return completed > 0, nil
 }
 
+// CompletedCaptureNumbers returns the completed non-test captures of project.
+func (s *Store) CompletedCaptureNumbers(project string) (map[string]bool, error) {
+	rows, err := s.db.Query(`
+		SELECT capture_number
+		FROM captures
+		WHERE service_name = ? AND project_name = ? AND completed = 1 AND is_test = 0
+	`, aggregateCaptureServiceName, project)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	captures := make(map[string]bool)
+	for rows.Next() {
+		var captureNumber string
+		if err := rows.Scan(&captureNumber); err != nil {
+			return nil, err
+		}
+		captures[models.NormalizeCaptureNumber(captureNumber)] = true
+	}
+
+	return captures, rows.Err()
+}
+
 // ResetProjectCaptureStatus resets the completion state of all captures for
 // the given project: clears capture_files records and sets completed=0 so
 // the counters start rebuilding from scratch on the next sync run.
@@ -679,7 +952,8 @@ func (s *Store) ResetProjectCaptureStatus(project string) error {
 
 		_, err = tx.Exec(`
 			UPDATE captures
-			SET completed = 0, completed_at = NULL, raw_count = 0, has_xml = 0, has_dat = 0
+			SET completed = 0, completed_at = NULL, raw_count = 0, has_xml = 0, has_dat = 0,
+			    first_file_at = '', last_copied_at = '', transfer_ms = 0
 			WHERE service_name = ? AND project_name = ?
 		`, aggregateCaptureServiceName, project)
 		return err
@@ -703,8 +977,8 @@ func (s *Store) RecordCapture(obs CaptureObservation) (models.PersistedCaptureSt
 		_, err := tx.Exec(`
 		INSERT INTO captures (
 			service_name, project_name, capture_number, is_test, data_type,
-			sensor_code, session_id, is_verified, last_seen_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			sensor_code, session_id, is_verified, first_seen_at, last_seen_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(service_name, project_name, capture_number)
 		DO UPDATE SET
 			is_test = CASE WHEN captures.is_test = 1 OR excluded.is_test = 1 THEN 1 ELSE 0 END,
@@ -723,6 +997,7 @@ func (s *Store) RecordCapture(obs CaptureObservation) (models.PersistedCaptureSt
 			obs.Info.SessionID,
 			boolToInt(obs.Info.IsVerified),
 			now,
+			now,
 		)
 		if err != nil {
 			return err
@@ -742,6 +1017,30 @@ func (s *Store) RecordCapture(obs CaptureObservation) (models.PersistedCaptureSt
 			inserted = affected > 0
 		}
 
+		if strings.TrimSpace(obs.Destination) != "" {
+			_, err = tx.Exec(`
+		UPDATE capture_files SET destination = ?
+		WHERE service_name = ? AND project_name = ? AND capture_number = ? AND file_key = ?
+	`, obs.Destination, aggregateService, obs.Project, obs.Info.CaptureNumber, obs.FileKey)
+			if err != nil {
+				return err
+			}
+		}
+
+		if node := strings.TrimSpace(obs.Node); node != "" {
+			_, err = tx.Exec(`
+		UPDATE capture_files SET node_name = ?
+		WHERE service_name = ? AND project_name = ? AND capture_number = ? AND file_key = ?
+	`, strings.ToUpper(node), aggregateService, obs.Project, obs.Info.CaptureNumber, obs.FileKey)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := recordCaptureTimingTx(tx, obs, inserted); err != nil {
+			return err
+		}
+
 		rawCount, hasXML, hasDAT, isTest, alreadyCompleted, err := s.captureProgress(tx, obs.Project, obs.Info.CaptureNumber)
 		if err != nil {
 			return err
@@ -750,7 +1049,9 @@ func (s *Store) RecordCapture(obs CaptureObservation) (models.PersistedCaptureSt
 		completed := false
 		requireXML := obs.RequireXML && !isTest
 		requireDAT := obs.RequireDAT && !isTest
-		shouldComplete := rawCount == obs.RequiredRawFiles && (!requireXML || hasXML) && (!requireDAT || hasDAT)
+		// >= because nodes in maintenance lower RequiredRawFiles while their
+		// files may still arrive.
+		shouldComplete := rawCount >= obs.RequiredRawFiles && (!requireXML || hasXML) && (!requireDAT || hasDAT)
 		if shouldComplete && !alreadyCompleted {
 			completed = inserted || !alreadyCompleted
 		}
@@ -803,6 +1104,134 @@ func (s *Store) RecordCapture(obs CaptureObservation) (models.PersistedCaptureSt
 	return resultStatus, resultDone, nil
 }
 
+// ListCaptureLocations returns, per capture, the destination directories its
+// files were copied to. A capture split by destination roll-over lists several.
+func (s *Store) ListCaptureLocations(project string) ([]models.CaptureLocation, error) {
+	rows, err := s.db.Query(`
+		SELECT DISTINCT capture_number, destination
+		FROM capture_files
+		WHERE service_name = ? AND project_name = ? AND destination <> ''
+		ORDER BY CAST(capture_number AS INTEGER), capture_number, destination
+	`, aggregateCaptureServiceName, project)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locations := make([]models.CaptureLocation, 0)
+	for rows.Next() {
+		var captureNumber, destination string
+		if err := rows.Scan(&captureNumber, &destination); err != nil {
+			return nil, err
+		}
+
+		if n := len(locations); n > 0 && locations[n-1].CaptureNumber == captureNumber {
+			locations[n-1].Destinations = append(locations[n-1].Destinations, destination)
+			continue
+		}
+		locations = append(locations, models.CaptureLocation{CaptureNumber: captureNumber, Destinations: []string{destination}})
+	}
+
+	return locations, rows.Err()
+}
+
+// ListCaptureSessions returns the acquisition sessions of project, oldest
+// first, with when the sync first and last saw a capture of each.
+func (s *Store) ListCaptureSessions(project string) ([]models.CaptureSession, error) {
+	rows, err := s.db.Query(`
+		SELECT session_id,
+		       MIN(CASE WHEN first_seen_at <> '' THEN first_seen_at ELSE last_seen_at END),
+		       MAX(last_seen_at),
+		       COUNT(*),
+		       SUM(completed),
+		       SUM(is_test)
+		FROM captures
+		WHERE service_name = ? AND project_name = ? AND session_id <> ''
+		GROUP BY session_id
+		ORDER BY 2, session_id
+	`, aggregateCaptureServiceName, project)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]models.CaptureSession, 0)
+	for rows.Next() {
+		var session models.CaptureSession
+		var firstSeen, lastSeen string
+		if err := rows.Scan(&session.SessionID, &firstSeen, &lastSeen, &session.Captures, &session.CompletedCaptures, &session.TestCaptures); err != nil {
+			return nil, err
+		}
+		session.FirstSeenAt, _ = time.Parse(time.RFC3339Nano, firstSeen)
+		session.LastSeenAt, _ = time.Parse(time.RFC3339Nano, lastSeen)
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// NodeSensors returns, per node, the RAW sensor codes it has delivered for
+// any capture of any project.
+func (s *Store) NodeSensors() (map[string][]string, error) {
+	rows, err := s.db.Query(`
+		SELECT DISTINCT node_name, file_key
+		FROM capture_files
+		WHERE service_name = ? AND node_name <> '' AND file_key LIKE 'raw:%'
+		ORDER BY node_name, file_key
+	`, aggregateCaptureServiceName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sensors := make(map[string][]string)
+	for rows.Next() {
+		var node, fileKey string
+		if err := rows.Scan(&node, &fileKey); err != nil {
+			return nil, err
+		}
+		sensors[node] = append(sensors[node], strings.TrimPrefix(fileKey, "raw:"))
+	}
+
+	return sensors, rows.Err()
+}
+
+// SetNodeMaintenance marks node as being serviced.
+func (s *Store) SetNodeMaintenance(node, reason string, since time.Time) error {
+	return s.execWrite(`
+		INSERT INTO node_maintenance (node_name, reason, since)
+		VALUES (?, ?, ?)
+		ON CONFLICT(node_name) DO UPDATE SET reason = excluded.reason
+	`, strings.ToUpper(strings.TrimSpace(node)), reason, since.UTC().Format(time.RFC3339Nano))
+}
+
+// ClearNodeMaintenance returns node to normal operation.
+func (s *Store) ClearNodeMaintenance(node string) error {
+	return s.execWrite(`DELETE FROM node_maintenance WHERE node_name = ?`, strings.ToUpper(strings.TrimSpace(node)))
+}
+
+// ListNodeMaintenance returns all nodes currently in maintenance.
+func (s *Store) ListNodeMaintenance() ([]models.NodeMaintenance, error) {
+	rows, err := s.db.Query(`SELECT node_name, reason, since FROM node_maintenance ORDER BY node_name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nodes []models.NodeMaintenance
+	for rows.Next() {
+		var entry models.NodeMaintenance
+		var since string
+		if err := rows.Scan(&entry.Node, &entry.Reason, &since); err != nil {
+			return nil, err
+		}
+		entry.Since, _ = time.Parse(time.RFC3339Nano, since)
+		nodes = append(nodes, entry)
+	}
+
+	return nodes, rows.Err()
+}
+
 func (s *Store) LoadProjectStatus(project string) (models.PersistedCaptureStatus, error) {
 	if strings.TrimSpace(project) == "" {
 		return models.PersistedCaptureStatus{}, nil
@@ -1140,6 +1569,77 @@ func (s *Store) ListCompletedEADRecords(project string) ([]EADRecord, error) {
 	return records, rows.Err()
 }
 
+// RecordSyncEvent appends a lifecycle event to the persistent event history.
+func (s *Store) RecordSyncEvent(event models.SyncEvent) error {
+	createdAt := event.Timestamp.UTC()
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+
+	totals := ""
+	if event.Totals != nil {
+		encoded, err := json.Marshal(event.Totals)
+		if err != nil {
+			return fmt.Errorf("failed to encode sync event totals: %w", err)
+		}
+		totals = string(encoded)
+	}
+
+	return s.execWrite(`
+		INSERT INTO sync_events (
+			service_name, event_type, project_name, destination,
+			message, reason, totals, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, s.serviceName, event.Type, event.Project, event.Destination, event.Message, event.Reason, totals, createdAt.Format(time.RFC3339Nano))
+}
+
+// ListSyncEvents returns the most recent lifecycle events, newest first.
+func (s *Store) ListSyncEvents(limit int) ([]models.SyncEvent, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := s.db.Query(`
+		SELECT event_type, project_name, destination, message, reason, totals, created_at
+		FROM sync_events
+		WHERE service_name = ?
+		ORDER BY id DESC
+		LIMIT ?
+	`, s.serviceName, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]models.SyncEvent, 0)
+	for rows.Next() {
+		var (
+			event        models.SyncEvent
+			totalsRaw    string
+			createdAtRaw string
+		)
+		if err := rows.Scan(&event.Type, &event.Project, &event.Destination, &event.Message, &event.Reason, &totalsRaw, &createdAtRaw); err != nil {
+			return nil, err
+		}
+		if totalsRaw != "" {
+			var totals models.SyncTotals
+			if err := json.Unmarshal([]byte(totalsRaw), &totals); err != nil {
+				return nil, err
+			}
+			event.Totals = &totals
+		}
+		if createdAtRaw != "" {
+			event.Timestamp, err = time.Parse(time.RFC3339Nano, createdAtRaw)
+			if err != nil {
+				return nil, err
+			}
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
 func (s *Store) persistedCaptureStatusTx(tx *sql.Tx, project string) (models.PersistedCaptureStatus, error) {
 	stats, err := s.projectStatsTx(tx, project)
 	if err != nil {
@@ -2,7 +2,10 @@ package state
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -759,3 +762,559 @@ func TestStoreListCompletedEADRecordsReturnsCompletedCapturesOnly(t *testing.T)
 		t.Fatalf("Area = %q, want Area-27", records[0].Area)
 	}
 }
+
+func TestStoreRecordsAndListsSyncEvents(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+
+	if err := store.RecordSyncEvent(models.SyncEvent{
+		Type:    models.SyncEventFailed,
+		Project: "ProjA",
+		Message: "Synchronization failed",
+		Reason:  "destination unavailable",
+	}); err != nil {
+		t.Fatalf("RecordSyncEvent(failed) returned error: %v", err)
+	}
+	if err := store.RecordSyncEvent(models.SyncEvent{
+		Type:    models.SyncEventFinished,
+		Project: "ProjA",
+		Message: "Synchronization finished",
+		Totals:  &models.SyncTotals{CopiedFiles: 14, CopiedBytes: 4096, CompletedCaptures: 1},
+	}); err != nil {
+		t.Fatalf("RecordSyncEvent(finished) returned error: %v", err)
+	}
+
+	events, err := store.ListSyncEvents(10)
+	if err != nil {
+		t.Fatalf("ListSyncEvents returned error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("len(events) = %d, want 2", len(events))
+	}
+	if events[0].Type != models.SyncEventFinished {
+		t.Fatalf("events[0].Type = %q, want %q", events[0].Type, models.SyncEventFinished)
+	}
+	if events[0].Totals == nil || events[0].Totals.CopiedFiles != 14 {
+		t.Fatalf("events[0].Totals = %+v, want 14 copied files", events[0].Totals)
+	}
+	if events[1].Reason != "destination unavailable" {
+		t.Fatalf("events[1].Reason = %q, want destination unavailable", events[1].Reason)
+	}
+}
+
+func TestStoreListsCaptureLocationsAcrossDestinations(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	info := func(sensor string) models.CaptureInfo {
+		return models.CaptureInfo{DataType: "Lvl00", CaptureNumber: "00007", ProjectName: "ProjA", SensorCode: sensor, SessionID: "ABC"}
+	}
+
+	for _, obs := range []CaptureObservation{
+		{Project: "ProjA", Info: info("00-00"), FileKey: "raw:00-00", RequiredRawFiles: 2, Destination: "/ucdata/2026-10-16/ProjA"},
+		{Project: "ProjA", Info: info("00-01"), FileKey: "raw:00-01", RequiredRawFiles: 2, Destination: "/ucdata-FLIGHT2/2026-10-16/ProjA"},
+		{Project: "ProjA", Info: models.CaptureInfo{DataType: "Lvl00", CaptureNumber: "00008", ProjectName: "ProjA", SensorCode: "00-00"}, FileKey: "raw:00-00", RequiredRawFiles: 2, Destination: "/ucdata-FLIGHT2/2026-10-16/ProjA"},
+	} {
+		if _, _, err := store.RecordCapture(obs); err != nil {
+			t.Fatalf("RecordCapture returned error: %v", err)
+		}
+	}
+
+	locations, err := store.ListCaptureLocations("ProjA")
+	if err != nil {
+		t.Fatalf("ListCaptureLocations returned error: %v", err)
+	}
+	if len(locations) != 2 {
+		t.Fatalf("expected 2 captures, got %#v", locations)
+	}
+	if locations[0].CaptureNumber != "00007" || len(locations[0].Destinations) != 2 {
+		t.Fatalf("expected capture 00007 to span two destinations, got %#v", locations[0])
+	}
+	if locations[1].CaptureNumber != "00008" || len(locations[1].Destinations) != 1 || locations[1].Destinations[0] != "/ucdata-FLIGHT2/2026-10-16/ProjA" {
+		t.Fatalf("unexpected location for capture 00008: %#v", locations[1])
+	}
+}
+
+func TestListCopiedFilesReturnsEntriesWithDestination(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	modTime := time.Unix(1710000000, 123).UTC()
+	if err := store.MarkFileCopiedTo("ProjA", "raw/a.raw", 10, modTime, "/ucdata/2026-05-01/ProjA"); err != nil {
+		t.Fatalf("MarkFileCopiedTo returned error: %v", err)
+	}
+	if err := store.MarkFileCopied("ProjA", "raw/b.raw", 20, modTime); err != nil {
+		t.Fatalf("MarkFileCopied returned error: %v", err)
+	}
+
+	files, err := store.ListCopiedFiles("ProjA")
+	if err != nil {
+		t.Fatalf("ListCopiedFiles returned error: %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("len(files) = %d, want 1", len(files))
+	}
+	got := files[0]
+	if got.RelativePath != "raw/a.raw" || got.Size != 10 || !got.ModTime.Equal(modTime) || got.DestDir != "/ucdata/2026-05-01/ProjA" {
+		t.Fatalf("file = %+v", got)
+	}
+}
+
+func TestStoreNodeMaintenanceRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	since := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	if err := store.SetNodeMaintenance("wu05", "disk swap", since); err != nil {
+		t.Fatalf("SetNodeMaintenance returned error: %v", err)
+	}
+	if err := store.SetNodeMaintenance("WU05", "disk swap, cable check", since.Add(time.Hour)); err != nil {
+		t.Fatalf("SetNodeMaintenance (update) returned error: %v", err)
+	}
+
+	nodes, err := store.ListNodeMaintenance()
+	if err != nil {
+		t.Fatalf("ListNodeMaintenance returned error: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].Node != "WU05" || nodes[0].Reason != "disk swap, cable check" || !nodes[0].Since.Equal(since) {
+		t.Fatalf("nodes = %+v", nodes)
+	}
+
+	if err := store.ClearNodeMaintenance("WU05"); err != nil {
+		t.Fatalf("ClearNodeMaintenance returned error: %v", err)
+	}
+	if nodes, err := store.ListNodeMaintenance(); err != nil || len(nodes) != 0 {
+		t.Fatalf("after clear nodes = %+v, err = %v", nodes, err)
+	}
+}
+
+func TestStoreVerifyCheckpointRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	files := []VerifyFile{{Source: "/src/a.raw", Dest: "/dst/a.raw"}, {Source: "/src/b.raw", Dest: "/dst/b.raw"}}
+	started := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	if err := store.StartVerify("/dst/run", files, started); err != nil {
+		t.Fatalf("StartVerify returned error: %v", err)
+	}
+
+	checkpoint := VerifyCheckpoint{
+		RunDir:     "/dst/run",
+		NotSampled: 8,
+		NextIndex:  1,
+		Checked:    1,
+		Mismatched: []string{"/dst/a.raw"},
+		FileOffset: 4096,
+		SourceHash: []byte{1, 2, 3},
+		DestHash:   []byte{4, 5, 6},
+	}
+	if err := store.SaveVerifyCheckpoint(checkpoint); err != nil {
+		t.Fatalf("SaveVerifyCheckpoint returned error: %v", err)
+	}
+
+	loaded, ok, err := store.LoadVerifyCheckpoint()
+	if err != nil || !ok {
+		t.Fatalf("LoadVerifyCheckpoint = %v, %v", ok, err)
+	}
+	if loaded.Total != 2 || loaded.NotSampled != 8 || loaded.NextIndex != 1 || loaded.Checked != 1 || loaded.FileOffset != 4096 ||
+		len(loaded.Mismatched) != 1 || string(loaded.DestHash) != string([]byte{4, 5, 6}) || !loaded.StartedAt.Equal(started) {
+		t.Fatalf("checkpoint = %+v", loaded)
+	}
+	loadedFiles, err := store.LoadVerifyFiles()
+	if err != nil || len(loadedFiles) != 2 || loadedFiles[1] != files[1] {
+		t.Fatalf("LoadVerifyFiles = %+v, %v", loadedFiles, err)
+	}
+
+	if err := store.ClearVerify(); err != nil {
+		t.Fatalf("ClearVerify returned error: %v", err)
+	}
+	if _, ok, err := store.LoadVerifyCheckpoint(); err != nil || ok {
+		t.Fatalf("after clear ok = %v, err = %v", ok, err)
+	}
+}
+
+func TestStoreAlertLifecycle(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	raisedAt := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	alert := models.Alert{Type: models.SyncEventTaskStalled, Severity: "warning", Message: "WU01/E$ stalled", Project: "ProjA", LastSeenAt: raisedAt}
+
+	first, err := store.RaiseAlert(alert)
+	if err != nil {
+		t.Fatalf("RaiseAlert returned error: %v", err)
+	}
+	alert.Message = "WU02/E$ stalled"
+	alert.LastSeenAt = raisedAt.Add(time.Minute)
+	repeat, err := store.RaiseAlert(alert)
+	if err != nil {
+		t.Fatalf("RaiseAlert (repeat) returned error: %v", err)
+	}
+	if repeat.ID != first.ID || repeat.Count != 2 || repeat.Message != "WU02/E$ stalled" || !repeat.RaisedAt.Equal(raisedAt) || repeat.State != models.AlertOpen {
+		t.Fatalf("repeat = %+v, want the first alert bumped", repeat)
+	}
+
+	acknowledged, err := store.AcknowledgeAlert(first.ID, raisedAt.Add(2*time.Minute))
+	if err != nil || acknowledged.State != models.AlertAcknowledged || acknowledged.AcknowledgedAt == nil {
+		t.Fatalf("AcknowledgeAlert = %+v, %v", acknowledged, err)
+	}
+	if _, err := store.ResolveAlert(first.ID, raisedAt.Add(3*time.Minute)); err != nil {
+		t.Fatalf("ResolveAlert returned error: %v", err)
+	}
+	if open, err := store.ListAlerts(false, 0); err != nil || len(open) != 0 {
+		t.Fatalf("open alerts = %+v, %v", open, err)
+	}
+
+	again, err := store.RaiseAlert(alert)
+	if err != nil || again.ID == first.ID || again.Count != 1 {
+		t.Fatalf("RaiseAlert after resolve = %+v, %v, want a new alert", again, err)
+	}
+	all, err := store.ListAlerts(true, 0)
+	if err != nil || len(all) != 2 || all[0].ID != again.ID {
+		t.Fatalf("all alerts = %+v, %v", all, err)
+	}
+	if _, err := store.ResolveAlert(9999, time.Now()); err != ErrAlertNotFound {
+		t.Fatalf("ResolveAlert(unknown) error = %v, want ErrAlertNotFound", err)
+	}
+}
+
+func TestStoreReportsCaptureLatencyPercentiles(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	acquired := time.Date(2024, 5, 17, 10, 0, 0, 0, time.UTC)
+	// Capture N is copied N minutes after its first file was written.
+	for n := 1; n <= 4; n++ {
+		captureNumber := fmt.Sprintf("%05d", n)
+		for i, fileKey := range []string{"raw:00-00", "raw:00-01", "xml:CU", "dat:CU"} {
+			if _, _, err := store.RecordCapture(CaptureObservation{
+				Project:          "ProjA",
+				Info:             models.CaptureInfo{DataType: "Lvl00", CaptureNumber: captureNumber, ProjectName: "ProjA"},
+				FileKey:          fileKey,
+				RequiredRawFiles: 2,
+				RequireXML:       true,
+				RequireDAT:       true,
+				FileModTime:      acquired.Add(time.Duration(3-i) * time.Second),
+				CopiedAt:         acquired.Add(time.Duration(n)*time.Minute - time.Duration(3-i)*time.Second),
+				Transfer:         time.Second,
+			}); err != nil {
+				t.Fatalf("RecordCapture(%s, %s) error = %v", captureNumber, fileKey, err)
+			}
+		}
+	}
+
+	stats, err := store.CaptureTimingStats("ProjA")
+	if err != nil {
+		t.Fatalf("CaptureTimingStats() error = %v", err)
+	}
+	if stats.Captures != 4 || stats.LatencyP50Seconds != 120 || stats.LatencyP95Seconds != 240 || stats.LatencyMaxSeconds != 240 || stats.TransferP50Seconds != 4 {
+		t.Fatalf("CaptureTimingStats() = %+v, want 4 captures, p50 120s, p95 240s and 4s transfer each", stats)
+	}
+}
+
+func TestStoreReportsCopyVolumeSpansAndNodeDeliveries(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	acquired := time.Date(2024, 5, 17, 10, 0, 0, 0, time.UTC)
+	for _, step := range []struct {
+		capture, fileKey, node string
+	}{
+		{"00001", "raw:00-00", "WU01"},
+		{"00001", "raw:00-01", "WU02"},
+		{"00001", "xml:CU", "WU01"},
+		{"00002", "raw:00-00", "WU01"},
+	} {
+		if _, _, err := store.RecordCapture(CaptureObservation{
+			Project:          "ProjA",
+			Info:             models.CaptureInfo{DataType: "Lvl00", CaptureNumber: step.capture, ProjectName: "ProjA"},
+			FileKey:          step.fileKey,
+			RequiredRawFiles: 2,
+			RequireXML:       true,
+			Node:             step.node,
+			FileModTime:      acquired,
+			CopiedAt:         acquired.Add(2 * time.Minute),
+		}); err != nil {
+			t.Fatalf("RecordCapture(%s, %s) error = %v", step.capture, step.fileKey, err)
+		}
+	}
+	for _, path := range []string{"a.raw", "b.raw"} {
+		if err := store.MarkFileCopiedTo("ProjA", path, 1024, acquired, "/ucdata"); err != nil {
+			t.Fatalf("MarkFileCopiedTo(%s) error = %v", path, err)
+		}
+	}
+
+	volumes, err := store.CopyVolumeByMinute("ProjA")
+	if err != nil {
+		t.Fatalf("CopyVolumeByMinute() error = %v", err)
+	}
+	if len(volumes) != 1 || volumes[0].Files != 2 || volumes[0].Bytes != 2048 || volumes[0].Minute.IsZero() {
+		t.Fatalf("CopyVolumeByMinute() = %+v, want both files in one minute", volumes)
+	}
+
+	spans, err := store.CaptureSpans("ProjA")
+	if err != nil {
+		t.Fatalf("CaptureSpans() error = %v", err)
+	}
+	if len(spans) != 2 || spans[0].CaptureNumber != "00001" || !spans[0].Completed || spans[1].Completed {
+		t.Fatalf("CaptureSpans() = %+v, want 00001 complete and 00002 not", spans)
+	}
+	if !spans[0].FirstFileAt.Equal(acquired) || !spans[0].LastCopiedAt.Equal(acquired.Add(2*time.Minute)) {
+		t.Fatalf("span 00001 = %+v, want first file at %s copied 2m later", spans[0], acquired)
+	}
+
+	nodes, err := store.NodeDeliveries("ProjA")
+	if err != nil {
+		t.Fatalf("NodeDeliveries() error = %v", err)
+	}
+	if len(nodes) != 2 || nodes[0].Node != "WU01" || nodes[0].Files != 3 || nodes[0].RawFiles != 2 || nodes[0].Captures != 2 {
+		t.Fatalf("NodeDeliveries() = %+v, want WU01 with 3 files, 2 RAW, 2 captures", nodes)
+	}
+	if len(nodes[1].Sensors) != 1 || nodes[1].Sensors[0] != "00-01" {
+		t.Fatalf("WU02 sensors = %v, want [00-01]", nodes[1].Sensors)
+	}
+}
+
+func TestStoreCaptureTagsFollowTheProject(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	tags, err := NormalizeCaptureTags([]string{" Cloudy", "re-fly", "cloudy"})
+	if err != nil || strings.Join(tags, ",") != "cloudy,re-fly" {
+		t.Fatalf("NormalizeCaptureTags() = %v, %v, want cloudy,re-fly", tags, err)
+	}
+	if _, err := NormalizeCaptureTag("bad tag"); err == nil {
+		t.Fatal("NormalizeCaptureTag accepted a space")
+	}
+
+	if err := store.AddCaptureTags("ProjA", "00005", tags); err != nil {
+		t.Fatalf("AddCaptureTags() error = %v", err)
+	}
+	if err := store.AddCaptureTags("ProjA", "00005", []string{"cloudy", "haze"}); err != nil {
+		t.Fatalf("AddCaptureTags() error = %v", err)
+	}
+	if err := store.SetCaptureTags("ProjA", "00006", []string{"re-fly"}); err != nil {
+		t.Fatalf("SetCaptureTags() error = %v", err)
+	}
+	if err := store.RemoveCaptureTag("ProjA", "00005", "re-fly"); err != nil {
+		t.Fatalf("RemoveCaptureTag() error = %v", err)
+	}
+	if got, err := store.TagsOfCapture("ProjA", "00005"); err != nil || strings.Join(got, ",") != "cloudy,haze" {
+		t.Fatalf("TagsOfCapture(00005) = %v, %v, want cloudy,haze", got, err)
+	}
+
+	if err := store.RenameProject("ProjA", "ProjB"); err != nil {
+		t.Fatalf("RenameProject() error = %v", err)
+	}
+	all, err := store.CaptureTags("ProjB")
+	if err != nil || len(all) != 2 || strings.Join(all["00006"], ",") != "re-fly" {
+		t.Fatalf("CaptureTags(ProjB) = %v, %v, want the tags of both captures", all, err)
+	}
+
+	if err := store.DeleteProject("ProjB"); err != nil {
+		t.Fatalf("DeleteProject() error = %v", err)
+	}
+	if all, err := store.CaptureTags("ProjB"); err != nil || len(all) != 0 {
+		t.Fatalf("CaptureTags after delete = %v, %v, want none", all, err)
+	}
+}
+
+func TestStoreProjectRegistryKeepsAliasesUnique(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	if _, err := store.SetProjectRegistryEntry(models.ProjectRegistryEntry{Project: "ProjA"}); err == nil {
+		t.Fatal("SetProjectRegistryEntry accepted an empty entry")
+	}
+	saved, err := store.SetProjectRegistryEntry(models.ProjectRegistryEntry{Project: " ProjA ", Alias: " North block ", Customer: "Cadastre"})
+	if err != nil || saved.Project != "ProjA" || saved.Alias != "North block" || saved.UpdatedAt.IsZero() {
+		t.Fatalf("SetProjectRegistryEntry() = %+v, %v", saved, err)
+	}
+	if _, err := store.SetProjectRegistryEntry(models.ProjectRegistryEntry{Project: "ProjB", Alias: "north BLOCK"}); !errors.Is(err, ErrProjectAliasTaken) {
+		t.Fatalf("duplicate alias error = %v, want ErrProjectAliasTaken", err)
+	}
+	if _, err := store.SetProjectRegistryEntry(models.ProjectRegistryEntry{Project: "ProjA", Alias: "North block", Notes: "Re-fly lines 3-5"}); err != nil {
+		t.Fatalf("updating ProjA with its own alias: %v", err)
+	}
+
+	if err := store.RenameProject("ProjA", "ProjA-2026"); err != nil {
+		t.Fatalf("RenameProject() error = %v", err)
+	}
+	entry, err := store.ProjectRegistryEntry("ProjA-2026")
+	if err != nil || entry == nil || entry.Notes != "Re-fly lines 3-5" || entry.Customer != "" {
+		t.Fatalf("ProjectRegistryEntry(ProjA-2026) = %+v, %v, want the replaced entry", entry, err)
+	}
+
+	if err := store.DeleteProjectRegistryEntry("ProjA-2026"); err != nil {
+		t.Fatalf("DeleteProjectRegistryEntry() error = %v", err)
+	}
+	if entries, err := store.ProjectRegistry(); err != nil || len(entries) != 0 {
+		t.Fatalf("ProjectRegistry() = %+v, %v, want none", entries, err)
+	}
+}
+
+func TestStoreKeepsChecksumsAndSessionsAcrossRestarts(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state.db")
+	store, err := New(path, "ucxsync-test")
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	modTime := time.Unix(1710000000, 0).UTC()
+	if err := store.MarkFileCopiedTo("ProjA", "a.raw", 10, modTime, "/ucdata/2026-05-01/ProjA"); err != nil {
+		t.Fatalf("MarkFileCopiedTo returned error: %v", err)
+	}
+	if err := store.RecordFileChecksum("ProjA", "a.raw", "abc123"); err != nil {
+		t.Fatalf("RecordFileChecksum returned error: %v", err)
+	}
+	for _, obs := range []CaptureObservation{
+		{Project: "ProjA", Info: models.CaptureInfo{CaptureNumber: "00001", SensorCode: "00-00", SessionID: "S1"}, FileKey: "raw:00-00", RequiredRawFiles: 1},
+		{Project: "ProjA", Info: models.CaptureInfo{CaptureNumber: "00002", SensorCode: "00-00", SessionID: "S1"}, FileKey: "raw:00-00", RequiredRawFiles: 2},
+		{Project: "ProjA", Info: models.CaptureInfo{CaptureNumber: "00003", SensorCode: "00-00", SessionID: "S2", IsTest: true}, FileKey: "raw:00-00", RequiredRawFiles: 1},
+	} {
+		if _, _, err := store.RecordCapture(obs); err != nil {
+			t.Fatalf("RecordCapture returned error: %v", err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	store = newNamedTestStore(t, path, "ucxsync-test")
+	files, err := store.ListCopiedFiles("ProjA")
+	if err != nil || len(files) != 1 || files[0].SHA256 != "abc123" {
+		t.Fatalf("ListCopiedFiles = %+v, %v, want the recorded checksum", files, err)
+	}
+	sessions, err := store.ListCaptureSessions("ProjA")
+	if err != nil {
+		t.Fatalf("ListCaptureSessions returned error: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("sessions = %+v, want 2", sessions)
+	}
+	if s := sessions[0]; s.SessionID != "S1" || s.Captures != 2 || s.CompletedCaptures != 1 || s.FirstSeenAt.IsZero() {
+		t.Fatalf("first session = %+v", s)
+	}
+	if s := sessions[1]; s.SessionID != "S2" || s.TestCaptures != 1 {
+		t.Fatalf("second session = %+v", s)
+	}
+	status, err := store.LoadProjectStatus("ProjA")
+	if err != nil || status.CompletedCaptures != 1 || status.CompletedTestCaptures != 1 {
+		t.Fatalf("LoadProjectStatus = %+v, %v, want the counters kept", status, err)
+	}
+
+	// A new copy of the file drops the checksum of the old one.
+	if err := store.MarkFileCopiedTo("ProjA", "a.raw", 12, modTime, "/ucdata/2026-05-01/ProjA"); err != nil {
+		t.Fatalf("MarkFileCopiedTo returned error: %v", err)
+	}
+	if files, _ := store.ListCopiedFiles("ProjA"); len(files) != 1 || files[0].SHA256 != "" {
+		t.Fatalf("ListCopiedFiles = %+v, want the checksum dropped", files)
+	}
+}
+
+func TestStoreKeepsJobNotesPerJobAndProject(t *testing.T) {
+	store := newTestStore(t)
+
+	if _, err := store.AddJobNote(models.JobNote{JobID: "job-1", Project: "ProjA", Text: "   "}); err == nil {
+		t.Fatal("AddJobNote accepted an empty note")
+	}
+	if _, err := store.AddJobNote(models.JobNote{Project: "ProjA", Text: "no job"}); err == nil {
+		t.Fatal("AddJobNote accepted a note without a job")
+	}
+	if _, err := store.AddJobNote(models.JobNote{JobID: "job-1", Project: "ProjA", Text: strings.Repeat("x", maxJobNoteLength+1)}); err == nil {
+		t.Fatal("AddJobNote accepted an overlong note")
+	}
+
+	first, err := store.AddJobNote(models.JobNote{JobID: "job-1", Project: "ProjA", Text: " Node 5 rebooted mid-flight "})
+	if err != nil {
+		t.Fatalf("AddJobNote returned error: %v", err)
+	}
+	if first.ID == 0 || first.Text != "Node 5 rebooted mid-flight" || first.CreatedAt.IsZero() {
+		t.Fatalf("first = %+v, want a trimmed note with id and time", first)
+	}
+	if _, err := store.AddJobNote(models.JobNote{JobID: "job-2", Project: "ProjA", Text: "Second sortie"}); err != nil {
+		t.Fatalf("AddJobNote returned error: %v", err)
+	}
+	if _, err := store.AddJobNote(models.JobNote{JobID: "job-3", Project: "ProjB", Text: "Other project"}); err != nil {
+		t.Fatalf("AddJobNote returned error: %v", err)
+	}
+
+	notes, err := store.JobNotes("job-1")
+	if err != nil || len(notes) != 1 || notes[0].ID != first.ID {
+		t.Fatalf("JobNotes(job-1) = %+v, %v, want the first note", notes, err)
+	}
+	notes, err = store.ProjectJobNotes("ProjA")
+	if err != nil || len(notes) != 2 {
+		t.Fatalf("ProjectJobNotes(ProjA) = %+v, %v, want two notes", notes, err)
+	}
+
+	project, err := store.DeleteJobNote(first.ID)
+	if err != nil || project != "ProjA" {
+		t.Fatalf("DeleteJobNote = %q, %v, want ProjA", project, err)
+	}
+	if project, err := store.DeleteJobNote(first.ID); err != nil || project != "" {
+		t.Fatalf("second DeleteJobNote = %q, %v, want no note", project, err)
+	}
+
+	if err := store.DeleteProject("ProjA"); err != nil {
+		t.Fatalf("DeleteProject returned error: %v", err)
+	}
+	if notes, err := store.ProjectJobNotes("ProjA"); err != nil || len(notes) != 0 {
+		t.Fatalf("ProjectJobNotes after delete = %+v, %v, want none", notes, err)
+	}
+	if notes, err := store.ProjectJobNotes("ProjB"); err != nil || len(notes) != 1 {
+		t.Fatalf("ProjectJobNotes(ProjB) = %+v, %v, want the other project's note", notes, err)
+	}
+}
+
+func TestStoreJobHistoryListsFinishedAndInterruptedJobs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+	store := newNamedTestStore(t, path, "ucxsync-test")
+
+	started := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	if err := store.RecordJobStarted(models.JobRecord{JobID: "job-1", Project: "ProjA", Destination: "/data/a", StartedAt: started}); err != nil {
+		t.Fatalf("RecordJobStarted returned error: %v", err)
+	}
+	totals := models.SyncTotals{CopiedFiles: 12, CopiedBytes: 1 << 20, CompletedCaptures: 2}
+	if err := store.RecordJobFinished("job-1", models.JobFinished, "idle", started.Add(time.Hour), totals); err != nil {
+		t.Fatalf("RecordJobFinished returned error: %v", err)
+	}
+	for i, project := range []string{"ProjB", "ProjA"} {
+		job := models.JobRecord{JobID: fmt.Sprintf("job-%d", i+2), Project: project, StartedAt: started.Add(time.Duration(i+2) * time.Hour)}
+		if err := store.RecordJobStarted(job); err != nil {
+			t.Fatalf("RecordJobStarted returned error: %v", err)
+		}
+	}
+
+	if err := store.InterruptRunningJobs(); err != nil {
+		t.Fatalf("InterruptRunningJobs returned error: %v", err)
+	}
+
+	jobs, err := store.ListJobs("ProjA", 10)
+	if err != nil {
+		t.Fatalf("ListJobs returned error: %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].JobID != "job-3" || jobs[1].JobID != "job-1" {
+		t.Fatalf("ListJobs(ProjA) = %+v, want job-3 and job-1, newest first", jobs)
+	}
+	if jobs[0].State != models.JobInterrupted || jobs[0].FinishedAt != nil {
+		t.Fatalf("job-3 = %+v, want it interrupted", jobs[0])
+	}
+	finished := jobs[1]
+	if finished.State != models.JobFinished || finished.Reason != "idle" || finished.FinishedAt == nil ||
+		!finished.FinishedAt.Equal(started.Add(time.Hour)) || finished.Totals != totals {
+		t.Fatalf("job-1 = %+v, want it finished with its totals", finished)
+	}
+
+	if jobs, err := store.ListJobs("", 1); err != nil || len(jobs) != 1 || jobs[0].JobID != "job-3" {
+		t.Fatalf("ListJobs(limit 1) = %+v, %v, want the newest job", jobs, err)
+	}
+	if err := store.DeleteProject("ProjA"); err != nil {
+		t.Fatalf("DeleteProject returned error: %v", err)
+	}
+	if jobs, err := store.ListJobs("", 10); err != nil || len(jobs) != 1 || jobs[0].Project != "ProjB" {
+		t.Fatalf("ListJobs after delete = %+v, %v, want the ProjB job", jobs, err)
+	}
+}
@@ -0,0 +1,137 @@
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxCaptureTagLength bounds a tag so it stays readable in reports.
+const maxCaptureTagLength = 32
+
+// NormalizeCaptureTag trims and lower-cases tag and checks it is made of
+// letters, digits, '-', '_' and '.', e.g. "cloudy" or "re-fly".
+func NormalizeCaptureTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", fmt.Errorf("tag is empty")
+	}
+	if utf8.RuneCountInString(tag) > maxCaptureTagLength {
+		return "", fmt.Errorf("tag %q is longer than %d characters", tag, maxCaptureTagLength)
+	}
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' && r != '.' {
+			return "", fmt.Errorf("tag %q may only contain letters, digits, '-', '_' and '.'", tag)
+		}
+	}
+	return tag, nil
+}
+
+// NormalizeCaptureTags normalizes tags with NormalizeCaptureTag, dropping
+// duplicates and sorting them.
+func NormalizeCaptureTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag, err := NormalizeCaptureTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// AddCaptureTags tags a capture of project. Tags it already has are kept.
+func (s *Store) AddCaptureTags(project, captureNumber string, tags []string) error {
+	return s.withWriteTx(func(tx *sql.Tx) error {
+		return addCaptureTagsTx(tx, project, captureNumber, tags)
+	})
+}
+
+// SetCaptureTags replaces the tags of a capture; no tags clears them.
+func (s *Store) SetCaptureTags(project, captureNumber string, tags []string) error {
+	return s.withWriteTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM capture_tags WHERE project_name = ? AND capture_number = ?`, project, captureNumber); err != nil {
+			return err
+		}
+		return addCaptureTagsTx(tx, project, captureNumber, tags)
+	})
+}
+
+// RemoveCaptureTag removes one tag from a capture.
+func (s *Store) RemoveCaptureTag(project, captureNumber, tag string) error {
+	return s.execWrite(`
+		DELETE FROM capture_tags
+		WHERE project_name = ? AND capture_number = ? AND tag = ?
+	`, project, captureNumber, tag)
+}
+
+func addCaptureTagsTx(tx *sql.Tx, project, captureNumber string, tags []string) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	for _, tag := range tags {
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO capture_tags (project_name, capture_number, tag, created_at)
+			VALUES (?, ?, ?, ?)
+		`, project, captureNumber, tag, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CaptureTags returns the sorted tags of every tagged capture of project,
+// keyed by capture number.
+func (s *Store) CaptureTags(project string) (map[string][]string, error) {
+	rows, err := s.db.Query(`
+		SELECT capture_number, tag
+		FROM capture_tags
+		WHERE project_name = ?
+		ORDER BY CAST(capture_number AS INTEGER), capture_number, tag
+	`, project)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var captureNumber, tag string
+		if err := rows.Scan(&captureNumber, &tag); err != nil {
+			return nil, err
+		}
+		tags[captureNumber] = append(tags[captureNumber], tag)
+	}
+	return tags, rows.Err()
+}
+
+// TagsOfCapture returns the sorted tags of one capture.
+func (s *Store) TagsOfCapture(project, captureNumber string) ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT tag FROM capture_tags
+		WHERE project_name = ? AND capture_number = ?
+	`, project, captureNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags, rows.Err()
+}
@@ -0,0 +1,114 @@
+package state
+
+import (
+	"database/sql"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// timingLayout has a fixed width so stored times compare as strings.
+const timingLayout = "2006-01-02T15:04:05.000000Z"
+
+// recordCaptureTimingTx folds one file into the capture's timing: the
+// earliest file mtime, the latest copy and, for a file key seen for the first
+// time, its transfer time.
+func recordCaptureTimingTx(tx *sql.Tx, obs CaptureObservation, newFile bool) error {
+	firstFileAt := ""
+	if !obs.FileModTime.IsZero() {
+		firstFileAt = obs.FileModTime.UTC().Format(timingLayout)
+	}
+	lastCopiedAt := ""
+	if !obs.CopiedAt.IsZero() {
+		lastCopiedAt = obs.CopiedAt.UTC().Format(timingLayout)
+	}
+	var transferMS int64
+	if newFile && obs.Transfer > 0 {
+		transferMS = obs.Transfer.Milliseconds()
+	}
+	if firstFileAt == "" && lastCopiedAt == "" && transferMS == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(`
+		UPDATE captures
+		SET first_file_at = CASE WHEN ? <> '' AND (first_file_at = '' OR ? < first_file_at) THEN ? ELSE first_file_at END,
+		    last_copied_at = CASE WHEN ? > last_copied_at THEN ? ELSE last_copied_at END,
+		    transfer_ms = transfer_ms + ?
+		WHERE service_name = ? AND project_name = ? AND capture_number = ?
+	`, firstFileAt, firstFileAt, firstFileAt, lastCopiedAt, lastCopiedAt, transferMS,
+		aggregateCaptureServiceName, obs.Project, obs.Info.CaptureNumber)
+	return err
+}
+
+// CaptureTimingStats summarises how far the completed, non-test captures of
+// project lagged behind acquisition: from the first file written by the
+// camera to the last file copied.
+func (s *Store) CaptureTimingStats(project string) (models.CaptureTimingStats, error) {
+	var stats models.CaptureTimingStats
+	if strings.TrimSpace(project) == "" {
+		return stats, nil
+	}
+
+	rows, err := s.db.Query(`
+		SELECT first_file_at, last_copied_at, COALESCE(completed_at, ''), transfer_ms
+		FROM captures
+		WHERE service_name = ? AND project_name = ? AND completed = 1 AND is_test = 0 AND first_file_at <> ''
+	`, aggregateCaptureServiceName, project)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+
+	var latencies, transfers []float64
+	for rows.Next() {
+		var firstFileAt, lastCopiedAt, completedAt string
+		var transferMS int64
+		if err := rows.Scan(&firstFileAt, &lastCopiedAt, &completedAt, &transferMS); err != nil {
+			return stats, err
+		}
+
+		first, err := time.Parse(time.RFC3339Nano, firstFileAt)
+		if err != nil {
+			continue
+		}
+		end := lastCopiedAt
+		if end == "" {
+			end = completedAt
+		}
+		last, err := time.Parse(time.RFC3339Nano, end)
+		if err != nil {
+			continue
+		}
+		latencies = append(latencies, math.Max(0, last.Sub(first).Seconds()))
+		transfers = append(transfers, float64(transferMS)/1000)
+	}
+	if err := rows.Err(); err != nil {
+		return stats, err
+	}
+
+	stats.Captures = len(latencies)
+	stats.LatencyP50Seconds = percentile(latencies, 50)
+	stats.LatencyP95Seconds = percentile(latencies, 95)
+	stats.LatencyMaxSeconds = percentile(latencies, 100)
+	stats.TransferP50Seconds = percentile(transfers, 50)
+	stats.TransferP95Seconds = percentile(transfers, 95)
+	return stats, nil
+}
+
+// percentile returns the nearest-rank p-th percentile of values, 0 for none.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
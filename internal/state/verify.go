@@ -0,0 +1,147 @@
+package state
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// VerifyFile is one copied file queued for verification.
+type VerifyFile struct {
+	Source string
+	Dest   string
+}
+
+// VerifyCheckpoint is the resume token of a verify pass: the files before
+// NextIndex are done, and the file at NextIndex was hashed up to FileOffset
+// with the marshaled SHA-256 states SourceHash and DestHash. NotSampled
+// counts the files of the run a sampled pass left out.
+type VerifyCheckpoint struct {
+	RunDir     string
+	Total      int
+	NotSampled int
+	NextIndex  int
+	Checked    int
+	Skipped    int
+	Mismatched []string
+	FileOffset int64
+	SourceHash []byte
+	DestHash   []byte
+	StartedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// StartVerify replaces any earlier verify pass with a new one over files.
+func (s *Store) StartVerify(runDir string, files []VerifyFile, startedAt time.Time) error {
+	return s.withWriteTx(func(tx *sql.Tx) error {
+		if err := s.clearVerifyTx(tx); err != nil {
+			return err
+		}
+		stmt, err := tx.Prepare(`INSERT INTO verify_files (service_name, seq, source_path, dest_path) VALUES (?, ?, ?, ?)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for i, file := range files {
+			if _, err := stmt.Exec(s.serviceName, i, file.Source, file.Dest); err != nil {
+				return err
+			}
+		}
+
+		now := startedAt.UTC().Format(time.RFC3339Nano)
+		_, err = tx.Exec(`
+			INSERT INTO verify_progress (service_name, run_dir, total, started_at, updated_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, s.serviceName, runDir, len(files), now, now)
+		return err
+	})
+}
+
+// SaveVerifyCheckpoint records how far the verify pass has come.
+func (s *Store) SaveVerifyCheckpoint(checkpoint VerifyCheckpoint) error {
+	mismatched, err := json.Marshal(checkpoint.Mismatched)
+	if err != nil {
+		return fmt.Errorf("failed to encode mismatched files: %w", err)
+	}
+
+	return s.execWrite(`
+		UPDATE verify_progress
+		SET next_index = ?, checked = ?, skipped = ?, not_sampled = ?, mismatched = ?,
+			file_offset = ?, source_hash = ?, dest_hash = ?, updated_at = ?
+		WHERE service_name = ? AND run_dir = ?
+	`, checkpoint.NextIndex, checkpoint.Checked, checkpoint.Skipped, checkpoint.NotSampled, string(mismatched),
+		checkpoint.FileOffset, checkpoint.SourceHash, checkpoint.DestHash, time.Now().UTC().Format(time.RFC3339Nano),
+		s.serviceName, checkpoint.RunDir)
+}
+
+// LoadVerifyCheckpoint returns the unfinished verify pass, if any.
+func (s *Store) LoadVerifyCheckpoint() (VerifyCheckpoint, bool, error) {
+	var (
+		checkpoint    VerifyCheckpoint
+		mismatchedRaw string
+		startedAtRaw  string
+		updatedAtRaw  string
+	)
+	err := s.db.QueryRow(`
+		SELECT run_dir, total, next_index, checked, skipped, not_sampled, mismatched,
+			file_offset, source_hash, dest_hash, started_at, updated_at
+		FROM verify_progress
+		WHERE service_name = ?
+	`, s.serviceName).Scan(&checkpoint.RunDir, &checkpoint.Total, &checkpoint.NextIndex, &checkpoint.Checked, &checkpoint.Skipped, &checkpoint.NotSampled,
+		&mismatchedRaw, &checkpoint.FileOffset, &checkpoint.SourceHash, &checkpoint.DestHash, &startedAtRaw, &updatedAtRaw)
+	if err == sql.ErrNoRows {
+		return VerifyCheckpoint{}, false, nil
+	}
+	if err != nil {
+		return VerifyCheckpoint{}, false, err
+	}
+
+	if err := json.Unmarshal([]byte(mismatchedRaw), &checkpoint.Mismatched); err != nil {
+		return VerifyCheckpoint{}, false, fmt.Errorf("failed to decode mismatched files: %w", err)
+	}
+	if checkpoint.StartedAt, err = time.Parse(time.RFC3339Nano, startedAtRaw); err != nil {
+		return VerifyCheckpoint{}, false, err
+	}
+	if checkpoint.UpdatedAt, err = time.Parse(time.RFC3339Nano, updatedAtRaw); err != nil {
+		return VerifyCheckpoint{}, false, err
+	}
+	return checkpoint, true, nil
+}
+
+// LoadVerifyFiles returns the files of the unfinished verify pass in order.
+func (s *Store) LoadVerifyFiles() ([]VerifyFile, error) {
+	rows, err := s.db.Query(`
+		SELECT source_path, dest_path
+		FROM verify_files
+		WHERE service_name = ?
+		ORDER BY seq
+	`, s.serviceName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []VerifyFile
+	for rows.Next() {
+		var file VerifyFile
+		if err := rows.Scan(&file.Source, &file.Dest); err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, rows.Err()
+}
+
+// ClearVerify forgets the verify pass once it has finished.
+func (s *Store) ClearVerify() error {
+	return s.withWriteTx(s.clearVerifyTx)
+}
+
+func (s *Store) clearVerifyTx(tx *sql.Tx) error {
+	if _, err := tx.Exec(`DELETE FROM verify_files WHERE service_name = ?`, s.serviceName); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`DELETE FROM verify_progress WHERE service_name = ?`, s.serviceName)
+	return err
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"io/fs"
+	"os"
+	"time"
+)
+
+// LocalDriver is the name of the mounted file system driver.
+const LocalDriver = "local"
+
+// Local reads and writes through the local file system: CIFS mounts on the
+// source side, a mounted disk on the destination side.
+type Local struct{}
+
+func init() {
+	RegisterSource(LocalDriver, func(Params) (SourceProvider, error) { return Local{}, nil })
+	RegisterDestination(LocalDriver, func(Params) (DestinationProvider, error) { return Local{}, nil })
+}
+
+func (Local) ReadDir(path string) ([]fs.DirEntry, error) { return os.ReadDir(path) }
+
+func (Local) Stat(path string) (fs.FileInfo, error) { return os.Stat(path) }
+
+// Open returns the *os.File itself so io.Copy keeps the kernel copy path.
+func (Local) Open(path string) (File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (Local) MkdirAll(path string) error { return os.MkdirAll(path, 0755) }
+
+func (Local) Create(path string) (WriteFile, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (Local) Chtimes(path string, atime, mtime time.Time) error {
+	return os.Chtimes(path, atime, mtime)
+}
+
+func (Local) Rename(oldpath, newpath string) error { return os.Rename(oldpath, newpath) }
+
+func (Local) Remove(path string) error { return os.Remove(path) }
+
+func (Local) Link(oldpath, newpath string) error { return os.Link(oldpath, newpath) }
+
+func (Local) IsLocalFS() bool { return true }
@@ -0,0 +1,160 @@
+// Package storage puts source shares and the destination behind provider
+// interfaces so the sync engine does not depend on how files are reached.
+// Drivers register themselves by name; the built-in "local" driver uses
+// mounted file systems, which is how UCXSync has always worked.
+package storage
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"sync"
+	"time"
+)
+
+// File is an open source file.
+type File interface {
+	io.Reader
+	io.ReaderAt
+	io.Closer
+	Stat() (fs.FileInfo, error)
+}
+
+// WriteFile is a destination file being written.
+type WriteFile interface {
+	io.Writer
+	io.Closer
+}
+
+// SourceProvider reads the worker shares. Paths are those the engine builds
+// under network.mount_root (<root>/<node>/<share>/...); drivers that do not
+// mount shares map them onto the node and share themselves.
+type SourceProvider interface {
+	ReadDir(path string) ([]fs.DirEntry, error)
+	Stat(path string) (fs.FileInfo, error)
+	Open(path string) (File, error)
+}
+
+// DestinationProvider writes the synchronized files.
+type DestinationProvider interface {
+	MkdirAll(path string) error
+	Stat(path string) (fs.FileInfo, error)
+	Create(path string) (WriteFile, error)
+	Chtimes(path string, atime, mtime time.Time) error
+	Rename(oldpath, newpath string) error
+	Remove(path string) error
+}
+
+// Reader is implemented by destinations whose files can be read back, which
+// checksum verification, spot checks and verify runs need.
+type Reader interface {
+	Open(path string) (File, error)
+}
+
+// Linker is implemented by destinations that can give a file a second name,
+// which hardlinking unchanged files into a new snapshot needs.
+type Linker interface {
+	Link(oldpath, newpath string) error
+}
+
+// LocalFS is implemented by providers whose paths name files of the local
+// file system. Resumed and delta copies, change notify, move mode and the
+// stale temp file cleanup work on such paths directly.
+type LocalFS interface {
+	IsLocalFS() bool
+}
+
+// IsLocal reports whether provider works on the local file system.
+func IsLocal(provider any) bool {
+	local, ok := provider.(LocalFS)
+	return ok && local.IsLocalFS()
+}
+
+// Params carries driver-specific settings from the configuration.
+type Params map[string]string
+
+// SourceFactory creates a source provider from its settings.
+type SourceFactory func(Params) (SourceProvider, error)
+
+// DestinationFactory creates a destination provider from its settings.
+type DestinationFactory func(Params) (DestinationProvider, error)
+
+var (
+	mu           sync.RWMutex
+	sources      = map[string]SourceFactory{}
+	destinations = map[string]DestinationFactory{}
+)
+
+// RegisterSource makes a source driver available by name. It panics when
+// the name is taken, like database/sql.Register.
+func RegisterSource(name string, factory SourceFactory) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := sources[name]; exists {
+		panic("storage: source driver registered twice: " + name)
+	}
+	sources[name] = factory
+}
+
+// RegisterDestination makes a destination driver available by name.
+func RegisterDestination(name string, factory DestinationFactory) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := destinations[name]; exists {
+		panic("storage: destination driver registered twice: " + name)
+	}
+	destinations[name] = factory
+}
+
+// NewSource creates a provider with the named source driver.
+func NewSource(driver string, params Params) (SourceProvider, error) {
+	mu.RLock()
+	factory, ok := sources[driver]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown source storage driver %q (available: %v)", driver, SourceDrivers())
+	}
+	return factory(params)
+}
+
+// NewDestination creates a provider with the named destination driver.
+func NewDestination(driver string, params Params) (DestinationProvider, error) {
+	mu.RLock()
+	factory, ok := destinations[driver]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown destination storage driver %q (available: %v)", driver, DestinationDrivers())
+	}
+	return factory(params)
+}
+
+// SourceDrivers lists the registered source drivers.
+func SourceDrivers() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DestinationDrivers lists the registered destination drivers.
+func DestinationDrivers() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(destinations))
+	for name := range destinations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewSourceRejectsUnknownDriver(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewSource("sftp-missing", nil); err == nil || !strings.Contains(err.Error(), "local") {
+		t.Fatalf("NewSource() error = %v, want unknown driver listing local", err)
+	}
+	if _, err := NewDestination("s3-missing", nil); err == nil {
+		t.Fatal("NewDestination() accepted an unknown driver")
+	}
+}
+
+func TestLocalDriverRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	source, err := NewSource(LocalDriver, nil)
+	if err != nil {
+		t.Fatalf("NewSource() error = %v", err)
+	}
+	target, err := NewDestination(LocalDriver, nil)
+	if err != nil {
+		t.Fatalf("NewDestination() error = %v", err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "a", "b")
+	if err := target.MkdirAll(dir); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	path := filepath.Join(dir, "file.raw")
+	dst, err := target.Create(path)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := io.WriteString(dst, "payload"); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	dst.Close()
+	mtime := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	if err := target.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+
+	entries, err := source.ReadDir(dir)
+	if err != nil || len(entries) != 1 || entries[0].Name() != "file.raw" {
+		t.Fatalf("ReadDir() = %v, %v", entries, err)
+	}
+	src, err := source.Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer src.Close()
+	if _, ok := src.(*os.File); !ok {
+		t.Fatalf("Open() returned %T, want *os.File for the kernel copy path", src)
+	}
+	info, err := src.Stat()
+	if err != nil || info.Size() != 7 || !info.ModTime().Equal(mtime) {
+		t.Fatalf("Stat() = %+v, %v", info, err)
+	}
+}
+
+func TestLocalCapabilities(t *testing.T) {
+	t.Parallel()
+
+	var target DestinationProvider = Local{}
+	if _, ok := target.(Reader); !ok {
+		t.Fatal("Local destination cannot be read back")
+	}
+	if _, ok := target.(Linker); !ok {
+		t.Fatal("Local destination cannot hardlink")
+	}
+	if !IsLocal(target) {
+		t.Fatal("IsLocal(Local) = false")
+	}
+
+	// A driver wrapping another one only has the capabilities it declares.
+	wrapped := struct{ DestinationProvider }{target}
+	if _, ok := any(wrapped).(Reader); ok || IsLocal(wrapped) {
+		t.Fatal("wrapped destination inherited capabilities it does not declare")
+	}
+}
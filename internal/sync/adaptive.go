@@ -0,0 +1,278 @@
+package sync
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+const (
+	defaultAdaptiveInterval = 10 * time.Second
+	// adaptiveMinGain is the relative throughput gain an extra copy slot must
+	// bring to be kept.
+	adaptiveMinGain = 0.05
+	// Ticks to hold the limit after backing off before probing upwards again.
+	adaptiveHoldAfterErrors  = 3
+	adaptiveHoldAfterPlateau = 6
+)
+
+// AdaptiveParallelismOptions bounds the per-node copy concurrency tuned at
+// runtime from observed throughput and errors.
+type AdaptiveParallelismOptions struct {
+	Enabled  bool
+	Min      int
+	Max      int
+	Interval time.Duration
+}
+
+// SetAdaptiveParallelism enables or disables per-node adaptive concurrency.
+// The global max_parallelism limit still applies on top of it.
+func (s *Service) SetAdaptiveParallelism(opts AdaptiveParallelismOptions) {
+	if opts.Min < 1 {
+		opts.Min = 1
+	}
+	if opts.Max < opts.Min {
+		opts.Max = opts.Min
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultAdaptiveInterval
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.adaptive = opts
+}
+
+// nodeLimiter is a semaphore whose size can change while copies hold it.
+type nodeLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	active  int
+	changed chan struct{}
+
+	bytes     int64 // copied since the last adjustment
+	errors    int32 // failed copies since the last adjustment
+	saturated int32 // 1 if a copy had to wait for a slot since the last adjustment
+
+	// Controller state, only touched by adjust.
+	throughput float64
+	probing    bool
+	hold       int
+}
+
+func newNodeLimiter(limit int) *nodeLimiter {
+	return &nodeLimiter{limit: limit, changed: make(chan struct{})}
+}
+
+func (l *nodeLimiter) acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.active < l.limit {
+			l.active++
+			l.mu.Unlock()
+			return nil
+		}
+		atomic.StoreInt32(&l.saturated, 1)
+		changed := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// tryAcquire takes a slot when one is free, without waiting.
+func (l *nodeLimiter) tryAcquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.active < l.limit {
+		l.active++
+		return true
+	}
+	atomic.StoreInt32(&l.saturated, 1)
+	return false
+}
+
+func (l *nodeLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.active--
+	l.wakeLocked()
+}
+
+func (l *nodeLimiter) setLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit = limit
+	l.wakeLocked()
+}
+
+func (l *nodeLimiter) wakeLocked() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+func (l *nodeLimiter) state() (limit, active int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.limit, l.active
+}
+
+// adjust runs one step of the hill climb: halve on errors, keep adding a slot
+// while the node is saturated and each slot still pays off, and step back
+// once the marginal gain drops below adaptiveMinGain.
+func (l *nodeLimiter) adjust(window time.Duration, opts AdaptiveParallelismOptions) (int, int) {
+	bytes := atomic.SwapInt64(&l.bytes, 0)
+	errors := atomic.SwapInt32(&l.errors, 0)
+	saturated := atomic.SwapInt32(&l.saturated, 0) == 1
+	throughput := float64(bytes) / window.Seconds()
+
+	current, _ := l.state()
+	next := current
+
+	switch {
+	case errors > 0:
+		next = current / 2
+		l.probing = false
+		l.hold = adaptiveHoldAfterErrors
+	case l.probing && throughput < l.throughput*(1+adaptiveMinGain):
+		next = current - 1
+		l.probing = false
+		l.hold = adaptiveHoldAfterPlateau
+	case l.hold > 0:
+		l.hold--
+		l.probing = false
+	case saturated && current < opts.Max:
+		next = current + 1
+		l.probing = true
+	default:
+		l.probing = false
+	}
+
+	if next < opts.Min {
+		next = opts.Min
+	}
+	if next > opts.Max {
+		next = opts.Max
+	}
+	l.throughput = throughput
+	if next != current {
+		l.setLimit(next)
+	}
+	return current, next
+}
+
+// nodeLimiterFor returns the limiter for node, or nil when adaptive
+// parallelism is disabled.
+func (s *Service) nodeLimiterFor(node string) *nodeLimiter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.adaptive.Enabled {
+		return nil
+	}
+	if s.nodeLimiters == nil {
+		s.nodeLimiters = make(map[string]*nodeLimiter)
+	}
+	limiter, ok := s.nodeLimiters[node]
+	if !ok {
+		limiter = newNodeLimiter(s.adaptive.Min)
+		s.nodeLimiters[node] = limiter
+	}
+	return limiter
+}
+
+func (s *Service) recordNodeCopy(node string, bytes int64) {
+	s.mu.RLock()
+	limiter := s.nodeLimiters[node]
+	s.mu.RUnlock()
+
+	if limiter != nil {
+		atomic.AddInt64(&limiter.bytes, bytes)
+	}
+}
+
+func (s *Service) recordNodeError(node string) {
+	s.mu.RLock()
+	limiter := s.nodeLimiters[node]
+	s.mu.RUnlock()
+
+	if limiter != nil {
+		atomic.AddInt32(&limiter.errors, 1)
+	}
+}
+
+// adaptiveParallelismLoop periodically retunes every node limiter.
+func (s *Service) adaptiveParallelismLoop(ctx context.Context) {
+	defer s.wg.Done()
+
+	s.mu.RLock()
+	opts := s.adaptive
+	s.mu.RUnlock()
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.adjustNodeParallelism(opts.Interval, opts)
+		}
+	}
+}
+
+func (s *Service) adjustNodeParallelism(window time.Duration, opts AdaptiveParallelismOptions) {
+	s.mu.RLock()
+	limiters := make(map[string]*nodeLimiter, len(s.nodeLimiters))
+	for node, limiter := range s.nodeLimiters {
+		limiters[node] = limiter
+	}
+	s.mu.RUnlock()
+
+	for node, limiter := range limiters {
+		previous, next := limiter.adjust(window, opts)
+		if previous != next {
+			log.Info().
+				Str("node", node).
+				Int("from", previous).
+				Int("to", next).
+				Float64("throughput_mbps", limiter.throughput/1024/1024).
+				Msg("Adaptive parallelism adjusted")
+		}
+	}
+}
+
+// nodeParallelismLocked reports the current per-node limits. Must be called
+// with s.mu held.
+func (s *Service) nodeParallelismLocked() []models.NodeParallelism {
+	if len(s.nodeLimiters) == 0 {
+		return nil
+	}
+
+	nodes := make([]models.NodeParallelism, 0, len(s.nodeLimiters))
+	for node, limiter := range s.nodeLimiters {
+		limit, active := limiter.state()
+		nodes = append(nodes, models.NodeParallelism{
+			Node:   node,
+			Limit:  limit,
+			Active: active,
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Node < nodes[j].Node })
+	return nodes
+}
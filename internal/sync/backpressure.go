@@ -0,0 +1,81 @@
+package sync
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// BackpressureOptions holds back scans while the copy queue is deep. A scan
+// lists a whole share over the same SMB link the copies use, and the files it
+// finds would only join the queue behind the ones already waiting.
+type BackpressureOptions struct {
+	Enabled   bool
+	HighWater int           // Queued files across tasks that hold back scans
+	LowWater  int           // Queued files at which scans resume
+	MaxDelay  time.Duration // Longest scans are held back, so new captures still show up
+}
+
+// SetBackpressure configures how scans give way to copies.
+func (s *Service) SetBackpressure(opts BackpressureOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.backpressure = opts
+}
+
+// queuedCopiesLocked counts the files the active tasks found and have not
+// copied or given up on yet. Caller must hold s.mu.
+func (s *Service) queuedCopiesLocked() int {
+	queued := 0
+	for _, task := range s.activeTasks {
+		remaining := atomic.LoadInt32(&task.totalFiles) - atomic.LoadInt32(&task.copiedFiles) - atomic.LoadInt32(&task.failedFiles)
+		if remaining > 0 {
+			queued += int(remaining)
+		}
+	}
+	return queued
+}
+
+// holdScans reports whether the iteration at now should start no scans. Once
+// the queue reaches HighWater, scans stay held until it drains to LowWater or
+// they have been held for MaxDelay.
+func (s *Service) holdScans(now time.Time) bool {
+	s.mu.Lock()
+	opts := s.backpressure
+	if !opts.Enabled {
+		s.scansHeldSince = time.Time{}
+		s.mu.Unlock()
+		return false
+	}
+	queued := s.queuedCopiesLocked()
+	wasHeld := !s.scansHeldSince.IsZero()
+	held := wasHeld
+	switch {
+	case !wasHeld && queued >= opts.HighWater:
+		held = true
+		s.scansHeldSince = now
+	case wasHeld && queued <= opts.LowWater:
+		held = false
+	case wasHeld && opts.MaxDelay > 0 && now.Sub(s.scansHeldSince) >= opts.MaxDelay:
+		// Let this iteration scan; the queue may hold them back again next.
+		held = false
+	}
+	heldFor := now.Sub(s.scansHeldSince)
+	if wasHeld && !held {
+		s.scansHeldSince = time.Time{}
+	}
+	s.mu.Unlock()
+
+	switch {
+	case held && !wasHeld:
+		log.Info().Int("queued_files", queued).Msg("Copy queue is deep, holding back scans")
+	case !held && wasHeld:
+		log.Info().Int("queued_files", queued).Str("held_for", heldFor.Round(time.Second).String()).Msg("Resuming scans")
+	}
+	if held {
+		atomic.AddInt32(&s.runScansDeferred, 1)
+	}
+	return held
+}
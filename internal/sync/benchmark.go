@@ -0,0 +1,91 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+const benchmarkChunkSize = 4 << 20 // 4 MB
+
+// BenchmarkDestination writes size bytes to a temporary file in dir, syncs it
+// to the disk and reports the write speed. The file is removed afterwards.
+func BenchmarkDestination(ctx context.Context, dir string, size int64) (models.DestinationBenchmark, error) {
+	if size <= 0 {
+		return models.DestinationBenchmark{}, fmt.Errorf("benchmark size must be positive")
+	}
+	if err := ensureDestinationReady(dir); err != nil {
+		return models.DestinationBenchmark{}, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return models.DestinationBenchmark{}, err
+	}
+
+	file, err := os.CreateTemp(dir, ".ucxsync-benchmark-*")
+	if err != nil {
+		return models.DestinationBenchmark{}, fmt.Errorf("failed to create benchmark file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	// Non-zero data, so compressing or deduplicating file systems do not
+	// report an unrealistic speed.
+	chunk := make([]byte, benchmarkChunkSize)
+	for i := range chunk {
+		chunk[i] = byte(i*7 + i>>8)
+	}
+
+	started := time.Now()
+	for written := int64(0); written < size; {
+		if err := ctx.Err(); err != nil {
+			return models.DestinationBenchmark{}, err
+		}
+		n := int64(len(chunk))
+		if remaining := size - written; remaining < n {
+			n = remaining
+		}
+		if _, err := file.Write(chunk[:n]); err != nil {
+			return models.DestinationBenchmark{}, fmt.Errorf("benchmark write failed: %w", err)
+		}
+		written += n
+	}
+	if err := file.Sync(); err != nil {
+		return models.DestinationBenchmark{}, fmt.Errorf("benchmark sync failed: %w", err)
+	}
+	elapsed := time.Since(started)
+
+	result := models.DestinationBenchmark{
+		Destination: dir,
+		Bytes:       size,
+		Seconds:     elapsed.Seconds(),
+		MeasuredAt:  time.Now().UTC(),
+	}
+	if elapsed > 0 {
+		result.WriteMBps = float64(size) / (1024 * 1024) / elapsed.Seconds()
+	}
+	return result, nil
+}
+
+// RecordBenchmark keeps the destination benchmark with the running job: it is
+// reported in the status and the run totals and saved in the state database.
+func (s *Service) RecordBenchmark(result models.DestinationBenchmark) {
+	s.mu.Lock()
+	if !s.isRunning {
+		s.mu.Unlock()
+		return
+	}
+	s.runBenchmark = &result
+	store := s.stateStore
+	s.invalidateStatus()
+	s.mu.Unlock()
+
+	if store != nil {
+		if err := store.RecordRunBenchmark(result.WriteMBps, result.MeasuredAt); err != nil {
+			log.Warn().Err(err).Msg("Failed to save destination benchmark")
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package sync
+
+import (
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// CircuitBreakerOptions suspends copies from a node whose copy failure rate
+// over Window exceeds FailureRate. Every attempt counts, retries included, so
+// a node failing the same files over and over trips the breaker too.
+type CircuitBreakerOptions struct {
+	Enabled     bool
+	Window      time.Duration // Sliding window of copy outcomes
+	MinAttempts int           // Outcomes in the window before the rate counts
+	FailureRate float64       // Open above this share of failed copies
+	OpenFor     time.Duration // How long copies stay suspended
+}
+
+// nodeBreaker holds the copy outcomes of one node within the window.
+type nodeBreaker struct {
+	outcomes  []copyOutcome
+	openedAt  time.Time
+	openUntil time.Time
+	trips     int
+}
+
+type copyOutcome struct {
+	at     time.Time
+	failed bool
+}
+
+// SetCircuitBreaker configures the per-node circuit breaker.
+func (s *Service) SetCircuitBreaker(opts CircuitBreakerOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.breakerOptions = opts
+	s.breakers = make(map[string]*nodeBreaker)
+}
+
+// nodeBreakerAllows reports whether copies from node may be dispatched.
+func (s *Service) nodeBreakerAllows(node string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	breaker := s.breakers[node]
+	return !s.breakerOptions.Enabled || breaker == nil || !time.Now().Before(breaker.openUntil)
+}
+
+// recordNodeOutcome adds a copy result to node's window and opens its breaker
+// once the failure rate crosses the threshold. After the open period any
+// failure reopens it at once; a success closes it.
+func (s *Service) recordNodeOutcome(node string, failed bool) {
+	now := time.Now()
+
+	s.mu.Lock()
+	opts := s.breakerOptions
+	if !opts.Enabled {
+		s.mu.Unlock()
+		return
+	}
+	if s.breakers == nil {
+		s.breakers = make(map[string]*nodeBreaker)
+	}
+	breaker := s.breakers[node]
+	if breaker == nil {
+		breaker = &nodeBreaker{}
+		s.breakers[node] = breaker
+	}
+	if now.Before(breaker.openUntil) {
+		// A copy dispatched before the breaker opened.
+		s.mu.Unlock()
+		return
+	}
+
+	halfOpen := !breaker.openUntil.IsZero()
+	breaker.outcomes = append(pruneOutcomes(breaker.outcomes, now.Add(-opts.Window)), copyOutcome{at: now, failed: failed})
+	attempts, failures := countOutcomes(breaker.outcomes)
+
+	trip := false
+	switch {
+	case halfOpen && !failed:
+		breaker.openUntil = time.Time{}
+		breaker.openedAt = time.Time{}
+		// Start the window over from the successful probe.
+		breaker.outcomes = append(breaker.outcomes[:0], copyOutcome{at: now})
+	case halfOpen && failed:
+		trip = true
+	case attempts >= opts.MinAttempts && float64(failures)/float64(attempts) > opts.FailureRate:
+		trip = true
+	}
+	if trip {
+		breaker.openedAt = now
+		breaker.openUntil = now.Add(opts.OpenFor)
+		breaker.trips++
+	}
+	project := s.project
+	destination := s.destination
+	s.mu.Unlock()
+
+	if halfOpen && !failed {
+		log.Info().Str("node", node).Msg("Node copies succeed again, circuit breaker closed")
+		return
+	}
+	if !trip {
+		return
+	}
+
+	log.Warn().
+		Str("node", node).
+		Int("attempts", attempts).
+		Int("failures", failures).
+		Dur("open_for", opts.OpenFor).
+		Msg("Node copy failure rate too high, circuit breaker opened")
+
+	s.emitEvent(models.SyncEvent{
+		Type:        models.SyncEventNodeBreakerOpen,
+		Project:     project,
+		Destination: destination,
+		Message:     s.messages.Sprintf(i18n.EventNodeBreakerOpen, node, failures, attempts, opts.OpenFor),
+	})
+}
+
+// NodeBreakers returns the breaker of every node with copies in the window
+// or a breaker that is not closed, sorted by node.
+func (s *Service) NodeBreakers() []models.NodeBreaker {
+	now := time.Now()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]models.NodeBreaker, 0, len(s.breakers))
+	for node, breaker := range s.breakers {
+		attempts, failures := countOutcomes(pruneOutcomes(breaker.outcomes, now.Add(-s.breakerOptions.Window)))
+		entry := models.NodeBreaker{
+			Node:     node,
+			State:    models.BreakerClosed,
+			Attempts: attempts,
+			Failures: failures,
+			Trips:    breaker.trips,
+		}
+		if attempts > 0 {
+			entry.FailureRate = float64(failures) / float64(attempts)
+		}
+		if !breaker.openUntil.IsZero() {
+			openedAt := breaker.openedAt.UTC()
+			retryAt := breaker.openUntil.UTC()
+			entry.OpenedAt = &openedAt
+			entry.RetryAt = &retryAt
+			entry.State = models.BreakerHalfOpen
+			if now.Before(breaker.openUntil) {
+				entry.State = models.BreakerOpen
+			}
+		}
+		if attempts == 0 && entry.State == models.BreakerClosed {
+			continue
+		}
+		result = append(result, entry)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Node < result[j].Node })
+	return result
+}
+
+// pruneOutcomes drops the outcomes before cutoff, oldest first.
+func pruneOutcomes(outcomes []copyOutcome, cutoff time.Time) []copyOutcome {
+	i := 0
+	for i < len(outcomes) && outcomes[i].at.Before(cutoff) {
+		i++
+	}
+	return outcomes[i:]
+}
+
+func countOutcomes(outcomes []copyOutcome) (attempts, failures int) {
+	for _, outcome := range outcomes {
+		if outcome.failed {
+			failures++
+		}
+	}
+	return len(outcomes), failures
+}
@@ -0,0 +1,361 @@
+package sync
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+// ChangeNotifyOptions lets the sync loop learn about new source files from
+// directory change notifications (inotify, which CIFS mounts back with SMB
+// change notify where the kernel supports it) and list only the folders that
+// changed. A full rescan still runs every FullRescanInterval, for changes the
+// share never reported. With Immediate a reported change starts a sync
+// iteration once the changes have been quiet for Debounce, instead of at the
+// next loop interval; shares that cannot be watched are still polled.
+type ChangeNotifyOptions struct {
+	Enabled            bool
+	FullRescanInterval time.Duration
+	Immediate          bool
+	Debounce           time.Duration
+}
+
+// sourceWatch tracks the changed folders of one source tree between sync
+// iterations.
+type sourceWatch struct {
+	mu       sync.Mutex
+	watcher  *fsnotify.Watcher
+	root     string
+	watched  map[string]bool     // Folders with a watch
+	dirty    map[string]bool     // Folders changed since the last listing
+	pending  map[string]struct{} // Files queued and not yet copied
+	needFull bool
+	lastFull time.Time
+	notify   func() // Wakes the sync loop on a change, nil when polled
+}
+
+// SetChangeNotify configures change-notify listing of the source shares.
+// Disabling it drops the current watches.
+func (s *Service) SetChangeNotify(opts ChangeNotifyOptions) {
+	s.mu.Lock()
+	s.changeNotify = opts
+	s.mu.Unlock()
+
+	if !opts.Enabled {
+		s.closeSourceWatches()
+	}
+}
+
+func (s *Service) changeNotifyOptions() ChangeNotifyOptions {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.changeNotify
+}
+
+// listSource returns the files of source worth checking. With change notify
+// on a local source, only the folders changed since the last listing are
+// read, plus the files still waiting to be copied; full reports whether the
+// whole tree was scanned. watch is nil when source is polled.
+func (s *Service) listSource(ctx context.Context, source string) (files []string, watch *sourceWatch, full bool, err error) {
+	opts := s.changeNotifyOptions()
+	if !opts.Enabled || !s.localSource() {
+		files, err = s.scanDirectory(ctx, source, source)
+		return files, nil, true, err
+	}
+
+	watch = s.sourceWatchFor(source)
+	if watch == nil {
+		files, err = s.scanDirectory(ctx, source, source)
+		return files, nil, true, err
+	}
+
+	dirty, pending, full := watch.take(time.Now(), opts.FullRescanInterval)
+	if full {
+		files, err = s.scanTree(ctx, source, watch.add)
+		if err != nil {
+			watch.rescanNext()
+			return nil, watch, true, err
+		}
+		return files, watch, true, nil
+	}
+
+	excludedDirectories := s.Exclusions().Directories
+	filter := s.currentFileFilter()
+	for _, dir := range dirty {
+		entries, err := s.sourceStorage().ReadDir(dir)
+		if err != nil {
+			// Removed since the event, or unreadable for now.
+			watch.forget(dir)
+			continue
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if !entry.IsDir() {
+				if filter.allows(path) {
+					files = append(files, path)
+				}
+				continue
+			}
+			if isExcludedDirectory(entry.Name(), excludedDirectories) || watch.isWatched(path) {
+				continue
+			}
+			// A folder created since the last listing.
+			subFiles, err := s.scanTree(ctx, path, watch.add)
+			if err == nil {
+				files = append(files, subFiles...)
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			watch.markDirty(dirty...)
+			return nil, watch, false, err
+		}
+	}
+
+	seen := make(map[string]bool, len(files))
+	for _, file := range files {
+		seen[file] = true
+	}
+	for _, file := range pending {
+		if !seen[file] {
+			files = append(files, file)
+		}
+	}
+	return files, watch, false, nil
+}
+
+// sourceWatchFor returns the watch of source, starting it on first use. It
+// returns nil when the source cannot be watched.
+func (s *Service) sourceWatchFor(source string) *sourceWatch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if watch, ok := s.sourceWatches[source]; ok {
+		return watch
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Warn().Err(err).Str("source", source).Msg("Change notify unavailable, polling source")
+		return nil
+	}
+	watch := &sourceWatch{
+		watcher:  watcher,
+		root:     source,
+		watched:  make(map[string]bool),
+		dirty:    make(map[string]bool),
+		pending:  make(map[string]struct{}),
+		needFull: true,
+	}
+	if s.changeNotify.Immediate {
+		watch.notify = s.signalSourceChanged
+	}
+	if s.sourceWatches == nil {
+		s.sourceWatches = make(map[string]*sourceWatch)
+	}
+	s.sourceWatches[source] = watch
+	go watch.run()
+	return watch
+}
+
+// signalSourceChanged wakes the sync loop for a reported source change.
+func (s *Service) signalSourceChanged() {
+	select {
+	case s.sourceChanged <- struct{}{}:
+	default:
+	}
+}
+
+// signalIfSourceChanged wakes the sync loop when source changed while its
+// task was busy copying, since the iteration the change woke skipped it.
+func (s *Service) signalIfSourceChanged(source string) {
+	s.mu.RLock()
+	watch := s.sourceWatches[source]
+	s.mu.RUnlock()
+
+	if watch != nil && watch.notify != nil && watch.hasChanges() {
+		watch.notify()
+	}
+}
+
+// waitForChangesToSettle waits until no change has been reported for
+// debounce, so the files of a capture are listed together, but no longer
+// than limit. It reports false when ctx ends first.
+func (s *Service) waitForChangesToSettle(ctx context.Context, debounce, limit time.Duration) bool {
+	if debounce <= 0 {
+		return ctx.Err() == nil
+	}
+	deadline := time.NewTimer(limit)
+	defer deadline.Stop()
+	quiet := time.NewTimer(debounce)
+	defer quiet.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-deadline.C:
+			return true
+		case <-quiet.C:
+			return true
+		case <-s.sourceChanged:
+			if !quiet.Stop() {
+				<-quiet.C
+			}
+			quiet.Reset(debounce)
+		}
+	}
+}
+
+// closeSourceWatches stops every source watch.
+func (s *Service) closeSourceWatches() {
+	s.mu.Lock()
+	watches := s.sourceWatches
+	s.sourceWatches = nil
+	s.mu.Unlock()
+
+	for _, watch := range watches {
+		watch.watcher.Close()
+	}
+}
+
+// run marks the folders reported by the watcher dirty until it is closed.
+func (w *sourceWatch) run() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.mu.Lock()
+			w.dirty[filepath.Dir(event.Name)] = true
+			if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+				// A removed folder loses its watch; list it in full if it
+				// comes back.
+				delete(w.watched, event.Name)
+			}
+			w.mu.Unlock()
+			if w.notify != nil {
+				w.notify()
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			// Events were lost (queue overflow): only a full rescan is safe.
+			log.Warn().Err(err).Str("source", w.root).Msg("Change notify error, rescanning source")
+			w.rescanNext()
+		}
+	}
+}
+
+// take returns and clears the changed folders, and the files still pending.
+// full is set when the tree is due a full rescan instead.
+func (w *sourceWatch) take(now time.Time, fullEvery time.Duration) (dirty, pending []string, full bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.needFull || (fullEvery > 0 && now.Sub(w.lastFull) >= fullEvery) {
+		w.needFull = false
+		w.lastFull = now
+		w.dirty = make(map[string]bool)
+		// The full listing queues whatever still needs copying.
+		w.pending = make(map[string]struct{})
+		return nil, nil, true
+	}
+
+	for dir := range w.dirty {
+		dirty = append(dirty, dir)
+	}
+	w.dirty = make(map[string]bool)
+	for file := range w.pending {
+		pending = append(pending, file)
+	}
+	return dirty, pending, false
+}
+
+// add watches dir. A folder that cannot be watched (no inotify support on
+// the mount, watch limit reached) leaves the tree to full rescans.
+func (w *sourceWatch) add(dir string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.watched[dir] {
+		return
+	}
+	if err := w.watcher.Add(dir); err != nil {
+		if !w.needFull {
+			log.Warn().Err(err).Str("dir", dir).Msg("Cannot watch source folder, rescanning source")
+		}
+		w.needFull = true
+		return
+	}
+	w.watched[dir] = true
+}
+
+// hasChanges reports whether changes wait to be listed.
+func (w *sourceWatch) hasChanges() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return len(w.dirty) > 0 || w.needFull
+}
+
+func (w *sourceWatch) isWatched(dir string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.watched[dir]
+}
+
+func (w *sourceWatch) forget(dir string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.watched, dir)
+	w.watcher.Remove(dir)
+}
+
+func (w *sourceWatch) markDirty(dirs ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, dir := range dirs {
+		w.dirty[dir] = true
+	}
+}
+
+func (w *sourceWatch) rescanNext() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.needFull = true
+}
+
+// keep lists files again on every iteration until released, so a failed or
+// deferred copy is retried without a change event.
+func (w *sourceWatch) keep(files []string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, file := range files {
+		w.pending[file] = struct{}{}
+	}
+}
+
+func (w *sourceWatch) release(file string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.pending, file)
+}
@@ -0,0 +1,120 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/storage"
+)
+
+// ErrChecksumMismatch means a copied file did not read back the same as its
+// source.
+var ErrChecksumMismatch = errors.New("checksum mismatch after copy")
+
+// SetVerifyChecksums makes every copy read the source and the destination
+// back and compare their SHA-256 before the file counts as copied. Size and
+// mtime only tell that a copy finished, not that SMB delivered every byte
+// intact.
+func (s *Service) SetVerifyChecksums(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.verifyChecksums = enabled
+}
+
+func (s *Service) verifyChecksumsEnabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.verifyChecksums
+}
+
+// verifyCopiedChecksum hashes sourcePath and destPath side by side and
+// returns the SHA-256 they share. On a mismatch destPath is removed so the
+// next pass copies the file again, and ErrChecksumMismatch is returned.
+// Destinations that cannot be read back are not checked and return a nil sum.
+func (s *Service) verifyCopiedChecksum(ctx context.Context, sourcePath, destPath string) ([]byte, error) {
+	target := s.destinationStorage()
+	reader, ok := target.(storage.Reader)
+	if !ok {
+		return nil, nil
+	}
+
+	budget := s.currentIOBudget()
+	var dstSum []byte
+	dstErr := make(chan error, 1)
+	go func() {
+		dst, err := reader.Open(destPath)
+		if err != nil {
+			dstErr <- err
+			return
+		}
+		defer dst.Close()
+		dstSum, err = hashReader(ctx, throttled(ctx, budget, IOJobSync, dst))
+		dstErr <- err
+	}()
+
+	srcSum, err := s.hashSourceFile(ctx, budget, sourcePath)
+	if waitErr := <-dstErr; err == nil {
+		err = waitErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("checksum verification of %s: %w", destPath, err)
+	}
+	if bytes.Equal(srcSum, dstSum) {
+		return srcSum, nil
+	}
+
+	atomic.AddInt32(&s.runChecksumMismatches, 1)
+	log.Error().
+		Str("source", sourcePath).
+		Str("dest", destPath).
+		Str("source_sha256", fmt.Sprintf("%x", srcSum)).
+		Str("dest_sha256", fmt.Sprintf("%x", dstSum)).
+		Msg("Copied file does not match its source, removing it")
+	s.logJobEvent(jobLogEntry{Event: jobEventChecksumMismatch, Source: sourcePath, Dest: destPath, Error: ErrChecksumMismatch.Error()})
+	s.destIndex.forget(destPath)
+	if err := target.Remove(destPath); err != nil {
+		log.Warn().Err(err).Str("dest", destPath).Msg("Failed to remove mismatched copy")
+	}
+	return nil, fmt.Errorf("%w: %s", ErrChecksumMismatch, destPath)
+}
+
+// recordFileChecksum keeps the verified sum of a copied file in the state
+// store, so later checks of the copy need not read the source again.
+func (s *Service) recordFileChecksum(relPath string, sum []byte) {
+	s.mu.RLock()
+	store := s.stateStore
+	project := s.project
+	s.mu.RUnlock()
+
+	if store == nil || sum == nil {
+		return
+	}
+	if err := store.RecordFileChecksum(project, filepath.ToSlash(relPath), hexSum(sum)); err != nil {
+		log.Warn().Err(err).Str("file", relPath).Msg("Failed to record file checksum")
+	}
+}
+
+func (s *Service) hashSourceFile(ctx context.Context, budget *ioBudget, sourcePath string) ([]byte, error) {
+	src, err := s.sourceStorage().Open(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	return hashReader(ctx, throttled(ctx, budget, IOJobSync, src))
+}
+
+// hexSum formats sum as hex, or "" when there is none.
+func hexSum(sum []byte) string {
+	if sum == nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sum)
+}
@@ -0,0 +1,36 @@
+package sync
+
+import (
+	"context"
+	"io"
+)
+
+// copyChunk is how much copyWithContext copies between checks of ctx. At
+// the speed of a CIFS share this is well under a second.
+const copyChunk = 8 << 20
+
+// copyWithContext copies src to dst like io.Copy, but stops between chunks
+// once ctx is cancelled, so stopping a job does not wait for the rest of a
+// multi-GB file. Each chunk goes through io.CopyN, which keeps the kernel
+// copy fast path between plain files. A read stuck on an unresponsive share
+// still blocks until the share times out. progress, when set, is called
+// after every chunk that moved data, so a long copy shows as alive.
+func copyWithContext(ctx context.Context, dst io.Writer, src io.Reader, progress func()) (int64, error) {
+	var written int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+		n, err := io.CopyN(dst, src, copyChunk)
+		written += n
+		if n > 0 && progress != nil {
+			progress()
+		}
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
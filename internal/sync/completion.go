@@ -0,0 +1,94 @@
+package sync
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// FinishReasonIdle marks a run that finished by itself after idle_finish
+// without new files.
+const FinishReasonIdle = "idle"
+
+// SetIdleFinish makes a run finish on its own once no file has been copied
+// for d and no task is active. Zero keeps runs going until they are stopped.
+func (s *Service) SetIdleFinish(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.idleFinish = d
+}
+
+func (s *Service) noteProgress(now time.Time) {
+	atomic.StoreInt64(&s.lastProgress, now.UnixNano())
+}
+
+// LastActivity returns when a file was last copied or a run started, or now
+// while an export or a verification pass is running.
+func (s *Service) LastActivity(now time.Time) time.Time {
+	s.mu.RLock()
+	busy := s.exportStatus.Running || s.verifyRunning
+	s.mu.RUnlock()
+
+	if busy {
+		return now
+	}
+	return time.Unix(0, atomic.LoadInt64(&s.lastProgress))
+}
+
+// idleFinishDue reports whether the running job has been idle long enough
+// to finish. Failed files waiting for a retry keep it running.
+func (s *Service) idleFinishDue(now time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.idleFinish <= 0 || s.paused || len(s.pausedNodes) > 0 || len(s.activeTasks) > 0 || len(s.retryQueue) > 0 {
+		return false
+	}
+	return now.Sub(time.Unix(0, atomic.LoadInt64(&s.lastProgress))) >= s.idleFinish
+}
+
+// finishIdle stops the run from inside one of its own goroutines, which
+// Stop waits for, so the stop itself runs separately.
+func (s *Service) finishIdle() {
+	s.mu.Lock()
+	s.finishReason = FinishReasonIdle
+	idle := s.idleFinish
+	s.mu.Unlock()
+
+	log.Info().Str("idle", idle.String()).Msg("No new files, finishing synchronization")
+	go s.Stop()
+}
+
+func (s *Service) recordRunCopy(source, dest string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.runCopies = append(s.runCopies, spotCandidate{source: source, dest: dest})
+}
+
+// RunDir returns the run folder the running job copies into, or
+// that of the last run when none is running.
+func (s *Service) RunDir() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.destDir != "" {
+		return s.destDir
+	}
+	return s.lastRunDir
+}
+
+// LastRunDir returns the run folder of the most recently finished
+// run, or "" before the first run finishes.
+func (s *Service) LastRunDir() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lastRunDir
+}
@@ -0,0 +1,235 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/internal/storage"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+const (
+	defaultSalvageBlockSize = 1 << 20 // 1 MB
+	salvageSectorSize       = 4096
+	// badBlocksSuffix names the sidecar report written next to a degraded copy.
+	badBlocksSuffix = ".badblocks.json"
+)
+
+// DegradedCopyOptions lets a file with unreadable sectors be copied with the
+// gaps zero-filled instead of failing as a whole.
+type DegradedCopyOptions struct {
+	Enabled   bool
+	BlockSize int // Read size while salvaging; failed blocks are retried per 4 KB sector
+}
+
+// badBlocksReport is the sidecar written next to a degraded copy.
+type badBlocksReport struct {
+	Source     string             `json:"source"`
+	Size       int64              `json:"size"`
+	BadBytes   int64              `json:"bad_bytes"`
+	BadRanges  []models.ByteRange `json:"bad_ranges"`
+	LastError  string             `json:"last_error"`
+	CopiedAt   time.Time          `json:"copied_at"`
+	FilledWith string             `json:"filled_with"`
+}
+
+// SetDegradedCopy configures salvage copying of files with read errors.
+// BlockSize zero or negative restores the default.
+func (s *Service) SetDegradedCopy(opts DegradedCopyOptions) {
+	if opts.BlockSize <= 0 {
+		opts.BlockSize = defaultSalvageBlockSize
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.degradedCopy = opts
+}
+
+func (s *Service) degradedCopyOptions() DegradedCopyOptions {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	opts := s.degradedCopy
+	if opts.BlockSize <= 0 {
+		opts.BlockSize = defaultSalvageBlockSize
+	}
+	return opts
+}
+
+// sourceReader remembers the error of the last failed read, so a failed copy
+// can tell a bad source sector from a failing destination.
+type sourceReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *sourceReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+// salvageResult lists the zero-filled ranges of a salvaged copy and the last
+// read error seen.
+type salvageResult struct {
+	bad     []models.ByteRange
+	readErr error
+}
+
+// salvageCopy copies src[offset:size] to dst, which must be positioned at
+// offset. Blocks that fail to read are retried sector by sector; sectors
+// that still fail are written as zeros and returned as bad ranges.
+func salvageCopy(ctx context.Context, src io.ReaderAt, dst io.Writer, offset, size int64, blockSize int) (salvageResult, error) {
+	var result salvageResult
+	buf := make([]byte, blockSize)
+	zeros := make([]byte, salvageSectorSize)
+
+	markBad := func(start, length int64) {
+		if n := len(result.bad); n > 0 && result.bad[n-1].Offset+result.bad[n-1].Length == start {
+			result.bad[n-1].Length += length
+			return
+		}
+		result.bad = append(result.bad, models.ByteRange{Offset: start, Length: length})
+	}
+
+	for offset < size {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		want := int64(blockSize)
+		if remaining := size - offset; remaining < want {
+			want = remaining
+		}
+		n, err := src.ReadAt(buf[:want], offset)
+		if int64(n) == want {
+			if _, werr := dst.Write(buf[:n]); werr != nil {
+				return result, werr
+			}
+			offset += want
+			continue
+		}
+		if err == nil || err == io.EOF {
+			// The source shrank while copying; nothing to salvage past it.
+			return result, fmt.Errorf("source ended at %d bytes, expected %d", offset+int64(n), size)
+		}
+		result.readErr = err
+
+		// Retry the block one sector at a time.
+		for end := offset + want; offset < end; {
+			sector := int64(salvageSectorSize)
+			if end-offset < sector {
+				sector = end - offset
+			}
+			n, err := src.ReadAt(buf[:sector], offset)
+			data := buf[:sector]
+			if int64(n) != sector {
+				if err != nil && err != io.EOF {
+					result.readErr = err
+				}
+				data = zeros[:sector]
+				markBad(offset, sector)
+			}
+			if _, werr := dst.Write(data); werr != nil {
+				return result, werr
+			}
+			offset += sector
+		}
+	}
+
+	return result, nil
+}
+
+// salvageAfterReadError finishes a copy that hit readErr after written bytes,
+// zero-filling what cannot be read, and writes the bad-block report next to
+// destPath. It returns the zero-filled ranges, none if the error was transient.
+func (s *Service) salvageAfterReadError(ctx context.Context, src storage.File, dst io.Writer, sourcePath, destPath string, written int64, readErr error, blockSize int) ([]models.ByteRange, error) {
+	info, err := src.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Warn().Err(readErr).Str("file", sourcePath).Int64("offset", written).Msg("Read error in source file, salvaging readable data")
+
+	result, err := salvageCopy(ctx, src, dst, written, info.Size(), blockSize)
+	if err != nil {
+		return nil, err
+	}
+	bad := result.bad
+	if len(bad) == 0 {
+		// The error was transient; the file is complete.
+		return nil, nil
+	}
+	lastErr := result.readErr
+	if lastErr == nil {
+		lastErr = readErr
+	}
+
+	var badBytes int64
+	for _, r := range bad {
+		badBytes += r.Length
+	}
+	data, err := json.MarshalIndent(badBlocksReport{
+		Source:     sourcePath,
+		Size:       info.Size(),
+		BadBytes:   badBytes,
+		BadRanges:  bad,
+		LastError:  lastErr.Error(),
+		CopiedAt:   time.Now().UTC(),
+		FilledWith: "zeros",
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(destPath+badBlocksSuffix, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write bad-block report: %w", err)
+	}
+	return bad, nil
+}
+
+// reportDegradedFile counts a degraded copy and raises file_degraded.
+func (s *Service) reportDegradedFile(sourcePath, destPath string, bad []models.ByteRange) {
+	var badBytes int64
+	for _, r := range bad {
+		badBytes += r.Length
+	}
+	atomic.AddInt32(&s.runDegradedFiles, 1)
+
+	s.mu.RLock()
+	project := s.project
+	destination := s.destination
+	s.mu.RUnlock()
+
+	log.Warn().
+		Str("file", sourcePath).
+		Int64("bad_bytes", badBytes).
+		Int("bad_ranges", len(bad)).
+		Str("report", destPath+badBlocksSuffix).
+		Msg("File copied degraded, unreadable ranges zero-filled")
+
+	s.emitEvent(models.SyncEvent{
+		Type:        models.SyncEventFileDegraded,
+		Project:     project,
+		Destination: destination,
+		Message:     s.messages.Sprintf(i18n.EventFileDegraded, filepath.Base(sourcePath), badBytes, len(bad)),
+		Reason:      filepath.Base(destPath + badBlocksSuffix),
+	})
+}
+
+// isSourceReadError reports whether err came from reading the source rather
+// than from cancellation or the destination.
+func isSourceReadError(reader *sourceReader, err error) bool {
+	return reader.err != nil && errors.Is(err, reader.err) && !errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,316 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+const (
+	defaultDeltaBlockSize = 64 << 10
+	// deltaLiteralFlush bounds the unmatched source bytes held before they
+	// are written out.
+	deltaLiteralFlush = 1 << 20
+)
+
+// DeltaCopyOptions re-syncs a changed file from the existing destination
+// copy: the old copy is cloned into the temp file, a rolling checksum matches
+// source blocks against it, and blocks still at their old offset are not
+// written again. That covers appended files and same-size edits; data shifted
+// by an insert or a cut has to be rewritten. The patched temp file replaces
+// the copy by rename, like a normal copy, so a crash never leaves a half
+// patched file and a copy hardlinked into an earlier snapshot stays as it
+// was. The whole source is still read, since the share cannot compute
+// checksums itself; the saving is in destination writes, which are shared
+// extents on copy-on-write file systems. Files under MinSize are copied whole.
+type DeltaCopyOptions struct {
+	Enabled   bool
+	MinSize   int64
+	BlockSize int
+}
+
+// deltaStats is what one delta copy wrote.
+type deltaStats struct {
+	size    int64 // Source size
+	written int64 // Bytes written to the destination
+}
+
+// SetDeltaCopy configures delta copies of changed files.
+func (s *Service) SetDeltaCopy(opts DeltaCopyOptions) {
+	if opts.BlockSize <= 0 {
+		opts.BlockSize = defaultDeltaBlockSize
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deltaCopy = opts
+}
+
+func (s *Service) deltaCopyOptions() DeltaCopyOptions {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.deltaCopy
+}
+
+// canDeltaCopy reports whether the earlier copy at destPath can serve as the
+// base of a source of srcSize bytes.
+func (s *Service) canDeltaCopy(destPath string, srcSize int64) bool {
+	opts := s.deltaCopyOptions()
+	if !opts.Enabled || srcSize < opts.MinSize {
+		return false
+	}
+	if !s.localDestination() {
+		return false
+	}
+	info, err := os.Stat(destPath)
+	return err == nil && info.Mode().IsRegular() && info.Size() >= int64(opts.BlockSize)
+}
+
+// deltaCopyFile writes the content of src to tmpPath, starting from a clone
+// of the earlier copy at destPath, which is only read. On error tmpPath is
+// removed and destPath left as it was.
+func (s *Service) deltaCopyFile(ctx context.Context, src io.Reader, destPath, tmpPath string) (deltaStats, error) {
+	blockSize := s.deltaCopyOptions().BlockSize
+
+	old, err := os.Open(destPath)
+	if err != nil {
+		return deltaStats{}, err
+	}
+	defer old.Close()
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return deltaStats{}, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			dst.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	// io.Copy between two files uses copy_file_range, a reflink where the
+	// file system supports it.
+	size, err := io.Copy(dst, old)
+	if err != nil {
+		return deltaStats{}, err
+	}
+	signatures, err := blockSignatures(ctx, io.NewSectionReader(dst, 0, size), blockSize)
+	if err != nil {
+		return deltaStats{}, err
+	}
+	patcher := &deltaPatcher{dst: dst, blockSize: blockSize, signatures: signatures}
+	if err := patcher.run(ctx, throttled(ctx, s.currentIOBudget(), IOJobSync, src)); err != nil {
+		return deltaStats{}, err
+	}
+	if err := dst.Truncate(patcher.pos); err != nil {
+		return deltaStats{}, err
+	}
+	if err := dst.Sync(); err != nil {
+		return deltaStats{}, err
+	}
+	if err := dst.Close(); err != nil {
+		return deltaStats{}, err
+	}
+	committed = true
+	return deltaStats{size: patcher.pos, written: patcher.written}, nil
+}
+
+// blockSignature identifies one full block of the old destination copy.
+type blockSignature struct {
+	index  int64
+	strong [sha256.Size]byte
+}
+
+// blockSignatures returns the signatures of the full blocks of r keyed by
+// their weak checksum. A trailing partial block is left out.
+func blockSignatures(ctx context.Context, r io.Reader, blockSize int) (map[uint32][]blockSignature, error) {
+	signatures := make(map[uint32][]blockSignature)
+	block := make([]byte, blockSize)
+	for index := int64(0); ; index++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(r, block); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return signatures, nil
+			}
+			return nil, err
+		}
+		weak := newRollingChecksum(block).sum()
+		signatures[weak] = append(signatures[weak], blockSignature{index: index, strong: sha256.Sum256(block)})
+	}
+}
+
+// deltaPatcher streams the source over the old copy, matching it against old
+// blocks at or after the write position and skipping the writes of blocks
+// already in place. Writes move forward only, so an old block matched later
+// has not been overwritten, as with rsync --inplace.
+type deltaPatcher struct {
+	dst        *os.File
+	blockSize  int
+	signatures map[uint32][]blockSignature
+
+	pos     int64 // Destination offset of the next byte of the new content
+	written int64
+}
+
+func (p *deltaPatcher) run(ctx context.Context, src io.Reader) error {
+	bs := p.blockSize
+	buf := make([]byte, 0, deltaLiteralFlush+4*bs)
+	literal, start := 0, 0 // buf[literal:start] is unmatched, buf[start:] not yet looked at
+	eof := false
+	var rolling rollingChecksum
+	rolled := false
+
+	for {
+		if !eof && len(buf)-start < bs {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if literal > 0 {
+				buf = append(buf[:0], buf[literal:]...)
+				start -= literal
+				literal = 0
+			}
+			n, err := io.ReadFull(src, buf[len(buf):cap(buf)])
+			buf = buf[:len(buf)+n]
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				eof = true
+			} else if err != nil {
+				return err
+			}
+		}
+		if len(buf)-start < bs {
+			// Too short for a block: the rest is literal.
+			return p.writeLiteral(buf[literal:])
+		}
+
+		window := buf[start : start+bs]
+		if !rolled {
+			rolling = newRollingChecksum(window)
+			rolled = true
+		}
+		if index, ok := p.match(rolling.sum(), window); ok {
+			if err := p.writeLiteral(buf[literal:start]); err != nil {
+				return err
+			}
+			if err := p.reuseBlock(index, window); err != nil {
+				return err
+			}
+			start += bs
+			literal = start
+			rolled = false
+			continue
+		}
+
+		if start+bs < len(buf) {
+			rolling.roll(buf[start], buf[start+bs])
+		} else {
+			rolled = false
+		}
+		start++
+		if start-literal >= deltaLiteralFlush {
+			if err := p.writeLiteral(buf[literal:start]); err != nil {
+				return err
+			}
+			literal = start
+		}
+	}
+}
+
+// match finds an old block equal to window that has not been overwritten.
+// The block already in place is preferred, since it needs no write at all.
+func (p *deltaPatcher) match(weak uint32, window []byte) (int64, bool) {
+	candidates := p.signatures[weak]
+	if len(candidates) == 0 {
+		return 0, false
+	}
+	strong := sha256.Sum256(window)
+	found, index := false, int64(0)
+	for _, candidate := range candidates {
+		offset := candidate.index * int64(p.blockSize)
+		if candidate.strong != strong || offset < p.pos {
+			continue
+		}
+		if offset == p.pos {
+			return candidate.index, true
+		}
+		if !found {
+			found, index = true, candidate.index
+		}
+	}
+	return index, found
+}
+
+// reuseBlock puts old block index at the write position. A block already
+// there is left alone; another one is copied from the old copy.
+func (p *deltaPatcher) reuseBlock(index int64, window []byte) error {
+	offset := index * int64(p.blockSize)
+	if offset != p.pos {
+		if _, err := p.dst.WriteAt(window, p.pos); err != nil {
+			return err
+		}
+		p.written += int64(len(window))
+	}
+	p.pos += int64(len(window))
+	return nil
+}
+
+// writeLiteral writes source bytes the old copy does not hold, skipping the
+// part that already reads the same at the write position.
+func (p *deltaPatcher) writeLiteral(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	existing := make([]byte, len(data))
+	n, err := p.dst.ReadAt(existing, p.pos)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if n == len(data) && bytes.Equal(existing, data) {
+		p.pos += int64(len(data))
+		return nil
+	}
+	if _, err := p.dst.WriteAt(data, p.pos); err != nil {
+		return err
+	}
+	p.pos += int64(len(data))
+	p.written += int64(len(data))
+	return nil
+}
+
+// rollingChecksum is the rsync weak checksum of a window, updated in O(1)
+// as the window slides one byte.
+type rollingChecksum struct {
+	a, b uint32
+	n    uint32
+}
+
+func newRollingChecksum(window []byte) rollingChecksum {
+	c := rollingChecksum{n: uint32(len(window))}
+	for i, x := range window {
+		c.a += uint32(x)
+		c.b += uint32(len(window)-i) * uint32(x)
+	}
+	return c
+}
+
+func (c *rollingChecksum) roll(out, in byte) {
+	c.a += uint32(in) - uint32(out)
+	c.b += c.a - c.n*uint32(out)
+}
+
+func (c rollingChecksum) sum() uint32 {
+	return c.a&0xffff | c.b<<16
+}
+
+func (s *Service) recordDeltaCopy(stats deltaStats) {
+	atomic.AddInt32(&s.runDeltaFiles, 1)
+	atomic.AddInt64(&s.runDeltaSavedBytes, stats.size-stats.written)
+}
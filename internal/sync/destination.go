@@ -0,0 +1,217 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// ErrDestinationUnmounted is returned when the filesystem the destination was
+// started on is no longer mounted (e.g. a USB cable was pulled).
+var ErrDestinationUnmounted = errors.New("destination filesystem is no longer mounted")
+
+// mountInfo identifies the filesystem a path lives on.
+type mountInfo struct {
+	MountPoint string
+	Device     string
+	FSType     string
+}
+
+// resolveMountForPath returns the /proc/mounts entry with the longest mount
+// point that contains path.
+func resolveMountForPath(path string) (mountInfo, error) {
+	data, err := os.ReadFile("/proc/mounts")
+	if err != nil {
+		return mountInfo{}, err
+	}
+
+	return findMountForPath(string(data), path), nil
+}
+
+func findMountForPath(procMounts, path string) mountInfo {
+	clean := filepath.ToSlash(filepath.Clean(path))
+
+	var best mountInfo
+	for _, line := range strings.Split(procMounts, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		fsType := ""
+		if len(fields) >= 3 {
+			fsType = fields[2]
+		}
+
+		mountPoint := unescapeMountField(fields[1])
+		if mountPoint != "/" && clean != mountPoint && !strings.HasPrefix(clean, mountPoint+"/") {
+			continue
+		}
+
+		if len(mountPoint) >= len(best.MountPoint) {
+			best = mountInfo{MountPoint: mountPoint, Device: fields[0], FSType: fsType}
+		}
+	}
+
+	return best
+}
+
+// unescapeMountField decodes the octal escapes /proc/mounts uses for spaces
+// and other special characters.
+func unescapeMountField(field string) string {
+	replacer := strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`)
+	return replacer.Replace(field)
+}
+
+// verifyDestinationMounted checks that destRoot still lives on the same
+// filesystem that was mounted when the run started. Destinations that started
+// on the root filesystem are not checked.
+func (s *Service) verifyDestinationMounted(destRoot string) error {
+	s.mu.RLock()
+	expected := s.destinationMount
+	resolve := s.resolveMount
+	s.mu.RUnlock()
+
+	if expected.MountPoint == "" || expected.MountPoint == "/" {
+		return nil
+	}
+
+	if resolve == nil {
+		resolve = resolveMountForPath
+	}
+
+	current, err := resolve(destRoot)
+	if err != nil {
+		return fmt.Errorf("failed to check destination mount %s: %w", expected.MountPoint, err)
+	}
+
+	if current.MountPoint != expected.MountPoint || current.Device != expected.Device {
+		return fmt.Errorf("%w: %s (%s)", ErrDestinationUnmounted, expected.MountPoint, expected.Device)
+	}
+
+	return nil
+}
+
+// hardPause stops dispatching copies, cancels in-flight tasks and raises an
+// alert. The job stays paused until Resume is called; stopping it before
+// that reports the run as failed.
+func (s *Service) hardPause(reason string) {
+	project, destination, ok := s.pause(reason)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	s.failReason = reason
+	s.mu.Unlock()
+
+	log.Error().
+		Str("project", project).
+		Str("destination", destination).
+		Str("reason", reason).
+		Msg("Destination lost, synchronization paused")
+
+	s.emitEvent(models.SyncEvent{
+		Type:        models.SyncEventDestinationLost,
+		Project:     project,
+		Destination: destination,
+		Message:     s.messages.Sprintf(i18n.EventDestinationLost, destination),
+		Reason:      reason,
+	})
+}
+
+// pause stops dispatching new copies and cancels active tasks. It reports
+// false if the service is not running or already paused.
+func (s *Service) pause(reason string) (project, destination string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isRunning || s.paused {
+		return "", "", false
+	}
+
+	s.paused = true
+	s.pauseReason = reason
+	s.invalidateStatus()
+	for _, task := range s.activeTasks {
+		if task.cancel != nil {
+			task.cancel()
+		}
+	}
+
+	return s.project, s.destination, true
+}
+
+func (s *Service) isPaused() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.paused
+}
+
+// PauseReasonOperator is the pause reason reported for a pause requested
+// through Pause.
+const PauseReasonOperator = "paused by operator"
+
+// Pause stops dispatching new copies. Unlike Stop it keeps the job, its
+// progress and the mounted shares, and copies already in flight finish, so
+// Resume continues where the job left off instead of rescanning from scratch.
+func (s *Service) Pause() error {
+	s.mu.Lock()
+	if !s.isRunning {
+		s.mu.Unlock()
+		return fmt.Errorf("synchronization is not running")
+	}
+	if s.paused {
+		s.mu.Unlock()
+		return nil
+	}
+	s.paused = true
+	s.pauseReason = PauseReasonOperator
+	s.invalidateStatus()
+	project := s.project
+	s.mu.Unlock()
+
+	log.Info().Str("project", project).Msg("Synchronization paused by operator")
+	return nil
+}
+
+// Resume clears a pause after verifying that the destination is mounted again.
+func (s *Service) Resume() error {
+	s.mu.RLock()
+	running := s.isRunning
+	paused := s.paused
+	destination := s.destination
+	s.mu.RUnlock()
+
+	if !running {
+		return fmt.Errorf("synchronization is not running")
+	}
+	if !paused {
+		return nil
+	}
+
+	if err := ensureDestinationReady(destination); err != nil {
+		return err
+	}
+	if err := s.verifyDestinationMounted(destination); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.paused = false
+	s.pauseReason = ""
+	s.failReason = ""
+	// Files may have been removed to make room, or replaced, while paused.
+	s.quotaBaseline = nil
+	s.destIndex.reset()
+	s.invalidateStatus()
+	s.mu.Unlock()
+
+	log.Info().Str("destination", destination).Msg("Synchronization resumed")
+	return nil
+}
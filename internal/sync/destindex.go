@@ -0,0 +1,107 @@
+package sync
+
+import (
+	"sync"
+	"time"
+)
+
+// DestinationIndexOptions keeps the size and mtime of every source file
+// found unchanged on the destination in memory, so the next scans skip the
+// destination stat (and the state database lookup) for it. The index is
+// dropped every RefreshInterval, so copies deleted or replaced behind the
+// service's back are noticed; the copied files of the state database are its
+// persisted form across restarts.
+type DestinationIndexOptions struct {
+	Enabled         bool
+	RefreshInterval time.Duration
+}
+
+// destIndexEntry is the source file a destination copy was found to match.
+type destIndexEntry struct {
+	size    int64
+	modTime time.Time
+}
+
+// destinationIndex maps destination paths to the source file their copy
+// matched when it was last stat-ed or written.
+type destinationIndex struct {
+	mu      sync.Mutex
+	entries map[string]destIndexEntry
+	builtAt time.Time
+}
+
+// SetDestinationIndex configures the destination index. It takes effect
+// with the next job.
+func (s *Service) SetDestinationIndex(opts DestinationIndexOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.destIndexOpts = opts
+}
+
+// destinationIndexOptions returns the index settings, disabled during a
+// forced full resync, which must look at every copy again.
+func (s *Service) destinationIndexOptions() DestinationIndexOptions {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.forceFullResync {
+		return DestinationIndexOptions{}
+	}
+	return s.destIndexOpts
+}
+
+// indexedCopyMatches reports whether destPath is indexed as a copy of a
+// source file of size and modTime.
+func (s *Service) indexedCopyMatches(destPath string, size int64, modTime time.Time) bool {
+	opts := s.destinationIndexOptions()
+	if !opts.Enabled {
+		return false
+	}
+	return s.destIndex.lookup(destPath, size, modTime, opts.RefreshInterval, time.Now())
+}
+
+// indexCopy records destPath as an up-to-date copy of a source file of size
+// and modTime.
+func (s *Service) indexCopy(destPath string, size int64, modTime time.Time) {
+	if !s.destinationIndexOptions().Enabled {
+		return
+	}
+	s.destIndex.remember(destPath, destIndexEntry{size: size, modTime: modTime})
+}
+
+func (i *destinationIndex) lookup(path string, size int64, modTime time.Time, refresh time.Duration, now time.Time) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if refresh > 0 && now.Sub(i.builtAt) >= refresh {
+		i.entries = nil
+	}
+	entry, ok := i.entries[path]
+	return ok && entry.size == size && entry.modTime.Equal(modTime)
+}
+
+func (i *destinationIndex) remember(path string, entry destIndexEntry) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.entries == nil {
+		i.entries = make(map[string]destIndexEntry)
+		i.builtAt = time.Now()
+	}
+	i.entries[path] = entry
+}
+
+func (i *destinationIndex) forget(path string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	delete(i.entries, path)
+}
+
+func (i *destinationIndex) reset() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.entries = nil
+}
@@ -0,0 +1,59 @@
+package sync
+
+import (
+	"context"
+	"path/filepath"
+)
+
+// EstimateRemainingBytes sums the size of project files on all node shares
+// that still have to be copied. Files already recorded as copied are skipped
+// unless forceFullResync is set.
+func (s *Service) EstimateRemainingBytes(ctx context.Context, project string, forceFullResync bool) (int64, error) {
+	s.mu.RLock()
+	store := s.stateStore
+	s.mu.RUnlock()
+
+	var total int64
+	for _, node := range s.nodes {
+		for _, share := range s.sharesFor(node) {
+			if err := ctx.Err(); err != nil {
+				return total, err
+			}
+
+			source := filepath.Join(s.shareRoot(node, share), project)
+			if _, err := s.sourceStorage().Stat(source); err != nil {
+				continue
+			}
+
+			files, err := s.scanDirectory(ctx, source, source)
+			if err != nil {
+				return total, err
+			}
+
+			for _, file := range files {
+				if err := ctx.Err(); err != nil {
+					return total, err
+				}
+
+				info, err := s.sourceStorage().Stat(file)
+				if err != nil {
+					continue
+				}
+
+				if store != nil && !forceFullResync {
+					relPath, err := filepath.Rel(source, file)
+					if err == nil {
+						copied, err := store.IsFileCopied(project, filepath.ToSlash(relPath), info.Size(), info.ModTime())
+						if err == nil && copied {
+							continue
+						}
+					}
+				}
+
+				total += info.Size()
+			}
+		}
+	}
+
+	return total, nil
+}
@@ -0,0 +1,108 @@
+package sync
+
+import (
+	"strings"
+
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+var (
+	defaultExcludedDirectories = []string{
+		"System Volume Information",
+		"RECYCLER",
+		"RECYCLED",
+		"$RECYCLE.BIN",
+		".git",
+		".svn",
+		"node_modules",
+	}
+	defaultExcludedProjects = []string{
+		"system volume information", "recycler", "recycled", "$recycle.bin",
+		"logs", "log", "temp", "tmp", "windows", "program files",
+	}
+)
+
+// DefaultExclusions returns the built-in directory and project exclusions.
+func DefaultExclusions() models.Exclusions {
+	return models.Exclusions{
+		Directories: append([]string(nil), defaultExcludedDirectories...),
+		Projects:    append([]string(nil), defaultExcludedProjects...),
+	}
+}
+
+// SetExclusions replaces the exclusion lists. Names are trimmed and
+// de-duplicated case-insensitively; a nil list keeps the built-in default,
+// an empty one disables that kind of exclusion.
+func (s *Service) SetExclusions(exclusions models.Exclusions) {
+	defaults := DefaultExclusions()
+	if exclusions.Directories == nil {
+		exclusions.Directories = defaults.Directories
+	}
+	if exclusions.Projects == nil {
+		exclusions.Projects = defaults.Projects
+	}
+	exclusions.Directories = normalizeExclusionList(exclusions.Directories)
+	exclusions.Projects = normalizeExclusionList(exclusions.Projects)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.exclusions = exclusions
+}
+
+// Exclusions returns a copy of the active exclusion lists.
+func (s *Service) Exclusions() models.Exclusions {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return models.Exclusions{
+		Directories: append([]string{}, s.exclusions.Directories...),
+		Projects:    append([]string{}, s.exclusions.Projects...),
+	}
+}
+
+func normalizeExclusionList(names []string) []string {
+	seen := make(map[string]struct{}, len(names))
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		key := strings.ToLower(name)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		normalized = append(normalized, name)
+	}
+	return normalized
+}
+
+// isValidProjectName rejects system folders, names listed in excluded (also
+// matching "<name> ..." variants) and hidden or one-letter names.
+func isValidProjectName(name string, excluded []string) bool {
+	lower := strings.ToLower(name)
+	for _, ex := range excluded {
+		ex = strings.ToLower(ex)
+		if lower == ex || strings.HasPrefix(lower, ex+" ") {
+			return false
+		}
+	}
+
+	if strings.HasPrefix(name, "$") || strings.HasPrefix(name, ".") || len(name) <= 1 {
+		return false
+	}
+
+	return true
+}
+
+func isExcludedDirectory(name string, excluded []string) bool {
+	for _, ex := range excluded {
+		if strings.EqualFold(name, ex) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,470 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/internal/state"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// ErrExportRunning is returned by StartExport while another export is active.
+var ErrExportRunning = errors.New("export already running")
+
+// ExportOptions describes a delivery export: completed, verified captures of
+// Project are copied from the run folders under Source (the working
+// destination root) to Destination/<project>.
+type ExportOptions struct {
+	Project     string
+	Source      string
+	Destination string
+	Lvl00Only   bool // Deliver only Lvl00 RAW files and EAD XML
+	// FromCapture and ToCapture limit the delivery to an inclusive range of
+	// capture numbers, compared by value; empty means unbounded.
+	FromCapture string
+	ToCapture   string
+	// Tags limits the delivery to captures with any of these tags;
+	// ExcludeTags leaves out captures with any of them, e.g. "re-fly".
+	Tags        []string
+	ExcludeTags []string
+}
+
+// tagsAllow reports whether a capture with tags passes the export's tag
+// filters.
+func (opts ExportOptions) tagsAllow(tags []string) bool {
+	has := func(wanted []string) bool {
+		for _, tag := range tags {
+			for _, candidate := range wanted {
+				if tag == candidate {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if len(opts.Tags) > 0 && !has(opts.Tags) {
+		return false
+	}
+	return !has(opts.ExcludeTags)
+}
+
+// inRange reports whether captureNumber lies within the export's capture range.
+func (opts ExportOptions) inRange(captureNumber string) bool {
+	if opts.FromCapture != "" && models.CompareCaptureNumbers(captureNumber, opts.FromCapture) < 0 {
+		return false
+	}
+	if opts.ToCapture != "" && models.CompareCaptureNumbers(captureNumber, opts.ToCapture) > 0 {
+		return false
+	}
+	return true
+}
+
+// exportFile is one file selected for delivery.
+type exportFile struct {
+	path    string // In the working copy
+	relPath string // Under the project folder
+	size    int64
+}
+
+// deliveryManifest is written next to the delivered captures.
+type deliveryManifest struct {
+	Project     string                       `json:"project"`
+	Registry    *models.ProjectRegistryEntry `json:"registry,omitempty"` // Alias, customer and notes of Project
+	Notes       []models.JobNote             `json:"notes,omitempty"`    // Operator notes of the project's sync jobs
+	GeneratedAt time.Time                    `json:"generated_at"`
+	Lvl00Only   bool                         `json:"lvl00_only"`
+	Captures    []string                     `json:"captures"`
+	Tags        map[string][]string          `json:"tags,omitempty"` // Tags of the delivered captures
+	Files       []deliveryManifestFile       `json:"files"`
+}
+
+type deliveryManifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// StartExport starts a delivery export in the background. Progress is
+// reported by ExportStatus and the result as an export_finished or
+// export_failed event.
+func (s *Service) StartExport(ctx context.Context, opts ExportOptions) error {
+	opts.Source = filepath.Clean(opts.Source)
+	opts.Destination = filepath.Clean(opts.Destination)
+	if opts.Project == "" || opts.Source == "" || opts.Destination == "" {
+		return fmt.Errorf("project, source and destination are required")
+	}
+	if opts.Source == opts.Destination {
+		return fmt.Errorf("delivery destination must differ from the working destination")
+	}
+	for _, bound := range []*string{&opts.FromCapture, &opts.ToCapture} {
+		if *bound == "" {
+			continue
+		}
+		if _, ok := models.CaptureNumberValue(*bound); !ok {
+			return fmt.Errorf("invalid capture number %q", *bound)
+		}
+		*bound = models.NormalizeCaptureNumber(*bound)
+	}
+	if opts.FromCapture != "" && opts.ToCapture != "" && models.CompareCaptureNumbers(opts.FromCapture, opts.ToCapture) > 0 {
+		return fmt.Errorf("capture range %s-%s is empty", opts.FromCapture, opts.ToCapture)
+	}
+	for _, tags := range []*[]string{&opts.Tags, &opts.ExcludeTags} {
+		normalized, err := state.NormalizeCaptureTags(*tags)
+		if err != nil {
+			return err
+		}
+		*tags = normalized
+	}
+	if err := ensureDestinationReady(opts.Destination); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.exportStatus.Running {
+		return ErrExportRunning
+	}
+	if s.stateStore == nil {
+		return fmt.Errorf("state database is not available")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s.exportCancel = cancel
+	s.exportStatus = models.ExportStatus{
+		Running:     true,
+		Project:     opts.Project,
+		Source:      opts.Source,
+		Destination: opts.Destination,
+		Lvl00Only:   opts.Lvl00Only,
+		FromCapture: opts.FromCapture,
+		ToCapture:   opts.ToCapture,
+		Tags:        opts.Tags,
+		ExcludeTags: opts.ExcludeTags,
+		StartedAt:   time.Now().UTC(),
+	}
+
+	go s.runExport(ctx, opts)
+	return nil
+}
+
+// CancelExport stops a running export. Files already delivered stay in place
+// and are skipped by the next export.
+func (s *Service) CancelExport() {
+	s.mu.RLock()
+	cancel := s.exportCancel
+	s.mu.RUnlock()
+
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// ExportStatus returns the progress of the current or last export.
+func (s *Service) ExportStatus() models.ExportStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.exportStatus
+}
+
+func (s *Service) runExport(ctx context.Context, opts ExportOptions) {
+	manifest, err := s.export(ctx, opts)
+
+	s.mu.Lock()
+	finishedAt := time.Now().UTC()
+	s.exportStatus.Running = false
+	s.exportStatus.FinishedAt = &finishedAt
+	s.exportStatus.Manifest = manifest
+	if err != nil {
+		s.exportStatus.Error = err.Error()
+	}
+	status := s.exportStatus
+	s.exportCancel()
+	s.exportCancel = nil
+	s.mu.Unlock()
+
+	event := models.SyncEvent{
+		Type:        models.SyncEventExportFinished,
+		Project:     opts.Project,
+		Destination: opts.Destination,
+		Message:     s.messages.Sprintf(i18n.EventExportFinished, status.Captures, status.CopiedFiles, status.TotalFiles),
+	}
+	if err != nil {
+		log.Error().Err(err).Str("project", opts.Project).Msg("Delivery export failed")
+		event.Type = models.SyncEventExportFailed
+		event.Message = s.messages.Sprintf(i18n.EventExportFailed)
+		event.Reason = err.Error()
+	} else {
+		log.Info().Str("project", opts.Project).Str("manifest", manifest).Int("captures", status.Captures).Msg("Delivery export finished")
+	}
+	s.emitEvent(event)
+}
+
+// export copies the selected files and writes the delivery manifest,
+// returning its path.
+func (s *Service) export(ctx context.Context, opts ExportOptions) (string, error) {
+	s.mu.RLock()
+	store := s.stateStore
+	requiredSensors := len(s.requiredSensors)
+	s.mu.RUnlock()
+
+	completed, err := store.CompletedCaptureNumbers(opts.Project)
+	if err != nil {
+		return "", fmt.Errorf("failed to load completed captures: %w", err)
+	}
+	tags, err := store.CaptureTags(opts.Project)
+	if err != nil {
+		return "", fmt.Errorf("failed to load capture tags: %w", err)
+	}
+	registry, err := store.ProjectRegistryEntry(opts.Project)
+	if err != nil {
+		return "", fmt.Errorf("failed to load project registry: %w", err)
+	}
+	notes, err := store.ProjectJobNotes(opts.Project)
+	if err != nil {
+		return "", fmt.Errorf("failed to load job notes: %w", err)
+	}
+	files, captures, skipped, err := selectExportFiles(opts, completed, tags, requiredSensors)
+	if err != nil {
+		return "", err
+	}
+
+	var totalBytes int64
+	for _, file := range files {
+		totalBytes += file.size
+	}
+	s.updateExportStatus(func(status *models.ExportStatus) {
+		status.Captures = len(captures)
+		status.SkippedCaptures = skipped
+		status.TotalFiles = len(files)
+		status.TotalBytes = totalBytes
+	})
+
+	deliveryDir := filepath.Join(opts.Destination, opts.Project)
+	manifest := deliveryManifest{
+		Project:   opts.Project,
+		Registry:  registry,
+		Notes:     notes,
+		Lvl00Only: opts.Lvl00Only,
+		Captures:  captures,
+		Tags:      make(map[string][]string),
+		Files:     make([]deliveryManifestFile, 0, len(files)),
+	}
+	for _, captureNumber := range captures {
+		if len(tags[captureNumber]) > 0 {
+			manifest.Tags[captureNumber] = tags[captureNumber]
+		}
+	}
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		sum, err := deliverFile(ctx, s.currentIOBudget(), file.path, filepath.Join(deliveryDir, file.relPath))
+		if err != nil {
+			return "", fmt.Errorf("failed to deliver %s: %w", file.relPath, err)
+		}
+		manifest.Files = append(manifest.Files, deliveryManifestFile{
+			Path:   filepath.ToSlash(file.relPath),
+			Size:   file.size,
+			SHA256: sum,
+		})
+		s.updateExportStatus(func(status *models.ExportStatus) {
+			status.CopiedFiles++
+			status.CopiedBytes += file.size
+		})
+	}
+
+	manifest.GeneratedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(deliveryDir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(deliveryDir, opts.Project+"-delivery-manifest.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func (s *Service) updateExportStatus(update func(*models.ExportStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update(&s.exportStatus)
+}
+
+// selectExportFiles picks the files of completed, verified captures from the
+// run folders of the project. A capture counts as verified when every sensor
+// it delivered has a Lvl00 RAW file. When the same file exists in several run
+// folders the newest one wins. Captures left out by the tag filters are not
+// counted as skipped.
+func selectExportFiles(opts ExportOptions, completed map[string]bool, tags map[string][]string, requiredSensors int) ([]exportFile, []string, int, error) {
+	projectDirs, err := ProjectRunFolders(opts.Source, opts.Project)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	byRelPath := make(map[string]exportFile)
+	captureOf := make(map[string]string)                // relPath -> capture number
+	verifiedSensors := make(map[string]map[string]bool) // capture -> sensor -> has Lvl00
+	for _, projectDir := range projectDirs {
+		err := filepath.Walk(projectDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			name := info.Name()
+			capture := ParseCaptureFile(name)
+			if capture == nil {
+				return nil
+			}
+			include := true
+			switch capture.Kind {
+			case models.CaptureFileRaw:
+				sensors, ok := verifiedSensors[capture.CaptureNumber]
+				if !ok {
+					sensors = make(map[string]bool)
+					verifiedSensors[capture.CaptureNumber] = sensors
+				}
+				sensors[capture.SensorCode] = sensors[capture.SensorCode] || capture.IsVerified
+				include = !opts.Lvl00Only || capture.IsVerified
+			case models.CaptureFileQuality:
+				include = !opts.Lvl00Only
+			}
+			if capture.IsTest || !include || !opts.inRange(capture.CaptureNumber) {
+				return nil
+			}
+
+			relPath, err := filepath.Rel(projectDir, path)
+			if err != nil {
+				return err
+			}
+			byRelPath[relPath] = exportFile{path: path, relPath: relPath, size: info.Size()}
+			captureOf[relPath] = capture.CaptureNumber
+			return nil
+		})
+		if err != nil {
+			return nil, nil, 0, err
+		}
+	}
+
+	deliverable := make(map[string]bool)
+	seen := make(map[string]bool)
+	for _, captureNumber := range captureOf {
+		if seen[captureNumber] || !opts.tagsAllow(tags[captureNumber]) {
+			continue
+		}
+		seen[captureNumber] = true
+		if completed[captureNumber] && captureVerified(verifiedSensors[captureNumber], requiredSensors) {
+			deliverable[captureNumber] = true
+		}
+	}
+
+	files := make([]exportFile, 0, len(byRelPath))
+	for relPath, file := range byRelPath {
+		if deliverable[captureOf[relPath]] {
+			files = append(files, file)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].relPath < files[j].relPath })
+
+	captures := make([]string, 0, len(deliverable))
+	for captureNumber := range deliverable {
+		captures = append(captures, captureNumber)
+	}
+	sort.Slice(captures, func(i, j int) bool { return models.CompareCaptureNumbers(captures[i], captures[j]) < 0 })
+	return files, captures, len(seen) - len(deliverable), nil
+}
+
+func captureVerified(sensors map[string]bool, requiredSensors int) bool {
+	if len(sensors) < requiredSensors {
+		return false
+	}
+	for _, verified := range sensors {
+		if !verified {
+			return false
+		}
+	}
+	return true
+}
+
+// deliverFile copies source to dest through a temporary file and returns the
+// SHA-256 of the delivered content. A dest with matching size and mtime is
+// kept, so an interrupted export resumes where it stopped. The copy draws on
+// budget as the export job.
+func deliverFile(ctx context.Context, budget *ioBudget, source, dest string) (string, error) {
+	srcInfo, err := os.Stat(source)
+	if err != nil {
+		return "", err
+	}
+	if dstInfo, err := os.Stat(dest); err == nil && dstInfo.Size() == srcInfo.Size() && dstInfo.ModTime().Equal(srcInfo.ModTime()) {
+		existing, err := os.Open(dest)
+		if err != nil {
+			return "", err
+		}
+		defer existing.Close()
+		sum, err := hashReader(ctx, existing)
+		if err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(sum), nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", err
+	}
+	src, err := os.Open(source)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	tmp := dest + exportTempSuffix
+	dst, err := os.Create(tmp)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(dst, h), throttled(ctx, budget, IOJobExport, &contextReader{ctx: ctx, r: src})); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	os.Chtimes(tmp, srcInfo.ModTime(), srcInfo.ModTime())
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// contextReader stops a copy once ctx is cancelled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
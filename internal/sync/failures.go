@@ -0,0 +1,183 @@
+package sync
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+const defaultMaxCopyAttempts = 3
+
+// SetMaxCopyAttempts configures how many consecutive failed copies of one file
+// are tolerated before it is quarantined. Zero or negative restores the default.
+func (s *Service) SetMaxCopyAttempts(attempts int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if attempts <= 0 {
+		attempts = defaultMaxCopyAttempts
+	}
+	s.maxCopyAttempts = attempts
+}
+
+// recordCopyFailure counts a failed copy of sourcePath. Once the file reaches
+// the attempt limit it is quarantined: skipped by later scans until
+// re-queued. Returns true when this failure quarantined the file.
+func (s *Service) recordCopyFailure(task *taskInfo, sourcePath, relPath string, copyErr error) bool {
+	s.mu.Lock()
+	failure := s.noteCopyFailureLocked(task, sourcePath, relPath, copyErr)
+
+	maxAttempts := s.maxCopyAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxCopyAttempts
+	}
+	quarantined := !failure.Quarantined && failure.Attempts >= maxAttempts
+	if quarantined {
+		failure.Quarantined = true
+	}
+	project := s.project
+	destination := s.destination
+	attempts := failure.Attempts
+	s.mu.Unlock()
+
+	if quarantined {
+		s.logJobEvent(jobLogEntry{Event: jobEventQuarantined, Node: task.node, Source: sourcePath, Error: copyErr.Error()})
+		s.emitEvent(models.SyncEvent{
+			Type:        models.SyncEventFileQuarantined,
+			Project:     project,
+			Destination: destination,
+			Message:     s.messages.Sprintf(i18n.EventFileQuarantined, filepath.Base(sourcePath), attempts),
+			Reason:      copyErr.Error(),
+		})
+	}
+
+	return quarantined
+}
+
+// quarantineIncompatibleFile quarantines a file the destination cannot hold
+// on its first failure: no retry can copy it until the destination changes.
+// It is reported with a single destination_incompatible event.
+func (s *Service) quarantineIncompatibleFile(task *taskInfo, sourcePath, relPath string, copyErr error) {
+	s.mu.Lock()
+	failure := s.noteCopyFailureLocked(task, sourcePath, relPath, copyErr)
+	alreadyQuarantined := failure.Quarantined
+	failure.Quarantined = true
+	s.mu.Unlock()
+
+	if alreadyQuarantined {
+		return
+	}
+	log.Warn().Err(copyErr).Str("file", sourcePath).Msg("File does not fit the destination filesystem, skipping it")
+	s.logJobEvent(jobLogEntry{Event: jobEventQuarantined, Node: task.node, Source: sourcePath, Error: copyErr.Error()})
+	s.reportIncompatibleFile(sourcePath, copyErr)
+}
+
+// noteCopyFailureLocked counts a failed copy of sourcePath and returns its
+// failure record. Must be called with s.mu held.
+func (s *Service) noteCopyFailureLocked(task *taskInfo, sourcePath, relPath string, copyErr error) *models.CopyFailure {
+	now := time.Now().UTC()
+	if s.failures == nil {
+		s.failures = make(map[string]*models.CopyFailure)
+	}
+	failure, ok := s.failures[sourcePath]
+	if !ok {
+		failure = &models.CopyFailure{
+			Path:          sourcePath,
+			RelativePath:  relPath,
+			Node:          task.node,
+			Share:         task.share,
+			FirstFailedAt: now,
+		}
+		s.failures[sourcePath] = failure
+	}
+	failure.Attempts++
+	failure.LastError = copyErr.Error()
+	failure.LastFailedAt = now
+	return failure
+}
+
+// clearCopyFailure forgets earlier failures of a file that has now been copied.
+func (s *Service) clearCopyFailure(sourcePath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, sourcePath)
+}
+
+// hasCopyFailure reports whether an earlier copy of sourcePath failed.
+func (s *Service) hasCopyFailure(sourcePath string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.failures[sourcePath]
+	return ok
+}
+
+func (s *Service) isQuarantined(sourcePath string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	failure, ok := s.failures[sourcePath]
+	return ok && failure.Quarantined
+}
+
+func (s *Service) quarantinedCountLocked() int {
+	count := 0
+	for _, failure := range s.failures {
+		if failure.Quarantined {
+			count++
+		}
+	}
+	return count
+}
+
+// CopyFailures returns the quarantined files, most recent failure first.
+func (s *Service) CopyFailures() []models.CopyFailure {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	failures := make([]models.CopyFailure, 0, len(s.failures))
+	for _, failure := range s.failures {
+		if failure.Quarantined {
+			failures = append(failures, *failure)
+		}
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].LastFailedAt.After(failures[j].LastFailedAt)
+	})
+	return failures
+}
+
+// RequeueFailures releases quarantined files so the next scan copies them
+// again. With no paths, every quarantined file is released. Returns the
+// number of files re-queued.
+func (s *Service) RequeueFailures(paths []string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requeued := 0
+	if len(paths) == 0 {
+		for path, failure := range s.failures {
+			if failure.Quarantined {
+				delete(s.failures, path)
+				requeued++
+			}
+		}
+	} else {
+		for _, path := range paths {
+			if failure, ok := s.failures[path]; ok && failure.Quarantined {
+				delete(s.failures, path)
+				requeued++
+			}
+		}
+	}
+
+	if requeued > 0 {
+		log.Info().Int("files", requeued).Msg("Re-queued quarantined files")
+	}
+	return requeued
+}
@@ -0,0 +1,62 @@
+package sync
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// FileFilter limits the files synced by name. Patterns use filepath.Match
+// syntax and match the file name case-insensitively. An empty Include lets
+// every name in; Exclude wins over Include.
+type FileFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// SetFileFilter replaces the include and exclude patterns of synced files.
+func (s *Service) SetFileFilter(filter FileFilter) {
+	normalized := FileFilter{
+		Include: normalizePatterns(filter.Include),
+		Exclude: normalizePatterns(filter.Exclude),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fileFilter = normalized
+}
+
+func (s *Service) currentFileFilter() FileFilter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.fileFilter
+}
+
+func normalizePatterns(patterns []string) []string {
+	var normalized []string
+	for _, pattern := range patterns {
+		if pattern = strings.ToLower(strings.TrimSpace(pattern)); pattern != "" {
+			normalized = append(normalized, pattern)
+		}
+	}
+	return normalized
+}
+
+// allows reports whether the file at path passes the filter.
+func (f FileFilter) allows(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	if matchesAny(f.Exclude, name) {
+		return false
+	}
+	return len(f.Include) == 0 || matchesAny(f.Include, name)
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
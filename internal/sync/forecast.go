@@ -0,0 +1,98 @@
+package sync
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// remainingBytesLocked sums the bytes still queued by active tasks. Caller must hold s.mu.
+func (s *Service) remainingBytesLocked() int64 {
+	var remaining int64
+	for _, task := range s.activeTasks {
+		left := atomic.LoadInt64(&task.totalBytes) - atomic.LoadInt64(&task.copiedBytes)
+		if left > 0 {
+			remaining += left
+		}
+	}
+	return remaining
+}
+
+// DiskSpaceForecast projects destination free space against the bytes that
+// still have to be copied in the current run.
+func (s *Service) DiskSpaceForecast() (*models.DiskSpaceForecast, error) {
+	s.mu.RLock()
+	running := s.isRunning
+	destination := s.destination
+	remaining := s.remainingBytesLocked()
+	startedAt := s.startedAt
+	s.mu.RUnlock()
+
+	if !running || destination == "" {
+		return nil, nil
+	}
+
+	space, err := s.CheckDiskSpace(destination)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildDiskSpaceForecast(remaining, space.FreeBytes, space.RequiredFreeBytes, atomic.LoadInt64(&s.runCopiedBytes), time.Since(startedAt)), nil
+}
+
+func buildDiskSpaceForecast(remaining int64, free uint64, reserved, copiedBytes int64, elapsed time.Duration) *models.DiskSpaceForecast {
+	usable := int64(free) - reserved
+	forecast := &models.DiskSpaceForecast{
+		RemainingBytes: remaining,
+		FreeBytes:      free,
+		ReservedBytes:  reserved,
+		MarginBytes:    usable - remaining,
+	}
+	forecast.Shortfall = forecast.MarginBytes < 0
+
+	if elapsed > 0 && copiedBytes > 0 {
+		forecast.ThroughputBytesPerSec = float64(copiedBytes) / elapsed.Seconds()
+	}
+	if forecast.ThroughputBytesPerSec > 0 && usable > 0 {
+		forecast.HoursUntilFull = float64(usable) / forecast.ThroughputBytesPerSec / 3600
+	}
+
+	return forecast
+}
+
+// checkDiskSpaceForecast raises a one-shot warning event when the remaining
+// workload no longer fits on the destination.
+func (s *Service) checkDiskSpaceForecast() {
+	forecast, err := s.DiskSpaceForecast()
+	if err != nil || forecast == nil {
+		return
+	}
+
+	s.mu.Lock()
+	alreadyWarned := s.diskShortfallWarned
+	s.diskShortfallWarned = forecast.Shortfall
+	project := s.project
+	destination := s.destination
+	s.mu.Unlock()
+
+	if !forecast.Shortfall || alreadyWarned {
+		return
+	}
+
+	log.Warn().
+		Str("destination", destination).
+		Int64("remaining_bytes", forecast.RemainingBytes).
+		Uint64("free_bytes", forecast.FreeBytes).
+		Int64("margin_bytes", forecast.MarginBytes).
+		Msg("Destination is projected to run out of space")
+
+	s.emitEvent(models.SyncEvent{
+		Type:        models.SyncEventDiskSpaceWarning,
+		Project:     project,
+		Destination: destination,
+		Message:     s.messages.Sprintf(i18n.EventDiskSpaceShort, destination, float64(-forecast.MarginBytes)/1024/1024/1024),
+	})
+}
@@ -0,0 +1,138 @@
+package sync
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// ErrFileTooLargeForDestination is returned when a file exceeds the maximum
+// file size of the destination filesystem (4 GB on FAT32).
+var ErrFileTooLargeForDestination = errors.New("file is too large for destination filesystem")
+
+// DefaultMTimeTolerance is how far a destination mtime may lag the source
+// before the file is copied again. Node clocks skewed by more than this make
+// incremental copying unreliable.
+const DefaultMTimeTolerance = 2 * time.Second
+
+const fat32MaxFileSize = 4*1024*1024*1024 - 1
+
+// filesystemProfile describes the limitations of a destination filesystem.
+type filesystemProfile struct {
+	Name string
+	// MaxFileSize is the largest file the filesystem can hold; 0 means no limit.
+	MaxFileSize int64
+	// MTimeTolerance absorbs timestamp precision loss after Chtimes.
+	MTimeTolerance time.Duration
+	// WindowsNames means the filesystem rejects <>:"\|?* and control characters.
+	WindowsNames bool
+}
+
+func profileForFilesystem(fsType string) filesystemProfile {
+	switch strings.ToLower(fsType) {
+	case "vfat", "msdos", "fat", "fat32":
+		// FAT stores modification times with 2-second granularity, rounding up.
+		return filesystemProfile{Name: "FAT32", MaxFileSize: fat32MaxFileSize, MTimeTolerance: 2 * DefaultMTimeTolerance, WindowsNames: true}
+	case "exfat":
+		return filesystemProfile{Name: "exFAT", MTimeTolerance: DefaultMTimeTolerance, WindowsNames: true}
+	case "ntfs", "ntfs3", "fuseblk":
+		return filesystemProfile{Name: "NTFS", MTimeTolerance: DefaultMTimeTolerance, WindowsNames: true}
+	default:
+		return filesystemProfile{Name: fsType, MTimeTolerance: DefaultMTimeTolerance}
+	}
+}
+
+// destinationRelPath maps a source-relative path to a name the destination
+// filesystem accepts. Paths are returned unchanged on POSIX filesystems.
+func (p filesystemProfile) destinationRelPath(relPath string) string {
+	if !p.WindowsNames {
+		return relPath
+	}
+
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	for i, part := range parts {
+		parts[i] = sanitizeWindowsName(part)
+	}
+
+	return filepath.FromSlash(strings.Join(parts, "/"))
+}
+
+func sanitizeWindowsName(name string) string {
+	if name == "" || name == "." || name == ".." {
+		return name
+	}
+
+	sanitized := strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`<>:"\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+
+	// Windows silently drops trailing dots and spaces.
+	trimmed := strings.TrimRight(sanitized, ". ")
+	if trimmed == "" {
+		return "_"
+	}
+	if len(trimmed) != len(sanitized) {
+		trimmed += "_"
+	}
+
+	return trimmed
+}
+
+func (s *Service) destinationProfile() filesystemProfile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return profileForFilesystem(s.destinationMount.FSType)
+}
+
+// warnDestinationFilesystem tells the operator up front about filesystem
+// limitations that will affect this run.
+func (s *Service) warnDestinationFilesystem() {
+	s.mu.RLock()
+	fsType := s.destinationMount.FSType
+	project := s.project
+	destination := s.destination
+	s.mu.RUnlock()
+
+	profile := profileForFilesystem(fsType)
+	if profile.MaxFileSize <= 0 {
+		return
+	}
+
+	log.Warn().
+		Str("destination", destination).
+		Str("fstype", fsType).
+		Int64("max_file_size", profile.MaxFileSize).
+		Msg("Destination filesystem limits file size")
+
+	s.emitEvent(models.SyncEvent{
+		Type:        models.SyncEventDestinationIncompatible,
+		Project:     project,
+		Destination: destination,
+		Message:     s.messages.Sprintf(i18n.EventFilesystemLimit, destination, profile.Name),
+	})
+}
+
+// reportIncompatibleFile raises an event for a file the destination cannot hold.
+func (s *Service) reportIncompatibleFile(filePath string, err error) {
+	s.mu.RLock()
+	project := s.project
+	destination := s.destination
+	s.mu.RUnlock()
+
+	s.emitEvent(models.SyncEvent{
+		Type:        models.SyncEventDestinationIncompatible,
+		Project:     project,
+		Destination: destination,
+		Message:     s.messages.Sprintf(i18n.EventFileIncompatible, filepath.Base(filePath), destination),
+		Reason:      err.Error(),
+	})
+}
@@ -0,0 +1,87 @@
+package sync
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/state"
+	"github.com/zangezia/UCXSync/internal/storage"
+)
+
+// SetLinkUnchanged controls whether a full re-sync into a new snapshot folder
+// hardlinks files that are unchanged since the previous snapshot instead of
+// copying them again (rsync --link-dest style).
+func (s *Service) SetLinkUnchanged(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.linkUnchanged = enabled
+}
+
+// loadLinkSources reads the copied-file ledger before a full re-sync resets
+// it. Entries already pointing at destDir are skipped: they are the files
+// about to be overwritten, not a previous snapshot.
+func loadLinkSources(store *state.Store, project, destDir string) map[string]state.CopiedFile {
+	files, err := store.ListCopiedFiles(project)
+	if err != nil {
+		log.Warn().Err(err).Str("project", project).Msg("Failed to load previous snapshot ledger, copying all files")
+		return nil
+	}
+
+	sources := make(map[string]state.CopiedFile, len(files))
+	for _, file := range files {
+		if filepath.Clean(file.DestDir) == filepath.Clean(destDir) {
+			continue
+		}
+		sources[file.RelativePath] = file
+	}
+
+	if len(sources) > 0 {
+		log.Info().Str("project", project).Int("files", len(sources)).Msg("Previous snapshot found, unchanged files will be hardlinked")
+	}
+	return sources
+}
+
+// linkFromPreviousSnapshot hardlinks destPath to the previous snapshot's copy
+// of relPath when the ledger shows the source is unchanged since that copy.
+// It reports false whenever the file has to be copied instead.
+func (s *Service) linkFromPreviousSnapshot(relPath, destPath string, srcInfo os.FileInfo) bool {
+	s.mu.RLock()
+	sources := s.linkSources
+	s.mu.RUnlock()
+
+	if len(sources) == 0 {
+		return false
+	}
+
+	entry, ok := sources[filepath.ToSlash(relPath)]
+	if !ok || entry.Size != srcInfo.Size() || !entry.ModTime.Equal(srcInfo.ModTime().UTC()) {
+		return false
+	}
+
+	target := s.destinationStorage()
+	linker, ok := target.(storage.Linker)
+	if !ok {
+		return false
+	}
+
+	previous := filepath.Join(entry.DestDir, s.destinationProfile().destinationRelPath(relPath))
+	prevInfo, err := target.Stat(previous)
+	if err != nil || prevInfo.Size() != srcInfo.Size() {
+		return false
+	}
+
+	if err := target.Remove(destPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false
+	}
+	if err := linker.Link(previous, destPath); err != nil {
+		// FAT/exFAT and cross-device snapshots cannot be linked.
+		log.Debug().Err(err).Str("file", relPath).Msg("Hardlink failed, copying instead")
+		return false
+	}
+
+	return true
+}
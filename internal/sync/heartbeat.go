@@ -0,0 +1,123 @@
+package sync
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// HeartbeatName is the heartbeat file kept in each run folder.
+const HeartbeatName = "ucxsync-heartbeat.json"
+
+// HeartbeatOptions rewrites HeartbeatName in the run folder every Interval
+// while a job runs, and once more when it stops.
+type HeartbeatOptions struct {
+	Enabled  bool
+	Interval time.Duration
+}
+
+// SetHeartbeat configures the destination heartbeat. It applies from the
+// next job.
+func (s *Service) SetHeartbeat(opts HeartbeatOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.heartbeat = opts
+}
+
+// newJobID names a job by its project and start time, with a random tail so
+// two kits starting the same project at once still differ.
+func newJobID(project string, startedAt time.Time) string {
+	tail := make([]byte, 3)
+	rand.Read(tail)
+	return fmt.Sprintf("%s-%s-%s", project, startedAt.UTC().Format("20060102T150405Z"), hex.EncodeToString(tail))
+}
+
+func (s *Service) heartbeatLoop(ctx context.Context) {
+	defer s.wg.Done()
+
+	s.mu.RLock()
+	interval := s.heartbeat.Interval
+	s.mu.RUnlock()
+
+	s.writeHeartbeat(false)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.writeHeartbeat(true)
+			return
+		case <-ticker.C:
+			s.writeHeartbeat(false)
+		}
+	}
+}
+
+// writeHeartbeat replaces the heartbeat of the current run folder. The file
+// is written beside and renamed over the old one, so a reader never sees it
+// half written.
+func (s *Service) writeHeartbeat(stopped bool) {
+	heartbeat := s.heartbeatSnapshot(stopped)
+	dir := s.currentDestDir("")
+	if dir == "" {
+		return
+	}
+
+	data, err := json.MarshalIndent(heartbeat, "", "  ")
+	if err != nil {
+		return
+	}
+	path := filepath.Join(dir, HeartbeatName)
+	tmp := path + tempFileSuffix
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		log.Warn().Err(err).Str("dir", dir).Msg("Failed to write destination heartbeat")
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		log.Warn().Err(err).Str("dir", dir).Msg("Failed to write destination heartbeat")
+	}
+}
+
+func (s *Service) heartbeatSnapshot(stopped bool) models.DestinationHeartbeat {
+	host, _ := os.Hostname()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	heartbeat := models.DestinationHeartbeat{
+		JobID:                 s.jobID,
+		Host:                  host,
+		Project:               s.project,
+		State:                 "running",
+		StartedAt:             s.startedAt.UTC(),
+		UpdatedAt:             time.Now().UTC(),
+		CompletedCaptures:     int(atomic.LoadInt32(&s.completedCaptures)),
+		CompletedTestCaptures: int(atomic.LoadInt32(&s.completedTestCaptures)),
+		LastCaptureNumber:     s.lastCaptureNumber,
+		CopiedFiles:           int(atomic.LoadInt32(&s.runCopiedFiles)),
+		CopiedBytes:           atomic.LoadInt64(&s.runCopiedBytes),
+		FailedFiles:           int(atomic.LoadInt32(&s.runFailedFiles)),
+		QuarantinedFiles:      s.quarantinedCountLocked(),
+	}
+	switch {
+	case stopped:
+		heartbeat.State = "stopped"
+	case s.paused:
+		heartbeat.State = "paused"
+		heartbeat.PauseReason = s.pauseReason
+	}
+	return heartbeat
+}
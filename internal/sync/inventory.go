@@ -0,0 +1,176 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// inventoryCapture identifies a capture across sensors and shares.
+type inventoryCapture struct {
+	session string
+	number  string
+	test    bool
+}
+
+// Inventory walks project on every node/share without copying and totals
+// its files, captures and sessions. Unreadable shares are listed with their
+// error rather than failing the inventory.
+func (s *Service) Inventory(ctx context.Context, project string) (models.Inventory, error) {
+	inventory := models.Inventory{
+		Project:     project,
+		GeneratedAt: time.Now().UTC(),
+		Shares:      []models.ShareInventory{},
+		Sessions:    []models.SessionInventory{},
+	}
+
+	type shareResult struct {
+		share    models.ShareInventory
+		captures map[inventoryCapture]bool
+		sessions map[string]*models.SessionInventory
+	}
+
+	var wg sync.WaitGroup
+	results := make([]shareResult, 0, len(s.nodes)*len(s.shares))
+	var mu sync.Mutex
+	for _, node := range s.nodes {
+		for _, share := range s.sharesFor(node) {
+			wg.Add(1)
+			go func(node, share string) {
+				defer wg.Done()
+
+				source := filepath.Join(s.shareRoot(node, share), project)
+				result := shareResult{
+					share:    models.ShareInventory{Node: node, Share: share, Path: source},
+					captures: make(map[inventoryCapture]bool),
+					sessions: make(map[string]*models.SessionInventory),
+				}
+				s.inventoryShare(ctx, source, &result.share, result.captures, result.sessions)
+
+				mu.Lock()
+				results = append(results, result)
+				mu.Unlock()
+			}(node, share)
+		}
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return models.Inventory{}, err
+	}
+
+	captures := make(map[inventoryCapture]bool)
+	sessions := make(map[string]*models.SessionInventory)
+	for _, result := range results {
+		inventory.Shares = append(inventory.Shares, result.share)
+		inventory.Files += result.share.Files
+		inventory.Bytes += result.share.Bytes
+		for capture := range result.captures {
+			captures[capture] = true
+		}
+		for id, part := range result.sessions {
+			session := sessions[id]
+			if session == nil {
+				session = &models.SessionInventory{SessionID: id}
+				sessions[id] = session
+			}
+			session.Files += part.Files
+			session.Bytes += part.Bytes
+		}
+	}
+
+	for capture := range captures {
+		session := sessions[capture.session]
+		if session == nil {
+			session = &models.SessionInventory{SessionID: capture.session}
+			sessions[capture.session] = session
+		}
+		if capture.test {
+			inventory.TestCaptures++
+			session.TestCaptures++
+			continue
+		}
+		inventory.Captures++
+		session.Captures++
+		if session.FirstCapture == "" || models.CompareCaptureNumbers(capture.number, session.FirstCapture) < 0 {
+			session.FirstCapture = capture.number
+		}
+		if session.LastCapture == "" || models.CompareCaptureNumbers(capture.number, session.LastCapture) > 0 {
+			session.LastCapture = capture.number
+		}
+	}
+	for _, session := range sessions {
+		inventory.Sessions = append(inventory.Sessions, *session)
+	}
+
+	sort.Slice(inventory.Shares, func(i, j int) bool {
+		if inventory.Shares[i].Node != inventory.Shares[j].Node {
+			return inventory.Shares[i].Node < inventory.Shares[j].Node
+		}
+		return inventory.Shares[i].Share < inventory.Shares[j].Share
+	})
+	sort.Slice(inventory.Sessions, func(i, j int) bool {
+		if inventory.Sessions[i].FirstCapture != inventory.Sessions[j].FirstCapture {
+			return models.CompareCaptureNumbers(inventory.Sessions[i].FirstCapture, inventory.Sessions[j].FirstCapture) < 0
+		}
+		return inventory.Sessions[i].SessionID < inventory.Sessions[j].SessionID
+	})
+
+	return inventory, nil
+}
+
+// inventoryShare totals the files under source into share, captures and
+// sessions.
+func (s *Service) inventoryShare(ctx context.Context, source string, share *models.ShareInventory, captures map[inventoryCapture]bool, sessions map[string]*models.SessionInventory) {
+	if _, err := s.sourceStorage().Stat(source); err != nil {
+		if !os.IsNotExist(err) {
+			share.Error = err.Error()
+		}
+		return
+	}
+
+	files, err := s.scanDirectory(ctx, source, source)
+	if err != nil {
+		share.Error = err.Error()
+	}
+
+	for _, path := range files {
+		info, err := s.sourceStorage().Stat(path)
+		if err != nil {
+			continue
+		}
+		share.Files++
+		share.Bytes += info.Size()
+
+		capture := ParseCaptureFile(path)
+		if capture == nil {
+			continue
+		}
+		if capture.Kind == models.CaptureFileRaw {
+			share.RawFiles++
+		}
+
+		captures[inventoryCapture{session: capture.SessionID, number: capture.CaptureNumber, test: capture.IsTest}] = true
+
+		session := sessions[capture.SessionID]
+		if session == nil {
+			session = &models.SessionInventory{SessionID: capture.SessionID}
+			sessions[capture.SessionID] = session
+		}
+		session.Files++
+		session.Bytes += info.Size()
+	}
+
+	for capture := range captures {
+		if capture.test {
+			share.TestCaptures++
+		} else {
+			share.Captures++
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package sync
+
+import (
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// Jobs drawing on the shared I/O budget.
+const (
+	IOJobSync   = "sync"
+	IOJobExport = "export"
+	IOJobVerify = "verify"
+)
+
+const (
+	// ioBudgetActiveWindow is how recently a job must have moved data to
+	// count towards the split; an idle job leaves its share to the others.
+	ioBudgetActiveWindow = 2 * time.Second
+	// ioBudgetChunk caps one read so a job cannot run far ahead of its pace.
+	ioBudgetChunk = 256 << 10
+)
+
+// IOBudgetOptions caps the combined read rate of the sync, export and
+// verify jobs. Jobs moving data split MaxMBps by Weights; a zero MaxMBps
+// leaves I/O unlimited.
+type IOBudgetOptions struct {
+	MaxMBps float64
+	Weights map[string]float64 // By IOJob*; missing jobs weigh 1
+}
+
+// ioBudget paces each job to its share of the budget. Every job reserves
+// time for the bytes it moved, so concurrent copies of one job share that
+// job's pace.
+type ioBudget struct {
+	mu          sync.Mutex
+	bytesPerSec float64
+	weights     map[string]float64
+	jobs        map[string]*ioBudgetJob
+	now         func() time.Time
+}
+
+type ioBudgetJob struct {
+	lastUse time.Time
+	next    time.Time // when the job's reserved bytes are paid off
+}
+
+func newIOBudget(opts IOBudgetOptions) *ioBudget {
+	weights := make(map[string]float64, len(opts.Weights))
+	for job, weight := range opts.Weights {
+		if weight > 0 {
+			weights[job] = weight
+		}
+	}
+	return &ioBudget{
+		bytesPerSec: opts.MaxMBps * 1024 * 1024,
+		weights:     weights,
+		jobs:        make(map[string]*ioBudgetJob),
+		now:         time.Now,
+	}
+}
+
+// SetIOBudget sets the shared I/O budget of the sync, export and verify jobs.
+func (s *Service) SetIOBudget(opts IOBudgetOptions) {
+	var budget *ioBudget
+	if opts.MaxMBps > 0 {
+		budget = newIOBudget(opts)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ioBudget = budget
+}
+
+func (s *Service) currentIOBudget() *ioBudget {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.ioBudget
+}
+
+func (b *ioBudget) weight(job string) float64 {
+	if weight, ok := b.weights[job]; ok {
+		return weight
+	}
+	return 1
+}
+
+// reserve charges n bytes to job and returns how long the job has to wait
+// to stay within its share.
+func (b *ioBudget) reserve(job string, n int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	j := b.jobs[job]
+	if j == nil {
+		j = &ioBudgetJob{}
+		b.jobs[job] = j
+	}
+	j.lastUse = now
+
+	rate := b.bytesPerSec * b.shareLocked(job, now)
+	start := j.next
+	if start.Before(now) {
+		start = now
+	}
+	j.next = start.Add(time.Duration(float64(n) / rate * float64(time.Second)))
+	return j.next.Sub(now)
+}
+
+// shareLocked is job's fraction of the budget among the jobs active at now.
+func (b *ioBudget) shareLocked(job string, now time.Time) float64 {
+	total := 0.0
+	for name, j := range b.jobs {
+		if name == job || now.Sub(j.lastUse) <= ioBudgetActiveWindow {
+			total += b.weight(name)
+		}
+	}
+	return b.weight(job) / total
+}
+
+// wait blocks until job may move n more bytes or ctx is done.
+func (b *ioBudget) wait(ctx context.Context, job string, n int) error {
+	delay := b.reserve(job, n)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// status reports the budget and the current split.
+func (b *ioBudget) status() *models.IOBudget {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	status := &models.IOBudget{LimitMBps: b.bytesPerSec / 1024 / 1024}
+	for name, j := range b.jobs {
+		if now.Sub(j.lastUse) > ioBudgetActiveWindow {
+			continue
+		}
+		share := b.shareLocked(name, now)
+		status.Jobs = append(status.Jobs, models.IOBudgetJob{
+			Job:       name,
+			Weight:    b.weight(name),
+			Share:     share,
+			LimitMBps: status.LimitMBps * share,
+		})
+	}
+	sort.Slice(status.Jobs, func(i, j int) bool { return status.Jobs[i].Job < status.Jobs[j].Job })
+	return status
+}
+
+// budgetReader paces reads of one job through the I/O budget.
+type budgetReader struct {
+	ctx    context.Context
+	budget *ioBudget
+	job    string
+	r      io.Reader
+}
+
+// throttled returns r paced by budget for job, or r itself without a budget,
+// so unlimited copies keep the kernel copy fast path.
+func throttled(ctx context.Context, budget *ioBudget, job string, r io.Reader) io.Reader {
+	if budget == nil {
+		return r
+	}
+	return &budgetReader{ctx: ctx, budget: budget, job: job, r: r}
+}
+
+func (r *budgetReader) Read(p []byte) (int, error) {
+	if len(p) > ioBudgetChunk {
+		p = p[:ioBudgetChunk]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if waitErr := r.budget.wait(r.ctx, r.job, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,125 @@
+package sync
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// Events of the per-job log.
+const (
+	jobEventStarted          = "started"
+	jobEventCopied           = "copied"  // With the SHA-256 when the copy was verified
+	jobEventLinked           = "linked"  // Hardlinked from the previous snapshot
+	jobEventPatched          = "patched" // Delta copy of a changed file
+	jobEventChecksumMismatch = "checksum_mismatch"
+	jobEventSpotMismatch     = "spot_mismatch"
+	jobEventCopyFailed       = "copy_failed"
+	jobEventQuarantined      = "quarantined"
+	jobEventSourceMoved      = "source_moved" // Move mode deleted or set aside the source
+	jobEventFinished         = "finished"
+)
+
+// jobLogEntry is one line of the per-job log.
+type jobLogEntry struct {
+	Time       time.Time          `json:"time"`
+	Job        string             `json:"job"`
+	Event      string             `json:"event"`
+	Node       string             `json:"node,omitempty"`
+	Source     string             `json:"source,omitempty"`
+	Dest       string             `json:"dest,omitempty"`
+	Bytes      int64              `json:"bytes,omitempty"`
+	DurationMS int64              `json:"duration_ms,omitempty"`
+	SHA256     string             `json:"sha256,omitempty"`
+	Target     string             `json:"target,omitempty"`
+	Error      string             `json:"error,omitempty"`
+	Reason     string             `json:"reason,omitempty"` // Why the job finished
+	Totals     *models.SyncTotals `json:"totals,omitempty"`
+}
+
+// jobLogName is the file name of the log of job in its run folder.
+func jobLogName(jobID string) string {
+	return "ucxsync-job-" + jobID + ".jsonl"
+}
+
+// jobLogFile appends to the log of the running job, reopening it when the
+// run folder changes with a destination roll-over.
+type jobLogFile struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// SetJobLog controls whether each job writes its copy, verify and error
+// events to a log of its own in the run folder, next to the data.
+func (s *Service) SetJobLog(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobLogEnabled = enabled
+}
+
+// logJobEvent appends entry to the log of the running job, if it keeps one.
+func (s *Service) logJobEvent(entry jobLogEntry) {
+	s.mu.RLock()
+	enabled := s.jobLogEnabled
+	jobID := s.jobID
+	dir := s.destDir
+	s.mu.RUnlock()
+
+	if !enabled || jobID == "" || dir == "" {
+		return
+	}
+	entry.Job = jobID
+	s.jobLog.write(filepath.Join(dir, jobLogName(jobID)), entry)
+}
+
+func (j *jobLogFile) write(path string, entry jobLogEntry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Warn().Err(err).Str("event", entry.Event).Msg("Failed to encode job log entry")
+		return
+	}
+	line = append(line, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil || j.path != path {
+		if j.file != nil {
+			j.file.Close()
+			j.file = nil
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			log.Debug().Err(err).Str("path", path).Msg("Failed to open job log")
+			return
+		}
+		j.file = file
+		j.path = path
+	}
+	if _, err := j.file.Write(line); err != nil {
+		// The destination may be gone; reopen on the next entry.
+		log.Warn().Err(err).Str("path", path).Msg("Failed to write job log")
+		j.file.Close()
+		j.file = nil
+	}
+}
+
+func (j *jobLogFile) close() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file != nil {
+		j.file.Close()
+		j.file = nil
+	}
+}
@@ -0,0 +1,17 @@
+//go:build linux
+
+package sync
+
+import (
+	"os"
+	"syscall"
+)
+
+// linkCount returns how many names the file of info has, 0 when unknown.
+func linkCount(info os.FileInfo) uint64 {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0
+	}
+	return uint64(stat.Nlink)
+}
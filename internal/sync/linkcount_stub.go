@@ -0,0 +1,10 @@
+//go:build !linux
+
+package sync
+
+import "os"
+
+// linkCount is a stub for non-Linux platforms: the link count is unknown.
+func linkCount(info os.FileInfo) uint64 {
+	return 0
+}
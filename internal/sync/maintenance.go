@@ -0,0 +1,155 @@
+package sync
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/state"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// SetNodeMaintenance puts node into or out of maintenance. While a node is
+// in maintenance its shares are not reported as unavailable, it is skipped by
+// the sync loop, and the RAW sensors it is known to deliver are not required
+// for a capture to count as complete.
+func (s *Service) SetNodeMaintenance(node string, enabled bool, reason string) error {
+	node = normalizeNodeName(node)
+	if !s.isKnownNode(node) {
+		return fmt.Errorf("unknown node %q", node)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maintenance == nil {
+		s.maintenance = make(map[string]models.NodeMaintenance)
+	}
+
+	if !enabled {
+		if s.stateStore != nil {
+			if err := s.stateStore.ClearNodeMaintenance(node); err != nil {
+				return err
+			}
+		}
+		delete(s.maintenance, node)
+		log.Info().Str("node", node).Msg("Node returned from maintenance")
+		return nil
+	}
+
+	entry, exists := s.maintenance[node]
+	if !exists {
+		entry = models.NodeMaintenance{Node: node, Since: time.Now().UTC()}
+	}
+	entry.Reason = reason
+
+	if s.stateStore != nil {
+		if err := s.stateStore.SetNodeMaintenance(node, reason, entry.Since); err != nil {
+			return err
+		}
+	}
+	s.maintenance[node] = entry
+	log.Warn().Str("node", node).Str("reason", reason).Msg("Node placed in maintenance")
+	return nil
+}
+
+// NodesInMaintenance returns the nodes currently in maintenance, sorted by name.
+func (s *Service) NodesInMaintenance() []models.NodeMaintenance {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.nodesInMaintenanceLocked()
+}
+
+func (s *Service) nodesInMaintenanceLocked() []models.NodeMaintenance {
+	if len(s.maintenance) == 0 {
+		return nil
+	}
+
+	nodes := make([]models.NodeMaintenance, 0, len(s.maintenance))
+	for _, entry := range s.maintenance {
+		nodes = append(nodes, entry)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Node < nodes[j].Node })
+	return nodes
+}
+
+func (s *Service) inMaintenance(node string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.maintenance[normalizeNodeName(node)]
+	return ok
+}
+
+func (s *Service) isKnownNode(node string) bool {
+	for _, candidate := range s.nodes {
+		if normalizeNodeName(candidate) == node {
+			return true
+		}
+	}
+	return false
+}
+
+// loadMaintenanceLocked restores maintenance flags and the learned
+// node-to-sensor mapping from store. Must be called with s.mu held.
+func (s *Service) loadMaintenanceLocked(store *state.Store) error {
+	entries, err := store.ListNodeMaintenance()
+	if err != nil {
+		return err
+	}
+	s.maintenance = make(map[string]models.NodeMaintenance, len(entries))
+	for _, entry := range entries {
+		s.maintenance[entry.Node] = entry
+	}
+
+	sensors, err := store.NodeSensors()
+	if err != nil {
+		return err
+	}
+	s.nodeSensors = make(map[string]map[string]struct{}, len(sensors))
+	for node, codes := range sensors {
+		for _, code := range codes {
+			s.learnNodeSensorLocked(node, code)
+		}
+	}
+	return nil
+}
+
+func (s *Service) learnNodeSensor(node, sensor string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.learnNodeSensorLocked(node, sensor)
+}
+
+func (s *Service) learnNodeSensorLocked(node, sensor string) {
+	node = normalizeNodeName(node)
+	if node == "" || sensor == "" {
+		return
+	}
+	if s.nodeSensors == nil {
+		s.nodeSensors = make(map[string]map[string]struct{})
+	}
+	codes, ok := s.nodeSensors[node]
+	if !ok {
+		codes = make(map[string]struct{})
+		s.nodeSensors[node] = codes
+	}
+	codes[sensor] = struct{}{}
+}
+
+// requiredRawFilesLocked returns how many RAW files a capture needs, leaving
+// out sensors delivered by nodes in maintenance. Must be called with s.mu held.
+func (s *Service) requiredRawFilesLocked() int {
+	excluded := make(map[string]struct{})
+	for node := range s.maintenance {
+		for code := range s.nodeSensors[node] {
+			if _, required := s.requiredSensors[code]; required {
+				excluded[code] = struct{}{}
+			}
+		}
+	}
+	return len(s.requiredSensors) - len(excluded)
+}
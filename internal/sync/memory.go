@@ -0,0 +1,212 @@
+package sync
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/state"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+const (
+	defaultCompactInterval    = 10 * time.Minute
+	defaultCaptureMaxAge      = 6 * time.Hour
+	defaultMaxTrackedCaptures = 10000
+	defaultMaxRunCopies       = 200000
+)
+
+// MemoryGuardOptions bounds the in-memory bookkeeping of a long run. Zero
+// fields use the defaults.
+type MemoryGuardOptions struct {
+	CompactInterval    time.Duration // How often the maps are compacted
+	CaptureMaxAge      time.Duration // Incomplete captures and stale failures older than this are evicted
+	MaxTrackedCaptures int           // Incomplete captures kept in memory at most
+	MaxRunCopies       int           // Copied files remembered for the verify completion action
+}
+
+// trackedCapture remembers an incomplete capture held in captureTracker so it
+// can be aged out and, when a state store is attached, persisted on eviction.
+type trackedCapture struct {
+	project     string
+	info        models.CaptureInfo
+	node        string
+	destination string
+	firstSeen   time.Time
+}
+
+// evictedCapture is a capture removed from memory, persisted outside the lock.
+type evictedCapture struct {
+	capture  *trackedCapture
+	fileKeys []string
+}
+
+// SetMemoryGuard configures compaction of the capture tracker, failure map and
+// run copy list.
+func (s *Service) SetMemoryGuard(opts MemoryGuardOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.memoryGuard = opts
+}
+
+func (s *Service) memoryGuardOptionsLocked() MemoryGuardOptions {
+	opts := s.memoryGuard
+	if opts.CompactInterval <= 0 {
+		opts.CompactInterval = defaultCompactInterval
+	}
+	if opts.CaptureMaxAge <= 0 {
+		opts.CaptureMaxAge = defaultCaptureMaxAge
+	}
+	if opts.MaxTrackedCaptures <= 0 {
+		opts.MaxTrackedCaptures = defaultMaxTrackedCaptures
+	}
+	if opts.MaxRunCopies <= 0 {
+		opts.MaxRunCopies = defaultMaxRunCopies
+	}
+	return opts
+}
+
+// memoryGuardLoop compacts the in-memory maps of a running job so a
+// week-long run does not grow without bound.
+func (s *Service) memoryGuardLoop(ctx context.Context) {
+	defer s.wg.Done()
+
+	s.mu.RLock()
+	interval := s.memoryGuardOptionsLocked().CompactInterval
+	s.mu.RUnlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.compactTrackedState(now)
+		}
+	}
+}
+
+// compactTrackedState evicts incomplete captures older than CaptureMaxAge or
+// beyond MaxTrackedCaptures (oldest first), forgets non-quarantined copy
+// failures not retried within CaptureMaxAge and keeps only the newest
+// MaxRunCopies run copies. Evicted captures are written to the state store
+// when one is attached, so a late file still completes them there.
+func (s *Service) compactTrackedState(now time.Time) models.MemoryAccounting {
+	s.mu.Lock()
+	opts := s.memoryGuardOptionsLocked()
+	cutoff := now.Add(-opts.CaptureMaxAge)
+
+	var evicted []evictedCapture
+	evict := func(captureNumber string) {
+		capture := s.captureMeta[captureNumber]
+		if capture != nil {
+			keys := make([]string, 0, len(s.captureTracker[captureNumber]))
+			for key := range s.captureTracker[captureNumber] {
+				keys = append(keys, key)
+			}
+			evicted = append(evicted, evictedCapture{capture: capture, fileKeys: keys})
+		}
+		delete(s.captureTracker, captureNumber)
+		delete(s.captureMeta, captureNumber)
+	}
+
+	for captureNumber := range s.captureTracker {
+		capture := s.captureMeta[captureNumber]
+		if capture == nil || capture.firstSeen.Before(cutoff) {
+			evict(captureNumber)
+		}
+	}
+	if excess := len(s.captureTracker) - opts.MaxTrackedCaptures; excess > 0 {
+		byAge := make([]string, 0, len(s.captureTracker))
+		for captureNumber := range s.captureTracker {
+			byAge = append(byAge, captureNumber)
+		}
+		sort.Slice(byAge, func(i, j int) bool {
+			return s.captureMeta[byAge[i]].firstSeen.Before(s.captureMeta[byAge[j]].firstSeen)
+		})
+		for _, captureNumber := range byAge[:excess] {
+			evict(captureNumber)
+		}
+	}
+
+	staleFailures := 0
+	for path, failure := range s.failures {
+		if !failure.Quarantined && failure.LastFailedAt.Before(cutoff) {
+			delete(s.failures, path)
+			delete(s.retryQueue, path)
+			staleFailures++
+		}
+	}
+
+	droppedCopies := 0
+	if excess := len(s.runCopies) - opts.MaxRunCopies; excess > 0 {
+		kept := make([]spotCandidate, opts.MaxRunCopies)
+		copy(kept, s.runCopies[excess:])
+		s.runCopies = kept
+		s.runCopiesDropped += excess
+		droppedCopies = excess
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	s.evictedCaptures += len(evicted)
+	s.heapAllocBytes = mem.HeapAlloc
+	s.lastCompactedAt = now
+	accounting := s.memoryAccountingLocked()
+	store := s.stateStore
+	requiredRAWFiles := s.requiredRawFilesLocked()
+	s.mu.Unlock()
+
+	if store != nil {
+		for _, e := range evicted {
+			for _, key := range e.fileKeys {
+				if _, _, err := store.RecordCapture(state.CaptureObservation{
+					Project:          e.capture.project,
+					Info:             e.capture.info,
+					FileKey:          key,
+					Destination:      e.capture.destination,
+					Node:             e.capture.node,
+					RequiredRawFiles: requiredRAWFiles,
+					RequireXML:       true,
+					RequireDAT:       true,
+				}); err != nil {
+					log.Warn().Err(err).Str("capture", e.capture.info.CaptureNumber).Msg("Failed to persist evicted capture")
+					break
+				}
+			}
+		}
+	}
+
+	if len(evicted) > 0 || staleFailures > 0 || droppedCopies > 0 {
+		log.Info().
+			Int("evicted_captures", len(evicted)).
+			Int("stale_failures", staleFailures).
+			Int("dropped_run_copies", droppedCopies).
+			Int("tracked_captures", accounting.TrackedCaptures).
+			Uint64("heap_alloc_bytes", accounting.HeapAllocBytes).
+			Msg("Compacted in-memory sync state")
+	}
+
+	return accounting
+}
+
+func (s *Service) memoryAccountingLocked() models.MemoryAccounting {
+	accounting := models.MemoryAccounting{
+		TrackedCaptures:  len(s.captureTracker),
+		CopyFailures:     len(s.failures),
+		RunCopies:        len(s.runCopies),
+		EvictedCaptures:  s.evictedCaptures,
+		DroppedRunCopies: s.runCopiesDropped,
+		HeapAllocBytes:   s.heapAllocBytes,
+	}
+	if !s.lastCompactedAt.IsZero() {
+		compactedAt := s.lastCompactedAt.UTC()
+		accounting.LastCompactedAt = &compactedAt
+	}
+	return accounting
+}
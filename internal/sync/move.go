@@ -0,0 +1,270 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// What move mode does with a source file once its capture is safe on the
+// destination.
+const (
+	MoveModeDelete = "delete" // Remove the file from the node share
+	MoveModeRename = "rename" // Move it into Folder on the same share
+)
+
+// moveAuditName is the audit log move mode appends to in the run folder.
+const moveAuditName = "ucxsync-moves.jsonl"
+
+// moveQueueSize is how many completed captures may wait for the move worker.
+const moveQueueSize = 256
+
+// MoveOptions frees node disk during long flights: once a capture is
+// complete, each of its source files is read back against its copy and then
+// deleted or set aside on the share. Only files this run copied are moved;
+// captures found complete from earlier runs keep their sources.
+type MoveOptions struct {
+	Enabled bool
+	Mode    string // MoveModeDelete or MoveModeRename
+	Folder  string // Hidden folder on the share root that rename moves into
+}
+
+// moveFile is a copied source file waiting for its capture to complete.
+type moveFile struct {
+	source     string // Path on the node share
+	sourceRoot string // Project folder of the share the file was found in
+	dest       string
+	size       int64
+}
+
+// moveJob is a completed capture whose source files can go.
+type moveJob struct {
+	project string
+	capture string
+	dir     string // Run folder the audit log is written to
+	opts    MoveOptions
+	files   []moveFile
+}
+
+// moveAuditEntry is one line of the audit log.
+type moveAuditEntry struct {
+	Time        time.Time `json:"time"`
+	Project     string    `json:"project"`
+	Capture     string    `json:"capture"`
+	Source      string    `json:"source"`
+	Destination string    `json:"destination"`
+	Action      string    `json:"action"` // deleted, renamed or kept
+	Target      string    `json:"target,omitempty"`
+	Bytes       int64     `json:"bytes"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// SetMove configures move mode. It takes effect with the next job.
+func (s *Service) SetMove(opts MoveOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.move = opts
+}
+
+// startMoveLocked starts the move worker for a run when move mode is on.
+// Files on remote sources or destinations are never moved: they cannot be
+// read back side by side. Caller must hold s.mu.
+func (s *Service) startMoveLocked(ctx context.Context) {
+	s.moveQueue = nil
+	s.moveCandidates = make(map[string][]moveFile)
+	if !s.move.Enabled {
+		return
+	}
+	if !s.localSource() || !s.localDestination() {
+		log.Warn().Msg("Move mode needs local source and destination paths, source files are kept")
+		return
+	}
+
+	queue := make(chan moveJob, moveQueueSize)
+	s.moveQueue = queue
+	s.wg.Add(1)
+	go s.moveLoop(ctx, queue)
+}
+
+// captureMoveKey tells normal and test captures of one number apart.
+func captureMoveKey(info *models.CaptureInfo) string {
+	if info.IsTest {
+		return info.CaptureNumber + "-T"
+	}
+	return info.CaptureNumber
+}
+
+// rememberMoveCandidate notes a copied capture file for the move worker.
+// relPath is sourcePath relative to the project folder it was found in.
+func (s *Service) rememberMoveCandidate(sourcePath, relPath, destPath string, size int64) {
+	filename := filepath.Base(sourcePath)
+	info := ParseCaptureFile(filename)
+	if info == nil || info.CaptureNumber == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.moveQueue == nil {
+		return
+	}
+	sourceRoot := filepath.Clean(strings.TrimSuffix(sourcePath, relPath))
+	key := captureMoveKey(info)
+	s.moveCandidates[key] = append(s.moveCandidates[key], moveFile{
+		source:     sourcePath,
+		sourceRoot: sourceRoot,
+		dest:       destPath,
+		size:       size,
+	})
+}
+
+// queueCaptureMove hands the files of a completed capture to the move
+// worker. When the worker is that far behind the files are kept.
+func (s *Service) queueCaptureMove(info *models.CaptureInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.moveQueue == nil {
+		return
+	}
+	key := captureMoveKey(info)
+	files := s.moveCandidates[key]
+	delete(s.moveCandidates, key)
+	if len(files) == 0 {
+		return
+	}
+
+	job := moveJob{project: s.project, capture: info.CaptureNumber, dir: s.destDir, opts: s.move, files: files}
+	select {
+	case s.moveQueue <- job:
+	default:
+		log.Warn().Str("capture", info.CaptureNumber).Msg("Move queue full, keeping the source files of the capture")
+	}
+}
+
+func (s *Service) moveLoop(ctx context.Context, queue chan moveJob) {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-queue:
+			s.moveCaptureFiles(ctx, job)
+		}
+	}
+}
+
+// moveCaptureFiles reads every file of job back against its copy and deletes
+// or sets aside the ones that match. Each file is written to the audit log,
+// kept files with the reason.
+func (s *Service) moveCaptureFiles(ctx context.Context, job moveJob) {
+	var entries []moveAuditEntry
+	moved := 0
+	for _, file := range job.files {
+		if ctx.Err() != nil {
+			break
+		}
+		entry := moveAuditEntry{
+			Time:        time.Now(),
+			Project:     job.project,
+			Capture:     job.capture,
+			Source:      file.source,
+			Destination: file.dest,
+			Action:      "kept",
+			Bytes:       file.size,
+		}
+		target, err := s.moveSourceFile(ctx, job.opts, file)
+		if err != nil {
+			entry.Error = err.Error()
+			log.Warn().Err(err).Str("source", file.source).Msg("Keeping source file of a completed capture")
+		} else {
+			entry.Action, entry.Target = "deleted", target
+			if target != "" {
+				entry.Action = "renamed"
+			}
+			moved++
+			s.logJobEvent(jobLogEntry{Event: jobEventSourceMoved, Source: file.source, Dest: file.dest, Bytes: file.size, Target: target})
+			atomic.AddInt32(&s.runMovedFiles, 1)
+			atomic.AddInt64(&s.runMovedBytes, file.size)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := appendMoveAudit(filepath.Join(job.dir, moveAuditName), entries); err != nil {
+		log.Error().Err(err).Str("capture", job.capture).Msg("Failed to write the move audit log")
+	}
+	log.Info().
+		Str("capture", job.capture).
+		Int("moved", moved).
+		Int("kept", len(entries)-moved).
+		Msg("Moved source files of a completed capture")
+}
+
+// moveSourceFile verifies file and deletes or renames its source. It returns
+// where the file was renamed to, or "" when it was deleted.
+func (s *Service) moveSourceFile(ctx context.Context, opts MoveOptions, file moveFile) (string, error) {
+	info, err := os.Stat(file.source)
+	if err != nil {
+		return "", err
+	}
+	if info.Size() != file.size {
+		return "", fmt.Errorf("source changed since it was copied")
+	}
+	if _, err := s.verifyCopiedChecksum(ctx, file.source, file.dest); err != nil {
+		return "", err
+	}
+
+	if opts.Mode != MoveModeRename {
+		return "", os.Remove(file.source)
+	}
+	rel, err := filepath.Rel(file.sourceRoot, file.source)
+	if err != nil {
+		return "", err
+	}
+	// <share>/<project>/<rel> goes to <share>/<folder>/<project>/<rel>, out of
+	// the project folder the scans read.
+	target := filepath.Join(filepath.Dir(file.sourceRoot), opts.Folder, filepath.Base(file.sourceRoot), rel)
+	if _, err := os.Lstat(target); err == nil {
+		return "", fmt.Errorf("%s already exists", target)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(file.source, target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// appendMoveAudit appends entries as JSON lines to path.
+func appendMoveAudit(path string, entries []moveAuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
@@ -0,0 +1,239 @@
+package sync
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sync/atomic"
+
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// FileNamePatterns are the regular expressions capture files are recognised
+// by, so another camera firmware revision or an Eagle/Osprey variant can be
+// tracked without recompiling. Named groups give the parts of a name a
+// meaning:
+//
+//	capture - capture number (required)
+//	test    - non-empty for a test capture
+//	project - project name
+//	session - session ID
+//	sensor  - sensor code (required in Raw)
+//	level   - processing level of a RAW file; VerifiedLevel marks it verified
+//
+// A Raw pattern without a level group counts every RAW file as verified.
+type FileNamePatterns struct {
+	Raw           string
+	Metadata      string
+	Quality       string
+	VerifiedLevel string
+}
+
+// Default naming of the UCX firmware.
+const (
+	// RAW capture file name format (from WU01-WU13 nodes):
+	// Lvl0X or Lvl00 - file type (0X=unverified, 00=verified)
+	// 00001 - capture number
+	// T (optional) - test capture marker
+	// Arh2k_mezen_200725 - project name
+	// 06-00 - sensor code (00-00, 00-01, 00-02, 01-00, etc.)
+	// BD11EBB0_BE00_4BE7_BC66_9DED8D740C2E - unique session ID
+	// .raw - file extension
+	DefaultRawPattern = `^(?P<level>Lvl\d+X?)-(?P<capture>\d+)(?:-(?P<test>T))?-(?P<project>.+)-(?P<sensor>\d+-\d+)-(?P<session>[A-F0-9_]+)\.raw$`
+
+	// XML metadata file name format (from CU node):
+	// EAD - prefix for metadata
+	// 00001 - capture number
+	// T (optional) - test capture marker
+	// Arh2k_mezen_200725 - project name
+	// BD11EBB0_BE00_4BE7_BC66_9DED8D740C2E - unique session ID
+	// .xml - file extension
+	// Note: XML file may be missing for test captures
+	DefaultMetadataPattern = `^EAD-(?P<capture>\d+)(?:-(?P<test>T))?-(?P<project>.+)-(?P<session>[A-F0-9_]+)\.xml$`
+
+	// RawQv quality file (optional supplemental file per capture)
+	DefaultQualityPattern = `^RawQv-(?P<capture>\d+)(?:-(?P<test>T))?-(?P<project>.+)-(?P<session>[A-F0-9_]+)\.dat$`
+
+	DefaultVerifiedLevel = "Lvl00"
+)
+
+// DefaultFileNamePatterns returns the naming of the UCX firmware.
+func DefaultFileNamePatterns() FileNamePatterns {
+	return FileNamePatterns{
+		Raw:           DefaultRawPattern,
+		Metadata:      DefaultMetadataPattern,
+		Quality:       DefaultQualityPattern,
+		VerifiedLevel: DefaultVerifiedLevel,
+	}
+}
+
+// fileNameParser is a compiled pattern and the indexes of its named groups.
+type fileNameParser struct {
+	re                                             *regexp.Regexp
+	capture, test, project, session, sensor, level int
+}
+
+type fileNameParsers struct {
+	raw, metadata, quality *fileNameParser
+	verifiedLevel          string
+}
+
+var fileNames atomic.Pointer[fileNameParsers]
+
+func init() {
+	parsers, err := compileFileNamePatterns(DefaultFileNamePatterns())
+	if err != nil {
+		panic(err)
+	}
+	fileNames.Store(parsers)
+}
+
+// SetFileNamePatterns replaces the patterns capture files are recognised by,
+// for every service of the process. Empty fields keep their default. Files
+// already recorded in the state database keep the capture they were parsed
+// into.
+func SetFileNamePatterns(patterns FileNamePatterns) error {
+	parsers, err := compileFileNamePatterns(patterns)
+	if err != nil {
+		return err
+	}
+	fileNames.Store(parsers)
+	return nil
+}
+
+func compileFileNamePatterns(patterns FileNamePatterns) (*fileNameParsers, error) {
+	defaults := DefaultFileNamePatterns()
+	if patterns.Raw == "" {
+		patterns.Raw = defaults.Raw
+	}
+	if patterns.Metadata == "" {
+		patterns.Metadata = defaults.Metadata
+	}
+	if patterns.Quality == "" {
+		patterns.Quality = defaults.Quality
+	}
+	if patterns.VerifiedLevel == "" {
+		patterns.VerifiedLevel = defaults.VerifiedLevel
+	}
+
+	raw, err := compileFileNamePattern("raw", patterns.Raw, "capture", "sensor")
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := compileFileNamePattern("metadata", patterns.Metadata, "capture")
+	if err != nil {
+		return nil, err
+	}
+	quality, err := compileFileNamePattern("quality", patterns.Quality, "capture")
+	if err != nil {
+		return nil, err
+	}
+	return &fileNameParsers{raw: raw, metadata: metadata, quality: quality, verifiedLevel: patterns.VerifiedLevel}, nil
+}
+
+func compileFileNamePattern(kind, pattern string, required ...string) (*fileNameParser, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s file name pattern: %w", kind, err)
+	}
+	for _, group := range required {
+		if re.SubexpIndex(group) < 0 {
+			return nil, fmt.Errorf("%s file name pattern has no (?P<%s>...) group", kind, group)
+		}
+	}
+	return &fileNameParser{
+		re:      re,
+		capture: re.SubexpIndex("capture"),
+		test:    re.SubexpIndex("test"),
+		project: re.SubexpIndex("project"),
+		session: re.SubexpIndex("session"),
+		sensor:  re.SubexpIndex("sensor"),
+		level:   re.SubexpIndex("level"),
+	}, nil
+}
+
+// parse returns the capture filename belongs to, or nil when it does not
+// match.
+func (p *fileNameParser) parse(filename string) *models.CaptureInfo {
+	matches := p.re.FindStringSubmatch(filename)
+	if matches == nil {
+		return nil
+	}
+	group := func(index int) string {
+		if index < 0 {
+			return ""
+		}
+		return matches[index]
+	}
+
+	return &models.CaptureInfo{
+		DataType:      group(p.level),
+		CaptureNumber: models.NormalizeCaptureNumber(group(p.capture)),
+		IsTest:        group(p.test) != "",
+		ProjectName:   group(p.project),
+		SensorCode:    group(p.sensor),
+		SessionID:     group(p.session),
+		IsVerified:    true,
+	}
+}
+
+func parseCaptureFileName(filename string) *models.CaptureInfo {
+	parsers := fileNames.Load()
+	info := parsers.raw.parse(filename)
+	if info == nil {
+		return nil
+	}
+	info.Kind = models.CaptureFileRaw
+
+	// Determine if verified: Lvl00 = verified, Lvl0X = unverified
+	if parsers.raw.level >= 0 {
+		info.IsVerified = info.DataType == parsers.verifiedLevel
+	} else {
+		info.DataType = "RAW"
+	}
+	return info
+}
+
+func parseMetadataFileName(filename string) *models.CaptureInfo {
+	info := fileNames.Load().metadata.parse(filename)
+	if info == nil {
+		return nil
+	}
+	info.Kind = models.CaptureFileMetadata
+	info.DataType = "EAD"
+	return info
+}
+
+func isEADMetadataFile(path string) bool {
+	info := ParseCaptureFile(path)
+	return info != nil && info.Kind == models.CaptureFileMetadata
+}
+
+func parseRawQvFileName(filename string) *models.CaptureInfo {
+	info := fileNames.Load().quality.parse(filename)
+	if info == nil {
+		return nil
+	}
+	info.Kind = models.CaptureFileQuality
+	info.DataType = "RawQv"
+	return info
+}
+
+// ParseCaptureFile returns the capture a RAW, metadata or quality file
+// belongs to under the configured naming, or nil for any other file.
+func ParseCaptureFile(path string) *models.CaptureInfo {
+	filename := filepath.Base(path)
+	if info := parseCaptureFileName(filename); info != nil {
+		return info
+	}
+	if info := parseMetadataFileName(filename); info != nil {
+		return info
+	}
+	return parseRawQvFileName(filename)
+}
+
+// IsMetadataFile reports whether path is an XML metadata file under the
+// configured naming.
+func IsMetadataFile(path string) bool {
+	return isEADMetadataFile(path)
+}
@@ -0,0 +1,91 @@
+package sync
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+)
+
+// PauseNode stops copying from one node while the others continue, for a
+// worker that is rebooted or has a disk re-seated mid-sync. Its running
+// tasks are cancelled, and files cut short are copied again on resume.
+// Unlike maintenance the node's sensors are still required for captures to
+// complete, and the pause ends with the job.
+func (s *Service) PauseNode(node string) error {
+	node = normalizeNodeName(node)
+	if !s.isKnownNode(node) {
+		return fmt.Errorf("unknown node %q", node)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isRunning {
+		return fmt.Errorf("synchronization is not running")
+	}
+	if s.pausedNodes[node] {
+		return nil
+	}
+	if s.pausedNodes == nil {
+		s.pausedNodes = make(map[string]bool)
+	}
+	s.pausedNodes[node] = true
+	for _, task := range s.activeTasks {
+		if normalizeNodeName(task.node) == node && task.cancel != nil {
+			task.cancel()
+		}
+	}
+	s.invalidateStatus()
+
+	log.Info().Str("node", node).Msg("Node paused by operator")
+	return nil
+}
+
+// ResumeNode lets a paused node be copied from again with the next scan.
+func (s *Service) ResumeNode(node string) error {
+	node = normalizeNodeName(node)
+	if !s.isKnownNode(node) {
+		return fmt.Errorf("unknown node %q", node)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.pausedNodes[node] {
+		return nil
+	}
+	delete(s.pausedNodes, node)
+	s.invalidateStatus()
+
+	log.Info().Str("node", node).Msg("Node resumed by operator")
+	return nil
+}
+
+// PausedNodes returns the nodes the operator paused, sorted by name.
+func (s *Service) PausedNodes() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.pausedNodesLocked()
+}
+
+func (s *Service) pausedNodesLocked() []string {
+	if len(s.pausedNodes) == 0 {
+		return nil
+	}
+
+	nodes := make([]string, 0, len(s.pausedNodes))
+	for node := range s.pausedNodes {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+func (s *Service) isNodePaused(node string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.pausedNodes[normalizeNodeName(node)]
+}
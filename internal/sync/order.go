@@ -0,0 +1,57 @@
+package sync
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// SetCaptureOrder controls whether each scan copies its files in capture
+// number order rather than in the order the share lists them, so the
+// destination fills chronologically and finished captures show up early.
+func (s *Service) SetCaptureOrder(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.captureOrder = enabled
+}
+
+func (s *Service) captureOrderEnabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.captureOrder
+}
+
+// captureNumberOf returns the capture number in a capture, metadata or RawQv
+// file name, or false for other files.
+func captureNumberOf(filename string) (int, bool) {
+	info := ParseCaptureFile(filename)
+	if info == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(info.CaptureNumber)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// sortByCapture orders files by capture number, oldest first. Files of one
+// capture keep their order, and files without a capture number go last in
+// the order they came.
+func sortByCapture(files []string) {
+	numbers := make(map[string]int, len(files))
+	for _, file := range files {
+		if n, ok := captureNumberOf(filepath.Base(file)); ok {
+			numbers[file] = n
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		a, aOK := numbers[files[i]]
+		b, bOK := numbers[files[j]]
+		if aOK != bOK {
+			return aOK
+		}
+		return aOK && a < b
+	})
+}
@@ -0,0 +1,129 @@
+package sync
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// PacingOptions sets a target completion time for every job: Target after
+// it starts. The projection is judged only once the job has run for Grace,
+// when its throughput means something.
+type PacingOptions struct {
+	Target time.Duration // 0 disables pacing
+	Grace  time.Duration
+}
+
+// SetPacing configures the target completion time. It applies from the
+// next status.
+func (s *Service) SetPacing(opts PacingOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pacing = opts
+}
+
+// Pacing projects when the running job finishes at its throughput so far
+// and compares that with its target. It is nil when no job runs or pacing
+// is off.
+func (s *Service) Pacing() *models.SyncPacing {
+	s.mu.RLock()
+	running := s.isRunning
+	opts := s.pacing
+	remaining := s.remainingBytesLocked()
+	startedAt := s.startedAt
+	s.mu.RUnlock()
+
+	if !running || opts.Target <= 0 {
+		return nil
+	}
+	return buildSyncPacing(remaining, atomic.LoadInt64(&s.runCopiedBytes), startedAt, startedAt.Add(opts.Target), time.Now())
+}
+
+func buildSyncPacing(remaining, copiedBytes int64, startedAt, deadline, now time.Time) *models.SyncPacing {
+	pacing := &models.SyncPacing{
+		Deadline:       deadline,
+		RemainingBytes: remaining,
+		OnTrack:        true,
+	}
+
+	if elapsed := now.Sub(startedAt); elapsed > 0 && copiedBytes > 0 {
+		pacing.ThroughputBytesPerSec = float64(copiedBytes) / elapsed.Seconds()
+	}
+	if left := deadline.Sub(now); left > 0 {
+		pacing.RequiredBytesPerSec = float64(remaining) / left.Seconds()
+	}
+
+	switch {
+	case remaining == 0:
+		finish := now
+		pacing.ProjectedFinish = &finish
+	case pacing.ThroughputBytesPerSec > 0:
+		finish := now.Add(time.Duration(float64(remaining) / pacing.ThroughputBytesPerSec * float64(time.Second)))
+		pacing.ProjectedFinish = &finish
+		pacing.OnTrack = !finish.After(deadline)
+	case !now.Before(deadline):
+		// Nothing copied yet and the time is up.
+		pacing.OnTrack = false
+	}
+
+	return pacing
+}
+
+// checkPacing raises a warning event when the projected finish slips past
+// the target, and an info event once it is back on track.
+func (s *Service) checkPacing() {
+	pacing := s.Pacing()
+	if pacing == nil {
+		return
+	}
+
+	s.mu.Lock()
+	judged := time.Since(s.startedAt) >= s.pacing.Grace
+	behind := !pacing.OnTrack && judged
+	alreadyWarned := s.pacingBehindWarned
+	if judged {
+		s.pacingBehindWarned = behind
+	}
+	project := s.project
+	destination := s.destination
+	s.mu.Unlock()
+
+	if !judged || behind == alreadyWarned {
+		return
+	}
+
+	deadline := pacing.Deadline.Local().Format("15:04")
+	if !behind {
+		log.Info().Time("deadline", pacing.Deadline).Msg("Sync back on pace to finish by its target")
+		s.emitEvent(models.SyncEvent{
+			Type:        models.SyncEventPacingOnTrack,
+			Project:     project,
+			Destination: destination,
+			Message:     s.messages.Sprintf(i18n.EventPacingOnTrack, deadline),
+		})
+		return
+	}
+
+	projected := "?"
+	if pacing.ProjectedFinish != nil {
+		projected = pacing.ProjectedFinish.Local().Format("15:04")
+	}
+	log.Warn().
+		Time("deadline", pacing.Deadline).
+		Str("projected_finish", projected).
+		Float64("throughput_bytes_per_sec", pacing.ThroughputBytesPerSec).
+		Float64("required_bytes_per_sec", pacing.RequiredBytesPerSec).
+		Msg("Sync is projected to miss its target completion time")
+
+	s.emitEvent(models.SyncEvent{
+		Type:        models.SyncEventPacingBehind,
+		Project:     project,
+		Destination: destination,
+		Message: s.messages.Sprintf(i18n.EventPacingBehind, projected, deadline,
+			pacing.ThroughputBytesPerSec/1024/1024, pacing.RequiredBytesPerSec/1024/1024),
+	})
+}
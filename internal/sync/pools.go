@@ -0,0 +1,130 @@
+package sync
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// Copy pools reported in WorkerPoolMetrics.
+const (
+	PoolCopy       = "copy"        // max_parallelism slots shared by scans and retries
+	PoolSmallFiles = "small_files" // Slots of the small-file pool
+	PoolPriority   = "priority"    // The EAD XML lane of each scan
+)
+
+// slotUsage accumulates how copies used the slots of one pool or node.
+type slotUsage struct {
+	active int64 // Copies holding a slot now
+	copies int64 // Copies finished
+	busy   int64 // Nanoseconds finished copies held their slot
+	wait   int64 // Nanoseconds copies waited for their slot
+}
+
+// useSlot records a copy of node in pool that waited for its slot. The
+// returned func ends it and must be called once the copy is done.
+func (s *Service) useSlot(pool, node string, waited time.Duration) (done func()) {
+	s.mu.Lock()
+	if s.poolUsage == nil {
+		s.poolUsage = make(map[string]*slotUsage)
+	}
+	if s.nodeUsage == nil {
+		s.nodeUsage = make(map[string]*slotUsage)
+	}
+	usages := []*slotUsage{s.poolUsage[pool], s.nodeUsage[node]}
+	if usages[0] == nil {
+		usages[0] = &slotUsage{}
+		s.poolUsage[pool] = usages[0]
+	}
+	if usages[1] == nil {
+		usages[1] = &slotUsage{}
+		s.nodeUsage[node] = usages[1]
+	}
+	s.mu.Unlock()
+
+	for _, usage := range usages {
+		atomic.AddInt64(&usage.active, 1)
+		atomic.AddInt64(&usage.wait, int64(waited))
+	}
+	started := time.Now()
+	return func() {
+		held := int64(time.Since(started))
+		for _, usage := range usages {
+			atomic.AddInt64(&usage.busy, held)
+			atomic.AddInt64(&usage.copies, 1)
+			atomic.AddInt64(&usage.active, -1)
+		}
+	}
+}
+
+// activeInLocked returns the copies holding a slot of pool. Caller must hold
+// s.mu.
+func (s *Service) activeInLocked(pool string) int {
+	if usage := s.poolUsage[pool]; usage != nil {
+		return int(atomic.LoadInt64(&usage.active))
+	}
+	return 0
+}
+
+// WorkerPoolMetrics reports the queue depths and how busy the copy slots of
+// the running job have been, so the effect of a parallelism change can be
+// measured.
+func (s *Service) WorkerPoolMetrics() models.WorkerPoolMetrics {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	metrics := models.WorkerPoolMetrics{Running: s.isRunning}
+	if !s.isRunning {
+		return metrics
+	}
+	since := s.startedAt
+	metrics.Since = &since
+	metrics.ActiveTasks = len(s.activeTasks)
+	metrics.QueuedFiles = s.queuedCopiesLocked()
+	metrics.PendingRetries = len(s.retryQueue)
+	elapsed := time.Since(s.startedAt).Seconds()
+
+	capacities := map[string]int{PoolCopy: cap(s.globalSemaphore), PoolPriority: 0}
+	if s.smallSemaphore != nil {
+		capacities[PoolSmallFiles] = cap(s.smallSemaphore)
+	}
+	for _, name := range []string{PoolCopy, PoolSmallFiles, PoolPriority} {
+		capacity, ok := capacities[name]
+		if !ok {
+			continue
+		}
+		pool := models.CopyPoolMetrics{Name: name, Capacity: capacity}
+		pool.Active, pool.Copies, pool.BusySeconds, pool.WaitSeconds = s.poolUsage[name].snapshot()
+		if capacity > 0 && elapsed > 0 {
+			pool.Utilization = pool.BusySeconds / (float64(capacity) * elapsed)
+		}
+		metrics.Pools = append(metrics.Pools, pool)
+	}
+
+	for name, usage := range s.nodeUsage {
+		node := models.NodePoolMetrics{Node: name}
+		node.Active, node.Copies, node.BusySeconds, node.WaitSeconds = usage.snapshot()
+		if elapsed > 0 {
+			node.AverageActive = node.BusySeconds / elapsed
+		}
+		if limiter := s.nodeLimiters[name]; limiter != nil {
+			node.Limit, _ = limiter.state()
+		}
+		metrics.Nodes = append(metrics.Nodes, node)
+	}
+	sort.Slice(metrics.Nodes, func(i, j int) bool { return metrics.Nodes[i].Node < metrics.Nodes[j].Node })
+	return metrics
+}
+
+// snapshot reads u; a nil u reads as unused.
+func (u *slotUsage) snapshot() (active int, copies int64, busySeconds, waitSeconds float64) {
+	if u == nil {
+		return 0, 0, 0, 0
+	}
+	return int(atomic.LoadInt64(&u.active)),
+		atomic.LoadInt64(&u.copies),
+		time.Duration(atomic.LoadInt64(&u.busy)).Seconds(),
+		time.Duration(atomic.LoadInt64(&u.wait)).Seconds()
+}
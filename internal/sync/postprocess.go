@@ -0,0 +1,353 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+const (
+	defaultPostProcessWorkers   = 2
+	defaultPostProcessQueueSize = 1000
+	defaultPostProcessTimeout   = 10 * time.Minute
+	// postProcessHistory bounds the captures whose pipeline status is kept.
+	postProcessHistory = 500
+	// postProcessOutputLimit is how much command or HTTP output a step keeps.
+	postProcessOutputLimit = 512
+)
+
+// PostProcessStep is one step of the per-capture pipeline: either a command
+// or an HTTP POST to URL. Command arguments may contain {project},
+// {capture}, {session}, {test}, {dir} (the run folder) and {destination}.
+type PostProcessStep struct {
+	Name    string
+	Command []string
+	URL     string
+	Timeout time.Duration // Zero uses 10 minutes
+}
+
+// PostProcessOptions configures the pipeline run for every completed
+// capture. Steps run in order and a failed step skips the rest.
+type PostProcessOptions struct {
+	Steps     []PostProcessStep
+	Workers   int // Captures processed concurrently
+	QueueSize int // Completed captures waiting for a worker; beyond it they fail
+}
+
+// postProcessJob is a completed capture waiting for the pipeline.
+type postProcessJob struct {
+	key         string
+	project     string
+	capture     string
+	session     string
+	test        bool
+	dir         string
+	destination string
+	steps       []PostProcessStep
+}
+
+// postProcessRequest is the body posted by an HTTP step.
+type postProcessRequest struct {
+	Project     string `json:"project"`
+	Capture     string `json:"capture"`
+	SessionID   string `json:"session_id"`
+	IsTest      bool   `json:"is_test"`
+	Dir         string `json:"dir"`
+	Destination string `json:"destination"`
+}
+
+// SetPostProcessing configures the per-capture pipeline. It takes effect
+// with the next Start; no steps disables it.
+func (s *Service) SetPostProcessing(opts PostProcessOptions) {
+	if opts.Workers <= 0 {
+		opts.Workers = defaultPostProcessWorkers
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultPostProcessQueueSize
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.postProcess = opts
+}
+
+// startPostProcessingLocked starts the worker pool for a run. Caller must
+// hold s.mu.
+func (s *Service) startPostProcessingLocked(ctx context.Context) {
+	s.postQueue = nil
+	if len(s.postProcess.Steps) == 0 {
+		return
+	}
+
+	queue := make(chan *postProcessJob, s.postProcess.QueueSize)
+	s.postQueue = queue
+	for i := 0; i < s.postProcess.Workers; i++ {
+		s.wg.Add(1)
+		go s.postProcessWorker(ctx, queue)
+	}
+}
+
+// enqueuePostProcessing queues a completed capture for the pipeline.
+func (s *Service) enqueuePostProcessing(info *models.CaptureInfo) {
+	s.mu.Lock()
+	queue := s.postQueue
+	if queue == nil {
+		s.mu.Unlock()
+		return
+	}
+	job := &postProcessJob{
+		key:         s.project + "/" + info.CaptureNumber,
+		project:     s.project,
+		capture:     info.CaptureNumber,
+		session:     info.SessionID,
+		test:        info.IsTest,
+		dir:         s.destDir,
+		destination: s.destination,
+		steps:       s.postProcess.Steps,
+	}
+	status := &models.CaptureProcessing{
+		Project:   job.project,
+		Capture:   job.capture,
+		SessionID: job.session,
+		IsTest:    job.test,
+		Status:    models.PostProcessQueued,
+		QueuedAt:  time.Now(),
+		Steps:     make([]models.PostProcessStepStatus, len(job.steps)),
+	}
+	for i, step := range job.steps {
+		status.Steps[i] = models.PostProcessStepStatus{Name: step.Name, Status: models.PostProcessQueued}
+	}
+	s.rememberPostProcessingLocked(job.key, status)
+	s.mu.Unlock()
+
+	select {
+	case queue <- job:
+	default:
+		s.finishPostProcessing(job, models.PostProcessFailed, "post-processing queue is full")
+	}
+}
+
+// rememberPostProcessingLocked stores status, forgetting the oldest capture
+// beyond postProcessHistory. Caller must hold s.mu.
+func (s *Service) rememberPostProcessingLocked(key string, status *models.CaptureProcessing) {
+	if s.postProcessing == nil {
+		s.postProcessing = make(map[string]*models.CaptureProcessing)
+	}
+	if _, exists := s.postProcessing[key]; !exists {
+		s.postProcessOrder = append(s.postProcessOrder, key)
+	}
+	s.postProcessing[key] = status
+
+	for len(s.postProcessOrder) > postProcessHistory {
+		delete(s.postProcessing, s.postProcessOrder[0])
+		s.postProcessOrder = s.postProcessOrder[1:]
+	}
+}
+
+func (s *Service) postProcessWorker(ctx context.Context, queue chan *postProcessJob) {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Captures still queued when the run stops are not processed.
+			for {
+				select {
+				case job := <-queue:
+					s.finishPostProcessing(job, models.PostProcessCancelled, "")
+				default:
+					return
+				}
+			}
+		case job := <-queue:
+			s.runPostProcessing(ctx, job)
+		}
+	}
+}
+
+// runPostProcessing runs the steps of job in order, skipping the rest after
+// a failure.
+func (s *Service) runPostProcessing(ctx context.Context, job *postProcessJob) {
+	s.updatePostProcessing(job.key, func(status *models.CaptureProcessing) {
+		status.Status = models.PostProcessRunning
+	})
+
+	for i, step := range job.steps {
+		started := time.Now()
+		s.updatePostProcessing(job.key, func(status *models.CaptureProcessing) {
+			status.Steps[i].Status = models.PostProcessRunning
+			status.Steps[i].StartedAt = &started
+		})
+
+		output, err := s.runPostProcessStep(ctx, step, job)
+
+		finished := time.Now()
+		s.updatePostProcessing(job.key, func(status *models.CaptureProcessing) {
+			status.Steps[i].FinishedAt = &finished
+			status.Steps[i].Output = output
+			status.Steps[i].Status = models.PostProcessDone
+			if err != nil {
+				status.Steps[i].Status = models.PostProcessFailed
+				status.Steps[i].Error = err.Error()
+			}
+		})
+		if err == nil {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			s.finishPostProcessing(job, models.PostProcessCancelled, "")
+			return
+		}
+		log.Warn().Err(err).Str("capture", job.capture).Str("step", step.Name).Msg("Capture post-processing step failed")
+		s.finishPostProcessing(job, models.PostProcessFailed, err.Error())
+		s.emitEvent(models.SyncEvent{
+			Type:        models.SyncEventPostProcessFailed,
+			Project:     job.project,
+			Destination: job.destination,
+			Capture:     job.capture,
+			Message:     s.messages.Sprintf(i18n.EventPostProcessFailed, step.Name, job.capture),
+			Reason:      err.Error(),
+		})
+		return
+	}
+
+	s.finishPostProcessing(job, models.PostProcessDone, "")
+}
+
+// finishPostProcessing sets the final state of job; steps that did not run
+// become skipped, or take reason as their error when the capture failed
+// before any step ran.
+func (s *Service) finishPostProcessing(job *postProcessJob, outcome, reason string) {
+	finished := time.Now()
+	s.updatePostProcessing(job.key, func(status *models.CaptureProcessing) {
+		status.Status = outcome
+		status.FinishedAt = &finished
+		for i := range status.Steps {
+			step := &status.Steps[i]
+			if step.Status != models.PostProcessQueued {
+				continue
+			}
+			step.Status = models.PostProcessSkipped
+			if outcome == models.PostProcessCancelled {
+				step.Status = models.PostProcessCancelled
+			}
+			if i == 0 && reason != "" {
+				step.Error = reason
+			}
+		}
+	})
+}
+
+func (s *Service) updatePostProcessing(key string, fn func(*models.CaptureProcessing)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if status := s.postProcessing[key]; status != nil {
+		fn(status)
+	}
+}
+
+func (s *Service) runPostProcessStep(ctx context.Context, step PostProcessStep, job *postProcessJob) (string, error) {
+	timeout := step.Timeout
+	if timeout <= 0 {
+		timeout = defaultPostProcessTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if step.URL != "" {
+		return postCaptureToURL(ctx, step.URL, job)
+	}
+	if len(step.Command) == 0 {
+		return "", fmt.Errorf("step %s has neither a command nor a URL", step.Name)
+	}
+
+	replacer := strings.NewReplacer(
+		"{project}", job.project,
+		"{capture}", job.capture,
+		"{session}", job.session,
+		"{test}", strconv.FormatBool(job.test),
+		"{dir}", job.dir,
+		"{destination}", job.destination,
+	)
+	args := make([]string, len(step.Command))
+	for i, arg := range step.Command {
+		args[i] = replacer.Replace(arg)
+	}
+
+	output, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	return tailOutput(output), err
+}
+
+func postCaptureToURL(ctx context.Context, url string, job *postProcessJob) (string, error) {
+	body, err := json.Marshal(postProcessRequest{
+		Project:     job.project,
+		Capture:     job.capture,
+		SessionID:   job.session,
+		IsTest:      job.test,
+		Dir:         job.dir,
+		Destination: job.destination,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	output, _ := io.ReadAll(io.LimitReader(resp.Body, postProcessOutputLimit))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return tailOutput(output), fmt.Errorf("%s answered %s", url, resp.Status)
+	}
+	return tailOutput(output), nil
+}
+
+func tailOutput(output []byte) string {
+	output = bytes.TrimSpace(output)
+	if len(output) > postProcessOutputLimit {
+		output = output[len(output)-postProcessOutputLimit:]
+	}
+	return string(output)
+}
+
+// PostProcessing returns the pipeline status of recent captures, newest
+// first. A non-empty capture filters to that capture number.
+func (s *Service) PostProcessing(capture string) []models.CaptureProcessing {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]models.CaptureProcessing, 0, len(s.postProcessing))
+	for _, status := range s.postProcessing {
+		if capture != "" && status.Capture != capture {
+			continue
+		}
+		snapshot := *status
+		snapshot.Steps = append([]models.PostProcessStepStatus(nil), status.Steps...)
+		result = append(result, snapshot)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].QueuedAt.After(result[j].QueuedAt) })
+	return result
+}
@@ -0,0 +1,36 @@
+package sync
+
+import (
+	"context"
+	"time"
+)
+
+// splitMetadata separates the EAD XML files from the rest, keeping their
+// order. The XML decides whether a capture is complete and is a few
+// kilobytes, so it should never wait behind multi-GB raws.
+func splitMetadata(files []string) (metadata, rest []string) {
+	for _, file := range files {
+		if isEADMetadataFile(file) {
+			metadata = append(metadata, file)
+		} else {
+			rest = append(rest, file)
+		}
+	}
+	return metadata, rest
+}
+
+// copyMetadata is the priority lane: it copies files one by one before the
+// scan dispatches anything else, without waiting for a copy slot the raws of
+// other nodes may hold. It stops early like the copy loops do.
+func (s *Service) copyMetadata(ctx context.Context, task *taskInfo, files []string, sizes map[string]int64, copyOne func(string)) {
+	for _, file := range files {
+		if ctx.Err() != nil || s.isPaused() || s.isNodePaused(task.node) || !s.nodeBreakerAllows(task.node) || !s.quotaAllows(sizes[file]) {
+			return
+		}
+		task.touch(time.Now())
+
+		done := s.useSlot(PoolPriority, task.node, 0)
+		copyOne(file)
+		done()
+	}
+}
@@ -0,0 +1,154 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+const bytesPerGB = 1 << 30
+
+// quotaBaseline is the measured size of a destination under quota. Bytes the
+// run copies afterwards are added to it instead of walking the tree again.
+type quotaBaseline struct {
+	destination string
+	usedBytes   int64
+	copiedBytes int64 // runCopiedBytes when measured
+}
+
+// SetDestinationQuotas caps the bytes stored under each destination path,
+// independent of the free space left there. Non-positive limits are ignored.
+func (s *Service) SetDestinationQuotas(quotas map[string]int64) {
+	cleaned := make(map[string]int64, len(quotas))
+	for path, limit := range quotas {
+		if limit > 0 {
+			cleaned[filepath.Clean(path)] = limit
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.quotas = cleaned
+	s.quotaBaseline = nil
+}
+
+// destinationQuota reports the quota of the job's destination, if it has one.
+// With measure, a destination not measured yet is walked first; otherwise ok
+// is false until it has been.
+func (s *Service) destinationQuota(measure bool) (models.DestinationQuota, bool) {
+	s.mu.RLock()
+	destination := s.destination
+	limit := s.quotas[filepath.Clean(destination)]
+	baseline := s.quotaBaseline
+	s.mu.RUnlock()
+
+	if destination == "" || limit <= 0 {
+		return models.DestinationQuota{}, false
+	}
+	if baseline == nil || baseline.destination != destination {
+		if !measure {
+			return models.DestinationQuota{}, false
+		}
+		copied := atomic.LoadInt64(&s.runCopiedBytes)
+		used, err := directorySize(destination)
+		if err != nil {
+			log.Warn().Err(err).Str("destination", destination).Msg("Failed to measure destination for its quota")
+			return models.DestinationQuota{}, false
+		}
+		baseline = &quotaBaseline{destination: destination, usedBytes: used, copiedBytes: copied}
+
+		s.mu.Lock()
+		s.quotaBaseline = baseline
+		s.mu.Unlock()
+	}
+
+	used := baseline.usedBytes + atomic.LoadInt64(&s.runCopiedBytes) - baseline.copiedBytes
+	return models.DestinationQuota{
+		Destination: destination,
+		LimitBytes:  limit,
+		UsedBytes:   used,
+		Reached:     used >= limit,
+	}, true
+}
+
+// checkDestinationQuota pauses the sync once the destination's quota is used
+// up. It reports whether the current iteration must be skipped.
+func (s *Service) checkDestinationQuota() bool {
+	quota, ok := s.destinationQuota(true)
+	if !ok || !quota.Reached {
+		return false
+	}
+	s.pauseForQuota(quota, 0)
+	return true
+}
+
+// quotaAllows reports whether a file of size bytes still fits the quota of
+// the destination, pausing the sync when it does not. Copies already running
+// are not counted until they finish.
+func (s *Service) quotaAllows(size int64) bool {
+	quota, ok := s.destinationQuota(true)
+	if !ok || quota.UsedBytes+size <= quota.LimitBytes {
+		return true
+	}
+	s.pauseForQuota(quota, size)
+	return false
+}
+
+func (s *Service) pauseForQuota(quota models.DestinationQuota, next int64) {
+	reason := fmt.Sprintf("destination %s quota reached: %.1f of %.1f GB used", quota.Destination,
+		float64(quota.UsedBytes)/bytesPerGB, float64(quota.LimitBytes)/bytesPerGB)
+	project, destination, ok := s.pause(reason)
+	if !ok {
+		return
+	}
+
+	log.Warn().
+		Str("destination", destination).
+		Int64("used_bytes", quota.UsedBytes).
+		Int64("limit_bytes", quota.LimitBytes).
+		Int64("next_file_bytes", next).
+		Msg("Destination quota reached, synchronization paused")
+
+	s.emitEvent(models.SyncEvent{
+		Type:        models.SyncEventQuotaReached,
+		Project:     project,
+		Destination: destination,
+		Message: s.messages.Sprintf(i18n.EventQuotaReached, destination,
+			float64(quota.UsedBytes)/bytesPerGB, float64(quota.LimitBytes)/bytesPerGB),
+		Reason: reason,
+	})
+}
+
+// directorySize sums the sizes of the regular files under root. A missing
+// root is empty.
+func directorySize(root string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		total += info.Size()
+		return nil
+	})
+	return total, err
+}
@@ -0,0 +1,192 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// ErrNoProjectRename is returned by RemapProject when no renamed folder of
+// the running project was detected under that name.
+var ErrNoProjectRename = errors.New("no renamed project folder detected under that name")
+
+// sessionIDOf returns the session GUID in a capture, metadata or RawQv file
+// name, or "" for other files.
+func sessionIDOf(filename string) string {
+	info := ParseCaptureFile(filename)
+	if info == nil {
+		return ""
+	}
+	return info.SessionID
+}
+
+// noteRunSessions remembers the session GUIDs of files a task scanned. Files
+// keep their names when operators rename the project folder, so these GUIDs
+// identify the folder under its new name.
+func (s *Service) noteRunSessions(files []string) {
+	sessions := make(map[string]struct{})
+	for _, file := range files {
+		if id := sessionIDOf(filepath.Base(file)); id != "" {
+			sessions[id] = struct{}{}
+		}
+	}
+	if len(sessions) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.runSessions == nil {
+		s.runSessions = make(map[string]struct{}, len(sessions))
+	}
+	for id := range sessions {
+		s.runSessions[id] = struct{}{}
+	}
+}
+
+// detectProjectRename looks for another project folder on the shares holding
+// files of a session the running job already copied. It runs once the
+// project folder has disappeared from a share the job was syncing, and
+// offers the first match as a rename until the operator remaps the job.
+func (s *Service) detectProjectRename() {
+	s.mu.RLock()
+	project := s.project
+	destination := s.destination
+	pending := s.projectRename != nil
+	sessions := make(map[string]struct{}, len(s.runSessions))
+	for id := range s.runSessions {
+		sessions[id] = struct{}{}
+	}
+	s.mu.RUnlock()
+
+	if pending || len(sessions) == 0 {
+		return
+	}
+
+	excluded := s.Exclusions().Projects
+	for _, node := range s.nodes {
+		for _, share := range s.sharesFor(node) {
+			root := s.shareRoot(node, share)
+			entries, err := s.sourceStorage().ReadDir(root)
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				name := entry.Name()
+				if !entry.IsDir() || name == project || !isValidProjectName(name, excluded) {
+					continue
+				}
+				sessionID := s.sharedSession(filepath.Join(root, name), sessions)
+				if sessionID == "" {
+					continue
+				}
+
+				rename := &models.ProjectRename{
+					From:       project,
+					To:         name,
+					SessionID:  sessionID,
+					Source:     fmt.Sprintf("%s/%s", node, share),
+					DetectedAt: time.Now().UTC(),
+				}
+				s.mu.Lock()
+				if !s.isRunning || s.project != project || s.projectRename != nil {
+					s.mu.Unlock()
+					return
+				}
+				s.projectRename = rename
+				s.mu.Unlock()
+				s.invalidateStatus()
+
+				log.Warn().
+					Str("project", project).
+					Str("renamed_to", name).
+					Str("session", sessionID).
+					Str("source", rename.Source).
+					Msg("Project folder appears to have been renamed on the shares")
+				s.emitEvent(models.SyncEvent{
+					Type:        models.SyncEventProjectRenamed,
+					Project:     project,
+					Destination: destination,
+					Message:     s.messages.Sprintf(i18n.EventProjectRenamed, project, name, rename.Source),
+				})
+				return
+			}
+		}
+	}
+}
+
+// sharedSession returns a session GUID of sessions found among the file
+// names of dir, or "".
+func (s *Service) sharedSession(dir string, sessions map[string]struct{}) string {
+	entries, err := s.sourceStorage().ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if id := sessionIDOf(entry.Name()); id != "" {
+			if _, ok := sessions[id]; ok {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// ProjectRename returns the rename of the running project detected on the
+// shares and not yet remapped, if any.
+func (s *Service) ProjectRename() *models.ProjectRename {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.projectRename == nil {
+		return nil
+	}
+	rename := *s.projectRename
+	return &rename
+}
+
+// RemapProject switches the running job to the renamed project folder to.
+// The project's history moves to the new name, so captures already copied
+// are skipped, and files keep going to the current run folder.
+func (s *Service) RemapProject(to string) error {
+	s.mu.RLock()
+	rename := s.projectRename
+	store := s.stateStore
+	s.mu.RUnlock()
+
+	if rename == nil || rename.To != to {
+		return ErrNoProjectRename
+	}
+	if store != nil {
+		if err := store.RenameProject(rename.From, rename.To); err != nil {
+			return fmt.Errorf("failed to move project history: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	if s.projectRename != rename || s.project != rename.From {
+		s.mu.Unlock()
+		return ErrNoProjectRename
+	}
+	s.project = rename.To
+	for _, capture := range s.captureMeta {
+		if capture.project == rename.From {
+			capture.project = rename.To
+		}
+	}
+	s.projectRename = nil
+	s.mu.Unlock()
+	s.invalidateStatus()
+
+	log.Info().Str("from", rename.From).Str("to", rename.To).Msg("Remapped running job to renamed project folder")
+	return nil
+}
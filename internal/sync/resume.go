@@ -0,0 +1,141 @@
+package sync
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/storage"
+)
+
+// ResumeOptions continues copies of large files that were cut short from
+// where they stopped instead of from the start.
+type ResumeOptions struct {
+	Enabled     bool
+	MinSize     int64 // Smaller files are copied again from the start
+	VerifyBytes int64 // Bytes before the resume offset compared with the source
+}
+
+// SetResume configures resumable copies.
+func (s *Service) SetResume(opts ResumeOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resume = opts
+}
+
+func (s *Service) resumeOptions() ResumeOptions {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.resume
+}
+
+// keepsPartialCopies reports whether an interrupted copy of a size bytes
+// file is kept to be resumed.
+func (s *Service) keepsPartialCopies(size int64) bool {
+	opts := s.resumeOptions()
+	if !opts.Enabled || size < opts.MinSize {
+		return false
+	}
+	return s.localDestination()
+}
+
+// resumablePrefix returns how many bytes of src a partial copy already holds
+// at tmpPath, 0 to copy from the start. A partial copy under the real name
+// destPath, left by versions that wrote there directly, is moved to tmpPath
+// first. It is only taken when it is the file's one name, since a hardlink
+// shares its data with a snapshot, and when it was written after the source
+// last changed, since a finished copy of an older version carries that
+// version's mtime. The bytes just before the offset are compared with the source, since
+// the data last written before a power loss is the likeliest to be missing.
+func (s *Service) resumablePrefix(src storage.File, size int64, srcModTime int64, tmpPath, destPath string) int64 {
+	if !s.keepsPartialCopies(size) {
+		return 0
+	}
+
+	partial := tmpPath
+	info, err := os.Stat(tmpPath)
+	if err != nil {
+		partial = destPath
+		info, err = os.Stat(destPath)
+		if err != nil || info.Size() >= size || info.ModTime().UnixNano() <= srcModTime || linkCount(info) != 1 {
+			return 0
+		}
+	}
+	offset := info.Size()
+	if !info.Mode().IsRegular() || offset == 0 || offset > size {
+		return 0
+	}
+	if !prefixTailMatches(src, partial, offset, s.resumeOptions().VerifyBytes) {
+		log.Debug().Str("file", partial).Int64("offset", offset).Msg("Partial copy differs from the source, copying from the start")
+		return 0
+	}
+	if partial != tmpPath {
+		if err := os.Rename(partial, tmpPath); err != nil {
+			return 0
+		}
+	}
+	return offset
+}
+
+// prefixTailMatches compares the n bytes before offset in src and the file at
+// path.
+func prefixTailMatches(src io.ReaderAt, path string, offset, n int64) bool {
+	if n > offset {
+		n = offset
+	}
+	if n <= 0 {
+		return true
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	want := make([]byte, n)
+	got := make([]byte, n)
+	if _, err := src.ReadAt(want, offset-n); err != nil {
+		return false
+	}
+	if _, err := file.ReadAt(got, offset-n); err != nil {
+		return false
+	}
+	return bytes.Equal(want, got)
+}
+
+// openResumed opens the partial copy at tmpPath to append to it and returns
+// src positioned at offset.
+func openResumed(src storage.File, tmpPath string, offset, size int64) (*os.File, io.Reader, error) {
+	dst, err := os.OpenFile(tmpPath, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := dst.Truncate(offset); err != nil {
+		dst.Close()
+		return nil, nil, err
+	}
+	if _, err := dst.Seek(offset, io.SeekStart); err != nil {
+		dst.Close()
+		return nil, nil, err
+	}
+
+	// Seeking keeps the kernel copy fast path for plain files.
+	if seeker, ok := src.(io.Seeker); ok {
+		if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+			dst.Close()
+			return nil, nil, err
+		}
+		return dst, src, nil
+	}
+	return dst, io.NewSectionReader(src, offset, size-offset), nil
+}
+
+func (s *Service) recordResumedCopy(offset int64) {
+	atomic.AddInt32(&s.runResumedFiles, 1)
+	atomic.AddInt64(&s.runResumedBytes, offset)
+}
@@ -0,0 +1,264 @@
+package sync
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+const (
+	defaultRetryInitialBackoff = 5 * time.Second
+	defaultRetryMaxBackoff     = 5 * time.Minute
+
+	// maxStatusRetries caps the retries listed in the status.
+	maxStatusRetries = 20
+)
+
+// RetryOptions spaces the retries of a failed file: InitialBackoff after the
+// first failure, doubling after each further one up to MaxBackoff. The file
+// is quarantined after the configured number of copy attempts.
+type RetryOptions struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// queuedRetry is a failed file waiting for its next attempt, with what
+// copyFile needs to copy it outside a scan. The destination is the run
+// folder current when the attempt starts, which a roll-over may have moved.
+type queuedRetry struct {
+	path   string
+	node   string
+	share  string
+	source string // Source root of the share
+	due    time.Time
+	active bool // Being copied
+}
+
+// SetRetryBackoff configures the backoff between copy attempts of a failed
+// file. Zero values restore the defaults.
+func (s *Service) SetRetryBackoff(opts RetryOptions) {
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = defaultRetryInitialBackoff
+	}
+	if opts.MaxBackoff < opts.InitialBackoff {
+		opts.MaxBackoff = opts.InitialBackoff
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.retry = opts
+}
+
+// retryBackoff is the wait before the next attempt after attempts failures.
+func retryBackoff(opts RetryOptions, attempts int) time.Duration {
+	backoff := opts.InitialBackoff
+	for i := 1; i < attempts && backoff < opts.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > opts.MaxBackoff {
+		backoff = opts.MaxBackoff
+	}
+	return backoff
+}
+
+// queueRetry schedules the next attempt of a file whose copy just failed.
+// Scans leave the file to the retry queue until then.
+func (s *Service) queueRetry(task *taskInfo, sourcePath, source string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	failure, ok := s.failures[sourcePath]
+	if !ok {
+		return
+	}
+	if s.retryQueue == nil {
+		s.retryQueue = make(map[string]*queuedRetry)
+	}
+	due := failure.LastFailedAt.Add(retryBackoff(s.retry, failure.Attempts))
+	failure.NextRetryAt = &due
+	s.retryQueue[sourcePath] = &queuedRetry{
+		path:   sourcePath,
+		node:   task.node,
+		share:  task.share,
+		source: source,
+		due:    due,
+	}
+}
+
+// retryQueued reports whether sourcePath waits in the retry queue.
+func (s *Service) retryQueued(sourcePath string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.retryQueue[sourcePath]
+	return ok
+}
+
+// dueRetries marks the queued retries due at now as active and returns them,
+// oldest first.
+func (s *Service) dueRetries(now time.Time) []queuedRetry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []queuedRetry
+	for _, retry := range s.retryQueue {
+		if !retry.active && !now.Before(retry.due) {
+			retry.active = true
+			due = append(due, *retry)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].due.Before(due[j].due) })
+	return due
+}
+
+func (s *Service) retryLoop(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.runDueRetries(ctx, now)
+		}
+	}
+}
+
+// runDueRetries copies the files whose retry is due, sharing the copy slots,
+// the node's adaptive limit and the quota with the scans.
+func (s *Service) runDueRetries(ctx context.Context, now time.Time) {
+	due := s.dueRetries(now)
+	for i, retry := range due {
+		if s.isPaused() || s.isNodePaused(retry.node) || !s.nodeBreakerAllows(retry.node) || !s.quotaAllows(s.retrySize(retry)) {
+			s.deferRetries(due[i:])
+			return
+		}
+		// A node at its limit keeps its retry for the next tick rather than
+		// holding up the retries of other nodes.
+		limiter := s.nodeLimiterFor(retry.node)
+		if limiter != nil && !limiter.tryAcquire() {
+			s.deferRetries(due[i : i+1])
+			continue
+		}
+		waitStarted := time.Now()
+		select {
+		case <-ctx.Done():
+			if limiter != nil {
+				limiter.release()
+			}
+			s.deferRetries(due[i:])
+			return
+		case s.globalSemaphore <- struct{}{}:
+		}
+		done := s.useSlot(PoolCopy, retry.node, time.Since(waitStarted))
+
+		s.wg.Add(1)
+		go func(retry queuedRetry) {
+			defer s.wg.Done()
+			defer func() { <-s.globalSemaphore }()
+			if limiter != nil {
+				defer limiter.release()
+			}
+			defer done()
+			s.copyRetry(ctx, retry)
+		}(retry)
+	}
+}
+
+// retrySize is the current size of a queued file, 0 when it cannot be read.
+func (s *Service) retrySize(retry queuedRetry) int64 {
+	info, err := s.sourceStorage().Stat(retry.path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// deferRetries returns retries to the queue without an attempt.
+func (s *Service) deferRetries(retries []queuedRetry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, retry := range retries {
+		if queued, ok := s.retryQueue[retry.path]; ok {
+			queued.active = false
+		}
+	}
+}
+
+// copyRetry makes the next attempt of a queued file. The file stays queued,
+// and so out of the scans, until the attempt is over: a new failure queues
+// it again, anything else leaves it to the scans. The attempt runs as a task
+// of its own, so pausing the job or the node, a roll-over and the stall
+// watchdog cancel it like any copy.
+func (s *Service) copyRetry(ctx context.Context, retry queuedRetry) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	task := newTaskInfo(retry.node, retry.share, cancel, time.Now())
+	task.setState(taskRetrying)
+	atomic.StoreInt32(&task.totalFiles, 1)
+	key := retryTaskKey(retry.path)
+
+	s.mu.Lock()
+	dest := s.destDir
+	s.activeTasks[key] = task
+	s.mu.Unlock()
+
+	log.Debug().Str("file", retry.path).Msg("Retrying failed copy")
+	if err := s.copyFile(ctx, task, retry.path, retry.source, dest); err != nil {
+		s.handleCopyError(ctx, task, retry.path, retry.source, err)
+	} else {
+		s.recordNodeOutcome(task.node, false)
+		s.clearCopyFailure(retry.path)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.activeTasks[key] == task {
+		delete(s.activeTasks, key)
+	}
+	if queued, ok := s.retryQueue[retry.path]; ok && queued.active {
+		delete(s.retryQueue, retry.path)
+	}
+}
+
+// retryTaskKey is the activeTasks key of the attempt at a queued file, apart
+// from the node/share key of the scan task.
+func retryTaskKey(path string) string {
+	return retryTaskPrefix + path
+}
+
+const retryTaskPrefix = "retry:"
+
+func isRetryTaskKey(key string) bool {
+	return strings.HasPrefix(key, retryTaskPrefix)
+}
+
+// retryStatusLocked lists the files waiting for a retry, the soonest due
+// first. Caller must hold s.mu.
+func (s *Service) retryStatusLocked() (int, []models.CopyFailure) {
+	var retries []models.CopyFailure
+	for path := range s.retryQueue {
+		if failure, ok := s.failures[path]; ok {
+			retries = append(retries, *failure)
+		}
+	}
+	sort.Slice(retries, func(i, j int) bool {
+		return retries[i].NextRetryAt.Before(*retries[j].NextRetryAt)
+	})
+	count := len(retries)
+	if len(retries) > maxStatusRetries {
+		retries = retries[:maxStatusRetries]
+	}
+	return count, retries
+}
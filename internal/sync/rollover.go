@@ -0,0 +1,224 @@
+package sync
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// SetRolloverPolicy configures automatic destination roll-over. When the
+// destination reaches thresholdPercent of used space, synchronization pauses;
+// with autoSelect it then continues on the next available destination,
+// otherwise it waits for the operator to pick one. Zero disables roll-over.
+func (s *Service) SetRolloverPolicy(thresholdPercent float64, autoSelect bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if thresholdPercent < 0 {
+		thresholdPercent = 0
+	}
+
+	s.rolloverThreshold = thresholdPercent
+	s.rolloverAutoSelect = autoSelect
+}
+
+// SetDestinationCandidates provides the destinations roll-over may switch to.
+func (s *Service) SetDestinationCandidates(candidates func() []models.DestinationInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.destinationCandidates = candidates
+}
+
+// currentDestDir returns the directory the sync loop copies into, falling back
+// to fallback when no run-specific directory has been set.
+func (s *Service) currentDestDir(fallback string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.destDir == "" {
+		return fallback
+	}
+	return s.destDir
+}
+
+// checkDestinationRollover pauses the sync once the destination crosses the
+// roll-over threshold and, in auto-select mode, switches to the next device.
+// It reports whether the current iteration must be skipped.
+func (s *Service) checkDestinationRollover() bool {
+	s.mu.RLock()
+	threshold := s.rolloverThreshold
+	autoSelect := s.rolloverAutoSelect
+	destination := s.destination
+	candidatesFn := s.destinationCandidates
+	diskUsage := s.diskUsage
+	s.mu.RUnlock()
+
+	if threshold <= 0 || destination == "" || diskUsage == nil {
+		return false
+	}
+
+	usage, err := diskUsage(destination)
+	if err != nil || usage.UsedPercent < threshold {
+		return false
+	}
+
+	reason := fmt.Sprintf("destination %s is %.1f%% full (roll-over threshold %.1f%%)", destination, usage.UsedPercent, threshold)
+	project, _, ok := s.pause(reason)
+	if !ok {
+		return true
+	}
+
+	log.Warn().
+		Str("destination", destination).
+		Float64("used_percent", usage.UsedPercent).
+		Float64("threshold_percent", threshold).
+		Msg("Destination reached roll-over threshold, synchronization paused")
+
+	var next string
+	if candidatesFn != nil {
+		next = selectRolloverDestination(destination, threshold, candidatesFn())
+	}
+
+	message := s.messages.Sprintf(i18n.EventDestinationFull, destination)
+	if autoSelect && next != "" {
+		message = s.messages.Sprintf(i18n.EventDestinationFullSwitching, destination, next)
+	} else if next == "" {
+		message = s.messages.Sprintf(i18n.EventDestinationFullNoSpare, destination)
+	}
+
+	s.emitEvent(models.SyncEvent{
+		Type:        models.SyncEventDestinationFull,
+		Project:     project,
+		Destination: destination,
+		Message:     message,
+		Reason:      reason,
+	})
+
+	if autoSelect && next != "" {
+		if err := s.SwitchDestination(next); err != nil {
+			log.Error().Err(err).Str("destination", next).Msg("Automatic destination roll-over failed")
+		}
+	}
+
+	return true
+}
+
+// selectRolloverDestination picks the candidate with the most free space that
+// is not the current destination and is itself below the threshold.
+func selectRolloverDestination(current string, threshold float64, candidates []models.DestinationInfo) string {
+	current = filepath.Clean(current)
+
+	var (
+		best     string
+		bestFree float64
+	)
+	for _, candidate := range candidates {
+		path := filepath.Clean(candidate.Path)
+		if path == current || strings.HasPrefix(current, path+"/") || strings.HasPrefix(path, current+"/") {
+			continue
+		}
+		if candidate.TotalGB <= 0 {
+			continue
+		}
+
+		usedPercent := (candidate.TotalGB - candidate.FreeSpaceGB) / candidate.TotalGB * 100
+		if threshold > 0 && usedPercent >= threshold {
+			continue
+		}
+
+		if best == "" || candidate.FreeSpaceGB > bestFree {
+			best = path
+			bestFree = candidate.FreeSpaceGB
+		}
+	}
+
+	return best
+}
+
+// SwitchDestination moves a running sync to another destination device and
+// clears any pause. Captures already copied stay recorded against the old one.
+func (s *Service) SwitchDestination(destination string) error {
+	destination = strings.TrimSpace(destination)
+	if destination == "" {
+		return fmt.Errorf("destination is required")
+	}
+
+	if err := ensureDestinationReady(destination); err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	running := s.isRunning
+	project := s.project
+	previous := s.destination
+	resolve := s.resolveMount
+	store := s.stateStore
+	tempDir := s.tempDir
+	layout := s.runFolderLayout
+	jobID := s.jobID
+	s.mu.RUnlock()
+
+	if !running {
+		return fmt.Errorf("synchronization is not running")
+	}
+
+	destDir := runFolderPath(layout, destination, project, jobID, time.Now())
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("failed to create destination: %w", err)
+	}
+	if err := s.checkTempDir(tempRoot(tempDir, destination), destDir); err != nil {
+		return err
+	}
+
+	mount := mountInfo{}
+	if resolve != nil {
+		if resolved, err := resolve(destination); err == nil {
+			mount = resolved
+		} else {
+			log.Warn().Err(err).Str("destination", destination).Msg("Failed to resolve destination mount")
+		}
+	}
+
+	s.mu.Lock()
+	for _, task := range s.activeTasks {
+		if task.cancel != nil {
+			task.cancel()
+		}
+	}
+	s.destination = destination
+	s.destDir = destDir
+	s.destinationMount = mount
+	s.destIndex.reset()
+	s.paused = false
+	s.pauseReason = ""
+	s.invalidateStatus()
+	s.diskShortfallWarned = false
+	s.mu.Unlock()
+
+	if store != nil {
+		if err := store.UpdateRunDestination(destination); err != nil {
+			log.Error().Err(err).Msg("Failed to persist new destination")
+		}
+	}
+
+	log.Info().
+		Str("from", previous).
+		Str("to", destination).
+		Msg("Synchronization switched to a new destination")
+
+	s.emitEvent(models.SyncEvent{
+		Type:        models.SyncEventDestinationSwitched,
+		Project:     project,
+		Destination: destination,
+		Message:     s.messages.Sprintf(i18n.EventDestinationSwitched, destination, previous),
+	})
+
+	s.warnDestinationFilesystem()
+	return nil
+}
@@ -0,0 +1,85 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Layouts of the run folders a job writes into.
+const (
+	RunFolderByDate = "date" // <destination>/<YYYY-MM-DD>/<project>, shared by the jobs of a day
+	RunFolderByJob  = "job"  // <destination>/<project>/<YYYY-MM-DD>_<job id>, one per job
+)
+
+// SetRunFolderLayout selects where each job writes its files. With
+// RunFolderByJob every Start of a multi-day campaign gets a folder of its
+// own, holding only what that job copied: files recorded as copied by an
+// earlier job are not copied again. It takes effect with the next job.
+func (s *Service) SetRunFolderLayout(layout string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.runFolderLayout = layout
+}
+
+// runFolderPath returns the run folder of job jobID of project under
+// destination.
+func runFolderPath(layout, destination, project, jobID string, now time.Time) string {
+	day := now.Format("2006-01-02")
+	if layout == RunFolderByJob && jobID != "" {
+		return filepath.Join(destination, project, day+"_"+jobID)
+	}
+	return filepath.Join(destination, day, project)
+}
+
+// ProjectRunFolders lists the run folders of project under destination in
+// either layout, oldest first. Hidden folders, such as a temp dir inside the
+// destination, are left out. Folder names are compared exactly, so a
+// project name is never taken as a pattern.
+func ProjectRunFolders(destination, project string) ([]string, error) {
+	// Like a glob, unreadable folders simply hold no runs.
+	entries, _ := os.ReadDir(destination)
+	var byDate, byJob []string
+	for _, entry := range entries {
+		byDate = append(byDate, filepath.Join(destination, entry.Name(), project))
+		if entry.Name() != project {
+			continue
+		}
+		jobs, _ := os.ReadDir(filepath.Join(destination, project))
+		for _, job := range jobs {
+			byJob = append(byJob, filepath.Join(destination, project, job.Name()))
+		}
+	}
+
+	type runFolder struct {
+		path string
+		key  string // Starts with the day of the run
+	}
+	var folders []runFolder
+	for _, path := range byDate {
+		folders = append(folders, runFolder{path: path, key: filepath.Base(filepath.Dir(path))})
+	}
+	for _, path := range byJob {
+		folders = append(folders, runFolder{path: path, key: filepath.Base(path)})
+	}
+
+	var dirs []string
+	sort.Slice(folders, func(i, j int) bool {
+		if folders[i].key != folders[j].key {
+			return folders[i].key < folders[j].key
+		}
+		return folders[i].path < folders[j].path
+	})
+	for _, folder := range folders {
+		if strings.HasPrefix(folder.key, ".") {
+			continue // A temp dir inside the destination, not a run
+		}
+		if info, err := os.Stat(folder.path); err == nil && info.IsDir() {
+			dirs = append(dirs, folder.path)
+		}
+	}
+	return dirs, nil
+}
@@ -0,0 +1,49 @@
+package sync
+
+import (
+	"sort"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+func (s *Service) recordScanMetrics(metrics models.ScanMetrics) {
+	log.Debug().
+		Str("node", metrics.Node).
+		Str("share", metrics.Share).
+		Float64("duration_seconds", metrics.DurationSeconds).
+		Int("files_examined", metrics.FilesExamined).
+		Int("files_queued", metrics.FilesQueued).
+		Int64("bytes_queued", metrics.BytesQueued).
+		Msg("Scan completed")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.scanMetrics == nil {
+		s.scanMetrics = make(map[string]models.ScanMetrics)
+	}
+	s.scanMetrics[metrics.Node+"-"+metrics.Share] = metrics
+}
+
+// ScanMetrics returns the most recent scan metrics per node/share, slowest first.
+func (s *Service) ScanMetrics() []models.ScanMetrics {
+	s.mu.RLock()
+	result := make([]models.ScanMetrics, 0, len(s.scanMetrics))
+	for _, metrics := range s.scanMetrics {
+		result = append(result, metrics)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].DurationSeconds != result[j].DurationSeconds {
+			return result[i].DurationSeconds > result[j].DurationSeconds
+		}
+		if result[i].Node != result[j].Node {
+			return result[i].Node < result[j].Node
+		}
+		return result[i].Share < result[j].Share
+	})
+
+	return result
+}
@@ -0,0 +1,107 @@
+package sync
+
+import (
+	"errors"
+	"path"
+	"sort"
+
+	"github.com/zangezia/UCXSync/internal/state"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// suspectSizeRatio flags a sensor whose RAW files average less than this
+// share of the median sensor: a head writing truncated or empty frames.
+const suspectSizeRatio = 0.5
+
+// ErrNoStateStore is returned by queries that need the state database.
+var ErrNoStateStore = errors.New("state store not available")
+
+// SensorStats aggregates the RAW files of project copied so far per sensor
+// code, parsed from the file names.
+func (s *Service) SensorStats(project string) (models.SensorStatsReport, error) {
+	s.mu.RLock()
+	store := s.stateStore
+	s.mu.RUnlock()
+
+	if store == nil {
+		return models.SensorStatsReport{}, ErrNoStateStore
+	}
+	files, err := store.ListCopiedFiles(project)
+	if err != nil {
+		return models.SensorStatsReport{}, err
+	}
+	return buildSensorStats(project, files), nil
+}
+
+func buildSensorStats(project string, files []state.CopiedFile) models.SensorStatsReport {
+	type sensorTotals struct {
+		stats    models.SensorStats
+		captures map[string]struct{}
+	}
+
+	sensors := make(map[string]*sensorTotals, len(requiredSensorCodes))
+	for _, code := range requiredSensorCodes {
+		sensors[code] = &sensorTotals{stats: models.SensorStats{SensorCode: code}, captures: map[string]struct{}{}}
+	}
+	allCaptures := make(map[string]struct{})
+
+	for _, file := range files {
+		info := parseCaptureFileName(path.Base(file.RelativePath))
+		if info == nil {
+			continue
+		}
+		totals := sensors[info.SensorCode]
+		if totals == nil {
+			totals = &sensorTotals{stats: models.SensorStats{SensorCode: info.SensorCode}, captures: map[string]struct{}{}}
+			sensors[info.SensorCode] = totals
+		}
+		stats := &totals.stats
+		if stats.Files == 0 || file.Size < stats.MinBytes {
+			stats.MinBytes = file.Size
+		}
+		if file.Size > stats.MaxBytes {
+			stats.MaxBytes = file.Size
+		}
+		stats.Files++
+		stats.Bytes += file.Size
+		totals.captures[info.CaptureNumber] = struct{}{}
+		allCaptures[info.CaptureNumber] = struct{}{}
+	}
+
+	report := models.SensorStatsReport{Project: project, Captures: len(allCaptures), Sensors: []models.SensorStats{}}
+	var averages []int64
+	for _, totals := range sensors {
+		stats := &totals.stats
+		stats.Captures = len(totals.captures)
+		stats.MissingCaptures = len(allCaptures) - stats.Captures
+		if stats.Files > 0 {
+			stats.AverageBytes = stats.Bytes / int64(stats.Files)
+			averages = append(averages, stats.AverageBytes)
+		}
+		report.Sensors = append(report.Sensors, *stats)
+	}
+
+	median := medianInt64(averages)
+	for i := range report.Sensors {
+		stats := &report.Sensors[i]
+		if median > 0 {
+			stats.SizeRatio = float64(stats.AverageBytes) / float64(median)
+		}
+		stats.Suspect = stats.MissingCaptures > 0 || (median > 0 && stats.SizeRatio < suspectSizeRatio)
+	}
+	sort.Slice(report.Sensors, func(i, j int) bool { return report.Sensors[i].SensorCode < report.Sensors[j].SensorCode })
+	return report
+}
+
+func medianInt64(values []int64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
@@ -0,0 +1,81 @@
+package sync
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// SetShareSubpaths configures folders inside a share where projects live,
+// e.g. {"E$": `UCX\Data`}. Share names match case-insensitively with or
+// without the trailing $; backslashes are accepted as separators.
+func (s *Service) SetShareSubpaths(subpaths map[string]string) {
+	normalized := make(map[string]string, len(subpaths))
+	for share, subpath := range subpaths {
+		subpath = NormalizeShareSubpath(subpath)
+		if subpath == "" {
+			continue
+		}
+		normalized[shareKey(share)] = subpath
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shareSubpaths = normalized
+}
+
+// SetNodeShares sets the shares of individual nodes. Nodes left out use the
+// shares passed to New.
+func (s *Service) SetNodeShares(nodeShares map[string][]string) {
+	normalized := make(map[string][]string, len(nodeShares))
+	for node, shares := range nodeShares {
+		normalized[node] = append([]string(nil), shares...)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nodeShares = normalized
+}
+
+// sharesFor returns the shares scanned on node.
+func (s *Service) sharesFor(node string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if shares, ok := s.nodeShares[node]; ok {
+		return shares
+	}
+	return s.shares
+}
+
+// NormalizeShareSubpath converts a Windows-style share subpath to a clean
+// relative slash path. It returns "" for the share root.
+func NormalizeShareSubpath(subpath string) string {
+	subpath = strings.ReplaceAll(strings.TrimSpace(subpath), `\`, "/")
+	subpath = filepath.ToSlash(filepath.Clean("/" + subpath))
+	return strings.TrimPrefix(subpath, "/")
+}
+
+func shareKey(share string) string {
+	return strings.ToUpper(strings.TrimSuffix(strings.TrimSpace(share), "$"))
+}
+
+// shareMountPoint is where the node share is mounted locally.
+func (s *Service) shareMountPoint(node, share string) string {
+	return filepath.Join(s.baseMountDir, node, strings.TrimSuffix(share, "$"))
+}
+
+// shareRoot is the directory holding project folders: the mount point plus
+// the configured subpath for the share.
+func (s *Service) shareRoot(node, share string) string {
+	s.mu.RLock()
+	subpath := s.shareSubpaths[shareKey(share)]
+	s.mu.RUnlock()
+
+	root := s.shareMountPoint(node, share)
+	if subpath == "" {
+		return root
+	}
+	return filepath.Join(root, filepath.FromSlash(subpath))
+}
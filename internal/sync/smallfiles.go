@@ -0,0 +1,79 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SmallFileOptions gives files up to MaxSize bytes (logs, quality files)
+// Workers copy slots of their own next to max_parallelism, so they do not
+// wait for the raws queued before them. The EAD XML has a lane of its own,
+// see copyMetadata.
+type SmallFileOptions struct {
+	Enabled bool
+	MaxSize int64
+	Workers int
+}
+
+// SetSmallFiles configures the small-file pool. It applies from the next
+// job.
+func (s *Service) SetSmallFiles(opts SmallFileOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.smallFiles = opts
+}
+
+// splitSmallFiles separates the files for the small-file pool from the
+// rest, keeping their order. Files of unknown size count as large.
+func (s *Service) splitSmallFiles(files []string, sizes map[string]int64) (small, large []string) {
+	s.mu.RLock()
+	opts := s.smallFiles
+	enabled := s.smallSemaphore != nil
+	s.mu.RUnlock()
+
+	if !opts.Enabled || !enabled {
+		return nil, files
+	}
+	for _, file := range files {
+		if size, ok := sizes[file]; ok && size <= opts.MaxSize {
+			small = append(small, file)
+		} else {
+			large = append(large, file)
+		}
+	}
+	return small, large
+}
+
+// copySmallFiles copies files through the small-file slots in sem. They
+// bypass the node's adaptive limit, which is there for the raws.
+func (s *Service) copySmallFiles(ctx context.Context, task *taskInfo, sem chan struct{}, files []string, sizes map[string]int64, copyOne func(string)) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for _, file := range files {
+		if s.isPaused() || s.isNodePaused(task.node) || !s.nodeBreakerAllows(task.node) || !s.quotaAllows(sizes[file]) {
+			return
+		}
+		waitStarted := time.Now()
+		stopWaiting := s.keepTaskActive(task)
+		select {
+		case <-ctx.Done():
+			stopWaiting()
+			return
+		case sem <- struct{}{}:
+		}
+		stopWaiting()
+		task.touch(time.Now())
+		done := s.useSlot(PoolSmallFiles, task.node, time.Since(waitStarted))
+
+		wg.Add(1)
+		go func(filePath string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			defer done()
+			copyOne(filePath)
+		}(file)
+	}
+}
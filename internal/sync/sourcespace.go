@@ -0,0 +1,154 @@
+package sync
+
+import (
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+const defaultSourceSpaceCheckInterval = time.Minute
+
+// SourceSpaceOptions sets when a worker share counts as low on space. Zero
+// thresholds are disabled.
+type SourceSpaceOptions struct {
+	WarningFreePercent float64       // Low below this share of the disk free
+	WarningFreeBytes   int64         // Low below this many bytes free
+	CheckInterval      time.Duration // How often CheckSourceSpace should run
+}
+
+// SetSourceSpaceOptions configures the free-space thresholds of the worker
+// shares.
+func (s *Service) SetSourceSpaceOptions(opts SourceSpaceOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sourceSpace = opts
+}
+
+// SourceSpaceCheckInterval returns how often the worker shares should be
+// measured.
+func (s *Service) SourceSpaceCheckInterval() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.sourceSpace.CheckInterval <= 0 {
+		return defaultSourceSpaceCheckInterval
+	}
+	return s.sourceSpace.CheckInterval
+}
+
+// CheckSourceSpace measures the free space of every node share outside
+// maintenance and raises source_space_low for each share that dropped below
+// the thresholds since the previous check.
+func (s *Service) CheckSourceSpace() []models.ShareSpace {
+	s.mu.RLock()
+	opts := s.sourceSpace
+	diskUsage := s.diskUsage
+	mountPointMounted := s.mountPointMounted
+	previous := s.shareSpace
+	s.mu.RUnlock()
+
+	if diskUsage == nil {
+		diskUsage = disk.Usage
+	}
+
+	now := time.Now().UTC()
+	measured := make(map[string]models.ShareSpace, len(s.nodes)*len(s.shares))
+	var newlyLow []models.ShareSpace
+	for _, node := range s.nodes {
+		if s.inMaintenance(node) {
+			continue
+		}
+		for _, share := range s.sharesFor(node) {
+			space := models.ShareSpace{
+				Node:      node,
+				Share:     share,
+				Path:      s.shareMountPoint(node, share),
+				CheckedAt: now,
+			}
+			key := node + "-" + share
+			last := previous[key]
+
+			// A share that does not answer keeps its last state, so a flaky
+			// mount does not raise the same alert again.
+			if mounted, err := mountPointMounted(space.Path); err != nil || !mounted {
+				space.Error = "not mounted"
+				space.Low = last.Low
+				measured[key] = space
+				continue
+			}
+			usage, err := diskUsage(space.Path)
+			if err != nil {
+				space.Error = err.Error()
+				space.Low = last.Low
+				measured[key] = space
+				continue
+			}
+
+			space.Available = true
+			space.FreeBytes = usage.Free
+			space.TotalBytes = usage.Total
+			if usage.Total > 0 {
+				space.FreePercent = float64(usage.Free) / float64(usage.Total) * 100
+			}
+			space.Low = (opts.WarningFreePercent > 0 && space.FreePercent < opts.WarningFreePercent) ||
+				(opts.WarningFreeBytes > 0 && int64(usage.Free) < opts.WarningFreeBytes)
+			measured[key] = space
+
+			if space.Low && !last.Low {
+				newlyLow = append(newlyLow, space)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.shareSpace = measured
+	project := s.project
+	s.mu.Unlock()
+
+	for _, space := range newlyLow {
+		log.Warn().
+			Str("node", space.Node).
+			Str("share", space.Share).
+			Uint64("free_bytes", space.FreeBytes).
+			Uint64("total_bytes", space.TotalBytes).
+			Float64("free_percent", space.FreePercent).
+			Msg("Worker share running out of space")
+
+		s.emitEvent(models.SyncEvent{
+			Type:    models.SyncEventSourceSpaceLow,
+			Project: project,
+			Message: s.messages.Sprintf(i18n.EventSourceSpaceLow, space.Share, space.Node,
+				float64(space.FreeBytes)/bytesPerGB, space.FreePercent),
+		})
+	}
+
+	return sortedShareSpace(measured)
+}
+
+// SourceShareSpace returns the last measurement of every worker share,
+// sorted by node and share.
+func (s *Service) SourceShareSpace() []models.ShareSpace {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return sortedShareSpace(s.shareSpace)
+}
+
+func sortedShareSpace(spaces map[string]models.ShareSpace) []models.ShareSpace {
+	result := make([]models.ShareSpace, 0, len(spaces))
+	for _, space := range spaces {
+		result = append(result, space)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Node != result[j].Node {
+			return result[i].Node < result[j].Node
+		}
+		return result[i].Share < result[j].Share
+	})
+	return result
+}
@@ -0,0 +1,261 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/internal/storage"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+const (
+	defaultSpotCheckInterval    = time.Minute
+	defaultSpotCheckSampleBytes = 1 << 20
+	// spotCheckCandidates bounds how many recently copied files are kept
+	// for sampling.
+	spotCheckCandidates = 512
+)
+
+// SpotCheckOptions configures the periodic re-comparison of copied files
+// against their source.
+type SpotCheckOptions struct {
+	Enabled     bool
+	Interval    time.Duration
+	SampleBytes int64 // Size of the random byte range compared
+	FullHash    bool  // Compare SHA-256 of the whole file instead of a range
+}
+
+type spotCandidate struct {
+	task   *taskInfo // Reports the check while it runs
+	source string
+	dest   string
+}
+
+// SetSpotCheck configures destination integrity spot-checks. They start with
+// the next sync run.
+func (s *Service) SetSpotCheck(opts SpotCheckOptions) {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultSpotCheckInterval
+	}
+	if opts.SampleBytes <= 0 {
+		opts.SampleBytes = defaultSpotCheckSampleBytes
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.spotCheck = opts
+}
+
+// rememberSpotCandidate adds a freshly copied file to the sampling pool,
+// replacing a random older entry once the pool is full.
+func (s *Service) rememberSpotCandidate(task *taskInfo, source, dest string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.spotCheck.Enabled {
+		return
+	}
+	candidate := spotCandidate{task: task, source: source, dest: dest}
+	if len(s.spotCandidates) < spotCheckCandidates {
+		s.spotCandidates = append(s.spotCandidates, candidate)
+		return
+	}
+	s.spotCandidates[rand.Intn(len(s.spotCandidates))] = candidate
+}
+
+func (s *Service) spotCheckLoop(ctx context.Context) {
+	defer s.wg.Done()
+
+	s.mu.RLock()
+	opts := s.spotCheck
+	s.mu.RUnlock()
+
+	if _, ok := s.destinationStorage().(storage.Reader); !ok {
+		log.Warn().Msg("Destination storage cannot be read back, spot-checks are counted as skipped")
+	}
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.isPaused() {
+				continue
+			}
+			s.runSpotCheck(ctx, opts, rnd)
+		}
+	}
+}
+
+// runSpotCheck compares one random recently copied file with its source.
+// A mismatch is reported and the file is copied again.
+func (s *Service) runSpotCheck(ctx context.Context, opts SpotCheckOptions, rnd *rand.Rand) {
+	s.mu.RLock()
+	if len(s.spotCandidates) == 0 {
+		s.mu.RUnlock()
+		return
+	}
+	candidate := s.spotCandidates[rnd.Intn(len(s.spotCandidates))]
+	project := s.project
+	destination := s.destination
+	s.mu.RUnlock()
+
+	done := candidate.task.verifying()
+	match, err := s.compareCopiedFile(ctx, candidate.source, candidate.dest, opts, rnd)
+	done()
+	if err != nil {
+		// The source may be offline or rotated away; not a corruption signal.
+		atomic.AddInt32(&s.spotSkipped, 1)
+		log.Debug().Err(err).Str("file", candidate.dest).Msg("Spot-check skipped")
+		return
+	}
+	atomic.AddInt32(&s.spotChecks, 1)
+	if match {
+		return
+	}
+
+	atomic.AddInt32(&s.spotMismatches, 1)
+	reason := "destination differs from source"
+	if err := s.repairCopiedFile(candidate.source, candidate.dest); err != nil {
+		reason = fmt.Sprintf("%s; re-copy failed: %v", reason, err)
+		s.destIndex.forget(candidate.dest)
+	} else {
+		reason += "; file copied again"
+	}
+	s.logJobEvent(jobLogEntry{Event: jobEventSpotMismatch, Source: candidate.source, Dest: candidate.dest, Error: reason})
+
+	s.emitEvent(models.SyncEvent{
+		Type:        models.SyncEventIntegrityMismatch,
+		Project:     project,
+		Destination: destination,
+		Message:     s.messages.Sprintf(i18n.EventIntegrityMismatch, filepath.Base(candidate.dest)),
+		Reason:      reason,
+	})
+}
+
+// compareCopiedFile reports whether dest still matches source: sizes must
+// agree, then either a random SampleBytes range or the whole-file SHA-256
+// is compared. Destinations that cannot be read back are not checked and
+// return ErrDestinationNotReadable.
+func (s *Service) compareCopiedFile(ctx context.Context, source, dest string, opts SpotCheckOptions, rnd *rand.Rand) (bool, error) {
+	reader, ok := s.destinationStorage().(storage.Reader)
+	if !ok {
+		return false, ErrDestinationNotReadable
+	}
+
+	srcFile, err := s.sourceStorage().Open(source)
+	if err != nil {
+		return false, err
+	}
+	defer srcFile.Close()
+
+	srcInfo, err := srcFile.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	dstFile, err := reader.Open(dest)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer dstFile.Close()
+
+	dstInfo, err := dstFile.Stat()
+	if err != nil {
+		return false, err
+	}
+	if srcInfo.Size() != dstInfo.Size() {
+		return false, nil
+	}
+
+	size := srcInfo.Size()
+	if opts.FullHash || size <= opts.SampleBytes {
+		srcSum, err := hashReader(ctx, srcFile)
+		if err != nil {
+			return false, err
+		}
+		dstSum, err := hashReader(ctx, dstFile)
+		if err != nil {
+			return false, err
+		}
+		return bytes.Equal(srcSum, dstSum), nil
+	}
+
+	offset := rnd.Int63n(size - opts.SampleBytes + 1)
+	srcRange := make([]byte, opts.SampleBytes)
+	dstRange := make([]byte, opts.SampleBytes)
+	if _, err := srcFile.ReadAt(srcRange, offset); err != nil {
+		return false, err
+	}
+	if _, err := dstFile.ReadAt(dstRange, offset); err != nil {
+		return false, err
+	}
+	return bytes.Equal(srcRange, dstRange), nil
+}
+
+func hashReader(ctx context.Context, r io.Reader) ([]byte, error) {
+	h := sha256.New()
+	buf := make([]byte, 1<<20)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n, err := r.Read(buf)
+		h.Write(buf[:n])
+		if err == io.EOF {
+			return h.Sum(nil), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// repairCopiedFile copies source over dest through a temporary file so a
+// failed repair never leaves a truncated destination behind.
+func (s *Service) repairCopiedFile(source, dest string) error {
+	src, err := s.sourceStorage().Open(source)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	target := s.destinationStorage()
+	tmp := dest + spotCheckTempSuffix
+	dst, err := target.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		target.Remove(tmp)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		target.Remove(tmp)
+		return err
+	}
+	if info, err := src.Stat(); err == nil {
+		target.Chtimes(tmp, info.ModTime(), info.ModTime())
+	}
+	return target.Rename(tmp, dest)
+}
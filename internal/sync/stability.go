@@ -0,0 +1,52 @@
+package sync
+
+import (
+	"io/fs"
+	"time"
+)
+
+// StabilityOptions leaves files the nodes may still be writing for a later
+// scan. A RAW file grows while the camera captures, and a half-written copy
+// would later pass the size check against a file that stopped growing.
+type StabilityOptions struct {
+	Enabled bool
+	MinAge  time.Duration // mtime age that counts as settled at once; 0 waits for an unchanged second scan
+}
+
+// fileSighting is the size and mtime of a file when a scan last saw it.
+type fileSighting struct {
+	size    int64
+	modTime time.Time
+}
+
+// SetStability configures when a file counts as completely written.
+func (s *Service) SetStability(opts StabilityOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stability = opts
+}
+
+// settled reports whether file, as info describes it at now, is done being
+// written: its mtime is MinAge old, or its size and mtime are the ones the
+// previous scan saw. Otherwise the sighting is kept for the next scan.
+func (s *Service) settled(file string, info fs.FileInfo, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	opts := s.stability
+	if !opts.Enabled {
+		return true
+	}
+	previous, seen := s.sightings[file]
+	if (opts.MinAge > 0 && now.Sub(info.ModTime()) >= opts.MinAge) ||
+		(seen && previous.size == info.Size() && previous.modTime.Equal(info.ModTime())) {
+		delete(s.sightings, file)
+		return true
+	}
+	if s.sightings == nil {
+		s.sightings = make(map[string]fileSighting)
+	}
+	s.sightings[file] = fileSighting{size: info.Size(), modTime: info.ModTime()}
+	return false
+}
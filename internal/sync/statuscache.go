@@ -0,0 +1,65 @@
+package sync
+
+import (
+	"context"
+	"time"
+
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// statusRefreshInterval is how often a running job rebuilds the status
+// snapshot, and how old a snapshot may get before a read rebuilds it.
+const statusRefreshInterval = time.Second
+
+// cachedStatus is a status snapshot tagged with the generation it was built
+// in; invalidateStatus moves to the next generation.
+type cachedStatus struct {
+	status     models.SyncStatus
+	generation uint64
+	builtAt    time.Time
+}
+
+// StatusSnapshot returns the latest status without taking the service lock,
+// so frequent UI polls do not contend with copy bookkeeping. While a job runs
+// the snapshot is rebuilt every second in the background; otherwise a read
+// rebuilds a snapshot older than that. Start, stop, pause and resume
+// invalidate it, so they show up on the next read.
+func (s *Service) StatusSnapshot() models.SyncStatus {
+	cached := s.cachedStatus.Load()
+	if cached != nil && cached.generation == s.statusGeneration.Load() && time.Since(cached.builtAt) < statusRefreshInterval {
+		return cached.status
+	}
+	return s.refreshStatus()
+}
+
+// refreshStatus rebuilds the snapshot with GetStatus. A snapshot built while
+// the status was invalidated is returned but not kept.
+func (s *Service) refreshStatus() models.SyncStatus {
+	generation := s.statusGeneration.Load()
+	status := s.GetStatus()
+	s.cachedStatus.Store(&cachedStatus{status: status, generation: generation, builtAt: time.Now()})
+	return status
+}
+
+// invalidateStatus makes the next StatusSnapshot rebuild the status.
+func (s *Service) invalidateStatus() {
+	s.statusGeneration.Add(1)
+}
+
+// statusRefreshLoop keeps the snapshot fresh while a job runs, so API reads
+// never wait for the service lock.
+func (s *Service) statusRefreshLoop(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(statusRefreshInterval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.refreshStatus()
+		}
+	}
+}
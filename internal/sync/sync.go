@@ -2,7 +2,7 @@ package sync
 
 // File synchronization service for UCX capture files.
 //
-// Capture file naming convention:
+// Capture file naming convention (the default; see FileNamePatterns):
 //
 // RAW files (from WU01-WU13 nodes):
 //   Lvl00-00001-Arh2k_mezen_200725-06-00-BD11EBB0_BE00_4BE7_BC66_9DED8D740C2E.raw
@@ -39,7 +39,7 @@ import (
 	"os"
 	pathpkg "path"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -47,7 +47,9 @@ import (
 
 	"github.com/rs/zerolog/log"
 	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/zangezia/UCXSync/internal/i18n"
 	"github.com/zangezia/UCXSync/internal/state"
+	"github.com/zangezia/UCXSync/internal/storage"
 	"github.com/zangezia/UCXSync/pkg/models"
 )
 
@@ -55,12 +57,20 @@ import (
 type Service struct {
 	nodes               []string
 	shares              []string
-	baseMountDir        string // Base directory for mounted shares (e.g., /ucmount)
+	nodeShares          map[string][]string // Per-node overrides of shares
+	baseMountDir        string              // Base directory for mounted shares (e.g., /ucmount)
 	requiredSensors     map[string]struct{}
 	stateStore          *state.Store
 	copiedFileProcessor CopiedFileProcessor
+	eventNotifier       EventNotifier
+	source              storage.SourceProvider
+	target              storage.DestinationProvider
 	forceFullResync     bool
 	mountPointMounted   func(string) (bool, error)
+	resolveMount        func(string) (mountInfo, error)
+
+	cachedStatus     atomic.Pointer[cachedStatus] // Read by StatusSnapshot without s.mu
+	statusGeneration atomic.Uint64
 
 	mu                    sync.RWMutex
 	isRunning             bool
@@ -68,6 +78,10 @@ type Service struct {
 	destination           string
 	maxParallelism        int
 	globalSemaphore       chan struct{} // Global semaphore limiting total concurrent file operations
+	smallSemaphore        chan struct{} // Copy slots of the small-file pool, nil when it is off
+	smallFiles            SmallFileOptions
+	poolUsage             map[string]*slotUsage // Per copy pool, reset every job
+	nodeUsage             map[string]*slotUsage // Per node, reset every job
 	activeTasks           map[string]*taskInfo
 	captureTracker        map[string]map[string]bool // capture# -> fileType (raw/xml) -> completed
 	completedCaptures     int32
@@ -75,15 +89,124 @@ type Service struct {
 	lastCaptureNumber     string
 	lastTestCaptureNumber string
 	serviceLoopInterval   time.Duration
+	loopIntervalChanged   chan struct{}
+	sourceChanged         chan struct{} // A watched source reported a change
 	minFreeDiskSpace      int64
 	diskSpaceSafetyMargin int64
 	diskUsage             func(path string) (*disk.UsageStat, error)
 	syncIterationFunc     func(context.Context, string)
+	startedAt             time.Time
+	runCopiedFiles        int32
+	runLinkedFiles        int32
+	runDegradedFiles      int32
+	runLinkedBytes        int64
+	runDeltaFiles         int32
+	runDeltaSavedBytes    int64
+	runChecksumMismatches int32
+	runScansDeferred      int32
+	runResumedFiles       int32
+	runResumedBytes       int64
+	runMovedFiles         int32
+	runMovedBytes         int64
+	runFailedFiles        int32
+	runCopiedBytes        int64
+	runBenchmark          *models.DestinationBenchmark // Destination write test made before the job started
+	destinationMount      mountInfo
+	paused                bool
+	pauseReason           string
+	pausedNodes           map[string]bool // Nodes the operator paused, by normalized name
+	diskShortfallWarned   bool
+	pacing                PacingOptions
+	backpressure          BackpressureOptions
+	stability             StabilityOptions
+	sightings             map[string]fileSighting // Files left unsettled by the last scan
+	scansHeldSince        time.Time               // Zero while scans run freely
+	pacingBehindWarned    bool
+	stallTimeout          time.Duration
+	scanMetrics           map[string]models.ScanMetrics
+	destDir               string
+	rolloverThreshold     float64
+	rolloverAutoSelect    bool
+	destinationCandidates func() []models.DestinationInfo
+	linkUnchanged         bool
+	linkSources           map[string]state.CopiedFile
+	maintenance           map[string]models.NodeMaintenance
+	nodeSensors           map[string]map[string]struct{} // node -> RAW sensor codes it delivers
+	failures              map[string]*models.CopyFailure // source path -> failed copy attempts
+	maxCopyAttempts       int
+	retry                 RetryOptions
+	retryQueue            map[string]*queuedRetry // Failed files by source path, waiting for their next attempt
+	adaptive              AdaptiveParallelismOptions
+	nodeLimiters          map[string]*nodeLimiter
+	exclusions            models.Exclusions
+	fileFilter            FileFilter
+	spotCheck             SpotCheckOptions
+	spotCandidates        []spotCandidate
+	spotChecks            int32
+	spotSkipped           int32
+	spotMismatches        int32
+	shareSubpaths         map[string]string          // share key -> slash subpath holding projects
+	finishedTasks         map[string]models.SyncTask // node-share -> last finished task
+	idleFinish            time.Duration
+	lastProgress          int64 // Unix nanoseconds of the last copied file
+	finishReason          string
+	failReason            string // Why the destination was lost; a stop while it is set fails the run
+	runCopies             []spotCandidate
+	lastRunCopies         []spotCandidate
+	lastRunDir            string
+	exportStatus          models.ExportStatus
+	exportCancel          context.CancelFunc
+	messages              i18n.Catalog
+	memoryGuard           MemoryGuardOptions
+	degradedCopy          DegradedCopyOptions
+	postProcess           PostProcessOptions
+	postQueue             chan *postProcessJob
+	postProcessing        map[string]*models.CaptureProcessing // project/capture# -> pipeline status
+	postProcessOrder      []string
+	verifyRunning         bool
+	verifyCheckpoint      *state.VerifyCheckpoint    // Progress of the current or interrupted verify pass
+	verifyFiles           []state.VerifyFile         // Its files when there is no state store
+	verifySamplePercent   float64                    // Share of a run's files a verify pass hashes; 0 hashes all
+	captureMeta           map[string]*trackedCapture // capture# -> first sighting, for eviction
+	evictedCaptures       int
+	runCopiesDropped      int
+	lastRunCopiesDropped  int
+	heapAllocBytes        uint64
+	lastCompactedAt       time.Time
+	runSessions           map[string]struct{}   // Session GUIDs of the files the run has scanned
+	projectRename         *models.ProjectRename // Renamed project folder awaiting a remap
+	quotas                map[string]int64      // Destination path -> byte budget
+	quotaBaseline         *quotaBaseline
+	sourceSpace           SourceSpaceOptions
+	shareSpace            map[string]models.ShareSpace // node-share -> last free-space measurement
+	breakerOptions        CircuitBreakerOptions
+	breakers              map[string]*nodeBreaker
+	ioBudget              *ioBudget
+	changeNotify          ChangeNotifyOptions
+	sourceWatches         map[string]*sourceWatch // Source folder -> change watch
+	deltaCopy             DeltaCopyOptions
+	verifyChecksums       bool
+	captureOrder          bool   // Copy each scan's files in capture number order
+	tempDir               string // Where copies are written before the rename, "" next to the file
+	move                  MoveOptions
+	jobLogEnabled         bool
+	jobLog                jobLogFile // Log of the running job in its run folder
+	destIndexOpts         DestinationIndexOptions
+	destIndex             destinationIndex      // Copies found up to date, to skip their stat
+	runFolderLayout       string                // RunFolderByDate or RunFolderByJob
+	moveQueue             chan moveJob          // nil when move mode is off for the run
+	moveCandidates        map[string][]moveFile // capture key -> copied files waiting for completion
+	resume                ResumeOptions
+	heartbeat             HeartbeatOptions
+	jobID                 string
 
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
 }
 
+// taskInfo tracks one node/share task. node, share and cancel are set before
+// the task is published and never change; every other field is updated by
+// copy goroutines and must only be accessed atomically.
 type taskInfo struct {
 	node         string
 	share        string
@@ -92,10 +215,94 @@ type taskInfo struct {
 	failedFiles  int32
 	totalBytes   int64
 	copiedBytes  int64
-	lastActivity time.Time
+	lastActivity int64 // Unix nanoseconds
+	state        int32 // index into taskStates
+	checks       int32 // Copied files being checked against their source
 	cancel       context.CancelFunc
 }
 
+// Task states in the order a task normally moves through them. A copying
+// or retrying task reports taskVerifying while a copied file is checked.
+const (
+	taskScanning int32 = iota
+	taskCopying
+	taskRetrying
+	taskVerifying
+	taskStalled
+	taskDone
+	taskFailed
+)
+
+var taskStates = [...]string{
+	taskScanning:  models.TaskStateScanning,
+	taskCopying:   models.TaskStateCopying,
+	taskRetrying:  models.TaskStateRetrying,
+	taskVerifying: models.TaskStateVerifying,
+	taskStalled:   models.TaskStateStalled,
+	taskDone:      models.TaskStateDone,
+	taskFailed:    models.TaskStateFailed,
+}
+
+func newTaskInfo(node, share string, cancel context.CancelFunc, now time.Time) *taskInfo {
+	task := &taskInfo{node: node, share: share, cancel: cancel, state: taskScanning}
+	task.touch(now)
+	return task
+}
+
+func (t *taskInfo) setState(state int32) {
+	atomic.StoreInt32(&t.state, state)
+}
+
+// verifying marks a copied file of the task as being checked against its
+// source until done is called. Other files may be copied meanwhile.
+func (t *taskInfo) verifying() (done func()) {
+	atomic.AddInt32(&t.checks, 1)
+	return func() { atomic.AddInt32(&t.checks, -1) }
+}
+
+// currentState is the state reported for the task.
+func (t *taskInfo) currentState() int32 {
+	state := atomic.LoadInt32(&t.state)
+	if (state == taskCopying || state == taskRetrying) && atomic.LoadInt32(&t.checks) > 0 {
+		return taskVerifying
+	}
+	return state
+}
+
+// touch records progress on the task.
+func (t *taskInfo) touch(now time.Time) {
+	atomic.StoreInt64(&t.lastActivity, now.UnixNano())
+}
+
+func (t *taskInfo) lastActive() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&t.lastActivity))
+}
+
+// snapshot returns a consistent-enough copy of the task for status reporting
+// without taking any lock.
+func (t *taskInfo) snapshot() models.SyncTask {
+	totalBytes := atomic.LoadInt64(&t.totalBytes)
+	copiedBytes := atomic.LoadInt64(&t.copiedBytes)
+
+	progress := 0.0
+	if totalBytes > 0 {
+		progress = float64(copiedBytes) / float64(totalBytes) * 100.0
+	}
+
+	return models.SyncTask{
+		Node:         t.node,
+		Share:        t.share,
+		Status:       taskStates[t.currentState()],
+		LastActivity: t.lastActive(),
+		TotalFiles:   int(atomic.LoadInt32(&t.totalFiles)),
+		CopiedFiles:  int(atomic.LoadInt32(&t.copiedFiles)),
+		FailedFiles:  int(atomic.LoadInt32(&t.failedFiles)),
+		TotalBytes:   totalBytes,
+		CopiedBytes:  copiedBytes,
+		Progress:     progress,
+	}
+}
+
 type CopiedFileEvent struct {
 	Project         string
 	RelativePath    string
@@ -111,6 +318,15 @@ type CopiedFileProcessor interface {
 	ProcessCopiedFile(context.Context, CopiedFileEvent) error
 }
 
+// EventNotifier receives synchronization lifecycle events such as a finished
+// or failed run.
+type EventNotifier interface {
+	NotifySyncEvent(models.SyncEvent)
+}
+
+// ErrSyncAlreadyRunning is returned by Start when a run is already active.
+var ErrSyncAlreadyRunning = errors.New("synchronization already running")
+
 var (
 	requiredSensorCodes = []string{
 		"00-00", "00-01", "00-02", "00-03",
@@ -119,29 +335,6 @@ var (
 		"03-00",
 		"04-00", "05-00", "06-00", "07-00",
 	}
-
-	// RAW capture file name format (from WU01-WU13 nodes):
-	// Lvl0X or Lvl00 - file type (0X=unverified, 00=verified)
-	// 00001 - capture number
-	// T (optional) - test capture marker
-	// Arh2k_mezen_200725 - project name
-	// 06-00 - sensor code (00-00, 00-01, 00-02, 01-00, etc.)
-	// BD11EBB0_BE00_4BE7_BC66_9DED8D740C2E - unique session ID
-	// .raw - file extension
-	captureRegex = regexp.MustCompile(`^(Lvl\d+X?)-(\d+)(?:-(T))?-(.+)-(\d+-\d+)-([A-F0-9_]+)\.raw$`)
-
-	// XML metadata file name format (from CU node):
-	// EAD - prefix for metadata
-	// 00001 - capture number
-	// T (optional) - test capture marker
-	// Arh2k_mezen_200725 - project name
-	// BD11EBB0_BE00_4BE7_BC66_9DED8D740C2E - unique session ID
-	// .xml - file extension
-	// Note: XML file may be missing for test captures
-	metadataRegex = regexp.MustCompile(`^EAD-(\d+)(?:-(T))?-(.+)-([A-F0-9_]+)\.xml$`)
-
-	// RawQv quality file (optional supplemental file per capture)
-	rawQvRegex = regexp.MustCompile(`^RawQv-(\d+)(?:-(T))?-(.+)-([A-F0-9_]+)\.dat$`)
 )
 
 const (
@@ -168,26 +361,60 @@ func New(nodes, shares []string, baseMountDir string) *Service {
 		baseMountDir:          baseMountDir,
 		requiredSensors:       requiredSensors,
 		mountPointMounted:     isMountPointMounted,
+		resolveMount:          resolveMountForPath,
 		activeTasks:           make(map[string]*taskInfo),
 		captureTracker:        make(map[string]map[string]bool),
+		captureMeta:           make(map[string]*trackedCapture),
 		serviceLoopInterval:   defaultServiceLoopInterval,
+		loopIntervalChanged:   make(chan struct{}, 1),
+		sourceChanged:         make(chan struct{}, 1),
 		minFreeDiskSpace:      defaultMinFreeDiskSpace,
 		diskSpaceSafetyMargin: defaultDiskSpaceSafetyMargin,
 		diskUsage:             disk.Usage,
+		stallTimeout:          defaultStallTimeout,
+		maxCopyAttempts:       defaultMaxCopyAttempts,
+		retry:                 RetryOptions{InitialBackoff: defaultRetryInitialBackoff, MaxBackoff: defaultRetryMaxBackoff},
+		linkUnchanged:         true,
+		exclusions:            DefaultExclusions(),
+		scanMetrics:           make(map[string]models.ScanMetrics),
 	}
 }
 
-// SetServiceLoopInterval overrides the background sync polling interval.
-func (s *Service) SetServiceLoopInterval(interval time.Duration) {
+// SetMountPointChecker replaces the check used to decide whether a node share
+// is mounted. Simulation mode uses it to accept plain local directories.
+func (s *Service) SetMountPointChecker(checker func(string) (bool, error)) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if checker == nil {
+		checker = isMountPointMounted
+	}
+	s.mountPointMounted = checker
+}
+
+// SetServiceLoopInterval overrides the background sync polling interval. A
+// running sync loop picks up the new interval immediately.
+func (s *Service) SetServiceLoopInterval(interval time.Duration) {
+	s.mu.Lock()
 	if interval <= 0 {
-		s.serviceLoopInterval = defaultServiceLoopInterval
+		interval = defaultServiceLoopInterval
+	}
+	s.serviceLoopInterval = interval
+	changed := s.loopIntervalChanged
+	s.mu.Unlock()
+
+	if changed == nil {
 		return
 	}
+	select {
+	case changed <- struct{}{}:
+	default:
+	}
+}
 
-	s.serviceLoopInterval = interval
+// ServiceLoopInterval returns the current background sync polling interval.
+func (s *Service) ServiceLoopInterval() time.Duration {
+	return s.loopInterval()
 }
 
 // SetDiskSpaceThresholds configures the minimum free space and extra safety margin.
@@ -234,11 +461,20 @@ func (s *Service) SetStateStore(store *state.Store) error {
 	s.destination = status.Destination
 	s.maxParallelism = status.MaxParallelism
 	s.isRunning = false
+	s.invalidateStatus()
 	atomic.StoreInt32(&s.completedCaptures, int32(status.CompletedCaptures))
 	atomic.StoreInt32(&s.completedTestCaptures, int32(status.CompletedTestCaptures))
 	s.lastCaptureNumber = status.LastCaptureNumber
 	s.lastTestCaptureNumber = status.LastTestCaptureNumber
 	s.captureTracker = make(map[string]map[string]bool)
+	s.captureMeta = make(map[string]*trackedCapture)
+	if err := s.loadMaintenanceLocked(store); err != nil {
+		return err
+	}
+
+	if err := store.InterruptRunningJobs(); err != nil {
+		return err
+	}
 
 	if status.IsRunning {
 		return store.StopRun(state.StatusSnapshot{
@@ -262,13 +498,90 @@ func (s *Service) SetCopiedFileProcessor(processor CopiedFileProcessor) {
 	s.copiedFileProcessor = processor
 }
 
+// SetLanguage selects the language of event messages ("ru" or "en"). Call it
+// before Start.
+func (s *Service) SetLanguage(lang string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = i18n.New(lang)
+}
+
+// SetStorage replaces the providers used to read the shares and write the
+// destination; nil keeps the local file system. Call it before Start.
+// Hardlinking unchanged files, mount checks and the completion actions still
+// work on the local destination path.
+func (s *Service) SetStorage(source storage.SourceProvider, target storage.DestinationProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.source = source
+	s.target = target
+}
+
+// sourceStorage returns the source provider, the local file system unless
+// SetStorage replaced it.
+func (s *Service) sourceStorage() storage.SourceProvider {
+	if s.source == nil {
+		return storage.Local{}
+	}
+	return s.source
+}
+
+// destinationStorage returns the destination provider.
+func (s *Service) destinationStorage() storage.DestinationProvider {
+	if s.target == nil {
+		return storage.Local{}
+	}
+	return s.target
+}
+
+// localSource reports whether the source provider works on the local file
+// system, see storage.LocalFS.
+func (s *Service) localSource() bool {
+	return storage.IsLocal(s.sourceStorage())
+}
+
+// localDestination reports whether the destination provider works on the
+// local file system.
+func (s *Service) localDestination() bool {
+	return storage.IsLocal(s.destinationStorage())
+}
+
+// SetEventNotifier registers a receiver for sync finished/failed events.
+func (s *Service) SetEventNotifier(notifier EventNotifier) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.eventNotifier = notifier
+}
+
 // Start begins synchronization
 func (s *Service) Start(ctx context.Context, project, destination string, maxParallelism int, forceFullResync bool) error {
+	if err := s.start(ctx, project, destination, maxParallelism, forceFullResync); err != nil {
+		if !errors.Is(err, ErrSyncAlreadyRunning) {
+			s.emitEvent(models.SyncEvent{
+				Type:        models.SyncEventFailed,
+				Project:     project,
+				Destination: destination,
+				Message:     s.messages.Sprintf(i18n.EventSyncFailed, project, destination),
+				Reason:      err.Error(),
+			})
+		}
+		return err
+	}
+
+	s.warnDestinationFilesystem()
+	return nil
+}
+
+func (s *Service) start(ctx context.Context, project, destination string, maxParallelism int, forceFullResync bool) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	defer s.invalidateStatus()
 
 	if s.isRunning {
-		return fmt.Errorf("synchronization already running")
+		return ErrSyncAlreadyRunning
 	}
 
 	s.project = project
@@ -276,12 +589,56 @@ func (s *Service) Start(ctx context.Context, project, destination string, maxPar
 	s.maxParallelism = maxParallelism
 	s.forceFullResync = forceFullResync
 	s.globalSemaphore = make(chan struct{}, maxParallelism) // Global limit across all tasks
+	s.smallSemaphore = nil
+	if s.smallFiles.Enabled && s.smallFiles.Workers > 0 {
+		s.smallSemaphore = make(chan struct{}, s.smallFiles.Workers)
+	}
 	s.isRunning = true
+	s.sightings = nil
+	s.poolUsage = nil
+	s.nodeUsage = nil
 	s.captureTracker = make(map[string]map[string]bool)
+	s.captureMeta = make(map[string]*trackedCapture)
+	s.evictedCaptures = 0
 	atomic.StoreInt32(&s.completedCaptures, 0)
 	atomic.StoreInt32(&s.completedTestCaptures, 0)
 	s.lastCaptureNumber = ""
 	s.lastTestCaptureNumber = ""
+	s.startedAt = time.Now()
+	s.jobID = newJobID(project, s.startedAt)
+	atomic.StoreInt32(&s.runCopiedFiles, 0)
+	atomic.StoreInt32(&s.runLinkedFiles, 0)
+	atomic.StoreInt32(&s.runDegradedFiles, 0)
+	atomic.StoreInt64(&s.runLinkedBytes, 0)
+	atomic.StoreInt32(&s.runDeltaFiles, 0)
+	atomic.StoreInt64(&s.runDeltaSavedBytes, 0)
+	atomic.StoreInt32(&s.runResumedFiles, 0)
+	atomic.StoreInt64(&s.runResumedBytes, 0)
+	atomic.StoreInt32(&s.runMovedFiles, 0)
+	atomic.StoreInt64(&s.runMovedBytes, 0)
+	atomic.StoreInt32(&s.runChecksumMismatches, 0)
+	atomic.StoreInt32(&s.runScansDeferred, 0)
+	atomic.StoreInt32(&s.runFailedFiles, 0)
+	atomic.StoreInt64(&s.runCopiedBytes, 0)
+	s.runBenchmark = nil
+	s.failures = make(map[string]*models.CopyFailure)
+	s.retryQueue = make(map[string]*queuedRetry)
+	s.nodeLimiters = nil
+	s.spotCandidates = nil
+	s.finishedTasks = make(map[string]models.SyncTask)
+	atomic.StoreInt32(&s.spotChecks, 0)
+	atomic.StoreInt32(&s.spotSkipped, 0)
+	atomic.StoreInt32(&s.spotMismatches, 0)
+	s.noteProgress(time.Now())
+	s.finishReason = ""
+	s.failReason = ""
+	s.runCopies = nil
+	s.runCopiesDropped = 0
+	s.runSessions = make(map[string]struct{})
+	s.destIndex.reset()
+	s.projectRename = nil
+	s.quotaBaseline = nil
+	s.breakers = make(map[string]*nodeBreaker)
 
 	ctx, cancel := context.WithCancel(ctx)
 	s.cancel = cancel
@@ -292,13 +649,34 @@ func (s *Service) Start(ctx context.Context, project, destination string, maxPar
 		return err
 	}
 
-	// Create destination directory: <destination>/<YYYY-MM-DD>/<project>
-	dateDir := time.Now().Format("2006-01-02")
-	destDir := filepath.Join(destination, dateDir, project)
+	s.paused = false
+	s.pauseReason = ""
+	s.pausedNodes = nil
+	s.diskShortfallWarned = false
+	s.pacingBehindWarned = false
+	s.scansHeldSince = time.Time{}
+	s.destinationMount = mountInfo{}
+	if s.resolveMount != nil {
+		if mount, err := s.resolveMount(destination); err == nil {
+			s.destinationMount = mount
+		} else {
+			log.Warn().Err(err).Str("destination", destination).Msg("Failed to resolve destination mount")
+		}
+	}
+
+	// Create the run folder: <destination>/<YYYY-MM-DD>/<project>, or
+	// <destination>/<project>/<YYYY-MM-DD>_<job id> with the job layout
+	destDir := runFolderPath(s.runFolderLayout, destination, project, s.jobID, time.Now())
 	if err := os.MkdirAll(destDir, 0755); err != nil {
 		s.isRunning = false
+		s.cancel = nil
 		return fmt.Errorf("failed to create destination: %w", err)
 	}
+	if err := s.checkTempDir(tempRoot(s.tempDir, destination), destDir); err != nil {
+		s.isRunning = false
+		s.cancel = nil
+		return err
+	}
 
 	log.Info().
 		Str("project", project).
@@ -306,8 +684,12 @@ func (s *Service) Start(ctx context.Context, project, destination string, maxPar
 		Int("parallelism", maxParallelism).
 		Msg("Starting synchronization")
 
+	s.linkSources = nil
 	if s.stateStore != nil {
 		if forceFullResync {
+			if s.linkUnchanged {
+				s.linkSources = loadLinkSources(s.stateStore, project, destDir)
+			}
 			if err := s.stateStore.ResetCopiedFiles(project); err != nil {
 				s.isRunning = false
 				s.cancel = nil
@@ -330,12 +712,52 @@ func (s *Service) Start(ctx context.Context, project, destination string, maxPar
 		atomic.StoreInt32(&s.completedTestCaptures, int32(persisted.CompletedTestCaptures))
 		s.lastCaptureNumber = persisted.LastCaptureNumber
 		s.lastTestCaptureNumber = persisted.LastTestCaptureNumber
+
+		job := models.JobRecord{JobID: s.jobID, Project: project, Destination: destDir, StartedAt: s.startedAt}
+		if err := s.stateStore.RecordJobStarted(job); err != nil {
+			log.Warn().Err(err).Str("job", s.jobID).Msg("Failed to record job in history")
+		}
+	}
+
+	s.destDir = destDir
+	if s.jobLogEnabled {
+		s.jobLog.write(filepath.Join(destDir, jobLogName(s.jobID)), jobLogEntry{Job: s.jobID, Event: jobEventStarted, Dest: destDir})
 	}
 
 	// Start main sync loop
 	s.wg.Add(1)
 	go s.syncLoop(ctx, destDir)
 
+	s.wg.Add(1)
+	go s.stallWatchdog(ctx)
+
+	s.wg.Add(1)
+	go s.memoryGuardLoop(ctx)
+
+	s.wg.Add(1)
+	go s.statusRefreshLoop(ctx)
+
+	if s.adaptive.Enabled {
+		s.wg.Add(1)
+		go s.adaptiveParallelismLoop(ctx)
+	}
+
+	if s.spotCheck.Enabled {
+		s.wg.Add(1)
+		go s.spotCheckLoop(ctx)
+	}
+
+	if s.heartbeat.Enabled {
+		s.wg.Add(1)
+		go s.heartbeatLoop(ctx)
+	}
+
+	s.wg.Add(1)
+	go s.retryLoop(ctx)
+
+	s.startPostProcessingLocked(ctx)
+	s.startMoveLocked(ctx)
+
 	return nil
 }
 
@@ -357,8 +779,14 @@ func (s *Service) Stop() {
 	}
 
 	s.wg.Wait()
+	s.closeSourceWatches()
 
 	s.mu.Lock()
+	if !s.isRunning {
+		// A concurrent Stop already finished the run.
+		s.mu.Unlock()
+		return
+	}
 	statusSnapshot := state.StatusSnapshot{
 		Project:               s.project,
 		Destination:           s.destination,
@@ -368,21 +796,125 @@ func (s *Service) Stop() {
 		LastCaptureNumber:     s.lastCaptureNumber,
 		LastTestCaptureNumber: s.lastTestCaptureNumber,
 	}
+	totals := s.runTotalsLocked()
+	finishReason := s.finishReason
+	eventType, jobState := models.SyncEventFinished, models.JobFinished
+	switch {
+	case s.failReason != "":
+		eventType, jobState, finishReason = models.SyncEventFailed, models.JobFailed, s.failReason
+	case finishReason == "":
+		eventType, jobState = models.SyncEventStopped, models.JobStopped
+	}
+	s.failReason = ""
+	jobLogPath := ""
+	if s.jobLogEnabled && s.destDir != "" {
+		jobLogPath = filepath.Join(s.destDir, jobLogName(s.jobID))
+	}
+	jobID := s.jobID
+	s.lastRunDir = s.destDir
+	s.lastRunCopies = s.runCopies
+	s.lastRunCopiesDropped = s.runCopiesDropped
+	s.runCopies = nil
+	s.runCopiesDropped = 0
 	s.isRunning = false
 	s.cancel = nil
 	s.activeTasks = make(map[string]*taskInfo)
 	s.forceFullResync = false
+	s.paused = false
+	s.pauseReason = ""
+	s.pausedNodes = nil
+	s.destDir = ""
 	s.globalSemaphore = nil // Release semaphore
+	s.smallSemaphore = nil
 	store := s.stateStore
+	s.invalidateStatus()
 	s.mu.Unlock()
 
 	if store != nil {
 		if err := store.StopRun(statusSnapshot); err != nil {
 			log.Error().Err(err).Msg("Failed to persist stopped synchronization state")
 		}
+		if err := store.RecordJobFinished(jobID, jobState, finishReason, time.Now(), totals); err != nil {
+			log.Warn().Err(err).Str("job", jobID).Msg("Failed to record job in history")
+		}
 	}
 
+	if jobLogPath != "" {
+		s.jobLog.write(jobLogPath, jobLogEntry{Job: jobID, Event: jobEventFinished, Reason: finishReason, Totals: &totals})
+	}
+	s.jobLog.close()
+
 	log.Info().Msg("Synchronization stopped")
+
+	var message string
+	switch eventType {
+	case models.SyncEventFailed:
+		message = s.messages.Sprintf(i18n.EventSyncFailed, statusSnapshot.Project, statusSnapshot.Destination)
+	case models.SyncEventStopped:
+		message = s.messages.Sprintf(i18n.EventSyncStopped, totals.CopiedFiles, totals.FailedFiles, totals.CompletedCaptures)
+	default:
+		message = s.messages.Sprintf(i18n.EventSyncFinished, totals.CopiedFiles, totals.FailedFiles, totals.CompletedCaptures)
+	}
+	s.emitEvent(models.SyncEvent{
+		Type:        eventType,
+		Project:     statusSnapshot.Project,
+		Destination: statusSnapshot.Destination,
+		Message:     message,
+		Reason:      finishReason,
+		Totals:      &totals,
+	})
+}
+
+// runTotalsLocked summarizes the current run. Caller must hold s.mu.
+func (s *Service) runTotalsLocked() models.SyncTotals {
+	totals := models.SyncTotals{
+		CopiedFiles:           int(atomic.LoadInt32(&s.runCopiedFiles)),
+		FailedFiles:           int(atomic.LoadInt32(&s.runFailedFiles)),
+		CopiedBytes:           atomic.LoadInt64(&s.runCopiedBytes),
+		LinkedFiles:           int(atomic.LoadInt32(&s.runLinkedFiles)),
+		LinkedBytes:           atomic.LoadInt64(&s.runLinkedBytes),
+		DeltaFiles:            int(atomic.LoadInt32(&s.runDeltaFiles)),
+		DeltaSavedBytes:       atomic.LoadInt64(&s.runDeltaSavedBytes),
+		ResumedFiles:          int(atomic.LoadInt32(&s.runResumedFiles)),
+		ResumedBytes:          atomic.LoadInt64(&s.runResumedBytes),
+		MovedFiles:            int(atomic.LoadInt32(&s.runMovedFiles)),
+		MovedBytes:            atomic.LoadInt64(&s.runMovedBytes),
+		ScansDeferred:         int(atomic.LoadInt32(&s.runScansDeferred)),
+		ChecksumMismatches:    int(atomic.LoadInt32(&s.runChecksumMismatches)),
+		DegradedFiles:         int(atomic.LoadInt32(&s.runDegradedFiles)),
+		CompletedCaptures:     int(atomic.LoadInt32(&s.completedCaptures)),
+		CompletedTestCaptures: int(atomic.LoadInt32(&s.completedTestCaptures)),
+	}
+	if !s.startedAt.IsZero() {
+		totals.DurationSeconds = time.Since(s.startedAt).Seconds()
+	}
+	if s.runBenchmark != nil {
+		totals.DestinationWriteMBps = s.runBenchmark.WriteMBps
+	}
+	return totals
+}
+
+func (s *Service) emitEvent(event models.SyncEvent) {
+	s.mu.RLock()
+	notifier := s.eventNotifier
+	if event.JobID == "" {
+		event.JobID = s.jobID
+	}
+	s.mu.RUnlock()
+
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	if event.Type == models.SyncEventFailed {
+		log.Error().Str("project", event.Project).Str("reason", event.Reason).Msg(event.Message)
+	}
+
+	if notifier == nil {
+		return
+	}
+
+	notifier.NotifySyncEvent(event)
 }
 
 // IsProjectRunning reports whether the given project is currently being synced.
@@ -395,32 +927,35 @@ func (s *Service) IsProjectRunning(project string) bool {
 // GetStatus returns current sync status
 func (s *Service) GetStatus() models.SyncStatus {
 	s.mu.RLock()
-	tasks := make([]models.SyncTask, 0, len(s.activeTasks))
-	for _, task := range s.activeTasks {
-		progress := 0.0
-		if task.totalBytes > 0 {
-			progress = float64(atomic.LoadInt64(&task.copiedBytes)) / float64(task.totalBytes) * 100.0
+	tasks := make([]models.SyncTask, 0, len(s.activeTasks)+len(s.finishedTasks))
+	for key, task := range s.activeTasks {
+		if !isRetryTaskKey(key) {
+			tasks = append(tasks, task.snapshot())
+		}
+	}
+	// While syncing, node/shares without a running task show how their last
+	// task ended.
+	for key, task := range s.finishedTasks {
+		if _, running := s.activeTasks[key]; !running && s.isRunning && !isRetryTaskKey(key) {
+			tasks = append(tasks, task)
 		}
-
-		tasks = append(tasks, models.SyncTask{
-			Node:         task.node,
-			Share:        task.share,
-			Status:       "running",
-			LastActivity: task.lastActivity,
-			TotalFiles:   int(atomic.LoadInt32(&task.totalFiles)),
-			CopiedFiles:  int(atomic.LoadInt32(&task.copiedFiles)),
-			FailedFiles:  int(atomic.LoadInt32(&task.failedFiles)),
-			TotalBytes:   atomic.LoadInt64(&task.totalBytes),
-			CopiedBytes:  atomic.LoadInt64(&task.copiedBytes),
-			Progress:     progress,
-		})
 	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].Node != tasks[j].Node {
+			return tasks[i].Node < tasks[j].Node
+		}
+		return tasks[i].Share < tasks[j].Share
+	})
 
 	// Calculate active file operations (semaphore usage)
 	activeOps := 0
 	if s.globalSemaphore != nil {
 		activeOps = len(s.globalSemaphore)
 	}
+	if s.smallSemaphore != nil {
+		activeOps += len(s.smallSemaphore)
+	}
+	activeOps += s.activeInLocked(PoolPriority)
 
 	status := models.SyncStatus{
 		IsRunning:             s.isRunning,
@@ -432,11 +967,43 @@ func (s *Service) GetStatus() models.SyncStatus {
 		CompletedTestCaptures: int(atomic.LoadInt32(&s.completedTestCaptures)),
 		LastCaptureNumber:     s.lastCaptureNumber,
 		LastTestCaptureNumber: s.lastTestCaptureNumber,
+		Paused:                s.paused,
+		PauseReason:           s.pauseReason,
+		MaintenanceNodes:      s.nodesInMaintenanceLocked(),
+		PausedNodes:           s.pausedNodesLocked(),
+		QuarantinedFiles:      s.quarantinedCountLocked(),
+		QueuedFiles:           s.queuedCopiesLocked(),
+		ScansHeld:             !s.scansHeldSince.IsZero(),
+		NodeParallelism:       s.nodeParallelismLocked(),
+		SpotChecks:            int(atomic.LoadInt32(&s.spotChecks)),
+		SpotChecksSkipped:     int(atomic.LoadInt32(&s.spotSkipped)),
+		SpotCheckMismatches:   int(atomic.LoadInt32(&s.spotMismatches)),
 		ActiveTasks:           tasks,
 	}
+	status.PendingRetries, status.Retries = s.retryStatusLocked()
+	if s.isRunning {
+		status.JobID = s.jobID
+		memory := s.memoryAccountingLocked()
+		status.Memory = &memory
+		status.Benchmark = s.runBenchmark
+		status.ProjectRename = s.projectRename
+	}
 	store := s.stateStore
+	budget := s.ioBudget
 	s.mu.RUnlock()
 
+	if budget != nil {
+		status.IOBudget = budget.status()
+	}
+	if quota, ok := s.destinationQuota(false); ok && status.IsRunning {
+		status.Quota = &quota
+	}
+
+	if forecast, err := s.DiskSpaceForecast(); err == nil {
+		status.DiskForecast = forecast
+	}
+	status.Pacing = s.Pacing()
+
 	if store != nil {
 		persisted, err := store.LoadStatus()
 		if err == nil {
@@ -474,11 +1041,17 @@ type UnavailableShare struct {
 // CheckSharesAvailability returns a list of node/share pairs whose mount
 // points cannot be stat'd. An empty slice means all shares are reachable.
 func (s *Service) CheckSharesAvailability() []UnavailableShare {
+	s.mu.RLock()
+	mountPointMounted := s.mountPointMounted
+	s.mu.RUnlock()
+
 	var unavailable []UnavailableShare
 	for _, node := range s.nodes {
-		for _, share := range s.shares {
-			shareName := strings.TrimSuffix(share, "$")
-			mountPoint := filepath.Join(s.baseMountDir, node, shareName)
+		if s.inMaintenance(node) {
+			continue
+		}
+		for _, share := range s.sharesFor(node) {
+			mountPoint := s.shareMountPoint(node, share)
 			if _, err := os.Stat(mountPoint); err != nil {
 				unavailable = append(unavailable, UnavailableShare{
 					Node:  node,
@@ -488,7 +1061,7 @@ func (s *Service) CheckSharesAvailability() []UnavailableShare {
 				continue
 			}
 
-			mounted, err := s.mountPointMounted(mountPoint)
+			mounted, err := mountPointMounted(mountPoint)
 			if err != nil || !mounted {
 				unavailable = append(unavailable, UnavailableShare{
 					Node:  node,
@@ -501,23 +1074,28 @@ func (s *Service) CheckSharesAvailability() []UnavailableShare {
 	return unavailable
 }
 
-// FindProjects scans network for available projects
+// FindProjects scans network for available projects. Shares not read yet
+// when ctx is cancelled are skipped and ctx.Err() is returned.
 func (s *Service) FindProjects(ctx context.Context) ([]models.ProjectInfo, error) {
 	projectMap := make(map[string]string) // name -> source
 	var mu sync.Mutex
 
 	var wg sync.WaitGroup
 	for _, node := range s.nodes {
-		for _, share := range s.shares {
+		for _, share := range s.sharesFor(node) {
 			wg.Add(1)
 			go func(node, share string) {
 				defer wg.Done()
 
-				// Get mount point for this node/share
-				shareName := strings.TrimSuffix(share, "$")
-				root := filepath.Join(s.baseMountDir, node, shareName)
+				if ctx.Err() != nil {
+					return
+				}
+
+				// Get the project folder root for this node/share
+				root := s.shareRoot(node, share)
+				excludedProjects := s.Exclusions().Projects
 
-				entries, err := os.ReadDir(root)
+				entries, err := s.sourceStorage().ReadDir(root)
 				if err != nil {
 					log.Debug().
 						Str("node", node).
@@ -534,7 +1112,7 @@ func (s *Service) FindProjects(ctx context.Context) ([]models.ProjectInfo, error
 					}
 
 					name := entry.Name()
-					if !isValidProjectName(name) {
+					if !isValidProjectName(name, excludedProjects) {
 						continue
 					}
 
@@ -550,6 +1128,11 @@ func (s *Service) FindProjects(ctx context.Context) ([]models.ProjectInfo, error
 
 	wg.Wait()
 
+	// A partial scan is not worth keeping.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Convert to slice and sort
 	projects := make([]models.ProjectInfo, 0, len(projectMap))
 	for name, source := range projectMap {
@@ -579,14 +1162,36 @@ func (s *Service) syncLoop(ctx context.Context, destDir string) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	s.runSyncIteration(ctx, destDir)
+	s.mu.RLock()
+	destination, project := s.destination, s.project
+	s.mu.RUnlock()
+	s.removeStaleTempFiles(ctx, destination, project)
+	s.runSyncIteration(ctx, s.currentDestDir(destDir))
 
 	for {
+		if ctx.Err() == nil && s.idleFinishDue(time.Now()) {
+			s.finishIdle()
+			return
+		}
+
 		select {
 		case <-ctx.Done():
 			return
+		case <-s.loopIntervalChanged:
+			if next := s.loopInterval(); next != interval {
+				interval = next
+				ticker.Reset(interval)
+				log.Info().Str("interval", interval.String()).Msg("Service loop interval changed")
+			}
 		case <-ticker.C:
-			s.runSyncIteration(ctx, destDir)
+			// Roll-over may have moved the run to another destination.
+			s.runSyncIteration(ctx, s.currentDestDir(destDir))
+		case <-s.sourceChanged:
+			if !s.waitForChangesToSettle(ctx, s.changeNotifyOptions().Debounce, interval) {
+				return
+			}
+			s.runSyncIteration(ctx, s.currentDestDir(destDir))
+			ticker.Reset(interval)
 		}
 	}
 }
@@ -616,13 +1221,40 @@ func (s *Service) runSyncIteration(ctx context.Context, destDir string) {
 }
 
 func (s *Service) syncIteration(ctx context.Context, destDir string) {
+	if s.isPaused() {
+		return
+	}
+
 	if err := ensureDestinationReady(destDir); err != nil {
 		log.Error().Err(err).Str("destination", destDir).Msg("Destination unavailable, skipping sync iteration")
 		return
 	}
 
+	if err := s.verifyDestinationMounted(destDir); err != nil {
+		s.hardPause(err.Error())
+		return
+	}
+
+	if s.checkDestinationRollover() {
+		return
+	}
+	if s.checkDestinationQuota() {
+		return
+	}
+
+	s.checkDiskSpaceForecast()
+	s.checkPacing()
+
+	if s.holdScans(time.Now()) {
+		return
+	}
+
+	projectMissing := false
 	for _, node := range s.nodes {
-		for _, share := range s.shares {
+		if s.inMaintenance(node) || s.isNodePaused(node) || !s.nodeBreakerAllows(node) {
+			continue
+		}
+		for _, share := range s.sharesFor(node) {
 			select {
 			case <-ctx.Done():
 				return
@@ -631,13 +1263,15 @@ func (s *Service) syncIteration(ctx context.Context, destDir string) {
 
 			key := fmt.Sprintf("%s-%s", node, share)
 
-			// Get mount point for this node/share
-			shareName := strings.TrimSuffix(share, "$")
-			mountPoint := filepath.Join(s.baseMountDir, node, shareName)
-			source := filepath.Join(mountPoint, s.project)
+			// Get the project folder for this node/share
+			source := filepath.Join(s.shareRoot(node, share), s.project)
 
 			// Check if source exists
-			if _, err := os.Stat(source); os.IsNotExist(err) {
+			if _, err := s.sourceStorage().Stat(source); os.IsNotExist(err) {
+				s.mu.RLock()
+				_, synced := s.finishedTasks[key]
+				s.mu.RUnlock()
+				projectMissing = projectMissing || synced
 				continue
 			}
 
@@ -659,18 +1293,19 @@ func (s *Service) syncIteration(ctx context.Context, destDir string) {
 			s.startSyncTask(ctx, node, share, source, destDir)
 		}
 	}
+
+	// A folder that was synced earlier in the run and is gone now may have
+	// been renamed by an operator.
+	if projectMissing {
+		s.detectProjectRename()
+	}
 }
 
 func (s *Service) startSyncTask(parentCtx context.Context, node, share, source, dest string) {
 	key := fmt.Sprintf("%s-%s", node, share)
 
 	ctx, cancel := context.WithCancel(parentCtx)
-	task := &taskInfo{
-		node:         node,
-		share:        share,
-		lastActivity: time.Now(),
-		cancel:       cancel,
-	}
+	task := newTaskInfo(node, share, cancel, time.Now())
 
 	s.mu.Lock()
 	s.activeTasks[key] = task
@@ -679,14 +1314,25 @@ func (s *Service) startSyncTask(parentCtx context.Context, node, share, source,
 	s.wg.Add(1)
 	go func() {
 		defer s.wg.Done()
+		var syncErr error
 		defer func() {
 			s.mu.Lock()
-			delete(s.activeTasks, key)
+			// The stall watchdog may already have replaced this task.
+			if s.activeTasks[key] == task {
+				delete(s.activeTasks, key)
+				if ctx.Err() == nil {
+					s.recordFinishedTaskLocked(key, task, syncErr)
+				}
+			}
 			s.mu.Unlock()
+			if ctx.Err() == nil {
+				s.signalIfSourceChanged(source)
+			}
 		}()
 
 		if err := s.syncDirectory(ctx, task, source, dest); err != nil {
 			if ctx.Err() == nil {
+				syncErr = err
 				log.Error().
 					Err(err).
 					Str("node", node).
@@ -698,64 +1344,207 @@ func (s *Service) startSyncTask(parentCtx context.Context, node, share, source,
 }
 
 func (s *Service) syncDirectory(ctx context.Context, task *taskInfo, source, dest string) error {
-	// Scan source directory
-	files, err := s.scanDirectory(ctx, source, source)
+	scanStarted := time.Now()
+
+	// Scan source directory, or only what changed when it is watched
+	files, watch, full, err := s.listSource(ctx, source)
 	if err != nil {
 		return err
 	}
+	s.noteRunSessions(files)
 
 	// Filter files that need copying
 	filesToCopy := make([]string, 0)
+	fileSizes := make(map[string]int64)
 	var totalBytes int64
 
+	retrying := false
+	var unsettled []string
 	for _, file := range files {
+		if s.isQuarantined(file) || s.retryQueued(file) {
+			continue
+		}
 		if s.shouldCopyFile(file, source, dest) {
+			info, err := s.sourceStorage().Stat(file)
+			if err == nil && !s.settled(file, info, time.Now()) {
+				unsettled = append(unsettled, file)
+				continue
+			}
+			retrying = retrying || s.hasCopyFailure(file)
 			filesToCopy = append(filesToCopy, file)
-			if info, err := os.Stat(file); err == nil {
+			if err == nil {
+				fileSizes[file] = info.Size()
 				totalBytes += info.Size()
 			}
 		}
 	}
+	if len(unsettled) > 0 {
+		log.Debug().
+			Str("node", task.node).
+			Str("share", task.share).
+			Int("files", len(unsettled)).
+			Msg("Leaving files still being written for the next scan")
+	}
+
+	if s.captureOrderEnabled() {
+		sortByCapture(filesToCopy)
+	}
 
 	atomic.StoreInt32(&task.totalFiles, int32(len(filesToCopy)))
 	atomic.StoreInt64(&task.totalBytes, totalBytes)
-
-	// Copy files with parallelism (using global semaphore shared across all tasks)
+	task.touch(time.Now())
+	if retrying {
+		task.setState(taskRetrying)
+	} else {
+		task.setState(taskCopying)
+	}
+
+	s.recordScanMetrics(models.ScanMetrics{
+		Node:            task.node,
+		Share:           task.share,
+		StartedAt:       scanStarted,
+		DurationSeconds: time.Since(scanStarted).Seconds(),
+		FilesExamined:   len(files),
+		FilesQueued:     len(filesToCopy),
+		BytesQueued:     totalBytes,
+		Incremental:     !full,
+		FilesUnsettled:  len(unsettled),
+	})
+	// Until copied, queued files are listed again whatever the watch reports,
+	// and so are unsettled ones until a scan finds them unchanged.
+	watch.keep(filesToCopy)
+	watch.keep(unsettled)
+
+	// Copy files with parallelism (using global semaphore shared across all
+	// tasks, plus the node's adaptive limit when enabled)
 	var wg sync.WaitGroup
+	limiter := s.nodeLimiterFor(task.node)
 
-	for _, file := range filesToCopy {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case s.globalSemaphore <- struct{}{}:
+	copyOne := func(filePath string) {
+		if err := s.copyFile(ctx, task, filePath, source, dest); err != nil {
+			s.handleCopyError(ctx, task, filePath, source, err)
+			return
+		}
+		s.recordNodeOutcome(task.node, false)
+		s.clearCopyFailure(filePath)
+		watch.release(filePath)
+	}
+
+	// The EAD XML decides capture completeness, so it is copied first.
+	metadata, others := splitMetadata(filesToCopy)
+	s.copyMetadata(ctx, task, metadata, fileSizes, copyOne)
+
+	// Small files (logs, quality files) go through their own slots so they
+	// are not stuck behind multi-GB raws.
+	small, large := s.splitSmallFiles(others, fileSizes)
+	if len(small) > 0 {
+		sem := s.smallSemaphore
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.copySmallFiles(ctx, task, sem, small, fileSizes, copyOne)
+		}()
+	}
+
+	for _, file := range large {
+		if s.isPaused() || s.isNodePaused(task.node) || !s.nodeBreakerAllows(task.node) || !s.quotaAllows(fileSizes[file]) {
+			break
+		}
+
+		waitStarted := time.Now()
+		stopWaiting := s.keepTaskActive(task)
+		if limiter != nil {
+			err = limiter.acquire(ctx)
+		}
+		if err == nil {
+			select {
+			case <-ctx.Done():
+				if limiter != nil {
+					limiter.release()
+				}
+				err = ctx.Err()
+			case s.globalSemaphore <- struct{}{}:
+			}
+		}
+		stopWaiting()
+		if err != nil {
+			break
 		}
+		task.touch(time.Now())
+		done := s.useSlot(PoolCopy, task.node, time.Since(waitStarted))
 
 		wg.Add(1)
 		go func(filePath string) {
 			defer wg.Done()
 			defer func() { <-s.globalSemaphore }()
-
-			if err := s.copyFile(ctx, task, filePath, source, dest); err != nil {
-				atomic.AddInt32(&task.failedFiles, 1)
-				log.Error().
-					Err(err).
-					Str("file", filePath).
-					Msg("Failed to copy file")
+			if limiter != nil {
+				defer limiter.release()
 			}
+			defer done()
+			copyOne(filePath)
 		}(file)
 	}
 
 	wg.Wait()
-	return nil
+	return err
+}
+
+// handleCopyError records a failed copy of filePath from the source root
+// source and schedules its retry.
+func (s *Service) handleCopyError(ctx context.Context, task *taskInfo, filePath, source string, err error) {
+	if errors.Is(err, ErrDestinationUnmounted) {
+		s.hardPause(err.Error())
+		return
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+		// Stopped mid-file; the file is copied again next run.
+		log.Debug().Str("file", filePath).Msg("Copy interrupted by stop")
+		return
+	}
+	if errors.Is(err, ErrFileTooLargeForDestination) {
+		// Copying it again cannot help, and the node is not to blame.
+		relPath, _ := filepath.Rel(source, filePath)
+		s.quarantineIncompatibleFile(task, filePath, filepath.ToSlash(relPath), err)
+		return
+	}
+	atomic.AddInt32(&task.failedFiles, 1)
+	atomic.AddInt32(&s.runFailedFiles, 1)
+	log.Error().
+		Err(err).
+		Str("file", filePath).
+		Msg("Failed to copy file")
+	s.logJobEvent(jobLogEntry{Event: jobEventCopyFailed, Node: task.node, Source: filePath, Error: err.Error()})
+
+	if ctx.Err() == nil {
+		s.recordNodeError(task.node)
+		s.recordNodeOutcome(task.node, true)
+		relPath, _ := filepath.Rel(source, filePath)
+		if !s.recordCopyFailure(task, filePath, filepath.ToSlash(relPath), err) {
+			s.queueRetry(task, filePath, source)
+		}
+	}
 }
 
 func (s *Service) scanDirectory(ctx context.Context, root, current string) ([]string, error) {
+	return s.scanTree(ctx, current, nil)
+}
+
+// scanTree lists the files below current, skipping excluded directories and
+// files the file filter leaves out.
+// onDir, when set, is called for current and every directory below it before
+// the directory is read.
+func (s *Service) scanTree(ctx context.Context, current string, onDir func(string)) ([]string, error) {
 	var files []string
 
-	entries, err := os.ReadDir(current)
+	if onDir != nil {
+		onDir(current)
+	}
+	entries, err := s.sourceStorage().ReadDir(current)
 	if err != nil {
 		return nil, err
 	}
+	excludedDirectories := s.Exclusions().Directories
+	filter := s.currentFileFilter()
 
 	for _, entry := range entries {
 		select {
@@ -767,14 +1556,14 @@ func (s *Service) scanDirectory(ctx context.Context, root, current string) ([]st
 		path := filepath.Join(current, entry.Name())
 
 		if entry.IsDir() {
-			if isExcludedDirectory(entry.Name()) {
+			if isExcludedDirectory(entry.Name(), excludedDirectories) {
 				continue
 			}
-			subFiles, err := s.scanDirectory(ctx, root, path)
+			subFiles, err := s.scanTree(ctx, path, onDir)
 			if err == nil {
 				files = append(files, subFiles...)
 			}
-		} else {
+		} else if filter.allows(path) {
 			files = append(files, path)
 		}
 	}
@@ -783,17 +1572,27 @@ func (s *Service) scanDirectory(ctx context.Context, root, current string) ([]st
 }
 
 func (s *Service) shouldCopyFile(sourcePath, sourceRoot, destRoot string) bool {
+	if !s.currentFileFilter().allows(sourcePath) {
+		return false
+	}
+
 	relPath, err := filepath.Rel(sourceRoot, sourcePath)
 	if err != nil {
 		return true
 	}
 	relPath = filepath.ToSlash(relPath)
 
-	sourceInfo, err := os.Stat(sourcePath)
+	sourceInfo, err := s.sourceStorage().Stat(sourcePath)
 	if err != nil {
 		return true
 	}
 
+	profile := s.destinationProfile()
+	destPath := filepath.Join(destRoot, profile.destinationRelPath(relPath))
+	if s.indexedCopyMatches(destPath, sourceInfo.Size(), sourceInfo.ModTime()) {
+		return false
+	}
+
 	s.mu.RLock()
 	store := s.stateStore
 	project := s.project
@@ -803,6 +1602,7 @@ func (s *Service) shouldCopyFile(sourcePath, sourceRoot, destRoot string) bool {
 	if store != nil && !forceFullResync {
 		copied, err := store.IsFileCopied(project, relPath, sourceInfo.Size(), sourceInfo.ModTime())
 		if err == nil && copied {
+			s.indexCopy(destPath, sourceInfo.Size(), sourceInfo.ModTime())
 			return false
 		}
 		if err != nil {
@@ -813,13 +1613,7 @@ func (s *Service) shouldCopyFile(sourcePath, sourceRoot, destRoot string) bool {
 		// parent capture has already been marked complete. A completed capture
 		// must not be re-downloaded unless the user explicitly requests a full
 		// re-sync (forceFullResync flag).
-		capInfo := parseCaptureFileName(filepath.Base(sourcePath))
-		if capInfo == nil {
-			capInfo = parseMetadataFileName(filepath.Base(sourcePath))
-		}
-		if capInfo == nil {
-			capInfo = parseRawQvFileName(filepath.Base(sourcePath))
-		}
+		capInfo := ParseCaptureFile(sourcePath)
 		if capInfo != nil && capInfo.CaptureNumber != "" {
 			done, doneErr := store.IsCaptureDone(project, capInfo.CaptureNumber)
 			if doneErr == nil && done {
@@ -828,8 +1622,7 @@ func (s *Service) shouldCopyFile(sourcePath, sourceRoot, destRoot string) bool {
 		}
 	}
 
-	destPath := filepath.Join(destRoot, relPath)
-	destInfo, err := os.Stat(destPath)
+	destInfo, err := s.destinationStorage().Stat(destPath)
 	if os.IsNotExist(err) {
 		return true
 	}
@@ -837,22 +1630,23 @@ func (s *Service) shouldCopyFile(sourcePath, sourceRoot, destRoot string) bool {
 		return true
 	}
 
-	// Copy if size differs or source is newer (with 2-second tolerance)
+	// Copy if size differs or source is newer (with filesystem-specific tolerance)
 	if destInfo.Size() != sourceInfo.Size() {
 		return true
 	}
 
-	if destInfo.ModTime().Before(sourceInfo.ModTime().Add(-2 * time.Second)) {
+	if destInfo.ModTime().Before(sourceInfo.ModTime().Add(-profile.MTimeTolerance)) {
 		return true
 	}
 
 	if store != nil && !forceFullResync {
-		if err := s.reconcilePersistedFileState(sourcePath, relPath, sourceInfo); err != nil {
+		if err := s.reconcilePersistedFileState(sourcePath, relPath, sourceInfo, destRoot); err != nil {
 			log.Warn().Err(err).Str("file", relPath).Msg("Failed to reconcile persisted file state")
 			return true
 		}
 	}
 
+	s.indexCopy(destPath, sourceInfo.Size(), sourceInfo.ModTime())
 	return false
 }
 
@@ -861,64 +1655,265 @@ func (s *Service) copyFile(ctx context.Context, task *taskInfo, sourcePath, sour
 		return err
 	}
 
+	// Never write into an empty mount point on the root filesystem.
+	if err := s.verifyDestinationMounted(destRoot); err != nil {
+		return err
+	}
+
 	relPath, err := filepath.Rel(sourceRoot, sourcePath)
 	if err != nil {
 		return err
 	}
 
-	destPath := filepath.Join(destRoot, relPath)
+	profile := s.destinationProfile()
+	destPath := filepath.Join(destRoot, profile.destinationRelPath(relPath))
 
 	// Create destination directory
 	destDir := filepath.Dir(destPath)
-	if err := os.MkdirAll(destDir, 0755); err != nil {
+	if err := s.destinationStorage().MkdirAll(destDir); err != nil {
 		return err
 	}
 
+	// Unchanged files from the previous snapshot are hardlinked, not copied.
+	if srcInfo, err := s.sourceStorage().Stat(sourcePath); err == nil && s.linkFromPreviousSnapshot(relPath, destPath, srcInfo) {
+		atomic.AddInt32(&task.copiedFiles, 1)
+		atomic.AddInt64(&task.copiedBytes, srcInfo.Size())
+		atomic.AddInt32(&s.runLinkedFiles, 1)
+		atomic.AddInt64(&s.runLinkedBytes, srcInfo.Size())
+		task.touch(time.Now())
+		s.logJobEvent(jobLogEntry{Event: jobEventLinked, Node: task.node, Source: sourcePath, Dest: destPath, Bytes: srcInfo.Size()})
+
+		return s.finishCopiedFile(ctx, task, sourcePath, relPath, destPath, destRoot, srcInfo, 0)
+	}
+
 	// Open source file
-	src, err := os.Open(sourcePath)
+	started := time.Now()
+	src, err := s.sourceStorage().Open(sourcePath)
 	if err != nil {
 		return err
 	}
 	defer src.Close()
 
-	// Create destination file
-	dst, err := os.Create(destPath)
-	if err != nil {
-		return err
+	if profile.MaxFileSize > 0 {
+		if srcInfo, err := src.Stat(); err == nil && srcInfo.Size() > profile.MaxFileSize {
+			return fmt.Errorf("%w: %s is %d bytes, %s allows at most %d", ErrFileTooLargeForDestination, relPath, srcInfo.Size(), profile.Name, profile.MaxFileSize)
+		}
+	}
+
+	// Write to a temporary file renamed into place once complete, so an
+	// interrupted copy never leaves a truncated file under the real name.
+	// A large file cut short earlier continues where it stopped.
+	tmpPath := s.tempPathFor(destPath)
+	if tmpDir := filepath.Dir(tmpPath); tmpDir != destDir {
+		if err := s.destinationStorage().MkdirAll(tmpDir); err != nil {
+			return err
+		}
+	}
+
+	// A changed file whose earlier copy is still there is patched from it.
+	degraded := s.degradedCopyOptions()
+	if srcInfo, err := src.Stat(); err == nil && !degraded.Enabled && s.canDeltaCopy(destPath, srcInfo.Size()) {
+		return s.deltaCopyAndFinish(ctx, task, src, sourcePath, relPath, destPath, tmpPath, destRoot, started)
+	}
+	var reader io.Reader = src
+	var dst storage.WriteFile
+	var offset int64
+	keepPartial := false
+	if srcInfo, err := src.Stat(); err == nil {
+		keepPartial = s.keepsPartialCopies(srcInfo.Size())
+		if !degraded.Enabled {
+			offset = s.resumablePrefix(src, srcInfo.Size(), srcInfo.ModTime().UnixNano(), tmpPath, destPath)
+		}
+		if offset > 0 {
+			resumed, rest, err := openResumed(src, tmpPath, offset, srcInfo.Size())
+			if err != nil {
+				return err
+			}
+			dst, reader = resumed, rest
+			s.recordResumedCopy(offset)
+			log.Info().Str("file", relPath).Int64("offset", offset).Int64("size", srcInfo.Size()).Msg("Resuming interrupted copy")
+		}
+	}
+	if dst == nil {
+		if dst, err = s.destinationStorage().Create(tmpPath); err != nil {
+			return err
+		}
 	}
-	defer dst.Close()
+	committed := false
+	defer func() {
+		if !committed {
+			dst.Close()
+			if !keepPartial {
+				s.destinationStorage().Remove(tmpPath)
+			}
+		}
+	}()
 
 	// Copy with context cancellation
-	written, err := io.Copy(dst, src)
+	var tracked *sourceReader
+	if degraded.Enabled {
+		// Wrapping src gives up the kernel copy fast path, so only do it
+		// when read errors are to be salvaged.
+		tracked = &sourceReader{r: src}
+		reader = tracked
+	}
+	reader = throttled(ctx, s.currentIOBudget(), IOJobSync, reader)
+	written, err := copyWithContext(ctx, dst, reader, func() { task.touch(time.Now()) })
+	written += offset
+	var badRanges []models.ByteRange
 	if err != nil {
+		if tracked == nil || !isSourceReadError(tracked, err) {
+			return err
+		}
+		badRanges, err = s.salvageAfterReadError(ctx, src, dst, sourcePath, destPath, written, err, degraded.BlockSize)
+		if err != nil {
+			return err
+		}
+		if info, statErr := src.Stat(); statErr == nil {
+			written = info.Size()
+		}
+	}
+
+	if err := dst.Close(); err != nil {
 		return err
 	}
 
+	// A salvaged copy is known to differ where the source was unreadable.
+	var sum []byte
+	if len(badRanges) == 0 && s.verifyChecksumsEnabled() {
+		done := task.verifying()
+		sum, err = s.verifyCopiedChecksum(ctx, sourcePath, tmpPath)
+		done()
+		if err != nil {
+			return err
+		}
+	}
+
 	// Preserve timestamps
 	info, statErr := src.Stat()
 	if statErr == nil {
-		os.Chtimes(destPath, info.ModTime(), info.ModTime())
+		s.destinationStorage().Chtimes(tmpPath, info.ModTime(), info.ModTime())
+	}
+	if err := s.destinationStorage().Rename(tmpPath, destPath); err != nil {
+		return err
 	}
+	committed = true
 
 	// Update stats
 	atomic.AddInt32(&task.copiedFiles, 1)
 	atomic.AddInt64(&task.copiedBytes, written)
-	task.lastActivity = time.Now()
+	atomic.AddInt32(&s.runCopiedFiles, 1)
+	atomic.AddInt64(&s.runCopiedBytes, written)
+	s.recordNodeCopy(task.node, written)
+	task.touch(time.Now())
 
-	s.mu.RLock()
-	s.mu.RUnlock()
 	if statErr != nil {
 		return statErr
 	}
+	if len(badRanges) > 0 {
+		s.reportDegradedFile(sourcePath, destPath, badRanges)
+	}
+	s.logJobEvent(jobLogEntry{
+		Event:      jobEventCopied,
+		Node:       task.node,
+		Source:     sourcePath,
+		Dest:       destPath,
+		Bytes:      written,
+		DurationMS: time.Since(started).Milliseconds(),
+		SHA256:     hexSum(sum),
+	})
 
-	completedCapture, err := s.persistCopiedFileState(sourcePath, relPath, info, task.node)
+	if err := s.finishCopiedFile(ctx, task, sourcePath, relPath, destPath, destRoot, info, time.Since(started)); err != nil {
+		return err
+	}
+	s.recordFileChecksum(relPath, sum)
+	return nil
+}
+
+// deltaCopyAndFinish patches the earlier copy at destPath to src in tmpPath,
+// renames it into place and records it like a full copy.
+func (s *Service) deltaCopyAndFinish(ctx context.Context, task *taskInfo, src storage.File, sourcePath, relPath, destPath, tmpPath, destRoot string, started time.Time) error {
+	stats, err := s.deltaCopyFile(ctx, src, destPath, tmpPath)
+	if err != nil {
+		return err
+	}
+	var sum []byte
+	if s.verifyChecksumsEnabled() {
+		done := task.verifying()
+		sum, err = s.verifyCopiedChecksum(ctx, sourcePath, tmpPath)
+		done()
+		if err != nil {
+			s.destinationStorage().Remove(tmpPath)
+			return err
+		}
+	}
+
+	info, err := src.Stat()
+	if err != nil {
+		s.destinationStorage().Remove(tmpPath)
+		return err
+	}
+	s.destinationStorage().Chtimes(tmpPath, info.ModTime(), info.ModTime())
+	if err := s.destinationStorage().Rename(tmpPath, destPath); err != nil {
+		s.destinationStorage().Remove(tmpPath)
+		return err
+	}
+
+	atomic.AddInt32(&task.copiedFiles, 1)
+	atomic.AddInt64(&task.copiedBytes, stats.size)
+	atomic.AddInt32(&s.runCopiedFiles, 1)
+	atomic.AddInt64(&s.runCopiedBytes, stats.size)
+	s.recordDeltaCopy(stats)
+	s.recordNodeCopy(task.node, stats.size)
+	task.touch(time.Now())
+
+	log.Debug().
+		Str("file", relPath).
+		Int64("size", stats.size).
+		Int64("written", stats.written).
+		Msg("Patched destination copy")
+	s.logJobEvent(jobLogEntry{
+		Event:      jobEventPatched,
+		Node:       task.node,
+		Source:     sourcePath,
+		Dest:       destPath,
+		Bytes:      stats.written,
+		DurationMS: time.Since(started).Milliseconds(),
+		SHA256:     hexSum(sum),
+	})
+
+	if err := s.finishCopiedFile(ctx, task, sourcePath, relPath, destPath, destRoot, info, time.Since(started)); err != nil {
+		return err
+	}
+	s.recordFileChecksum(relPath, sum)
+	return nil
+}
+
+// finishCopiedFile records a file that is now present at destPath, copied in
+// transfer (zero for a hardlink), and runs capture tracking and post-copy
+// processing for it.
+func (s *Service) finishCopiedFile(ctx context.Context, task *taskInfo, sourcePath, relPath, destPath, destRoot string, info os.FileInfo, transfer time.Duration) error {
+	timing := copyTiming{modTime: info.ModTime(), copiedAt: time.Now(), transfer: transfer}
+	completedCapture, err := s.persistCopiedFileState(sourcePath, relPath, info, task.node, destRoot, timing)
 	if err != nil {
 		return err
 	}
+	s.indexCopy(destPath, info.Size(), info.ModTime())
+	s.rememberMoveCandidate(sourcePath, relPath, destPath, info.Size())
+	if completedCapture {
+		s.notifyCaptureCompleted(filepath.Base(sourcePath))
+	}
+	s.rememberSpotCandidate(task, sourcePath, destPath)
+	s.recordRunCopy(sourcePath, destPath)
+	s.noteProgress(time.Now())
 
 	if isEADMetadataFile(relPath) || completedCapture {
+		s.mu.RLock()
+		project := s.project
+		s.mu.RUnlock()
+
 		s.processCopiedFile(ctx, CopiedFileEvent{
-			Project:         s.project,
+			Project:         project,
 			RelativePath:    filepath.ToSlash(relPath),
 			SourcePath:      sourcePath,
 			DestinationPath: destPath,
@@ -951,22 +1946,22 @@ func (s *Service) processCopiedFile(ctx context.Context, event CopiedFileEvent)
 	}
 }
 
-func (s *Service) persistCopiedFileState(sourcePath, relPath string, info os.FileInfo, node string) (bool, error) {
+func (s *Service) persistCopiedFileState(sourcePath, relPath string, info os.FileInfo, node, destination string, timing copyTiming) (bool, error) {
 	s.mu.RLock()
 	project := s.project
 	store := s.stateStore
 	s.mu.RUnlock()
 
 	if store == nil {
-		return s.trackCaptureCompletionStatus(filepath.Base(sourcePath), node)
+		return s.trackCaptureFile(filepath.Base(sourcePath), node, destination, timing)
 	}
 
 	var errs []error
-	completedCapture, err := s.trackCaptureCompletionStatus(filepath.Base(sourcePath), node)
+	completedCapture, err := s.trackCaptureFile(filepath.Base(sourcePath), node, destination, timing)
 	if err != nil {
 		errs = append(errs, err)
 	}
-	if err := store.MarkFileCopied(project, relPath, info.Size(), info.ModTime()); err != nil {
+	if err := store.MarkFileCopiedTo(project, relPath, info.Size(), info.ModTime(), destination); err != nil {
 		errs = append(errs, err)
 	}
 
@@ -977,7 +1972,7 @@ func (s *Service) persistCopiedFileState(sourcePath, relPath string, info os.Fil
 	return completedCapture, nil
 }
 
-func (s *Service) reconcilePersistedFileState(sourcePath, relPath string, info os.FileInfo) error {
+func (s *Service) reconcilePersistedFileState(sourcePath, relPath string, info os.FileInfo, destination string) error {
 	s.mu.RLock()
 	store := s.stateStore
 	project := s.project
@@ -988,10 +1983,10 @@ func (s *Service) reconcilePersistedFileState(sourcePath, relPath string, info o
 	}
 
 	var errs []error
-	if err := store.MarkFileCopied(project, relPath, info.Size(), info.ModTime()); err != nil {
+	if err := store.MarkFileCopiedTo(project, relPath, info.Size(), info.ModTime(), destination); err != nil {
 		errs = append(errs, err)
 	}
-	if err := s.trackCaptureCompletion(filepath.Base(sourcePath), ""); err != nil {
+	if _, err := s.trackCaptureFile(filepath.Base(sourcePath), "", destination, copyTiming{modTime: info.ModTime()}); err != nil {
 		errs = append(errs, err)
 	}
 
@@ -1002,52 +1997,88 @@ func (s *Service) reconcilePersistedFileState(sourcePath, relPath string, info o
 	return nil
 }
 
+// notifyCaptureCompleted emits a capture_completed event for the capture
+// that filename belongs to.
+func (s *Service) notifyCaptureCompleted(filename string) {
+	info := ParseCaptureFile(filename)
+	if info == nil {
+		return
+	}
+
+	s.mu.RLock()
+	project := s.project
+	destination := s.destination
+	s.mu.RUnlock()
+
+	key := i18n.EventCaptureCompleted
+	if info.IsTest {
+		key = i18n.EventTestCaptureCompleted
+	}
+
+	s.emitEvent(models.SyncEvent{
+		Type:        models.SyncEventCaptureCompleted,
+		Project:     project,
+		Destination: destination,
+		Capture:     info.CaptureNumber,
+		Message:     s.messages.Sprintf(key, info.CaptureNumber),
+	})
+	s.enqueuePostProcessing(info)
+	s.queueCaptureMove(info)
+}
+
 func (s *Service) trackCaptureCompletion(filename, node string) error {
-	_, err := s.trackCaptureCompletionStatus(filename, node)
+	_, err := s.trackCaptureCompletionStatus(filename, node, "")
 	return err
 }
 
-func (s *Service) trackCaptureCompletionStatus(filename, node string) (bool, error) {
+// trackCaptureCompletionStatus records a capture file; destination, when
+// known, is the directory the file was copied into.
+func (s *Service) trackCaptureCompletionStatus(filename, node, destination string) (bool, error) {
+	return s.trackCaptureFile(filename, node, destination, copyTiming{})
+}
+
+// copyTiming is when a capture file was written and copied, kept per capture
+// in the state store to measure how far the sync lags behind acquisition.
+type copyTiming struct {
+	modTime  time.Time // Source mtime
+	copiedAt time.Time // Zero when the file was already on the destination
+	transfer time.Duration
+}
+
+// trackCaptureFile is trackCaptureCompletionStatus with the file's timing.
+func (s *Service) trackCaptureFile(filename, node, destination string, timing copyTiming) (bool, error) {
 	if len(s.requiredSensors) == 0 {
 		return false, nil
 	}
 
-	// Try to parse as RAW file first
-	info := parseCaptureFileName(filename)
-	if info == nil {
-		// Try to parse as XML metadata file
-		info = parseMetadataFileName(filename)
-		if info == nil {
-			info = parseRawQvFileName(filename)
-			if info == nil {
-				return false, nil
-			}
-		}
+	info := ParseCaptureFile(filename)
+	if info == nil || info.CaptureNumber == "" {
+		return false, nil
 	}
 
-	if info.CaptureNumber == "" {
-		return false, nil
+	if info.Kind == models.CaptureFileRaw {
+		s.learnNodeSensor(node, strings.TrimSpace(info.SensorCode))
 	}
 
 	s.mu.RLock()
 	project := s.project
 	store := s.stateStore
+	requiredRAWFiles := s.requiredRawFilesLocked()
 	s.mu.RUnlock()
 
 	if store != nil {
-		fileExt := strings.ToLower(filepath.Ext(filename))
 		var fileKey string
 
-		switch fileExt {
-		case ".raw":
+		switch info.Kind {
+		case models.CaptureFileRaw:
 			sensorCode := strings.TrimSpace(info.SensorCode)
 			if _, ok := s.requiredSensors[sensorCode]; !ok {
 				return false, nil
 			}
 			fileKey = fmt.Sprintf("raw:%s", sensorCode)
-		case ".xml":
+		case models.CaptureFileMetadata:
 			fileKey = "xml:CU"
-		case ".dat":
+		case models.CaptureFileQuality:
 			fileKey = "dat:CU"
 		default:
 			return false, nil
@@ -1057,9 +2088,14 @@ func (s *Service) trackCaptureCompletionStatus(filename, node string) (bool, err
 			Project:          project,
 			Info:             *info,
 			FileKey:          fileKey,
-			RequiredRawFiles: len(s.requiredSensors),
+			Destination:      destination,
+			Node:             normalizeNodeName(node),
+			RequiredRawFiles: requiredRAWFiles,
 			RequireXML:       true,
 			RequireDAT:       true,
+			FileModTime:      timing.modTime,
+			CopiedAt:         timing.copiedAt,
+			Transfer:         timing.transfer,
 		})
 		if err != nil {
 			return false, err
@@ -1107,24 +2143,34 @@ func (s *Service) trackCaptureCompletionStatus(filename, node string) (bool, err
 	if !exists {
 		fileMap = make(map[string]bool)
 		s.captureTracker[info.CaptureNumber] = fileMap
+		if s.captureMeta == nil {
+			s.captureMeta = make(map[string]*trackedCapture)
+		}
+		s.captureMeta[info.CaptureNumber] = &trackedCapture{
+			project:     project,
+			info:        *info,
+			node:        normalizeNodeName(node),
+			destination: destination,
+			firstSeen:   time.Now(),
+		}
 	}
 
-	// Determine file type based on extension and content
-	fileExt := strings.ToLower(filepath.Ext(filename))
+	// Determine file type from the pattern the name matched
 	var fileKey string
 
-	if fileExt == ".raw" {
+	switch info.Kind {
+	case models.CaptureFileRaw:
 		sensorCode := strings.TrimSpace(info.SensorCode)
 		if _, ok := s.requiredSensors[sensorCode]; !ok {
 			return false, nil
 		}
 		fileKey = fmt.Sprintf("raw:%s", sensorCode)
-	} else if fileExt == ".xml" {
+	case models.CaptureFileMetadata:
 		fileKey = "xml:CU"
-	} else if fileExt == ".dat" {
+	case models.CaptureFileQuality:
 		// RawQv quality data file - optional supplemental file per capture
 		fileKey = "dat:CU"
-	} else {
+	default:
 		return false, nil // Unknown file type
 	}
 
@@ -1149,12 +2195,12 @@ func (s *Service) trackCaptureCompletionStatus(filename, node string) (bool, err
 		}
 	}
 
-	requiredRAWFiles := len(s.requiredSensors)
+	requiredRAWFiles = s.requiredRawFilesLocked()
 
 	log.Debug().
 		Str("capture", info.CaptureNumber).
 		Str("node", normalizeNodeName(node)).
-		Str("file_type", fileExt).
+		Str("file_type", info.Kind).
 		Bool("is_test", info.IsTest).
 		Int("raw_files", rawCount).
 		Bool("has_xml", hasXML).
@@ -1168,7 +2214,7 @@ func (s *Service) trackCaptureCompletionStatus(filename, node string) (bool, err
 	requireDAT := !info.IsTest
 
 	// Check if capture is complete
-	isComplete := rawCount == requiredRAWFiles && (!requireXML || hasXML) && (!requireDAT || hasDAT)
+	isComplete := rawCount >= requiredRAWFiles && (!requireXML || hasXML) && (!requireDAT || hasDAT)
 
 	if isComplete {
 		summary := formatCaptureSummary(rawCount, hasXML, hasDAT)
@@ -1200,6 +2246,7 @@ func (s *Service) trackCaptureCompletionStatus(filename, node string) (bool, err
 		}
 
 		delete(s.captureTracker, info.CaptureNumber)
+		delete(s.captureMeta, info.CaptureNumber)
 		return true, nil
 	}
 
@@ -1260,77 +2307,6 @@ func (s *Service) EnsureDestinationReady(destination string) error {
 	return ensureDestinationReady(destination)
 }
 
-func parseCaptureFileName(filename string) *models.CaptureInfo {
-	matches := captureRegex.FindStringSubmatch(filename)
-	if len(matches) != 7 {
-		return nil
-	}
-
-	dataType := matches[1]
-	captureNumber := matches[2]
-	testMarker := matches[3]
-	projectName := matches[4]
-	sensorCode := matches[5]
-	sessionID := matches[6]
-
-	// Determine if verified: Lvl00 = verified, Lvl0X = unverified
-	isVerified := dataType == "Lvl00"
-
-	return &models.CaptureInfo{
-		DataType:      dataType,
-		CaptureNumber: captureNumber,
-		IsTest:        testMarker != "",
-		ProjectName:   projectName,
-		SensorCode:    sensorCode,
-		SessionID:     sessionID,
-		IsVerified:    isVerified,
-	}
-}
-
-func parseMetadataFileName(filename string) *models.CaptureInfo {
-	matches := metadataRegex.FindStringSubmatch(filename)
-	if len(matches) != 5 {
-		return nil
-	}
-
-	captureNumber := matches[1]
-	testMarker := matches[2]
-	projectName := matches[3]
-	sessionID := matches[4]
-
-	return &models.CaptureInfo{
-		DataType:      "EAD",
-		CaptureNumber: captureNumber,
-		IsTest:        testMarker != "",
-		ProjectName:   projectName,
-		SensorCode:    "",
-		SessionID:     sessionID,
-		IsVerified:    true,
-	}
-}
-
-func isEADMetadataFile(path string) bool {
-	filename := filepath.Base(path)
-	return strings.EqualFold(filepath.Ext(filename), ".xml") && parseMetadataFileName(filename) != nil
-}
-
-func parseRawQvFileName(filename string) *models.CaptureInfo {
-	matches := rawQvRegex.FindStringSubmatch(filename)
-	if len(matches) != 5 {
-		return nil
-	}
-
-	return &models.CaptureInfo{
-		DataType:      "RawQv",
-		CaptureNumber: matches[1],
-		IsTest:        matches[2] != "",
-		ProjectName:   matches[3],
-		SensorCode:    "",
-		SessionID:     matches[4],
-		IsVerified:    true,
-	}
-}
-
 func normalizeNodeName(node string) string {
 	return strings.ToUpper(strings.TrimSpace(node))
 }
@@ -1353,25 +2329,46 @@ func formatCaptureSummary(rawCount int, hasXML, hasDAT bool) string {
 }
 
 func ensureDestinationReady(destination string) error {
-	if !requiresMountedDestination(destination) {
+	mountPoint, ok := ManagedMountPoint(destination)
+	if !ok {
 		return nil
 	}
 
-	mounted, err := isMountPointMounted(defaultDataMountPoint)
+	mounted, err := isMountPointMounted(mountPoint)
 	if err != nil {
-		return fmt.Errorf("failed to check destination mount %s: %w", defaultDataMountPoint, err)
+		return fmt.Errorf("failed to check destination mount %s: %w", mountPoint, err)
 	}
 
 	if !mounted {
-		return fmt.Errorf("destination %s is unavailable: %s is not mounted", destination, defaultDataMountPoint)
+		return fmt.Errorf("destination %s is unavailable: %s is not mounted", destination, mountPoint)
 	}
 
 	return nil
 }
 
 func requiresMountedDestination(destination string) bool {
+	_, ok := ManagedMountPoint(destination)
+	return ok
+}
+
+// ManagedMountPoint returns the data mount point managed by UCXSync that
+// contains destination: the default /ucdata, or /ucdata-<id> for additional
+// destination devices.
+func ManagedMountPoint(destination string) (string, bool) {
 	clean := filepath.ToSlash(pathpkg.Clean(destination))
-	return clean == defaultDataMountPoint || strings.HasPrefix(clean, defaultDataMountPoint+"/")
+	if !strings.HasPrefix(clean, "/") {
+		return "", false
+	}
+
+	top := "/" + strings.SplitN(strings.TrimPrefix(clean, "/"), "/", 2)[0]
+	if top == defaultDataMountPoint {
+		return top, true
+	}
+	if strings.HasPrefix(top, defaultDataMountPoint+"-") && len(top) > len(defaultDataMountPoint)+1 {
+		return top, true
+	}
+
+	return "", false
 }
 
 func isMountPointMounted(mountPoint string) (bool, error) {
@@ -1391,42 +2388,24 @@ func isMountPointMounted(mountPoint string) (bool, error) {
 	return false, nil
 }
 
-func isValidProjectName(name string) bool {
-	excluded := []string{
-		"system volume information", "recycler", "recycled", "$recycle.bin",
-		"logs", "log", "temp", "tmp", "windows", "program files",
-	}
-
-	lower := strings.ToLower(name)
-	for _, ex := range excluded {
-		if lower == ex || strings.HasPrefix(lower, ex+" ") {
-			return false
+// recordFinishedTaskLocked keeps the final state of a task that left
+// activeTasks so status can keep showing it. Must be called with s.mu held.
+func (s *Service) recordFinishedTaskLocked(key string, task *taskInfo, err error) {
+	state := atomic.LoadInt32(&task.state)
+	if state != taskStalled {
+		state = taskDone
+		if err != nil || atomic.LoadInt32(&task.failedFiles) > 0 {
+			state = taskFailed
 		}
+		task.setState(state)
 	}
 
-	if strings.HasPrefix(name, "$") || strings.HasPrefix(name, ".") || len(name) <= 1 {
-		return false
+	snapshot := task.snapshot()
+	if err != nil {
+		snapshot.Error = err.Error()
 	}
-
-	return true
-}
-
-func isExcludedDirectory(name string) bool {
-	excluded := []string{
-		"System Volume Information",
-		"RECYCLER",
-		"RECYCLED",
-		"$RECYCLE.BIN",
-		".git",
-		".svn",
-		"node_modules",
+	if s.finishedTasks == nil {
+		s.finishedTasks = make(map[string]models.SyncTask)
 	}
-
-	for _, ex := range excluded {
-		if strings.EqualFold(name, ex) {
-			return true
-		}
-	}
-
-	return false
+	s.finishedTasks[key] = snapshot
 }
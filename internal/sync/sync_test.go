@@ -1,17 +1,30 @@
 package sync
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/zangezia/UCXSync/internal/state"
+	"github.com/zangezia/UCXSync/internal/storage"
+	"github.com/zangezia/UCXSync/pkg/models"
 )
 
 type copiedFileProcessorStub struct {
@@ -104,6 +117,9 @@ func TestRequiresMountedDestination(t *testing.T) {
 	}{
 		{path: "/ucdata", expected: true},
 		{path: "/ucdata/project", expected: true},
+		{path: "/ucdata-FLIGHT2/project", expected: true},
+		{path: "/ucdata-", expected: false},
+		{path: "/ucdatax", expected: false},
 		{path: "/media/usb", expected: false},
 		{path: "/tmp/output", expected: false},
 	}
@@ -132,6 +148,86 @@ func TestParseRawQvFileName(t *testing.T) {
 	}
 }
 
+func TestSetFileNamePatterns(t *testing.T) {
+	// Not parallel: the patterns are shared by the whole package.
+	defer SetFileNamePatterns(DefaultFileNamePatterns())
+
+	err := SetFileNamePatterns(FileNamePatterns{
+		Raw:      `^(?P<project>[A-Za-z0-9]+)_(?P<capture>\d+)(?P<test>T)?_S(?P<sensor>\d+)\.raw$`,
+		Metadata: `^(?P<project>[A-Za-z0-9]+)_(?P<capture>\d+)(?P<test>T)?\.xml$`,
+	})
+	if err != nil {
+		t.Fatalf("SetFileNamePatterns() error = %v", err)
+	}
+
+	info := parseCaptureFileName("Osprey_42T_S3.raw")
+	if info == nil {
+		t.Fatal("expected RAW file to be parsed with the custom pattern")
+	}
+	if info.CaptureNumber != "00042" || !info.IsTest || info.ProjectName != "Osprey" || info.SensorCode != "3" {
+		t.Fatalf("parsed %+v", *info)
+	}
+	if !info.IsVerified || info.DataType != "RAW" {
+		t.Fatalf("IsVerified = %v, DataType = %q, want verified RAW without a level group", info.IsVerified, info.DataType)
+	}
+	if !isEADMetadataFile("WU01/Osprey_42.xml") {
+		t.Fatal("expected XML file to be metadata under the custom pattern")
+	}
+	if parseCaptureFileName("Lvl00-00001-ProjA-00-00-ABC.raw") != nil {
+		t.Fatal("default RAW name parsed under the custom pattern")
+	}
+	// Quality was left empty and keeps its default.
+	if parseRawQvFileName("RawQv-00002-GT3-B531D783.dat") == nil {
+		t.Fatal("expected the default quality pattern to be kept")
+	}
+
+	if err := SetFileNamePatterns(FileNamePatterns{Raw: `^(?P<capture>\d+)\.raw$`}); err == nil || !strings.Contains(err.Error(), "sensor") {
+		t.Fatalf("SetFileNamePatterns() without a sensor group error = %v", err)
+	}
+	if parseCaptureFileName("Osprey_42T_S3.raw") == nil {
+		t.Fatal("a rejected pattern replaced the configured one")
+	}
+}
+
+func TestTrackCaptureCompletionWithCustomExtensions(t *testing.T) {
+	// Not parallel: the patterns are shared by the whole package.
+	defer SetFileNamePatterns(DefaultFileNamePatterns())
+
+	err := SetFileNamePatterns(FileNamePatterns{
+		Raw:      `^(?P<project>[A-Za-z0-9]+)_(?P<capture>\d+)_(?P<sensor>\d+-\d+)\.tif$`,
+		Metadata: `^(?P<project>[A-Za-z0-9]+)_(?P<capture>\d+)\.json$`,
+		Quality:  `^(?P<project>[A-Za-z0-9]+)_(?P<capture>\d+)\.qv$`,
+	})
+	if err != nil {
+		t.Fatalf("SetFileNamePatterns() error = %v", err)
+	}
+
+	if !isEADMetadataFile("CU/Osprey_7.json") {
+		t.Fatal("expected the metadata file to be recognised by its pattern")
+	}
+	metadata, rest := splitMetadata([]string{"WU01/Osprey_7_00-00.tif", "CU/Osprey_7.json"})
+	if len(metadata) != 1 || len(rest) != 1 {
+		t.Fatalf("splitMetadata() = %v, %v, want the json in the priority lane", metadata, rest)
+	}
+
+	svc := New([]string{"WU01", "CU"}, []string{"E$"}, "/ucmount")
+	for _, sensorCode := range requiredSensorCodes {
+		svc.trackCaptureCompletion(fmt.Sprintf("Osprey_7_%s.tif", sensorCode), "WU01")
+	}
+	svc.trackCaptureCompletion("Osprey_7.json", "CU")
+	svc.trackCaptureCompletion("Osprey_7.qv", "CU")
+
+	if got := atomic.LoadInt32(&svc.completedCaptures); got != 1 {
+		t.Fatalf("completedCaptures = %d, want 1", got)
+	}
+	svc.mu.RLock()
+	learned := len(svc.nodeSensors["WU01"])
+	svc.mu.RUnlock()
+	if learned != len(requiredSensorCodes) {
+		t.Fatalf("learned %d sensors of WU01, want %d from the tif files", learned, len(requiredSensorCodes))
+	}
+}
+
 func TestParseCaptureFileNameWithTestMarkerAndUnderscoreProject(t *testing.T) {
 	info := parseCaptureFileName("Lvl0X-00001-T-Test_6-00-00-E55452A3_7F5A_4E6C_A049_945BF67F9D17.raw")
 	if info == nil {
@@ -922,3 +1018,4091 @@ func TestCopyFileInvokesProcessorWhenNonEADCompletesCapture(t *testing.T) {
 		t.Fatalf("completion-trigger event = %q, want RawQv file path", processor.events[1].RelativePath)
 	}
 }
+
+type eventNotifierStub struct {
+	mu     sync.Mutex
+	events []models.SyncEvent
+}
+
+func (s *eventNotifierStub) NotifySyncEvent(event models.SyncEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = append(s.events, event)
+}
+
+func TestStopEmitsSyncStoppedEventWithTotals(t *testing.T) {
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	notifier := &eventNotifierStub{}
+	svc.SetEventNotifier(notifier)
+
+	_, cancel := context.WithCancel(context.Background())
+	svc.mu.Lock()
+	svc.isRunning = true
+	svc.project = "ProjA"
+	svc.destination = "/tmp/dest"
+	svc.cancel = cancel
+	svc.startedAt = time.Now().Add(-time.Minute)
+	svc.mu.Unlock()
+	atomic.StoreInt32(&svc.runCopiedFiles, 3)
+	atomic.StoreInt32(&svc.runFailedFiles, 1)
+	atomic.StoreInt64(&svc.runCopiedBytes, 1024)
+
+	svc.Stop()
+
+	if len(notifier.events) != 1 {
+		t.Fatalf("len(events) = %d, want 1", len(notifier.events))
+	}
+	event := notifier.events[0]
+	if event.Type != models.SyncEventStopped {
+		t.Fatalf("event.Type = %q, want %q", event.Type, models.SyncEventStopped)
+	}
+	if event.Totals == nil || event.Totals.CopiedFiles != 3 || event.Totals.FailedFiles != 1 || event.Totals.CopiedBytes != 1024 {
+		t.Fatalf("event.Totals = %+v, want 3 copied / 1 failed / 1024 bytes", event.Totals)
+	}
+	if event.Totals.DurationSeconds < 59 {
+		t.Fatalf("event.Totals.DurationSeconds = %f, want at least 59", event.Totals.DurationSeconds)
+	}
+}
+
+func TestStopAfterLostDestinationEmitsSyncFailedEvent(t *testing.T) {
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	notifier := &eventNotifierStub{}
+	svc.SetEventNotifier(notifier)
+
+	_, cancel := context.WithCancel(context.Background())
+	svc.mu.Lock()
+	svc.isRunning = true
+	svc.project = "ProjA"
+	svc.destination = "/tmp/dest"
+	svc.cancel = cancel
+	svc.mu.Unlock()
+
+	svc.hardPause("destination unmounted")
+	svc.Stop()
+
+	if len(notifier.events) != 2 || notifier.events[0].Type != models.SyncEventDestinationLost {
+		t.Fatalf("events = %+v, want destination_lost then the end of the run", notifier.events)
+	}
+	event := notifier.events[1]
+	if event.Type != models.SyncEventFailed || event.Reason != "destination unmounted" || event.Totals == nil {
+		t.Fatalf("event = %+v, want sync_failed with the pause reason and totals", event)
+	}
+}
+
+func TestStartFailureEmitsSyncFailedEvent(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	blocker := filepath.Join(baseDir, "blocker")
+	if err := os.WriteFile(blocker, []byte("x"), 0644); err != nil {
+		t.Fatalf("failed to write blocker file: %v", err)
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	notifier := &eventNotifierStub{}
+	svc.SetEventNotifier(notifier)
+
+	if err := svc.Start(context.Background(), "ProjA", blocker, 1, false); err == nil {
+		t.Fatal("expected Start to fail when destination is a regular file")
+	}
+
+	if len(notifier.events) != 1 {
+		t.Fatalf("len(events) = %d, want 1", len(notifier.events))
+	}
+	if notifier.events[0].Type != models.SyncEventFailed {
+		t.Fatalf("event.Type = %q, want %q", notifier.events[0].Type, models.SyncEventFailed)
+	}
+	if notifier.events[0].Reason == "" {
+		t.Fatal("expected failure reason to be populated")
+	}
+}
+
+func TestFindMountForPathPrefersLongestMountPoint(t *testing.T) {
+	procMounts := "/dev/mmcblk0p2 / ext4 rw 0 0\n/dev/sda1 /ucdata exfat rw 0 0\n/dev/sdb1 /media/usb\\040disk ntfs rw 0 0\n"
+
+	testCases := []struct {
+		path       string
+		mountPoint string
+		device     string
+	}{
+		{path: "/ucdata/2025-01-01/ProjA", mountPoint: "/ucdata", device: "/dev/sda1"},
+		{path: "/ucdata-other", mountPoint: "/", device: "/dev/mmcblk0p2"},
+		{path: "/media/usb disk/ProjA", mountPoint: "/media/usb disk", device: "/dev/sdb1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			mount := findMountForPath(procMounts, tc.path)
+			if mount.MountPoint != tc.mountPoint || mount.Device != tc.device {
+				t.Fatalf("findMountForPath(%q) = %+v, want %s on %s", tc.path, mount, tc.device, tc.mountPoint)
+			}
+		})
+	}
+}
+
+func TestCopyFileHardPausesWhenDestinationUnmounted(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	sourceRoot := filepath.Join(baseDir, "source")
+	destRoot := filepath.Join(baseDir, "dest")
+	if err := os.MkdirAll(sourceRoot, 0755); err != nil {
+		t.Fatalf("failed to create source root: %v", err)
+	}
+	sourcePath := filepath.Join(sourceRoot, "file.raw")
+	if err := os.WriteFile(sourcePath, []byte("payload"), 0644); err != nil {
+		t.Fatalf("failed to write source file: %v", err)
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	notifier := &eventNotifierStub{}
+	svc.SetEventNotifier(notifier)
+	svc.mu.Lock()
+	svc.isRunning = true
+	svc.destination = destRoot
+	svc.destinationMount = mountInfo{MountPoint: destRoot, Device: "/dev/sdz1"}
+	svc.resolveMount = func(string) (mountInfo, error) {
+		return mountInfo{MountPoint: "/", Device: "/dev/root"}, nil
+	}
+	svc.globalSemaphore = make(chan struct{}, 1)
+	svc.mu.Unlock()
+
+	task := &taskInfo{node: "WU01", share: "E$"}
+	err := svc.copyFile(context.Background(), task, sourcePath, sourceRoot, destRoot)
+	if !errors.Is(err, ErrDestinationUnmounted) {
+		t.Fatalf("copyFile error = %v, want ErrDestinationUnmounted", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(destRoot, "file.raw")); !os.IsNotExist(statErr) {
+		t.Fatalf("expected no file to be written under the vanished mount point, stat err = %v", statErr)
+	}
+
+	svc.hardPause(err.Error())
+
+	status := svc.GetStatus()
+	if !status.Paused || status.PauseReason == "" {
+		t.Fatalf("status paused = %v reason = %q, want paused with reason", status.Paused, status.PauseReason)
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Type != models.SyncEventDestinationLost {
+		t.Fatalf("events = %+v, want one destination_lost event", notifier.events)
+	}
+
+	if err := svc.Resume(); !errors.Is(err, ErrDestinationUnmounted) {
+		t.Fatalf("Resume error = %v, want ErrDestinationUnmounted while still unmounted", err)
+	}
+}
+
+func TestPauseKeepsInFlightCopiesAndResumeContinues(t *testing.T) {
+	t.Parallel()
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	if err := svc.Pause(); err == nil {
+		t.Fatal("Pause succeeded with no job running")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	svc.mu.Lock()
+	svc.isRunning = true
+	svc.destination = t.TempDir()
+	svc.activeTasks["WU01-E$"] = &taskInfo{node: "WU01", share: "E$", cancel: cancel}
+	svc.mu.Unlock()
+
+	if err := svc.Pause(); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	status := svc.GetStatus()
+	if !status.Paused || status.PauseReason != PauseReasonOperator {
+		t.Fatalf("status paused = %v reason = %q, want paused by operator", status.Paused, status.PauseReason)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("Pause cancelled the copy in flight")
+	}
+	if err := svc.Pause(); err != nil {
+		t.Fatalf("second Pause: %v", err)
+	}
+
+	// Copies may be replaced by hand while the job is paused.
+	svc.destIndex.remember("/dest/file.raw", destIndexEntry{size: 1, modTime: time.Now()})
+	if err := svc.Resume(); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if svc.destIndex.entries != nil {
+		t.Fatal("Resume kept the destination index")
+	}
+	if status := svc.GetStatus(); status.Paused || status.PauseReason != "" || !status.IsRunning {
+		t.Fatalf("status after Resume = paused %v reason %q running %v, want a running job", status.Paused, status.PauseReason, status.IsRunning)
+	}
+}
+
+func TestDiskSpaceForecastReportsShortfall(t *testing.T) {
+	t.Parallel()
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetDiskSpaceThresholds(100, 50)
+	svc.diskUsage = func(path string) (*disk.UsageStat, error) {
+		return &disk.UsageStat{Free: 1150}, nil
+	}
+	svc.isRunning = true
+	svc.destination = "/ucdata"
+	svc.activeTasks["WU01-E$"] = &taskInfo{totalBytes: 1500, copiedBytes: 300}
+
+	forecast, err := svc.DiskSpaceForecast()
+	if err != nil {
+		t.Fatalf("DiskSpaceForecast() error = %v", err)
+	}
+	if forecast == nil {
+		t.Fatal("expected forecast for running sync")
+	}
+	if forecast.RemainingBytes != 1200 {
+		t.Fatalf("RemainingBytes = %d, want 1200", forecast.RemainingBytes)
+	}
+	if forecast.MarginBytes != -200 || !forecast.Shortfall {
+		t.Fatalf("MarginBytes = %d, Shortfall = %v, want -200 and true", forecast.MarginBytes, forecast.Shortfall)
+	}
+}
+
+func TestBuildDiskSpaceForecastEstimatesHoursUntilFull(t *testing.T) {
+	t.Parallel()
+
+	forecast := buildDiskSpaceForecast(0, 3600*1000+100, 100, 3600*1000, time.Hour)
+	if forecast.Shortfall {
+		t.Fatal("did not expect shortfall with no remaining bytes")
+	}
+	if forecast.HoursUntilFull < 0.99 || forecast.HoursUntilFull > 1.01 {
+		t.Fatalf("HoursUntilFull = %f, want ~1", forecast.HoursUntilFull)
+	}
+}
+
+func TestBuildSyncPacingProjectsFinishAgainstDeadline(t *testing.T) {
+	t.Parallel()
+
+	started := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	now := started.Add(time.Hour)
+	deadline := started.Add(4 * time.Hour)
+
+	// 1 MB/s so far; 3 hours left for the rest.
+	pacing := buildSyncPacing(2*3600<<20, 3600<<20, started, deadline, now)
+	if !pacing.OnTrack || pacing.ProjectedFinish == nil || !pacing.ProjectedFinish.Equal(started.Add(3*time.Hour)) {
+		t.Fatalf("pacing = %+v, want on track to finish at 11:00", pacing)
+	}
+	pacing = buildSyncPacing(4*3600<<20, 3600<<20, started, deadline, now)
+	if pacing.OnTrack || pacing.RequiredBytesPerSec < 1.33*(1<<20) || pacing.RequiredBytesPerSec > 1.34*(1<<20) {
+		t.Fatalf("pacing = %+v, want behind with 1.33 MB/s needed", pacing)
+	}
+	if pacing := buildSyncPacing(1, 0, started, deadline, deadline); pacing.OnTrack {
+		t.Fatalf("pacing = %+v, want behind when nothing was copied by the deadline", pacing)
+	}
+}
+
+func TestCheckPacingAlertsOnceAndClearsWhenBackOnTrack(t *testing.T) {
+	t.Parallel()
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	notifier := &eventNotifierStub{}
+	svc.SetEventNotifier(notifier)
+	svc.SetPacing(PacingOptions{Target: 2 * time.Hour, Grace: time.Minute})
+	svc.isRunning = true
+	svc.startedAt = time.Now().Add(-time.Hour)
+	task := &taskInfo{totalBytes: 10 << 30}
+	svc.activeTasks["WU01-E$"] = task
+	atomic.StoreInt64(&svc.runCopiedBytes, 1<<30)
+
+	svc.checkPacing()
+	svc.checkPacing()
+	if len(notifier.events) != 1 || notifier.events[0].Type != models.SyncEventPacingBehind {
+		t.Fatalf("events = %+v, want one pacing_behind", notifier.events)
+	}
+
+	atomic.StoreInt64(&task.copiedBytes, 10<<30)
+	svc.checkPacing()
+	if len(notifier.events) != 2 || notifier.events[1].Type != models.SyncEventPacingOnTrack {
+		t.Fatalf("events = %+v, want pacing_on_track after catching up", notifier.events)
+	}
+}
+
+func TestRecoverStalledTasksCancelsIdleTasks(t *testing.T) {
+	t.Parallel()
+
+	svc := New([]string{"WU01", "WU02"}, []string{"E$"}, "/ucmount")
+	svc.SetStallTimeout(time.Minute)
+	notifier := &eventNotifierStub{}
+	svc.SetEventNotifier(notifier)
+
+	now := time.Now()
+	stalledCtx, stalledCancel := context.WithCancel(context.Background())
+	defer stalledCancel()
+	svc.activeTasks["WU01-E$"] = newTaskInfo("WU01", "E$", stalledCancel, now.Add(-2*time.Minute))
+	svc.activeTasks["WU02-E$"] = newTaskInfo("WU02", "E$", func() {}, now.Add(-10*time.Second))
+
+	keys := svc.recoverStalledTasks(now)
+	if len(keys) != 1 || keys[0] != "WU01-E$" {
+		t.Fatalf("recoverStalledTasks() = %v, want [WU01-E$]", keys)
+	}
+	if stalledCtx.Err() == nil {
+		t.Fatal("expected stalled task context to be cancelled")
+	}
+	if _, exists := svc.activeTasks["WU01-E$"]; exists {
+		t.Fatal("expected stalled task to be removed so it can be restarted")
+	}
+	if _, exists := svc.activeTasks["WU02-E$"]; !exists {
+		t.Fatal("expected active task to be kept")
+	}
+
+	if len(notifier.events) != 1 || notifier.events[0].Type != models.SyncEventTaskStalled {
+		t.Fatalf("events = %+v, want one task_stalled event", notifier.events)
+	}
+}
+
+// slowChunkSource delivers each copyChunk of a file only after delay, like
+// a slow SMB1 share.
+type slowChunkSource struct {
+	mapSource
+	delay time.Duration
+}
+
+type slowChunkFile struct {
+	storage.File
+	delay time.Duration
+	read  int64
+}
+
+func (f *slowChunkFile) Read(p []byte) (int, error) {
+	if f.read%copyChunk == 0 {
+		time.Sleep(f.delay)
+	}
+	if rest := copyChunk - f.read%copyChunk; int64(len(p)) > rest {
+		p = p[:rest]
+	}
+	n, err := f.File.Read(p)
+	f.read += int64(n)
+	return n, err
+}
+
+func (s slowChunkSource) Open(path string) (storage.File, error) {
+	file, err := s.mapSource.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &slowChunkFile{File: file, delay: s.delay}, nil
+}
+
+func TestSlowCopyPastStallTimeoutIsNotCancelled(t *testing.T) {
+	t.Parallel()
+
+	const stallTimeout = 250 * time.Millisecond
+	destRoot := t.TempDir()
+	payload := bytes.Repeat([]byte{7}, 4*copyChunk)
+	fsys := fstest.MapFS{"ucmount/WU01/E/Project/big.raw": {Data: payload, ModTime: time.Now()}}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetStallTimeout(stallTimeout)
+	svc.SetStorage(slowChunkSource{mapSource: mapSource{fsys: fsys}, delay: stallTimeout / 2}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	task := newTaskInfo("WU01", "E$", cancel, time.Now())
+	svc.activeTasks["WU01-E$"] = task
+
+	done := make(chan error, 1)
+	started := time.Now()
+	go func() {
+		done <- svc.copyFile(ctx, task, "/ucmount/WU01/E/Project/big.raw", "/ucmount/WU01/E/Project", destRoot)
+	}()
+	ticker := time.NewTicker(stallTimeout / 10)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("copyFile() error = %v", err)
+			}
+			if elapsed := time.Since(started); elapsed < stallTimeout {
+				t.Fatalf("copy took %v, want longer than the stall timeout", elapsed)
+			}
+			return
+		case now := <-ticker.C:
+			if stalled := svc.recoverStalledTasks(now); len(stalled) > 0 {
+				t.Fatalf("recoverStalledTasks() = %v while the copy was moving data", stalled)
+			}
+		}
+	}
+}
+
+func TestTaskWaitingForCopySlotIsNotStalled(t *testing.T) {
+	t.Parallel()
+
+	const stallTimeout = 200 * time.Millisecond
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetStallTimeout(stallTimeout)
+	task := newTaskInfo("WU01", "E$", func() {}, time.Now())
+	svc.activeTasks["WU01-E$"] = task
+
+	stop := svc.keepTaskActive(task)
+	time.Sleep(2 * stallTimeout)
+	if stalled := svc.recoverStalledTasks(time.Now()); len(stalled) > 0 {
+		t.Fatalf("recoverStalledTasks() = %v for a task waiting for a slot", stalled)
+	}
+	stop()
+}
+
+func TestSyncDirectoryRecordsScanMetrics(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	sourceRoot := filepath.Join(baseDir, "source")
+	destRoot := filepath.Join(baseDir, "dest")
+	if err := os.MkdirAll(sourceRoot, 0755); err != nil {
+		t.Fatalf("MkdirAll(source) error = %v", err)
+	}
+	if err := os.MkdirAll(destRoot, 0755); err != nil {
+		t.Fatalf("MkdirAll(dest) error = %v", err)
+	}
+	for name, content := range map[string]string{"a.txt": "abc", "b.txt": "defgh"} {
+		if err := os.WriteFile(filepath.Join(sourceRoot, name), []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", name, err)
+		}
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.globalSemaphore = make(chan struct{}, 1)
+	task := &taskInfo{node: "WU01", share: "E$"}
+
+	if err := svc.syncDirectory(context.Background(), task, sourceRoot, destRoot); err != nil {
+		t.Fatalf("syncDirectory() error = %v", err)
+	}
+
+	metrics := svc.ScanMetrics()
+	if len(metrics) != 1 {
+		t.Fatalf("len(ScanMetrics()) = %d, want 1", len(metrics))
+	}
+	got := metrics[0]
+	if got.Node != "WU01" || got.Share != "E$" {
+		t.Fatalf("scan metrics node/share = %s/%s, want WU01/E$", got.Node, got.Share)
+	}
+	if got.FilesExamined != 2 || got.FilesQueued != 2 || got.BytesQueued != 8 {
+		t.Fatalf("scan metrics = %+v, want 2 examined, 2 queued, 8 bytes", got)
+	}
+}
+
+func TestCheckDestinationRolloverAutoSelectsNextDestination(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	first := filepath.Join(baseDir, "first")
+	second := filepath.Join(baseDir, "second")
+	for _, dir := range []string{first, second} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("MkdirAll(%s) error = %v", dir, err)
+		}
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	notifier := &eventNotifierStub{}
+	svc.SetEventNotifier(notifier)
+	svc.SetRolloverPolicy(90, true)
+	svc.SetDestinationCandidates(func() []models.DestinationInfo {
+		return []models.DestinationInfo{
+			{Path: first, FreeSpaceGB: 5, TotalGB: 100},
+			{Path: second, FreeSpaceGB: 80, TotalGB: 100},
+		}
+	})
+	svc.diskUsage = func(path string) (*disk.UsageStat, error) {
+		return &disk.UsageStat{UsedPercent: 95}, nil
+	}
+	svc.resolveMount = func(string) (mountInfo, error) { return mountInfo{}, nil }
+	svc.isRunning = true
+	svc.project = "ProjA"
+	svc.destination = first
+
+	if !svc.checkDestinationRollover() {
+		t.Fatal("expected roll-over to skip the current iteration")
+	}
+
+	status := svc.GetStatus()
+	if status.Destination != second {
+		t.Fatalf("Destination = %q, want %q", status.Destination, second)
+	}
+	if status.Paused {
+		t.Fatal("expected auto-selected roll-over to resume synchronization")
+	}
+	if _, err := os.Stat(svc.currentDestDir("")); err != nil {
+		t.Fatalf("expected new destination directory to exist: %v", err)
+	}
+
+	if len(notifier.events) != 2 ||
+		notifier.events[0].Type != models.SyncEventDestinationFull ||
+		notifier.events[1].Type != models.SyncEventDestinationSwitched {
+		t.Fatalf("events = %+v, want destination_full then destination_switched", notifier.events)
+	}
+}
+
+func TestSelectRolloverDestinationSkipsCurrentAndFullDevices(t *testing.T) {
+	t.Parallel()
+
+	candidates := []models.DestinationInfo{
+		{Path: "/ucdata", FreeSpaceGB: 900, TotalGB: 1000},
+		{Path: "/ucdata-B", FreeSpaceGB: 2, TotalGB: 1000},
+		{Path: "/ucdata-C", FreeSpaceGB: 300, TotalGB: 500},
+	}
+
+	if got := selectRolloverDestination("/ucdata", 95, candidates); got != "/ucdata-C" {
+		t.Fatalf("selectRolloverDestination() = %q, want /ucdata-C", got)
+	}
+	if got := selectRolloverDestination("/ucdata-C", 95, candidates[1:]); got != "" {
+		t.Fatalf("selectRolloverDestination() = %q, want no candidate", got)
+	}
+}
+
+func TestFilesystemProfileSanitizesWindowsNames(t *testing.T) {
+	t.Parallel()
+
+	fat := profileForFilesystem("vfat")
+	if fat.MaxFileSize != fat32MaxFileSize {
+		t.Fatalf("FAT32 MaxFileSize = %d, want %d", fat.MaxFileSize, int64(fat32MaxFileSize))
+	}
+	if got := fat.destinationRelPath("Cap:01/a?b.raw"); got != "Cap_01/a_b.raw" {
+		t.Fatalf("destinationRelPath() = %q, want Cap_01/a_b.raw", got)
+	}
+	if got := fat.destinationRelPath("dir./file "); got != "dir_/file_" {
+		t.Fatalf("destinationRelPath() = %q, want dir_/file_", got)
+	}
+
+	ext4 := profileForFilesystem("ext4")
+	if got := ext4.destinationRelPath("Cap:01/a?b.raw"); got != "Cap:01/a?b.raw" {
+		t.Fatalf("ext4 destinationRelPath() = %q, want unchanged", got)
+	}
+	if ext4.MaxFileSize != 0 {
+		t.Fatalf("ext4 MaxFileSize = %d, want no limit", ext4.MaxFileSize)
+	}
+}
+
+func TestCopyFileRejectsFilesLargerThanFAT32Limit(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	sourceRoot := filepath.Join(baseDir, "source")
+	destRoot := filepath.Join(baseDir, "dest")
+	if err := os.MkdirAll(sourceRoot, 0755); err != nil {
+		t.Fatalf("MkdirAll(source) error = %v", err)
+	}
+
+	// Sparse file just above the FAT32 limit; it is rejected before any data is read.
+	sourcePath := filepath.Join(sourceRoot, "big.raw")
+	file, err := os.Create(sourcePath)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := file.Truncate(fat32MaxFileSize + 1); err != nil {
+		file.Close()
+		t.Skipf("sparse files not supported: %v", err)
+	}
+	file.Close()
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.destinationMount = mountInfo{MountPoint: "/", FSType: "vfat"}
+
+	task := &taskInfo{node: "WU01", share: "E$"}
+	err = svc.copyFile(context.Background(), task, sourcePath, sourceRoot, destRoot)
+	if !errors.Is(err, ErrFileTooLargeForDestination) {
+		t.Fatalf("copyFile() error = %v, want ErrFileTooLargeForDestination", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(destRoot, "big.raw")); !os.IsNotExist(statErr) {
+		t.Fatalf("expected no destination file to be created, stat error = %v", statErr)
+	}
+}
+
+func TestFileTooLargeForDestinationIsQuarantinedAtOnce(t *testing.T) {
+	t.Parallel()
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	notifier := &eventNotifierStub{}
+	svc.SetEventNotifier(notifier)
+
+	task := &taskInfo{node: "WU01", share: "E$"}
+	source := "/ucmount/WU01/E/ProjA"
+	filePath := source + "/big.raw"
+	err := fmt.Errorf("%w: big.raw is too big", ErrFileTooLargeForDestination)
+	svc.handleCopyError(context.Background(), task, filePath, source, err)
+
+	if !svc.isQuarantined(filePath) {
+		t.Fatal("file too large for the destination was not quarantined")
+	}
+	if svc.retryQueued(filePath) {
+		t.Fatal("file too large for the destination was queued for a retry")
+	}
+	if got := atomic.LoadInt32(&task.failedFiles); got != 0 {
+		t.Fatalf("task failedFiles = %d, want 0", got)
+	}
+	if got := atomic.LoadInt32(&svc.runFailedFiles); got != 0 {
+		t.Fatalf("runFailedFiles = %d, want 0", got)
+	}
+
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	if len(notifier.events) != 1 || notifier.events[0].Type != models.SyncEventDestinationIncompatible {
+		t.Fatalf("events = %+v, want one destination_incompatible event", notifier.events)
+	}
+}
+
+func TestEstimateRemainingBytesSumsProjectFilesAcrossShares(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	writeFile := func(rel string, size int) {
+		t.Helper()
+		path := filepath.Join(baseDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	writeFile("WU01/E/ProjA/a.raw", 100)
+	writeFile("WU01/E/ProjA/sub/b.raw", 50)
+	writeFile("WU02/F/ProjA/c.raw", 25)
+	writeFile("WU02/F/Other/d.raw", 1000)
+
+	svc := &Service{
+		nodes:        []string{"WU01", "WU02"},
+		shares:       []string{"E$", "F$"},
+		baseMountDir: baseDir,
+	}
+
+	total, err := svc.EstimateRemainingBytes(context.Background(), "ProjA", false)
+	if err != nil {
+		t.Fatalf("EstimateRemainingBytes() error = %v", err)
+	}
+	if total != 175 {
+		t.Fatalf("EstimateRemainingBytes() = %d, want 175", total)
+	}
+}
+
+// Run with -race: the status and watchdog paths read task progress while copy
+// goroutines update it.
+func TestGetStatusIsRaceFreeDuringCopy(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	sourceRoot := filepath.Join(baseDir, "source")
+	destRoot := filepath.Join(baseDir, "dest")
+	if err := os.MkdirAll(sourceRoot, 0755); err != nil {
+		t.Fatalf("MkdirAll(source) error = %v", err)
+	}
+	if err := os.MkdirAll(destRoot, 0755); err != nil {
+		t.Fatalf("MkdirAll(dest) error = %v", err)
+	}
+	for i := 0; i < 32; i++ {
+		name := filepath.Join(sourceRoot, fmt.Sprintf("file%02d.txt", i))
+		if err := os.WriteFile(name, []byte("payload"), 0644); err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", name, err)
+		}
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.globalSemaphore = make(chan struct{}, 4)
+	task := newTaskInfo("WU01", "E$", func() {}, time.Now())
+	svc.mu.Lock()
+	svc.activeTasks["WU01-E$"] = task
+	svc.mu.Unlock()
+
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				_ = svc.GetStatus()
+				_ = svc.recoverStalledTasks(time.Now())
+			}
+		}()
+	}
+
+	err := svc.syncDirectory(context.Background(), task, sourceRoot, destRoot)
+	close(done)
+	readers.Wait()
+	if err != nil {
+		t.Fatalf("syncDirectory() error = %v", err)
+	}
+
+	got := task.snapshot()
+	if got.CopiedFiles != 32 || got.CopiedBytes != 32*int64(len("payload")) || got.Progress != 100 {
+		t.Fatalf("snapshot = %+v, want all 32 files copied", got)
+	}
+}
+
+func TestTaskInfoTouchUpdatesLastActive(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	task := newTaskInfo("WU01", "E$", nil, start)
+	if !task.lastActive().Equal(start) {
+		t.Fatalf("lastActive() = %v, want %v", task.lastActive(), start)
+	}
+
+	later := start.Add(time.Minute)
+	task.touch(later)
+	if got := task.snapshot().LastActivity; !got.Equal(later) {
+		t.Fatalf("snapshot LastActivity = %v, want %v", got, later)
+	}
+}
+
+func TestTaskInfoReportsVerifyingWhileACopyIsChecked(t *testing.T) {
+	t.Parallel()
+
+	task := newTaskInfo("WU01", "E$", nil, time.Now())
+	done := task.verifying()
+	if got := task.snapshot().Status; got != models.TaskStateScanning {
+		t.Fatalf("Status while scanning = %q, want %q", got, models.TaskStateScanning)
+	}
+
+	task.setState(taskCopying)
+	if got := task.snapshot().Status; got != models.TaskStateVerifying {
+		t.Fatalf("Status during a check = %q, want %q", got, models.TaskStateVerifying)
+	}
+	done()
+	if got := task.snapshot().Status; got != models.TaskStateCopying {
+		t.Fatalf("Status after the check = %q, want %q", got, models.TaskStateCopying)
+	}
+}
+
+func TestSyncLoopPicksUpIntervalChange(t *testing.T) {
+	t.Parallel()
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetServiceLoopInterval(time.Hour)
+
+	var iterations int32
+	svc.syncIterationFunc = func(context.Context, string) {
+		atomic.AddInt32(&iterations, 1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	svc.wg.Add(1)
+	go svc.syncLoop(ctx, "/tmp/dest")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt32(&iterations) < 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("syncLoop did not run its first iteration")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	svc.SetServiceLoopInterval(10 * time.Millisecond)
+	if got := svc.ServiceLoopInterval(); got != 10*time.Millisecond {
+		t.Fatalf("ServiceLoopInterval() = %s, want 10ms", got)
+	}
+
+	for atomic.LoadInt32(&iterations) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("iterations = %d after interval change, want at least 3", atomic.LoadInt32(&iterations))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCopyFileHardlinksUnchangedFileFromPreviousSnapshot(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	sourceRoot := filepath.Join(baseDir, "source")
+	previousDir := filepath.Join(baseDir, "dest", "2026-05-01", "ProjA")
+	currentDir := filepath.Join(baseDir, "dest", "2026-05-02", "ProjA")
+	for _, dir := range []string{sourceRoot, previousDir, currentDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("MkdirAll(%s) error = %v", dir, err)
+		}
+	}
+
+	store, err := state.New(filepath.Join(baseDir, "state.db"), "ucxsync-test")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	writeSnapshotFile := func(name, content string, recordInLedger bool) {
+		t.Helper()
+		sourceFile := filepath.Join(sourceRoot, name)
+		if err := os.WriteFile(sourceFile, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile(source) error = %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(previousDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile(previous) error = %v", err)
+		}
+		info, err := os.Stat(sourceFile)
+		if err != nil {
+			t.Fatalf("Stat(source) error = %v", err)
+		}
+		modTime := info.ModTime()
+		if !recordInLedger {
+			modTime = modTime.Add(-time.Hour)
+		}
+		if err := store.MarkFileCopiedTo("ProjA", name, info.Size(), modTime, previousDir); err != nil {
+			t.Fatalf("MarkFileCopiedTo() error = %v", err)
+		}
+	}
+	writeSnapshotFile("unchanged.raw", "same payload", true)
+	writeSnapshotFile("changed.raw", "new payload", false)
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	if err := svc.SetStateStore(store); err != nil {
+		t.Fatalf("SetStateStore() error = %v", err)
+	}
+	svc.mu.Lock()
+	svc.project = "ProjA"
+	svc.linkSources = loadLinkSources(store, "ProjA", currentDir)
+	svc.mu.Unlock()
+
+	task := newTaskInfo("WU01", "E$", nil, time.Now())
+	for _, name := range []string{"unchanged.raw", "changed.raw"} {
+		if err := svc.copyFile(context.Background(), task, filepath.Join(sourceRoot, name), sourceRoot, currentDir); err != nil {
+			t.Fatalf("copyFile(%s) error = %v", name, err)
+		}
+	}
+
+	sameInode := func(name string) bool {
+		t.Helper()
+		prev, err := os.Stat(filepath.Join(previousDir, name))
+		if err != nil {
+			t.Fatalf("Stat(previous %s) error = %v", name, err)
+		}
+		cur, err := os.Stat(filepath.Join(currentDir, name))
+		if err != nil {
+			t.Fatalf("Stat(current %s) error = %v", name, err)
+		}
+		return os.SameFile(prev, cur)
+	}
+	if !sameInode("unchanged.raw") {
+		t.Fatal("unchanged.raw was copied, want hardlink to previous snapshot")
+	}
+	if sameInode("changed.raw") {
+		t.Fatal("changed.raw was hardlinked, want fresh copy")
+	}
+
+	totals := svc.runTotalsLocked()
+	if totals.LinkedFiles != 1 || totals.CopiedFiles != 1 {
+		t.Fatalf("totals = %+v, want 1 linked and 1 copied", totals)
+	}
+	if got := atomic.LoadInt32(&task.copiedFiles); got != 2 {
+		t.Fatalf("task copiedFiles = %d, want 2", got)
+	}
+}
+
+// plainDestination writes through the local file system but declares no
+// optional capability, like a remote driver would.
+type plainDestination struct {
+	storage.DestinationProvider
+}
+
+func TestDestinationWithoutCapabilitiesIsNotLinkedOrReadBack(t *testing.T) {
+	t.Parallel()
+
+	previousDir := t.TempDir()
+	currentDir := t.TempDir()
+	name := "unchanged.raw"
+	if err := os.WriteFile(filepath.Join(previousDir, name), []byte("same payload"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	srcInfo, err := os.Stat(filepath.Join(previousDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetStorage(nil, plainDestination{storage.Local{}})
+	svc.mu.Lock()
+	svc.linkSources = map[string]state.CopiedFile{
+		name: {RelativePath: name, Size: srcInfo.Size(), ModTime: srcInfo.ModTime().UTC(), DestDir: previousDir},
+	}
+	svc.mu.Unlock()
+
+	destPath := filepath.Join(currentDir, name)
+	if svc.linkFromPreviousSnapshot(name, destPath, srcInfo) {
+		t.Fatal("linkFromPreviousSnapshot() linked through a destination without Link")
+	}
+	if sum, err := svc.verifyCopiedChecksum(context.Background(), filepath.Join(previousDir, name), destPath); sum != nil || err != nil {
+		t.Fatalf("verifyCopiedChecksum() = %x, %v, want the check skipped", sum, err)
+	}
+	if _, err := svc.VerifyLastRun(context.Background()); !errors.Is(err, ErrDestinationNotReadable) {
+		t.Fatalf("VerifyLastRun() error = %v, want ErrDestinationNotReadable", err)
+	}
+	svc.SetSpotCheck(SpotCheckOptions{Enabled: true, FullHash: true})
+	svc.rememberSpotCandidate(newTaskInfo("WU01", "E$", nil, time.Now()), filepath.Join(previousDir, name), destPath)
+	svc.runSpotCheck(context.Background(), svc.spotCheck, rand.New(rand.NewSource(1)))
+	if status := svc.GetStatus(); status.SpotChecks != 0 || status.SpotChecksSkipped != 1 {
+		t.Fatalf("spot checks = %d, skipped = %d, want the check skipped, not counted", status.SpotChecks, status.SpotChecksSkipped)
+	}
+	if svc.localDestination() || !svc.localSource() {
+		t.Fatal("expected only the source to work on the local file system")
+	}
+}
+
+func TestNodeMaintenanceExcludesNodeSensorsFromCompleteness(t *testing.T) {
+	t.Parallel()
+
+	store, err := state.New(filepath.Join(t.TempDir(), "state.db"), "ucxsync-test")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	nodes := []string{"WU01", "WU02", "WU03", "WU04", "WU05", "WU06", "WU07", "WU08", "WU09", "WU10", "WU11", "WU12", "WU13", "CU"}
+	svc := New(nodes, []string{"E$"}, "/ucmount")
+	if err := svc.SetStateStore(store); err != nil {
+		t.Fatalf("SetStateStore returned error: %v", err)
+	}
+	if _, err := store.StartRun("Project", "/tmp", 4); err != nil {
+		t.Fatalf("StartRun returned error: %v", err)
+	}
+	svc.mu.Lock()
+	svc.project = "Project"
+	svc.mu.Unlock()
+
+	deliver := func(capture string, skipNode string) {
+		for i, sensorCode := range requiredSensorCodes {
+			if nodes[i] == skipNode {
+				continue
+			}
+			svc.trackCaptureCompletion(fmt.Sprintf("Lvl00-%s-Project-%s-ABCDEF01_2345_6789_ABCD_EF0123456789.raw", capture, sensorCode), nodes[i])
+		}
+		svc.trackCaptureCompletion(fmt.Sprintf("EAD-%s-Project-ABCDEF01_2345_6789_ABCD_EF0123456789.xml", capture), "CU")
+		svc.trackCaptureCompletion(fmt.Sprintf("RawQv-%s-Project-ABCDEF01_2345_6789_ABCD_EF0123456789.dat", capture), "CU")
+	}
+
+	deliver("00001", "")
+	deliver("00002", "WU05")
+	if got := svc.GetStatus().CompletedCaptures; got != 1 {
+		t.Fatalf("completed captures before maintenance = %d, want 1", got)
+	}
+
+	if err := svc.SetNodeMaintenance("wu05", true, "replacing disk"); err != nil {
+		t.Fatalf("SetNodeMaintenance returned error: %v", err)
+	}
+	deliver("00003", "WU05")
+	if got := svc.GetStatus().CompletedCaptures; got != 2 {
+		t.Fatalf("completed captures with WU05 in maintenance = %d, want 2", got)
+	}
+
+	restored := New(nodes, []string{"E$"}, "/ucmount")
+	if err := restored.SetStateStore(store); err != nil {
+		t.Fatalf("SetStateStore returned error: %v", err)
+	}
+	maintenance := restored.NodesInMaintenance()
+	if len(maintenance) != 1 || maintenance[0].Node != "WU05" || maintenance[0].Reason != "replacing disk" {
+		t.Fatalf("restored maintenance = %+v, want WU05", maintenance)
+	}
+	restored.mu.RLock()
+	required := restored.requiredRawFilesLocked()
+	restored.mu.RUnlock()
+	if required != len(requiredSensorCodes)-1 {
+		t.Fatalf("restored required RAW files = %d, want %d", required, len(requiredSensorCodes)-1)
+	}
+
+	if err := svc.SetNodeMaintenance("WU05", false, ""); err != nil {
+		t.Fatalf("SetNodeMaintenance(off) returned error: %v", err)
+	}
+	if got := svc.NodesInMaintenance(); len(got) != 0 {
+		t.Fatalf("maintenance after clearing = %+v, want none", got)
+	}
+	if err := svc.SetNodeMaintenance("WU99", true, ""); err == nil {
+		t.Fatal("expected error for unknown node")
+	}
+}
+
+func TestCheckSharesAvailabilitySkipsNodesInMaintenance(t *testing.T) {
+	t.Parallel()
+
+	svc := New([]string{"WU01", "WU02"}, []string{"E$"}, t.TempDir())
+	svc.mountPointMounted = func(string) (bool, error) { return false, nil }
+
+	if err := svc.SetNodeMaintenance("WU02", true, ""); err != nil {
+		t.Fatalf("SetNodeMaintenance returned error: %v", err)
+	}
+
+	unavailable := svc.CheckSharesAvailability()
+	if len(unavailable) != 1 || unavailable[0].Node != "WU01" {
+		t.Fatalf("unavailable = %+v, want only WU01", unavailable)
+	}
+}
+
+func TestCopyFailuresQuarantineAfterMaxAttemptsAndRequeue(t *testing.T) {
+	t.Parallel()
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetMaxCopyAttempts(2)
+	notifier := &eventNotifierStub{}
+	svc.SetEventNotifier(notifier)
+
+	task := newTaskInfo("WU01", "E$", func() {}, time.Now())
+	copyErr := errors.New("input/output error")
+
+	if svc.recordCopyFailure(task, "/ucmount/WU01/E/P/a.raw", "a.raw", copyErr) {
+		t.Fatal("first failure must not quarantine")
+	}
+	if svc.isQuarantined("/ucmount/WU01/E/P/a.raw") {
+		t.Fatal("file quarantined after first failure")
+	}
+	if !svc.recordCopyFailure(task, "/ucmount/WU01/E/P/a.raw", "a.raw", copyErr) {
+		t.Fatal("second failure should quarantine")
+	}
+	svc.recordCopyFailure(task, "/ucmount/WU01/E/P/b.raw", "b.raw", copyErr)
+	svc.recordCopyFailure(task, "/ucmount/WU01/E/P/b.raw", "b.raw", copyErr)
+
+	failures := svc.CopyFailures()
+	if len(failures) != 2 {
+		t.Fatalf("len(CopyFailures()) = %d, want 2", len(failures))
+	}
+	if failures[1].Attempts != 2 || failures[1].LastError != copyErr.Error() || failures[1].Node != "WU01" {
+		t.Fatalf("failure = %+v", failures[1])
+	}
+	if got := svc.GetStatus().QuarantinedFiles; got != 2 {
+		t.Fatalf("QuarantinedFiles = %d, want 2", got)
+	}
+	if len(notifier.events) != 2 || notifier.events[0].Type != models.SyncEventFileQuarantined {
+		t.Fatalf("events = %+v, want two file_quarantined events", notifier.events)
+	}
+
+	if got := svc.RequeueFailures([]string{"/ucmount/WU01/E/P/a.raw"}); got != 1 {
+		t.Fatalf("RequeueFailures(a) = %d, want 1", got)
+	}
+	if svc.isQuarantined("/ucmount/WU01/E/P/a.raw") {
+		t.Fatal("a.raw still quarantined after requeue")
+	}
+	if got := svc.RequeueFailures(nil); got != 1 {
+		t.Fatalf("RequeueFailures(all) = %d, want 1", got)
+	}
+	if got := svc.CopyFailures(); len(got) != 0 {
+		t.Fatalf("CopyFailures() after requeue = %+v", got)
+	}
+}
+
+func TestNodeLimiterAdjustClimbsUntilGainDropsAndHalvesOnErrors(t *testing.T) {
+	t.Parallel()
+
+	opts := AdaptiveParallelismOptions{Enabled: true, Min: 1, Max: 6, Interval: time.Second}
+	limiter := newNodeLimiter(1)
+
+	step := func(bytes int64, errors int32, saturated bool) int {
+		atomic.StoreInt64(&limiter.bytes, bytes)
+		atomic.StoreInt32(&limiter.errors, errors)
+		if saturated {
+			atomic.StoreInt32(&limiter.saturated, 1)
+		}
+		_, next := limiter.adjust(time.Second, opts)
+		return next
+	}
+
+	if got := step(100, 0, true); got != 2 {
+		t.Fatalf("limit after saturated window = %d, want 2", got)
+	}
+	if got := step(200, 0, true); got != 3 {
+		t.Fatalf("limit after gain = %d, want 3", got)
+	}
+	if got := step(202, 0, true); got != 2 {
+		t.Fatalf("limit after flat throughput = %d, want 2", got)
+	}
+	for i := 0; i < adaptiveHoldAfterPlateau; i++ {
+		if got := step(200, 0, true); got != 2 {
+			t.Fatalf("limit during hold = %d, want 2", got)
+		}
+	}
+	if got := step(200, 0, true); got != 3 {
+		t.Fatalf("limit after hold = %d, want 3", got)
+	}
+	if got := step(250, 2, true); got != 1 {
+		t.Fatalf("limit after errors = %d, want 1", got)
+	}
+	if got := step(250, 0, false); got != 1 {
+		t.Fatalf("limit must stay at min, got %d", got)
+	}
+}
+
+func TestNodeLimiterAcquireHonoursLimitChanges(t *testing.T) {
+	t.Parallel()
+
+	limiter := newNodeLimiter(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := limiter.acquire(ctx); err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+
+	acquired := make(chan error, 1)
+	go func() { acquired <- limiter.acquire(ctx) }()
+
+	select {
+	case <-acquired:
+		t.Fatal("second acquire succeeded above the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	limiter.setLimit(2)
+	if err := <-acquired; err != nil {
+		t.Fatalf("acquire after raising limit: %v", err)
+	}
+	if atomic.LoadInt32(&limiter.saturated) != 1 {
+		t.Fatal("waiting acquire should mark the limiter saturated")
+	}
+	if limit, active := limiter.state(); limit != 2 || active != 2 {
+		t.Fatalf("state = %d/%d, want 2/2", limit, active)
+	}
+}
+
+func TestShareSubpathAppliesToFindProjectsAndEstimate(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	for _, rel := range []string{"WU01/E/UCX/Data/ProjA/a.raw", "WU01/E/Stray/b.raw", "WU01/F/ProjB/c.raw"} {
+		path := filepath.Join(baseDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, make([]byte, 10), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$", "F$"}, baseDir)
+	svc.SetShareSubpaths(map[string]string{"e$": `\UCX\Data\`})
+
+	projects, err := svc.FindProjects(context.Background())
+	if err != nil {
+		t.Fatalf("FindProjects returned error: %v", err)
+	}
+	names := make([]string, 0, len(projects))
+	for _, project := range projects {
+		names = append(names, project.Name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "ProjA,ProjB" {
+		t.Fatalf("projects = %v, want ProjA and ProjB", names)
+	}
+
+	total, err := svc.EstimateRemainingBytes(context.Background(), "ProjA", false)
+	if err != nil {
+		t.Fatalf("EstimateRemainingBytes returned error: %v", err)
+	}
+	if total != 10 {
+		t.Fatalf("EstimateRemainingBytes = %d, want 10", total)
+	}
+}
+
+func TestNormalizeShareSubpath(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"":                "",
+		`\`:               "",
+		`UCX\Data`:        "UCX/Data",
+		`/UCX//Data/`:     "UCX/Data",
+		`..\..\etc`:       "etc",
+		` UCX\..\Other\ `: "Other",
+	}
+	for input, want := range cases {
+		if got := NormalizeShareSubpath(input); got != want {
+			t.Errorf("NormalizeShareSubpath(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestExclusionsApplyToScanAndProjectDiscovery(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	for _, rel := range []string{
+		"WU01/E/ProjA/a.raw",
+		"WU01/E/ProjA/Junk/b.raw",
+		"WU01/E/ProjA/node_modules/c.raw",
+		"WU01/E/Scratch/d.raw",
+		"WU01/E/logs/e.txt",
+	} {
+		path := filepath.Join(baseDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, baseDir)
+	svc.SetExclusions(models.Exclusions{Directories: []string{" junk ", "JUNK"}, Projects: []string{"scratch"}})
+
+	if got := svc.Exclusions(); len(got.Directories) != 1 || got.Directories[0] != "junk" || len(got.Projects) != 1 {
+		t.Fatalf("Exclusions() = %+v", got)
+	}
+
+	source := filepath.Join(baseDir, "WU01", "E", "ProjA")
+	files, err := svc.scanDirectory(context.Background(), source, source)
+	if err != nil {
+		t.Fatalf("scanDirectory returned error: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("scanned files = %v, want a.raw and node_modules/c.raw", files)
+	}
+
+	projects, err := svc.FindProjects(context.Background())
+	if err != nil {
+		t.Fatalf("FindProjects returned error: %v", err)
+	}
+	names := make([]string, 0, len(projects))
+	for _, project := range projects {
+		names = append(names, project.Name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "ProjA,logs" {
+		t.Fatalf("projects = %v, want ProjA and logs", names)
+	}
+
+	svc.SetExclusions(models.Exclusions{})
+	if got := svc.Exclusions(); len(got.Directories) != len(defaultExcludedDirectories) || len(got.Projects) != len(defaultExcludedProjects) {
+		t.Fatalf("Exclusions() after reset = %+v, want defaults", got)
+	}
+}
+
+func TestRunSpotCheckDetectsAndRepairsCorruptedCopy(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "src.raw")
+	dest := filepath.Join(dir, "dst.raw")
+	payload := make([]byte, 64*1024)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+	if err := os.WriteFile(source, payload, 0644); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+	corrupted := append([]byte(nil), payload...)
+	corrupted[40000] ^= 0xFF
+	if err := os.WriteFile(dest, corrupted, 0644); err != nil {
+		t.Fatalf("write dest: %v", err)
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	notifier := &eventNotifierStub{}
+	svc.SetEventNotifier(notifier)
+	opts := SpotCheckOptions{Enabled: true, FullHash: true}
+	svc.SetSpotCheck(opts)
+	svc.rememberSpotCandidate(newTaskInfo("WU01", "E$", nil, time.Now()), source, dest)
+
+	rnd := rand.New(rand.NewSource(1))
+	svc.runSpotCheck(context.Background(), svc.spotCheck, rnd)
+
+	status := svc.GetStatus()
+	if status.SpotChecks != 1 || status.SpotCheckMismatches != 1 {
+		t.Fatalf("spot checks = %d/%d, want 1/1", status.SpotChecks, status.SpotCheckMismatches)
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Type != models.SyncEventIntegrityMismatch {
+		t.Fatalf("events = %+v, want integrity_mismatch", notifier.events)
+	}
+	repaired, err := os.ReadFile(dest)
+	if err != nil || !bytes.Equal(repaired, payload) {
+		t.Fatalf("destination not repaired (err = %v)", err)
+	}
+
+	svc.runSpotCheck(context.Background(), svc.spotCheck, rnd)
+	if status := svc.GetStatus(); status.SpotChecks != 2 || status.SpotCheckMismatches != 1 {
+		t.Fatalf("after repair spot checks = %d/%d, want 2/1", status.SpotChecks, status.SpotCheckMismatches)
+	}
+}
+
+func TestCompareCopiedFileSampledRange(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "src")
+	dest := filepath.Join(dir, "dst")
+	payload := bytes.Repeat([]byte("ucx"), 4096)
+	if err := os.WriteFile(source, payload, 0644); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+	if err := os.WriteFile(dest, payload, 0644); err != nil {
+		t.Fatalf("write dest: %v", err)
+	}
+
+	svc := New(nil, nil, "")
+	opts := SpotCheckOptions{SampleBytes: 512}
+	match, err := svc.compareCopiedFile(context.Background(), source, dest, opts, rand.New(rand.NewSource(7)))
+	if err != nil || !match {
+		t.Fatalf("compareCopiedFile = %v, %v; want match", match, err)
+	}
+
+	if err := os.WriteFile(dest, payload[:len(payload)-1], 0644); err != nil {
+		t.Fatalf("truncate dest: %v", err)
+	}
+	if match, err := svc.compareCopiedFile(context.Background(), source, dest, opts, rand.New(rand.NewSource(7))); err != nil || match {
+		t.Fatalf("compareCopiedFile on truncated copy = %v, %v; want mismatch", match, err)
+	}
+}
+
+func TestSyncTaskReportsStateThroughLifecycle(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	sourceRoot := filepath.Join(baseDir, "source")
+	destRoot := filepath.Join(baseDir, "dest")
+	for _, dir := range []string{sourceRoot, destRoot} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("MkdirAll(%s) error = %v", dir, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(sourceRoot, "a.txt"), []byte("payload"), 0644); err != nil {
+		t.Fatalf("WriteFile error = %v", err)
+	}
+
+	svc := New([]string{"WU01", "WU02"}, []string{"E$"}, "/ucmount")
+	svc.globalSemaphore = make(chan struct{}, 2)
+	svc.mu.Lock()
+	svc.isRunning = true
+	svc.mu.Unlock()
+
+	svc.startSyncTask(context.Background(), "WU01", "E$", sourceRoot, destRoot)
+	svc.startSyncTask(context.Background(), "WU02", "E$", filepath.Join(baseDir, "missing"), destRoot)
+	svc.wg.Wait()
+
+	tasks := svc.GetStatus().ActiveTasks
+	if len(tasks) != 2 {
+		t.Fatalf("tasks = %+v, want two finished tasks", tasks)
+	}
+	if tasks[0].Node != "WU01" || tasks[0].Status != models.TaskStateDone || tasks[0].CopiedFiles != 1 {
+		t.Fatalf("WU01 task = %+v, want done with 1 file", tasks[0])
+	}
+	if tasks[1].Node != "WU02" || tasks[1].Status != models.TaskStateFailed || tasks[1].Error == "" {
+		t.Fatalf("WU02 task = %+v, want failed with error", tasks[1])
+	}
+
+	stalled := newTaskInfo("WU01", "E$", func() {}, time.Now().Add(-time.Hour))
+	stalled.setState(taskCopying)
+	svc.mu.Lock()
+	svc.activeTasks["WU01-E$"] = stalled
+	svc.mu.Unlock()
+	svc.recoverStalledTasks(time.Now())
+
+	if got := svc.GetStatus().ActiveTasks[0]; got.Status != models.TaskStateStalled {
+		t.Fatalf("WU01 task after watchdog = %+v, want stalled", got)
+	}
+}
+
+func TestVerifyLastRunReportsMismatchedCopies(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		return path
+	}
+	goodSrc, goodDst := write("good.src", "same"), write("good.dst", "same")
+	badSrc, badDst := write("bad.src", "abcd"), write("bad.dst", "abcx")
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	_, cancel := context.WithCancel(context.Background())
+	svc.mu.Lock()
+	svc.isRunning = true
+	svc.cancel = cancel
+	svc.destDir = dir
+	svc.mu.Unlock()
+	svc.recordRunCopy(goodSrc, goodDst)
+	svc.recordRunCopy(badSrc, badDst)
+	svc.recordRunCopy(filepath.Join(dir, "gone.src"), goodDst)
+
+	svc.Stop()
+
+	if got := svc.LastRunDir(); got != dir {
+		t.Fatalf("LastRunDir = %q, want %q", got, dir)
+	}
+	result, err := svc.VerifyLastRun(context.Background())
+	if err != nil {
+		t.Fatalf("VerifyLastRun returned error: %v", err)
+	}
+	if result.Checked != 2 || result.Skipped != 1 || len(result.Mismatched) != 1 || result.Mismatched[0] != badDst {
+		t.Fatalf("result = %+v, want 2 checked, 1 skipped, %s mismatched", result, badDst)
+	}
+}
+
+func TestIdleFinishStopsRunWithoutNewFiles(t *testing.T) {
+	t.Parallel()
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	notifier := &eventNotifierStub{}
+	svc.SetEventNotifier(notifier)
+	svc.SetIdleFinish(time.Hour)
+
+	now := time.Now()
+	svc.noteProgress(now)
+	if svc.idleFinishDue(now.Add(59 * time.Minute)) {
+		t.Fatal("idle finish due before idle_finish elapsed")
+	}
+
+	svc.mu.Lock()
+	svc.activeTasks = map[string]*taskInfo{"WU01-E$": newTaskInfo("WU01", "E$", func() {}, now)}
+	svc.mu.Unlock()
+	if svc.idleFinishDue(now.Add(2 * time.Hour)) {
+		t.Fatal("idle finish due while a task is active")
+	}
+
+	svc.mu.Lock()
+	svc.activeTasks = make(map[string]*taskInfo)
+	svc.retryQueue = map[string]*queuedRetry{
+		"/ucmount/WU01/E/ProjA/a.raw": {path: "/ucmount/WU01/E/ProjA/a.raw", due: now.Add(3 * time.Hour)},
+	}
+	svc.mu.Unlock()
+	if svc.idleFinishDue(now.Add(2 * time.Hour)) {
+		t.Fatal("idle finish due while a failed file waits for its retry")
+	}
+	svc.mu.Lock()
+	svc.retryQueue = make(map[string]*queuedRetry)
+	svc.mu.Unlock()
+
+	_, cancel := context.WithCancel(context.Background())
+	svc.mu.Lock()
+	svc.activeTasks = make(map[string]*taskInfo)
+	svc.isRunning = true
+	svc.cancel = cancel
+	svc.mu.Unlock()
+	if !svc.idleFinishDue(now.Add(2 * time.Hour)) {
+		t.Fatal("idle finish not due after idle_finish without tasks")
+	}
+
+	svc.finishIdle()
+	var events []models.SyncEvent
+	deadline := time.Now().Add(2 * time.Second)
+	for len(events) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		notifier.mu.Lock()
+		events = append([]models.SyncEvent(nil), notifier.events...)
+		notifier.mu.Unlock()
+	}
+	if len(events) != 1 || events[0].Type != models.SyncEventFinished || events[0].Reason != FinishReasonIdle {
+		t.Fatalf("events = %+v, want sync_finished with reason idle", events)
+	}
+	if svc.GetStatus().IsRunning {
+		t.Fatal("run still active after idle finish")
+	}
+}
+
+func TestExportDeliversOnlyCompletedVerifiedCaptures(t *testing.T) {
+	t.Parallel()
+
+	work := t.TempDir()
+	delivery := t.TempDir()
+	project := "ProjA"
+	session := "BD11EBB0_BE00_4BE7_BC66_9DED8D740C2E"
+	projectDir := filepath.Join(work, "2026-10-15", project)
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	write := func(name string) {
+		if err := os.WriteFile(filepath.Join(projectDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	for _, sensor := range requiredSensorCodes {
+		write(fmt.Sprintf("Lvl00-00001-%s-%s-%s.raw", project, sensor, session))
+		write(fmt.Sprintf("Lvl0X-00001-%s-%s-%s.raw", project, sensor, session))
+		level := "Lvl00"
+		if sensor == "07-00" {
+			level = "Lvl0X" // capture 2 is not verified yet
+		}
+		write(fmt.Sprintf("%s-00002-%s-%s-%s.raw", level, project, sensor, session))
+	}
+	write(fmt.Sprintf("EAD-00001-%s-%s.xml", project, session))
+	write(fmt.Sprintf("RawQv-00001-%s-%s.dat", project, session))
+	write(fmt.Sprintf("EAD-00002-%s-%s.xml", project, session))
+
+	store, err := state.New(filepath.Join(t.TempDir(), "state.db"), "ucxsync-test")
+	if err != nil {
+		t.Fatalf("state.New returned error: %v", err)
+	}
+	defer store.Close()
+	for _, capture := range []string{"00001", "00002"} {
+		for _, sensor := range requiredSensorCodes {
+			_, _, err := store.RecordCapture(state.CaptureObservation{
+				Project:          project,
+				Info:             models.CaptureInfo{CaptureNumber: capture, SensorCode: sensor, DataType: "Lvl00"},
+				FileKey:          "raw:" + sensor,
+				RequiredRawFiles: len(requiredSensorCodes),
+			})
+			if err != nil {
+				t.Fatalf("RecordCapture returned error: %v", err)
+			}
+		}
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	notifier := &eventNotifierStub{}
+	svc.SetEventNotifier(notifier)
+	if err := svc.SetStateStore(store); err != nil {
+		t.Fatalf("SetStateStore returned error: %v", err)
+	}
+
+	if err := svc.StartExport(context.Background(), ExportOptions{Project: project, Source: work, Destination: delivery, Lvl00Only: true}); err != nil {
+		t.Fatalf("StartExport returned error: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for svc.ExportStatus().Running && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	status := svc.ExportStatus()
+	if status.Running || status.Error != "" {
+		t.Fatalf("export status = %+v, want finished without error", status)
+	}
+	wantFiles := len(requiredSensorCodes) + 1 // Lvl00 RAW + XML of capture 1
+	if status.Captures != 1 || status.SkippedCaptures != 1 || status.CopiedFiles != wantFiles {
+		t.Fatalf("export status = %+v, want 1 capture, 1 skipped, %d files", status, wantFiles)
+	}
+
+	delivered, err := os.ReadDir(filepath.Join(delivery, project))
+	if err != nil {
+		t.Fatalf("read delivery: %v", err)
+	}
+	for _, entry := range delivered {
+		name := entry.Name()
+		if strings.HasPrefix(name, "Lvl0X") || strings.Contains(name, "-00002-") || strings.HasPrefix(name, "RawQv") {
+			t.Fatalf("unexpected delivered file %s", name)
+		}
+	}
+	if len(delivered) != wantFiles+1 {
+		t.Fatalf("delivered %d entries, want %d files plus manifest", len(delivered), wantFiles)
+	}
+
+	var manifest deliveryManifest
+	data, err := os.ReadFile(status.Manifest)
+	if err != nil || json.Unmarshal(data, &manifest) != nil {
+		t.Fatalf("failed to read manifest %s: %v", status.Manifest, err)
+	}
+	if len(manifest.Captures) != 1 || manifest.Captures[0] != "00001" || len(manifest.Files) != wantFiles || manifest.Files[0].SHA256 == "" {
+		t.Fatalf("manifest = %+v, want capture 00001 with %d hashed files", manifest, wantFiles)
+	}
+}
+
+func TestCompactTrackedStateEvictsStaleCapturesToStore(t *testing.T) {
+	t.Parallel()
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount-a")
+	svc.mu.Lock()
+	svc.requiredSensors = map[string]struct{}{"00-00": {}, "00-01": {}}
+	svc.project = "Project"
+	svc.mu.Unlock()
+
+	for _, capture := range []string{"00005", "00006", "00007"} {
+		name := fmt.Sprintf("Lvl00-%s-Project-00-00-ABCDEF01_2345_6789_ABCD_EF0123456789.raw", capture)
+		if _, err := svc.trackCaptureCompletionStatus(name, "WU01", "/dest/2024-05-17/Project"); err != nil {
+			t.Fatalf("track %s failed: %v", capture, err)
+		}
+	}
+
+	store, err := state.New(filepath.Join(t.TempDir(), "state.db"), "ucxsync-test")
+	if err != nil {
+		t.Fatalf("state.New() error = %v", err)
+	}
+	defer store.Close()
+
+	now := time.Now()
+	svc.mu.Lock()
+	svc.stateStore = store
+	svc.captureMeta["00005"].firstSeen = now.Add(-7 * time.Hour)
+	svc.captureMeta["00006"].firstSeen = now.Add(-time.Hour)
+	svc.captureMeta["00007"].firstSeen = now.Add(-time.Minute)
+	svc.mu.Unlock()
+	svc.SetMemoryGuard(MemoryGuardOptions{CaptureMaxAge: 6 * time.Hour, MaxTrackedCaptures: 1})
+
+	accounting := svc.compactTrackedState(now)
+
+	if accounting.TrackedCaptures != 1 || accounting.EvictedCaptures != 2 {
+		t.Fatalf("accounting = %+v, want 1 tracked and 2 evicted", accounting)
+	}
+	if _, ok := svc.captureTracker["00007"]; !ok {
+		t.Fatal("expected the newest capture to stay in memory")
+	}
+
+	locations, err := store.ListCaptureLocations("Project")
+	if err != nil {
+		t.Fatalf("ListCaptureLocations() error = %v", err)
+	}
+	if len(locations) != 2 || locations[0].CaptureNumber != "00005" || locations[1].CaptureNumber != "00006" {
+		t.Fatalf("locations = %+v, want evicted captures 00005 and 00006 persisted", locations)
+	}
+}
+
+func TestCompactTrackedStateTrimsFailuresAndRunCopies(t *testing.T) {
+	t.Parallel()
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount-a")
+	svc.SetMemoryGuard(MemoryGuardOptions{CaptureMaxAge: time.Hour, MaxRunCopies: 2})
+
+	now := time.Now()
+	svc.mu.Lock()
+	svc.failures = map[string]*models.CopyFailure{
+		"stale":       {Path: "stale", Attempts: 1, LastFailedAt: now.Add(-2 * time.Hour)},
+		"recent":      {Path: "recent", Attempts: 1, LastFailedAt: now},
+		"quarantined": {Path: "quarantined", Attempts: 3, Quarantined: true, LastFailedAt: now.Add(-2 * time.Hour)},
+	}
+	svc.mu.Unlock()
+	for i := 0; i < 5; i++ {
+		svc.recordRunCopy(fmt.Sprintf("src-%d", i), fmt.Sprintf("dst-%d", i))
+	}
+
+	accounting := svc.compactTrackedState(now)
+
+	if accounting.CopyFailures != 2 || svc.hasCopyFailure("stale") || !svc.isQuarantined("quarantined") {
+		t.Fatalf("failures after compaction = %+v", svc.failures)
+	}
+	if accounting.RunCopies != 2 || accounting.DroppedRunCopies != 3 {
+		t.Fatalf("accounting = %+v, want 2 run copies kept and 3 dropped", accounting)
+	}
+	if svc.runCopies[0].dest != "dst-3" || svc.runCopies[1].dest != "dst-4" {
+		t.Fatalf("runCopies = %+v, want the newest two", svc.runCopies)
+	}
+}
+
+// badSectorReader fails every read that touches [badFrom, badTo).
+type badSectorReader struct {
+	data           []byte
+	badFrom, badTo int64
+}
+
+func (r *badSectorReader) ReadAt(p []byte, off int64) (int, error) {
+	end := off + int64(len(p))
+	if off < r.badTo && end > r.badFrom {
+		return 0, errors.New("input/output error")
+	}
+	return copy(p, r.data[off:]), nil
+}
+
+func TestSalvageCopyZeroFillsUnreadableSectors(t *testing.T) {
+	t.Parallel()
+
+	data := bytes.Repeat([]byte{0xAB}, 5*salvageSectorSize)
+	src := &badSectorReader{data: data, badFrom: salvageSectorSize + 10, badTo: 3*salvageSectorSize - 1}
+	var dst bytes.Buffer
+
+	result, err := salvageCopy(context.Background(), src, &dst, 0, int64(len(data)), 2*salvageSectorSize)
+	if err != nil {
+		t.Fatalf("salvageCopy() error = %v", err)
+	}
+
+	want := []models.ByteRange{{Offset: salvageSectorSize, Length: 2 * salvageSectorSize}}
+	if len(result.bad) != 1 || result.bad[0] != want[0] {
+		t.Fatalf("bad ranges = %+v, want %+v", result.bad, want)
+	}
+	if result.readErr == nil {
+		t.Fatal("readErr = nil, want the read error")
+	}
+
+	got := dst.Bytes()
+	if len(got) != len(data) {
+		t.Fatalf("copied %d bytes, want %d", len(got), len(data))
+	}
+	for i, b := range got {
+		inBad := int64(i) >= want[0].Offset && int64(i) < want[0].Offset+want[0].Length
+		if (inBad && b != 0) || (!inBad && b != 0xAB) {
+			t.Fatalf("byte %d = %#x, bad range zero-filled = %t", i, b, inBad)
+		}
+	}
+}
+
+func TestInventoryTotalsSharesCapturesAndSessions(t *testing.T) {
+	t.Parallel()
+
+	mount := t.TempDir()
+	project := "ProjA"
+	session := "BD11EBB0_BE00_4BE7_BC66_9DED8D740C2E"
+	write := func(node, share, name string) {
+		dir := filepath.Join(mount, node, share, project, "sub")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("1234"), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	write("WU01", "E", fmt.Sprintf("Lvl00-00001-%s-00-00-%s.raw", project, session))
+	write("WU01", "E", fmt.Sprintf("Lvl00-00002-%s-00-00-%s.raw", project, session))
+	write("WU01", "E", fmt.Sprintf("Lvl00-00003-T-%s-00-00-%s.raw", project, session))
+	write("WU02", "E", fmt.Sprintf("Lvl00-00002-%s-01-00-%s.raw", project, session))
+	write("WU02", "E", fmt.Sprintf("EAD-00004-%s-%s.xml", project, session))
+	write("WU02", "E", "notes.txt")
+
+	svc := New([]string{"WU01", "WU02"}, []string{"E$", "F$"}, mount)
+	inventory, err := svc.Inventory(context.Background(), project)
+	if err != nil {
+		t.Fatalf("Inventory() error = %v", err)
+	}
+
+	if inventory.Files != 6 || inventory.Bytes != 24 {
+		t.Fatalf("totals = %d files, %d bytes, want 6 and 24", inventory.Files, inventory.Bytes)
+	}
+	if inventory.Captures != 3 || inventory.TestCaptures != 1 {
+		t.Fatalf("captures = %d (+%d test), want 3 (+1 test)", inventory.Captures, inventory.TestCaptures)
+	}
+	if len(inventory.Shares) != 4 {
+		t.Fatalf("shares = %d, want 4", len(inventory.Shares))
+	}
+	wu01 := inventory.Shares[0]
+	if wu01.Node != "WU01" || wu01.Share != "E$" || wu01.RawFiles != 3 || wu01.Captures != 2 || wu01.TestCaptures != 1 {
+		t.Fatalf("WU01/E$ = %+v", wu01)
+	}
+	if len(inventory.Sessions) != 1 {
+		t.Fatalf("sessions = %+v, want one", inventory.Sessions)
+	}
+	got := inventory.Sessions[0]
+	if got.SessionID != session || got.Captures != 3 || got.FirstCapture != "00001" || got.LastCapture != "00004" || got.Files != 5 {
+		t.Fatalf("session = %+v", got)
+	}
+}
+
+func TestPostProcessingRunsStepsInOrderAndSkipsAfterFailure(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount-a")
+	svc.SetPostProcessing(PostProcessOptions{
+		Workers: 1,
+		Steps: []PostProcessStep{
+			{Name: "mark", Command: []string{"/bin/sh", "-c", "echo {capture}-{session} > {dir}/mark && echo marked"}},
+			{Name: "fail", Command: []string{"/bin/sh", "-c", "echo broken >&2; exit 3"}},
+			{Name: "never", Command: []string{"/bin/sh", "-c", "touch {dir}/never"}},
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	svc.mu.Lock()
+	svc.project = "ProjA"
+	svc.destDir = dir
+	svc.startPostProcessingLocked(ctx)
+	svc.mu.Unlock()
+
+	svc.enqueuePostProcessing(&models.CaptureInfo{CaptureNumber: "00007", SessionID: "ABCDEF01"})
+
+	var status models.CaptureProcessing
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got := svc.PostProcessing("00007")
+		if len(got) == 1 && got[0].FinishedAt != nil {
+			status = got[0]
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("post-processing did not finish: %+v", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	svc.wg.Wait()
+
+	if status.Status != models.PostProcessFailed {
+		t.Fatalf("status = %q, want failed", status.Status)
+	}
+	want := []string{models.PostProcessDone, models.PostProcessFailed, models.PostProcessSkipped}
+	for i, step := range status.Steps {
+		if step.Status != want[i] {
+			t.Fatalf("step %s = %q, want %q", step.Name, step.Status, want[i])
+		}
+	}
+	if status.Steps[0].Output != "marked" || status.Steps[1].Output != "broken" || status.Steps[1].Error == "" {
+		t.Fatalf("steps = %+v", status.Steps)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "mark")); err != nil || strings.TrimSpace(string(data)) != "00007-ABCDEF01" {
+		t.Fatalf("mark = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "never")); !os.IsNotExist(err) {
+		t.Fatalf("step after the failure ran: %v", err)
+	}
+}
+
+// mapSource serves a fstest.MapFS as the source shares; paths are the
+// engine's absolute paths without the leading slash.
+type mapSource struct {
+	fsys fstest.MapFS
+}
+
+func (m mapSource) name(path string) string { return strings.TrimPrefix(filepath.ToSlash(path), "/") }
+
+func (m mapSource) ReadDir(path string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(m.fsys, m.name(path))
+}
+
+func (m mapSource) Stat(path string) (fs.FileInfo, error) { return fs.Stat(m.fsys, m.name(path)) }
+
+func (m mapSource) Open(path string) (storage.File, error) {
+	file, err := m.fsys.Open(m.name(path))
+	if err != nil {
+		return nil, err
+	}
+	return file.(storage.File), nil
+}
+
+func TestCopyFileReadsThroughSourceProvider(t *testing.T) {
+	t.Parallel()
+
+	destRoot := t.TempDir()
+	mtime := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	name := "Lvl00-00005-Project-00-00-ABCDEF01_2345_6789_ABCD_EF0123456789.raw"
+	source := mapSource{fsys: fstest.MapFS{
+		"ucmount/WU01/E/Project/" + name: {Data: []byte("remote payload"), ModTime: mtime},
+	}}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetStorage(source, nil)
+	svc.mu.Lock()
+	svc.project = "Project"
+	svc.mu.Unlock()
+
+	sourceRoot := "/ucmount/WU01/E/Project"
+	files, err := svc.scanDirectory(context.Background(), sourceRoot, sourceRoot)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("scanDirectory() = %v, %v", files, err)
+	}
+
+	task := &taskInfo{node: "WU01", share: "E$"}
+	if err := svc.copyFile(context.Background(), task, files[0], sourceRoot, destRoot); err != nil {
+		t.Fatalf("copyFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(destRoot, name))
+	if err != nil || string(data) != "remote payload" {
+		t.Fatalf("copied file = %q, %v", data, err)
+	}
+	if info, err := os.Stat(filepath.Join(destRoot, name)); err != nil || !info.ModTime().Equal(mtime) {
+		t.Fatalf("copied mtime = %v, %v; want %v", info.ModTime(), err, mtime)
+	}
+}
+
+func TestVerifyLastRunResumesFromCheckpointAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	var files []state.VerifyFile
+	for _, name := range []string{"a.raw", "b.raw", "c.raw"} {
+		data := bytes.Repeat([]byte(name), 1000)
+		src := filepath.Join(dir, "src-"+name)
+		dst := filepath.Join(dir, "dst-"+name)
+		if err := os.WriteFile(src, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(dst, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, state.VerifyFile{Source: src, Dest: dst})
+	}
+
+	store, err := state.New(filepath.Join(dir, "state.db"), "ucxsync-test")
+	if err != nil {
+		t.Fatalf("state.New() error = %v", err)
+	}
+	defer store.Close()
+
+	// An earlier pass verified a.raw and hashed the first half of b.raw
+	// before the host went down.
+	if err := store.StartVerify("/dest/run", files, time.Now()); err != nil {
+		t.Fatalf("StartVerify() error = %v", err)
+	}
+	half := int64(len("b.raw") * 500)
+	partial := sha256.New()
+	partial.Write(bytes.Repeat([]byte("b.raw"), 500))
+	hashState, err := partial.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveVerifyCheckpoint(state.VerifyCheckpoint{
+		RunDir:     "/dest/run",
+		NextIndex:  1,
+		Checked:    1,
+		FileOffset: half,
+		SourceHash: hashState,
+		DestHash:   hashState,
+	}); err != nil {
+		t.Fatalf("SaveVerifyCheckpoint() error = %v", err)
+	}
+
+	// Neither the finished file nor the hashed half must be read again.
+	if err := os.Remove(files[0].Source); err != nil {
+		t.Fatal(err)
+	}
+	dst, err := os.OpenFile(files[1].Dest, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dst.WriteAt(make([]byte, half), 0); err != nil {
+		t.Fatal(err)
+	}
+	dst.Close()
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.stateStore = store
+
+	progress, ok := svc.VerifyProgress()
+	if !ok || progress.Running || progress.Done != 1 || progress.Total != 3 || progress.FileOffset != half {
+		t.Fatalf("VerifyProgress() = %+v, %t", progress, ok)
+	}
+
+	result, err := svc.VerifyLastRun(context.Background())
+	if err != nil {
+		t.Fatalf("VerifyLastRun() error = %v", err)
+	}
+	if result.Checked != 3 || result.Skipped != 0 || len(result.Mismatched) != 0 || result.ResumedFrom != 1 {
+		t.Fatalf("result = %+v, want 3 checked resumed from 1", result)
+	}
+	if _, ok := svc.VerifyProgress(); ok {
+		t.Fatal("expected the finished pass to be cleared")
+	}
+}
+
+func TestVerifyLastRunKeepsProgressWhenCancelled(t *testing.T) {
+	dir := t.TempDir()
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	for _, name := range []string{"a.raw", "b.raw"} {
+		src := filepath.Join(dir, "src-"+name)
+		dst := filepath.Join(dir, "dst-"+name)
+		for _, path := range []string{src, dst} {
+			if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		svc.lastRunCopies = append(svc.lastRunCopies, spotCandidate{source: src, dest: dst})
+	}
+	svc.lastRunDir = "/dest/run"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := svc.VerifyLastRun(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("VerifyLastRun() error = %v, want context.Canceled", err)
+	}
+	if progress, ok := svc.VerifyProgress(); !ok || progress.Running || progress.Total != 2 || progress.Done != 0 {
+		t.Fatalf("VerifyProgress() = %+v, %t", progress, ok)
+	}
+
+	result, err := svc.VerifyLastRun(context.Background())
+	if err != nil || result.Checked != 2 || len(result.Mismatched) != 0 {
+		t.Fatalf("VerifyLastRun() = %+v, %v", result, err)
+	}
+}
+
+func TestVerifyLastRunSamplesFilesAndKeepsMetadata(t *testing.T) {
+	dir := t.TempDir()
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetVerifySampling(25)
+	names := []string{"EAD-00001-ProjA-ABC.xml", "EAD-00002-ProjA-ABC.xml"}
+	for i := 0; i < 8; i++ {
+		names = append(names, fmt.Sprintf("Lvl00-0000%d-ProjA-00-00-ABC.raw", i))
+	}
+	for _, name := range names {
+		src := filepath.Join(dir, "src-"+name)
+		dst := filepath.Join(dir, name)
+		for _, path := range []string{src, dst} {
+			if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		svc.lastRunCopies = append(svc.lastRunCopies, spotCandidate{source: src, dest: dst})
+	}
+	svc.lastRunDir = "/dest/run"
+
+	// A corrupt metadata copy is always caught, whatever the sample.
+	if err := os.WriteFile(filepath.Join(dir, names[1]), []byte("corrupt"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := svc.VerifyLastRun(context.Background())
+	if err != nil {
+		t.Fatalf("VerifyLastRun() error = %v", err)
+	}
+	// Both XML files and a quarter of the eight RAW files.
+	if result.Checked != 4 || result.NotSampled != 6 {
+		t.Fatalf("result = %+v, want 4 checked and 6 not sampled", result)
+	}
+	if len(result.Mismatched) != 1 || filepath.Base(result.Mismatched[0]) != names[1] {
+		t.Fatalf("Mismatched = %v, want %s", result.Mismatched, names[1])
+	}
+
+	// A cancelled sampled pass resumes over the same sample.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := svc.VerifyLastRun(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("VerifyLastRun() error = %v, want context.Canceled", err)
+	}
+	if progress, ok := svc.VerifyProgress(); !ok || progress.Total != 4 || progress.NotSampled != 6 {
+		t.Fatalf("VerifyProgress() = %+v, %t", progress, ok)
+	}
+	result, err = svc.VerifyLastRun(context.Background())
+	if err != nil || result.ResumedFrom != 0 || result.Checked != 4 || result.NotSampled != 6 {
+		t.Fatalf("resumed VerifyLastRun() = %+v, %v", result, err)
+	}
+}
+
+func TestCaptureNumbersCompareByValueAcrossWidths(t *testing.T) {
+	t.Parallel()
+
+	session := "BD11EBB0_BE00_4BE7_BC66_9DED8D740C2E"
+	for raw, want := range map[string]string{"7": "00007", "00007": "00007", "000007": "00007", "100000": "100000"} {
+		info := parseCaptureFileName(fmt.Sprintf("Lvl00-%s-ProjA-00-00-%s.raw", raw, session))
+		if info == nil || info.CaptureNumber != want {
+			t.Fatalf("parse capture %q = %+v, want %s", raw, info, want)
+		}
+	}
+
+	numbers := []string{"100000", "99999", "00010", "00002", "100001"}
+	sort.Slice(numbers, func(i, j int) bool { return models.CompareCaptureNumbers(numbers[i], numbers[j]) < 0 })
+	if got := strings.Join(numbers, ","); got != "00002,00010,99999,100000,100001" {
+		t.Fatalf("sorted = %s", got)
+	}
+
+	opts := ExportOptions{FromCapture: "99999", ToCapture: "100000"}
+	if !opts.inRange("100000") || opts.inRange("100001") || opts.inRange("10000") {
+		t.Fatal("capture range must compare by value")
+	}
+}
+
+func TestStatusSnapshotDoesNotWaitForServiceLock(t *testing.T) {
+	t.Parallel()
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.mu.Lock()
+	svc.project = "ProjA"
+	svc.mu.Unlock()
+	if got := svc.StatusSnapshot().Project; got != "ProjA" {
+		t.Fatalf("snapshot project = %q, want ProjA", got)
+	}
+
+	// Copy bookkeeping holding the lock must not stall a status read.
+	svc.mu.Lock()
+	done := make(chan models.SyncStatus, 1)
+	go func() { done <- svc.StatusSnapshot() }()
+	select {
+	case status := <-done:
+		if status.Project != "ProjA" {
+			t.Fatalf("snapshot project = %q, want ProjA", status.Project)
+		}
+	case <-time.After(statusRefreshInterval / 2):
+		t.Fatal("StatusSnapshot blocked on the service lock")
+	}
+	svc.project = "ProjB"
+	svc.invalidateStatus()
+	svc.mu.Unlock()
+
+	if got := svc.StatusSnapshot().Project; got != "ProjB" {
+		t.Fatalf("snapshot project after invalidation = %q, want ProjB", got)
+	}
+}
+
+func TestBenchmarkDestinationMeasuresAndCleansUp(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "ucdata")
+	result, err := BenchmarkDestination(context.Background(), dir, 3<<20+123)
+	if err != nil {
+		t.Fatalf("BenchmarkDestination() error = %v", err)
+	}
+	if result.Bytes != 3<<20+123 || result.WriteMBps <= 0 || result.Destination != dir {
+		t.Fatalf("result = %+v", result)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("benchmark left %v behind (err %v)", entries, err)
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.RecordBenchmark(result)
+	if svc.GetStatus().Benchmark != nil {
+		t.Fatal("benchmark must only be recorded for a running job")
+	}
+	svc.mu.Lock()
+	svc.isRunning = true
+	svc.mu.Unlock()
+	svc.RecordBenchmark(result)
+	svc.mu.RLock()
+	totals := svc.runTotalsLocked()
+	svc.mu.RUnlock()
+	if totals.DestinationWriteMBps != result.WriteMBps {
+		t.Fatalf("totals.DestinationWriteMBps = %v, want %v", totals.DestinationWriteMBps, result.WriteMBps)
+	}
+}
+
+func TestRenamedProjectFolderIsDetectedBySessionAndRemapped(t *testing.T) {
+	t.Parallel()
+
+	mountRoot := t.TempDir()
+	const session = "ABCDEF01_2345_6789_ABCD_EF0123456789"
+	rawName := "Lvl00-00001-Arh2k_mezen-00-00-" + session + ".raw"
+	for dir, name := range map[string]string{
+		"Arh2k_mezen_fixed": rawName,
+		"OtherProject":      "Lvl00-00001-OtherProject-00-00-11111111_2222_3333_4444_555555555555.raw",
+	} {
+		path := filepath.Join(mountRoot, "WU01", "E", dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(path, []byte("raw"), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	store, err := state.New(filepath.Join(t.TempDir(), "state.db"), "ucxsync-test")
+	if err != nil {
+		t.Fatalf("state.New() error = %v", err)
+	}
+	defer store.Close()
+	modTime := time.Unix(1700000000, 0)
+	if err := store.MarkFileCopied("Arh2k_mezen", rawName, 3, modTime); err != nil {
+		t.Fatalf("MarkFileCopied() error = %v", err)
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, mountRoot)
+	notifier := &eventNotifierStub{}
+	svc.SetEventNotifier(notifier)
+	svc.mu.Lock()
+	svc.isRunning = true
+	svc.project = "Arh2k_mezen"
+	svc.stateStore = store
+	svc.mu.Unlock()
+	svc.noteRunSessions([]string{filepath.Join(mountRoot, "WU01", "E", "Arh2k_mezen", rawName)})
+
+	svc.detectProjectRename()
+
+	rename := svc.ProjectRename()
+	if rename == nil || rename.From != "Arh2k_mezen" || rename.To != "Arh2k_mezen_fixed" || rename.SessionID != session || rename.Source != "WU01/E$" {
+		t.Fatalf("ProjectRename() = %+v, want Arh2k_mezen -> Arh2k_mezen_fixed on WU01/E$", rename)
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Type != models.SyncEventProjectRenamed {
+		t.Fatalf("events = %+v, want one project_renamed event", notifier.events)
+	}
+
+	if err := svc.RemapProject("OtherProject"); !errors.Is(err, ErrNoProjectRename) {
+		t.Fatalf("RemapProject(OtherProject) error = %v, want ErrNoProjectRename", err)
+	}
+	if err := svc.RemapProject("Arh2k_mezen_fixed"); err != nil {
+		t.Fatalf("RemapProject() error = %v", err)
+	}
+	if svc.project != "Arh2k_mezen_fixed" || svc.ProjectRename() != nil {
+		t.Fatalf("project = %q, rename = %+v after remap", svc.project, svc.ProjectRename())
+	}
+	if copied, err := store.IsFileCopied("Arh2k_mezen_fixed", rawName, 3, modTime); err != nil || !copied {
+		t.Fatalf("IsFileCopied(renamed project) = %v, %v; want the copy history moved", copied, err)
+	}
+}
+
+func TestDestinationQuotaPausesBeforeTheBudgetIsExceeded(t *testing.T) {
+	t.Parallel()
+
+	destination := t.TempDir()
+	if err := os.WriteFile(filepath.Join(destination, "existing.raw"), make([]byte, 600), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, t.TempDir())
+	notifier := &eventNotifierStub{}
+	svc.SetEventNotifier(notifier)
+	svc.SetDestinationQuotas(map[string]int64{destination + "/": 1000})
+	svc.mu.Lock()
+	svc.isRunning = true
+	svc.project = "Arh2k_mezen"
+	svc.destination = destination
+	svc.mu.Unlock()
+
+	if svc.checkDestinationQuota() {
+		t.Fatal("checkDestinationQuota() = true with 600 of 1000 bytes used")
+	}
+	if !svc.quotaAllows(300) {
+		t.Fatal("quotaAllows(300) = false with 400 bytes left")
+	}
+	atomic.AddInt64(&svc.runCopiedBytes, 300)
+	if quota := svc.GetStatus().Quota; quota == nil || quota.UsedBytes != 900 || quota.LimitBytes != 1000 || quota.Reached {
+		t.Fatalf("status quota = %+v, want 900 of 1000 bytes used", quota)
+	}
+
+	if svc.quotaAllows(300) {
+		t.Fatal("quotaAllows(300) = true with 100 bytes left")
+	}
+	if !svc.isPaused() {
+		t.Fatal("sync not paused after the quota was reached")
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Type != models.SyncEventQuotaReached {
+		t.Fatalf("events = %+v, want one destination_quota_reached event", notifier.events)
+	}
+}
+
+func TestCheckSourceSpaceAlertsOnceWhenAShareRunsLow(t *testing.T) {
+	t.Parallel()
+
+	svc := New([]string{"WU01", "WU02"}, []string{"E$"}, "/ucmount")
+	notifier := &eventNotifierStub{}
+	svc.SetEventNotifier(notifier)
+	svc.SetSourceSpaceOptions(SourceSpaceOptions{WarningFreePercent: 10})
+	free := map[string]uint64{"/ucmount/WU01/E": 50, "/ucmount/WU02/E": 5}
+	mounted := map[string]bool{"/ucmount/WU01/E": true, "/ucmount/WU02/E": true}
+	svc.SetMountPointChecker(func(path string) (bool, error) { return mounted[path], nil })
+	svc.diskUsage = func(path string) (*disk.UsageStat, error) {
+		return &disk.UsageStat{Free: free[path], Total: 100}, nil
+	}
+
+	spaces := svc.CheckSourceSpace()
+	if len(spaces) != 2 || spaces[0].Node != "WU01" || spaces[0].Low || !spaces[1].Low || spaces[1].FreePercent != 5 {
+		t.Fatalf("CheckSourceSpace() = %+v, want WU02/E$ low at 5%%", spaces)
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Type != models.SyncEventSourceSpaceLow {
+		t.Fatalf("events = %+v, want one source_space_low event", notifier.events)
+	}
+
+	// A share that stops answering keeps its state instead of alerting again
+	// when it comes back still low.
+	mounted["/ucmount/WU02/E"] = false
+	if spaces := svc.CheckSourceSpace(); spaces[1].Available || !spaces[1].Low {
+		t.Fatalf("unmounted share = %+v, want unavailable and still low", spaces[1])
+	}
+	mounted["/ucmount/WU02/E"] = true
+	svc.CheckSourceSpace()
+	if len(notifier.events) != 1 {
+		t.Fatalf("events = %+v, want no repeated alert", notifier.events)
+	}
+
+	free["/ucmount/WU02/E"] = 40
+	svc.CheckSourceSpace()
+	free["/ucmount/WU02/E"] = 8
+	svc.CheckSourceSpace()
+	if len(notifier.events) != 2 || len(svc.SourceShareSpace()) != 2 {
+		t.Fatalf("events = %+v, want a new alert after the share recovered and ran low again", notifier.events)
+	}
+}
+
+func TestCircuitBreakerSuspendsANodeWithHighFailureRate(t *testing.T) {
+	t.Parallel()
+
+	svc := New([]string{"WU01", "WU02"}, []string{"E$"}, "/ucmount")
+	notifier := &eventNotifierStub{}
+	svc.SetEventNotifier(notifier)
+	svc.SetCircuitBreaker(CircuitBreakerOptions{Enabled: true, Window: time.Minute, MinAttempts: 4, FailureRate: 0.5, OpenFor: time.Hour})
+
+	svc.recordNodeOutcome("WU01", false)
+	svc.recordNodeOutcome("WU01", true)
+	svc.recordNodeOutcome("WU01", true)
+	if !svc.nodeBreakerAllows("WU01") {
+		t.Fatal("breaker opened before min_attempts outcomes")
+	}
+	svc.recordNodeOutcome("WU01", true)
+	svc.recordNodeOutcome("WU02", true)
+
+	if svc.nodeBreakerAllows("WU01") || !svc.nodeBreakerAllows("WU02") {
+		t.Fatal("want WU01 suspended and WU02 still dispatched")
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Type != models.SyncEventNodeBreakerOpen {
+		t.Fatalf("events = %+v, want one node_breaker_open event", notifier.events)
+	}
+	breakers := svc.NodeBreakers()
+	if len(breakers) != 2 || breakers[0].Node != "WU01" || breakers[0].State != models.BreakerOpen ||
+		breakers[0].Failures != 3 || breakers[0].Attempts != 4 || breakers[0].RetryAt == nil {
+		t.Fatalf("NodeBreakers() = %+v, want WU01 open after 3 of 4 failures", breakers)
+	}
+
+	// Once the open period is over one success closes the breaker.
+	svc.mu.Lock()
+	svc.breakers["WU01"].openUntil = time.Now().Add(-time.Second)
+	svc.mu.Unlock()
+	if !svc.nodeBreakerAllows("WU01") || svc.NodeBreakers()[0].State != models.BreakerHalfOpen {
+		t.Fatalf("NodeBreakers() = %+v, want WU01 half-open", svc.NodeBreakers())
+	}
+	svc.recordNodeOutcome("WU01", false)
+	if breakers := svc.NodeBreakers(); breakers[0].Node != "WU01" || breakers[0].State != models.BreakerClosed || breakers[0].Trips != 1 {
+		t.Fatalf("NodeBreakers() = %+v, want WU01 closed after a success", breakers)
+	}
+}
+
+func TestNodeSharesLimitWhichSharesAreChecked(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, dir := range []string{"WU01/E", "WU01/F", "CU/D"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("failed to create share: %v", err)
+		}
+	}
+
+	svc := New([]string{"WU01", "CU"}, []string{"E$", "F$"}, root)
+	svc.SetMountPointChecker(func(string) (bool, error) { return true, nil })
+	if unavailable := svc.CheckSharesAvailability(); len(unavailable) != 2 {
+		t.Fatalf("unavailable = %+v, want CU E$ and F$ without node shares", unavailable)
+	}
+
+	svc.SetNodeShares(map[string][]string{"CU": {"D$"}})
+	if unavailable := svc.CheckSharesAvailability(); len(unavailable) != 0 {
+		t.Fatalf("unavailable = %+v, want only configured shares checked", unavailable)
+	}
+}
+
+func TestFindProjectsAndEstimateStopWhenTheRequestIsCancelled(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	path := filepath.Join(baseDir, "WU01/E/ProjA/a.raw")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, make([]byte, 10), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, baseDir)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if projects, err := svc.FindProjects(ctx); !errors.Is(err, context.Canceled) || projects != nil {
+		t.Fatalf("FindProjects() = %v, %v, want context.Canceled and no partial result", projects, err)
+	}
+	if _, err := svc.EstimateRemainingBytes(ctx, "ProjA", false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("EstimateRemainingBytes() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestExportTagFiltersSelectCaptures(t *testing.T) {
+	t.Parallel()
+
+	opts := ExportOptions{Tags: []string{"cloudy", "haze"}, ExcludeTags: []string{"re-fly"}}
+	for _, tc := range []struct {
+		tags []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"haze"}, true},
+		{[]string{"cloudy", "re-fly"}, false},
+	} {
+		if got := opts.tagsAllow(tc.tags); got != tc.want {
+			t.Fatalf("tagsAllow(%v) = %v, want %v", tc.tags, got, tc.want)
+		}
+	}
+	if !(ExportOptions{ExcludeTags: []string{"re-fly"}}).tagsAllow(nil) {
+		t.Fatal("an untagged capture was left out by an exclude-only filter")
+	}
+}
+
+func TestIOBudgetSplitsTheRateAmongBusyJobs(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	budget := newIOBudget(IOBudgetOptions{
+		MaxMBps: 4,
+		Weights: map[string]float64{IOJobSync: 3, IOJobExport: 1},
+	})
+	budget.now = func() time.Time { return now }
+
+	// Alone, sync gets the whole budget: 4 MiB take a second.
+	if delay := budget.reserve(IOJobSync, 4<<20); delay != time.Second {
+		t.Fatalf("sync alone waits %v, want 1s", delay)
+	}
+
+	// With sync busy, export gets a quarter: 1 MiB/s.
+	if delay := budget.reserve(IOJobExport, 1<<20); delay != time.Second {
+		t.Fatalf("export beside sync waits %v, want 1s", delay)
+	}
+	status := budget.status()
+	if len(status.Jobs) != 2 || status.Jobs[0].Job != IOJobExport || status.Jobs[0].LimitMBps != 1 || status.Jobs[1].LimitMBps != 3 {
+		t.Fatalf("budget status = %+v, want export 1 MB/s and sync 3 MB/s", status)
+	}
+
+	// Once sync has been idle past the window, export has the budget to itself.
+	now = now.Add(ioBudgetActiveWindow + time.Second)
+	if delay := budget.reserve(IOJobExport, 4<<20); delay != time.Second {
+		t.Fatalf("export alone waits %v, want 1s", delay)
+	}
+	if status := budget.status(); len(status.Jobs) != 1 || status.Jobs[0].Share != 1 {
+		t.Fatalf("budget status = %+v, want export alone", status)
+	}
+}
+
+func TestIOBudgetWaitStopsWithTheContext(t *testing.T) {
+	t.Parallel()
+
+	budget := newIOBudget(IOBudgetOptions{MaxMBps: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := budget.wait(ctx, IOJobVerify, 10<<20); !errors.Is(err, context.Canceled) {
+		t.Fatalf("wait() error = %v, want context.Canceled", err)
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount-a")
+	svc.SetIOBudget(IOBudgetOptions{})
+	if svc.currentIOBudget() != nil {
+		t.Fatal("a zero max_mbps must leave I/O unlimited")
+	}
+}
+
+func TestChangeNotifyListsOnlyChangedFolders(t *testing.T) {
+	t.Parallel()
+
+	source := filepath.Join(t.TempDir(), "Project")
+	for _, dir := range []string{"CU", "WU01/Raw"} {
+		if err := os.MkdirAll(filepath.Join(source, dir), 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+	}
+	writeFile := func(rel string) string {
+		path := filepath.Join(source, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create folder of %s: %v", rel, err)
+		}
+		if err := os.WriteFile(path, []byte(rel), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", rel, err)
+		}
+		return path
+	}
+	writeFile("CU/old.xml")
+	writeFile("WU01/Raw/old.raw")
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount-a")
+	svc.SetChangeNotify(ChangeNotifyOptions{Enabled: true, FullRescanInterval: time.Hour})
+	defer svc.closeSourceWatches()
+
+	files, watch, full, err := svc.listSource(context.Background(), source)
+	if err != nil || !full || len(files) != 2 || watch == nil {
+		t.Fatalf("first listSource() = %v, full %v, err %v; want a full listing of 2 files", files, full, err)
+	}
+
+	changed := writeFile("WU01/Raw/new.raw")
+	created := writeFile("WU01/Raw/Sub/deep.raw")
+	var listed []string
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		files, _, full, err = svc.listSource(context.Background(), source)
+		if err != nil || full {
+			t.Fatalf("listSource() full %v, err %v; want an incremental listing", full, err)
+		}
+		listed = append(listed, files...)
+		if containsString(listed, changed) && containsString(listed, created) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	for _, file := range listed {
+		if strings.HasSuffix(file, "old.xml") {
+			t.Fatalf("incremental listing %v includes the unchanged CU folder", listed)
+		}
+	}
+	if !containsString(listed, changed) || !containsString(listed, created) {
+		t.Fatalf("incremental listings %v, want %s and %s", listed, changed, created)
+	}
+
+	// A queued file is listed again until it is copied.
+	watch.keep([]string{changed})
+	if files, _, _, _ := svc.listSource(context.Background(), source); !containsString(files, changed) {
+		t.Fatalf("listSource() = %v, want the pending %s", files, changed)
+	}
+	watch.release(changed)
+	if files, _, _, _ := svc.listSource(context.Background(), source); containsString(files, changed) {
+		t.Fatalf("listSource() = %v, want %s released", files, changed)
+	}
+}
+
+func containsString(values []string, want string) bool {
+	for _, value := range values {
+		if value == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCopyFilePatchesChangedDestinationInPlace(t *testing.T) {
+	t.Parallel()
+
+	const blockSize = 4096
+	rng := rand.New(rand.NewSource(1))
+	old := make([]byte, 64*blockSize+100)
+	rng.Read(old)
+	extra := make([]byte, 3000)
+	rng.Read(extra)
+
+	modified := append([]byte(nil), old...)
+	copy(modified[20*blockSize+10:], extra[:500])
+
+	// Shifted data must be moved, so only the part before the shift is saved.
+	cases := map[string]struct {
+		updated  []byte
+		minSaved int64
+	}{
+		"appended": {append(append([]byte(nil), old...), extra...), int64(len(old) - blockSize)},
+		"modified": {modified, int64(len(old) - 2*blockSize)},
+		"inserted": {append(append(append([]byte(nil), old[:10*blockSize+7]...), extra...), old[10*blockSize+7:]...), 10 * blockSize},
+		"removed":  {append(append([]byte(nil), old[:5*blockSize]...), old[9*blockSize+33:]...), 5 * blockSize},
+	}
+	for name, tc := range cases {
+		name, updated, minSaved := name, tc.updated, tc.minSaved
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			baseDir := t.TempDir()
+			sourceRoot := filepath.Join(baseDir, "source")
+			destRoot := filepath.Join(baseDir, "dest")
+			for _, dir := range []string{sourceRoot, destRoot} {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			sourcePath := filepath.Join(sourceRoot, "mission.log")
+			destPath := filepath.Join(destRoot, "mission.log")
+			if err := os.WriteFile(sourcePath, updated, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(destPath, old, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+			svc.SetDeltaCopy(DeltaCopyOptions{Enabled: true, BlockSize: blockSize})
+
+			task := &taskInfo{node: "WU01", share: "E$"}
+			if err := svc.copyFile(context.Background(), task, sourcePath, sourceRoot, destRoot); err != nil {
+				t.Fatalf("copyFile() error = %v", err)
+			}
+
+			data, err := os.ReadFile(destPath)
+			if err != nil || !bytes.Equal(data, updated) {
+				t.Fatalf("patched copy differs from source (%d bytes, want %d), err = %v", len(data), len(updated), err)
+			}
+			svc.mu.RLock()
+			totals := svc.runTotalsLocked()
+			svc.mu.RUnlock()
+			if totals.DeltaFiles != 1 || totals.CopiedBytes != int64(len(updated)) {
+				t.Fatalf("totals = %+v, want one delta file of %d bytes", totals, len(updated))
+			}
+			if saved := totals.DeltaSavedBytes; saved < minSaved {
+				t.Fatalf("delta saved %d of %d bytes, want at least %d", saved, len(updated), minSaved)
+			}
+		})
+	}
+}
+
+func TestCopyFileDeltaLeavesHardlinkedSnapshotIntact(t *testing.T) {
+	t.Parallel()
+
+	const blockSize = 4096
+	baseDir := t.TempDir()
+	sourceRoot := filepath.Join(baseDir, "source")
+	destRoot := filepath.Join(baseDir, "dest")
+	for _, dir := range []string{sourceRoot, destRoot} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := bytes.Repeat([]byte("snapshot"), 8*blockSize)
+	updated := append(append([]byte(nil), old...), "appended"...)
+	sourcePath := filepath.Join(sourceRoot, "mission.log")
+	destPath := filepath.Join(destRoot, "mission.log")
+	snapshotPath := filepath.Join(baseDir, "previous-mission.log")
+	if err := os.WriteFile(sourcePath, updated, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(snapshotPath, old, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(snapshotPath, destPath); err != nil {
+		t.Skipf("hardlinks not supported: %v", err)
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetDeltaCopy(DeltaCopyOptions{Enabled: true, BlockSize: blockSize})
+	task := &taskInfo{node: "WU01", share: "E$"}
+	if err := svc.copyFile(context.Background(), task, sourcePath, sourceRoot, destRoot); err != nil {
+		t.Fatalf("copyFile() error = %v", err)
+	}
+
+	if data, err := os.ReadFile(destPath); err != nil || !bytes.Equal(data, updated) {
+		t.Fatalf("patched copy differs from source, err = %v", err)
+	}
+	if data, err := os.ReadFile(snapshotPath); err != nil || !bytes.Equal(data, old) {
+		t.Fatalf("previous snapshot changed by the delta copy, err = %v", err)
+	}
+	if _, err := os.Stat(svc.tempPathFor(destPath)); !os.IsNotExist(err) {
+		t.Fatalf("temp file left behind, stat error = %v", err)
+	}
+}
+
+// corruptOnceSource delivers corrupted data on the first open, as a flaky
+// SMB transfer would, and the real data afterwards.
+type corruptOnceSource struct {
+	mapSource
+	corrupt mapSource
+	opens   int32
+}
+
+func (c *corruptOnceSource) Open(path string) (storage.File, error) {
+	if atomic.AddInt32(&c.opens, 1) == 1 {
+		return c.corrupt.Open(path)
+	}
+	return c.mapSource.Open(path)
+}
+
+func TestCopyFileVerifyChecksumsRejectsCorruptedCopy(t *testing.T) {
+	t.Parallel()
+
+	destRoot := t.TempDir()
+	mtime := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	name := "Lvl00-00005-Project-00-00-ABCDEF01_2345_6789_ABCD_EF0123456789.raw"
+	path := "ucmount/WU01/E/Project/" + name
+	source := &corruptOnceSource{
+		mapSource: mapSource{fsys: fstest.MapFS{path: {Data: []byte("capture payload"), ModTime: mtime}}},
+		corrupt:   mapSource{fsys: fstest.MapFS{path: {Data: []byte("capture pay1oad"), ModTime: mtime}}},
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetStorage(source, nil)
+	svc.SetVerifyChecksums(true)
+	svc.mu.Lock()
+	svc.project = "Project"
+	svc.mu.Unlock()
+
+	sourceRoot := "/ucmount/WU01/E/Project"
+	sourcePath := "/" + path
+	destPath := filepath.Join(destRoot, name)
+	task := &taskInfo{node: "WU01", share: "E$"}
+
+	if err := svc.copyFile(context.Background(), task, sourcePath, sourceRoot, destRoot); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("copyFile() error = %v, want ErrChecksumMismatch", err)
+	}
+	if _, err := os.Stat(destPath); !os.IsNotExist(err) {
+		t.Fatalf("mismatched copy left behind, stat error = %v", err)
+	}
+
+	if err := svc.copyFile(context.Background(), task, sourcePath, sourceRoot, destRoot); err != nil {
+		t.Fatalf("second copyFile() error = %v", err)
+	}
+	if data, err := os.ReadFile(destPath); err != nil || string(data) != "capture payload" {
+		t.Fatalf("copied file = %q, %v", data, err)
+	}
+
+	svc.mu.RLock()
+	totals := svc.runTotalsLocked()
+	svc.mu.RUnlock()
+	if totals.ChecksumMismatches != 1 || totals.CopiedFiles != 1 {
+		t.Fatalf("totals = %+v, want 1 mismatch and 1 copied file", totals)
+	}
+}
+
+// failingSource serves files whose reads fail after the first few bytes, as
+// a dropped network connection would.
+type failingSource struct {
+	mapSource
+}
+
+type failingFile struct {
+	storage.File
+	read int
+}
+
+func (f *failingFile) Read(p []byte) (int, error) {
+	if f.read >= 4 {
+		return 0, errors.New("connection reset by peer")
+	}
+	if len(p) > 4-f.read {
+		p = p[:4-f.read]
+	}
+	n, err := f.File.Read(p)
+	f.read += n
+	return n, err
+}
+
+func (f failingSource) Open(path string) (storage.File, error) {
+	file, err := f.mapSource.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &failingFile{File: file}, nil
+}
+
+func TestCopyFileLeavesNoPartialFileBehind(t *testing.T) {
+	t.Parallel()
+
+	destRoot := t.TempDir()
+	name := "Lvl00-00005-Project-00-00-ABCDEF01_2345_6789_ABCD_EF0123456789.raw"
+	path := "ucmount/WU01/E/Project/" + name
+	source := failingSource{mapSource{fsys: fstest.MapFS{path: {Data: []byte("capture payload"), ModTime: time.Now()}}}}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetStorage(source, nil)
+
+	task := &taskInfo{node: "WU01", share: "E$"}
+	if err := svc.copyFile(context.Background(), task, "/"+path, "/ucmount/WU01/E/Project", destRoot); err == nil {
+		t.Fatal("copyFile() succeeded with a failing source")
+	}
+	entries, err := os.ReadDir(destRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("destination holds %v after a failed copy, want nothing", entries)
+	}
+}
+
+func TestCopyFileResumesInterruptedCopy(t *testing.T) {
+	t.Parallel()
+
+	destRoot := t.TempDir()
+	name := "Lvl00-00005-Project-00-00-ABCDEF01_2345_6789_ABCD_EF0123456789.raw"
+	path := "ucmount/WU01/E/Project/" + name
+	payload := []byte("capture payload of a large raw")
+	fsys := fstest.MapFS{path: {Data: payload, ModTime: time.Now()}}
+	tmpPath := filepath.Join(destRoot, name+tempFileSuffix)
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetResume(ResumeOptions{Enabled: true, VerifyBytes: 2})
+	task := &taskInfo{node: "WU01", share: "E$"}
+
+	// The share drops after 4 bytes; the partial copy is kept.
+	svc.SetStorage(failingSource{mapSource{fsys: fsys}}, nil)
+	if err := svc.copyFile(context.Background(), task, "/"+path, "/ucmount/WU01/E/Project", destRoot); err == nil {
+		t.Fatal("copyFile() succeeded with a failing source")
+	}
+	if info, err := os.Stat(tmpPath); err != nil || info.Size() != 4 {
+		t.Fatalf("partial copy = %v, %v, want 4 bytes kept", info, err)
+	}
+
+	svc.SetStorage(mapSource{fsys: fsys}, nil)
+	if err := svc.copyFile(context.Background(), task, "/"+path, "/ucmount/WU01/E/Project", destRoot); err != nil {
+		t.Fatalf("copyFile() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(destRoot, name))
+	if err != nil || !bytes.Equal(data, payload) {
+		t.Fatalf("resumed copy = %q, %v, want %q", data, err, payload)
+	}
+	svc.mu.RLock()
+	totals := svc.runTotalsLocked()
+	svc.mu.RUnlock()
+	if totals.ResumedFiles != 1 || totals.ResumedBytes != 4 {
+		t.Fatalf("totals = %+v, want one copy resumed after 4 bytes", totals)
+	}
+}
+
+func TestCopyFileRestartsCopyWhosePrefixDiffers(t *testing.T) {
+	t.Parallel()
+
+	destRoot := t.TempDir()
+	name := "Lvl00-00005-Project-00-00-ABCDEF01_2345_6789_ABCD_EF0123456789.raw"
+	path := "ucmount/WU01/E/Project/" + name
+	payload := []byte("capture payload of a large raw")
+	destPath := filepath.Join(destRoot, name)
+	// Zeros where the data written just before a power loss should be.
+	if err := os.WriteFile(destPath+tempFileSuffix, append([]byte("capture "), 0, 0, 0, 0), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetResume(ResumeOptions{Enabled: true, VerifyBytes: 4})
+	svc.SetStorage(mapSource{fsys: fstest.MapFS{path: {Data: payload, ModTime: time.Now()}}}, nil)
+
+	task := &taskInfo{node: "WU01", share: "E$"}
+	if err := svc.copyFile(context.Background(), task, "/"+path, "/ucmount/WU01/E/Project", destRoot); err != nil {
+		t.Fatalf("copyFile() error = %v", err)
+	}
+	data, err := os.ReadFile(destPath)
+	if err != nil || !bytes.Equal(data, payload) {
+		t.Fatalf("copy = %q, %v, want %q", data, err, payload)
+	}
+	svc.mu.RLock()
+	totals := svc.runTotalsLocked()
+	svc.mu.RUnlock()
+	if totals.ResumedFiles != 0 {
+		t.Fatalf("totals = %+v, want the copy restarted from zero", totals)
+	}
+}
+
+func TestCopyFileDoesNotAdoptHardlinkedOrOlderCopy(t *testing.T) {
+	t.Parallel()
+
+	name := "Lvl00-00005-Project-00-00-ABCDEF01_2345_6789_ABCD_EF0123456789.raw"
+	path := "ucmount/WU01/E/Project/" + name
+	payload := []byte("capture payload of a large raw")
+	srcModTime := time.Now().Add(-time.Hour)
+	// A copy of an earlier version whose start matches the new source.
+	older := []byte("capture payload")
+
+	for _, tc := range []struct {
+		name    string
+		linked  bool
+		modTime time.Time
+	}{
+		{name: "hardlinked into a snapshot", linked: true, modTime: time.Now()},
+		{name: "finished copy of an older version", modTime: srcModTime.Add(-time.Hour)},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			destRoot := t.TempDir()
+			destPath := filepath.Join(destRoot, name)
+			if err := os.WriteFile(destPath, older, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(destPath, tc.modTime, tc.modTime); err != nil {
+				t.Fatal(err)
+			}
+			snapshotPath := filepath.Join(t.TempDir(), name)
+			if tc.linked {
+				if err := os.Link(destPath, snapshotPath); err != nil {
+					t.Skipf("hardlinks unsupported: %v", err)
+				}
+			}
+
+			svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+			svc.SetResume(ResumeOptions{Enabled: true, VerifyBytes: 4})
+			svc.SetStorage(mapSource{fsys: fstest.MapFS{path: {Data: payload, ModTime: srcModTime}}}, nil)
+
+			task := &taskInfo{node: "WU01", share: "E$"}
+			if err := svc.copyFile(context.Background(), task, "/"+path, "/ucmount/WU01/E/Project", destRoot); err != nil {
+				t.Fatalf("copyFile() error = %v", err)
+			}
+			data, err := os.ReadFile(destPath)
+			if err != nil || !bytes.Equal(data, payload) {
+				t.Fatalf("copy = %q, %v, want %q", data, err, payload)
+			}
+			svc.mu.RLock()
+			totals := svc.runTotalsLocked()
+			svc.mu.RUnlock()
+			if totals.ResumedFiles != 0 {
+				t.Fatalf("totals = %+v, want the copy made from the start", totals)
+			}
+			if tc.linked {
+				if data, err := os.ReadFile(snapshotPath); err != nil || !bytes.Equal(data, older) {
+					t.Fatalf("snapshot = %q, %v, want %q untouched", data, err, older)
+				}
+			}
+		})
+	}
+}
+
+// slowSource serves files that never end, 32 KB per millisecond.
+type slowSource struct {
+	mapSource
+}
+
+type slowFile struct {
+	storage.File
+}
+
+func (f slowFile) Read(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	if len(p) > 32<<10 {
+		p = p[:32<<10]
+	}
+	return len(p), nil
+}
+
+func (s slowSource) Open(path string) (storage.File, error) {
+	file, err := s.mapSource.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return slowFile{File: file}, nil
+}
+
+func TestCopyFileStopsMidFileWhenCancelled(t *testing.T) {
+	t.Parallel()
+
+	destRoot := t.TempDir()
+	name := "Lvl00-00005-Project-00-00-ABCDEF01_2345_6789_ABCD_EF0123456789.raw"
+	path := "ucmount/WU01/E/Project/" + name
+	source := slowSource{mapSource{fsys: fstest.MapFS{path: {Data: []byte("x"), ModTime: time.Now()}}}}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetStorage(source, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	started := time.Now()
+	task := &taskInfo{node: "WU01", share: "E$"}
+	err := svc.copyFile(ctx, task, "/"+path, "/ucmount/WU01/E/Project", destRoot)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("copyFile() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("copyFile() returned %v after cancellation, want within a second", elapsed)
+	}
+	if entries, _ := os.ReadDir(destRoot); len(entries) != 0 {
+		t.Fatalf("destination holds %v after a cancelled copy, want nothing", entries)
+	}
+}
+
+func TestRemoveStaleTempFilesCleansEveryDatedProjectFolder(t *testing.T) {
+	t.Parallel()
+
+	destination := t.TempDir()
+	files := map[string]bool{ // path -> kept
+		"2026-10-14/Arh2k/a.raw.ucxtmp":        false,
+		"2026-10-14/Arh2k/a.raw":               true,
+		"2026-10-16/Arh2k/sub/b.raw.ucxtmp":    false,
+		"2026-10-16/Other/c.raw.ucxtmp":        true,
+		"2026-10-16/Arh2k/EAD-00001.xml":       true,
+		"2026-10-16/Arh2k/b.raw.ucxtmp.report": true,
+	}
+	for path := range files {
+		full := filepath.Join(destination, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.removeStaleTempFiles(context.Background(), destination, "Arh2k")
+
+	for path, kept := range files {
+		_, err := os.Stat(filepath.Join(destination, path))
+		if exists := err == nil; exists != kept {
+			t.Fatalf("%s exists = %v, want %v", path, exists, kept)
+		}
+	}
+}
+
+func TestHeartbeatIsWrittenToTheRunFolder(t *testing.T) {
+	t.Parallel()
+
+	runDir := t.TempDir()
+	started := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetHeartbeat(HeartbeatOptions{Enabled: true, Interval: time.Hour})
+	svc.mu.Lock()
+	svc.project = "Arh2k"
+	svc.destDir = runDir
+	svc.startedAt = started
+	svc.jobID = newJobID("Arh2k", started)
+	svc.paused = true
+	svc.pauseReason = "destination full"
+	svc.mu.Unlock()
+	atomic.StoreInt32(&svc.completedCaptures, 42)
+	atomic.StoreInt32(&svc.runCopiedFiles, 588)
+
+	read := func() models.DestinationHeartbeat {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(runDir, HeartbeatName))
+		if err != nil {
+			t.Fatalf("read heartbeat: %v", err)
+		}
+		var heartbeat models.DestinationHeartbeat
+		if err := json.Unmarshal(data, &heartbeat); err != nil {
+			t.Fatalf("decode heartbeat: %v", err)
+		}
+		return heartbeat
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	svc.wg.Add(1)
+	go svc.heartbeatLoop(ctx)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(filepath.Join(runDir, HeartbeatName)); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no heartbeat written when the job started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	heartbeat := read()
+	if heartbeat.State != "paused" || heartbeat.PauseReason != "destination full" {
+		t.Fatalf("state = %q (%q), want paused", heartbeat.State, heartbeat.PauseReason)
+	}
+	if !strings.HasPrefix(heartbeat.JobID, "Arh2k-20261016T080000Z-") || heartbeat.CompletedCaptures != 42 || heartbeat.CopiedFiles != 588 || !heartbeat.StartedAt.Equal(started) {
+		t.Fatalf("heartbeat = %+v", heartbeat)
+	}
+
+	cancel()
+	svc.wg.Wait()
+	if heartbeat := read(); heartbeat.State != "stopped" {
+		t.Fatalf("state after stop = %q, want stopped", heartbeat.State)
+	}
+	if _, err := os.Stat(filepath.Join(runDir, HeartbeatName+tempFileSuffix)); !os.IsNotExist(err) {
+		t.Fatalf("temporary heartbeat left behind: %v", err)
+	}
+}
+
+func TestBuildSensorStatsFlagsSmallAndMissingSensors(t *testing.T) {
+	t.Parallel()
+
+	var files []state.CopiedFile
+	for capture := 1; capture <= 4; capture++ {
+		for _, sensor := range requiredSensorCodes {
+			size := int64(100 << 20)
+			switch {
+			case sensor == "02-01":
+				size = 10 << 20 // Truncated frames
+			case sensor == "07-00" && capture == 3:
+				continue // Frame missing
+			}
+			name := fmt.Sprintf("Lvl0X-%05d-Arh2k-%s-BD11EBB0_BE00_4BE7_BC66_9DED8D740C2E.raw", capture, sensor)
+			files = append(files, state.CopiedFile{RelativePath: "WU01/" + name, Size: size})
+		}
+	}
+	files = append(files, state.CopiedFile{RelativePath: "CU/EAD-00001-Arh2k-BD11EBB0_BE00_4BE7_BC66_9DED8D740C2E.xml", Size: 1})
+
+	report := buildSensorStats("Arh2k", files)
+	if report.Captures != 4 || len(report.Sensors) != len(requiredSensorCodes) {
+		t.Fatalf("report = %d captures, %d sensors, want 4 and %d", report.Captures, len(report.Sensors), len(requiredSensorCodes))
+	}
+	for _, stats := range report.Sensors {
+		switch stats.SensorCode {
+		case "02-01":
+			if !stats.Suspect || stats.AverageBytes != 10<<20 || stats.SizeRatio > 0.11 {
+				t.Fatalf("02-01 = %+v, want suspect at a tenth of the median", stats)
+			}
+		case "07-00":
+			if !stats.Suspect || stats.MissingCaptures != 1 || stats.Files != 3 {
+				t.Fatalf("07-00 = %+v, want suspect with one missing capture", stats)
+			}
+		default:
+			if stats.Suspect || stats.Files != 4 || stats.Bytes != 400<<20 {
+				t.Fatalf("%s = %+v, want four healthy files", stats.SensorCode, stats)
+			}
+		}
+	}
+}
+
+func TestRetryBackoffDoublesUpToMax(t *testing.T) {
+	t.Parallel()
+
+	opts := RetryOptions{InitialBackoff: 5 * time.Second, MaxBackoff: time.Minute}
+	want := []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute}
+	for i, w := range want {
+		if got := retryBackoff(opts, i+1); got != w {
+			t.Fatalf("retryBackoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestQueuedRetryFollowsDestinationRollover(t *testing.T) {
+	t.Parallel()
+
+	oldDisk, newDisk := t.TempDir(), t.TempDir()
+	name := "Lvl00-00005-Project-00-00-ABCDEF01_2345_6789_ABCD_EF0123456789.raw"
+	path := "ucmount/WU01/E/Project/" + name
+	payload := []byte("capture payload")
+	fsys := fstest.MapFS{path: {Data: payload, ModTime: time.Now()}}
+	source := "/ucmount/WU01/E/Project"
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetRetryBackoff(RetryOptions{InitialBackoff: time.Minute, MaxBackoff: time.Hour})
+	svc.globalSemaphore = make(chan struct{}, 1)
+	svc.resolveMount = func(path string) (mountInfo, error) {
+		if strings.HasPrefix(path, newDisk) {
+			return mountInfo{MountPoint: newDisk, Device: "/dev/sdc1"}, nil
+		}
+		return mountInfo{MountPoint: oldDisk, Device: "/dev/sdb1"}, nil
+	}
+	oldRun := filepath.Join(oldDisk, "2026-05-01", "Project")
+	svc.mu.Lock()
+	svc.isRunning = true
+	svc.project = "Project"
+	svc.destination = oldDisk
+	svc.destDir = oldRun
+	svc.destinationMount = mountInfo{MountPoint: oldDisk, Device: "/dev/sdb1"}
+	svc.mu.Unlock()
+
+	// The old disk filled up just before the roll-over.
+	task := &taskInfo{node: "WU01", share: "E$"}
+	ctx := context.Background()
+	svc.SetStorage(failingSource{mapSource{fsys: fsys}}, nil)
+	err := svc.copyFile(ctx, task, "/"+path, source, oldRun)
+	if err == nil {
+		t.Fatal("copyFile() succeeded with a failing source")
+	}
+	svc.handleCopyError(ctx, task, "/"+path, source, err)
+	due := *svc.GetStatus().Retries[0].NextRetryAt
+
+	svc.destIndex.remember(filepath.Join(oldRun, "other.raw"), destIndexEntry{size: 1, modTime: time.Now()})
+	if err := svc.SwitchDestination(newDisk); err != nil {
+		t.Fatalf("SwitchDestination() error = %v", err)
+	}
+	if svc.destIndex.entries != nil {
+		t.Fatal("SwitchDestination kept the index of the old disk")
+	}
+	svc.mu.RLock()
+	newRun := svc.destDir
+	svc.mu.RUnlock()
+
+	svc.SetStorage(mapSource{fsys: fsys}, nil)
+	svc.runDueRetries(ctx, due)
+	svc.wg.Wait()
+
+	if svc.isPaused() {
+		t.Fatalf("job paused after the retry: %s", svc.GetStatus().PauseReason)
+	}
+	data, err := os.ReadFile(filepath.Join(newRun, name))
+	if err != nil || !bytes.Equal(data, payload) {
+		t.Fatalf("retried copy on the new disk = %q, %v, want %q", data, err, payload)
+	}
+	if svc.retryQueued("/" + path) {
+		t.Fatal("file still queued after the retry")
+	}
+}
+
+func TestFailedCopyIsRetriedFromQueue(t *testing.T) {
+	t.Parallel()
+
+	destRoot := t.TempDir()
+	name := "Lvl00-00005-Project-00-00-ABCDEF01_2345_6789_ABCD_EF0123456789.raw"
+	path := "ucmount/WU01/E/Project/" + name
+	payload := []byte("capture payload")
+	fsys := fstest.MapFS{path: {Data: payload, ModTime: time.Now()}}
+	source := "/ucmount/WU01/E/Project"
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetRetryBackoff(RetryOptions{InitialBackoff: time.Minute, MaxBackoff: time.Hour})
+	svc.globalSemaphore = make(chan struct{}, 1)
+	svc.destDir = destRoot
+	task := &taskInfo{node: "WU01", share: "E$"}
+	ctx := context.Background()
+
+	svc.SetStorage(failingSource{mapSource{fsys: fsys}}, nil)
+	err := svc.copyFile(ctx, task, "/"+path, source, destRoot)
+	if err == nil {
+		t.Fatal("copyFile() succeeded with a failing source")
+	}
+	svc.handleCopyError(ctx, task, "/"+path, source, err)
+
+	status := svc.GetStatus()
+	if status.PendingRetries != 1 || len(status.Retries) != 1 || status.Retries[0].NextRetryAt == nil {
+		t.Fatalf("status retries = %d %+v, want the file queued with its next attempt", status.PendingRetries, status.Retries)
+	}
+	due := *status.Retries[0].NextRetryAt
+	if wait := due.Sub(status.Retries[0].LastFailedAt); wait != time.Minute {
+		t.Fatalf("next attempt after %v, want the initial backoff", wait)
+	}
+	if !svc.retryQueued("/" + path) {
+		t.Fatal("retryQueued() = false, want scans to skip the queued file")
+	}
+
+	// Not due yet: nothing is attempted.
+	svc.SetStorage(mapSource{fsys: fsys}, nil)
+	svc.runDueRetries(ctx, due.Add(-time.Second))
+	svc.wg.Wait()
+	if _, err := os.Stat(filepath.Join(destRoot, name)); !os.IsNotExist(err) {
+		t.Fatalf("copy before the retry was due: %v", err)
+	}
+
+	svc.runDueRetries(ctx, due)
+	svc.wg.Wait()
+	data, err := os.ReadFile(filepath.Join(destRoot, name))
+	if err != nil || !bytes.Equal(data, payload) {
+		t.Fatalf("retried copy = %q, %v, want %q", data, err, payload)
+	}
+	if svc.retryQueued("/"+path) || svc.hasCopyFailure("/"+path) {
+		t.Fatal("file still queued or failed after a successful retry")
+	}
+	if status := svc.GetStatus(); status.PendingRetries != 0 {
+		t.Fatalf("PendingRetries = %d after the retry, want 0", status.PendingRetries)
+	}
+}
+
+func TestFileFilterLimitsScannedAndCopiedFiles(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	source := filepath.Join(baseDir, "WU01", "E", "ProjA")
+	for _, name := range []string{"a.raw", "b.XML", "scratch.tmp", "c.raw.tmp", "notes.txt", "sub/d.RAW"} {
+		path := filepath.Join(source, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, baseDir)
+	svc.SetFileFilter(FileFilter{Include: []string{"*.raw", " *.xml", "*.tmp"}, Exclude: []string{"*.TMP"}})
+
+	files, err := svc.scanDirectory(context.Background(), source, source)
+	if err != nil {
+		t.Fatalf("scanDirectory() error = %v", err)
+	}
+	var names []string
+	for _, file := range files {
+		rel, _ := filepath.Rel(source, file)
+		names = append(names, filepath.ToSlash(rel))
+	}
+	sort.Strings(names)
+	if want := []string{"a.raw", "b.XML", "sub/d.RAW"}; strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("scanned %v, want %v", names, want)
+	}
+
+	if svc.shouldCopyFile(filepath.Join(source, "scratch.tmp"), source, t.TempDir()) {
+		t.Fatal("shouldCopyFile() = true for an excluded file")
+	}
+}
+
+func TestHoldScansFollowsCopyQueueDepth(t *testing.T) {
+	t.Parallel()
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetBackpressure(BackpressureOptions{Enabled: true, HighWater: 10, LowWater: 2, MaxDelay: time.Minute})
+	task := &taskInfo{node: "WU01", share: "E$", totalFiles: 12}
+	svc.activeTasks["WU01-E$"] = task
+	now := time.Now()
+
+	if !svc.holdScans(now) {
+		t.Fatal("holdScans() = false with 12 files queued, want scans held")
+	}
+	// Below high water but above low water: still held.
+	atomic.StoreInt32(&task.copiedFiles, 6)
+	if !svc.holdScans(now.Add(10 * time.Second)) {
+		t.Fatal("holdScans() = false before the queue drained to low water")
+	}
+	if status := svc.GetStatus(); !status.ScansHeld || status.QueuedFiles != 6 {
+		t.Fatalf("status scans_held = %v, queued_files = %d, want held with 6", status.ScansHeld, status.QueuedFiles)
+	}
+	atomic.StoreInt32(&task.copiedFiles, 9)
+	atomic.StoreInt32(&task.failedFiles, 1)
+	if svc.holdScans(now.Add(20 * time.Second)) {
+		t.Fatal("holdScans() = true after the queue drained to low water")
+	}
+
+	// A queue that never drains lets a scan through after MaxDelay.
+	atomic.StoreInt32(&task.totalFiles, 50)
+	if !svc.holdScans(now.Add(30 * time.Second)) {
+		t.Fatal("holdScans() = false with 40 files queued")
+	}
+	if svc.holdScans(now.Add(90 * time.Second)) {
+		t.Fatal("holdScans() = true after max_delay")
+	}
+
+	svc.mu.RLock()
+	totals := svc.runTotalsLocked()
+	svc.mu.RUnlock()
+	if totals.ScansDeferred != 3 {
+		t.Fatalf("ScansDeferred = %d, want 3", totals.ScansDeferred)
+	}
+}
+
+func TestSmallFilesAreCopiedWhileLargeCopiesHoldEverySlot(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	sourceRoot := filepath.Join(baseDir, "source")
+	destRoot := filepath.Join(baseDir, "dest")
+	for _, dir := range []string{sourceRoot, destRoot} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, size := range map[string]int{"capture.raw": 4096, "capture.xml": 64} {
+		if err := os.WriteFile(filepath.Join(sourceRoot, name), bytes.Repeat([]byte("x"), size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetSmallFiles(SmallFileOptions{Enabled: true, MaxSize: 1024, Workers: 1})
+	// Every regular slot is taken by copies of other shares.
+	svc.globalSemaphore = make(chan struct{}, 1)
+	svc.globalSemaphore <- struct{}{}
+	svc.smallSemaphore = make(chan struct{}, 1)
+	task := &taskInfo{node: "WU01", share: "E$"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- svc.syncDirectory(ctx, task, sourceRoot, destRoot) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(filepath.Join(destRoot, "capture.xml")); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			cancel()
+			t.Fatal("capture.xml not copied while the raw waited for a slot")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := os.Stat(filepath.Join(destRoot, "capture.raw")); !os.IsNotExist(err) {
+		t.Fatalf("capture.raw copied without a free slot: %v", err)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("syncDirectory() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestEADMetadataIsCopiedBeforeRawsWithoutACopySlot(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	sourceRoot := filepath.Join(baseDir, "source")
+	destRoot := filepath.Join(baseDir, "dest")
+	for _, dir := range []string{sourceRoot, destRoot} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const xmlName = "EAD-00001-Arh2k_mezen_200725-BD11EBB0_BE00_4BE7_BC66_9DED8D740C2E.xml"
+	const rawName = "Lvl00-00001-Arh2k_mezen_200725-00-00-BD11EBB0_BE00_4BE7_BC66_9DED8D740C2E.raw"
+	for name, size := range map[string]int{rawName: 4096, xmlName: 64} {
+		if err := os.WriteFile(filepath.Join(sourceRoot, name), bytes.Repeat([]byte("x"), size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svc := New([]string{"CU"}, []string{"E$"}, "/ucmount")
+	// No small-file pool, and every copy slot is taken by other nodes' raws.
+	svc.globalSemaphore = make(chan struct{}, 1)
+	svc.globalSemaphore <- struct{}{}
+	task := &taskInfo{node: "CU", share: "E$"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- svc.syncDirectory(ctx, task, sourceRoot, destRoot) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(filepath.Join(destRoot, xmlName)); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			cancel()
+			t.Fatal("EAD XML not copied while the raw waited for a slot")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := os.Stat(filepath.Join(destRoot, rawName)); !os.IsNotExist(err) {
+		t.Fatalf("raw copied without a free slot: %v", err)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("syncDirectory() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestSortByCaptureOrdersFilesChronologically(t *testing.T) {
+	t.Parallel()
+
+	const session = "BD11EBB0_BE00_4BE7_BC66_9DED8D740C2E"
+	files := []string{
+		"/src/Lvl00-00010-ProjA-00-00-" + session + ".raw",
+		"/src/notes.txt",
+		"/src/Lvl00-00002-ProjA-00-01-" + session + ".raw",
+		"/src/EAD-00010-ProjA-" + session + ".xml",
+		"/src/RawQv-00001-ProjA-" + session + ".dat",
+		"/src/Lvl00-00002-ProjA-00-00-" + session + ".raw",
+		"/src/log.txt",
+	}
+	sortByCapture(files)
+
+	want := []string{
+		"/src/RawQv-00001-ProjA-" + session + ".dat",
+		"/src/Lvl00-00002-ProjA-00-01-" + session + ".raw",
+		"/src/Lvl00-00002-ProjA-00-00-" + session + ".raw",
+		"/src/Lvl00-00010-ProjA-00-00-" + session + ".raw",
+		"/src/EAD-00010-ProjA-" + session + ".xml",
+		"/src/notes.txt",
+		"/src/log.txt",
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Fatalf("sortByCapture() = %v, want %v", files, want)
+		}
+	}
+}
+
+func TestWorkerPoolMetricsCountSlotUse(t *testing.T) {
+	t.Parallel()
+
+	svc := New([]string{"CU", "WU01"}, []string{"E$"}, "/ucmount")
+	svc.globalSemaphore = make(chan struct{}, 2)
+	svc.isRunning = true
+	svc.startedAt = time.Now().Add(-time.Second)
+	svc.retryQueue = map[string]*queuedRetry{"a": {node: "CU"}}
+
+	done := svc.useSlot(PoolCopy, "CU", 250*time.Millisecond)
+	svc.useSlot(PoolPriority, "WU01", 0)()
+
+	metrics := svc.WorkerPoolMetrics()
+	if !metrics.Running || metrics.PendingRetries != 1 {
+		t.Fatalf("metrics = %+v, want a running job with one pending retry", metrics)
+	}
+	if len(metrics.Pools) != 2 || metrics.Pools[0].Name != PoolCopy || metrics.Pools[1].Name != PoolPriority {
+		t.Fatalf("pools = %+v, want copy and priority without a small-file pool", metrics.Pools)
+	}
+	if copyPool := metrics.Pools[0]; copyPool.Capacity != 2 || copyPool.Active != 1 || copyPool.Copies != 0 || copyPool.WaitSeconds != 0.25 {
+		t.Fatalf("copy pool = %+v, want one copy in flight after a 250ms wait", copyPool)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	done()
+	metrics = svc.WorkerPoolMetrics()
+	copyPool := metrics.Pools[0]
+	if copyPool.Active != 0 || copyPool.Copies != 1 || copyPool.BusySeconds <= 0 || copyPool.Utilization <= 0 || copyPool.Utilization > 1 {
+		t.Fatalf("copy pool = %+v, want one finished copy and a utilization in (0, 1]", copyPool)
+	}
+	if len(metrics.Nodes) != 2 || metrics.Nodes[0].Node != "CU" || metrics.Nodes[0].Copies != 1 || metrics.Nodes[1].Node != "WU01" {
+		t.Fatalf("nodes = %+v, want CU and WU01 with a copy each", metrics.Nodes)
+	}
+
+	svc.isRunning = false
+	if idle := svc.WorkerPoolMetrics(); idle.Running || idle.Pools != nil {
+		t.Fatalf("idle metrics = %+v, want nothing but Running=false", idle)
+	}
+}
+
+// recordingDestination is the local destination remembering the files
+// created and failing renames with renameErr.
+type recordingDestination struct {
+	storage.Local
+	mu        sync.Mutex
+	created   []string
+	renameErr error
+}
+
+func (d *recordingDestination) Create(path string) (storage.WriteFile, error) {
+	d.mu.Lock()
+	d.created = append(d.created, path)
+	d.mu.Unlock()
+	return d.Local.Create(path)
+}
+
+func (d *recordingDestination) Rename(oldpath, newpath string) error {
+	if d.renameErr != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: d.renameErr}
+	}
+	return d.Local.Rename(oldpath, newpath)
+}
+
+func TestTempDirHoldsCopiesUntilTheRename(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	sourceRoot := filepath.Join(baseDir, "source")
+	destination := filepath.Join(baseDir, "dest")
+	destRoot := filepath.Join(destination, "2026-05-01", "ProjA")
+	for _, dir := range []string{sourceRoot, destRoot} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const filename = "Lvl00-00001-ProjA-00-00-ABCDEF01_2345_6789_ABCD_EF0123456789.raw"
+	if err := os.WriteFile(filepath.Join(sourceRoot, filename), []byte("raw payload"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	target := &recordingDestination{}
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetStorage(nil, target)
+	svc.SetTempDir(".ucxsync-tmp")
+	svc.mu.Lock()
+	svc.project = "ProjA"
+	svc.destination = destination
+	svc.mu.Unlock()
+
+	tempRoot := filepath.Join(destination, ".ucxsync-tmp")
+	if err := svc.checkTempDir(tempRoot, destRoot); err != nil {
+		t.Fatalf("checkTempDir() = %v, want nil on one filesystem", err)
+	}
+
+	task := &taskInfo{node: "WU01", share: "E$"}
+	if err := svc.copyFile(context.Background(), task, filepath.Join(sourceRoot, filename), sourceRoot, destRoot); err != nil {
+		t.Fatalf("copyFile() error = %v", err)
+	}
+	want := filepath.Join(tempRoot, "2026-05-01", "ProjA", filename) + tempFileSuffix
+	if last := target.created[len(target.created)-1]; last != want {
+		t.Fatalf("copy written to %s, want %s", last, want)
+	}
+	if data, err := os.ReadFile(filepath.Join(destRoot, filename)); err != nil || string(data) != "raw payload" {
+		t.Fatalf("destination file = %q, %v, want the payload", data, err)
+	}
+	if _, err := os.Stat(want); !os.IsNotExist(err) {
+		t.Fatalf("temp copy left behind: %v", err)
+	}
+
+	target.renameErr = syscall.EXDEV
+	if err := svc.checkTempDir(tempRoot, destRoot); !errors.Is(err, ErrTempDirCrossDevice) {
+		t.Fatalf("checkTempDir() = %v, want ErrTempDirCrossDevice", err)
+	}
+	if probes, _ := filepath.Glob(filepath.Join(tempRoot, "*"+tempFileSuffix)); len(probes) != 0 {
+		t.Fatalf("probe files left behind: %v", probes)
+	}
+}
+
+func TestFilesStillBeingWrittenWaitForTheNextScan(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	sourceRoot := filepath.Join(baseDir, "source")
+	destRoot := filepath.Join(baseDir, "dest")
+	for _, dir := range []string{sourceRoot, destRoot} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const growing = "Lvl00-00002-ProjA-00-00-ABCDEF01_2345_6789_ABCD_EF0123456789.raw"
+	const old = "Lvl00-00001-ProjA-00-00-ABCDEF01_2345_6789_ABCD_EF0123456789.raw"
+	for _, name := range []string{growing, old} {
+		if err := os.WriteFile(filepath.Join(sourceRoot, name), []byte("half"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	written := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(sourceRoot, old), written, written); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetStability(StabilityOptions{Enabled: true, MinAge: time.Minute})
+	svc.globalSemaphore = make(chan struct{}, 1)
+	scan := func() {
+		t.Helper()
+		task := &taskInfo{node: "WU01", share: "E$"}
+		if err := svc.syncDirectory(context.Background(), task, sourceRoot, destRoot); err != nil {
+			t.Fatalf("syncDirectory() error = %v", err)
+		}
+	}
+	copied := func(name string) bool {
+		_, err := os.Stat(filepath.Join(destRoot, name))
+		return err == nil
+	}
+
+	scan()
+	if !copied(old) || copied(growing) {
+		t.Fatalf("first scan copied old=%v growing=%v, want only the file written an hour ago", copied(old), copied(growing))
+	}
+	if metrics := svc.ScanMetrics(); len(metrics) != 1 || metrics[0].FilesUnsettled != 1 {
+		t.Fatalf("scan metrics = %+v, want one unsettled file", metrics)
+	}
+
+	// Still growing: the next scan sees another size and waits again.
+	if err := os.WriteFile(filepath.Join(sourceRoot, growing), []byte("half and more"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	scan()
+	if copied(growing) {
+		t.Fatal("file copied while its size still changed")
+	}
+
+	scan()
+	if data, err := os.ReadFile(filepath.Join(destRoot, growing)); err != nil || string(data) != "half and more" {
+		t.Fatalf("settled file = %q, %v, want the complete file", data, err)
+	}
+}
+
+func TestMoveModeFreesSourcesOfVerifiedCompleteCaptures(t *testing.T) {
+	t.Parallel()
+
+	for _, mode := range []string{MoveModeDelete, MoveModeRename} {
+		baseDir := t.TempDir()
+		shareRoot := filepath.Join(baseDir, "share")
+		sourceRoot := filepath.Join(shareRoot, "ProjA")
+		destRoot := filepath.Join(baseDir, "dest")
+		for _, dir := range []string{sourceRoot, destRoot} {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		files := []string{
+			"Lvl00-00001-ProjA-00-00-ABCDEF01_2345_6789_ABCD_EF0123456789.raw",
+			"EAD-00001-ProjA-ABCDEF01_2345_6789_ABCD_EF0123456789.xml",
+			"RawQv-00001-ProjA-ABCDEF01_2345_6789_ABCD_EF0123456789.dat",
+		}
+		for _, name := range files {
+			if err := os.WriteFile(filepath.Join(sourceRoot, name), []byte(name), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+		svc.SetMove(MoveOptions{Enabled: true, Mode: mode, Folder: ".ucxsync-synced"})
+		svc.mu.Lock()
+		svc.project = "ProjA"
+		svc.destDir = destRoot
+		svc.requiredSensors = map[string]struct{}{"00-00": {}}
+		svc.captureTracker = make(map[string]map[string]bool)
+		svc.moveCandidates = make(map[string][]moveFile)
+		svc.mu.Unlock()
+		// Stand in for the worker startMoveLocked would run.
+		queue := make(chan moveJob, 1)
+		svc.moveQueue = queue
+
+		task := &taskInfo{node: "WU01", share: "E$"}
+		for i, name := range files {
+			if err := svc.copyFile(context.Background(), task, filepath.Join(sourceRoot, name), sourceRoot, destRoot); err != nil {
+				t.Fatalf("copyFile(%s) error = %v", name, err)
+			}
+			if i < len(files)-1 && len(queue) != 0 {
+				t.Fatalf("%s: capture queued for moving before it was complete", mode)
+			}
+		}
+		// A file that no longer matches its copy stays on the node.
+		if err := os.WriteFile(filepath.Join(destRoot, files[1]), []byte("corrupt"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case job := <-queue:
+			svc.moveCaptureFiles(context.Background(), job)
+		default:
+			t.Fatalf("%s: completed capture was not queued for moving", mode)
+		}
+
+		for i, name := range files {
+			_, err := os.Stat(filepath.Join(sourceRoot, name))
+			if kept := i == 1; kept != (err == nil) {
+				t.Fatalf("%s: source %s present = %v, want %v", mode, name, err == nil, kept)
+			}
+		}
+		if mode == MoveModeRename {
+			aside := filepath.Join(shareRoot, ".ucxsync-synced", "ProjA", files[0])
+			if data, err := os.ReadFile(aside); err != nil || string(data) != files[0] {
+				t.Fatalf("renamed source = %q, %v, want it set aside", data, err)
+			}
+		}
+
+		audit, err := os.ReadFile(filepath.Join(destRoot, moveAuditName))
+		if err != nil {
+			t.Fatalf("audit log: %v", err)
+		}
+		actions := map[string]int{}
+		for _, line := range strings.Split(strings.TrimSpace(string(audit)), "\n") {
+			var entry moveAuditEntry
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatalf("audit line %q: %v", line, err)
+			}
+			actions[entry.Action]++
+		}
+		want := map[string]int{"kept": 1, "deleted": 2}
+		if mode == MoveModeRename {
+			want = map[string]int{"kept": 1, "renamed": 2}
+		}
+		if fmt.Sprint(actions) != fmt.Sprint(want) {
+			t.Fatalf("%s: audit actions = %v, want %v", mode, actions, want)
+		}
+		svc.mu.RLock()
+		totals := svc.runTotalsLocked()
+		svc.mu.RUnlock()
+		if totals.MovedFiles != 2 {
+			t.Fatalf("%s: MovedFiles = %d, want 2", mode, totals.MovedFiles)
+		}
+	}
+}
+
+func TestVerifiedCopyRecordsItsChecksum(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	sourceRoot := filepath.Join(baseDir, "source")
+	destRoot := filepath.Join(baseDir, "dest")
+	for _, dir := range []string{sourceRoot, destRoot} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store, err := state.New(filepath.Join(baseDir, "state.db"), "ucxsync-test")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	if err := svc.SetStateStore(store); err != nil {
+		t.Fatalf("SetStateStore returned error: %v", err)
+	}
+	svc.SetVerifyChecksums(true)
+	svc.mu.Lock()
+	svc.project = "ProjA"
+	svc.mu.Unlock()
+
+	const filename = "Lvl00-00001-ProjA-00-00-ABCDEF01_2345_6789_ABCD_EF0123456789.raw"
+	if err := os.WriteFile(filepath.Join(sourceRoot, filename), []byte("raw payload"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	task := &taskInfo{node: "WU01", share: "E$"}
+	if err := svc.copyFile(context.Background(), task, filepath.Join(sourceRoot, filename), sourceRoot, destRoot); err != nil {
+		t.Fatalf("copyFile() error = %v", err)
+	}
+
+	files, err := store.ListCopiedFiles("ProjA")
+	if err != nil || len(files) != 1 {
+		t.Fatalf("ListCopiedFiles = %+v, %v, want the copy", files, err)
+	}
+	if want := fmt.Sprintf("%x", sha256.Sum256([]byte("raw payload"))); files[0].SHA256 != want {
+		t.Fatalf("SHA256 = %q, want %q", files[0].SHA256, want)
+	}
+}
+
+func TestJobLogRecordsTheCopiesAndFailuresOfTheJob(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	sourceRoot := filepath.Join(baseDir, "source")
+	destRoot := filepath.Join(baseDir, "dest")
+	for _, dir := range []string{sourceRoot, destRoot} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const filename = "Lvl00-00001-ProjA-00-00-ABCDEF01_2345_6789_ABCD_EF0123456789.raw"
+	if err := os.WriteFile(filepath.Join(sourceRoot, filename), []byte("raw payload"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetJobLog(true)
+	svc.SetVerifyChecksums(true)
+	svc.mu.Lock()
+	svc.project = "ProjA"
+	svc.jobID = "ProjA-20260501T000000Z-abcdef"
+	svc.destDir = destRoot
+	svc.mu.Unlock()
+
+	task := &taskInfo{node: "WU01", share: "E$"}
+	if err := svc.copyFile(context.Background(), task, filepath.Join(sourceRoot, filename), sourceRoot, destRoot); err != nil {
+		t.Fatalf("copyFile() error = %v", err)
+	}
+	missing := filepath.Join(sourceRoot, "missing.raw")
+	svc.handleCopyError(context.Background(), task, missing, sourceRoot, os.ErrNotExist)
+	svc.jobLog.close()
+
+	data, err := os.ReadFile(filepath.Join(destRoot, "ucxsync-job-ProjA-20260501T000000Z-abcdef.jsonl"))
+	if err != nil {
+		t.Fatalf("job log: %v", err)
+	}
+	var entries []jobLogEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry jobLogEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("job log line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("job log = %+v, want a copy and a failure", entries)
+	}
+	copied := entries[0]
+	wantSum := fmt.Sprintf("%x", sha256.Sum256([]byte("raw payload")))
+	if copied.Event != jobEventCopied || copied.Job != "ProjA-20260501T000000Z-abcdef" || copied.Node != "WU01" ||
+		copied.Dest != filepath.Join(destRoot, filename) || copied.Bytes != int64(len("raw payload")) || copied.SHA256 != wantSum {
+		t.Fatalf("copied entry = %+v", copied)
+	}
+	if failed := entries[1]; failed.Event != jobEventCopyFailed || failed.Source != missing || failed.Error == "" {
+		t.Fatalf("failure entry = %+v", failed)
+	}
+}
+
+func TestStartAndStopRecordTheJobInTheHistory(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	store, err := state.New(filepath.Join(baseDir, "state.db"), "ucxsync-test")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	svc := New(nil, nil, filepath.Join(baseDir, "mnt"))
+	if err := svc.SetStateStore(store); err != nil {
+		t.Fatalf("SetStateStore returned error: %v", err)
+	}
+	if err := svc.Start(context.Background(), "ProjA", filepath.Join(baseDir, "dest"), 1, false); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	svc.mu.RLock()
+	jobID := svc.jobID
+	svc.mu.RUnlock()
+
+	jobs, err := store.ListJobs("", 10)
+	if err != nil || len(jobs) != 1 || jobs[0].JobID != jobID || jobs[0].State != models.JobRunning {
+		t.Fatalf("ListJobs while running = %+v, %v, want the running job", jobs, err)
+	}
+
+	atomic.StoreInt32(&svc.runCopiedFiles, 3)
+	atomic.StoreInt64(&svc.runCopiedBytes, 4096)
+	atomic.StoreInt32(&svc.runFailedFiles, 1)
+	svc.Stop()
+
+	jobs, err = store.ListJobs("ProjA", 10)
+	if err != nil || len(jobs) != 1 {
+		t.Fatalf("ListJobs after stop = %+v, %v, want one job", jobs, err)
+	}
+	job := jobs[0]
+	if job.State != models.JobStopped || job.FinishedAt == nil || job.Project != "ProjA" ||
+		job.Totals.CopiedFiles != 3 || job.Totals.CopiedBytes != 4096 || job.Totals.FailedFiles != 1 {
+		t.Fatalf("job = %+v, want the stopped job with its totals", job)
+	}
+}
+
+// statCountingDestination counts the destination stats of the engine.
+type statCountingDestination struct {
+	storage.Local
+	stats int32
+}
+
+func (d *statCountingDestination) Stat(path string) (fs.FileInfo, error) {
+	atomic.AddInt32(&d.stats, 1)
+	return d.Local.Stat(path)
+}
+
+func TestDestinationIndexSkipsTheStatOfUnchangedCopies(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	sourceRoot := filepath.Join(baseDir, "source")
+	destRoot := filepath.Join(baseDir, "dest")
+	for _, dir := range []string{sourceRoot, destRoot} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const filename = "Lvl00-00001-ProjA-00-00-ABCDEF01_2345_6789_ABCD_EF0123456789.raw"
+	sourcePath := filepath.Join(sourceRoot, filename)
+	destPath := filepath.Join(destRoot, filename)
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for _, path := range []string{sourcePath, destPath} {
+		if err := os.WriteFile(path, []byte("raw payload"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dest := &statCountingDestination{}
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	svc.SetStorage(nil, dest)
+	svc.SetDestinationIndex(DestinationIndexOptions{Enabled: true, RefreshInterval: time.Hour})
+
+	for i := 0; i < 3; i++ {
+		if svc.shouldCopyFile(sourcePath, sourceRoot, destRoot) {
+			t.Fatalf("scan %d: shouldCopyFile() = true for an up-to-date copy", i)
+		}
+	}
+	if stats := atomic.LoadInt32(&dest.stats); stats != 1 {
+		t.Fatalf("destination stats = %d, want only the first scan to stat the copy", stats)
+	}
+
+	// A changed source misses the index and is looked at again.
+	if err := os.WriteFile(sourcePath, []byte("raw payload, rewritten"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !svc.shouldCopyFile(sourcePath, sourceRoot, destRoot) {
+		t.Fatal("shouldCopyFile() = false for a changed source")
+	}
+
+	// Past the refresh interval the index is rebuilt from fresh stats.
+	if err := os.WriteFile(sourcePath, []byte("raw payload"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(sourcePath, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	svc.destIndex.mu.Lock()
+	svc.destIndex.builtAt = time.Now().Add(-2 * time.Hour)
+	svc.destIndex.mu.Unlock()
+	if err := os.Remove(destPath); err != nil {
+		t.Fatal(err)
+	}
+	if !svc.shouldCopyFile(sourcePath, sourceRoot, destRoot) {
+		t.Fatal("shouldCopyFile() = false for a copy deleted behind the index once it was refreshed")
+	}
+}
+
+func TestJobRunFoldersGiveEachStartAFolderOfItsOwn(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	destination := filepath.Join(baseDir, "dest")
+	svc := New(nil, nil, filepath.Join(baseDir, "mnt"))
+	svc.SetRunFolderLayout(RunFolderByJob)
+
+	var runDirs []string
+	for i := 0; i < 2; i++ {
+		if err := svc.Start(context.Background(), "ProjA", destination, 1, false); err != nil {
+			t.Fatalf("Start returned error: %v", err)
+		}
+		svc.mu.RLock()
+		jobID := svc.jobID
+		svc.mu.RUnlock()
+		runDir := svc.RunDir()
+		svc.Stop()
+
+		if filepath.Dir(runDir) != filepath.Join(destination, "ProjA") || !strings.HasSuffix(runDir, "_"+jobID) {
+			t.Fatalf("run folder = %q, want <destination>/ProjA/<date>_%s", runDir, jobID)
+		}
+		runDirs = append(runDirs, runDir)
+	}
+	if runDirs[0] == runDirs[1] {
+		t.Fatalf("both jobs wrote to %q, want a folder each", runDirs[0])
+	}
+}
+
+func TestProjectRunFoldersListsBothLayoutsOldestFirst(t *testing.T) {
+	t.Parallel()
+
+	destination := t.TempDir()
+	for _, dir := range []string{
+		"2026-10-16/ProjA",
+		"2026-10-14/ProjA",
+		"2026-10-14/ProjB",
+		"ProjA/2026-10-15_ProjA-20261015T080000Z-abcdef",
+		"ProjA/2026-10-16_ProjA-20261016T060000Z-123456",
+		".ucxsync-tmp/ProjA",
+	} {
+		if err := os.MkdirAll(filepath.Join(destination, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(destination, "ProjA", "notes.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dirs, err := ProjectRunFolders(destination, "ProjA")
+	if err != nil {
+		t.Fatalf("ProjectRunFolders returned error: %v", err)
+	}
+	want := []string{
+		filepath.Join(destination, "2026-10-14/ProjA"),
+		filepath.Join(destination, "ProjA/2026-10-15_ProjA-20261015T080000Z-abcdef"),
+		filepath.Join(destination, "2026-10-16/ProjA"),
+		filepath.Join(destination, "ProjA/2026-10-16_ProjA-20261016T060000Z-123456"),
+	}
+	if strings.Join(dirs, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("ProjectRunFolders = %v, want %v", dirs, want)
+	}
+}
+
+func TestPauseNodeCancelsOnlyThatNodesTasks(t *testing.T) {
+	t.Parallel()
+
+	svc := New([]string{"WU01", "WU02"}, []string{"E$"}, "/ucmount")
+	if err := svc.PauseNode("WU01"); err == nil {
+		t.Fatal("PauseNode succeeded without a running job")
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	defer cancel1()
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	svc.mu.Lock()
+	svc.isRunning = true
+	svc.idleFinish = time.Millisecond
+	svc.activeTasks["WU01-E$"] = &taskInfo{node: "WU01", share: "E$", cancel: cancel1}
+	svc.activeTasks["WU02-E$"] = &taskInfo{node: "WU02", share: "E$", cancel: cancel2}
+	svc.mu.Unlock()
+
+	if err := svc.PauseNode("WU77"); err == nil {
+		t.Fatal("PauseNode accepted an unknown node")
+	}
+	if err := svc.PauseNode("wu01"); err != nil {
+		t.Fatalf("PauseNode returned error: %v", err)
+	}
+	if ctx1.Err() == nil || ctx2.Err() != nil {
+		t.Fatalf("task contexts = %v, %v, want only the WU01 task cancelled", ctx1.Err(), ctx2.Err())
+	}
+	if nodes := svc.GetStatus().PausedNodes; len(nodes) != 1 || nodes[0] != "WU01" {
+		t.Fatalf("PausedNodes = %v, want WU01", nodes)
+	}
+	if !svc.isNodePaused("WU01") || svc.isNodePaused("WU02") {
+		t.Fatal("isNodePaused does not match the paused node")
+	}
+
+	svc.mu.Lock()
+	svc.activeTasks = make(map[string]*taskInfo)
+	svc.mu.Unlock()
+	if svc.idleFinishDue(time.Now().Add(time.Hour)) {
+		t.Fatal("idle finish due while a node is paused")
+	}
+
+	if err := svc.ResumeNode("WU01"); err != nil {
+		t.Fatalf("ResumeNode returned error: %v", err)
+	}
+	if nodes := svc.PausedNodes(); len(nodes) != 0 {
+		t.Fatalf("PausedNodes after resume = %v, want none", nodes)
+	}
+}
+
+func TestImmediateChangeNotifyWakesTheSyncLoop(t *testing.T) {
+	t.Parallel()
+
+	source := filepath.Join(t.TempDir(), "Project")
+	if err := os.MkdirAll(filepath.Join(source, "CU"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount-a")
+	svc.SetServiceLoopInterval(time.Hour)
+	svc.SetChangeNotify(ChangeNotifyOptions{Enabled: true, FullRescanInterval: time.Hour, Immediate: true, Debounce: 50 * time.Millisecond})
+	defer svc.closeSourceWatches()
+
+	iterations := make(chan struct{}, 4)
+	svc.syncIterationFunc = func(ctx context.Context, destDir string) {
+		// Stands in for the scan of the share, which watches its folders.
+		svc.listSource(ctx, source)
+		iterations <- struct{}{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	svc.wg.Add(1)
+	go svc.syncLoop(ctx, "/tmp/dest")
+
+	select {
+	case <-iterations:
+	case <-time.After(2 * time.Second):
+		t.Fatal("syncLoop did not run its first iteration")
+	}
+
+	// A burst of files, as a capture writes them, wakes one iteration long
+	// before the hour-long loop interval.
+	for i := 0; i < 3; i++ {
+		name := fmt.Sprintf("EAD-0000%d.xml", i)
+		if err := os.WriteFile(filepath.Join(source, "CU", name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case <-iterations:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a source change did not wake the sync loop")
+	}
+}
+
+func TestWaitForChangesToSettleWaitsForQuietButNotForever(t *testing.T) {
+	t.Parallel()
+
+	svc := New([]string{"WU01"}, []string{"E$"}, "/ucmount-a")
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				svc.signalSourceChanged()
+			}
+		}
+	}()
+
+	started := time.Now()
+	if !svc.waitForChangesToSettle(context.Background(), 50*time.Millisecond, 200*time.Millisecond) {
+		t.Fatal("waitForChangesToSettle() = false without cancellation")
+	}
+	if elapsed := time.Since(started); elapsed < 150*time.Millisecond || elapsed > 2*time.Second {
+		t.Fatalf("waited %v under constant changes, want about the 200ms limit", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if svc.waitForChangesToSettle(ctx, 50*time.Millisecond, time.Second) {
+		t.Fatal("waitForChangesToSettle() = true after cancellation")
+	}
+}
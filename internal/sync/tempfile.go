@@ -0,0 +1,156 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// tempFileSuffix marks a copy still being written. copyFile renames it to
+// the real name only once the data is complete.
+const tempFileSuffix = ".ucxtmp"
+
+// Temporary names of exported files and spot-check repairs.
+const (
+	exportTempSuffix    = ".ucxexport"
+	spotCheckTempSuffix = ".spotcheck"
+)
+
+// IsTempFile reports whether name is a file UCXSync is still writing: a
+// copy, which resumable copies keep between runs, an export or a spot-check
+// repair not yet renamed to its real name.
+func IsTempFile(name string) bool {
+	for _, suffix := range []string{tempFileSuffix, exportTempSuffix, spotCheckTempSuffix} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeStaleTempFiles deletes the temporary files that copies cut short by
+// a crash or power loss left in the project's run folders of the destination
+// and the temp dir. It runs before the first copy of a run, so no temporary
+// file of this run exists yet. With resumable copies the files are kept
+// instead.
+func (s *Service) removeStaleTempFiles(ctx context.Context, destination, project string) {
+	if !s.localDestination() || destination == "" || project == "" {
+		return
+	}
+
+	projectDirs, err := ProjectRunFolders(destination, project)
+	if err != nil {
+		return
+	}
+	s.mu.RLock()
+	root := tempRoot(s.tempDir, destination)
+	s.mu.RUnlock()
+	if root != "" {
+		tempDirs, _ := ProjectRunFolders(root, project)
+		projectDirs = append(projectDirs, tempDirs...)
+	}
+
+	resume := s.resumeOptions().Enabled
+	removed := 0
+	for _, dir := range projectDirs {
+		filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if err != nil || entry.IsDir() || !strings.HasSuffix(entry.Name(), tempFileSuffix) {
+				return nil
+			}
+			if resume {
+				// Kept for its copy to resume; a partial copy can be
+				// smaller than resume.min_size.
+				return nil
+			}
+			if err := os.Remove(path); err != nil {
+				log.Warn().Err(err).Str("file", path).Msg("Failed to remove stale temporary copy")
+				return nil
+			}
+			removed++
+			return nil
+		})
+	}
+	if removed > 0 {
+		log.Info().Int("files", removed).Str("destination", destination).Str("project", project).Msg("Removed stale temporary copies")
+	}
+}
+
+// ErrTempDirCrossDevice means copies written to the temp directory could
+// not be renamed into the destination.
+var ErrTempDirCrossDevice = errors.New("temp directory is not on the destination filesystem")
+
+// SetTempDir sets the folder copies are written to before they are renamed
+// into place. Empty writes them next to the file; a relative dir is a folder
+// inside the destination.
+func (s *Service) SetTempDir(dir string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tempDir = dir
+}
+
+// tempRoot resolves dir against destination; "" means no temp dir.
+func tempRoot(dir, destination string) string {
+	if dir == "" || filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(destination, dir)
+}
+
+// tempPathFor returns where the copy to destPath is written. Under a temp
+// dir the path below the destination is kept, so copies of different
+// projects and days never share a name.
+func (s *Service) tempPathFor(destPath string) string {
+	s.mu.RLock()
+	root := tempRoot(s.tempDir, s.destination)
+	destination := s.destination
+	s.mu.RUnlock()
+
+	if root == "" {
+		return destPath + tempFileSuffix
+	}
+	rel, err := filepath.Rel(destination, destPath)
+	if err != nil || !filepath.IsLocal(rel) {
+		return destPath + tempFileSuffix
+	}
+	return filepath.Join(root, rel) + tempFileSuffix
+}
+
+// checkTempDir renames a probe file from the temp dir root into destDir, so
+// a temp dir on another filesystem fails the job start instead of every
+// copy. An empty root needs no check.
+func (s *Service) checkTempDir(root, destDir string) error {
+	if root == "" {
+		return nil
+	}
+
+	dest := s.destinationStorage()
+	if err := dest.MkdirAll(root); err != nil {
+		return fmt.Errorf("failed to create temp directory %s: %w", root, err)
+	}
+	name := fmt.Sprintf(".ucxsync-probe-%d%s", time.Now().UnixNano(), tempFileSuffix)
+	probe := filepath.Join(root, name)
+	file, err := dest.Create(probe)
+	if err != nil {
+		return fmt.Errorf("failed to write to temp directory %s: %w", root, err)
+	}
+	file.Close()
+
+	target := filepath.Join(destDir, name)
+	if err := dest.Rename(probe, target); err != nil {
+		dest.Remove(probe)
+		return fmt.Errorf("%w: %s to %s: %v", ErrTempDirCrossDevice, root, destDir, err)
+	}
+	dest.Remove(target)
+	return nil
+}
@@ -0,0 +1,390 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/state"
+	"github.com/zangezia/UCXSync/internal/storage"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+const (
+	// verifyCheckpointBytes is how much of a large file is hashed between
+	// checkpoints of its SHA-256 state.
+	verifyCheckpointBytes = 1 << 30
+	// verifySaveInterval throttles checkpoints written between files.
+	verifySaveInterval = 5 * time.Second
+)
+
+// ErrVerifyRunning is returned when a verify pass is already in progress.
+var ErrVerifyRunning = errors.New("verification already running")
+
+// ErrDestinationNotReadable is returned when the destination storage cannot
+// read copied files back.
+var ErrDestinationNotReadable = errors.New("destination storage cannot be read back")
+
+// VerifyLastRun compares every file copied by the most recently finished run
+// with its source by size and SHA-256. Copies the memory guard dropped from a
+// very long run count as skipped.
+//
+// Progress is checkpointed, in the state database when one is attached, so a
+// pass interrupted by a stop or a restart resumes at the file, and the byte
+// offset within it, where it left off. A pass resumes when the last run has
+// the same folder and file count, or when no run has finished since the
+// restart.
+//
+// With a sample percentage set, see SetVerifySampling, a new pass hashes only
+// that share of the files plus every XML metadata file.
+func (s *Service) VerifyLastRun(ctx context.Context) (models.VerifyResult, error) {
+	if _, ok := s.destinationStorage().(storage.Reader); !ok {
+		return models.VerifyResult{}, ErrDestinationNotReadable
+	}
+	checkpoint, files, err := s.beginVerify()
+	if err != nil {
+		return models.VerifyResult{}, err
+	}
+	defer func() {
+		s.mu.Lock()
+		s.verifyRunning = false
+		s.mu.Unlock()
+	}()
+
+	result := models.VerifyResult{ResumedFrom: checkpoint.NextIndex}
+	if checkpoint.NextIndex > 0 || checkpoint.FileOffset > 0 {
+		log.Info().
+			Str("run_dir", checkpoint.RunDir).
+			Int("done", checkpoint.NextIndex).
+			Int("total", checkpoint.Total).
+			Int64("file_offset", checkpoint.FileOffset).
+			Msg("Resuming interrupted verification")
+	}
+
+	lastSave := time.Now()
+	for checkpoint.NextIndex < len(files) {
+		if err := ctx.Err(); err != nil {
+			s.saveVerifyCheckpoint(checkpoint)
+			return verifyResult(result, checkpoint), err
+		}
+
+		file := files[checkpoint.NextIndex]
+		match, err := s.verifyFile(ctx, file, &checkpoint)
+		if ctx.Err() != nil {
+			s.saveVerifyCheckpoint(checkpoint)
+			return verifyResult(result, checkpoint), ctx.Err()
+		}
+		switch {
+		case err != nil:
+			checkpoint.Skipped++
+		case !match:
+			checkpoint.Checked++
+			checkpoint.Mismatched = append(checkpoint.Mismatched, file.Dest)
+		default:
+			checkpoint.Checked++
+		}
+		checkpoint.NextIndex++
+		checkpoint.FileOffset = 0
+		checkpoint.SourceHash = nil
+		checkpoint.DestHash = nil
+
+		if time.Since(lastSave) >= verifySaveInterval {
+			s.saveVerifyCheckpoint(checkpoint)
+			lastSave = time.Now()
+		}
+	}
+
+	s.finishVerify()
+	return verifyResult(result, checkpoint), nil
+}
+
+func verifyResult(result models.VerifyResult, checkpoint state.VerifyCheckpoint) models.VerifyResult {
+	result.Checked = checkpoint.Checked
+	result.Skipped = checkpoint.Skipped
+	result.NotSampled = checkpoint.NotSampled
+	result.Mismatched = checkpoint.Mismatched
+	return result
+}
+
+// beginVerify returns the checkpoint and files of the pass to run: the
+// interrupted one when it belongs to the last run, otherwise a new pass
+// over the last run's copies.
+func (s *Service) beginVerify() (state.VerifyCheckpoint, []state.VerifyFile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.verifyRunning {
+		return state.VerifyCheckpoint{}, nil, ErrVerifyRunning
+	}
+
+	runDir := s.lastRunDir
+	copies := s.lastRunCopies
+	store := s.stateStore
+	percent := s.verifySamplePercent
+
+	pending, files, err := s.pendingVerifyLocked()
+	if err != nil {
+		return state.VerifyCheckpoint{}, nil, err
+	}
+	if pending != nil && (len(copies) == 0 || (pending.RunDir == runDir && pending.Total+pending.NotSampled == len(copies))) {
+		s.verifyRunning = true
+		s.verifyCheckpoint = pending
+		return *pending, files, nil
+	}
+
+	files = make([]state.VerifyFile, len(copies))
+	for i, copied := range copies {
+		files[i] = state.VerifyFile{Source: copied.source, Dest: copied.dest}
+	}
+	files = sampleVerifyFiles(files, percent, rand.New(rand.NewSource(time.Now().UnixNano())))
+	now := time.Now()
+	checkpoint := state.VerifyCheckpoint{
+		RunDir:     runDir,
+		Total:      len(files),
+		NotSampled: len(copies) - len(files),
+		Skipped:    s.lastRunCopiesDropped,
+		StartedAt:  now,
+		UpdatedAt:  now,
+	}
+	if store != nil {
+		if err := store.StartVerify(runDir, files, now); err != nil {
+			return state.VerifyCheckpoint{}, nil, fmt.Errorf("failed to record verification: %w", err)
+		}
+		if checkpoint.Skipped > 0 || checkpoint.NotSampled > 0 {
+			if err := store.SaveVerifyCheckpoint(checkpoint); err != nil {
+				return state.VerifyCheckpoint{}, nil, fmt.Errorf("failed to record verification: %w", err)
+			}
+		}
+		s.verifyFiles = nil
+	} else {
+		s.verifyFiles = files
+	}
+	s.verifyRunning = true
+	s.verifyCheckpoint = &checkpoint
+	return checkpoint, files, nil
+}
+
+// SetVerifySampling makes verify passes hash only percent of a run's files,
+// picked at random, plus all of its XML metadata, for a quick integrity check
+// when the disk has to leave soon. 0 or 100 verifies every file. It takes
+// effect with the next pass; an interrupted pass resumes as it started.
+func (s *Service) SetVerifySampling(percent float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.verifySamplePercent = percent
+}
+
+// sampleVerifyFiles returns the XML metadata files and a random percent of
+// the others, in their original order.
+func sampleVerifyFiles(files []state.VerifyFile, percent float64, rnd *rand.Rand) []state.VerifyFile {
+	if percent <= 0 || percent >= 100 {
+		return files
+	}
+
+	var sampled, others []int
+	for i, file := range files {
+		if isEADMetadataFile(file.Dest) {
+			sampled = append(sampled, i)
+		} else {
+			others = append(others, i)
+		}
+	}
+	rnd.Shuffle(len(others), func(i, j int) { others[i], others[j] = others[j], others[i] })
+	count := int(math.Ceil(float64(len(others)) * percent / 100))
+	sampled = append(sampled, others[:count]...)
+	sort.Ints(sampled)
+
+	result := make([]state.VerifyFile, len(sampled))
+	for i, index := range sampled {
+		result[i] = files[index]
+	}
+	return result
+}
+
+// pendingVerifyLocked returns the interrupted verify pass, from memory or
+// the state database. Caller must hold s.mu.
+func (s *Service) pendingVerifyLocked() (*state.VerifyCheckpoint, []state.VerifyFile, error) {
+	if s.stateStore == nil {
+		if s.verifyCheckpoint == nil {
+			return nil, nil, nil
+		}
+		checkpoint := *s.verifyCheckpoint
+		return &checkpoint, s.verifyFiles, nil
+	}
+
+	checkpoint, ok, err := s.stateStore.LoadVerifyCheckpoint()
+	if err != nil || !ok {
+		return nil, nil, err
+	}
+	files, err := s.stateStore.LoadVerifyFiles()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &checkpoint, files, nil
+}
+
+func (s *Service) saveVerifyCheckpoint(checkpoint state.VerifyCheckpoint) {
+	checkpoint.UpdatedAt = time.Now()
+	checkpoint.Mismatched = append([]string(nil), checkpoint.Mismatched...)
+
+	s.mu.Lock()
+	s.verifyCheckpoint = &checkpoint
+	store := s.stateStore
+	s.mu.Unlock()
+
+	if store != nil {
+		if err := store.SaveVerifyCheckpoint(checkpoint); err != nil {
+			log.Warn().Err(err).Msg("Failed to save verification checkpoint")
+		}
+	}
+}
+
+func (s *Service) finishVerify() {
+	s.mu.Lock()
+	s.verifyCheckpoint = nil
+	s.verifyFiles = nil
+	store := s.stateStore
+	s.mu.Unlock()
+
+	if store != nil {
+		if err := store.ClearVerify(); err != nil {
+			log.Warn().Err(err).Msg("Failed to clear finished verification")
+		}
+	}
+}
+
+// VerifyProgress reports the running or interrupted verify pass; ok is false
+// when there is none.
+func (s *Service) VerifyProgress() (models.VerifyProgress, bool) {
+	s.mu.RLock()
+	running := s.verifyRunning
+	checkpoint := s.verifyCheckpoint
+	store := s.stateStore
+	s.mu.RUnlock()
+
+	if checkpoint == nil && store != nil {
+		if stored, ok, err := store.LoadVerifyCheckpoint(); err == nil && ok {
+			checkpoint = &stored
+		}
+	}
+	if checkpoint == nil {
+		return models.VerifyProgress{}, false
+	}
+	return models.VerifyProgress{
+		Running:    running,
+		RunDir:     checkpoint.RunDir,
+		Total:      checkpoint.Total,
+		Done:       checkpoint.NextIndex,
+		Checked:    checkpoint.Checked,
+		Skipped:    checkpoint.Skipped,
+		NotSampled: checkpoint.NotSampled,
+		Mismatched: len(checkpoint.Mismatched),
+		FileOffset: checkpoint.FileOffset,
+		StartedAt:  checkpoint.StartedAt,
+		UpdatedAt:  checkpoint.UpdatedAt,
+	}, true
+}
+
+// verifyFile hashes source and dest side by side, continuing from the hash
+// states in checkpoint when they belong to this file, and checkpoints every
+// verifyCheckpointBytes. A missing destination or a size difference is a
+// mismatch.
+func (s *Service) verifyFile(ctx context.Context, file state.VerifyFile, checkpoint *state.VerifyCheckpoint) (bool, error) {
+	reader, ok := s.destinationStorage().(storage.Reader)
+	if !ok {
+		return false, ErrDestinationNotReadable
+	}
+	src, err := s.sourceStorage().Open(file.Source)
+	if err != nil {
+		return false, err
+	}
+	defer src.Close()
+	dst, err := reader.Open(file.Dest)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer dst.Close()
+
+	srcInfo, err := src.Stat()
+	if err != nil {
+		return false, err
+	}
+	dstInfo, err := dst.Stat()
+	if err != nil {
+		return false, err
+	}
+	if srcInfo.Size() != dstInfo.Size() {
+		return false, nil
+	}
+
+	srcHash, dstHash := sha256.New(), sha256.New()
+	offset := int64(0)
+	if checkpoint.FileOffset > 0 && checkpoint.FileOffset <= srcInfo.Size() &&
+		restoreHash(srcHash, checkpoint.SourceHash) && restoreHash(dstHash, checkpoint.DestHash) {
+		offset = checkpoint.FileOffset
+	} else {
+		srcHash, dstHash = sha256.New(), sha256.New()
+	}
+
+	budget := s.currentIOBudget()
+	buf := make([]byte, 1<<20)
+	lastCheckpoint := offset
+	for offset < srcInfo.Size() {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		n := int64(len(buf))
+		if remaining := srcInfo.Size() - offset; remaining < n {
+			n = remaining
+		}
+		if _, err := src.ReadAt(buf[:n], offset); err != nil && err != io.EOF {
+			return false, err
+		}
+		srcHash.Write(buf[:n])
+		if _, err := dst.ReadAt(buf[:n], offset); err != nil && err != io.EOF {
+			return false, err
+		}
+		dstHash.Write(buf[:n])
+		offset += n
+		if budget != nil {
+			// Both copies of the chunk were read.
+			if err := budget.wait(ctx, IOJobVerify, int(2*n)); err != nil {
+				return false, err
+			}
+		}
+
+		if offset-lastCheckpoint >= verifyCheckpointBytes && offset < srcInfo.Size() {
+			srcState, srcErr := srcHash.(encoding.BinaryMarshaler).MarshalBinary()
+			dstState, dstErr := dstHash.(encoding.BinaryMarshaler).MarshalBinary()
+			if srcErr == nil && dstErr == nil {
+				checkpoint.FileOffset = offset
+				checkpoint.SourceHash = srcState
+				checkpoint.DestHash = dstState
+				s.saveVerifyCheckpoint(*checkpoint)
+			}
+			lastCheckpoint = offset
+		}
+	}
+
+	return bytes.Equal(srcHash.Sum(nil), dstHash.Sum(nil)), nil
+}
+
+func restoreHash(h hash.Hash, saved []byte) bool {
+	unmarshaler, ok := h.(encoding.BinaryUnmarshaler)
+	return ok && len(saved) > 0 && unmarshaler.UnmarshalBinary(saved) == nil
+}
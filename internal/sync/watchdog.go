@@ -0,0 +1,146 @@
+package sync
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+const (
+	defaultStallTimeout      = 10 * time.Minute
+	minStallWatchdogInterval = time.Second
+	stallWatchdogIntervalDiv = 4
+)
+
+// SetStallTimeout configures how long a task may go without progress before
+// the watchdog cancels it. Zero or negative restores the default.
+func (s *Service) SetStallTimeout(timeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if timeout <= 0 {
+		s.stallTimeout = defaultStallTimeout
+		return
+	}
+
+	s.stallTimeout = timeout
+}
+
+func (s *Service) currentStallTimeout() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.stallTimeout <= 0 {
+		return defaultStallTimeout
+	}
+
+	return s.stallTimeout
+}
+
+// keepTaskActive touches task until the returned stop is called. A task
+// queued for a copy slot the other nodes hold makes no progress, but has
+// not stalled either.
+func (s *Service) keepTaskActive(task *taskInfo) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(s.currentStallTimeout() / stallWatchdogIntervalDiv)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				task.touch(now)
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// stallWatchdog periodically cancels tasks that stopped making progress, e.g.
+// a CIFS read hung on an unresponsive node. The next sync iteration restarts them.
+func (s *Service) stallWatchdog(ctx context.Context) {
+	defer s.wg.Done()
+
+	interval := s.currentStallTimeout() / stallWatchdogIntervalDiv
+	if interval < minStallWatchdogInterval {
+		interval = minStallWatchdogInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.recoverStalledTasks(now)
+		}
+	}
+}
+
+type stalledTask struct {
+	key   string
+	node  string
+	share string
+	idle  time.Duration
+}
+
+// recoverStalledTasks cancels and forgets tasks idle for longer than the stall
+// timeout so that syncIteration can start a fresh task for the same node/share.
+func (s *Service) recoverStalledTasks(now time.Time) []string {
+	timeout := s.currentStallTimeout()
+
+	s.mu.Lock()
+	if s.paused {
+		s.mu.Unlock()
+		return nil
+	}
+
+	var stalled []stalledTask
+	for key, task := range s.activeTasks {
+		idle := now.Sub(task.lastActive())
+		if idle < timeout {
+			continue
+		}
+
+		if task.cancel != nil {
+			task.cancel()
+		}
+		delete(s.activeTasks, key)
+		task.setState(taskStalled)
+		if !isRetryTaskKey(key) {
+			s.recordFinishedTaskLocked(key, task, nil)
+		}
+		stalled = append(stalled, stalledTask{key: key, node: task.node, share: task.share, idle: idle})
+	}
+	project := s.project
+	destination := s.destination
+	s.mu.Unlock()
+
+	keys := make([]string, 0, len(stalled))
+	for _, task := range stalled {
+		keys = append(keys, task.key)
+
+		log.Warn().
+			Str("node", task.node).
+			Str("share", task.share).
+			Dur("idle", task.idle).
+			Msg("Sync task stalled, restarting")
+
+		s.emitEvent(models.SyncEvent{
+			Type:        models.SyncEventTaskStalled,
+			Project:     project,
+			Destination: destination,
+			Message:     s.messages.Sprintf(i18n.EventTaskStalled, task.node, task.share),
+			Reason:      s.messages.Sprintf(i18n.ReasonNoProgress, task.idle.Truncate(time.Second)),
+		})
+	}
+
+	return keys
+}
@@ -0,0 +1,320 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/config"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/internal/state"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// alertRule is the resolved policy for one sync event type.
+type alertRule struct {
+	severity string
+	category string
+	title    i18n.Key
+	notify   bool
+	sound    bool
+}
+
+// defaultAlertRules is the built-in policy; web.alerts.events overrides it.
+var defaultAlertRules = map[string]alertRule{
+	models.SyncEventCaptureCompleted:        {severity: "success", category: "capture", title: i18n.AlertCaptureCompleted, notify: false, sound: true},
+	models.SyncEventFinished:                {severity: "success", category: "sync", title: i18n.AlertSyncFinished, notify: true, sound: true},
+	models.SyncEventStopped:                 {severity: "info", category: "sync", title: i18n.AlertSyncStopped, notify: true, sound: false},
+	models.SyncEventFailed:                  {severity: "critical", category: "sync", title: i18n.AlertSyncFailed, notify: true, sound: true},
+	models.SyncEventTaskStalled:             {severity: "warning", category: "sync", title: i18n.AlertTaskStalled, notify: false, sound: false},
+	models.SyncEventPacingBehind:            {severity: "warning", category: "sync", title: i18n.AlertPacingBehind, notify: true, sound: false},
+	models.SyncEventPacingOnTrack:           {severity: "info", category: "sync", title: i18n.AlertPacingOnTrack, notify: false, sound: false},
+	models.SyncEventDiskSpaceWarning:        {severity: "warning", category: "disk", title: i18n.AlertDiskSpaceWarning, notify: true, sound: true},
+	models.SyncEventDestinationFull:         {severity: "warning", category: "disk", title: i18n.AlertDestinationFull, notify: true, sound: true},
+	models.SyncEventDestinationLost:         {severity: "critical", category: "destination", title: i18n.AlertDestinationLost, notify: true, sound: true},
+	models.SyncEventDestinationSwitched:     {severity: "info", category: "destination", title: i18n.AlertDestinationSwitched, notify: true, sound: false},
+	models.SyncEventDestinationIncompatible: {severity: "warning", category: "destination", title: i18n.AlertDestinationIncompatible, notify: false, sound: false},
+	models.SyncEventQuotaReached:            {severity: "warning", category: "disk", title: i18n.AlertQuotaReached, notify: true, sound: true},
+	models.SyncEventSourceSpaceLow:          {severity: "warning", category: "disk", title: i18n.AlertSourceSpaceLow, notify: true, sound: false},
+	models.SyncEventNodeBreakerOpen:         {severity: "warning", category: "network", title: i18n.AlertNodeBreakerOpen, notify: true, sound: false},
+	models.SyncEventIntegrityMismatch:       {severity: "critical", category: "destination", title: i18n.AlertIntegrityMismatch, notify: true, sound: true},
+	models.SyncEventFileQuarantined:         {severity: "warning", category: "sync", title: i18n.AlertFileQuarantined, notify: false, sound: false},
+	models.SyncEventFileDegraded:            {severity: "warning", category: "sync", title: i18n.AlertFileDegraded, notify: true, sound: false},
+	models.SyncEventProjectRenamed:          {severity: "warning", category: "sync", title: i18n.AlertProjectRenamed, notify: true, sound: true},
+	models.SyncEventPostProcessFailed:       {severity: "warning", category: "sync", title: i18n.AlertPostProcessFailed, notify: true, sound: false},
+	models.SyncEventExportFinished:          {severity: "success", category: "destination", title: i18n.AlertExportFinished, notify: true, sound: true},
+	models.SyncEventExportFailed:            {severity: "critical", category: "destination", title: i18n.AlertExportFailed, notify: true, sound: true},
+	models.AlertShareMountFailed:            {severity: "critical", category: "network", title: i18n.AlertShareMountFailed, notify: true, sound: true},
+}
+
+// alertsResolvedBy lists the alert types an event clears: a switch to a new
+// destination ends a full or lost one.
+var alertsResolvedBy = map[string][]string{
+	models.SyncEventDestinationSwitched: {models.SyncEventDestinationFull, models.SyncEventDestinationLost, models.SyncEventQuotaReached},
+	models.SyncEventPacingOnTrack:       {models.SyncEventPacingBehind},
+}
+
+// alertPolicy decides the severity and notification hints attached to sync
+// events broadcast over WebSocket.
+type alertPolicy struct {
+	browser  bool
+	sound    bool
+	rules    map[string]alertRule
+	messages i18n.Catalog
+}
+
+func newAlertPolicy(cfg config.WebAlerts, messages i18n.Catalog) alertPolicy {
+	rules := make(map[string]alertRule, len(defaultAlertRules)+len(cfg.Events))
+	for event, rule := range defaultAlertRules {
+		rules[event] = rule
+	}
+
+	for event, override := range cfg.Events {
+		rule, ok := rules[event]
+		if !ok {
+			rule = alertRule{severity: "info", category: "sync", title: i18n.Key(event)}
+		}
+		if override.Severity != "" {
+			rule.severity = override.Severity
+		}
+		if override.Notify != nil {
+			rule.notify = *override.Notify
+		}
+		if override.Sound != nil {
+			rule.sound = *override.Sound
+		}
+		rules[event] = rule
+	}
+
+	return alertPolicy{
+		browser:  cfg.BrowserNotifications,
+		sound:    cfg.Sound,
+		rules:    rules,
+		messages: messages,
+	}
+}
+
+// metaFor returns the UI metadata for event. The notification is omitted
+// when the policy suggests neither a browser notification nor a sound.
+func (p alertPolicy) metaFor(event models.SyncEvent) *models.EventMeta {
+	rule := p.ruleFor(event.Type)
+
+	meta := &models.EventMeta{
+		Severity: rule.severity,
+		Category: rule.category,
+	}
+
+	browser := p.browser && rule.notify
+	sound := p.sound && rule.sound
+	if browser || sound {
+		body := event.Message
+		if event.Reason != "" {
+			body += ": " + event.Reason
+		}
+		meta.Notification = &models.EventNotification{
+			Title:   p.messages.Sprintf(rule.title),
+			Body:    body,
+			Browser: browser,
+			Sound:   sound,
+		}
+	}
+
+	return meta
+}
+
+func (p alertPolicy) ruleFor(eventType string) alertRule {
+	rule, ok := p.rules[eventType]
+	if !ok {
+		rule, ok = defaultAlertRules[eventType]
+	}
+	if !ok {
+		rule = alertRule{severity: "info", category: "sync", title: i18n.Key(eventType)}
+	}
+	return rule
+}
+
+// maxActiveAlerts bounds the alerts sent with every status.
+const maxActiveAlerts = 20
+
+// raisesAlert reports whether events of severity stay in the alert center.
+func raisesAlert(severity string) bool {
+	return severity == "warning" || severity == "critical"
+}
+
+// recordAlert keeps a warning or critical event in the alert center and
+// resolves the alerts the event clears.
+func (s *Server) recordAlert(event models.SyncEvent) {
+	for _, alertType := range alertsResolvedBy[event.Type] {
+		s.resolveAlertsOfType(alertType)
+	}
+
+	rule := s.alerts.ruleFor(event.Type)
+	if !raisesAlert(rule.severity) {
+		return
+	}
+	at := event.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	s.raiseAlert(models.Alert{
+		Type:        event.Type,
+		Severity:    rule.severity,
+		Category:    rule.category,
+		Title:       s.alerts.messages.Sprintf(rule.title),
+		Message:     event.Message,
+		Reason:      event.Reason,
+		Project:     event.Project,
+		Destination: event.Destination,
+		LastSeenAt:  at,
+	})
+}
+
+// raiseAlert persists alert and pushes it to connected clients. Without a
+// state database the alert is only broadcast.
+func (s *Server) raiseAlert(alert models.Alert) {
+	if s.stateStore != nil {
+		stored, err := s.stateStore.RaiseAlert(alert)
+		if err != nil {
+			log.Error().Err(err).Str("type", alert.Type).Msg("Failed to record alert")
+		} else {
+			alert = stored
+		}
+	} else {
+		alert.Count = 1
+		alert.State = models.AlertOpen
+		alert.RaisedAt = alert.LastSeenAt
+	}
+
+	s.broadcast(models.WSMessage{Type: "alert", Payload: alert})
+}
+
+// resolveAlertsOfType closes the unresolved alerts of alertType once their
+// condition has cleared.
+func (s *Server) resolveAlertsOfType(alertType string) {
+	if s.stateStore == nil {
+		return
+	}
+	resolved, err := s.stateStore.ResolveAlertsOfType(alertType, time.Now())
+	if err != nil {
+		log.Error().Err(err).Str("type", alertType).Msg("Failed to resolve alerts")
+	}
+	for _, alert := range resolved {
+		s.broadcast(models.WSMessage{Type: "alert", Payload: alert})
+	}
+}
+
+// activeAlerts returns the unresolved alerts, critical ones first and then
+// the most recently seen, for the banners shown with the status.
+func (s *Server) activeAlerts() []models.ActiveAlert {
+	active := []models.ActiveAlert{}
+	if s.stateStore == nil {
+		return active
+	}
+	alerts, err := s.stateStore.ListAlerts(false, maxActiveAlerts)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list active alerts")
+		return active
+	}
+	for _, alert := range alerts {
+		active = append(active, models.ActiveAlert{
+			ID:           alert.ID,
+			Type:         alert.Type,
+			Severity:     alert.Severity,
+			Category:     alert.Category,
+			Title:        alert.Title,
+			Message:      alert.Message,
+			Count:        alert.Count,
+			Since:        alert.RaisedAt,
+			Acknowledged: alert.State == models.AlertAcknowledged,
+		})
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		return active[i].Severity == "critical" && active[j].Severity != "critical"
+	})
+	return active
+}
+
+// raiseShareMountAlert records a failed remount of unavailable shares.
+func (s *Server) raiseShareMountAlert(unavailable int, err error) {
+	rule := s.alerts.ruleFor(models.AlertShareMountFailed)
+	s.raiseAlert(models.Alert{
+		Type:       models.AlertShareMountFailed,
+		Severity:   rule.severity,
+		Category:   rule.category,
+		Title:      s.alerts.messages.Sprintf(rule.title),
+		Message:    s.messages.Sprintf(i18n.EventShareMountFailed, unavailable),
+		Reason:     err.Error(),
+		LastSeenAt: time.Now(),
+	})
+}
+
+// handleAlerts lists the alert center on GET (?all=true includes resolved
+// alerts). POST {"id": ..., "action": "acknowledge"|"resolve"} updates one.
+func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.stateStore == nil {
+		http.Error(w, "state store not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		limit := 100
+		if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+			if _, err := fmt.Sscanf(raw, "%d", &limit); err != nil || limit <= 0 {
+				http.Error(w, "invalid limit", http.StatusBadRequest)
+				return
+			}
+		}
+		alerts, err := s.stateStore.ListAlerts(r.URL.Query().Get("all") == "true", limit)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to list alerts: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(alerts)
+		return
+	}
+
+	var req struct {
+		ID     int64  `json:"id"`
+		Action string `json:"action"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID <= 0 {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	var (
+		alert models.Alert
+		err   error
+	)
+	switch req.Action {
+	case "acknowledge":
+		alert, err = s.stateStore.AcknowledgeAlert(req.ID, time.Now())
+	case "resolve":
+		alert, err = s.stateStore.ResolveAlert(req.ID, time.Now())
+	default:
+		http.Error(w, "action must be acknowledge or resolve", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, state.ErrAlertNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to update alert: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	s.broadcast(models.WSMessage{Type: "alert", Payload: alert})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alert)
+}
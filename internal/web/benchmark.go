@@ -0,0 +1,68 @@
+package web
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// benchmarkTimeout bounds the destination write test made before a job.
+const benchmarkTimeout = 2 * time.Minute
+
+// benchmarkDestination runs the write test of destination before a job
+// starts and reports the result to the log. A speed below
+// sync.benchmark.warn_ratio of monitoring.max_disk_throughput_mbps is flagged
+// as slow. It returns nil when the test fails; the job starts regardless.
+func (s *Server) benchmarkDestination(ctx context.Context, destination string) *models.DestinationBenchmark {
+	ctx, cancel := context.WithTimeout(ctx, benchmarkTimeout)
+	defer cancel()
+
+	settings := s.cfg.Sync.Benchmark
+	size := int64(settings.SizeMB) << 20
+	if size <= 0 {
+		size = 256 << 20
+	}
+	result, err := s.benchmarkDestinationFunc(ctx, destination, size)
+	if err != nil {
+		log.Warn().Err(err).Str("destination", destination).Msg("Destination benchmark failed")
+		s.broadcast(models.WSMessage{
+			Type: "log",
+			Payload: models.LogMessage{
+				Timestamp: time.Now(),
+				Level:     "warn",
+				Message:   s.messages.Sprintf(i18n.LogBenchmarkFailed, err.Error()),
+			},
+		})
+		return nil
+	}
+
+	result.ExpectedMBps = s.cfg.Monitoring.MaxDiskThroughputMBps
+	warnRatio := settings.WarnRatio
+	if warnRatio <= 0 {
+		warnRatio = 0.5
+	}
+	result.Slow = result.ExpectedMBps > 0 && result.WriteMBps < result.ExpectedMBps*warnRatio
+
+	log.Info().
+		Str("destination", destination).
+		Float64("write_mbps", result.WriteMBps).
+		Float64("expected_mbps", result.ExpectedMBps).
+		Bool("slow", result.Slow).
+		Msg("Destination benchmark finished")
+	level, message := "info", s.messages.Sprintf(i18n.LogBenchmarkResult, destination, result.WriteMBps)
+	if result.Slow {
+		level, message = "warn", s.messages.Sprintf(i18n.LogBenchmarkSlow, destination, result.WriteMBps, result.ExpectedMBps)
+	}
+	s.broadcast(models.WSMessage{
+		Type: "log",
+		Payload: models.LogMessage{
+			Timestamp: time.Now(),
+			Level:     level,
+			Message:   message,
+		},
+	})
+	return &result
+}
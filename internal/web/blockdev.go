@@ -0,0 +1,298 @@
+package web
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// haveTool reports whether name is on PATH. Minimal embedded images ship
+// without util-linux or hdparm, so optional tools are looked up before they
+// are run and a native fallback is used where one exists.
+func haveTool(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// sysfsRoots are where the kernel and udev describe block devices.
+type sysfsRoots struct {
+	SysBlock string // One entry per disk, partitions below their disk
+	DevDisk  string // udev by-uuid, by-label and by-partuuid links
+	Dev      string // Device nodes, read to sniff the filesystem
+}
+
+var defaultSysfsRoots = sysfsRoots{SysBlock: "/sys/block", DevDisk: "/dev/disk", Dev: "/dev"}
+
+// sysfsBlockDevices builds the device tree `lsblk -J -b` would print from
+// sysfs, the udev links and procMounts, for hosts without lsblk. Virtual
+// disks (loop, ram, zram) are left out; device-mapper devices show up as the
+// crypt children of the partitions they open.
+func sysfsBlockDevices(roots sysfsRoots, procMounts string) ([]lsblkDevice, error) {
+	entries, err := os.ReadDir(roots.SysBlock)
+	if err != nil {
+		return nil, err
+	}
+	mounts := parseMountSources(procMounts)
+	ids := readDiskIDs(roots.DevDisk)
+
+	var devices []lsblkDevice
+	for _, entry := range entries {
+		name := entry.Name()
+		if hasAnyPrefix(name, "loop", "ram", "zram", "dm-") {
+			continue
+		}
+		dir := filepath.Join(roots.SysBlock, name)
+		disk := roots.device(name, dir, "disk", mounts, ids)
+		disk.RM = readSysfsValue(dir, "removable")
+		disk.Model = readSysfsValue(dir, "device/model")
+		disk.Serial = readSysfsValue(dir, "device/serial")
+		disk.Tran = sysfsTransport(dir)
+
+		children, _ := os.ReadDir(dir)
+		for _, child := range children {
+			partDir := filepath.Join(dir, child.Name())
+			if !strings.HasPrefix(child.Name(), name) || readSysfsValue(partDir, "partition") == "" {
+				continue
+			}
+			disk.Children = append(disk.Children, roots.device(child.Name(), partDir, "part", mounts, ids))
+		}
+		devices = append(devices, disk)
+	}
+	return devices, nil
+}
+
+// device describes the disk or partition name whose sysfs folder is dir.
+func (roots sysfsRoots) device(name, dir, kind string, mounts map[string]mountSource, ids map[string]diskIDs) lsblkDevice {
+	dev := lsblkDevice{Name: name, Type: kind}
+	if sectors, err := strconv.ParseUint(readSysfsValue(dir, "size"), 10, 64); err == nil {
+		// sysfs counts 512-byte sectors whatever the logical block size.
+		dev.Size = strconv.FormatUint(sectors*512, 10)
+	}
+	id := ids[name]
+	dev.UUID, dev.Label, dev.PartUUID = id.UUID, id.Label, id.PartUUID
+	if mount, ok := mounts[name]; ok {
+		dev.MountPoint, dev.FSType = mount.MountPoint, mount.FSType
+	}
+	if dev.FSType == "" {
+		dev.FSType = sniffFilesystem(filepath.Join(roots.Dev, name))
+	}
+
+	// An open LUKS container is held by its dm-crypt mapping.
+	holders, _ := os.ReadDir(filepath.Join(dir, "holders"))
+	for _, holder := range holders {
+		holderDir := filepath.Join(roots.SysBlock, holder.Name())
+		if !strings.HasPrefix(readSysfsValue(holderDir, "dm/uuid"), "CRYPT-") {
+			continue
+		}
+		mapped := lsblkDevice{Name: readSysfsValue(holderDir, "dm/name"), Type: "crypt"}
+		if mount, ok := mounts[mapped.Name]; ok {
+			mapped.MountPoint, mapped.FSType = mount.MountPoint, mount.FSType
+		}
+		dev.Children = append(dev.Children, mapped)
+	}
+	return dev
+}
+
+// sysfsTransport guesses lsblk's TRAN from the bus path of a disk.
+func sysfsTransport(dir string) string {
+	path, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case strings.Contains(path, "/usb"):
+		return "usb"
+	case strings.Contains(path, "/nvme"):
+		return "nvme"
+	case strings.Contains(path, "/ata"):
+		return "sata"
+	case strings.Contains(path, "/mmc"):
+		return "mmc"
+	default:
+		return ""
+	}
+}
+
+func readSysfsValue(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func hasAnyPrefix(s string, prefixes ...string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// mountSource is where a device is mounted and with which filesystem.
+type mountSource struct {
+	MountPoint string
+	FSType     string
+}
+
+// parseMountSources maps the device names in /proc/mounts (sda1, or the
+// mapper name of /dev/mapper/<name>) to their first mount.
+func parseMountSources(procMounts string) map[string]mountSource {
+	mounts := make(map[string]mountSource)
+	for _, line := range strings.Split(procMounts, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.HasPrefix(fields[0], "/dev/") {
+			continue
+		}
+		name := filepath.Base(fields[0])
+		if _, ok := mounts[name]; !ok {
+			mounts[name] = mountSource{MountPoint: unescapeMountField(fields[1]), FSType: fields[2]}
+		}
+	}
+	return mounts
+}
+
+// unescapeMountField decodes the octal escapes (\040 for a space) the
+// kernel writes in /proc/mounts.
+func unescapeMountField(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+	var out strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if code, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				out.WriteByte(byte(code))
+				i += 3
+				continue
+			}
+		}
+		out.WriteByte(field[i])
+	}
+	return out.String()
+}
+
+// diskIDs are the filesystem and partition identifiers udev found.
+type diskIDs struct {
+	UUID, Label, PartUUID string
+}
+
+// readDiskIDs maps device names to the udev links pointing at them.
+func readDiskIDs(devDisk string) map[string]diskIDs {
+	ids := make(map[string]diskIDs)
+	for _, kind := range []string{"by-uuid", "by-label", "by-partuuid"} {
+		dir := filepath.Join(devDisk, kind)
+		links, _ := os.ReadDir(dir)
+		for _, link := range links {
+			target, err := os.Readlink(filepath.Join(dir, link.Name()))
+			if err != nil {
+				continue
+			}
+			name := filepath.Base(target)
+			id := ids[name]
+			switch kind {
+			case "by-uuid":
+				id.UUID = link.Name()
+			case "by-label":
+				// udev writes unsafe characters as \xNN.
+				id.Label = unescapeUdevLabel(link.Name())
+			case "by-partuuid":
+				id.PartUUID = link.Name()
+			}
+			ids[name] = id
+		}
+	}
+	return ids
+}
+
+func unescapeUdevLabel(label string) string {
+	if !strings.Contains(label, `\x`) {
+		return label
+	}
+	var out strings.Builder
+	for i := 0; i < len(label); i++ {
+		if strings.HasPrefix(label[i:], `\x`) && i+4 <= len(label) {
+			if code, err := strconv.ParseUint(label[i+2:i+4], 16, 8); err == nil {
+				out.WriteByte(byte(code))
+				i += 3
+				continue
+			}
+		}
+		out.WriteByte(label[i])
+	}
+	return out.String()
+}
+
+// sniffFilesystem names the filesystem on a device from its superblock,
+// with lsblk's names for the ones a destination may use, or "" when it is
+// unreadable or none of them.
+func sniffFilesystem(devicePath string) string {
+	file, err := os.Open(devicePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	// The btrfs superblock is the furthest in, at 64 KiB.
+	buf := make([]byte, 0x10048)
+	n, _ := file.ReadAt(buf, 0)
+	return filesystemFromSuperblock(buf[:n])
+}
+
+func filesystemFromSuperblock(buf []byte) string {
+	at := func(offset int, magic string) bool {
+		return len(buf) >= offset+len(magic) && bytes.Equal(buf[offset:offset+len(magic)], []byte(magic))
+	}
+	switch {
+	case at(0, "LUKS\xba\xbe"):
+		return luksFSType
+	case at(0, "XFSB"):
+		return "xfs"
+	case at(3, "EXFAT   "):
+		return "exfat"
+	case at(3, "NTFS    "):
+		return "ntfs"
+	case at(82, "FAT32   "), at(54, "FAT16   "), at(54, "FAT12   "):
+		return "vfat"
+	case at(1080, "\x53\xef") && len(buf) >= 1024+0x64:
+		// ext4 uses extents, ext3 has a journal.
+		compat := binary.LittleEndian.Uint32(buf[1024+0x5c:])
+		incompat := binary.LittleEndian.Uint32(buf[1024+0x60:])
+		switch {
+		case incompat&0x40 != 0:
+			return "ext4"
+		case compat&0x4 != 0:
+			return "ext3"
+		default:
+			return "ext2"
+		}
+	case at(0x10040, "_BHRfS_M"):
+		return "btrfs"
+	default:
+		return ""
+	}
+}
+
+// sysfsProbeDevice is probeDevice without lsblk: the filesystem from the
+// mount table or the superblock, the UUID and label from the udev links.
+func sysfsProbeDevice(roots sysfsRoots, procMounts, devicePath string) deviceProbe {
+	// /dev/mapper/<name> links to the dm-N node the udev links point at.
+	name := filepath.Base(devicePath)
+	if resolved, err := filepath.EvalSymlinks(devicePath); err == nil {
+		name = filepath.Base(resolved)
+	}
+	id := readDiskIDs(roots.DevDisk)[name]
+	probe := deviceProbe{UUID: id.UUID, Label: id.Label}
+	mounts := parseMountSources(procMounts)
+	if mount, ok := mounts[filepath.Base(devicePath)]; ok {
+		probe.FSType = strings.ToLower(mount.FSType)
+	} else {
+		probe.FSType = sniffFilesystem(devicePath)
+	}
+	return probe
+}
@@ -0,0 +1,308 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/config"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	syncService "github.com/zangezia/UCXSync/internal/sync"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// completionResult is the outcome of one completion action.
+type completionResult struct {
+	Action  string `json:"action"`
+	OK      bool   `json:"ok"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Message string `json:"message"`
+}
+
+// completionReport is what the notify action posts to notify_url.
+type completionReport struct {
+	Event   models.SyncEvent   `json:"event"`
+	Actions []completionResult `json:"actions"`
+}
+
+// captureManifest is written into the run folder by the manifest action.
+type captureManifest struct {
+	Project     string                       `json:"project"`
+	Registry    *models.ProjectRegistryEntry `json:"registry,omitempty"` // Alias, customer and notes of Project
+	JobID       string                       `json:"job_id,omitempty"`
+	Notes       []models.JobNote             `json:"notes,omitempty"` // Operator notes of the job
+	Destination string                       `json:"destination"`
+	GeneratedAt time.Time                    `json:"generated_at"`
+	Totals      *models.SyncTotals           `json:"totals,omitempty"`
+	Captures    []models.CaptureLocation     `json:"captures"`
+}
+
+// handleSyncCompletion reports the configured completion actions so the UI
+// can preselect them for the next job.
+func (s *Server) handleSyncCompletion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	completion := s.cfg.Sync.Completion
+	actions := completion.Actions
+	if actions == nil {
+		actions = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"actions":           actions,
+		"notify_configured": completion.NotifyURL != "",
+		"idle_finish":       completion.IdleFinish.String(),
+		"benchmark":         s.cfg.Sync.Benchmark.Enabled,
+	})
+}
+
+// handleVerify reports the running or interrupted verify pass on GET; POST
+// resumes an interrupted pass, or verifies the last run, in the background.
+func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if progress, ok := s.verifyProgressFunc(); ok && progress.Running {
+			http.Error(w, syncService.ErrVerifyRunning.Error(), http.StatusConflict)
+			return
+		}
+		go s.runCompletionActions(context.Background(), models.SyncEvent{}, []string{config.CompletionVerify})
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	progress, ok := s.verifyProgressFunc()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pending":  ok,
+		"progress": progress,
+	})
+}
+
+// setCompletionActions remembers the actions to run when the current job
+// finishes.
+func (s *Server) setCompletionActions(actions []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.completionActions = actions
+}
+
+// takeCompletionActions returns and clears the pending completion actions so
+// they run at most once per job.
+func (s *Server) takeCompletionActions() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	actions := s.completionActions
+	s.completionActions = nil
+	return actions
+}
+
+// runCompletionActions runs actions (already in execution order) for the
+// finished run described by event. A failed verify or manifest leaves the
+// destination mounted and the host running so the operator can look.
+func (s *Server) runCompletionActions(ctx context.Context, event models.SyncEvent, actions []string) []completionResult {
+	results := make([]completionResult, 0, len(actions))
+	failed := false
+
+	for _, action := range actions {
+		result := completionResult{Action: action}
+		if failed && (action == config.CompletionEject || action == config.CompletionPowerOff) {
+			result.Skipped = true
+			result.Message = s.messages.Sprintf(i18n.CompletionSkipped)
+		} else {
+			message, err := s.runCompletionAction(ctx, action, event, results)
+			result.OK = err == nil
+			result.Message = message
+			if err != nil {
+				result.Message = err.Error()
+				failed = true
+			}
+		}
+		results = append(results, result)
+
+		level := "info"
+		if !result.OK {
+			level = "warn"
+		}
+		log.Info().Str("action", action).Bool("ok", result.OK).Bool("skipped", result.Skipped).Str("message", result.Message).Msg("Completion action finished")
+		s.broadcast(models.WSMessage{
+			Type: "log",
+			Payload: models.LogMessage{
+				Timestamp: time.Now(),
+				Level:     level,
+				Message:   s.messages.Sprintf(i18n.LogCompletionAction, action, result.Message),
+			},
+		})
+	}
+
+	return results
+}
+
+func (s *Server) runCompletionAction(ctx context.Context, action string, event models.SyncEvent, results []completionResult) (string, error) {
+	switch action {
+	case config.CompletionVerify:
+		verify, err := s.verifyRunFunc(ctx)
+		if err != nil {
+			return "", fmt.Errorf("verification failed: %w", err)
+		}
+		if len(verify.Mismatched) > 0 {
+			return "", errors.New(s.messages.Sprintf(i18n.CompletionVerifyFailed, len(verify.Mismatched), verify.Checked, verify.Mismatched[0]))
+		}
+		if verify.NotSampled > 0 {
+			return s.messages.Sprintf(i18n.CompletionSampled, verify.Checked, verify.NotSampled, verify.Skipped), nil
+		}
+		return s.messages.Sprintf(i18n.CompletionVerified, verify.Checked, verify.Skipped), nil
+	case config.CompletionManifest:
+		path, err := s.writeCaptureManifest(event)
+		if err != nil {
+			return "", fmt.Errorf("failed to write manifest: %w", err)
+		}
+		return s.messages.Sprintf(i18n.CompletionManifest, path), nil
+	case config.CompletionNotify:
+		if err := s.postCompletionReport(ctx, completionReport{Event: event, Actions: results}); err != nil {
+			return "", fmt.Errorf("notification failed: %w", err)
+		}
+		return s.messages.Sprintf(i18n.CompletionNotified), nil
+	case config.CompletionEject:
+		if err := s.ejectDestinationFunc(event.Destination); err != nil {
+			return "", fmt.Errorf("failed to eject destination: %w", err)
+		}
+		return s.messages.Sprintf(i18n.CompletionEjected), nil
+	case config.CompletionPowerOff:
+		if err := s.powerOffFunc(); err != nil {
+			return "", fmt.Errorf("failed to power off: %w", err)
+		}
+		return s.messages.Sprintf(i18n.CompletionPoweringOff), nil
+	default:
+		return "", fmt.Errorf("unknown completion action %q", action)
+	}
+}
+
+// writeCaptureManifest writes <project>-manifest.json listing where every
+// capture of the finished run ended up.
+func (s *Server) writeCaptureManifest(event models.SyncEvent) (string, error) {
+	if s.stateStore == nil {
+		return "", fmt.Errorf("state database is not available")
+	}
+	dir := s.lastRunDirFunc()
+	if dir == "" {
+		return "", fmt.Errorf("run folder is unknown")
+	}
+	locations, err := s.stateStore.ListCaptureLocations(event.Project)
+	if err != nil {
+		return "", err
+	}
+	registry, err := s.stateStore.ProjectRegistryEntry(event.Project)
+	if err != nil {
+		return "", err
+	}
+	var notes []models.JobNote
+	if event.JobID != "" {
+		if notes, err = s.stateStore.JobNotes(event.JobID); err != nil {
+			return "", err
+		}
+	}
+
+	data, err := json.MarshalIndent(captureManifest{
+		Project:     event.Project,
+		Registry:    registry,
+		JobID:       event.JobID,
+		Notes:       notes,
+		Destination: event.Destination,
+		GeneratedAt: time.Now().UTC(),
+		Totals:      event.Totals,
+		Captures:    locations,
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, event.Project+"-manifest.json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return path, nil
+}
+
+func (s *Server) postCompletionReport(ctx context.Context, report completionReport) error {
+	url := s.cfg.Sync.Completion.NotifyURL
+	if url == "" {
+		return fmt.Errorf("sync.completion.notify_url is not configured")
+	}
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notify_url answered %s", resp.Status)
+	}
+	return nil
+}
+
+// ejectDestination unmounts the data device holding destination and asks the
+// kernel to eject it when eject(1) is available.
+func (s *Server) ejectDestination(destination string) error {
+	devicePath, err := destinationDevice(destination)
+	if err != nil {
+		return err
+	}
+	if backing := luksBackingDevice(devMapperRoot, devicePath); backing != "" {
+		// Unmounted and locked through the encrypted device, which is the one
+		// to eject.
+		devicePath = backing
+	}
+
+	if _, err := s.unmountDevice(devicePath); err != nil {
+		return err
+	}
+
+	if _, err := exec.LookPath("eject"); err != nil {
+		return nil
+	}
+	if output, err := exec.Command("eject", devicePath).CombinedOutput(); err != nil {
+		// Unmounted is already safe to unplug.
+		log.Warn().Err(err).Str("device", devicePath).Str("output", strings.TrimSpace(string(output))).Msg("Failed to eject destination device")
+	}
+	return nil
+}
+
+// scheduleHostShutdown powers the host off after a short delay so the
+// current response and log broadcast still go out.
+func scheduleHostShutdown() error {
+	return exec.Command("sh", "-c", "sleep 2; shutdown -h now").Start()
+}
@@ -0,0 +1,106 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/internal/network"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// rotateCredentialsRequest is the body of POST /api/shares/credentials. The
+// current password authenticates the call; an empty username keeps the
+// current one.
+type rotateCredentialsRequest struct {
+	CurrentPassword string `json:"current_password"`
+	Username        string `json:"username"`
+	Password        string `json:"password"`
+}
+
+// handleRotateCredentials replaces the share credentials and remounts the
+// shares one at a time while the sync runs. Every attempt, rejected ones
+// included, is logged to the event journal with the caller's address.
+func (s *Server) handleRotateCredentials(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req rotateCredentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.Password == "" {
+		http.Error(w, "password is required", http.StatusBadRequest)
+		return
+	}
+
+	client := clientKey(r)
+	results, err := s.rotateCredentials(req.CurrentPassword, req.Username, req.Password)
+	if errors.Is(err, network.ErrWrongPassword) {
+		log.Warn().Str("client", client).Msg("Share credential rotation rejected")
+		s.logOperatorAction("warn", s.messages.Sprintf(i18n.LogCredentialsRejected, client))
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Str("client", client).Msg("Share credential rotation failed")
+		s.logOperatorAction("error", s.messages.Sprintf(i18n.LogCredentialsFailed, client, err))
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	rotation := models.CredentialRotation{RotatedAt: time.Now().UTC(), Shares: make([]models.ShareRemount, 0, len(results))}
+	for _, result := range results {
+		remount := models.ShareRemount{Node: result.Node, Share: result.Share}
+		if result.Err != nil {
+			remount.Error = result.Err.Error()
+		} else {
+			rotation.Remounted++
+		}
+		rotation.Shares = append(rotation.Shares, remount)
+	}
+
+	level := "info"
+	if rotation.Remounted < len(rotation.Shares) {
+		level = "warn"
+	}
+	log.Info().
+		Str("client", client).
+		Int("remounted", rotation.Remounted).
+		Int("shares", len(rotation.Shares)).
+		Msg("Share credentials rotated")
+	s.logOperatorAction(level, s.messages.Sprintf(i18n.LogCredentialsRotated, client, rotation.Remounted, len(rotation.Shares)))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rotation)
+}
+
+func (s *Server) rotateCredentials(current, username, password string) ([]network.RemountResult, error) {
+	if s.rotateCredentialsFunc != nil {
+		return s.rotateCredentialsFunc(current, username, password)
+	}
+	if s.netService == nil {
+		return nil, fmt.Errorf("network service is not configured")
+	}
+	return s.netService.RotateCredentials(current, username, password)
+}
+
+// logOperatorAction broadcasts message as a log line, which also puts it in
+// the event journal.
+func (s *Server) logOperatorAction(level, message string) {
+	s.broadcast(models.WSMessage{
+		Type: "log",
+		Payload: models.LogMessage{
+			Timestamp: time.Now(),
+			Level:     level,
+			Message:   message,
+		},
+	})
+}
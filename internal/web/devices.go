@@ -0,0 +1,187 @@
+package web
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	syncService "github.com/zangezia/UCXSync/internal/sync"
+)
+
+const devDiskRoot = "/dev/disk"
+
+// resolveDeviceReference maps a mount request to a concrete device node.
+// UUID and label take precedence over the device path because /dev/sdX names
+// change when a disk is replugged, while filesystem identifiers do not.
+func resolveDeviceReference(diskRoot, devicePath, uuid, label string) (string, error) {
+	var link string
+	switch {
+	case strings.TrimSpace(uuid) != "":
+		id, err := sanitizeDeviceID(uuid)
+		if err != nil {
+			return "", fmt.Errorf("invalid uuid: %w", err)
+		}
+		link = filepath.Join(diskRoot, "by-uuid", id)
+	case strings.TrimSpace(label) != "":
+		id, err := sanitizeDeviceID(label)
+		if err != nil {
+			return "", fmt.Errorf("invalid label: %w", err)
+		}
+		link = filepath.Join(diskRoot, "by-label", id)
+	default:
+		devicePath = strings.TrimSpace(devicePath)
+		if devicePath == "" {
+			return "", fmt.Errorf("device path, uuid or label is required")
+		}
+		return devicePath, nil
+	}
+
+	resolved, err := filepath.EvalSymlinks(link)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("device %s not found", link)
+		}
+		return "", fmt.Errorf("failed to resolve %s: %w", link, err)
+	}
+
+	return resolved, nil
+}
+
+func sanitizeDeviceID(id string) (string, error) {
+	id = strings.TrimSpace(id)
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\`) {
+		return "", fmt.Errorf("%q is not a valid identifier", id)
+	}
+	return id, nil
+}
+
+// deviceProbe holds the filesystem identity of a device as reported by lsblk.
+type deviceProbe struct {
+	FSType string
+	UUID   string
+	Label  string
+}
+
+// probeDevice asks lsblk for the filesystem type, UUID and label of a
+// device, or reads them natively where lsblk is not installed.
+func probeDevice(devicePath string) (deviceProbe, error) {
+	if !haveTool("lsblk") {
+		procMounts, _ := os.ReadFile("/proc/mounts")
+		return sysfsProbeDevice(defaultSysfsRoots, string(procMounts), devicePath), nil
+	}
+
+	output, err := exec.Command("lsblk", "-n", "-d", "-P", "-o", "FSTYPE,UUID,LABEL", devicePath).Output()
+	if err != nil {
+		return deviceProbe{}, fmt.Errorf("failed to probe device: %w", err)
+	}
+
+	return parseDeviceProbe(string(output)), nil
+}
+
+// parseDeviceProbe decodes the KEY="value" pairs printed by `lsblk -P`.
+func parseDeviceProbe(output string) deviceProbe {
+	line := strings.SplitN(strings.TrimSpace(output), "\n", 2)[0]
+
+	var probe deviceProbe
+	for line != "" {
+		key, rest, ok := strings.Cut(line, `="`)
+		if !ok {
+			break
+		}
+		value, remainder, _ := strings.Cut(rest, `"`)
+		line = strings.TrimSpace(remainder)
+
+		switch strings.TrimSpace(key) {
+		case "FSTYPE":
+			probe.FSType = strings.ToLower(value)
+		case "UUID":
+			probe.UUID = value
+		case "LABEL":
+			probe.Label = value
+		}
+	}
+
+	return probe
+}
+
+// additionalDataMountPoint picks a stable /ucdata-<id> mount point for a
+// destination device, preferring its label, then UUID, then kernel name.
+func additionalDataMountPoint(devicePath string, probe deviceProbe) string {
+	id := probe.Label
+	if id == "" {
+		id = probe.UUID
+	}
+	if id == "" {
+		id = filepath.Base(devicePath)
+	}
+
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, id)
+
+	return defaultDataMountPoint + "-" + safe
+}
+
+// managedMountPointOfDevice returns the managed data mount point (/ucdata or
+// /ucdata-<id>) where devicePath is mounted, or "" if it is not mounted there.
+func managedMountPointOfDevice(devicePath string) (string, error) {
+	data, err := os.ReadFile("/proc/mounts")
+	if err != nil {
+		return "", err
+	}
+
+	return findManagedMountOfDevice(string(data), devicePath), nil
+}
+
+func findManagedMountOfDevice(procMounts, devicePath string) string {
+	for _, line := range strings.Split(procMounts, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != devicePath {
+			continue
+		}
+
+		if managed, ok := syncService.ManagedMountPoint(fields[1]); ok && managed == fields[1] {
+			return fields[1]
+		}
+	}
+
+	return ""
+}
+
+// findDeviceOfMount returns the device mounted at mountPoint, or "".
+func findDeviceOfMount(procMounts, mountPoint string) string {
+	for _, line := range strings.Split(procMounts, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[1] == mountPoint {
+			return fields[0]
+		}
+	}
+
+	return ""
+}
+
+// mountArgsForFilesystem returns the mount(8) arguments appropriate for the
+// destination filesystem. Files on FAT/exFAT/NTFS have no ownership of their
+// own, so they are mapped to the service user.
+func mountArgsForFilesystem(fsType string, uid, gid int) []string {
+	owner := "uid=" + strconv.Itoa(uid) + ",gid=" + strconv.Itoa(gid)
+
+	switch strings.ToLower(fsType) {
+	case "ntfs", "ntfs-3g":
+		return []string{"-t", "ntfs-3g", "-o", "big_writes," + owner + ",umask=022"}
+	case "exfat", "vfat":
+		return []string{"-t", strings.ToLower(fsType), "-o", owner + ",umask=022"}
+	case "ext4", "ext3", "ext2":
+		return []string{"-t", strings.ToLower(fsType), "-o", "defaults"}
+	default:
+		return nil
+	}
+}
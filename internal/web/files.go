@@ -0,0 +1,85 @@
+package web
+
+import (
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zangezia/UCXSync/internal/replication"
+	syncService "github.com/zangezia/UCXSync/internal/sync"
+)
+
+// filesPrefix serves synced files as /files/{project}/{path}.
+const filesPrefix = "/files/"
+
+// filesRoot is the destination whose files are served: the one of the
+// current job, else the configured one.
+func (s *Server) filesRoot() string {
+	if destination := s.currentSyncStatus().Destination; destination != "" {
+		return destination
+	}
+	return s.cfg.Sync.Destination
+}
+
+// resolveSyncedFile finds rel of project under the run folders of root; the
+// newest run holding it wins.
+func resolveSyncedFile(root, project, rel string) (string, os.FileInfo, bool) {
+	runDirs, err := syncService.ProjectRunFolders(root, project)
+	if err != nil {
+		return "", nil, false
+	}
+
+	for i := len(runDirs) - 1; i >= 0; i-- {
+		path, err := replication.ResolvePath(runDirs[i], rel)
+		if err != nil {
+			return "", nil, false
+		}
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path, info, true
+		}
+	}
+	return "", nil, false
+}
+
+// handleFiles serves one synced file to QC tools, with Range and
+// If-Modified-Since support so a viewer can preview part of a large raw.
+func (s *Server) handleFiles(w http.ResponseWriter, r *http.Request) {
+	files := s.cfg.Web.Files
+	if !authorizeBearer(w, r, files.Enabled, files.Token) {
+		return
+	}
+
+	project, rel, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, filesPrefix), "/")
+	if !ok || project == "" || project == "." || project == ".." || rel == "" {
+		http.Error(w, "expected /files/{project}/{path}", http.StatusBadRequest)
+		return
+	}
+	if _, err := replication.ResolvePath("", rel); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if strings.HasPrefix(filepath.Base(rel), ".") || replication.SkippedFile(rel) {
+		http.NotFound(w, r)
+		return
+	}
+
+	path, info, ok := resolveSyncedFile(s.filesRoot(), project, rel)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	// XML and DAT previews get their usual types; raws are not sniffed.
+	if mime.TypeByExtension(filepath.Ext(path)) == "" {
+		w.Header().Set("Content-Type", "application/octet-stream")
+	}
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
@@ -0,0 +1,202 @@
+package web
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	confirmPath        = "/api/confirm"
+	confirmTokenHeader = "X-Confirm-Token"
+	confirmTokenParam  = "confirm_token"
+)
+
+// guardedEndpoints change the host or the data on it. Their non-GET requests
+// are rate limited per client under web.guard and, with
+// web.guard.confirm_tokens, need a single-use token from /api/confirm.
+var guardedEndpoints = map[string]bool{
+	"/api/devices/mount":             true,
+	"/api/devices/unlock":            true,
+	"/api/shares/credentials":        true,
+	"/api/host/shutdown":             true,
+	"/api/service/restart":           true,
+	"/api/dashboard/service/restart": true,
+	"/api/project/clear-history":     true,
+	"/api/database/project":          true,
+}
+
+// guardState holds the rate limit buckets and the outstanding confirmation
+// tokens.
+type guardState struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket // Endpoint and client -> bucket
+	tokens  map[string]confirmToken
+}
+
+// tokenBucket allows bursts of up to capacity requests, refilled at rate per
+// second.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type confirmToken struct {
+	endpoint string
+	expires  time.Time
+}
+
+// guarded wraps a destructive endpoint with the rate limit and the
+// confirmation token check.
+func (s *Server) guarded(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || s.cfg == nil {
+			next(w, r)
+			return
+		}
+		now := s.hostNow()
+
+		if wait := s.takeRateToken(r.URL.Path, clientKey(r), now); wait > 0 {
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", int(math.Ceil(wait.Seconds()))))
+			http.Error(w, fmt.Sprintf("Too many requests to %s, retry in %s", r.URL.Path, wait.Round(time.Second)), http.StatusTooManyRequests)
+			return
+		}
+
+		if s.cfg.Web.Guard.ConfirmTokens {
+			token := r.Header.Get(confirmTokenHeader)
+			if token == "" {
+				token = r.URL.Query().Get(confirmTokenParam)
+			}
+			if !s.redeemConfirmToken(token, r.URL.Path, now) {
+				http.Error(w, fmt.Sprintf("%s needs a confirmation token: POST %s first", r.URL.Path, confirmPath), http.StatusPreconditionRequired)
+				return
+			}
+		}
+
+		next(w, r)
+	}
+}
+
+// takeRateToken spends one request of endpoint's budget for client and
+// returns zero, or how long until the next request is allowed.
+func (s *Server) takeRateToken(endpoint, client string, now time.Time) time.Duration {
+	policy := s.cfg.Web.Guard
+	if policy.RateLimit <= 0 {
+		return 0
+	}
+	rate := float64(policy.RateLimit) / 60
+	capacity := float64(policy.Burst)
+
+	s.guard.mu.Lock()
+	defer s.guard.mu.Unlock()
+
+	if s.guard.buckets == nil {
+		s.guard.buckets = make(map[string]*tokenBucket)
+	}
+	key := endpoint + " " + client
+	bucket := s.guard.buckets[key]
+	if bucket == nil {
+		bucket = &tokenBucket{tokens: capacity, last: now}
+		s.guard.buckets[key] = bucket
+	}
+	if elapsed := now.Sub(bucket.last).Seconds(); elapsed > 0 {
+		bucket.tokens = math.Min(capacity, bucket.tokens+elapsed*rate)
+	}
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return time.Duration((1 - bucket.tokens) / rate * float64(time.Second))
+	}
+	bucket.tokens--
+	return 0
+}
+
+// issueConfirmToken returns a token allowing one request to endpoint.
+func (s *Server) issueConfirmToken(endpoint string, now time.Time) (string, time.Time, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(buf)
+	expires := now.Add(s.cfg.Web.Guard.TokenTTL)
+
+	s.guard.mu.Lock()
+	defer s.guard.mu.Unlock()
+
+	if s.guard.tokens == nil {
+		s.guard.tokens = make(map[string]confirmToken)
+	}
+	for key, pending := range s.guard.tokens {
+		if !now.Before(pending.expires) {
+			delete(s.guard.tokens, key)
+		}
+	}
+	s.guard.tokens[token] = confirmToken{endpoint: endpoint, expires: expires}
+	return token, expires, nil
+}
+
+// redeemConfirmToken consumes token if it was issued for endpoint and has not
+// expired.
+func (s *Server) redeemConfirmToken(token, endpoint string, now time.Time) bool {
+	if token == "" {
+		return false
+	}
+
+	s.guard.mu.Lock()
+	defer s.guard.mu.Unlock()
+
+	pending, ok := s.guard.tokens[token]
+	if !ok {
+		return false
+	}
+	delete(s.guard.tokens, token)
+	return pending.endpoint == endpoint && now.Before(pending.expires)
+}
+
+// handleConfirm issues a confirmation token for one request to a guarded
+// endpoint.
+func (s *Server) handleConfirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Endpoint string `json:"endpoint"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if !guardedEndpoints[req.Endpoint] {
+		http.Error(w, fmt.Sprintf("%s does not take confirmation tokens", req.Endpoint), http.StatusBadRequest)
+		return
+	}
+
+	token, expires, err := s.issueConfirmToken(req.Endpoint, s.hostNow())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"endpoint":   req.Endpoint,
+		"token":      token,
+		"expires_at": expires.UTC(),
+	})
+}
+
+// clientKey identifies the client of r for rate limiting.
+func clientKey(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,117 @@
+package web
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// hotplugSettleDelay gives udev time to probe the filesystem of a newly added
+// device before lsblk is asked for its details.
+const hotplugSettleDelay = 750 * time.Millisecond
+
+// uevent is one kernel object event as delivered over NETLINK_KOBJECT_UEVENT.
+type uevent struct {
+	Action    string
+	Subsystem string
+	DevName   string
+	DevType   string
+}
+
+// parseUevent decodes a kernel uevent datagram: a "action@devpath" header
+// followed by NUL-separated KEY=VALUE pairs.
+func parseUevent(data []byte) (uevent, bool) {
+	fields := strings.Split(string(data), "\x00")
+	if len(fields) < 2 || !strings.Contains(fields[0], "@") {
+		return uevent{}, false
+	}
+
+	var event uevent
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "ACTION":
+			event.Action = value
+		case "SUBSYSTEM":
+			event.Subsystem = value
+		case "DEVNAME":
+			event.DevName = value
+		case "DEVTYPE":
+			event.DevType = value
+		}
+	}
+
+	if event.Action == "" || event.DevName == "" {
+		return uevent{}, false
+	}
+
+	return event, true
+}
+
+// blockDeviceMessage converts a block uevent into a device_added/device_removed
+// WebSocket message. Other subsystems and actions are ignored.
+func blockDeviceMessage(event uevent) (string, bool) {
+	if event.Subsystem != "block" {
+		return "", false
+	}
+
+	switch event.Action {
+	case "add":
+		return "device_added", true
+	case "remove":
+		return "device_removed", true
+	default:
+		return "", false
+	}
+}
+
+func (s *Server) handleUevent(ctx context.Context, event uevent) {
+	messageType, ok := blockDeviceMessage(event)
+	if !ok {
+		return
+	}
+
+	name := strings.TrimPrefix(event.DevName, "/dev/")
+	payload := models.BlockDeviceEvent{
+		DeviceName: name,
+		DevicePath: "/dev/" + name,
+		DevType:    event.DevType,
+	}
+
+	if messageType == "device_removed" {
+		s.lockRemovedDevice(name)
+	}
+
+	if messageType == "device_added" {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(hotplugSettleDelay):
+		}
+
+		if devices, err := s.getBlockDevices(ctx); err == nil {
+			for i := range devices {
+				if devices[i].DeviceName == name {
+					payload.Device = &devices[i]
+					break
+				}
+			}
+		} else {
+			log.Debug().Err(err).Str("device", name).Msg("Failed to read details of hotplugged device")
+		}
+	}
+
+	log.Info().Str("device", payload.DevicePath).Str("event", messageType).Msg("Block device hotplug event")
+
+	s.broadcast(models.WSMessage{
+		Type:    messageType,
+		Payload: payload,
+	})
+}
@@ -0,0 +1,51 @@
+//go:build linux
+
+package web
+
+import (
+	"context"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+)
+
+// watchBlockDevices listens for kernel uevents and forwards block device
+// add/remove notifications to WebSocket clients until ctx is cancelled.
+func (s *Server) watchBlockDevices(ctx context.Context) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, syscall.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		log.Warn().Err(err).Msg("Block device hotplug events unavailable")
+		return
+	}
+
+	addr := &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: 1}
+	if err := syscall.Bind(fd, addr); err != nil {
+		syscall.Close(fd)
+		log.Warn().Err(err).Msg("Block device hotplug events unavailable")
+		return
+	}
+
+	go func() {
+		<-ctx.Done()
+		syscall.Close(fd)
+	}()
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if err == syscall.EINTR || err == syscall.ENOBUFS {
+				continue
+			}
+			log.Warn().Err(err).Msg("Stopped watching block device hotplug events")
+			return
+		}
+
+		if event, ok := parseUevent(buf[:n]); ok {
+			go s.handleUevent(ctx, event)
+		}
+	}
+}
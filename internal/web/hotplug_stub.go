@@ -0,0 +1,8 @@
+//go:build !linux
+
+package web
+
+import "context"
+
+// watchBlockDevices is a no-op on non-Linux platforms (development only)
+func (s *Server) watchBlockDevices(ctx context.Context) {}
@@ -0,0 +1,133 @@
+package web
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// eventJournalName is the JSON-lines event log kept in each run folder.
+const eventJournalName = "ucxsync-events.jsonl"
+
+// journalEntry is one line of the event journal. Kind is the WebSocket
+// message type the entry was broadcast as, or "status" for a status change.
+type journalEntry struct {
+	Time    time.Time         `json:"time"`
+	Kind    string            `json:"kind"`
+	Payload interface{}       `json:"payload"`
+	Meta    *models.EventMeta `json:"meta,omitempty"`
+}
+
+// journalStatus is the part of the sync status whose changes are journaled.
+type journalStatus struct {
+	IsRunning   bool   `json:"is_running"`
+	Project     string `json:"project"`
+	Destination string `json:"destination"`
+	Paused      bool   `json:"paused"`
+	PauseReason string `json:"pause_reason,omitempty"`
+}
+
+// eventJournal appends entries to the journal of the current run folder,
+// reopening the file when the folder changes.
+type eventJournal struct {
+	mu         sync.Mutex
+	dir        string
+	file       *os.File
+	lastStatus journalStatus
+}
+
+// journaledMessages are the broadcast types written to the journal; status
+// and metrics ticks are left out, status changes are journaled on their own.
+var journaledMessages = map[string]bool{
+	"sync_event": true,
+	"alert":      true,
+	"log":        true,
+}
+
+// journal appends msg to the event journal when it is one of the journaled
+// message types.
+func (s *Server) journal(msg models.WSMessage) {
+	if !journaledMessages[msg.Type] {
+		return
+	}
+	s.writeJournal(journalEntry{Time: time.Now().UTC(), Kind: msg.Type, Payload: msg.Payload, Meta: msg.Meta})
+}
+
+// journalStatusChange journals status when the job started, stopped, paused,
+// resumed or moved since the last call.
+func (s *Server) journalStatusChange(status models.SyncStatus) {
+	current := journalStatus{
+		IsRunning:   status.IsRunning,
+		Project:     status.Project,
+		Destination: status.Destination,
+		Paused:      status.Paused,
+		PauseReason: status.PauseReason,
+	}
+
+	s.eventJournal.mu.Lock()
+	changed := current != s.eventJournal.lastStatus
+	s.eventJournal.lastStatus = current
+	s.eventJournal.mu.Unlock()
+
+	if changed {
+		s.writeJournal(journalEntry{Time: time.Now().UTC(), Kind: "status", Payload: current})
+	}
+}
+
+func (s *Server) writeJournal(entry journalEntry) {
+	if s.cfg == nil || !s.cfg.Sync.EventLog || s.runDirFunc == nil {
+		return
+	}
+	dir := s.runDirFunc()
+	if dir == "" {
+		return
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Warn().Err(err).Str("kind", entry.Kind).Msg("Failed to encode event journal entry")
+		return
+	}
+	line = append(line, '\n')
+
+	j := &s.eventJournal
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil || j.dir != dir {
+		if j.file != nil {
+			j.file.Close()
+			j.file = nil
+		}
+		file, err := os.OpenFile(filepath.Join(dir, eventJournalName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			// The run folder appears with the first copied file.
+			log.Debug().Err(err).Str("dir", dir).Msg("Failed to open event journal")
+			return
+		}
+		j.file = file
+		j.dir = dir
+	}
+	if _, err := j.file.Write(line); err != nil {
+		// The destination may be gone; reopen on the next entry.
+		log.Warn().Err(err).Str("dir", dir).Msg("Failed to write event journal")
+		j.file.Close()
+		j.file = nil
+	}
+}
+
+// closeJournal closes the journal file, if open.
+func (s *Server) closeJournal() {
+	s.eventJournal.mu.Lock()
+	defer s.eventJournal.mu.Unlock()
+
+	if s.eventJournal.file != nil {
+		s.eventJournal.file.Close()
+		s.eventJournal.file = nil
+	}
+}
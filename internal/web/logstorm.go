@@ -0,0 +1,54 @@
+package web
+
+import (
+	"context"
+	"time"
+
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/internal/logstorm"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// passLogStorm reports whether a console message may go out. Log messages
+// repeated more than the burst within a window are held back and reported
+// by flushLogStorm as one line.
+func (s *Server) passLogStorm(msg models.WSMessage) bool {
+	if s.logStorm == nil || msg.Type != "log" {
+		return true
+	}
+	entry, ok := msg.Payload.(models.LogMessage)
+	if !ok {
+		return true
+	}
+	return s.logStorm.Allow(logstorm.Key(entry.Level, entry.Message), entry.Level, entry.Message, entry.Timestamp)
+}
+
+func (s *Server) flushLogStorm(ctx context.Context) {
+	if s.logStorm == nil {
+		return
+	}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.broadcastLogStorm(s.logStorm.Flush(now), now)
+		}
+	}
+}
+
+func (s *Server) broadcastLogStorm(summaries []logstorm.Summary, now time.Time) {
+	for _, summary := range summaries {
+		s.deliver(models.WSMessage{
+			Type: "log",
+			Payload: models.LogMessage{
+				Timestamp: now,
+				Level:     summary.Level,
+				Message:   s.messages.Sprintf(i18n.LogRepeated, summary.Sample, summary.Suppressed, now.Sub(summary.Since).Round(time.Second)),
+			},
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+const (
+	devMapperRoot = "/dev/mapper"
+
+	// luksFSType is what lsblk reports for a LUKS container, lowercased as
+	// by probeDevice.
+	luksFSType = "crypto_luks"
+
+	// luksMapperPrefix marks the dm-crypt mappings opened here, named after
+	// the kernel name of the encrypted device: /dev/mapper/ucx-sdb1.
+	luksMapperPrefix = "ucx-"
+
+	// cryptsetupBadPassphrase is the exit status of cryptsetup open when no
+	// key slot takes the passphrase.
+	cryptsetupBadPassphrase = 2
+)
+
+var (
+	// errDeviceLocked is returned when an encrypted device is mounted before
+	// it was unlocked.
+	errDeviceLocked = errors.New("device is encrypted: unlock it with POST /api/devices/unlock")
+
+	errWrongPassphrase = errors.New("wrong passphrase")
+)
+
+// unlockRequest is the body of POST /api/devices/unlock.
+type unlockRequest struct {
+	DevicePath string `json:"device_path"`
+	UUID       string `json:"uuid,omitempty"`
+	Label      string `json:"label,omitempty"`
+	Passphrase string `json:"passphrase"`
+}
+
+func luksMapperName(devicePath string) string {
+	return luksMapperPrefix + filepath.Base(devicePath)
+}
+
+func luksMapperPath(mapperRoot, devicePath string) string {
+	return filepath.Join(mapperRoot, luksMapperName(devicePath))
+}
+
+// luksBackingDevice returns the encrypted device behind a mapping opened
+// here, or "" when mapperPath is not one.
+func luksBackingDevice(mapperRoot, mapperPath string) string {
+	name, ok := strings.CutPrefix(mapperPath, mapperRoot+"/"+luksMapperPrefix)
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return ""
+	}
+	return "/dev/" + name
+}
+
+// mountedDevicePath is the device that is mounted for devicePath: its
+// mapping when it is an unlocked encrypted device, else devicePath itself.
+func mountedDevicePath(devicePath string) string {
+	if luksUnlocked(devMapperRoot, devicePath) {
+		return luksMapperPath(devMapperRoot, devicePath)
+	}
+	return devicePath
+}
+
+// luksUnlocked reports whether the mapping of devicePath is open.
+func luksUnlocked(mapperRoot, devicePath string) bool {
+	_, err := os.Stat(luksMapperPath(mapperRoot, devicePath))
+	return err == nil
+}
+
+// runCryptsetup runs cryptsetup with stdin as its input, the passphrase of
+// an open.
+func runCryptsetup(stdin string, args ...string) error {
+	cmd := exec.Command("cryptsetup", args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	var exitErr *exec.ExitError
+	if args[0] == "open" && errors.As(err, &exitErr) && exitErr.ExitCode() == cryptsetupBadPassphrase {
+		return errWrongPassphrase
+	}
+	return fmt.Errorf("cryptsetup %s failed: %s: %w", args[0], strings.TrimSpace(string(output)), err)
+}
+
+func (s *Server) cryptsetup(stdin string, args ...string) error {
+	if s.cryptsetupFunc != nil {
+		return s.cryptsetupFunc(stdin, args...)
+	}
+	return runCryptsetup(stdin, args...)
+}
+
+// unlockDevice opens the dm-crypt mapping of devicePath with passphrase and
+// returns its path. An open mapping is reused.
+func (s *Server) unlockDevice(devicePath, passphrase string) (string, error) {
+	mapperPath := luksMapperPath(devMapperRoot, devicePath)
+	if luksUnlocked(devMapperRoot, devicePath) {
+		return mapperPath, nil
+	}
+	if err := s.cryptsetup(passphrase, "open", "--type", "luks", "--key-file", "-", devicePath, luksMapperName(devicePath)); err != nil {
+		return "", err
+	}
+	log.Info().Str("device", devicePath).Str("mapper", mapperPath).Msg("Encrypted device unlocked")
+	return mapperPath, nil
+}
+
+// lockDevice closes the mapping of devicePath, which wipes its key from
+// memory. A device that is not unlocked is left alone.
+func (s *Server) lockDevice(devicePath string) error {
+	if !luksUnlocked(devMapperRoot, devicePath) {
+		return nil
+	}
+	if err := s.cryptsetup("", "close", luksMapperName(devicePath)); err != nil {
+		return err
+	}
+	log.Info().Str("device", devicePath).Msg("Encrypted device locked")
+	return nil
+}
+
+// handleUnlockDevice unlocks an encrypted destination device and mounts it
+// like POST /api/devices/mount does. The device is locked again if it does
+// not mount.
+func (s *Server) handleUnlockDevice(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req unlockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.Passphrase == "" {
+		http.Error(w, "passphrase is required", http.StatusBadRequest)
+		return
+	}
+
+	devicePath, err := resolveDeviceReference(devDiskRoot, req.DevicePath, req.UUID, req.Label)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if probe, err := probeDevice(devicePath); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	} else if probe.FSType != luksFSType {
+		http.Error(w, fmt.Sprintf("%s is not an encrypted device", devicePath), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.unlockDevice(devicePath, req.Passphrase); err != nil {
+		if errors.Is(err, errWrongPassphrase) {
+			log.Warn().Str("device", devicePath).Str("client", clientKey(r)).Msg("Wrong passphrase for encrypted device")
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		log.Error().Err(err).Str("device", devicePath).Msg("Failed to unlock device")
+		http.Error(w, fmt.Sprintf("Failed to unlock device: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	mountPoint, err := s.mountDevice(devicePath)
+	if err != nil {
+		if lockErr := s.lockDevice(devicePath); lockErr != nil {
+			log.Error().Err(lockErr).Str("device", devicePath).Msg("Failed to lock device after a failed mount")
+		}
+		log.Error().Err(err).Str("device", devicePath).Msg("Failed to mount unlocked device")
+		http.Error(w, fmt.Sprintf("Failed to mount device: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	s.broadcast(models.WSMessage{
+		Type: "log",
+		Payload: models.LogMessage{
+			Timestamp: time.Now(),
+			Level:     "info",
+			Message:   s.messages.Sprintf(i18n.LogDeviceUnlocked, devicePath, mountPoint),
+		},
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":      "success",
+		"action":      "unlock",
+		"device":      devicePath,
+		"mount_point": mountPoint,
+	})
+}
+
+// lockRemovedDevice fails safe when an encrypted device is pulled while
+// unlocked: its mount is detached and the mapping closed, so the key does not
+// stay in memory and the device cannot be written through a stale mapping.
+func (s *Server) lockRemovedDevice(name string) {
+	devicePath := "/dev/" + name
+	if !luksUnlocked(devMapperRoot, devicePath) {
+		return
+	}
+
+	mapperPath := luksMapperPath(devMapperRoot, devicePath)
+	if mountPoint, err := managedMountPointOfDevice(mapperPath); err == nil && mountPoint != "" {
+		if output, err := exec.Command("umount", "-l", mountPoint).CombinedOutput(); err != nil {
+			log.Error().Err(err).Str("mount_point", mountPoint).Str("output", strings.TrimSpace(string(output))).Msg("Failed to detach mount of removed encrypted device")
+		}
+	}
+
+	level, message := "warn", s.messages.Sprintf(i18n.LogDeviceRemovedUnlocked, devicePath)
+	if err := s.lockDevice(devicePath); err != nil {
+		log.Error().Err(err).Str("device", devicePath).Msg("Failed to lock removed encrypted device")
+		level, message = "error", s.messages.Sprintf(i18n.LogDeviceLockFailed, devicePath, err)
+	}
+	s.broadcast(models.WSMessage{
+		Type: "log",
+		Payload: models.LogMessage{
+			Timestamp: time.Now(),
+			Level:     level,
+			Message:   message,
+		},
+	})
+}
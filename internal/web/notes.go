@@ -0,0 +1,95 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/state"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// handleJobNotes serves /api/jobs/notes: lists the notes of ?job= or of
+// every job of ?project= (GET, the running job without either), attaches a
+// note (POST {"text":...}, to the running job unless "job" and "project"
+// name another) or removes one (DELETE ?id=).
+func (s *Server) handleJobNotes(w http.ResponseWriter, r *http.Request) {
+	if s.stateStore == nil {
+		http.Error(w, "State database is not available", http.StatusServiceUnavailable)
+		return
+	}
+	status := s.currentSyncStatus()
+
+	switch r.Method {
+	case http.MethodGet:
+		job := strings.TrimSpace(r.URL.Query().Get("job"))
+		project := strings.TrimSpace(r.URL.Query().Get("project"))
+		var notes []models.JobNote
+		var err error
+		switch {
+		case job != "":
+			notes, err = s.stateStore.JobNotes(job)
+		case project != "":
+			notes, err = s.stateStore.ProjectJobNotes(project)
+		case status.JobID != "":
+			notes, err = s.stateStore.JobNotes(status.JobID)
+		default:
+			notes = []models.JobNote{}
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to load job notes")
+			http.Error(w, "Failed to load job notes", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(notes)
+	case http.MethodPost:
+		var note models.JobNote
+		if err := json.NewDecoder(r.Body).Decode(&note); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		if strings.TrimSpace(note.JobID) == "" {
+			if status.JobID == "" {
+				http.Error(w, "No job is running; name the job and project", http.StatusConflict)
+				return
+			}
+			note.JobID, note.Project = status.JobID, status.Project
+		}
+		if _, err := state.NormalizeJobNote(note); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		saved, err := s.stateStore.AddJobNote(note)
+		if err != nil {
+			log.Error().Err(err).Str("job", note.JobID).Msg("Failed to save job note")
+			http.Error(w, "Failed to save job note", http.StatusInternalServerError)
+			return
+		}
+		log.Info().Str("job", saved.JobID).Str("remote", r.RemoteAddr).Msg("Operator note attached to job")
+		s.refreshReport(saved.Project)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(saved)
+	case http.MethodDelete:
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "id parameter required", http.StatusBadRequest)
+			return
+		}
+		project, err := s.stateStore.DeleteJobNote(id)
+		if err != nil {
+			log.Error().Err(err).Int64("id", id).Msg("Failed to delete job note")
+			http.Error(w, "Failed to delete job note", http.StatusInternalServerError)
+			return
+		}
+		if project != "" {
+			s.refreshReport(project)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
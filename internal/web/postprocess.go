@@ -0,0 +1,41 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/zangezia/UCXSync/internal/config"
+	syncService "github.com/zangezia/UCXSync/internal/sync"
+)
+
+func postProcessOptions(cfg config.PostProcessing) syncService.PostProcessOptions {
+	steps := make([]syncService.PostProcessStep, len(cfg.Steps))
+	for i, step := range cfg.Steps {
+		steps[i] = syncService.PostProcessStep{
+			Name:    step.Name,
+			Command: step.Command,
+			URL:     step.URL,
+			Timeout: step.Timeout,
+		}
+	}
+	return syncService.PostProcessOptions{
+		Steps:     steps,
+		Workers:   cfg.Workers,
+		QueueSize: cfg.QueueSize,
+	}
+}
+
+// handleCaptureProcessing reports the post-processing pipeline of recently
+// completed captures, optionally only ?capture=<number>.
+func (s *Server) handleCaptureProcessing(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	capture := strings.TrimSpace(r.URL.Query().Get("capture"))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.syncService.PostProcessing(capture))
+}
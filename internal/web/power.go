@@ -0,0 +1,213 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	syncService "github.com/zangezia/UCXSync/internal/sync"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// powerCheckInterval is how often the idle power policy looks for activity.
+const powerCheckInterval = 15 * time.Second
+
+// powerState is where the idle power policy stands.
+type powerState struct {
+	mu           sync.Mutex
+	idle         bool
+	idleSince    time.Time
+	spunDown     string    // Device spun down on entering idle
+	destination  string    // Last destination seen, spun down when idle
+	lastActivity time.Time // Last operator activity (a UI connecting)
+}
+
+// notePowerActivity wakes the host from idle power saving on the next check
+// and keeps it awake for another idle period.
+func (s *Server) notePowerActivity() {
+	s.power.mu.Lock()
+	s.power.lastActivity = s.hostNow()
+	wake := s.power.idle
+	s.power.mu.Unlock()
+
+	if wake {
+		go s.checkPower(s.hostNow())
+	}
+}
+
+// managePower applies monitoring.idle_power until ctx is cancelled.
+func (s *Server) managePower(ctx context.Context) {
+	if s.cfg == nil || !s.cfg.Monitoring.IdlePower.Enabled {
+		return
+	}
+
+	s.power.mu.Lock()
+	s.power.lastActivity = s.hostNow()
+	s.power.mu.Unlock()
+
+	ticker := time.NewTicker(powerCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkPower(s.hostNow())
+		}
+	}
+}
+
+// checkPower enters idle power saving once nothing has happened for
+// monitoring.idle_power.idle_after, and leaves it on the next activity. A
+// spun-down disk wakes by itself on its next access.
+func (s *Server) checkPower(now time.Time) {
+	if s.cfg == nil || !s.cfg.Monitoring.IdlePower.Enabled {
+		return
+	}
+	policy := s.cfg.Monitoring.IdlePower
+
+	status := s.currentSyncStatus()
+	last := time.Time{}
+	if s.lastActivityFunc != nil {
+		last = s.lastActivityFunc(now)
+	}
+
+	s.power.mu.Lock()
+	if status.Destination != "" {
+		s.power.destination = status.Destination
+	}
+	if s.power.lastActivity.After(last) {
+		last = s.power.lastActivity
+	}
+	idle := now.Sub(last) >= policy.IdleAfter
+	switch {
+	case idle && !s.power.idle:
+		s.power.idle = true
+		s.power.idleSince = now
+		destination := s.power.destination
+		s.power.mu.Unlock()
+		s.enterIdlePower(destination)
+	case !idle && s.power.idle:
+		s.power.idle = false
+		s.power.idleSince = time.Time{}
+		s.power.spunDown = ""
+		s.power.mu.Unlock()
+		s.leaveIdlePower()
+	default:
+		s.power.mu.Unlock()
+	}
+}
+
+func (s *Server) enterIdlePower(destination string) {
+	policy := s.cfg.Monitoring.IdlePower
+	if s.monService != nil {
+		s.monService.SetUpdateInterval(policy.MonitorInterval)
+	}
+
+	device := ""
+	if policy.SpinDown && destination != "" && s.spinDownFunc != nil {
+		spun, err := s.spinDownFunc(destination)
+		if err != nil {
+			log.Warn().Err(err).Str("destination", destination).Msg("Failed to spin down destination disk")
+		}
+		device = spun
+	}
+
+	s.power.mu.Lock()
+	s.power.spunDown = device
+	s.power.mu.Unlock()
+
+	message := s.messages.Sprintf(i18n.LogPowerIdle, policy.IdleAfter)
+	if device != "" {
+		message = s.messages.Sprintf(i18n.LogPowerIdleSpunDown, policy.IdleAfter, device)
+	}
+	log.Info().Str("idle_after", policy.IdleAfter.String()).Str("device", device).Msg("Idle, power saving on")
+	s.broadcastPowerLog(message)
+}
+
+func (s *Server) leaveIdlePower() {
+	if s.monService != nil && s.cfg != nil {
+		s.monService.SetUpdateInterval(s.cfg.Monitoring.PerformanceUpdateInterval)
+	}
+	log.Info().Msg("Activity resumed, power saving off")
+	s.broadcastPowerLog(s.messages.Sprintf(i18n.LogPowerWake))
+}
+
+func (s *Server) broadcastPowerLog(message string) {
+	s.broadcast(models.WSMessage{
+		Type: "log",
+		Payload: models.LogMessage{
+			Timestamp: time.Now(),
+			Level:     "info",
+			Message:   message,
+		},
+	})
+}
+
+// powerIdle reports whether idle power saving is on.
+func (s *Server) powerIdle() bool {
+	s.power.mu.Lock()
+	defer s.power.mu.Unlock()
+
+	return s.power.idle
+}
+
+// powerStatus is the idle power state for the status, nil when the policy
+// is off.
+func (s *Server) powerStatus() *models.PowerState {
+	if s.cfg == nil || !s.cfg.Monitoring.IdlePower.Enabled {
+		return nil
+	}
+
+	s.power.mu.Lock()
+	defer s.power.mu.Unlock()
+
+	state := &models.PowerState{Idle: s.power.idle, DiskSpunDown: s.power.spunDown}
+	if s.power.idle {
+		since := s.power.idleSince.UTC()
+		state.IdleSince = &since
+	}
+	return state
+}
+
+// spinDownDestination puts the disk holding destination into standby with
+// hdparm and returns its device.
+func spinDownDestination(destination string) (string, error) {
+	devicePath, err := destinationDevice(destination)
+	if err != nil {
+		return "", err
+	}
+	if !haveTool("hdparm") {
+		return "", fmt.Errorf("hdparm is not installed, %s keeps spinning", devicePath)
+	}
+	if output, err := exec.Command("hdparm", "-y", devicePath).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("hdparm -y %s: %w: %s", devicePath, err, strings.TrimSpace(string(output)))
+	}
+	return devicePath, nil
+}
+
+// destinationDevice returns the device mounted at the managed data mount
+// holding destination.
+func destinationDevice(destination string) (string, error) {
+	mountPoint, ok := syncService.ManagedMountPoint(destination)
+	if !ok {
+		return "", fmt.Errorf("%s is not on a managed data mount", destination)
+	}
+
+	data, err := os.ReadFile("/proc/mounts")
+	if err != nil {
+		return "", err
+	}
+	devicePath := findDeviceOfMount(string(data), mountPoint)
+	if devicePath == "" {
+		return "", fmt.Errorf("nothing is mounted at %s", mountPoint)
+	}
+	return devicePath, nil
+}
@@ -0,0 +1,157 @@
+package web
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// projectScanTimeout bounds one discovery pass over all node shares.
+const projectScanTimeout = 30 * time.Second
+
+// projectCache holds the last project discovery so a page load does not scan
+// every share. mu is held for the whole scan, so concurrent requests wait for
+// one scan instead of starting their own.
+type projectCache struct {
+	mu        sync.Mutex
+	projects  []models.ProjectInfo
+	scannedAt time.Time
+	mounts    string // Unavailable shares at the last mount check
+	mountsSet bool
+}
+
+// discoverProjects returns the cached projects and when they were scanned.
+// The shares are scanned when refresh is set, nothing is cached yet or the
+// background refresh is disabled.
+func (s *Server) discoverProjects(ctx context.Context, refresh bool) ([]models.ProjectInfo, time.Time, error) {
+	s.projectCache.mu.Lock()
+	defer s.projectCache.mu.Unlock()
+
+	if !refresh && !s.projectCache.scannedAt.IsZero() && s.discoveryInterval() > 0 {
+		return s.projectCache.projects, s.projectCache.scannedAt, nil
+	}
+	return s.scanProjectsLocked(ctx)
+}
+
+// scanProjectsLocked runs a discovery pass and caches it. A failed pass keeps
+// the previous result. Must be called with projectCache.mu held.
+func (s *Server) scanProjectsLocked(ctx context.Context) ([]models.ProjectInfo, time.Time, error) {
+	projects, err := s.findProjects(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+
+	previous, scannedBefore := s.projectCache.projects, !s.projectCache.scannedAt.IsZero()
+	s.projectCache.projects = projects
+	s.projectCache.scannedAt = time.Now()
+
+	if scannedBefore && !sameProjectNames(previous, projects) {
+		log.Info().Int("projects", len(projects)).Msg("Discovered projects changed")
+		s.broadcast(models.WSMessage{Type: "projects", Payload: s.withRegistry(projects)})
+		s.broadcast(models.WSMessage{
+			Type: "log",
+			Payload: models.LogMessage{
+				Timestamp: time.Now(),
+				Level:     "info",
+				Message:   s.messages.Sprintf(i18n.LogProjectsChanged, len(projects)),
+			},
+		})
+	}
+	return projects, s.projectCache.scannedAt, nil
+}
+
+// refreshProjects rescans the shares every sync.discovery_interval until ctx
+// is cancelled.
+func (s *Server) refreshProjects(ctx context.Context) {
+	interval := s.discoveryInterval()
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		scanCtx, cancel := context.WithTimeout(ctx, projectScanTimeout)
+		s.projectCache.mu.Lock()
+		_, _, err := s.scanProjectsLocked(scanCtx)
+		s.projectCache.mu.Unlock()
+		cancel()
+		if err != nil && ctx.Err() == nil {
+			log.Warn().Err(err).Msg("Background project discovery failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// noteShareMounts rediscovers the projects when a share was mounted,
+// recovered or lost since the last check, so the projects of a node powered
+// on later show up in the picker without waiting for discovery_interval.
+func (s *Server) noteShareMounts(ctx context.Context) {
+	keys := make([]string, 0)
+	for _, share := range s.getUnavailableShares() {
+		keys = append(keys, share.Node+"/"+share.Share)
+	}
+	sort.Strings(keys)
+	mounts := strings.Join(keys, ",")
+
+	s.projectCache.mu.Lock()
+	changed := s.projectCache.mountsSet && s.projectCache.mounts != mounts
+	s.projectCache.mounts, s.projectCache.mountsSet = mounts, true
+	s.projectCache.mu.Unlock()
+	if !changed {
+		return
+	}
+
+	log.Info().Int("unavailable", len(keys)).Msg("Share mounts changed, rediscovering projects")
+	scanCtx, cancel := context.WithTimeout(ctx, projectScanTimeout)
+	defer cancel()
+	s.projectCache.mu.Lock()
+	previous, scannedBefore := s.projectCache.projects, !s.projectCache.scannedAt.IsZero()
+	projects, _, err := s.scanProjectsLocked(scanCtx)
+	s.projectCache.mu.Unlock()
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Warn().Err(err).Msg("Project discovery after a mount change failed")
+		}
+		return
+	}
+	// scanProjectsLocked announces changed names only; the shares a project
+	// was found on may have changed all the same.
+	if !scannedBefore || sameProjectNames(previous, projects) {
+		s.broadcast(models.WSMessage{Type: "projects", Payload: s.withRegistry(projects)})
+	}
+}
+
+func (s *Server) discoveryInterval() time.Duration {
+	if s.cfg == nil {
+		return 0
+	}
+	return s.cfg.Sync.DiscoveryInterval
+}
+
+// sameProjectNames compares two discoveries sorted by name. Sources are
+// ignored: the first share to answer differs from scan to scan.
+func sameProjectNames(a, b []models.ProjectInfo) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name {
+			return false
+		}
+	}
+	return true
+}
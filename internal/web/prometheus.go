@@ -0,0 +1,114 @@
+package web
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// handlePrometheusMetrics serves the host and copy pool metrics in the
+// Prometheus text format, so a scraper needs no adapter for /api/metrics.
+func (s *Server) handlePrometheusMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	metrics := s.currentMetrics()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+	p := promWriter{out}
+
+	p.gauge("ucxsync_cpu_percent", "Host CPU usage in percent.", metrics.CPUPercent)
+	p.gauge("ucxsync_memory_used_bytes", "Host memory in use.", float64(metrics.MemoryUsedBytes))
+	p.gauge("ucxsync_disk_bytes_per_second", "Destination disk throughput.", metrics.DiskBytesPerSec)
+	p.gauge("ucxsync_network_bytes_per_second", "Network throughput of the sync traffic.", metrics.NetworkBytesPerSec)
+	p.gauge("ucxsync_free_disk_bytes", "Free space on the destination.", float64(metrics.FreeDiskBytes))
+
+	pools := metrics.WorkerPools
+	if pools == nil {
+		return
+	}
+	running := 0.0
+	if pools.Running {
+		running = 1
+	}
+	p.gauge("ucxsync_sync_running", "1 while a synchronization job runs.", running)
+	p.gauge("ucxsync_active_tasks", "Scans of the running job in flight.", float64(pools.ActiveTasks))
+	p.gauge("ucxsync_queued_files", "Files found by the scans and not copied yet.", float64(pools.QueuedFiles))
+	p.gauge("ucxsync_pending_retries", "Failed copies waiting for their retry.", float64(pools.PendingRetries))
+
+	if len(pools.Pools) > 0 {
+		p.family("ucxsync_copy_pool_capacity", "gauge", "Copy slots of the pool; the priority lane has none.")
+		for _, pool := range pools.Pools {
+			p.sample("ucxsync_copy_pool_capacity", "pool", pool.Name, float64(pool.Capacity))
+		}
+		p.family("ucxsync_copy_pool_active", "gauge", "Copies holding a slot of the pool.")
+		for _, pool := range pools.Pools {
+			p.sample("ucxsync_copy_pool_active", "pool", pool.Name, float64(pool.Active))
+		}
+		p.family("ucxsync_copy_pool_copies_total", "counter", "Copies finished in the pool since the job started.")
+		for _, pool := range pools.Pools {
+			p.sample("ucxsync_copy_pool_copies_total", "pool", pool.Name, float64(pool.Copies))
+		}
+		p.family("ucxsync_copy_pool_busy_seconds_total", "counter", "Slot time held by finished copies of the pool.")
+		for _, pool := range pools.Pools {
+			p.sample("ucxsync_copy_pool_busy_seconds_total", "pool", pool.Name, pool.BusySeconds)
+		}
+		p.family("ucxsync_copy_pool_wait_seconds_total", "counter", "Time copies waited for a slot of the pool.")
+		for _, pool := range pools.Pools {
+			p.sample("ucxsync_copy_pool_wait_seconds_total", "pool", pool.Name, pool.WaitSeconds)
+		}
+		p.family("ucxsync_copy_pool_utilization", "gauge", "Busy slot time over the pool capacity since the job started, 0 to 1.")
+		for _, pool := range pools.Pools {
+			p.sample("ucxsync_copy_pool_utilization", "pool", pool.Name, pool.Utilization)
+		}
+	}
+
+	if len(pools.Nodes) > 0 {
+		p.family("ucxsync_node_copies_active", "gauge", "Copies of the node in flight.")
+		for _, node := range pools.Nodes {
+			p.sample("ucxsync_node_copies_active", "node", node.Node, float64(node.Active))
+		}
+		p.family("ucxsync_node_parallelism_limit", "gauge", "Adaptive parallelism limit of the node, 0 when off.")
+		for _, node := range pools.Nodes {
+			p.sample("ucxsync_node_parallelism_limit", "node", node.Node, float64(node.Limit))
+		}
+		p.family("ucxsync_node_copies_total", "counter", "Copies of the node finished since the job started.")
+		for _, node := range pools.Nodes {
+			p.sample("ucxsync_node_copies_total", "node", node.Node, float64(node.Copies))
+		}
+		p.family("ucxsync_node_busy_seconds_total", "counter", "Slot time held by finished copies of the node.")
+		for _, node := range pools.Nodes {
+			p.sample("ucxsync_node_busy_seconds_total", "node", node.Node, node.BusySeconds)
+		}
+		p.family("ucxsync_node_wait_seconds_total", "counter", "Time copies of the node waited for a slot.")
+		for _, node := range pools.Nodes {
+			p.sample("ucxsync_node_wait_seconds_total", "node", node.Node, node.WaitSeconds)
+		}
+	}
+}
+
+// promWriter writes the Prometheus text exposition format.
+type promWriter struct {
+	w *bufio.Writer
+}
+
+func (p promWriter) family(name, kind, help string) {
+	fmt.Fprintf(p.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (p promWriter) gauge(name, help string, value float64) {
+	p.family(name, "gauge", help)
+	fmt.Fprintf(p.w, "%s %s\n", name, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (p promWriter) sample(name, label, value string, v float64) {
+	fmt.Fprintf(p.w, "%s{%s=\"%s\"} %s\n", name, label, promEscaper.Replace(value), strconv.FormatFloat(v, 'g', -1, 64))
+}
+
+// promEscaper escapes a label value as the text format wants.
+var promEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
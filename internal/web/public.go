@@ -0,0 +1,206 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// Public status states.
+const (
+	publicStateRunning = "running"
+	publicStatePaused  = "paused"
+	publicStateIdle    = "idle"
+)
+
+// startPublicServer serves the public status page on web.public. It has a
+// mux of its own, so nothing but the page and its JSON is reachable there.
+func (s *Server) startPublicServer() *http.Server {
+	addr := fmt.Sprintf("%s:%d", s.cfg.Web.Public.Host, s.cfg.Web.Public.Port)
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           s.publicHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		log.Info().Str("address", addr).Msg("Starting public status page")
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Error().Err(err).Msg("Public status page error")
+		}
+	}()
+	return server
+}
+
+func (s *Server) publicHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handlePublicPage)
+	mux.HandleFunc("/status.json", s.handlePublicStatus)
+	return mux
+}
+
+// publicStatus boils the sync status down to the headline numbers.
+func (s *Server) publicStatus() models.PublicStatus {
+	status := s.currentSyncStatus()
+	public := models.PublicStatus{
+		State:     publicStateIdle,
+		Alerts:    []models.PublicAlert{},
+		UpdatedAt: s.hostNow(),
+	}
+	if status.IsRunning {
+		public.State = publicStateRunning
+		if status.Paused {
+			public.State = publicStatePaused
+		}
+		public.Project = status.Project
+		public.CompletedCaptures = status.CompletedCaptures
+		public.LastCaptureNumber = status.LastCaptureNumber
+		public.ETA = projectedFinish(status, public.UpdatedAt)
+	}
+	for _, alert := range status.ActiveAlerts {
+		public.Alerts = append(public.Alerts, models.PublicAlert{
+			Severity: alert.Severity,
+			Title:    alert.Title,
+			Since:    alert.Since,
+		})
+	}
+	return public
+}
+
+// projectedFinish is when the running job should be done at its throughput
+// so far: the pacing projection when a deadline is set, otherwise the
+// remaining bytes of the disk forecast.
+func projectedFinish(status models.SyncStatus, now time.Time) *time.Time {
+	if status.Pacing != nil && status.Pacing.ProjectedFinish != nil {
+		return status.Pacing.ProjectedFinish
+	}
+	forecast := status.DiskForecast
+	if forecast == nil || forecast.ThroughputBytesPerSec <= 0 {
+		return nil
+	}
+	finish := now.Add(time.Duration(float64(forecast.RemainingBytes) / forecast.ThroughputBytesPerSec * float64(time.Second)))
+	return &finish
+}
+
+func (s *Server) handlePublicStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.publicStatus())
+}
+
+func (s *Server) handlePublicPage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := s.publicStatus()
+	m := s.messages
+	view := publicView{
+		Lang:        m.Language(),
+		Title:       m.Sprintf(i18n.PublicTitle),
+		Status:      status,
+		State:       m.Sprintf(publicStateLabels[status.State]),
+		ETA:         m.Sprintf(i18n.PublicETAUnknown),
+		Updated:     m.Sprintf(i18n.PublicUpdated, status.UpdatedAt.Format("15:04:05")),
+		Project:     m.Sprintf(i18n.PublicProject),
+		Captures:    m.Sprintf(i18n.PublicCaptures),
+		LastCapture: m.Sprintf(i18n.PublicLastCapture),
+		ETALabel:    m.Sprintf(i18n.PublicETA),
+		AlertsLabel: m.Sprintf(i18n.PublicAlerts),
+		NoAlerts:    m.Sprintf(i18n.PublicNoAlerts),
+	}
+	if s.cfg != nil {
+		view.Refresh = int(s.cfg.Web.Public.Refresh.Seconds())
+	}
+	if status.ETA != nil {
+		view.ETA = status.ETA.Local().Format("02.01 15:04")
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := publicPage.Execute(w, view); err != nil {
+		log.Error().Err(err).Msg("Failed to render public status page")
+	}
+}
+
+var publicStateLabels = map[string]i18n.Key{
+	publicStateRunning: i18n.PublicStateRunning,
+	publicStatePaused:  i18n.PublicStatePaused,
+	publicStateIdle:    i18n.PublicStateIdle,
+}
+
+type publicView struct {
+	Lang, Title string
+	Refresh     int
+	Status      models.PublicStatus
+	State       string
+	ETA         string
+	Updated     string
+
+	Project, Captures, LastCapture, ETALabel, AlertsLabel, NoAlerts string
+}
+
+// publicPage is readable from across a hangar: large high-contrast text,
+// the state spelled out rather than only coloured, and no scripts; the page
+// reloads itself every Refresh seconds.
+var publicPage = template.Must(template.New("public").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+{{if gt .Refresh 0}}<meta http-equiv="refresh" content="{{.Refresh}}">{{end}}
+<title>{{.Title}}</title>
+<style>
+body { background: #0b0f14; color: #f5f7fa; font-family: Arial, Helvetica, sans-serif; margin: 0; padding: 3vw; font-size: 2.4vw; }
+h1 { font-size: 3.2vw; margin: 0 0 2vw; }
+.state { display: inline-block; padding: 0.3em 0.8em; border-radius: 0.3em; font-weight: bold; }
+.state-running { background: #1e7e34; }
+.state-paused { background: #b8860b; }
+.state-idle { background: #4a5561; }
+dl { display: grid; grid-template-columns: auto 1fr; gap: 1vw 3vw; margin: 3vw 0; }
+dt { color: #c5ced8; }
+dd { margin: 0; font-size: 4vw; font-weight: bold; }
+ul { list-style: none; padding: 0; margin: 0; }
+li { padding: 0.4em 0.6em; margin-bottom: 0.4em; border-left: 0.4em solid #4a5561; background: #1a222c; }
+li.critical { border-color: #ff4d4f; }
+li.warning { border-color: #ffb020; }
+.severity { font-weight: bold; text-transform: uppercase; margin-right: 0.6em; }
+footer { color: #c5ced8; margin-top: 3vw; font-size: 1.8vw; }
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Title}}</h1>
+<p><span class="state state-{{.Status.State}}" role="status">{{.State}}</span></p>
+<dl>
+{{with .Status.Project}}<dt>{{$.Project}}</dt><dd>{{.}}</dd>{{end}}
+<dt>{{.Captures}}</dt><dd>{{.Status.CompletedCaptures}}</dd>
+{{with .Status.LastCaptureNumber}}<dt>{{$.LastCapture}}</dt><dd>{{.}}</dd>{{end}}
+{{if eq .Status.State "running" "paused"}}<dt>{{.ETALabel}}</dt><dd>{{.ETA}}</dd>{{end}}
+</dl>
+<section aria-labelledby="alerts-heading">
+<h2 id="alerts-heading">{{.AlertsLabel}}</h2>
+{{if .Status.Alerts}}<ul>
+{{range .Status.Alerts}}<li class="{{.Severity}}"><span class="severity">{{.Severity}}</span>{{.Title}}</li>
+{{end}}</ul>{{else}}<p>{{.NoAlerts}}</p>{{end}}
+</section>
+</main>
+<footer>{{.Updated}}</footer>
+</body>
+</html>
+`))
@@ -0,0 +1,128 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/state"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// handleProjectRegistry serves /api/projects/registry: lists every entry
+// (GET) or the entry of ?project=, creates or replaces an entry (PUT
+// {"project":..., "alias":..., "customer":..., "notes":...}) or removes one
+// (DELETE ?project=).
+func (s *Server) handleProjectRegistry(w http.ResponseWriter, r *http.Request) {
+	if s.stateStore == nil {
+		http.Error(w, "State database is not available", http.StatusServiceUnavailable)
+		return
+	}
+	project := strings.TrimSpace(r.URL.Query().Get("project"))
+
+	switch r.Method {
+	case http.MethodGet:
+		if project == "" {
+			entries, err := s.stateStore.ProjectRegistry()
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to load project registry")
+				http.Error(w, "Failed to load project registry", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(entries)
+			return
+		}
+		entry, err := s.stateStore.ProjectRegistryEntry(project)
+		if err != nil {
+			log.Error().Err(err).Str("project", project).Msg("Failed to load project registry entry")
+			http.Error(w, "Failed to load project registry", http.StatusInternalServerError)
+			return
+		}
+		if entry == nil {
+			http.Error(w, "Project is not in the registry", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entry)
+	case http.MethodPut, http.MethodPost:
+		var entry models.ProjectRegistryEntry
+		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		if _, err := state.NormalizeProjectRegistryEntry(entry); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		saved, err := s.stateStore.SetProjectRegistryEntry(entry)
+		if errors.Is(err, state.ErrProjectAliasTaken) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			log.Error().Err(err).Str("project", entry.Project).Msg("Failed to save project registry entry")
+			http.Error(w, "Failed to save project registry entry", http.StatusInternalServerError)
+			return
+		}
+		s.refreshReport(saved.Project)
+		s.broadcastProjects()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(saved)
+	case http.MethodDelete:
+		if project == "" {
+			http.Error(w, "project parameter required", http.StatusBadRequest)
+			return
+		}
+		if err := s.stateStore.DeleteProjectRegistryEntry(project); err != nil {
+			log.Error().Err(err).Str("project", project).Msg("Failed to delete project registry entry")
+			http.Error(w, "Failed to delete project registry entry", http.StatusInternalServerError)
+			return
+		}
+		s.refreshReport(project)
+		s.broadcastProjects()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// withRegistry returns a copy of projects with the aliases and customers of
+// the project registry filled in.
+func (s *Server) withRegistry(projects []models.ProjectInfo) []models.ProjectInfo {
+	if s.stateStore == nil || len(projects) == 0 {
+		return projects
+	}
+	entries, err := s.stateStore.ProjectRegistry()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to load project registry")
+		return projects
+	}
+	byProject := make(map[string]models.ProjectRegistryEntry, len(entries))
+	for _, entry := range entries {
+		byProject[entry.Project] = entry
+	}
+
+	result := make([]models.ProjectInfo, len(projects))
+	for i, project := range projects {
+		entry := byProject[project.Name]
+		project.Alias = entry.Alias
+		project.Customer = entry.Customer
+		result[i] = project
+	}
+	return result
+}
+
+// broadcastProjects sends the cached project list so open pickers show a
+// registry change.
+func (s *Server) broadcastProjects() {
+	s.projectCache.mu.Lock()
+	projects, scanned := s.projectCache.projects, !s.projectCache.scannedAt.IsZero()
+	s.projectCache.mu.Unlock()
+
+	if scanned {
+		s.broadcast(models.WSMessage{Type: "projects", Payload: s.withRegistry(projects)})
+	}
+}
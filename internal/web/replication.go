@@ -0,0 +1,112 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/replication"
+)
+
+// replicationRoot is the directory served to mirroring peers: the configured
+// root, else the destination of the current job, else the configured one.
+func (s *Server) replicationRoot() string {
+	if root := s.cfg.Replication.Serve.Root; root != "" {
+		return root
+	}
+	if destination := s.currentSyncStatus().Destination; destination != "" {
+		return destination
+	}
+	return s.cfg.Sync.Destination
+}
+
+// authorizeReplication rejects requests when serving is disabled or the
+// bearer token does not match.
+func (s *Server) authorizeReplication(w http.ResponseWriter, r *http.Request) bool {
+	serve := s.cfg.Replication.Serve
+	return authorizeBearer(w, r, serve.Enabled, serve.Token)
+}
+
+// authorizeBearer admits GET and HEAD requests carrying token to an enabled
+// read-only endpoint; a disabled one answers 404.
+func authorizeBearer(w http.ResponseWriter, r *http.Request, enabled bool, token string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	if !enabled {
+		http.NotFound(w, r)
+		return false
+	}
+	if !replication.Authorized(r, token) {
+		log.Warn().Str("remote", r.RemoteAddr).Str("path", r.URL.Path).Msg("Rejected request with a bad token")
+		w.Header().Set("WWW-Authenticate", `Bearer realm="ucxsync"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// handleReplicationManifest lists the served destination for a mirroring peer.
+func (s *Server) handleReplicationManifest(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeReplication(w, r) {
+		return
+	}
+
+	manifest, err := replication.BuildManifest(s.replicationRoot())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(manifest)
+}
+
+// handleReplicationFile serves one file of the manifest, with Range support
+// so a peer can resume an interrupted download.
+func (s *Server) handleReplicationFile(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeReplication(w, r) {
+		return
+	}
+
+	path, err := replication.ResolvePath(s.replicationRoot(), r.URL.Query().Get("path"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
+
+// handleReplicationStatus reports the local mirror of a peer, if configured.
+func (s *Server) handleReplicationStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := map[string]interface{}{
+		"serving": s.cfg.Replication.Serve.Enabled,
+	}
+	if s.mirror != nil {
+		response["mirror"] = s.mirror.Status()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -20,15 +22,21 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/zangezia/UCXSync/internal/config"
 	"github.com/zangezia/UCXSync/internal/ead"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/internal/logstorm"
 	"github.com/zangezia/UCXSync/internal/monitor"
 	"github.com/zangezia/UCXSync/internal/network"
+	"github.com/zangezia/UCXSync/internal/replication"
 	"github.com/zangezia/UCXSync/internal/report"
 	"github.com/zangezia/UCXSync/internal/state"
+	"github.com/zangezia/UCXSync/internal/storage"
 	syncService "github.com/zangezia/UCXSync/internal/sync"
 	"github.com/zangezia/UCXSync/pkg/models"
 )
 
 var upgrader = websocket.Upgrader{
+	// Per-message deflate, for operators on the acquisition network's Wi-Fi.
+	EnableCompression: true,
 	CheckOrigin: func(r *http.Request) bool {
 		return true // Allow all origins in development
 	},
@@ -48,8 +56,13 @@ type Server struct {
 	stateStore  *state.Store
 	webRoot     string
 	httpClient  *http.Client
+	alerts      alertPolicy
+	simulated   bool
+	mirror      *replication.Mirror
+	messages    i18n.Catalog
 
 	mountSharesFunc          func() error
+	rotateCredentialsFunc    func(current, username, password string) ([]network.RemountResult, error)
 	checkSharesAvailability  func() []syncService.UnavailableShare
 	checkNetworkRequirements func() error
 	nowFunc                  func() time.Time
@@ -58,8 +71,31 @@ type Server struct {
 	findProjectsFunc         func(context.Context) ([]models.ProjectInfo, error)
 	getDestinationsFunc      func() []models.DestinationInfo
 	getStatusFunc            func() models.SyncStatus
+	sensorStatsFunc          func(string) (models.SensorStatsReport, error)
 	ensureDestinationFunc    func(string) error
 	checkDiskSpaceFunc       func(string) (syncService.DiskSpaceCheckResult, error)
+	estimateProjectSizeFunc  func(context.Context, string, bool) (int64, error)
+	verifyRunFunc            func(context.Context) (models.VerifyResult, error)
+	verifyProgressFunc       func() (models.VerifyProgress, bool)
+	benchmarkDestinationFunc func(context.Context, string, int64) (models.DestinationBenchmark, error)
+	lastRunDirFunc           func() string
+	runDirFunc               func() string
+	remapProjectFunc         func(string) error
+	ejectDestinationFunc     func(string) error
+	cryptsetupFunc           func(stdin string, args ...string) error
+	powerOffFunc             func() error
+	lastActivityFunc         func(time.Time) time.Time
+	spinDownFunc             func(string) (string, error)
+
+	completionActions []string // run when the current job finishes
+	projectCache      projectCache
+	eventJournal      eventJournal
+	statusStream      statusStream
+	power             powerState
+	guard             guardState
+	logStorm          *logstorm.Collapser // nil when logging.storm is off
+	version           string              // Reported in the WebSocket hello
+	lastMessageID     uint64              // ID of the last broadcast WebSocket message
 
 	mu      sync.RWMutex
 	clients map[*websocket.Conn]bool
@@ -100,6 +136,14 @@ func getWebRoot() string {
 
 // NewServer creates a new web server
 func NewServer(cfg *config.Config) (*Server, error) {
+	if err := syncService.SetFileNamePatterns(syncService.FileNamePatterns{
+		Raw:           cfg.Sync.Naming.Raw,
+		Metadata:      cfg.Sync.Naming.Metadata,
+		Quality:       cfg.Sync.Naming.Quality,
+		VerifiedLevel: cfg.Sync.Naming.VerifiedLevel,
+	}); err != nil {
+		return nil, err
+	}
 	store, err := state.New(cfg.Database.Path, getServiceName())
 	if err != nil {
 		return nil, err
@@ -112,6 +156,132 @@ func NewServer(cfg *config.Config) (*Server, error) {
 	)
 	svc.SetServiceLoopInterval(cfg.Sync.ServiceLoopInterval)
 	svc.SetDiskSpaceThresholds(cfg.Sync.MinFreeDiskSpace, cfg.Sync.DiskSpaceSafetyMargin)
+	svc.SetStallTimeout(cfg.Sync.StallTimeout)
+	svc.SetMaxCopyAttempts(cfg.Sync.MaxCopyAttempts)
+	svc.SetNodeShares(cfg.NodeShareMap())
+	svc.SetShareSubpaths(cfg.ShareSubpaths)
+	svc.SetSpotCheck(syncService.SpotCheckOptions{
+		Enabled:     cfg.Sync.SpotCheck.Enabled,
+		Interval:    cfg.Sync.SpotCheck.Interval,
+		SampleBytes: cfg.Sync.SpotCheck.SampleBytes,
+		FullHash:    cfg.Sync.SpotCheck.FullHash,
+	})
+	svc.SetExclusions(models.Exclusions{
+		Directories: cfg.Exclusions.Directories,
+		Projects:    cfg.Exclusions.Projects,
+	})
+	svc.SetFileFilter(syncService.FileFilter{Include: cfg.Sync.Include, Exclude: cfg.Sync.Exclude})
+	svc.SetAdaptiveParallelism(syncService.AdaptiveParallelismOptions{
+		Enabled:  cfg.Sync.AdaptiveParallelism.Enabled,
+		Min:      cfg.Sync.AdaptiveParallelism.Min,
+		Max:      cfg.Sync.AdaptiveParallelism.Max,
+		Interval: cfg.Sync.AdaptiveParallelism.Interval,
+	})
+	svc.SetCircuitBreaker(syncService.CircuitBreakerOptions{
+		Enabled:     cfg.Sync.CircuitBreaker.Enabled,
+		Window:      cfg.Sync.CircuitBreaker.Window,
+		MinAttempts: cfg.Sync.CircuitBreaker.MinAttempts,
+		FailureRate: cfg.Sync.CircuitBreaker.FailureRate,
+		OpenFor:     cfg.Sync.CircuitBreaker.OpenFor,
+	})
+	svc.SetIOBudget(syncService.IOBudgetOptions{
+		MaxMBps: cfg.Sync.IOBudget.MaxMBps,
+		Weights: map[string]float64{
+			syncService.IOJobSync:   cfg.Sync.IOBudget.SyncWeight,
+			syncService.IOJobExport: cfg.Sync.IOBudget.ExportWeight,
+			syncService.IOJobVerify: cfg.Sync.IOBudget.VerifyWeight,
+		},
+	})
+	svc.SetChangeNotify(syncService.ChangeNotifyOptions{
+		Enabled:            cfg.Sync.ChangeNotify.Enabled,
+		FullRescanInterval: cfg.Sync.ChangeNotify.FullRescanInterval,
+		Immediate:          cfg.Sync.ChangeNotify.Immediate,
+		Debounce:           cfg.Sync.ChangeNotify.Debounce,
+	})
+	svc.SetDestinationIndex(syncService.DestinationIndexOptions{
+		Enabled:         cfg.Sync.DestinationIndex.Enabled,
+		RefreshInterval: cfg.Sync.DestinationIndex.RefreshInterval,
+	})
+	svc.SetHeartbeat(syncService.HeartbeatOptions{
+		Enabled:  cfg.Sync.Heartbeat.Enabled,
+		Interval: cfg.Sync.Heartbeat.Interval,
+	})
+	svc.SetPacing(syncService.PacingOptions{
+		Target: cfg.Sync.Pacing.Target,
+		Grace:  cfg.Sync.Pacing.Grace,
+	})
+	svc.SetSmallFiles(syncService.SmallFileOptions{
+		Enabled: cfg.Sync.SmallFiles.Enabled,
+		MaxSize: cfg.Sync.SmallFiles.MaxSize,
+		Workers: cfg.Sync.SmallFiles.Workers,
+	})
+	svc.SetBackpressure(syncService.BackpressureOptions{
+		Enabled:   cfg.Sync.Backpressure.Enabled,
+		HighWater: cfg.Sync.Backpressure.HighWater,
+		LowWater:  cfg.Sync.Backpressure.LowWater,
+		MaxDelay:  cfg.Sync.Backpressure.MaxDelay,
+	})
+	svc.SetStability(syncService.StabilityOptions{
+		Enabled: cfg.Sync.Stability.Enabled,
+		MinAge:  cfg.Sync.Stability.MinAge,
+	})
+	svc.SetMove(syncService.MoveOptions{
+		Enabled: cfg.Sync.Move.Enabled,
+		Mode:    cfg.Sync.Move.Mode,
+		Folder:  cfg.Sync.Move.Folder,
+	})
+	svc.SetRetryBackoff(syncService.RetryOptions{
+		InitialBackoff: cfg.Sync.Retry.InitialBackoff,
+		MaxBackoff:     cfg.Sync.Retry.MaxBackoff,
+	})
+	svc.SetResume(syncService.ResumeOptions{
+		Enabled:     cfg.Sync.Resume.Enabled,
+		MinSize:     cfg.Sync.Resume.MinSize,
+		VerifyBytes: cfg.Sync.Resume.VerifyBytes,
+	})
+	svc.SetDeltaCopy(syncService.DeltaCopyOptions{
+		Enabled:   cfg.Sync.DeltaCopy.Enabled,
+		MinSize:   cfg.Sync.DeltaCopy.MinSize,
+		BlockSize: cfg.Sync.DeltaCopy.BlockSize,
+	})
+	svc.SetRolloverPolicy(cfg.Sync.RolloverThresholdPercent, cfg.Sync.RolloverAutoSelect)
+	svc.SetDestinationQuotas(destinationQuotas(cfg.Sync.Quotas))
+	svc.SetSourceSpaceOptions(syncService.SourceSpaceOptions{
+		WarningFreePercent: cfg.Monitoring.SourceSpace.WarningFreePercent,
+		WarningFreeBytes:   int64(cfg.Monitoring.SourceSpace.WarningFreeGB * (1 << 30)),
+		CheckInterval:      cfg.Monitoring.SourceSpace.CheckInterval,
+	})
+	svc.SetLinkUnchanged(cfg.Sync.LinkUnchanged)
+	svc.SetVerifyChecksums(cfg.Sync.VerifyChecksums)
+	svc.SetCaptureOrder(cfg.Sync.CaptureOrder)
+	svc.SetTempDir(cfg.Sync.TempDir)
+	svc.SetJobLog(cfg.Sync.JobLog)
+	svc.SetRunFolderLayout(cfg.Sync.RunFolder)
+	svc.SetVerifySampling(cfg.Sync.Verify.SamplePercent)
+	svc.SetIdleFinish(cfg.Sync.Completion.IdleFinish)
+	svc.SetLanguage(cfg.Web.Language)
+	svc.SetMemoryGuard(syncService.MemoryGuardOptions{
+		CompactInterval:    cfg.Sync.Memory.CompactInterval,
+		CaptureMaxAge:      cfg.Sync.Memory.CaptureMaxAge,
+		MaxTrackedCaptures: cfg.Sync.Memory.MaxTrackedCaptures,
+		MaxRunCopies:       cfg.Sync.Memory.MaxRunCopies,
+	})
+	svc.SetDegradedCopy(syncService.DegradedCopyOptions{
+		Enabled:   cfg.Sync.DegradedCopy.Enabled,
+		BlockSize: cfg.Sync.DegradedCopy.BlockSize,
+	})
+	svc.SetPostProcessing(postProcessOptions(cfg.Sync.PostProcessing))
+	source, err := storage.NewSource(cfg.Storage.Source.Driver, cfg.Storage.Source.Params)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	target, err := storage.NewDestination(cfg.Storage.Destination.Driver, cfg.Storage.Destination.Params)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	svc.SetStorage(source, target)
 	if err := svc.SetStateStore(store); err != nil {
 		store.Close()
 		return nil, err
@@ -132,6 +302,7 @@ func NewServer(cfg *config.Config) (*Server, error) {
 		cfg.Credentials.Password,
 	)
 	netService.SetBaseMountDir(cfg.Network.MountRoot)
+	netService.SetNodeShares(cfg.NodeShareMap())
 	netService.SetMountOptions(cfg.Network.MountOptions)
 
 	server := &Server{
@@ -142,13 +313,19 @@ func NewServer(cfg *config.Config) (*Server, error) {
 		serviceName: getServiceName(),
 		stateStore:  store,
 		webRoot:     getWebRoot(),
+		alerts:      newAlertPolicy(cfg.Web.Alerts, i18n.New(cfg.Web.Language)),
+		messages:    i18n.New(cfg.Web.Language),
 		httpClient: &http.Client{
 			Timeout: 5 * time.Second,
 		},
 		clients: make(map[*websocket.Conn]bool),
 	}
+	if storm := cfg.Logging.Storm; storm.Enabled {
+		server.logStorm = logstorm.NewCollapser(storm.Window, storm.Burst)
+	}
 
 	server.mountSharesFunc = netService.MountAll
+	server.rotateCredentialsFunc = netService.RotateCredentials
 	server.checkSharesAvailability = svc.CheckSharesAvailability
 	server.checkNetworkRequirements = network.CheckRequirements
 	server.nowFunc = time.Now
@@ -156,19 +333,65 @@ func NewServer(cfg *config.Config) (*Server, error) {
 	server.syncHardwareClockFunc = syncHardwareClock
 	server.findProjectsFunc = svc.FindProjects
 	server.getDestinationsFunc = server.getAvailableDestinations
-	server.getStatusFunc = svc.GetStatus
+	server.getStatusFunc = svc.StatusSnapshot
+	server.sensorStatsFunc = svc.SensorStats
 	server.ensureDestinationFunc = svc.EnsureDestinationReady
 	server.checkDiskSpaceFunc = svc.CheckDiskSpace
+	server.estimateProjectSizeFunc = svc.EstimateRemainingBytes
+	server.verifyRunFunc = svc.VerifyLastRun
+	server.verifyProgressFunc = svc.VerifyProgress
+	server.benchmarkDestinationFunc = syncService.BenchmarkDestination
+	server.lastRunDirFunc = svc.LastRunDir
+	server.runDirFunc = svc.RunDir
+	server.remapProjectFunc = svc.RemapProject
+	server.ejectDestinationFunc = server.ejectDestination
+	server.powerOffFunc = scheduleHostShutdown
+	server.lastActivityFunc = svc.LastActivity
+	server.spinDownFunc = spinDownDestination
+	if cfg.Simulate.Enabled {
+		server.enableSimulation(svc)
+	}
+	if mirror := cfg.Replication.Mirror; mirror.Enabled {
+		server.mirror = replication.NewMirror(replication.Options{
+			PeerURL:     mirror.PeerURL,
+			Token:       mirror.Token,
+			Destination: mirror.Destination,
+			Interval:    mirror.Interval,
+		})
+	}
+	svc.SetDestinationCandidates(server.getDestinationsFunc)
+	svc.SetEventNotifier(server)
 
 	return server, nil
 }
 
+// destinationQuotas converts sync.quotas to byte limits per destination.
+func destinationQuotas(quotas []config.SyncQuota) map[string]int64 {
+	limits := make(map[string]int64, len(quotas))
+	for _, quota := range quotas {
+		limits[quota.Destination] = int64(quota.MaxGB * (1 << 30))
+	}
+	return limits
+}
+
 // Start starts the web server
 func (s *Server) Start(ctx context.Context) error {
 	// Start performance monitoring
 	metricsChan := s.monService.Start(ctx)
 	go s.broadcastMetrics(ctx, metricsChan)
 
+	// Notify the UI when destination disks are plugged in or removed
+	go s.watchBlockDevices(ctx)
+
+	if s.mirror != nil {
+		go s.mirror.Run(ctx)
+	}
+
+	go s.monitorSourceSpace(ctx)
+	go s.refreshProjects(ctx)
+	go s.managePower(ctx)
+	go s.flushLogStorm(ctx)
+
 	// Setup routes
 	mux := http.NewServeMux()
 
@@ -179,25 +402,61 @@ func (s *Server) Start(ctx context.Context) error {
 	// API endpoints
 	mux.HandleFunc("/", s.handleIndex)
 	mux.HandleFunc("/api/projects", s.handleGetProjects)
+	mux.HandleFunc("/api/projects/registry", s.handleProjectRegistry)
 	mux.HandleFunc("/api/destinations", s.handleGetDestinations)
 	mux.HandleFunc("/api/devices", s.handleGetDevices)
-	mux.HandleFunc("/api/devices/mount", s.handleMountDevice)
+	mux.HandleFunc("/api/devices/mount", s.guarded(s.handleMountDevice))
+	mux.HandleFunc("/api/devices/unlock", s.guarded(s.handleUnlockDevice))
 	mux.HandleFunc("/api/shares/mount", s.handleMountShares)
 	mux.HandleFunc("/api/shares/check", s.handleCheckShares)
-	mux.HandleFunc("/api/service/restart", s.handleRestartService)
+	mux.HandleFunc("/api/shares/credentials", s.guarded(s.handleRotateCredentials))
+	mux.HandleFunc("/api/service/restart", s.guarded(s.handleRestartService))
+	mux.HandleFunc(confirmPath, s.handleConfirm)
 	mux.HandleFunc("/api/host/time", s.handleHostTime)
 	mux.HandleFunc("/api/host/time/sync", s.handleSyncHostTime)
-	mux.HandleFunc("/api/host/shutdown", s.handleHostShutdown)
+	mux.HandleFunc("/api/host/shutdown", s.guarded(s.handleHostShutdown))
 	mux.HandleFunc("/api/status", s.handleGetStatus)
+	mux.HandleFunc("/api/status/snapshot", s.handleStatusSnapshot)
 	mux.HandleFunc("/api/project-stats", s.handleGetProjectStats)
+	mux.HandleFunc("/api/stats/sensors", s.handleSensorStats)
 	mux.HandleFunc("/api/project/report", s.handleDownloadProjectReport)
-	mux.HandleFunc("/api/project/clear-history", s.handleClearProjectHistory)
+	mux.HandleFunc("/api/project/manifest", s.handleGetProjectManifest)
+	mux.HandleFunc("/api/project/sessions", s.handleGetProjectSessions)
+	mux.HandleFunc("/api/jobs/notes", s.handleJobNotes)
+	mux.HandleFunc("/api/project/clear-history", s.guarded(s.handleClearProjectHistory))
 	mux.HandleFunc("/api/database/projects", s.handleDatabaseProjects)
-	mux.HandleFunc("/api/database/project", s.handleDatabaseProject)
+	mux.HandleFunc("/api/database/project", s.guarded(s.handleDatabaseProject))
 	mux.HandleFunc("/api/metrics", s.handleGetMetrics)
+	mux.HandleFunc("/metrics", s.handlePrometheusMetrics)
+	mux.HandleFunc("/api/events", s.handleGetEvents)
+	mux.HandleFunc("/api/history", s.handleGetHistory)
+	mux.HandleFunc("/api/config/effective", s.handleGetEffectiveConfig)
 	mux.HandleFunc("/api/preflight", s.handleGetPreflight)
 	mux.HandleFunc("/api/sync/start", s.handleStartSync)
 	mux.HandleFunc("/api/sync/stop", s.handleStopSync)
+	mux.HandleFunc("/api/sync/pause", s.handlePauseSync)
+	mux.HandleFunc("/api/sync/resume", s.handleResumeSync)
+	mux.HandleFunc("/api/sync/rollover", s.handleRolloverSync)
+	mux.HandleFunc("/api/sync/remap", s.handleRemapProject)
+	mux.HandleFunc("/api/sync/loop-interval", s.handleSyncLoopInterval)
+	mux.HandleFunc("/api/sync/completion", s.handleSyncCompletion)
+	mux.HandleFunc("/api/sync/templates", s.handleSyncTemplates)
+	mux.HandleFunc("/api/verify", s.handleVerify)
+	mux.HandleFunc("/api/export", s.handleExport)
+	mux.HandleFunc("/api/captures/processing", s.handleCaptureProcessing)
+	mux.HandleFunc("/api/captures/", s.handleCaptureTags)
+	mux.HandleFunc(replication.ManifestPath, s.handleReplicationManifest)
+	mux.HandleFunc(replication.FilePath, s.handleReplicationFile)
+	mux.HandleFunc("/api/replication/status", s.handleReplicationStatus)
+	mux.HandleFunc(filesPrefix, s.handleFiles)
+	mux.HandleFunc("/api/nodes/maintenance", s.handleNodeMaintenance)
+	mux.HandleFunc("/api/nodes/space", s.handleNodeSpace)
+	mux.HandleFunc("/api/nodes/breakers", s.handleNodeBreakers)
+	mux.HandleFunc("/api/nodes/", s.handleNodePause)
+	mux.HandleFunc("/api/alerts", s.handleAlerts)
+	mux.HandleFunc("/api/failures", s.handleGetFailures)
+	mux.HandleFunc("/api/exclusions", s.handleExclusions)
+	mux.HandleFunc("/api/failures/requeue", s.handleRequeueFailures)
 	mux.HandleFunc("/api/dashboard/project-stats", s.handleDashboardProjectStats)
 	mux.HandleFunc("/api/dashboard/project/report", s.handleDownloadProjectReport)
 	mux.HandleFunc("/api/dashboard/config", s.handleDashboardConfig)
@@ -208,7 +467,7 @@ func (s *Server) Start(ctx context.Context) error {
 	mux.HandleFunc("/api/dashboard/sync/start", s.handleDashboardStartSync)
 	mux.HandleFunc("/api/dashboard/sync/stop", s.handleDashboardStopSync)
 	mux.HandleFunc("/api/dashboard/shares/mount", s.handleDashboardMountShares)
-	mux.HandleFunc("/api/dashboard/service/restart", s.handleDashboardRestartService)
+	mux.HandleFunc("/api/dashboard/service/restart", s.guarded(s.handleDashboardRestartService))
 	mux.HandleFunc("/ws", s.handleWebSocket)
 
 	addr := fmt.Sprintf("%s:%d", s.cfg.Web.Host, s.cfg.Web.Port)
@@ -225,6 +484,11 @@ func (s *Server) Start(ctx context.Context) error {
 		}
 	}()
 
+	var publicServer *http.Server
+	if s.cfg.Web.Public.Enabled {
+		publicServer = s.startPublicServer()
+	}
+
 	go s.autoRemountShares(ctx)
 
 	// Wait for context cancellation
@@ -233,6 +497,7 @@ func (s *Server) Start(ctx context.Context) error {
 	// Graceful shutdown
 	log.Info().Msg("Shutting down web server...")
 	defer func() {
+		s.closeJournal()
 		if s.stateStore != nil {
 			if err := s.stateStore.Close(); err != nil {
 				log.Error().Err(err).Msg("Failed to close SQLite state store")
@@ -243,14 +508,22 @@ func (s *Server) Start(ctx context.Context) error {
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Stop sync
+	// Stop sync; completion actions are for finished jobs, not shutdowns
+	s.takeCompletionActions()
 	s.syncService.Stop()
 
 	// Unmount shares
-	if err := s.netService.UnmountAll(); err != nil {
-		log.Error().Err(err).Msg("Failed to unmount shares")
+	if !s.simulated {
+		if err := s.netService.UnmountAll(); err != nil {
+			log.Error().Err(err).Msg("Failed to unmount shares")
+		}
 	}
 
+	if publicServer != nil {
+		if err := publicServer.Shutdown(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("Failed to shut down public status page")
+		}
+	}
 	return server.Shutdown(shutdownCtx)
 }
 
@@ -265,18 +538,28 @@ func (s *Server) handleGetProjects(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), projectScanTimeout)
 	defer cancel()
 
-	projects, err := s.findProjects(ctx)
+	refresh, _ := strconv.ParseBool(r.URL.Query().Get("refresh"))
+	projects, scannedAt, err := s.discoverProjects(ctx, refresh)
 	if err != nil {
+		if r.Context().Err() != nil {
+			// The browser navigated away; the scan stopped with it.
+			log.Debug().Err(err).Msg("Project discovery cancelled")
+			return
+		}
 		log.Error().Err(err).Msg("Failed to find projects")
 		http.Error(w, "Failed to find projects", http.StatusInternalServerError)
 		return
 	}
 
+	// The body stays a plain list for existing clients; the cache age goes
+	// in the headers.
+	w.Header().Set("Age", strconv.Itoa(int(time.Since(scannedAt).Seconds())))
+	w.Header().Set("X-Projects-Scanned-At", scannedAt.UTC().Format(time.RFC3339))
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(projects)
+	json.NewEncoder(w).Encode(s.withRegistry(projects))
 }
 
 func (s *Server) handleGetDestinations(w http.ResponseWriter, r *http.Request) {
@@ -285,7 +568,7 @@ func (s *Server) handleGetDestinations(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	destinations := s.getAvailableDestinations()
+	destinations := s.availableDestinations()
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(destinations)
@@ -355,6 +638,8 @@ func (s *Server) handleGetProjectStats(w http.ResponseWriter, r *http.Request) {
 		CompletedTestCaptures int    `json:"completed_test_captures"`
 		LastCaptureNumber     string `json:"last_capture_number"`
 		LastTestCaptureNumber string `json:"last_test_capture_number"`
+		// Timing is the latency distribution of the completed captures.
+		Timing *models.CaptureTimingStats `json:"timing,omitempty"`
 	}
 
 	stats := projectStats{Project: project}
@@ -365,12 +650,53 @@ func (s *Server) handleGetProjectStats(w http.ResponseWriter, r *http.Request) {
 			stats.LastCaptureNumber = ps.LastCaptureNumber
 			stats.LastTestCaptureNumber = ps.LastTestCaptureNumber
 		}
+		if timing, err := s.stateStore.CaptureTimingStats(project); err != nil {
+			log.Warn().Err(err).Str("project", project).Msg("Failed to load capture timing")
+		} else if timing.Captures > 0 {
+			stats.Timing = &timing
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stats)
 }
 
+// handleSensorStats reports copied RAW files per sensor code for ?project=,
+// or the project of the current job.
+func (s *Server) handleSensorStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	project := strings.TrimSpace(r.URL.Query().Get("project"))
+	if project == "" {
+		project = s.currentSyncStatus().Project
+	}
+	if project == "" {
+		http.Error(w, "project parameter required", http.StatusBadRequest)
+		return
+	}
+	if s.sensorStatsFunc == nil {
+		http.Error(w, "sensor statistics not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	report, err := s.sensorStatsFunc(project)
+	if errors.Is(err, syncService.ErrNoStateStore) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Str("project", project).Msg("Failed to collect sensor statistics")
+		http.Error(w, fmt.Sprintf("failed to collect sensor statistics: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
 func (s *Server) handleDashboardProjectStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -431,6 +757,11 @@ func (s *Server) handleDownloadProjectReport(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	if r.URL.Query().Get("format") == "html" {
+		s.serveHTMLReport(w, project, destinationPath)
+		return
+	}
+
 	reportPath := report.DefaultPath(destinationPath, project)
 	if filepath.Base(reportPath) != filename || !isPathWithin(destinationPath, reportPath) {
 		http.Error(w, "invalid report path", http.StatusBadRequest)
@@ -460,102 +791,789 @@ func (s *Server) handleDownloadProjectReport(w http.ResponseWriter, r *http.Requ
 	http.ServeContent(w, r, filename, info.ModTime(), file)
 }
 
-func (s *Server) handleGetMetrics(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// serveHTMLReport renders the mission report of project as one HTML file
+// with its charts drawn server-side, for people who never open the JSON.
+func (s *Server) serveHTMLReport(w http.ResponseWriter, project, destination string) {
+	if s.stateStore == nil {
+		http.Error(w, "state database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	mission, err := report.Collect(s.stateStore, project, destination)
+	if err != nil {
+		log.Error().Err(err).Str("project", project).Msg("Failed to collect mission report")
+		http.Error(w, "failed to build report", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteHTML(&buf, mission); err != nil {
+		log.Error().Err(err).Str("project", project).Msg("Failed to render HTML report")
+		http.Error(w, "failed to build report", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, quoteHeaderFilename(report.HTMLFilename(project))))
+	w.Write(buf.Bytes())
+}
+
+func (s *Server) handleGetMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.currentMetrics())
+}
+
+func (s *Server) currentMetrics() models.PerformanceMetrics {
+	metrics := s.monService.GetMetrics()
+	if s.syncService != nil {
+		metrics.ScanMetrics = s.syncService.ScanMetrics()
+		metrics.SourceShares = s.syncService.SourceShareSpace()
+		pools := s.syncService.WorkerPoolMetrics()
+		metrics.WorkerPools = &pools
+	}
+	metrics.Destinations = s.destinationsForMetrics()
+	return metrics
+}
+
+func (s *Server) handleGetEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.stateStore == nil {
+		http.Error(w, "state store not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit := 100
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		if _, err := fmt.Sscanf(raw, "%d", &limit); err != nil || limit <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	events, err := s.stateStore.ListSyncEvents(limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list sync events")
+		http.Error(w, fmt.Sprintf("failed to list sync events: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+func (s *Server) handleGetHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.stateStore == nil {
+		http.Error(w, "state store not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit := 100
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		if _, err := fmt.Sscanf(raw, "%d", &limit); err != nil || limit <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	jobs, err := s.stateStore.ListJobs(strings.TrimSpace(r.URL.Query().Get("project")), limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list job history")
+		http.Error(w, fmt.Sprintf("failed to list job history: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
+
+// NotifySyncEvent routes a sync lifecycle event to the event history and all
+// connected WebSocket clients.
+func (s *Server) NotifySyncEvent(event models.SyncEvent) {
+	if s.stateStore != nil {
+		if err := s.stateStore.RecordSyncEvent(event); err != nil {
+			log.Error().Err(err).Str("type", event.Type).Msg("Failed to record sync event")
+		}
+	}
+
+	s.broadcast(models.WSMessage{
+		Type:    "sync_event",
+		Payload: event,
+		Meta:    s.alerts.metaFor(event),
+	})
+	s.recordAlert(event)
+
+	switch event.Type {
+	case models.SyncEventFinished, models.SyncEventStopped:
+		if actions := s.takeCompletionActions(); len(actions) > 0 {
+			go s.runCompletionActions(context.Background(), event, actions)
+		}
+	case models.SyncEventFailed:
+		// The destination is gone; the actions would only act on a lost disk.
+		s.takeCompletionActions()
+	}
+}
+
+func (s *Server) handleStartSync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req startSyncRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.Template != "" {
+		if err := s.applyJobTemplate(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Info().Str("template", req.Template).Str("project", req.Project).Msg("Starting sync from job template")
+	}
+
+	if req.Project == "" || req.Destination == "" {
+		http.Error(w, "Project and destination are required", http.StatusBadRequest)
+		return
+	}
+
+	completionActions := s.cfg.Sync.Completion.Actions
+	if req.CompletionActions != nil {
+		actions, err := config.NormalizeCompletionActions(*req.CompletionActions)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, action := range actions {
+			if action == config.CompletionNotify && s.cfg.Sync.Completion.NotifyURL == "" {
+				http.Error(w, "notify requires sync.completion.notify_url", http.StatusBadRequest)
+				return
+			}
+		}
+		completionActions = actions
+	}
+
+	if req.MaxParallelism <= 0 {
+		req.MaxParallelism = s.cfg.Sync.MaxParallelism
+	}
+
+	// Set target disk for monitoring
+	s.monService.SetTargetDisk(req.Destination)
+
+	// Check that all shares are mounted and accessible
+	if unavailable := s.getUnavailableShares(); len(unavailable) > 0 {
+		var missing []string
+		for _, u := range unavailable {
+			missing = append(missing, fmt.Sprintf("%s/%s (%s)", u.Node, u.Share, u.Path))
+		}
+		msg := fmt.Sprintf("Cannot start sync: %d share(s) unavailable: %s", len(missing), strings.Join(missing, "; "))
+		log.Warn().Strs("unavailable", missing).Msg("Shares not available, sync blocked")
+		http.Error(w, msg, http.StatusServiceUnavailable)
+		return
+	}
+
+	// Refuse to start a job that cannot finish on this destination
+	if startErr := s.checkStartPreflight(r.Context(), req.Project, req.Destination, req.ForceFullResync); startErr != nil {
+		log.Warn().Str("destination", req.Destination).Str("reason", startErr.Message).Msg("Sync start blocked by preflight")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusPreconditionFailed)
+		json.NewEncoder(w).Encode(startErr)
+		return
+	}
+
+	runBenchmark := s.cfg.Sync.Benchmark.Enabled
+	if req.Benchmark != nil {
+		runBenchmark = *req.Benchmark
+	}
+	var benchmark *models.DestinationBenchmark
+	if runBenchmark {
+		benchmark = s.benchmarkDestination(r.Context(), req.Destination)
+	}
+
+	// Start sync
+	ctx := context.Background()
+	if err := s.syncService.Start(ctx, req.Project, req.Destination, req.MaxParallelism, req.ForceFullResync); err != nil {
+		log.Error().Err(err).Msg("Failed to start sync")
+		http.Error(w, fmt.Sprintf("Failed to start sync: %v", err), http.StatusInternalServerError)
+		return
+	}
+	s.setCompletionActions(completionActions)
+	if benchmark != nil {
+		s.syncService.RecordBenchmark(*benchmark)
+	}
+
+	message := s.messages.Sprintf(i18n.LogSyncStarted, req.Project, req.Destination, req.ForceFullResync)
+	if len(completionActions) > 0 {
+		message += s.messages.Sprintf(i18n.LogOnCompletion, strings.Join(completionActions, ", "))
+	}
+
+	// Broadcast log message
+	s.broadcast(models.WSMessage{
+		Type: "log",
+		Payload: models.LogMessage{
+			Timestamp: time.Now(),
+			Level:     "info",
+			Message:   message,
+		},
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "started", "benchmark": benchmark})
+}
+
+func (s *Server) handleStopSync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.syncService.Stop()
+
+	// Broadcast log message
+	s.broadcast(models.WSMessage{
+		Type: "log",
+		Payload: models.LogMessage{
+			Timestamp: time.Now(),
+			Level:     "info",
+			Message:   s.messages.Sprintf(i18n.LogSyncStopped),
+		},
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "stopped"})
+}
+
+// handlePauseSync stops dispatching new copies without stopping the job.
+func (s *Server) handlePauseSync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := s.syncService.Pause(); err != nil {
+		log.Warn().Err(err).Msg("Failed to pause sync")
+		http.Error(w, fmt.Sprintf("Failed to pause sync: %v", err), http.StatusConflict)
+		return
+	}
+
+	s.broadcast(models.WSMessage{
+		Type: "log",
+		Payload: models.LogMessage{
+			Timestamp: time.Now(),
+			Level:     "info",
+			Message:   s.messages.Sprintf(i18n.LogSyncPaused),
+		},
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "paused"})
+}
+
+func (s *Server) handleResumeSync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := s.syncService.Resume(); err != nil {
+		log.Warn().Err(err).Msg("Failed to resume sync")
+		http.Error(w, fmt.Sprintf("Failed to resume sync: %v", err), http.StatusConflict)
+		return
+	}
+
+	s.broadcast(models.WSMessage{
+		Type: "log",
+		Payload: models.LogMessage{
+			Timestamp: time.Now(),
+			Level:     "info",
+			Message:   s.messages.Sprintf(i18n.LogSyncResumed),
+		},
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "resumed"})
+}
+
+// minServiceLoopInterval keeps runtime changes from hammering the CIFS shares.
+const minServiceLoopInterval = time.Second
+
+// handleSyncLoopInterval reports (GET) or changes (POST {"interval":"30s"})
+// how often the sync loop rescans the node shares.
+func (s *Server) handleSyncLoopInterval(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req struct {
+			Interval string `json:"interval"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		interval, err := time.ParseDuration(strings.TrimSpace(req.Interval))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid interval: %v", err), http.StatusBadRequest)
+			return
+		}
+		if interval < minServiceLoopInterval {
+			http.Error(w, fmt.Sprintf("Interval must be at least %s", minServiceLoopInterval), http.StatusBadRequest)
+			return
+		}
+
+		s.syncService.SetServiceLoopInterval(interval)
+		log.Info().Str("interval", interval.String()).Msg("Service loop interval changed via API")
+
+		s.broadcast(models.WSMessage{
+			Type: "log",
+			Payload: models.LogMessage{
+				Timestamp: time.Now(),
+				Level:     "info",
+				Message:   s.messages.Sprintf(i18n.LogRescanInterval, interval),
+			},
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	interval := s.syncService.ServiceLoopInterval()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"interval":         interval.String(),
+		"interval_seconds": interval.Seconds(),
+	})
+}
+
+// handleExport reports the delivery export (GET), starts one (POST
+// {"project":"...","destination":"/ucdata-x","lvl00_only":true}) or
+// cancels it (DELETE). The source defaults to the configured working
+// destination.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req struct {
+			Project     string   `json:"project"`
+			Source      string   `json:"source"`
+			Destination string   `json:"destination"`
+			Lvl00Only   bool     `json:"lvl00_only"`
+			FromCapture string   `json:"from_capture"`
+			ToCapture   string   `json:"to_capture"`
+			Tags        []string `json:"tags"`
+			ExcludeTags []string `json:"exclude_tags"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		if strings.TrimSpace(req.Source) == "" {
+			req.Source = s.cfg.Sync.Destination
+		}
+
+		err := s.syncService.StartExport(context.Background(), syncService.ExportOptions{
+			Project:     strings.TrimSpace(req.Project),
+			Source:      strings.TrimSpace(req.Source),
+			Destination: strings.TrimSpace(req.Destination),
+			Lvl00Only:   req.Lvl00Only,
+			FromCapture: strings.TrimSpace(req.FromCapture),
+			ToCapture:   strings.TrimSpace(req.ToCapture),
+			Tags:        req.Tags,
+			ExcludeTags: req.ExcludeTags,
+		})
+		if errors.Is(err, syncService.ErrExportRunning) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to start export: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		s.broadcast(models.WSMessage{
+			Type: "log",
+			Payload: models.LogMessage{
+				Timestamp: time.Now(),
+				Level:     "info",
+				Message:   s.messages.Sprintf(i18n.LogExportStarted, req.Project, req.Destination),
+			},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodDelete:
+		s.syncService.CancelExport()
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.syncService.ExportStatus())
+}
+
+// handleNodeMaintenance lists nodes in maintenance (GET) or toggles the flag
+// for one node (POST {"node":"WU05","maintenance":true,"reason":"..."}).
+func (s *Server) handleNodeMaintenance(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req struct {
+			Node        string `json:"node"`
+			Maintenance bool   `json:"maintenance"`
+			Reason      string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		node := strings.ToUpper(strings.TrimSpace(req.Node))
+		reason := strings.TrimSpace(req.Reason)
+		if err := s.syncService.SetNodeMaintenance(node, req.Maintenance, reason); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		message := s.messages.Sprintf(i18n.LogNodeMaintenanceOff, node)
+		level := "info"
+		if req.Maintenance {
+			message = s.messages.Sprintf(i18n.LogNodeMaintenanceOn, node)
+			if reason != "" {
+				message += ": " + reason
+			}
+			level = "warn"
+		}
+		s.broadcast(models.WSMessage{
+			Type: "log",
+			Payload: models.LogMessage{
+				Timestamp: time.Now(),
+				Level:     level,
+				Message:   message,
+			},
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	nodes := s.syncService.NodesInMaintenance()
+	if nodes == nil {
+		nodes = []models.NodeMaintenance{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(nodes)
+}
+
+// handleNodePause pauses or resumes copying from one node while the others
+// continue: POST /api/nodes/{node}/pause and POST /api/nodes/{node}/resume.
+func (s *Server) handleNodePause(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/nodes/"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || (parts[1] != "pause" && parts[1] != "resume") {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	node := strings.ToUpper(parts[0])
+	pause := parts[1] == "pause"
+	if pause && !s.currentSyncStatus().IsRunning {
+		http.Error(w, "synchronization is not running", http.StatusConflict)
+		return
+	}
+	var err error
+	if pause {
+		err = s.syncService.PauseNode(node)
+	} else {
+		err = s.syncService.ResumeNode(node)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	message := s.messages.Sprintf(i18n.LogNodeResumed, node)
+	if pause {
+		message = s.messages.Sprintf(i18n.LogNodePaused, node)
+	}
+	s.broadcast(models.WSMessage{
+		Type: "log",
+		Payload: models.LogMessage{
+			Timestamp: time.Now(),
+			Level:     "info",
+			Message:   message,
+		},
+	})
+
+	nodes := s.syncService.PausedNodes()
+	if nodes == nil {
+		nodes = []string{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"node": node, "paused": pause, "paused_nodes": nodes})
+}
+
+// handleNodeSpace returns the free space of every worker share from the
+// last check; ?refresh=true measures the shares again first.
+func (s *Server) handleNodeSpace(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var spaces []models.ShareSpace
+	if refresh, _ := strconv.ParseBool(r.URL.Query().Get("refresh")); refresh {
+		spaces = s.syncService.CheckSourceSpace()
+	} else {
+		spaces = s.syncService.SourceShareSpace()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(spaces)
+}
+
+// handleNodeBreakers returns the copy failure rate of each node over the
+// circuit breaker window and whether copies from it are suspended.
+func (s *Server) handleNodeBreakers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.syncService.NodeBreakers())
+}
+
+// handleExclusions returns (GET), replaces (PUT/POST) or resets to the
+// built-in defaults (DELETE) the directory and project exclusion lists. A list
+// omitted from a PUT body is left unchanged. Changes last until restart.
+func (s *Server) handleExclusions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var req models.Exclusions
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		current := s.syncService.Exclusions()
+		if req.Directories == nil {
+			req.Directories = current.Directories
+		}
+		if req.Projects == nil {
+			req.Projects = current.Projects
+		}
+		s.syncService.SetExclusions(req)
+		log.Info().
+			Strs("directories", req.Directories).
+			Strs("projects", req.Projects).
+			Msg("Exclusion lists changed via API")
+	case http.MethodDelete:
+		s.syncService.SetExclusions(models.Exclusions{})
+		log.Info().Msg("Exclusion lists reset to defaults via API")
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.syncService.Exclusions())
+}
+
+func (s *Server) handleGetFailures(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.syncService.CopyFailures())
+}
+
+// handleRequeueFailures releases quarantined files. An empty or missing
+// "paths" list re-queues all of them.
+func (s *Server) handleRequeueFailures(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Paths []string `json:"paths"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+	}
+
+	requeued := s.syncService.RequeueFailures(req.Paths)
+	if requeued > 0 {
+		s.broadcast(models.WSMessage{
+			Type: "log",
+			Payload: models.LogMessage{
+				Timestamp: time.Now(),
+				Level:     "info",
+				Message:   s.messages.Sprintf(i18n.LogFilesRequeued, requeued),
+			},
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"requeued": requeued,
+		"failures": s.syncService.CopyFailures(),
+	})
+}
+
+func (s *Server) handleRolloverSync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Destination string `json:"destination"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	req.Destination = strings.TrimSpace(req.Destination)
+	if req.Destination == "" {
+		http.Error(w, "Destination is required", http.StatusBadRequest)
 		return
 	}
 
-	metrics := s.monService.GetMetrics()
+	if err := s.syncService.SwitchDestination(req.Destination); err != nil {
+		log.Warn().Err(err).Str("destination", req.Destination).Msg("Failed to switch destination")
+		http.Error(w, fmt.Sprintf("Failed to switch destination: %v", err), http.StatusConflict)
+		return
+	}
+
+	s.monService.SetTargetDisk(req.Destination)
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(metrics)
+	json.NewEncoder(w).Encode(map[string]string{"status": "switched", "destination": req.Destination})
 }
 
-func (s *Server) handleStartSync(w http.ResponseWriter, r *http.Request) {
+// handleRemapProject switches the running job to its project folder renamed
+// on the shares, once the operator confirms the detected rename.
+func (s *Server) handleRemapProject(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	var req struct {
-		Project         string `json:"project"`
-		Destination     string `json:"destination"`
-		MaxParallelism  int    `json:"max_parallelism"`
-		ForceFullResync bool   `json:"force_full_resync"`
+		Project string `json:"project"`
 	}
-
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
-	if req.Project == "" || req.Destination == "" {
-		http.Error(w, "Project and destination are required", http.StatusBadRequest)
+	req.Project = strings.TrimSpace(req.Project)
+	if req.Project == "" {
+		http.Error(w, "Project is required", http.StatusBadRequest)
 		return
 	}
 
-	if req.MaxParallelism <= 0 {
-		req.MaxParallelism = s.cfg.Sync.MaxParallelism
-	}
-
-	// Set target disk for monitoring
-	s.monService.SetTargetDisk(req.Destination)
-
-	// Check that all shares are mounted and accessible
-	if unavailable := s.getUnavailableShares(); len(unavailable) > 0 {
-		var missing []string
-		for _, u := range unavailable {
-			missing = append(missing, fmt.Sprintf("%s/%s (%s)", u.Node, u.Share, u.Path))
+	if err := s.remapProjectFunc(req.Project); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, syncService.ErrNoProjectRename) {
+			status = http.StatusConflict
 		}
-		msg := fmt.Sprintf("Cannot start sync: %d share(s) unavailable: %s", len(missing), strings.Join(missing, "; "))
-		log.Warn().Strs("unavailable", missing).Msg("Shares not available, sync blocked")
-		http.Error(w, msg, http.StatusServiceUnavailable)
-		return
-	}
-
-	// Start sync
-	ctx := context.Background()
-	if err := s.syncService.Start(ctx, req.Project, req.Destination, req.MaxParallelism, req.ForceFullResync); err != nil {
-		log.Error().Err(err).Msg("Failed to start sync")
-		http.Error(w, fmt.Sprintf("Failed to start sync: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Failed to remap project: %v", err), status)
 		return
 	}
 
-	// Broadcast log message
+	s.resolveAlertsOfType(models.SyncEventProjectRenamed)
 	s.broadcast(models.WSMessage{
 		Type: "log",
 		Payload: models.LogMessage{
 			Timestamp: time.Now(),
 			Level:     "info",
-			Message:   fmt.Sprintf("Started synchronization: project=%s, destination=%s, full_resync=%t", req.Project, req.Destination, req.ForceFullResync),
+			Message:   s.messages.Sprintf(i18n.LogProjectRemapped, req.Project),
 		},
 	})
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "started"})
+	json.NewEncoder(w).Encode(map[string]string{"status": "remapped", "project": req.Project})
 }
 
-func (s *Server) handleStopSync(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+func (s *Server) handleGetProjectManifest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	s.syncService.Stop()
+	project := strings.TrimSpace(r.URL.Query().Get("project"))
+	if project == "" {
+		http.Error(w, "project parameter required", http.StatusBadRequest)
+		return
+	}
 
-	// Broadcast log message
-	s.broadcast(models.WSMessage{
-		Type: "log",
-		Payload: models.LogMessage{
-			Timestamp: time.Now(),
-			Level:     "info",
-			Message:   "Synchronization stopped",
-		},
-	})
+	if s.stateStore == nil {
+		http.Error(w, "State database is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	locations, err := s.stateStore.ListCaptureLocations(project)
+	if err != nil {
+		log.Error().Err(err).Str("project", project).Msg("Failed to load capture manifest")
+		http.Error(w, "Failed to load capture manifest", http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "stopped"})
+	json.NewEncoder(w).Encode(locations)
+}
+
+// handleGetProjectSessions lists the acquisition sessions the state database
+// has seen captures of for a project.
+func (s *Server) handleGetProjectSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	project := strings.TrimSpace(r.URL.Query().Get("project"))
+	if project == "" {
+		http.Error(w, "project parameter required", http.StatusBadRequest)
+		return
+	}
+
+	if s.stateStore == nil {
+		http.Error(w, "State database is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	sessions, err := s.stateStore.ListCaptureSessions(project)
+	if err != nil {
+		log.Error().Err(err).Str("project", project).Msg("Failed to load capture sessions")
+		http.Error(w, "Failed to load capture sessions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
 }
 
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
@@ -570,13 +1588,16 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	s.mu.Unlock()
 
 	log.Info().Str("remote", r.RemoteAddr).Msg("WebSocket client connected")
+	s.notePowerActivity()
 
-	// Send initial status
-	status := s.currentSyncStatus()
-	s.sendToClient(conn, models.WSMessage{
-		Type:    "status",
-		Payload: status,
-	})
+	// Send the hello, then the initial status: the one the next
+	// status_delta applies to
+	statusMsg, ok := s.lastStatusMessage()
+	if !ok {
+		statusMsg = models.WSMessage{Type: "status", Payload: s.currentSyncStatus()}
+	}
+	s.sendToClient(conn, s.helloMessage(statusMsg.Seq))
+	s.sendToClient(conn, statusMsg)
 
 	// Send initial metrics
 	metrics := s.monService.GetMetrics()
@@ -596,15 +1617,27 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		}()
 
 		for {
-			_, _, err := conn.ReadMessage()
+			_, data, err := conn.ReadMessage()
 			if err != nil {
 				break
 			}
+			s.handleClientMessage(conn, data)
 		}
 	}()
 }
 
 func (s *Server) broadcast(msg models.WSMessage) {
+	if !s.passLogStorm(msg) {
+		return
+	}
+	s.deliver(msg)
+}
+
+// deliver journals msg and sends it to every client.
+func (s *Server) deliver(msg models.WSMessage) {
+	msg.ID = s.nextMessageID()
+	s.journal(msg)
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -624,6 +1657,7 @@ func (s *Server) broadcastMetrics(ctx context.Context, metricsChan <-chan models
 	defer ticker.Stop()
 
 	var lastMetrics models.PerformanceMetrics
+	var lastBroadcast time.Time
 
 	for {
 		select {
@@ -634,15 +1668,25 @@ func (s *Server) broadcastMetrics(ctx context.Context, metricsChan <-chan models
 				return
 			}
 			lastMetrics = metrics
-		case <-ticker.C:
-			// Broadcast status
+		case now := <-ticker.C:
+			if s.powerIdle() && now.Sub(lastBroadcast) < s.cfg.Monitoring.IdlePower.MonitorInterval {
+				// Power saving: update the UI at the idle monitoring rate.
+				continue
+			}
+			lastBroadcast = now
+
+			// Broadcast status, or only what changed since the last tick
 			status := s.currentSyncStatus()
-			s.broadcast(models.WSMessage{
-				Type:    "status",
-				Payload: status,
-			})
+			s.journalStatusChange(status)
+			if msg, ok := s.statusMessage(status); ok {
+				s.broadcast(msg)
+			}
 
-			// Broadcast metrics
+			// Broadcast metrics with per-device free space
+			lastMetrics.Destinations = s.destinationsForMetrics()
+			if s.syncService != nil {
+				lastMetrics.SourceShares = s.syncService.SourceShareSpace()
+			}
 			s.broadcast(models.WSMessage{
 				Type:    "metrics",
 				Payload: lastMetrics,
@@ -651,6 +1695,13 @@ func (s *Server) broadcastMetrics(ctx context.Context, metricsChan <-chan models
 	}
 }
 
+func (s *Server) destinationsForMetrics() []models.DestinationInfo {
+	if s.getDestinationsFunc == nil {
+		return nil
+	}
+	return s.getDestinationsFunc()
+}
+
 // getAvailableDestinations scans for available storage destinations
 func (s *Server) getAvailableDestinations() []models.DestinationInfo {
 	var destinations []models.DestinationInfo
@@ -706,8 +1757,10 @@ func (s *Server) getAvailableDestinations() []models.DestinationInfo {
 			continue
 		}
 
-		// Only allow external storage: /media/* or the configured default data mount.
-		if mountPoint != defaultDataMountPoint && !strings.HasPrefix(mountPoint, "/media/") {
+		// Only allow external storage: /media/* or the managed data mounts.
+		managedMount, isManaged := syncService.ManagedMountPoint(mountPoint)
+		isManaged = isManaged && managedMount == mountPoint
+		if !isManaged && !strings.HasPrefix(mountPoint, "/media/") {
 			continue
 		}
 
@@ -719,6 +1772,8 @@ func (s *Server) getAvailableDestinations() []models.DestinationInfo {
 			if mountPoint == defaultDataMountPoint {
 				label = "USB-SSD Storage (default)"
 				isDefault = true
+			} else if isManaged {
+				label = fmt.Sprintf("USB-SSD Storage: %s", strings.TrimPrefix(mountPoint, defaultDataMountPoint+"-"))
 			} else {
 				label = fmt.Sprintf("External: %s", filepath.Base(mountPoint))
 			}
@@ -765,8 +1820,12 @@ func (s *Server) handleGetDevices(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	devices, err := s.getBlockDevices()
+	devices, err := s.getBlockDevices(r.Context())
 	if err != nil {
+		if r.Context().Err() != nil {
+			// The client went away; lsblk was killed with the request.
+			return
+		}
 		log.Error().Err(err).Msg("Failed to get block devices")
 		http.Error(w, "Failed to get devices", http.StatusInternalServerError)
 		return
@@ -789,37 +1848,50 @@ func (s *Server) handleMountDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.DevicePath == "" || req.Action == "" {
-		http.Error(w, "Device path and action are required", http.StatusBadRequest)
+	if req.Action == "" {
+		http.Error(w, "Action is required", http.StatusBadRequest)
+		return
+	}
+
+	devicePath, err := resolveDeviceReference(devDiskRoot, req.DevicePath, req.UUID, req.Label)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	req.DevicePath = devicePath
 
 	if req.Action == "unmount" {
 		status := s.syncService.GetStatus()
-		if status.IsRunning && isManagedDataDestination(status.Destination) {
+		deviceMount, _ := managedMountPointOfDevice(mountedDevicePath(req.DevicePath))
+		if status.IsRunning && isDestinationOnMount(status.Destination, deviceMount) {
+			s.takeCompletionActions()
 			s.syncService.Stop()
 			s.broadcast(models.WSMessage{
 				Type: "log",
 				Payload: models.LogMessage{
 					Timestamp: time.Now(),
 					Level:     "warn",
-					Message:   "Синхронизация остановлена перед размонтированием диска назначения",
+					Message:   s.messages.Sprintf(i18n.LogStoppedForUnmount),
 				},
 			})
 		}
 	}
 
-	var err error
+	var mountPoint string
 	switch req.Action {
 	case "mount":
-		err = s.mountDevice(req.DevicePath)
+		mountPoint, err = s.mountDevice(req.DevicePath)
 	case "unmount":
-		err = s.unmountDevice(req.DevicePath)
+		mountPoint, err = s.unmountDevice(req.DevicePath)
 	default:
 		http.Error(w, "Invalid action. Use 'mount' or 'unmount'", http.StatusBadRequest)
 		return
 	}
 
+	if errors.Is(err, errDeviceLocked) {
+		http.Error(w, err.Error(), http.StatusLocked)
+		return
+	}
 	if err != nil {
 		log.Error().Err(err).Str("device", req.DevicePath).Str("action", req.Action).Msg("Device operation failed")
 		http.Error(w, fmt.Sprintf("Failed to %s device: %v", req.Action, err), http.StatusInternalServerError)
@@ -827,20 +1899,25 @@ func (s *Server) handleMountDevice(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Broadcast log message
+	deviceMessage := i18n.LogDeviceMounted
+	if req.Action == "unmount" {
+		deviceMessage = i18n.LogDeviceUnmounted
+	}
 	s.broadcast(models.WSMessage{
 		Type: "log",
 		Payload: models.LogMessage{
 			Timestamp: time.Now(),
 			Level:     "info",
-			Message:   fmt.Sprintf("Device %s: %s", req.Action, req.DevicePath),
+			Message:   s.messages.Sprintf(deviceMessage, req.DevicePath),
 		},
 	})
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
-		"status": "success",
-		"action": req.Action,
-		"device": req.DevicePath,
+		"status":      "success",
+		"action":      req.Action,
+		"device":      req.DevicePath,
+		"mount_point": mountPoint,
 	})
 }
 
@@ -893,7 +1970,7 @@ func (s *Server) handleMountShares(w http.ResponseWriter, r *http.Request) {
 		Payload: models.LogMessage{
 			Timestamp: time.Now(),
 			Level:     "info",
-			Message:   "Повторная попытка монтирования шар выполнена",
+			Message:   s.messages.Sprintf(i18n.LogSharesRemounted),
 		},
 	})
 
@@ -937,7 +2014,7 @@ func (s *Server) handleClearProjectHistory(w http.ResponseWriter, r *http.Reques
 		Payload: models.LogMessage{
 			Timestamp: time.Now(),
 			Level:     "warn",
-			Message:   fmt.Sprintf("История проекта '%s' очищена", body.Project),
+			Message:   s.messages.Sprintf(i18n.LogProjectHistoryCleared, body.Project),
 		},
 	})
 
@@ -984,7 +2061,7 @@ func (s *Server) handleDatabaseProjects(w http.ResponseWriter, r *http.Request)
 			Payload: models.LogMessage{
 				Timestamp: time.Now(),
 				Level:     "warn",
-				Message:   "Project database cleared",
+				Message:   s.messages.Sprintf(i18n.LogDatabaseCleared),
 			},
 		})
 
@@ -1035,7 +2112,7 @@ func (s *Server) handleDatabaseProject(w http.ResponseWriter, r *http.Request) {
 		Payload: models.LogMessage{
 			Timestamp: time.Now(),
 			Level:     "warn",
-			Message:   fmt.Sprintf("Project '%s' deleted from database", body.Project),
+			Message:   s.messages.Sprintf(i18n.LogProjectDeleted, body.Project),
 		},
 	})
 
@@ -1061,7 +2138,7 @@ func (s *Server) handleRestartService(w http.ResponseWriter, r *http.Request) {
 		Payload: models.LogMessage{
 			Timestamp: time.Now(),
 			Level:     "warn",
-			Message:   fmt.Sprintf("Запрошен перезапуск службы %s", s.serviceName),
+			Message:   s.messages.Sprintf(i18n.LogServiceRestart, s.serviceName),
 		},
 	})
 
@@ -1138,7 +2215,7 @@ func (s *Server) handleSyncHostTime(w http.ResponseWriter, r *http.Request) {
 		Payload: models.LogMessage{
 			Timestamp: after,
 			Level:     "warn",
-			Message:   fmt.Sprintf("Host time synchronized from browser device, drift corrected: %s", drift.Round(time.Second)),
+			Message:   s.messages.Sprintf(i18n.LogHostTimeSynced, drift.Round(time.Second)),
 		},
 	})
 
@@ -1162,8 +2239,7 @@ func (s *Server) handleHostShutdown(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cmd := exec.Command("sh", "-c", "sleep 2; shutdown -h now")
-	if err := cmd.Start(); err != nil {
+	if err := scheduleHostShutdown(); err != nil {
 		log.Error().Err(err).Msg("Failed to schedule host shutdown")
 		http.Error(w, fmt.Sprintf("failed to shutdown host: %v", err), http.StatusInternalServerError)
 		return
@@ -1174,7 +2250,7 @@ func (s *Server) handleHostShutdown(w http.ResponseWriter, r *http.Request) {
 		Payload: models.LogMessage{
 			Timestamp: time.Now(),
 			Level:     "warn",
-			Message:   "Запрошено выключение хоста",
+			Message:   s.messages.Sprintf(i18n.LogHostShutdown),
 		},
 	})
 
@@ -1193,14 +2269,29 @@ func (s *Server) handleDashboardConfig(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(s.dashboardConfig())
 }
 
-func (s *Server) currentSyncStatus() models.SyncStatus {
-	if s.getStatusFunc != nil {
-		return s.getStatusFunc()
+// handleGetEffectiveConfig reports the merged configuration the service is
+// running with, including where each value came from. Secrets are masked.
+func (s *Server) handleGetEffectiveConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
-	if s.syncService == nil {
-		return models.SyncStatus{}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.cfg.Effective())
+}
+
+func (s *Server) currentSyncStatus() models.SyncStatus {
+	var status models.SyncStatus
+	switch {
+	case s.getStatusFunc != nil:
+		status = s.getStatusFunc()
+	case s.syncService != nil:
+		status = s.syncService.GetStatus()
 	}
-	return s.syncService.GetStatus()
+	status.ActiveAlerts = s.activeAlerts()
+	status.Power = s.powerStatus()
+	return status
 }
 
 func (s *Server) hostNow() time.Time {
@@ -1324,6 +2415,88 @@ func (s *Server) ensureDestinationReady(destination string) error {
 	return s.syncService.EnsureDestinationReady(destination)
 }
 
+// startPreflightEstimateTimeout bounds how long sync start waits for the
+// project size estimate before falling back to the free-space threshold alone.
+const startPreflightEstimateTimeout = 30 * time.Second
+
+// checkStartPreflight verifies that the destination is mounted, writable and
+// large enough for the remaining project data plus the safety margin. It
+// returns nil when the sync may start.
+func (s *Server) checkStartPreflight(ctx context.Context, project, destination string, forceFullResync bool) *models.SyncStartError {
+	const gib = float64(1024 * 1024 * 1024)
+
+	fail := func(check models.PreflightCheck) *models.SyncStartError {
+		return &models.SyncStartError{
+			Error:   "preflight_failed",
+			Message: fmt.Sprintf("Cannot start sync: %s", check.Message),
+			Checks:  []models.PreflightCheck{check},
+		}
+	}
+
+	if err := s.ensureDestinationReady(destination); err != nil {
+		return fail(models.PreflightCheck{Key: "mount", Label: "Диск назначения", Status: "blocked", Message: err.Error()})
+	}
+
+	if err := checkDestinationWritable(destination); err != nil {
+		return fail(models.PreflightCheck{Key: "writable", Label: "Запись на диск", Status: "blocked", Message: err.Error()})
+	}
+
+	diskCheck, err := s.checkDestinationDiskSpace(destination)
+	if err != nil {
+		return fail(models.PreflightCheck{Key: "disk", Label: "Свободное место", Status: "blocked", Message: fmt.Sprintf("Не удалось проверить свободное место: %v", err)})
+	}
+
+	var estimated int64
+	if s.estimateProjectSizeFunc != nil {
+		estimateCtx, cancel := context.WithTimeout(ctx, startPreflightEstimateTimeout)
+		estimated, err = s.estimateProjectSizeFunc(estimateCtx, project, forceFullResync)
+		cancel()
+		if err != nil {
+			log.Warn().Err(err).Str("project", project).Msg("Project size estimate unavailable, checking free-space threshold only")
+			estimated = 0
+		}
+	}
+
+	required := diskCheck.RequiredFreeBytes + estimated
+	if int64(diskCheck.FreeBytes) < required {
+		startErr := fail(models.PreflightCheck{
+			Key:    "disk",
+			Label:  "Свободное место",
+			Status: "blocked",
+			Message: fmt.Sprintf(
+				"Свободно %.1f ГБ, требуется %.1f ГБ (данные проекта %.1f ГБ + запас %.1f ГБ)",
+				float64(diskCheck.FreeBytes)/gib, float64(required)/gib, float64(estimated)/gib, float64(diskCheck.RequiredFreeBytes)/gib,
+			),
+		})
+		startErr.FreeBytes = diskCheck.FreeBytes
+		startErr.RequiredBytes = required
+		startErr.EstimatedBytes = estimated
+		return startErr
+	}
+
+	return nil
+}
+
+// checkDestinationWritable creates and removes a probe file in destination.
+func checkDestinationWritable(destination string) error {
+	probe, err := os.CreateTemp(destination, ".ucxsync-write-test-*")
+	if err != nil {
+		return fmt.Errorf("destination %s is not writable: %w", destination, err)
+	}
+
+	name := probe.Name()
+	closeErr := probe.Close()
+	removeErr := os.Remove(name)
+	if closeErr != nil {
+		return fmt.Errorf("destination %s is not writable: %w", destination, closeErr)
+	}
+	if removeErr != nil {
+		return fmt.Errorf("failed to remove write probe in %s: %w", destination, removeErr)
+	}
+
+	return nil
+}
+
 func (s *Server) checkDestinationDiskSpace(destination string) (syncService.DiskSpaceCheckResult, error) {
 	if s.checkDiskSpaceFunc != nil {
 		return s.checkDiskSpaceFunc(destination)
@@ -1337,6 +2510,7 @@ func (s *Server) checkDestinationDiskSpace(destination string) (syncService.Disk
 func (s *Server) attemptShareRemount() {
 	unavailable := s.getUnavailableShares()
 	if len(unavailable) == 0 {
+		s.resolveAlertsOfType(models.AlertShareMountFailed)
 		return
 	}
 
@@ -1347,6 +2521,7 @@ func (s *Server) attemptShareRemount() {
 
 	if err := s.mountAllShares(); err != nil {
 		log.Warn().Err(err).Msg("Share remount attempt failed")
+		s.raiseShareMountAlert(len(unavailable), err)
 	}
 }
 
@@ -1357,6 +2532,7 @@ func (s *Server) autoRemountShares(ctx context.Context) {
 	}
 
 	s.attemptShareRemount()
+	s.noteShareMounts(ctx)
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
@@ -1367,6 +2543,25 @@ func (s *Server) autoRemountShares(ctx context.Context) {
 			return
 		case <-ticker.C:
 			s.attemptShareRemount()
+			s.noteShareMounts(ctx)
+		}
+	}
+}
+
+// monitorSourceSpace measures the worker shares' free space until ctx is
+// cancelled; crossing a threshold raises a source_space_low alert.
+func (s *Server) monitorSourceSpace(ctx context.Context) {
+	s.syncService.CheckSourceSpace()
+
+	ticker := time.NewTicker(s.syncService.SourceSpaceCheckInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.syncService.CheckSourceSpace()
 		}
 	}
 }
@@ -1407,7 +2602,7 @@ func (s *Server) buildPreflightStatus(ctx context.Context, project, destination
 		appendCheck("sync", "Состояние службы", "ready", "Служба готова к новому запуску")
 	}
 
-	projects, err := s.findProjects(ctx)
+	projects, _, err := s.discoverProjects(ctx, false)
 	if err != nil {
 		appendCheck("project", "Проект", "blocked", "Не удалось получить список проектов")
 	} else {
@@ -1762,7 +2957,7 @@ func (s *Server) handleDashboardDestinations(w http.ResponseWriter, r *http.Requ
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(s.getAvailableDestinations())
+	json.NewEncoder(w).Encode(s.availableDestinations())
 }
 
 func (s *Server) handleDashboardStartSync(w http.ResponseWriter, r *http.Request) {
@@ -2043,7 +3238,7 @@ func (s *Server) dispatchDashboardAction(ctx context.Context, method, apiPath st
 			defer wg.Done()
 
 			result := models.DashboardActionResult{ID: inst.ID, Name: inst.Name}
-			statusCode, err := s.proxyJSON(ctx, method, inst.URL, apiPath, body, nil)
+			statusCode, err := s.proxyJSON(ctx, method, inst.URL, s.confirmedPath(ctx, inst.URL, apiPath), body, nil)
 			result.StatusCode = statusCode
 			if err != nil {
 				result.Success = false
@@ -2060,6 +3255,22 @@ func (s *Server) dispatchDashboardAction(ctx context.Context, method, apiPath st
 	return results, nil
 }
 
+// confirmedPath adds a confirmation token from the instance at baseURL to a
+// guarded apiPath. Instances without /api/confirm get apiPath unchanged.
+func (s *Server) confirmedPath(ctx context.Context, baseURL, apiPath string) string {
+	if !guardedEndpoints[apiPath] {
+		return apiPath
+	}
+	body, _ := json.Marshal(map[string]string{"endpoint": apiPath})
+	var confirm struct {
+		Token string `json:"token"`
+	}
+	if _, err := s.proxyJSON(ctx, http.MethodPost, baseURL, confirmPath, body, &confirm); err != nil || confirm.Token == "" {
+		return apiPath
+	}
+	return apiPath + "?" + confirmTokenParam + "=" + url.QueryEscape(confirm.Token)
+}
+
 func (s *Server) selectDashboardTargets(targetIDs []string) ([]config.DashboardInstance, error) {
 	if !s.dashboardEnabled() {
 		return nil, fmt.Errorf("dashboard mode is not configured")
@@ -2120,28 +3331,47 @@ func (s *Server) proxyJSON(ctx context.Context, method, baseURL, apiPath string,
 	return resp.StatusCode, nil
 }
 
-// getBlockDevices returns list of all block devices using lsblk
-func (s *Server) getBlockDevices() ([]models.BlockDeviceInfo, error) {
-	var devices []models.BlockDeviceInfo
-	type lsblkDevice struct {
-		Name       string        `json:"name"`
-		Size       string        `json:"size"`
-		FSType     string        `json:"fstype"`
-		Label      string        `json:"label"`
-		MountPoint string        `json:"mountpoint"`
-		Type       string        `json:"type"`
-		RM         interface{}   `json:"rm"`
-		Model      string        `json:"model"`
-		Children   []lsblkDevice `json:"children"`
-	}
-
-	// Use lsblk to get block device information
-	cmd := exec.Command("lsblk", "-J", "-o", "NAME,SIZE,FSTYPE,LABEL,MOUNTPOINT,TYPE,RM,MODEL")
+// getBlockDevices returns list of all block devices using lsblk, or read
+// from sysfs where lsblk is not installed. lsblk is killed when ctx is
+// cancelled.
+func (s *Server) getBlockDevices(ctx context.Context) ([]models.BlockDeviceInfo, error) {
+	if !haveTool("lsblk") {
+		procMounts, _ := os.ReadFile("/proc/mounts")
+		blockDevices, err := sysfsBlockDevices(defaultSysfsRoots, string(procMounts))
+		if err != nil {
+			return nil, fmt.Errorf("lsblk is not installed and sysfs is unreadable: %w", err)
+		}
+		return destinationCandidates(blockDevices, s.cfg.Network.MountRoot), nil
+	}
+
+	// -b reports exact sizes in bytes instead of rounded human-readable values.
+	cmd := exec.CommandContext(ctx, "lsblk", "-J", "-b", "-o", "NAME,SIZE,FSTYPE,LABEL,UUID,PARTUUID,SERIAL,TRAN,MOUNTPOINT,TYPE,RM,MODEL")
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to run lsblk: %w", err)
 	}
 
+	return parseLsblkDevices(output, s.cfg.Network.MountRoot)
+}
+
+type lsblkDevice struct {
+	Name       string        `json:"name"`
+	Size       interface{}   `json:"size"`
+	FSType     string        `json:"fstype"`
+	Label      string        `json:"label"`
+	UUID       string        `json:"uuid"`
+	PartUUID   string        `json:"partuuid"`
+	Serial     string        `json:"serial"`
+	Tran       string        `json:"tran"`
+	MountPoint string        `json:"mountpoint"`
+	Type       string        `json:"type"`
+	RM         interface{}   `json:"rm"`
+	Model      string        `json:"model"`
+	Children   []lsblkDevice `json:"children"`
+}
+
+// parseLsblkDevices converts `lsblk -J -b` output into destination candidates.
+func parseLsblkDevices(output []byte, mountRoot string) ([]models.BlockDeviceInfo, error) {
 	var lsblkOutput struct {
 		BlockDevices []lsblkDevice `json:"blockdevices"`
 	}
@@ -2150,6 +3380,14 @@ func (s *Server) getBlockDevices() ([]models.BlockDeviceInfo, error) {
 		return nil, fmt.Errorf("failed to parse lsblk output: %w", err)
 	}
 
+	return destinationCandidates(lsblkOutput.BlockDevices, mountRoot), nil
+}
+
+// destinationCandidates picks the filesystems that can hold a destination
+// from a device tree as lsblk reports it.
+func destinationCandidates(blockDevices []lsblkDevice, mountRoot string) []models.BlockDeviceInfo {
+	var devices []models.BlockDeviceInfo
+
 	parseRemovable := func(raw interface{}) bool {
 		switch v := raw.(type) {
 		case bool:
@@ -2163,43 +3401,69 @@ func (s *Server) getBlockDevices() ([]models.BlockDeviceInfo, error) {
 		}
 	}
 
-	var walkDevices func([]lsblkDevice)
-	walkDevices = func(entries []lsblkDevice) {
+	// Serial, transport and model are reported on the whole disk only, so
+	// partitions inherit them from their parent.
+	var walkDevices func(entries []lsblkDevice, parent lsblkDevice)
+	walkDevices = func(entries []lsblkDevice, parent lsblkDevice) {
 		for _, dev := range entries {
+			if dev.Serial == "" {
+				dev.Serial = parent.Serial
+			}
+			if dev.Tran == "" {
+				dev.Tran = parent.Tran
+			}
+			if dev.Model == "" {
+				dev.Model = parent.Model
+			}
+			if dev.RM == nil {
+				dev.RM = parent.RM
+			}
+
 			// Skip if no filesystem
 			if dev.FSType == "" {
-				walkDevices(dev.Children)
+				walkDevices(dev.Children, dev)
 				continue
 			}
 
 			// Allow partitions and whole-disk filesystems.
 			if dev.Type != "part" && dev.Type != "disk" {
-				walkDevices(dev.Children)
+				walkDevices(dev.Children, dev)
 				continue
 			}
 
+			mountPoint := dev.MountPoint
+			encrypted := strings.EqualFold(dev.FSType, luksFSType)
+			unlocked := false
+			if encrypted {
+				// The filesystem and its mount are on the open mapping.
+				for _, child := range dev.Children {
+					if child.Type == "crypt" {
+						unlocked = true
+						mountPoint = child.MountPoint
+					}
+				}
+			}
+
 			// Skip system partitions (mounted on /, /boot, /home, etc.)
-			if dev.MountPoint == "/" ||
-				strings.HasPrefix(dev.MountPoint, "/boot") ||
-				strings.HasPrefix(dev.MountPoint, "/home") ||
-				strings.HasPrefix(dev.MountPoint, "/var") ||
-				strings.HasPrefix(dev.MountPoint, "/snap") {
-				walkDevices(dev.Children)
+			if mountPoint == "/" ||
+				strings.HasPrefix(mountPoint, "/boot") ||
+				strings.HasPrefix(mountPoint, "/home") ||
+				strings.HasPrefix(mountPoint, "/var") ||
+				strings.HasPrefix(mountPoint, "/snap") {
+				walkDevices(dev.Children, dev)
 				continue
 			}
 
 			// Skip UCX network mounts
-			if strings.HasPrefix(dev.MountPoint, s.cfg.Network.MountRoot) {
-				walkDevices(dev.Children)
+			if strings.HasPrefix(mountPoint, mountRoot) {
+				walkDevices(dev.Children, dev)
 				continue
 			}
 
 			devicePath := "/dev/" + dev.Name
 			isRemovable := parseRemovable(dev.RM)
-			isMounted := dev.MountPoint != ""
-
-			// Get size in bytes for sorting
-			sizeBytes := parseSizeToBytes(dev.Size)
+			isMounted := mountPoint != ""
+			sizeBytes := parseLsblkSize(dev.Size)
 
 			label := dev.Label
 			if label == "" {
@@ -2219,20 +3483,26 @@ func (s *Server) getBlockDevices() ([]models.BlockDeviceInfo, error) {
 				DevicePath:  devicePath,
 				DeviceName:  dev.Name,
 				Label:       label,
-				Size:        dev.Size,
+				Size:        formatDeviceSize(sizeBytes),
 				SizeBytes:   sizeBytes,
 				FSType:      dev.FSType,
-				MountPoint:  dev.MountPoint,
+				UUID:        dev.UUID,
+				PartUUID:    dev.PartUUID,
+				Serial:      strings.TrimSpace(dev.Serial),
+				Transport:   strings.ToLower(strings.TrimSpace(dev.Tran)),
+				MountPoint:  mountPoint,
 				IsMounted:   isMounted,
 				IsRemovable: isRemovable,
 				Model:       strings.TrimSpace(dev.Model),
+				Encrypted:   encrypted,
+				Unlocked:    unlocked,
 			})
 
-			walkDevices(dev.Children)
+			walkDevices(dev.Children, dev)
 		}
 	}
 
-	walkDevices(lsblkOutput.BlockDevices)
+	walkDevices(blockDevices, lsblkDevice{})
 
 	// Sort: removable first, then by size (largest first)
 	sort.Slice(devices, func(i, j int) bool {
@@ -2242,27 +3512,85 @@ func (s *Server) getBlockDevices() ([]models.BlockDeviceInfo, error) {
 		return devices[i].SizeBytes > devices[j].SizeBytes
 	})
 
-	return devices, nil
+	return devices
+}
+
+// parseLsblkSize reads a byte size that lsblk reports either as a JSON number
+// or, in older util-linux releases, as a quoted string.
+func parseLsblkSize(raw interface{}) uint64 {
+	switch v := raw.(type) {
+	case float64:
+		if v < 0 {
+			return 0
+		}
+		return uint64(v)
+	case string:
+		value, err := strconv.ParseUint(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return 0
+		}
+		return value
+	default:
+		return 0
+	}
+}
+
+// formatDeviceSize renders a byte count the way lsblk does (1024-based, one decimal).
+func formatDeviceSize(bytes uint64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%dB", bytes)
+	}
+
+	value := float64(bytes)
+	suffixes := []string{"K", "M", "G", "T", "P"}
+	i := -1
+	for value >= unit && i < len(suffixes)-1 {
+		value /= unit
+		i++
+	}
+
+	return fmt.Sprintf("%.1f%s", value, suffixes[i])
 }
 
-// mountDevice mounts a device to /ucdata
-func (s *Server) mountDevice(devicePath string) error {
+// mountDevice mounts a device to /ucdata, or to /ucdata-<id> when the default
+// mount point is already taken by another destination device. An encrypted
+// device is mounted through its mapping and must be unlocked first.
+func (s *Server) mountDevice(devicePath string) (string, error) {
+	probe, err := probeDevice(devicePath)
+	if err != nil {
+		log.Warn().Err(err).Str("device", devicePath).Msg("Mounting without filesystem-specific options")
+	}
+	if probe.FSType == luksFSType {
+		if !luksUnlocked(devMapperRoot, devicePath) {
+			return "", errDeviceLocked
+		}
+		devicePath = luksMapperPath(devMapperRoot, devicePath)
+		if probe, err = probeDevice(devicePath); err != nil {
+			log.Warn().Err(err).Str("device", devicePath).Msg("Mounting without filesystem-specific options")
+		}
+	}
+
 	mountPoint := defaultDataMountPoint
+	if isMounted, _ := isPathMounted(mountPoint); isMounted {
+		mountPoint = additionalDataMountPoint(devicePath, probe)
+	}
 
 	// Check if something is already mounted
 	if isMounted, _ := isPathMounted(mountPoint); isMounted {
-		return fmt.Errorf("something is already mounted at %s", mountPoint)
+		return "", fmt.Errorf("something is already mounted at %s", mountPoint)
 	}
 
 	// Create mount point if it doesn't exist
 	if err := os.MkdirAll(mountPoint, 0755); err != nil {
-		return fmt.Errorf("failed to create mount point: %w", err)
+		return "", fmt.Errorf("failed to create mount point: %w", err)
 	}
 
 	// Mount the device
-	cmd := exec.Command("mount", devicePath, mountPoint)
+	args := append(mountArgsForFilesystem(probe.FSType, os.Getuid(), os.Getgid()), devicePath, mountPoint)
+	cmd := exec.Command("mount", args...)
 	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("mount failed: %s: %w", string(output), err)
+		return "", fmt.Errorf("mount failed: %s: %w", string(output), err)
 	}
 
 	// Set permissions
@@ -2270,32 +3598,41 @@ func (s *Server) mountDevice(devicePath string) error {
 		log.Warn().Err(err).Msg("Failed to set permissions on mount point")
 	}
 
-	log.Info().Str("device", devicePath).Str("mount_point", mountPoint).Msg("Device mounted successfully")
-	return nil
+	log.Info().Str("device", devicePath).Str("fstype", probe.FSType).Str("mount_point", mountPoint).Msg("Device mounted successfully")
+	return mountPoint, nil
 }
 
-// unmountDevice unmounts a device
-func (s *Server) unmountDevice(devicePath string) error {
-	mountPoint := defaultDataMountPoint
-
-	// Check if the device is actually mounted at this location
-	mounted, err := isDeviceMountedAt(devicePath, mountPoint)
+// unmountDevice unmounts a device from whichever managed data mount point it
+// uses. An encrypted device is locked again once unmounted.
+func (s *Server) unmountDevice(devicePath string) (string, error) {
+	mountPoint, err := managedMountPointOfDevice(mountedDevicePath(devicePath))
 	if err != nil {
-		return fmt.Errorf("failed to check mount status: %w", err)
+		return "", fmt.Errorf("failed to check mount status: %w", err)
 	}
 
-	if !mounted {
-		return fmt.Errorf("device %s is not mounted at %s", devicePath, mountPoint)
+	if mountPoint == "" {
+		return "", fmt.Errorf("device %s is not mounted at %s", devicePath, defaultDataMountPoint)
 	}
 
 	// Unmount
 	cmd := exec.Command("umount", mountPoint)
 	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("unmount failed: %s: %w", string(output), err)
+		return "", fmt.Errorf("unmount failed: %s: %w", string(output), err)
+	}
+
+	// Additional mount points are created on demand, so clean them up again
+	if mountPoint != defaultDataMountPoint {
+		if err := os.Remove(mountPoint); err != nil && !os.IsNotExist(err) {
+			log.Warn().Err(err).Str("mount_point", mountPoint).Msg("Failed to remove mount point")
+		}
+	}
+
+	if err := s.lockDevice(devicePath); err != nil {
+		return mountPoint, fmt.Errorf("unmounted, but failed to lock the encrypted device: %w", err)
 	}
 
 	log.Info().Str("device", devicePath).Str("mount_point", mountPoint).Msg("Device unmounted successfully")
-	return nil
+	return mountPoint, nil
 }
 
 // isPathMounted checks if a path is currently mounted
@@ -2316,57 +3653,20 @@ func isPathMounted(path string) (bool, error) {
 	return false, nil
 }
 
-// isDeviceMountedAt checks if a specific device is mounted at a specific path
-func isDeviceMountedAt(devicePath, mountPath string) (bool, error) {
-	data, err := os.ReadFile("/proc/mounts")
-	if err != nil {
-		return false, err
-	}
-
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		fields := strings.Fields(line)
-		if len(fields) >= 2 && fields[0] == devicePath && fields[1] == mountPath {
-			return true, nil
-		}
-	}
-
-	return false, nil
+func isManagedDataDestination(destination string) bool {
+	_, ok := syncService.ManagedMountPoint(destination)
+	return ok
 }
 
-// parseSizeToBytes converts human-readable size to bytes
-func parseSizeToBytes(size string) uint64 {
-	size = strings.TrimSpace(size)
-	if size == "" {
-		return 0
-	}
-
-	var multiplier uint64 = 1
-	size = strings.ToUpper(size)
-
-	if strings.HasSuffix(size, "T") {
-		multiplier = 1024 * 1024 * 1024 * 1024
-		size = strings.TrimSuffix(size, "T")
-	} else if strings.HasSuffix(size, "G") {
-		multiplier = 1024 * 1024 * 1024
-		size = strings.TrimSuffix(size, "G")
-	} else if strings.HasSuffix(size, "M") {
-		multiplier = 1024 * 1024
-		size = strings.TrimSuffix(size, "M")
-	} else if strings.HasSuffix(size, "K") {
-		multiplier = 1024
-		size = strings.TrimSuffix(size, "K")
+// isDestinationOnMount reports whether destination lives on mountPoint. An
+// unknown mount point falls back to any managed data mount.
+func isDestinationOnMount(destination, mountPoint string) bool {
+	if mountPoint == "" {
+		return isManagedDataDestination(destination)
 	}
 
-	var value float64
-	fmt.Sscanf(size, "%f", &value)
-
-	return uint64(value * float64(multiplier))
-}
-
-func isManagedDataDestination(destination string) bool {
-	clean := filepath.ToSlash(filepath.Clean(destination))
-	return clean == defaultDataMountPoint || strings.HasPrefix(clean, defaultDataMountPoint+"/")
+	managed, ok := syncService.ManagedMountPoint(destination)
+	return ok && managed == mountPoint
 }
 
 func safeReportFilename(project string) (string, error) {
@@ -1,9 +1,12 @@
 package web
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -15,7 +18,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/zangezia/UCXSync/internal/config"
+	"github.com/zangezia/UCXSync/internal/i18n"
+	"github.com/zangezia/UCXSync/internal/monitor"
+	"github.com/zangezia/UCXSync/internal/network"
+	"github.com/zangezia/UCXSync/internal/replication"
+	"github.com/zangezia/UCXSync/internal/report"
 	"github.com/zangezia/UCXSync/internal/state"
 	syncService "github.com/zangezia/UCXSync/internal/sync"
 	"github.com/zangezia/UCXSync/pkg/models"
@@ -467,6 +476,44 @@ func TestHandleDownloadProjectReportServesSelectedDestinationReport(t *testing.T
 	}
 }
 
+func TestHandleDownloadProjectReportRendersHTML(t *testing.T) {
+	t.Parallel()
+
+	destination := t.TempDir()
+	store, err := state.New(filepath.Join(t.TempDir(), "state.db"), "ucxsync-test")
+	if err != nil {
+		t.Fatalf("open state store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := store.MarkFileCopiedTo("ProjA", "WU01/a.raw", 1<<20, time.Now(), destination); err != nil {
+		t.Fatalf("MarkFileCopiedTo() error = %v", err)
+	}
+
+	server := &Server{
+		stateStore: store,
+		getDestinationsFunc: func() []models.DestinationInfo {
+			return []models.DestinationInfo{{Path: destination, Type: "usb"}}
+		},
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/project/report?format=html&project=ProjA&destination="+url.QueryEscape(destination), nil)
+	resp := httptest.NewRecorder()
+
+	server.handleDownloadProjectReport(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200, body=%s", resp.Code, resp.Body.String())
+	}
+	if contentType := resp.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+		t.Fatalf("Content-Type = %q, want text/html", contentType)
+	}
+	if disposition := resp.Header().Get("Content-Disposition"); !strings.Contains(disposition, "ProjA-report.html") {
+		t.Fatalf("Content-Disposition = %q, want the HTML report filename", disposition)
+	}
+	if body := resp.Body.String(); !strings.Contains(body, "<svg") || !strings.Contains(body, "1.0 MiB") {
+		t.Fatalf("body does not hold the throughput chart and copied volume: %s", body)
+	}
+}
+
 func TestHandleDownloadProjectReportRejectsUnsafeProject(t *testing.T) {
 	t.Parallel()
 
@@ -754,3 +801,1761 @@ func findPreflightCheck(t *testing.T, preflight models.PreflightStatus, key stri
 	t.Fatalf("preflight check %q not found", key)
 	return models.PreflightCheck{}
 }
+
+func TestParseLsblkDevicesUsesExactSizesAndInheritsDiskIdentity(t *testing.T) {
+	t.Parallel()
+
+	output := []byte(`{
+	  "blockdevices": [
+	    {"name":"sda","size":256060514304,"fstype":null,"label":null,"uuid":null,"partuuid":null,"serial":"S3Z9NB0K","tran":"sata","mountpoint":null,"type":"disk","rm":false,"model":"Samsung SSD",
+	      "children":[{"name":"sda1","size":"255013273600","fstype":"ext4","label":"root","uuid":"1111","partuuid":"aaaa-01","serial":null,"tran":null,"mountpoint":"/","type":"part","rm":false,"model":null}]},
+	    {"name":"sdb","size":2000398934016,"fstype":null,"label":null,"uuid":null,"partuuid":null,"serial":"WX12","tran":"usb","mountpoint":null,"type":"disk","rm":true,"model":"Elements",
+	      "children":[{"name":"sdb1","size":2000397795328,"fstype":"exfat","label":"FLIGHT","uuid":"64A1-0F2C","partuuid":"bbbb-01","serial":null,"tran":null,"mountpoint":null,"type":"part","rm":true,"model":null}]}
+	  ]
+	}`)
+
+	devices, err := parseLsblkDevices(output, "/ucmount")
+	if err != nil {
+		t.Fatalf("parseLsblkDevices() error = %v", err)
+	}
+	if len(devices) != 1 {
+		t.Fatalf("len(devices) = %d, want 1 (system partition skipped)", len(devices))
+	}
+
+	dev := devices[0]
+	if dev.DevicePath != "/dev/sdb1" {
+		t.Fatalf("DevicePath = %q, want /dev/sdb1", dev.DevicePath)
+	}
+	if dev.SizeBytes != 2000397795328 {
+		t.Fatalf("SizeBytes = %d, want 2000397795328", dev.SizeBytes)
+	}
+	if dev.Size != "1.8T" {
+		t.Fatalf("Size = %q, want 1.8T", dev.Size)
+	}
+	if dev.UUID != "64A1-0F2C" || dev.PartUUID != "bbbb-01" {
+		t.Fatalf("UUID/PartUUID = %q/%q, want 64A1-0F2C/bbbb-01", dev.UUID, dev.PartUUID)
+	}
+	if dev.Serial != "WX12" || dev.Transport != "usb" || !dev.IsRemovable {
+		t.Fatalf("Serial/Transport/IsRemovable = %q/%q/%v, want WX12/usb/true", dev.Serial, dev.Transport, dev.IsRemovable)
+	}
+}
+
+func TestResolveDeviceReferencePrefersUUID(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	device := filepath.Join(root, "sdc1")
+	if err := os.WriteFile(device, nil, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	for _, dir := range []string{"by-uuid", "by-label"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("MkdirAll(%s) error = %v", dir, err)
+		}
+	}
+	if err := os.Symlink(device, filepath.Join(root, "by-uuid", "64A1-0F2C")); err != nil {
+		t.Fatalf("Symlink(uuid) error = %v", err)
+	}
+	if err := os.Symlink(device, filepath.Join(root, "by-label", "FLIGHT")); err != nil {
+		t.Fatalf("Symlink(label) error = %v", err)
+	}
+
+	got, err := resolveDeviceReference(root, "/dev/sdb1", "64A1-0F2C", "")
+	if err != nil || got != device {
+		t.Fatalf("resolveDeviceReference(uuid) = %q, %v; want %q", got, err, device)
+	}
+
+	got, err = resolveDeviceReference(root, "", "", "FLIGHT")
+	if err != nil || got != device {
+		t.Fatalf("resolveDeviceReference(label) = %q, %v; want %q", got, err, device)
+	}
+
+	if _, err := resolveDeviceReference(root, "", "../sdc1", ""); err == nil {
+		t.Fatal("expected path-like uuid to be rejected")
+	}
+
+	got, err = resolveDeviceReference(root, "/dev/sdb1", "", "")
+	if err != nil || got != "/dev/sdb1" {
+		t.Fatalf("resolveDeviceReference(path) = %q, %v; want /dev/sdb1", got, err)
+	}
+}
+
+func TestMountArgsForFilesystem(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		fsType string
+		want   []string
+	}{
+		{fsType: "ntfs", want: []string{"-t", "ntfs-3g", "-o", "big_writes,uid=1000,gid=1000,umask=022"}},
+		{fsType: "exfat", want: []string{"-t", "exfat", "-o", "uid=1000,gid=1000,umask=022"}},
+		{fsType: "ext4", want: []string{"-t", "ext4", "-o", "defaults"}},
+		{fsType: "xfs", want: nil},
+	}
+
+	for _, tt := range tests {
+		got := mountArgsForFilesystem(tt.fsType, 1000, 1000)
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Fatalf("mountArgsForFilesystem(%q) = %v, want %v", tt.fsType, got, tt.want)
+		}
+	}
+}
+
+func TestParseUeventBlockDeviceAdd(t *testing.T) {
+	t.Parallel()
+
+	raw := []byte("add@/devices/pci0000:00/usb2/2-1/host6/block/sdb/sdb1\x00ACTION=add\x00DEVPATH=/devices/pci0000:00/usb2/2-1/host6/block/sdb/sdb1\x00SUBSYSTEM=block\x00DEVNAME=sdb1\x00DEVTYPE=partition\x00SEQNUM=4242\x00")
+
+	event, ok := parseUevent(raw)
+	if !ok {
+		t.Fatal("expected uevent to parse")
+	}
+	if event.Action != "add" || event.Subsystem != "block" || event.DevName != "sdb1" || event.DevType != "partition" {
+		t.Fatalf("parseUevent() = %+v", event)
+	}
+
+	messageType, ok := blockDeviceMessage(event)
+	if !ok || messageType != "device_added" {
+		t.Fatalf("blockDeviceMessage() = %q, %v; want device_added", messageType, ok)
+	}
+
+	if _, ok := blockDeviceMessage(uevent{Action: "add", Subsystem: "usb", DevName: "bus/usb/002/003"}); ok {
+		t.Fatal("expected non-block uevent to be ignored")
+	}
+	if _, ok := parseUevent([]byte("libudev\x00garbage")); ok {
+		t.Fatal("expected malformed uevent to be rejected")
+	}
+}
+
+func TestAdditionalDataMountPointPrefersLabel(t *testing.T) {
+	t.Parallel()
+
+	if got := additionalDataMountPoint("/dev/sdc1", deviceProbe{Label: "FLIGHT 2", UUID: "64A1-0F2C"}); got != "/ucdata-FLIGHT_2" {
+		t.Fatalf("additionalDataMountPoint(label) = %q, want /ucdata-FLIGHT_2", got)
+	}
+	if got := additionalDataMountPoint("/dev/sdc1", deviceProbe{UUID: "64A1-0F2C"}); got != "/ucdata-64A1-0F2C" {
+		t.Fatalf("additionalDataMountPoint(uuid) = %q, want /ucdata-64A1-0F2C", got)
+	}
+	if got := additionalDataMountPoint("/dev/sdc1", deviceProbe{}); got != "/ucdata-sdc1" {
+		t.Fatalf("additionalDataMountPoint(name) = %q, want /ucdata-sdc1", got)
+	}
+}
+
+func TestFindManagedMountOfDevice(t *testing.T) {
+	t.Parallel()
+
+	procMounts := "/dev/sda1 / ext4 rw 0 0\n" +
+		"/dev/sdb1 /ucdata exfat rw 0 0\n" +
+		"/dev/sdc1 /media/operator/backup ext4 rw 0 0\n" +
+		"/dev/sdd1 /ucdata-FLIGHT2 ntfs rw 0 0\n"
+
+	tests := map[string]string{
+		"/dev/sdb1": "/ucdata",
+		"/dev/sdd1": "/ucdata-FLIGHT2",
+		"/dev/sdc1": "",
+		"/dev/sde1": "",
+	}
+	for device, want := range tests {
+		if got := findManagedMountOfDevice(procMounts, device); got != want {
+			t.Fatalf("findManagedMountOfDevice(%q) = %q, want %q", device, got, want)
+		}
+	}
+
+	if !isDestinationOnMount("/ucdata-FLIGHT2/2026-10-16/ProjA", "/ucdata-FLIGHT2") {
+		t.Fatal("expected destination on /ucdata-FLIGHT2 to match its mount")
+	}
+	if isDestinationOnMount("/ucdata/2026-10-16/ProjA", "/ucdata-FLIGHT2") {
+		t.Fatal("did not expect /ucdata destination to match /ucdata-FLIGHT2")
+	}
+}
+
+func TestParseDeviceProbe(t *testing.T) {
+	t.Parallel()
+
+	probe := parseDeviceProbe(`FSTYPE="exfat" UUID="64A1-0F2C" LABEL="FLIGHT 2"` + "\n")
+	if probe.FSType != "exfat" || probe.UUID != "64A1-0F2C" || probe.Label != "FLIGHT 2" {
+		t.Fatalf("parseDeviceProbe() = %+v", probe)
+	}
+}
+
+func TestCheckStartPreflightBlocksWhenProjectDoesNotFit(t *testing.T) {
+	t.Parallel()
+
+	destination := t.TempDir()
+	server := newPreflightTestServer(models.SyncStatus{}, func(s *Server) {
+		s.ensureDestinationFunc = func(string) error { return nil }
+		s.estimateProjectSizeFunc = func(context.Context, string, bool) (int64, error) {
+			return 10 * 1024 * 1024 * 1024, nil
+		}
+	})
+
+	startErr := server.checkStartPreflight(context.Background(), "ProjA", destination, false)
+	if startErr == nil {
+		t.Fatal("checkStartPreflight() = nil, want space error")
+	}
+	if len(startErr.Checks) != 1 || startErr.Checks[0].Key != "disk" {
+		t.Fatalf("Checks = %+v, want single disk check", startErr.Checks)
+	}
+	if want := int64(11 * 1024 * 1024 * 1024); startErr.RequiredBytes != want {
+		t.Fatalf("RequiredBytes = %d, want %d", startErr.RequiredBytes, want)
+	}
+	if entries, _ := os.ReadDir(destination); len(entries) != 0 {
+		t.Fatalf("write probe left %d entries in destination", len(entries))
+	}
+}
+
+func TestCheckStartPreflightFallsBackToThresholdWhenEstimateFails(t *testing.T) {
+	t.Parallel()
+
+	server := newPreflightTestServer(models.SyncStatus{}, func(s *Server) {
+		s.ensureDestinationFunc = func(string) error { return nil }
+		s.estimateProjectSizeFunc = func(context.Context, string, bool) (int64, error) {
+			return 0, context.DeadlineExceeded
+		}
+	})
+
+	if startErr := server.checkStartPreflight(context.Background(), "ProjA", t.TempDir(), false); startErr != nil {
+		t.Fatalf("checkStartPreflight() = %+v, want nil", startErr)
+	}
+}
+
+func TestAlertPolicyAppliesOverridesAndSwitches(t *testing.T) {
+	t.Parallel()
+
+	notify := true
+	policy := newAlertPolicy(config.WebAlerts{
+		BrowserNotifications: true,
+		Sound:                false,
+		Events: map[string]config.AlertRule{
+			models.SyncEventTaskStalled: {Severity: "critical", Notify: &notify},
+		},
+	}, i18n.New(i18n.English))
+
+	meta := policy.metaFor(models.SyncEvent{Type: models.SyncEventTaskStalled, Message: "Task WU01/E stalled", Reason: "no progress"})
+	if meta.Severity != "critical" || meta.Category != "sync" {
+		t.Fatalf("meta = %+v, want critical sync", meta)
+	}
+	if meta.Notification == nil || !meta.Notification.Browser || meta.Notification.Sound {
+		t.Fatalf("notification = %+v, want browser only", meta.Notification)
+	}
+	if meta.Notification.Body != "Task WU01/E stalled: no progress" {
+		t.Fatalf("notification body = %q", meta.Notification.Body)
+	}
+	if meta.Notification.Title != "Task stalled" {
+		t.Fatalf("notification title = %q, want the English catalog title", meta.Notification.Title)
+	}
+
+	meta = policy.metaFor(models.SyncEvent{Type: models.SyncEventCaptureCompleted, Message: "Capture 00042 completed"})
+	if meta.Severity != "success" || meta.Notification != nil {
+		t.Fatalf("capture meta = %+v, want success without notification", meta)
+	}
+}
+
+func TestHandlePauseSyncRequiresARunningJob(t *testing.T) {
+	t.Parallel()
+
+	server := newPreflightTestServer(models.SyncStatus{}, func(s *Server) {
+		s.syncService = syncService.New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	})
+
+	rec := httptest.NewRecorder()
+	server.handlePauseSync(rec, httptest.NewRequest(http.MethodGet, "/api/sync/pause", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET status = %d, want 405", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	server.handlePauseSync(rec, httptest.NewRequest(http.MethodPost, "/api/sync/pause", nil))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409 with no job running: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleSyncLoopIntervalUpdatesService(t *testing.T) {
+	t.Parallel()
+
+	server := newPreflightTestServer(models.SyncStatus{}, func(s *Server) {
+		s.syncService = syncService.New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/sync/loop-interval", strings.NewReader(`{"interval":"45s"}`))
+	rec := httptest.NewRecorder()
+	server.handleSyncLoopInterval(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	if got := server.syncService.ServiceLoopInterval(); got != 45*time.Second {
+		t.Fatalf("ServiceLoopInterval() = %s, want 45s", got)
+	}
+
+	var resp struct {
+		Interval        string  `json:"interval"`
+		IntervalSeconds float64 `json:"interval_seconds"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Interval != "45s" || resp.IntervalSeconds != 45 {
+		t.Fatalf("response = %+v, want 45s", resp)
+	}
+
+	for _, body := range []string{`{"interval":"100ms"}`, `{"interval":"soon"}`} {
+		req := httptest.NewRequest(http.MethodPost, "/api/sync/loop-interval", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		server.handleSyncLoopInterval(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("body %s: status = %d, want 400", body, rec.Code)
+		}
+	}
+}
+
+func TestHandleNodeMaintenanceTogglesNode(t *testing.T) {
+	t.Parallel()
+
+	server := newPreflightTestServer(models.SyncStatus{}, func(s *Server) {
+		s.syncService = syncService.New([]string{"WU01", "WU02"}, []string{"E$"}, "/ucmount")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/nodes/maintenance", strings.NewReader(`{"node":"wu02","maintenance":true,"reason":"fan swap"}`))
+	rec := httptest.NewRecorder()
+	server.handleNodeMaintenance(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var nodes []models.NodeMaintenance
+	if err := json.NewDecoder(rec.Body).Decode(&nodes); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].Node != "WU02" || nodes[0].Reason != "fan swap" {
+		t.Fatalf("response = %+v, want WU02 in maintenance", nodes)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/nodes/maintenance", strings.NewReader(`{"node":"WU77","maintenance":true}`))
+	rec = httptest.NewRecorder()
+	server.handleNodeMaintenance(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown node status = %d, want 400", rec.Code)
+	}
+}
+
+func TestHandleRequeueFailuresReleasesAllWithEmptyBody(t *testing.T) {
+	t.Parallel()
+
+	server := newPreflightTestServer(models.SyncStatus{}, func(s *Server) {
+		s.syncService = syncService.New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	})
+
+	rec := httptest.NewRecorder()
+	server.handleGetFailures(rec, httptest.NewRequest(http.MethodGet, "/api/failures", nil))
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Fatalf("GET /api/failures = %d %q, want empty list", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	server.handleRequeueFailures(rec, httptest.NewRequest(http.MethodPost, "/api/failures/requeue", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Requeued int `json:"requeued"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Requeued != 0 {
+		t.Fatalf("requeued = %d, want 0", resp.Requeued)
+	}
+
+	rec = httptest.NewRecorder()
+	server.handleRequeueFailures(rec, httptest.NewRequest(http.MethodGet, "/api/failures/requeue", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET requeue status = %d, want 405", rec.Code)
+	}
+}
+
+func TestHandleExclusionsUpdatesAndResets(t *testing.T) {
+	t.Parallel()
+
+	server := newPreflightTestServer(models.SyncStatus{}, func(s *Server) {
+		s.syncService = syncService.New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+	})
+
+	rec := httptest.NewRecorder()
+	server.handleExclusions(rec, httptest.NewRequest(http.MethodPut, "/api/exclusions", strings.NewReader(`{"directories":["Junk"]}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var got models.Exclusions
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(got.Directories) != 1 || got.Directories[0] != "Junk" {
+		t.Fatalf("directories = %v, want [Junk]", got.Directories)
+	}
+	if len(got.Projects) != len(syncService.DefaultExclusions().Projects) {
+		t.Fatalf("projects = %v, want defaults kept", got.Projects)
+	}
+
+	rec = httptest.NewRecorder()
+	server.handleExclusions(rec, httptest.NewRequest(http.MethodDelete, "/api/exclusions", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("DELETE status = %d, want 200", rec.Code)
+	}
+	if got := server.syncService.Exclusions(); len(got.Directories) != len(syncService.DefaultExclusions().Directories) {
+		t.Fatalf("directories after reset = %v, want defaults", got.Directories)
+	}
+}
+
+func TestRunCompletionActionsSkipsEjectAndPowerOffAfterFailedVerify(t *testing.T) {
+	t.Parallel()
+
+	var report completionReport
+	notify := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&report)
+	}))
+	defer notify.Close()
+
+	var ejected, poweredOff bool
+	server := newPreflightTestServer(models.SyncStatus{}, func(s *Server) {
+		s.cfg.Sync.Completion.NotifyURL = notify.URL
+		s.httpClient = notify.Client()
+		s.verifyRunFunc = func(context.Context) (models.VerifyResult, error) {
+			return models.VerifyResult{Checked: 3, Mismatched: []string{"/ucdata/a.raw"}}, nil
+		}
+		s.ejectDestinationFunc = func(string) error { ejected = true; return nil }
+		s.powerOffFunc = func() error { poweredOff = true; return nil }
+	})
+
+	event := models.SyncEvent{Type: models.SyncEventFinished, Project: "ProjA", Destination: "/ucdata"}
+	results := server.runCompletionActions(context.Background(), event, []string{"verify", "notify", "eject", "poweroff"})
+
+	if len(results) != 4 || results[0].OK || !results[1].OK || !results[2].Skipped || !results[3].Skipped {
+		t.Fatalf("results = %+v, want failed verify, sent notify, skipped eject and poweroff", results)
+	}
+	if ejected || poweredOff {
+		t.Fatalf("ejected=%t poweredOff=%t, want neither after a failed verify", ejected, poweredOff)
+	}
+	if report.Event.Project != "ProjA" || len(report.Actions) != 1 || report.Actions[0].Action != "verify" {
+		t.Fatalf("notify report = %+v, want the verify result for ProjA", report)
+	}
+}
+
+func TestHandleStartSyncRejectsUnknownCompletionAction(t *testing.T) {
+	t.Parallel()
+
+	server := newPreflightTestServer(models.SyncStatus{}, nil)
+
+	rec := httptest.NewRecorder()
+	body := `{"project":"ProjA","destination":"/ucdata","completion_actions":["reboot"]}`
+	server.handleStartSync(rec, httptest.NewRequest(http.MethodPost, "/api/sync/start", strings.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestNotifySyncEventRunsCompletionActionsOnce(t *testing.T) {
+	t.Parallel()
+
+	powerOffs := make(chan struct{}, 2)
+	server := newPreflightTestServer(models.SyncStatus{}, func(s *Server) {
+		s.powerOffFunc = func() error { powerOffs <- struct{}{}; return nil }
+	})
+	server.setCompletionActions([]string{"poweroff"})
+
+	event := models.SyncEvent{Type: models.SyncEventFinished, Project: "ProjA", Destination: "/ucdata"}
+	server.NotifySyncEvent(event)
+	server.NotifySyncEvent(event)
+
+	select {
+	case <-powerOffs:
+	case <-time.After(2 * time.Second):
+		t.Fatal("completion actions did not run")
+	}
+	select {
+	case <-powerOffs:
+		t.Fatal("completion actions ran twice")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestNotifySyncEventDropsCompletionActionsOfFailedRun(t *testing.T) {
+	t.Parallel()
+
+	poweredOff := make(chan struct{}, 1)
+	server := newPreflightTestServer(models.SyncStatus{}, func(s *Server) {
+		s.powerOffFunc = func() error { poweredOff <- struct{}{}; return nil }
+	})
+	server.setCompletionActions([]string{"poweroff"})
+
+	server.NotifySyncEvent(models.SyncEvent{Type: models.SyncEventFailed, Project: "ProjA", Destination: "/ucdata"})
+	if actions := server.takeCompletionActions(); len(actions) != 0 {
+		t.Fatalf("pending actions = %v, want none after a failed run", actions)
+	}
+	select {
+	case <-poweredOff:
+		t.Fatal("completion actions ran for a failed run")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestFindDeviceOfMount(t *testing.T) {
+	t.Parallel()
+
+	mounts := "/dev/sda1 / ext4 rw 0 0\n/dev/sdb1 /ucdata exfat rw 0 0\n"
+	if got := findDeviceOfMount(mounts, "/ucdata"); got != "/dev/sdb1" {
+		t.Fatalf("device = %q, want /dev/sdb1", got)
+	}
+	if got := findDeviceOfMount(mounts, "/ucdata-x"); got != "" {
+		t.Fatalf("device = %q, want none", got)
+	}
+}
+
+func TestHandleExportRejectsDeliveryOntoWorkingDestination(t *testing.T) {
+	t.Parallel()
+
+	server := newPreflightTestServer(models.SyncStatus{}, func(s *Server) {
+		s.syncService = syncService.New([]string{"WU01"}, []string{"E$"}, "/ucmount")
+		s.cfg.Sync.Destination = "/tmp/work"
+	})
+
+	rec := httptest.NewRecorder()
+	body := `{"project":"ProjA","destination":"/tmp/work"}`
+	server.handleExport(rec, httptest.NewRequest(http.MethodPost, "/api/export", strings.NewReader(body)))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "differ") {
+		t.Fatalf("status = %d (%s), want 400 for delivery onto the working destination", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	server.handleExport(rec, httptest.NewRequest(http.MethodGet, "/api/export", nil))
+	var status models.ExportStatus
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil || status.Running {
+		t.Fatalf("GET status = %+v (err %v), want idle export", status, err)
+	}
+}
+
+func TestReplicationEndpointsMirrorDestinationToPeer(t *testing.T) {
+	t.Parallel()
+
+	primary := t.TempDir()
+	rawPath := filepath.Join(primary, "2024-05-17", "ProjA", "Lvl00-00005-ProjA-00-00-X.raw")
+	if err := os.MkdirAll(filepath.Dir(rawPath), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(rawPath, []byte("raw payload"), 0644); err != nil {
+		t.Fatalf("write raw: %v", err)
+	}
+	if err := os.WriteFile(rawPath+".ucxexport", []byte("partial"), 0644); err != nil {
+		t.Fatalf("write temp: %v", err)
+	}
+
+	server := newPreflightTestServer(models.SyncStatus{Destination: primary}, func(s *Server) {
+		s.cfg.Replication.Serve = config.ReplicationServe{Enabled: true, Token: "0123456789abcdef"}
+	})
+	mux := http.NewServeMux()
+	mux.HandleFunc(replication.ManifestPath, server.handleReplicationManifest)
+	mux.HandleFunc(replication.FilePath, server.handleReplicationFile)
+	peer := httptest.NewServer(mux)
+	defer peer.Close()
+
+	resp, err := http.Get(peer.URL + replication.ManifestPath)
+	if err != nil {
+		t.Fatalf("GET manifest: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("manifest without token status = %d, want 401", resp.StatusCode)
+	}
+
+	mirrorDir := t.TempDir()
+	mirror := replication.NewMirror(replication.Options{PeerURL: peer.URL, Token: "0123456789abcdef", Destination: mirrorDir})
+	if err := mirror.SyncOnce(context.Background()); err != nil {
+		t.Fatalf("SyncOnce() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(mirrorDir, "2024-05-17", "ProjA", "Lvl00-00005-ProjA-00-00-X.raw"))
+	if err != nil || string(data) != "raw payload" {
+		t.Fatalf("mirrored raw = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(mirrorDir, "2024-05-17", "ProjA", "Lvl00-00005-ProjA-00-00-X.raw.ucxexport")); !os.IsNotExist(err) {
+		t.Fatalf("temporary export file was mirrored: %v", err)
+	}
+	if status := mirror.Status(); status.CopiedFiles != 1 || status.ManifestFiles != 1 || status.LastError != "" {
+		t.Fatalf("mirror status = %+v", status)
+	}
+}
+
+func TestFilesEndpointServesRangesOfNewestSyncedFile(t *testing.T) {
+	t.Parallel()
+
+	destination := t.TempDir()
+	for day, payload := range map[string]string{"2024-05-16": "old payload", "2024-05-17": "0123456789"} {
+		path := filepath.Join(destination, day, "ProjA", "WU01", "Lvl00-00005-ProjA-00-00-X.raw")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(payload), 0644); err != nil {
+			t.Fatalf("write raw: %v", err)
+		}
+	}
+
+	server := newPreflightTestServer(models.SyncStatus{Destination: destination}, func(s *Server) {
+		s.cfg.Web.Files = config.WebFiles{Enabled: true, Token: "0123456789abcdef"}
+	})
+	get := func(path, token, byteRange string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if byteRange != "" {
+			req.Header.Set("Range", byteRange)
+		}
+		rec := httptest.NewRecorder()
+		server.handleFiles(rec, req)
+		return rec
+	}
+
+	const rawURL = "/files/ProjA/WU01/Lvl00-00005-ProjA-00-00-X.raw"
+	if rec := get(rawURL, "", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("without token status = %d, want 401", rec.Code)
+	}
+	rec := get(rawURL, "0123456789abcdef", "bytes=2-5")
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "2345" {
+		t.Fatalf("range response = %d %q, want 206 \"2345\"", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Range"); got != "bytes 2-5/10" {
+		t.Fatalf("Content-Range = %q", got)
+	}
+	if rec := get("/files/ProjA/../ProjB/secret.raw", "0123456789abcdef", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("traversal status = %d, want 400", rec.Code)
+	}
+	if rec := get("/files/ProjA/WU01/missing.raw", "0123456789abcdef", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing file status = %d, want 404", rec.Code)
+	}
+
+	for _, project := range []string{"*", "%3Froj%3F", "[P]rojA"} {
+		if rec := get("/files/"+project+"/WU01/Lvl00-00005-ProjA-00-00-X.raw", "0123456789abcdef", ""); rec.Code != http.StatusNotFound {
+			t.Fatalf("project %q status = %d, want 404", project, rec.Code)
+		}
+	}
+
+	partial := filepath.Join(destination, "2024-05-17", "ProjA", "WU01", "Lvl00-00006-ProjA-00-00-X.raw.ucxtmp")
+	if err := os.WriteFile(partial, []byte("half"), 0644); err != nil {
+		t.Fatalf("write partial copy: %v", err)
+	}
+	if rec := get("/files/ProjA/WU01/Lvl00-00006-ProjA-00-00-X.raw.ucxtmp", "0123456789abcdef", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("partial copy status = %d, want 404", rec.Code)
+	}
+}
+
+func TestJobTemplatesFillStartRequest(t *testing.T) {
+	t.Parallel()
+
+	benchmark := true
+	server := newPreflightTestServer(models.SyncStatus{}, func(s *Server) {
+		s.cfg.Sync.Destination = "/ucdata"
+		s.cfg.Sync.MaxParallelism = 8
+		s.cfg.Sync.Completion.Actions = []string{config.CompletionManifest}
+		s.cfg.Sync.Templates = []config.JobTemplate{
+			{Name: "nightly-ingest", Project: "ProjA", MaxParallelism: 12, CompletionActions: []string{config.CompletionVerify}, Benchmark: &benchmark},
+			{Name: "plain", Project: "ProjB"},
+		}
+	})
+
+	req := startSyncRequest{Template: "nightly-ingest", Destination: "/media/usb1"}
+	if err := server.applyJobTemplate(&req); err != nil {
+		t.Fatalf("applyJobTemplate() error = %v", err)
+	}
+	if req.Project != "ProjA" || req.Destination != "/media/usb1" || req.MaxParallelism != 12 ||
+		req.CompletionActions == nil || strings.Join(*req.CompletionActions, ",") != "verify" || req.Benchmark == nil || !*req.Benchmark {
+		t.Fatalf("request = %+v, want template fields with the destination override", req)
+	}
+	if err := server.applyJobTemplate(&startSyncRequest{Template: "missing"}); err == nil {
+		t.Fatal("expected unknown template to be rejected")
+	}
+
+	rec := httptest.NewRecorder()
+	server.handleSyncTemplates(rec, httptest.NewRequest(http.MethodGet, "/api/sync/templates", nil))
+	var templates []models.JobTemplate
+	if err := json.NewDecoder(rec.Body).Decode(&templates); err != nil {
+		t.Fatalf("decode templates: %v", err)
+	}
+	if len(templates) != 2 {
+		t.Fatalf("templates = %+v, want 2", templates)
+	}
+	if plain := templates[1]; plain.Destination != "/ucdata" || plain.MaxParallelism != 8 || strings.Join(plain.CompletionActions, ",") != "manifest" || plain.Benchmark {
+		t.Fatalf("plain template = %+v, want sync defaults", plain)
+	}
+}
+
+func TestAlertCenterKeepsWarningsUntilResolved(t *testing.T) {
+	t.Parallel()
+
+	store, err := state.New(filepath.Join(t.TempDir(), "state.db"), "ucxsync-test")
+	if err != nil {
+		t.Fatalf("state.New returned error: %v", err)
+	}
+	defer store.Close()
+	server := &Server{stateStore: store}
+
+	server.NotifySyncEvent(models.SyncEvent{Type: models.SyncEventCaptureCompleted, Project: "ProjA", Message: "Capture 00001 completed"})
+	server.NotifySyncEvent(models.SyncEvent{Type: models.SyncEventDestinationFull, Project: "ProjA", Destination: "/ucdata", Message: "full"})
+	server.NotifySyncEvent(models.SyncEvent{Type: models.SyncEventDestinationFull, Project: "ProjA", Destination: "/ucdata", Message: "still full"})
+
+	rec := httptest.NewRecorder()
+	server.handleAlerts(rec, httptest.NewRequest(http.MethodGet, "/api/alerts", nil))
+	var alerts []models.Alert
+	if err := json.NewDecoder(rec.Body).Decode(&alerts); err != nil {
+		t.Fatalf("decode alerts: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].Type != models.SyncEventDestinationFull || alerts[0].Count != 2 || alerts[0].Message != "still full" {
+		t.Fatalf("alerts = %+v, want one destination_full alert seen twice", alerts)
+	}
+
+	rec = httptest.NewRecorder()
+	body := `{"id":` + strconv.FormatInt(alerts[0].ID, 10) + `,"action":"acknowledge"}`
+	server.handleAlerts(rec, httptest.NewRequest(http.MethodPost, "/api/alerts", strings.NewReader(body)))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"state":"acknowledged"`) {
+		t.Fatalf("acknowledge = %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Switching to a new destination clears the full one.
+	server.NotifySyncEvent(models.SyncEvent{Type: models.SyncEventDestinationSwitched, Project: "ProjA", Destination: "/ucdata2"})
+	if open, err := store.ListAlerts(false, 0); err != nil || len(open) != 0 {
+		t.Fatalf("open alerts after switch = %+v, %v", open, err)
+	}
+}
+
+func TestBenchmarkDestinationFlagsSlowDisk(t *testing.T) {
+	t.Parallel()
+
+	var gotSize int64
+	server := newPreflightTestServer(models.SyncStatus{}, func(s *Server) {
+		s.cfg.Sync.Benchmark = config.SyncBenchmark{SizeMB: 8, WarnRatio: 0.5}
+		s.cfg.Monitoring.MaxDiskThroughputMBps = 200
+		s.benchmarkDestinationFunc = func(_ context.Context, dir string, size int64) (models.DestinationBenchmark, error) {
+			gotSize = size
+			return models.DestinationBenchmark{Destination: dir, Bytes: size, WriteMBps: 60}, nil
+		}
+	})
+
+	result := server.benchmarkDestination(context.Background(), "/ucdata")
+	if result == nil || !result.Slow || result.ExpectedMBps != 200 || gotSize != 8<<20 {
+		t.Fatalf("result = %+v, size = %d, want a slow result against 200 MB/s", result, gotSize)
+	}
+
+	server.benchmarkDestinationFunc = func(context.Context, string, int64) (models.DestinationBenchmark, error) {
+		return models.DestinationBenchmark{WriteMBps: 150}, nil
+	}
+	if result := server.benchmarkDestination(context.Background(), "/ucdata"); result == nil || result.Slow {
+		t.Fatalf("result = %+v, want a normal speed", result)
+	}
+}
+
+func TestProjectsEndpointServesCachedDiscoveryUntilRefreshed(t *testing.T) {
+	t.Parallel()
+
+	scans := 0
+	server := newPreflightTestServer(models.SyncStatus{}, func(s *Server) {
+		s.cfg.Sync.DiscoveryInterval = time.Minute
+		s.findProjectsFunc = func(context.Context) ([]models.ProjectInfo, error) {
+			scans++
+			return []models.ProjectInfo{{Name: "ProjB", Source: "WU02/E$"}, {Name: "ProjA", Source: "WU01/E$"}}, nil
+		}
+	})
+
+	get := func(target string) ([]models.ProjectInfo, *httptest.ResponseRecorder) {
+		rec := httptest.NewRecorder()
+		server.handleGetProjects(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, body = %s", target, rec.Code, rec.Body.String())
+		}
+		var projects []models.ProjectInfo
+		if err := json.NewDecoder(rec.Body).Decode(&projects); err != nil {
+			t.Fatalf("decode projects: %v", err)
+		}
+		return projects, rec
+	}
+
+	projects, rec := get("/api/projects")
+	if len(projects) != 2 || projects[0].Name != "ProjA" || rec.Header().Get("Age") != "0" || rec.Header().Get("X-Projects-Scanned-At") == "" {
+		t.Fatalf("projects = %+v, headers = %v; want a sorted list with its cache age", projects, rec.Header())
+	}
+	get("/api/projects")
+	if scans != 1 {
+		t.Fatalf("scans = %d after a cached request, want 1", scans)
+	}
+	get("/api/projects?refresh=true")
+	if scans != 2 {
+		t.Fatalf("scans = %d after ?refresh=true, want 2", scans)
+	}
+}
+
+func TestEventJournalWritesEventsAlertsAndStatusChangesToRunFolder(t *testing.T) {
+	t.Parallel()
+
+	runDir := t.TempDir()
+	cfg := &config.Config{}
+	cfg.Sync.EventLog = true
+	server := &Server{cfg: cfg, runDirFunc: func() string { return runDir }}
+	defer server.closeJournal()
+
+	status := models.SyncStatus{IsRunning: true, Project: "ProjA", Destination: "/ucdata"}
+	server.journalStatusChange(status)
+	server.journalStatusChange(status)
+	server.NotifySyncEvent(models.SyncEvent{Type: models.SyncEventDestinationFull, Project: "ProjA", Destination: "/ucdata", Message: "full"})
+	server.broadcast(models.WSMessage{Type: "metrics", Payload: models.PerformanceMetrics{}})
+	status.Paused = true
+	server.journalStatusChange(status)
+
+	data, err := os.ReadFile(filepath.Join(runDir, eventJournalName))
+	if err != nil {
+		t.Fatalf("ReadFile(journal) error = %v", err)
+	}
+	var kinds []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry struct {
+			Kind string `json:"kind"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("journal line %q: %v", line, err)
+		}
+		kinds = append(kinds, entry.Kind)
+	}
+	if got := strings.Join(kinds, ","); got != "status,sync_event,alert,status" {
+		t.Fatalf("journal kinds = %s, want status,sync_event,alert,status", got)
+	}
+}
+
+func TestStatusMessageSendsOnlyChangedFieldsAndTasks(t *testing.T) {
+	t.Parallel()
+
+	s := &Server{}
+	status := models.SyncStatus{
+		IsRunning: true,
+		Project:   "ProjA",
+		ActiveTasks: []models.SyncTask{
+			{Node: "WU01", Share: "E$", Status: models.TaskStateCopying, CopiedFiles: 1},
+			{Node: "WU02", Share: "E$", Status: models.TaskStateCopying, CopiedFiles: 1},
+		},
+	}
+
+	first, ok := s.statusMessage(status)
+	if !ok || first.Type != "status" || first.Seq != 1 {
+		t.Fatalf("first message = %+v, want a full status numbered 1", first)
+	}
+	if _, ok := s.statusMessage(status); ok {
+		t.Fatal("an unchanged status was sent again")
+	}
+
+	status.CompletedCaptures = 3
+	status.ActiveTasks = []models.SyncTask{
+		{Node: "WU01", Share: "E$", Status: models.TaskStateCopying, CopiedFiles: 2},
+	}
+	msg, ok := s.statusMessage(status)
+	delta, isDelta := msg.Payload.(models.StatusDelta)
+	if !ok || msg.Type != "status_delta" || !isDelta || delta.Base != 1 || msg.Seq != 2 {
+		t.Fatalf("second message = %+v, want a delta from 1 numbered 2", msg)
+	}
+	if len(delta.Fields) != 1 || string(delta.Fields["completed_captures"]) != "3" {
+		t.Fatalf("delta fields = %s, want only completed_captures", delta.Fields)
+	}
+	if len(delta.Tasks) != 1 || delta.Tasks[0].CopiedFiles != 2 ||
+		len(delta.RemovedTasks) != 1 || delta.RemovedTasks[0] != "WU02/E$" || len(delta.TaskOrder) != 1 {
+		t.Fatalf("delta tasks = %+v, want WU01/E$ changed and WU02/E$ removed", delta)
+	}
+
+	if last, ok := s.lastStatusMessage(); !ok || last.Seq != 2 || last.Payload.(models.SyncStatus).CompletedCaptures != 3 {
+		t.Fatalf("lastStatusMessage() = %+v, want the status numbered 2", last)
+	}
+
+	for i := 0; i < statusKeyframeEvery; i++ {
+		status.CompletedCaptures++
+		if msg, _ = s.statusMessage(status); msg.Type == "status" {
+			return
+		}
+	}
+	t.Fatalf("no full status within %d ticks", statusKeyframeEvery)
+}
+
+func TestCaptureTagsEndpointUpdatesTagsAndTheReport(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := state.New(filepath.Join(dir, "state.db"), "ucxsync-test")
+	if err != nil {
+		t.Fatalf("state.New returned error: %v", err)
+	}
+	defer store.Close()
+	destination := filepath.Join(dir, "ucdata")
+	reportPath := report.DefaultPath(destination, "ProjA")
+	if err := report.WriteJSON(reportPath, report.Build("ProjA", nil, report.Options{})); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	server := &Server{
+		cfg:           &config.Config{Sync: config.Sync{Destination: destination}},
+		stateStore:    store,
+		getStatusFunc: func() models.SyncStatus { return models.SyncStatus{} },
+	}
+
+	request := func(method, target, body string) (int, models.CaptureTags) {
+		rec := httptest.NewRecorder()
+		server.handleCaptureTags(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		var tags models.CaptureTags
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&tags); err != nil {
+				t.Fatalf("decode tags: %v", err)
+			}
+		}
+		return rec.Code, tags
+	}
+
+	code, tags := request(http.MethodPost, "/api/captures/5/tags?project=ProjA", `{"tags":["Cloudy","re-fly"]}`)
+	if code != http.StatusOK || tags.Capture != "00005" || strings.Join(tags.Tags, ",") != "cloudy,re-fly" {
+		t.Fatalf("POST = %d %+v, want capture 00005 tagged cloudy,re-fly", code, tags)
+	}
+	if code, tags = request(http.MethodDelete, "/api/captures/00005/tags?project=ProjA&tag=cloudy", ""); code != http.StatusOK || strings.Join(tags.Tags, ",") != "re-fly" {
+		t.Fatalf("DELETE = %d %+v, want only re-fly left", code, tags)
+	}
+	if code, _ = request(http.MethodPut, "/api/captures/5/tags?project=ProjA", `{"tags":["no spaces"]}`); code != http.StatusBadRequest {
+		t.Fatalf("PUT with an invalid tag = %d, want 400", code)
+	}
+	if code, _ = request(http.MethodGet, "/api/captures/5/tags", ""); code != http.StatusBadRequest {
+		t.Fatalf("GET without project = %d, want 400", code)
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	var refreshed report.DestinationReport
+	if err := json.Unmarshal(data, &refreshed); err != nil || refreshed.Project != "ProjA" {
+		t.Fatalf("report = %s, %v, want the rewritten ProjA report", data, err)
+	}
+}
+
+func TestStatusCarriesActiveAlertsCriticalFirst(t *testing.T) {
+	t.Parallel()
+
+	store, err := state.New(filepath.Join(t.TempDir(), "state.db"), "ucxsync-test")
+	if err != nil {
+		t.Fatalf("state.New returned error: %v", err)
+	}
+	defer store.Close()
+	server := &Server{stateStore: store, getStatusFunc: func() models.SyncStatus { return models.SyncStatus{IsRunning: true} }}
+
+	if status := server.currentSyncStatus(); status.ActiveAlerts == nil || len(status.ActiveAlerts) != 0 {
+		t.Fatalf("active alerts = %#v, want an empty list", status.ActiveAlerts)
+	}
+
+	server.NotifySyncEvent(models.SyncEvent{Type: models.SyncEventDiskSpaceWarning, Project: "ProjA", Message: "low disk"})
+	server.NotifySyncEvent(models.SyncEvent{Type: models.SyncEventDestinationLost, Project: "ProjA", Message: "lost"})
+	server.NotifySyncEvent(models.SyncEvent{Type: models.SyncEventCaptureCompleted, Project: "ProjA", Message: "done"})
+
+	status := server.currentSyncStatus()
+	if !status.IsRunning || len(status.ActiveAlerts) != 2 || status.ActiveAlerts[0].Type != models.SyncEventDestinationLost ||
+		status.ActiveAlerts[1].Severity != "warning" || status.ActiveAlerts[1].Message != "low disk" {
+		t.Fatalf("active alerts = %+v, want destination_lost before the disk warning", status.ActiveAlerts)
+	}
+
+	if _, err := store.ResolveAlert(status.ActiveAlerts[0].ID, time.Now()); err != nil {
+		t.Fatalf("ResolveAlert() error = %v", err)
+	}
+	if status := server.currentSyncStatus(); len(status.ActiveAlerts) != 1 {
+		t.Fatalf("active alerts after resolve = %+v, want one", status.ActiveAlerts)
+	}
+}
+
+func TestIdlePowerSpinsDownTheDestinationAndWakesOnActivity(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	lastCopy := start
+	var spunDown []string
+	server := newPreflightTestServer(models.SyncStatus{IsRunning: true, Project: "ProjA", Destination: "/ucdata/ProjA"}, func(s *Server) {
+		s.cfg.Monitoring.IdlePower = config.IdlePower{Enabled: true, IdleAfter: 15 * time.Minute, SpinDown: true, MonitorInterval: 30 * time.Second}
+		s.lastActivityFunc = func(time.Time) time.Time { return lastCopy }
+		s.spinDownFunc = func(destination string) (string, error) {
+			spunDown = append(spunDown, destination)
+			return "/dev/sda1", nil
+		}
+	})
+
+	server.checkPower(start.Add(10 * time.Minute))
+	if server.powerIdle() || len(spunDown) != 0 {
+		t.Fatalf("idle after 10 minutes: idle %v, spun down %v", server.powerIdle(), spunDown)
+	}
+
+	server.checkPower(start.Add(15 * time.Minute))
+	server.checkPower(start.Add(16 * time.Minute))
+	power := server.powerStatus()
+	if !power.Idle || power.DiskSpunDown != "/dev/sda1" || power.IdleSince == nil {
+		t.Fatalf("power after 15 idle minutes = %+v, want idle with /dev/sda1 spun down", power)
+	}
+	if len(spunDown) != 1 || spunDown[0] != "/ucdata/ProjA" {
+		t.Fatalf("spun down %v, want the destination once", spunDown)
+	}
+
+	// A new capture arrives.
+	lastCopy = start.Add(17 * time.Minute)
+	server.checkPower(start.Add(17 * time.Minute))
+	if power := server.powerStatus(); power.Idle || power.DiskSpunDown != "" {
+		t.Fatalf("power after a copy = %+v, want awake", power)
+	}
+	if status := server.currentSyncStatus(); status.Power == nil || status.Power.Idle {
+		t.Fatalf("status power = %+v, want awake", status.Power)
+	}
+}
+
+func TestProjectRegistryEndpointAnnotatesProjectsAndReports(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := state.New(filepath.Join(dir, "state.db"), "ucxsync-test")
+	if err != nil {
+		t.Fatalf("state.New returned error: %v", err)
+	}
+	defer store.Close()
+	destination := filepath.Join(dir, "ucdata")
+	reportPath := report.DefaultPath(destination, "ProjA")
+	if err := report.WriteJSON(reportPath, report.Build("ProjA", nil, report.Options{})); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	server := newPreflightTestServer(models.SyncStatus{}, func(s *Server) {
+		s.cfg.Sync.Destination = destination
+		s.stateStore = store
+	})
+
+	request := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.handleProjectRegistry(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := request(http.MethodPut, "/api/projects/registry", `{"project":"ProjA","alias":"North block","customer":"Cadastre","notes":"Lines 1-12"}`); rec.Code != http.StatusOK {
+		t.Fatalf("PUT = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := request(http.MethodPut, "/api/projects/registry", `{"project":"ProjB","alias":"north block"}`); rec.Code != http.StatusConflict {
+		t.Fatalf("PUT with a taken alias = %d, want 409", rec.Code)
+	}
+	if rec := request(http.MethodPut, "/api/projects/registry", `{"project":"ProjB"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("PUT without details = %d, want 400", rec.Code)
+	}
+	if rec := request(http.MethodGet, "/api/projects/registry?project=ProjB", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("GET unknown = %d, want 404", rec.Code)
+	}
+
+	rec := httptest.NewRecorder()
+	server.handleGetProjects(rec, httptest.NewRequest(http.MethodGet, "/api/projects", nil))
+	var projects []models.ProjectInfo
+	if err := json.NewDecoder(rec.Body).Decode(&projects); err != nil || len(projects) != 1 || projects[0].Alias != "North block" || projects[0].Customer != "Cadastre" {
+		t.Fatalf("projects = %+v, %v, want ProjA with its alias and customer", projects, err)
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	var refreshed report.DestinationReport
+	if err := json.Unmarshal(data, &refreshed); err != nil || refreshed.Registry == nil || refreshed.Registry.Notes != "Lines 1-12" {
+		t.Fatalf("report = %s, %v, want the registry entry embedded", data, err)
+	}
+
+	if rec := request(http.MethodDelete, "/api/projects/registry?project=ProjA", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE = %d, want 204", rec.Code)
+	}
+	if rec := request(http.MethodGet, "/api/projects/registry", ""); rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Fatalf("GET after delete = %d %s, want an empty list", rec.Code, rec.Body.String())
+	}
+}
+
+func TestGuardedEndpointRequiresTokenAndLimitsRate(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	cfg := &config.Config{}
+	cfg.Web.Guard = config.WebGuard{RateLimit: 6, Burst: 1, ConfirmTokens: true, TokenTTL: time.Minute}
+	server := &Server{cfg: cfg, nowFunc: func() time.Time { return now }}
+
+	var calls int
+	handler := server.guarded(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusAccepted)
+	})
+	confirm := func(endpoint string) string {
+		rec := httptest.NewRecorder()
+		server.handleConfirm(rec, httptest.NewRequest(http.MethodPost, confirmPath, strings.NewReader(`{"endpoint":"`+endpoint+`"}`)))
+		var body struct {
+			Token string `json:"token"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Token == "" {
+			t.Fatalf("confirm %s: status %d, token %q, err %v", endpoint, rec.Code, body.Token, err)
+		}
+		return body.Token
+	}
+	shutdown := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/host/shutdown", nil)
+		if token != "" {
+			req.Header.Set(confirmTokenHeader, token)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec.Code
+	}
+
+	if code := shutdown(""); code != http.StatusPreconditionRequired {
+		t.Fatalf("without token: status %d, want 428", code)
+	}
+	now = now.Add(10 * time.Second)
+	if code := shutdown(confirm("/api/service/restart")); code != http.StatusPreconditionRequired {
+		t.Fatalf("token of another endpoint: status %d, want 428", code)
+	}
+
+	now = now.Add(10 * time.Second)
+	token := confirm("/api/host/shutdown")
+	if code := shutdown(token); code != http.StatusAccepted {
+		t.Fatalf("with token: status %d, want 202", code)
+	}
+	// A double-click: the second request comes too soon.
+	if code := shutdown(confirm("/api/host/shutdown")); code != http.StatusTooManyRequests {
+		t.Fatalf("repeated request: status %d, want 429", code)
+	}
+	now = now.Add(10 * time.Second)
+	if code := shutdown(token); code != http.StatusPreconditionRequired {
+		t.Fatalf("reused token: status %d, want 428", code)
+	}
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+
+	rec := httptest.NewRecorder()
+	server.handleConfirm(rec, httptest.NewRequest(http.MethodPost, confirmPath, strings.NewReader(`{"endpoint":"/api/status"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("confirm for unguarded endpoint: status %d, want 400", rec.Code)
+	}
+}
+
+func TestStatusSnapshotCollectsTheRuntimePicture(t *testing.T) {
+	store, err := state.New(filepath.Join(t.TempDir(), "state.db"), "ucxsync-test")
+	if err != nil {
+		t.Fatalf("state.New() error = %v", err)
+	}
+	defer store.Close()
+	for i := 0; i < snapshotHistory+5; i++ {
+		if err := store.RecordSyncEvent(models.SyncEvent{Type: models.SyncEventFinished, Timestamp: time.Now(), Project: "Arh2k"}); err != nil {
+			t.Fatalf("RecordSyncEvent() error = %v", err)
+		}
+	}
+
+	server := &Server{
+		stateStore:    store,
+		getStatusFunc: func() models.SyncStatus { return models.SyncStatus{IsRunning: true, Project: "Arh2k"} },
+		checkSharesAvailability: func() []syncService.UnavailableShare {
+			return []syncService.UnavailableShare{{Node: "WU03", Share: "F$", Path: "/ucmount/WU03/F"}}
+		},
+	}
+
+	rec := httptest.NewRecorder()
+	server.handleStatusSnapshot(rec, httptest.NewRequest(http.MethodGet, "/api/status/snapshot", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	var snapshot models.StatusSnapshot
+	if err := json.NewDecoder(rec.Body).Decode(&snapshot); err != nil {
+		t.Fatalf("decode snapshot: %v", err)
+	}
+	if !snapshot.Status.IsRunning || snapshot.Status.Project != "Arh2k" {
+		t.Fatalf("status = %+v", snapshot.Status)
+	}
+	if len(snapshot.Events) != snapshotHistory {
+		t.Fatalf("events = %d, want the last %d", len(snapshot.Events), snapshotHistory)
+	}
+	if len(snapshot.UnavailableShares) != 1 || snapshot.UnavailableShares[0].Node != "WU03" {
+		t.Fatalf("unavailable shares = %+v", snapshot.UnavailableShares)
+	}
+	if snapshot.Alerts == nil || snapshot.Mounts == nil {
+		t.Fatalf("alerts and mounts must be present even when empty: %+v", snapshot)
+	}
+
+	procMounts := strings.Join([]string{
+		"/dev/sda1 / ext4 rw 0 0",
+		"//wu01/E$ /ucmount/WU01/E cifs rw 0 0",
+		"/dev/sdb1 /ucdata exfat rw 0 0",
+		"/dev/sdc1 /media/usb\\040disk ext4 rw 0 0",
+		"proc /proc proc rw 0 0",
+	}, "\n")
+	mounts := snapshotMounts(procMounts, "/ucmount", "/media/usb disk/Arh2k")
+	want := []models.MountEntry{
+		{Kind: "share", Device: "//wu01/E$", MountPoint: "/ucmount/WU01/E", FSType: "cifs"},
+		{Kind: "destination", Device: "/dev/sdb1", MountPoint: "/ucdata", FSType: "exfat"},
+		{Kind: "destination", Device: "/dev/sdc1", MountPoint: "/media/usb disk", FSType: "ext4"},
+	}
+	if len(mounts) != len(want) {
+		t.Fatalf("mounts = %+v, want %+v", mounts, want)
+	}
+	for i := range want {
+		if mounts[i] != want[i] {
+			t.Fatalf("mounts[%d] = %+v, want %+v", i, mounts[i], want[i])
+		}
+	}
+}
+
+func TestWebSocketOpensWithHelloAndAnswersResync(t *testing.T) {
+	t.Parallel()
+
+	server := &Server{
+		monService:    monitor.New(time.Second, 1, 100, 0),
+		getStatusFunc: func() models.SyncStatus { return models.SyncStatus{IsRunning: true, Project: "Arh2k"} },
+		clients:       make(map[*websocket.Conn]bool),
+	}
+	server.SetVersion("1.2.3")
+	server.broadcast(models.WSMessage{Type: models.WSLog, Payload: models.LogMessage{Message: "before"}})
+
+	httpServer := httptest.NewServer(http.HandlerFunc(server.handleWebSocket))
+	defer httpServer.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	read := func() (models.WSMessage, json.RawMessage) {
+		t.Helper()
+		var msg struct {
+			models.WSMessage
+			Payload json.RawMessage `json:"payload"`
+		}
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return msg.WSMessage, msg.Payload
+	}
+
+	msg, payload := read()
+	var hello models.WSHelloPayload
+	if err := json.Unmarshal(payload, &hello); msg.Type != models.WSHello || err != nil {
+		t.Fatalf("first message = %s (%v), want hello", msg.Type, err)
+	}
+	if hello.Protocol != models.WSProtocolVersion || hello.ServerVersion != "1.2.3" || hello.LastID != 1 {
+		t.Fatalf("hello = %+v, want protocol %d, version 1.2.3 and last id 1", hello, models.WSProtocolVersion)
+	}
+	if msg, _ := read(); msg.Type != models.WSStatus {
+		t.Fatalf("second message = %s, want status", msg.Type)
+	}
+	if msg, _ := read(); msg.Type != models.WSMetrics {
+		t.Fatalf("third message = %s, want metrics", msg.Type)
+	}
+
+	conn.WriteJSON(models.WSClientMessage{Type: models.WSClientHello, Payload: json.RawMessage(`{"protocol":1}`)})
+	conn.WriteJSON(models.WSClientMessage{Type: "unknown"})
+	conn.WriteJSON(models.WSClientMessage{Type: models.WSClientResync})
+	msg, payload = read()
+	var status models.SyncStatus
+	if err := json.Unmarshal(payload, &status); msg.Type != models.WSStatus || err != nil || status.Project != "Arh2k" {
+		t.Fatalf("resync answer = %s %s (%v), want the full status", msg.Type, payload, err)
+	}
+
+	server.broadcast(models.WSMessage{Type: models.WSLog, Payload: models.LogMessage{Message: "after"}})
+	if msg, _ := read(); msg.Type != models.WSLog || msg.ID != 2 {
+		t.Fatalf("broadcast = %+v, want log numbered 2", msg)
+	}
+}
+
+func TestSensorStatsDefaultsToTheRunningProject(t *testing.T) {
+	t.Parallel()
+
+	var asked string
+	server := &Server{
+		getStatusFunc: func() models.SyncStatus { return models.SyncStatus{IsRunning: true, Project: "Arh2k"} },
+		sensorStatsFunc: func(project string) (models.SensorStatsReport, error) {
+			asked = project
+			return models.SensorStatsReport{Project: project, Sensors: []models.SensorStats{{SensorCode: "00-00", Files: 2}}}, nil
+		},
+	}
+
+	rec := httptest.NewRecorder()
+	server.handleSensorStats(rec, httptest.NewRequest(http.MethodGet, "/api/stats/sensors", nil))
+	if rec.Code != http.StatusOK || asked != "Arh2k" {
+		t.Fatalf("status = %d, project = %q, want 200 for Arh2k", rec.Code, asked)
+	}
+	var report models.SensorStatsReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil || len(report.Sensors) != 1 {
+		t.Fatalf("report = %+v, %v", report, err)
+	}
+
+	server.sensorStatsFunc = func(string) (models.SensorStatsReport, error) {
+		return models.SensorStatsReport{}, syncService.ErrNoStateStore
+	}
+	rec = httptest.NewRecorder()
+	server.handleSensorStats(rec, httptest.NewRequest(http.MethodGet, "/api/stats/sensors?project=Other", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status without state store = %d, want 503", rec.Code)
+	}
+}
+
+func TestHandleRotateCredentialsReportsRemountedShares(t *testing.T) {
+	t.Parallel()
+
+	var gotCurrent, gotPassword string
+	server := &Server{
+		rotateCredentialsFunc: func(current, username, password string) ([]network.RemountResult, error) {
+			gotCurrent, gotPassword = current, password
+			return []network.RemountResult{
+				{Node: "WU01", Share: "E$"},
+				{Node: "WU02", Share: "E$", Err: errors.New("host is down")},
+			}, nil
+		},
+	}
+	body := strings.NewReader(`{"current_password":"old","password":"new"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/shares/credentials", body)
+	resp := httptest.NewRecorder()
+
+	server.handleRotateCredentials(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", resp.Code, resp.Body.String())
+	}
+	if gotCurrent != "old" || gotPassword != "new" {
+		t.Fatalf("rotated with current %q, new %q", gotCurrent, gotPassword)
+	}
+	var rotation models.CredentialRotation
+	if err := json.NewDecoder(resp.Body).Decode(&rotation); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if rotation.Remounted != 1 || len(rotation.Shares) != 2 || rotation.Shares[1].Error != "host is down" {
+		t.Fatalf("rotation = %+v, want one of two shares remounted", rotation)
+	}
+	if strings.Contains(resp.Body.String(), "new") {
+		t.Fatalf("response %s echoes the password", resp.Body.String())
+	}
+}
+
+func TestHandleRotateCredentialsRejectsWrongCurrentPassword(t *testing.T) {
+	t.Parallel()
+
+	server := &Server{
+		rotateCredentialsFunc: func(string, string, string) ([]network.RemountResult, error) {
+			return nil, network.ErrWrongPassword
+		},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/shares/credentials", strings.NewReader(`{"current_password":"guess","password":"new"}`))
+	resp := httptest.NewRecorder()
+
+	server.handleRotateCredentials(resp, req)
+
+	if resp.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", resp.Code)
+	}
+}
+
+func TestParseLsblkDevicesMarksEncryptedDevices(t *testing.T) {
+	t.Parallel()
+
+	output := []byte(`{
+	  "blockdevices": [
+	    {"name":"sdb","size":1000204886016,"fstype":null,"type":"disk","rm":true,"tran":"usb",
+	      "children":[{"name":"sdb1","size":1000203837440,"fstype":"crypto_LUKS","uuid":"5c1e","type":"part",
+	        "children":[{"name":"ucx-sdb1","size":1000187060224,"fstype":"ext4","type":"crypt","mountpoint":"/ucdata"}]}]},
+	    {"name":"sdc","size":1000204886016,"fstype":null,"type":"disk","rm":true,"tran":"usb",
+	      "children":[{"name":"sdc1","size":1000203837440,"fstype":"crypto_LUKS","uuid":"7d2f","type":"part"}]},
+	    {"name":"nvme0n1","size":512110190592,"fstype":null,"type":"disk","rm":false,
+	      "children":[{"name":"nvme0n1p2","size":511000000000,"fstype":"crypto_LUKS","type":"part",
+	        "children":[{"name":"dm_crypt-0","size":510983000000,"fstype":"ext4","type":"crypt","mountpoint":"/"}]}]}
+	  ]
+	}`)
+
+	devices, err := parseLsblkDevices(output, "/ucmount")
+	if err != nil {
+		t.Fatalf("parseLsblkDevices() error = %v", err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("devices = %+v, want the two USB disks (encrypted root skipped)", devices)
+	}
+	byName := map[string]models.BlockDeviceInfo{}
+	for _, dev := range devices {
+		byName[dev.DeviceName] = dev
+	}
+	if dev := byName["sdb1"]; !dev.Encrypted || !dev.Unlocked || !dev.IsMounted || dev.MountPoint != "/ucdata" {
+		t.Fatalf("sdb1 = %+v, want encrypted, unlocked and mounted at /ucdata", dev)
+	}
+	if dev := byName["sdc1"]; !dev.Encrypted || dev.Unlocked || dev.IsMounted {
+		t.Fatalf("sdc1 = %+v, want encrypted and locked", dev)
+	}
+}
+
+func TestLUKSMapperNamesRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	mapper := luksMapperPath("/dev/mapper", "/dev/sdb1")
+	if mapper != "/dev/mapper/ucx-sdb1" {
+		t.Fatalf("luksMapperPath() = %q, want /dev/mapper/ucx-sdb1", mapper)
+	}
+	if got := luksBackingDevice("/dev/mapper", mapper); got != "/dev/sdb1" {
+		t.Fatalf("luksBackingDevice(%q) = %q, want /dev/sdb1", mapper, got)
+	}
+	for _, other := range []string{"/dev/sdb1", "/dev/mapper/vg-root", "/dev/mapper/ucx-"} {
+		if got := luksBackingDevice("/dev/mapper", other); got != "" {
+			t.Fatalf("luksBackingDevice(%q) = %q, want none", other, got)
+		}
+	}
+}
+
+func TestPublicStatusPageShowsHeadlineOnly(t *testing.T) {
+	t.Parallel()
+
+	store, err := state.New(filepath.Join(t.TempDir(), "state.db"), "ucxsync-test")
+	if err != nil {
+		t.Fatalf("open state store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	raisedAt := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	if _, err := store.RaiseAlert(models.Alert{Type: models.SyncEventTaskStalled, Severity: "warning", Title: "Task stalled", Message: "/ucmount/WU01/E stalled", LastSeenAt: raisedAt}); err != nil {
+		t.Fatalf("RaiseAlert: %v", err)
+	}
+
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.Local)
+	server := &Server{
+		stateStore: store,
+		messages:   i18n.New(i18n.English),
+		nowFunc:    func() time.Time { return now },
+		getStatusFunc: func() models.SyncStatus {
+			return models.SyncStatus{
+				IsRunning:         true,
+				Project:           "ProjA",
+				Destination:       "/ucdata",
+				CompletedCaptures: 42,
+				LastCaptureNumber: "00042",
+				DiskForecast:      &models.DiskSpaceForecast{RemainingBytes: 3600 << 20, ThroughputBytesPerSec: 1 << 20},
+			}
+		},
+	}
+	handler := server.publicHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status.json", nil))
+	var status models.PublicStatus
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatalf("decode status: %v", err)
+	}
+	if status.State != "running" || status.CompletedCaptures != 42 || status.ETA == nil || !status.ETA.Equal(now.Add(time.Hour)) {
+		t.Fatalf("public status = %+v, want running with 42 captures and an ETA in an hour", status)
+	}
+	if len(status.Alerts) != 1 || status.Alerts[0].Title != "Task stalled" {
+		t.Fatalf("alerts = %+v, want the stalled task", status.Alerts)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	page := rec.Body.String()
+	for _, want := range []string{`lang="en"`, "Copying", "42", "00042", "13:00", "Task stalled"} {
+		if !strings.Contains(page, want) {
+			t.Fatalf("page missing %q:\n%s", want, page)
+		}
+	}
+	for _, leak := range []string{"/ucdata", "/ucmount", "<script", "<button"} {
+		if strings.Contains(page, leak) {
+			t.Fatalf("page exposes %q", leak)
+		}
+	}
+
+	for _, path := range []string{"/api/status", "/api/sync/stop", "/static/js/app.js"} {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("%s status = %d, want 404 on the public listener", path, rec.Code)
+		}
+	}
+}
+
+func TestPrometheusMetricsUseTheTextFormat(t *testing.T) {
+	t.Parallel()
+
+	server := &Server{
+		monService:  monitor.New(time.Second, 1, 100, 0),
+		syncService: syncService.New([]string{"CU"}, []string{"E$"}, "/ucmount"),
+	}
+	rec := httptest.NewRecorder()
+	server.handlePrometheusMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Fatalf("Content-Type = %q, want the Prometheus text format", got)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE ucxsync_cpu_percent gauge\n",
+		"# TYPE ucxsync_sync_running gauge\nucxsync_sync_running 0\n",
+		"ucxsync_queued_files 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("metrics missing %q:\n%s", want, body)
+		}
+	}
+
+	var out bytes.Buffer
+	w := bufio.NewWriter(&out)
+	promWriter{w}.sample("ucxsync_node_copies_total", "node", "W\"U\\01\n", 3)
+	w.Flush()
+	if got, want := out.String(), `ucxsync_node_copies_total{node="W\"U\\01\n"} 3`+"\n"; got != want {
+		t.Fatalf("sample = %q, want %q", got, want)
+	}
+}
+
+func TestMountChangeRediscoversProjects(t *testing.T) {
+	t.Parallel()
+
+	var wu02Mounted atomic.Bool
+	scans := 0
+	server := newPreflightTestServer(models.SyncStatus{}, func(s *Server) {
+		s.messages = i18n.New(i18n.English)
+		s.cfg.Sync.DiscoveryInterval = time.Hour
+		s.checkSharesAvailability = func() []syncService.UnavailableShare {
+			if wu02Mounted.Load() {
+				return nil
+			}
+			return []syncService.UnavailableShare{{Node: "WU02", Share: "E$", Path: "/ucmount/WU02/E"}}
+		}
+		s.findProjectsFunc = func(context.Context) ([]models.ProjectInfo, error) {
+			scans++
+			if wu02Mounted.Load() {
+				return []models.ProjectInfo{{Name: "ProjA", Source: "WU01/E$"}, {Name: "ProjB", Source: "WU02/E$"}}, nil
+			}
+			return []models.ProjectInfo{{Name: "ProjA", Source: "WU01/E$"}}, nil
+		}
+	})
+	if _, _, err := server.discoverProjects(context.Background(), false); err != nil {
+		t.Fatalf("discoverProjects() error = %v", err)
+	}
+
+	server.noteShareMounts(context.Background())
+	server.noteShareMounts(context.Background())
+	if scans != 1 {
+		t.Fatalf("scans = %d without a mount change, want only the first discovery", scans)
+	}
+
+	wu02Mounted.Store(true)
+	server.noteShareMounts(context.Background())
+	projects, _, _ := server.discoverProjects(context.Background(), false)
+	if scans != 2 || len(projects) != 2 || projects[1].Name != "ProjB" {
+		t.Fatalf("scans = %d, projects = %+v; want ProjB discovered once WU02 was mounted", scans, projects)
+	}
+
+	wu02Mounted.Store(false)
+	server.noteShareMounts(context.Background())
+	if projects, _, _ := server.discoverProjects(context.Background(), false); scans != 3 || len(projects) != 1 {
+		t.Fatalf("scans = %d, projects = %+v; want ProjB gone with its share", scans, projects)
+	}
+}
+
+func TestSysfsBlockDevicesStandInForLsblk(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	roots := sysfsRoots{
+		SysBlock: filepath.Join(root, "sys", "block"),
+		DevDisk:  filepath.Join(root, "dev", "disk"),
+		Dev:      filepath.Join(root, "dev"),
+	}
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := func(kind, name, target string) {
+		t.Helper()
+		dir := filepath.Join(roots.DevDisk, kind)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink("../../"+target, filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// sdb: USB disk with an open LUKS partition mounted at /ucdata.
+	write(filepath.Join(roots.SysBlock, "sdb", "removable"), "1\n")
+	write(filepath.Join(roots.SysBlock, "sdb", "size"), "1953525168\n")
+	write(filepath.Join(roots.SysBlock, "sdb", "device", "model"), "Portable SSD \n")
+	write(filepath.Join(roots.SysBlock, "sdb", "sdb1", "partition"), "1\n")
+	write(filepath.Join(roots.SysBlock, "sdb", "sdb1", "size"), "1953523120\n")
+	write(filepath.Join(roots.SysBlock, "sdb", "sdb1", "holders", "dm-0"), "")
+	write(filepath.Join(roots.SysBlock, "dm-0", "dm", "uuid"), "CRYPT-LUKS2-5c1e-ucx-sdb1\n")
+	write(filepath.Join(roots.SysBlock, "dm-0", "dm", "name"), "ucx-sdb1\n")
+	write(filepath.Join(roots.Dev, "sdb1"), "LUKS\xba\xbe")
+	// sdc: unmounted exFAT partition known only from its superblock.
+	write(filepath.Join(roots.SysBlock, "sdc", "removable"), "1\n")
+	write(filepath.Join(roots.SysBlock, "sdc", "sdc1", "partition"), "1\n")
+	write(filepath.Join(roots.SysBlock, "sdc", "sdc1", "size"), "2048\n")
+	write(filepath.Join(roots.Dev, "sdc1"), "\xeb\x76\x90EXFAT   ")
+	link("by-uuid", "64A5-F009", "sdc1")
+	link("by-label", `FLIGHT\x20DATA`, "sdc1")
+	write(filepath.Join(roots.SysBlock, "loop0", "size"), "100\n")
+
+	procMounts := "/dev/vda1 / ext4 rw 0 0\n/dev/mapper/ucx-sdb1 /ucdata ext4 rw 0 0\n"
+	blockDevices, err := sysfsBlockDevices(roots, procMounts)
+	if err != nil {
+		t.Fatalf("sysfsBlockDevices() error = %v", err)
+	}
+	devices := destinationCandidates(blockDevices, "/ucmount")
+	if len(devices) != 2 {
+		t.Fatalf("devices = %+v, want sdb1 and sdc1", devices)
+	}
+	byName := map[string]models.BlockDeviceInfo{}
+	for _, dev := range devices {
+		byName[dev.DeviceName] = dev
+	}
+	if dev := byName["sdb1"]; !dev.Encrypted || !dev.Unlocked || dev.MountPoint != "/ucdata" || dev.SizeBytes != 1953523120*512 || dev.Model != "Portable SSD" || !dev.IsRemovable {
+		t.Fatalf("sdb1 = %+v, want the unlocked LUKS partition mounted at /ucdata", dev)
+	}
+	if dev := byName["sdc1"]; dev.FSType != "exfat" || dev.UUID != "64A5-F009" || !strings.HasPrefix(dev.Label, "FLIGHT DATA") || dev.IsMounted {
+		t.Fatalf("sdc1 = %+v, want the unmounted exFAT partition with its udev label", dev)
+	}
+
+	if probe := sysfsProbeDevice(roots, procMounts, filepath.Join(roots.Dev, "sdc1")); probe.FSType != "exfat" || probe.UUID != "64A5-F009" || probe.Label != "FLIGHT DATA" {
+		t.Fatalf("probe = %+v, want exfat with its UUID and label", probe)
+	}
+}
+
+func TestFilesystemFromSuperblockTellsExtVersions(t *testing.T) {
+	t.Parallel()
+
+	superblock := func(compat, incompat uint32) []byte {
+		buf := make([]byte, 2048)
+		buf[1080], buf[1081] = 0x53, 0xef
+		binary.LittleEndian.PutUint32(buf[1024+0x5c:], compat)
+		binary.LittleEndian.PutUint32(buf[1024+0x60:], incompat)
+		return buf
+	}
+	for _, tc := range []struct {
+		buf  []byte
+		want string
+	}{
+		{superblock(0x4, 0x2c0), "ext4"},
+		{superblock(0x4, 0x2), "ext3"},
+		{superblock(0, 0), "ext2"},
+		{make([]byte, 2048), ""},
+		{nil, ""},
+	} {
+		if got := filesystemFromSuperblock(tc.buf); got != tc.want {
+			t.Fatalf("filesystemFromSuperblock() = %q, want %q", got, tc.want)
+		}
+	}
+}
+
+func TestJobNotesAttachToTheRunningJobAndReachTheReport(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := state.New(filepath.Join(dir, "state.db"), "ucxsync-test")
+	if err != nil {
+		t.Fatalf("state.New returned error: %v", err)
+	}
+	defer store.Close()
+	destination := filepath.Join(dir, "ucdata")
+	reportPath := report.DefaultPath(destination, "ProjA")
+	if err := report.WriteJSON(reportPath, report.Build("ProjA", nil, report.Options{})); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	const job = "ProjA-20261016T080000Z-abcdef"
+	server := newPreflightTestServer(models.SyncStatus{IsRunning: true, Project: "ProjA", Destination: destination, JobID: job}, func(s *Server) {
+		s.stateStore = store
+	})
+
+	request := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.handleJobNotes(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := request(http.MethodPost, "/api/jobs/notes", `{"text":"  Flight 2, cirrus over lines 7-9 "}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST = %d %s", rec.Code, rec.Body.String())
+	}
+	var saved models.JobNote
+	if err := json.NewDecoder(rec.Body).Decode(&saved); err != nil || saved.JobID != job || saved.Project != "ProjA" || saved.Text != "Flight 2, cirrus over lines 7-9" {
+		t.Fatalf("saved = %+v, %v, want the note on the running job", saved, err)
+	}
+	if rec := request(http.MethodPost, "/api/jobs/notes", `{"text":"  "}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("POST without text = %d, want 400", rec.Code)
+	}
+
+	rec = request(http.MethodGet, "/api/jobs/notes", "")
+	var notes []models.JobNote
+	if err := json.NewDecoder(rec.Body).Decode(&notes); err != nil || len(notes) != 1 || notes[0].ID != saved.ID {
+		t.Fatalf("GET = %+v, %v, want the note", notes, err)
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	var refreshed report.DestinationReport
+	if err := json.Unmarshal(data, &refreshed); err != nil || len(refreshed.Notes) != 1 || refreshed.Notes[0].Text != saved.Text {
+		t.Fatalf("report = %s, %v, want the note embedded", data, err)
+	}
+
+	if rec := request(http.MethodDelete, "/api/jobs/notes?id="+strconv.FormatInt(saved.ID, 10), ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE = %d, want 204", rec.Code)
+	}
+	if rec := request(http.MethodGet, "/api/jobs/notes?project=ProjA", ""); strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Fatalf("GET after delete = %s, want an empty list", rec.Body.String())
+	}
+}
+
+func TestHandleNodePausePausesAndResumesOneNode(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	svc := syncService.New([]string{"WU01", "WU02"}, []string{"E$"}, filepath.Join(dir, "mnt"))
+	running := false
+	server := newPreflightTestServer(models.SyncStatus{}, func(s *Server) {
+		s.syncService = svc
+		s.getStatusFunc = func() models.SyncStatus { return models.SyncStatus{IsRunning: running} }
+	})
+
+	post := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.handleNodePause(rec, httptest.NewRequest(http.MethodPost, path, nil))
+		return rec
+	}
+
+	if rec := post("/api/nodes/WU02/pause"); rec.Code != http.StatusConflict {
+		t.Fatalf("pause without a job = %d, want 409", rec.Code)
+	}
+
+	if err := svc.Start(context.Background(), "ProjA", filepath.Join(dir, "dest"), 1, false); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	defer svc.Stop()
+	running = true
+
+	rec := post("/api/nodes/wu02/pause")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("pause = %d %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Node        string   `json:"node"`
+		Paused      bool     `json:"paused"`
+		PausedNodes []string `json:"paused_nodes"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Node != "WU02" || !resp.Paused || len(resp.PausedNodes) != 1 || resp.PausedNodes[0] != "WU02" {
+		t.Fatalf("pause response = %+v, %v, want WU02 paused", resp, err)
+	}
+	if rec := post("/api/nodes/WU77/pause"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown node = %d, want 400", rec.Code)
+	}
+	if rec := post("/api/nodes/WU02/reboot"); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown action = %d, want 404", rec.Code)
+	}
+
+	rec = post("/api/nodes/WU02/resume")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("resume = %d %s", rec.Code, rec.Body.String())
+	}
+	if nodes := svc.PausedNodes(); len(nodes) != 0 {
+		t.Fatalf("PausedNodes after resume = %v, want none", nodes)
+	}
+}
@@ -0,0 +1,39 @@
+package web
+
+import (
+	"path/filepath"
+
+	"github.com/zangezia/UCXSync/internal/config"
+	syncService "github.com/zangezia/UCXSync/internal/sync"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// SimulatedDestination is the destination offered in simulation mode.
+func SimulatedDestination(cfg *config.Config) string {
+	return filepath.Join(cfg.Simulate.Root, "destination")
+}
+
+// enableSimulation replaces share mounting and discovery with no-ops so the
+// locally generated shares under the mount root are used as-is.
+func (s *Server) enableSimulation(svc *syncService.Service) {
+	s.simulated = true
+	svc.SetMountPointChecker(func(string) (bool, error) { return true, nil })
+
+	s.mountSharesFunc = func() error { return nil }
+	s.checkNetworkRequirements = func() error { return nil }
+
+	destination := SimulatedDestination(s.cfg)
+	s.getDestinationsFunc = func() []models.DestinationInfo {
+		simulated := models.DestinationInfo{
+			Path:      destination,
+			Label:     "Simulation",
+			Type:      "local",
+			IsDefault: true,
+		}
+		if freeGB, totalGB, err := getDiskSpace(destination); err == nil {
+			simulated.FreeSpaceGB = freeGB
+			simulated.TotalGB = totalGB
+		}
+		return append([]models.DestinationInfo{simulated}, s.getAvailableDestinations()...)
+	}
+}
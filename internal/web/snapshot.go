@@ -0,0 +1,139 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	syncService "github.com/zangezia/UCXSync/internal/sync"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// snapshotHistory is how many alerts and events a snapshot carries.
+const snapshotHistory = 100
+
+// handleStatusSnapshot returns the status, metrics, mounts, alerts and recent
+// events as one JSON document for trouble reports. Parts that cannot be
+// collected are listed under errors instead of failing the snapshot.
+func (s *Server) handleStatusSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	snapshot := s.statusSnapshot()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="ucxsync-snapshot-%s.json"`, snapshot.GeneratedAt.Format("20060102-150405")))
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(snapshot)
+}
+
+func (s *Server) statusSnapshot() models.StatusSnapshot {
+	snapshot := models.StatusSnapshot{
+		GeneratedAt:       s.hostNow().UTC(),
+		Status:            s.currentSyncStatus(),
+		Mounts:            []models.MountEntry{},
+		UnavailableShares: []models.PreflightUnavailableShare{},
+		Alerts:            []models.Alert{},
+		Events:            []models.SyncEvent{},
+	}
+	snapshot.Hostname, _ = os.Hostname()
+
+	if s.monService != nil {
+		metrics := s.currentMetrics()
+		snapshot.Metrics = &metrics
+	}
+
+	if data, err := os.ReadFile("/proc/mounts"); err != nil {
+		snapshot.Errors = append(snapshot.Errors, fmt.Sprintf("mounts: %v", err))
+	} else {
+		mountRoot := ""
+		if s.cfg != nil {
+			mountRoot = s.cfg.Network.MountRoot
+		}
+		snapshot.Mounts = snapshotMounts(string(data), mountRoot, snapshot.Status.Destination)
+	}
+
+	for _, share := range s.getUnavailableShares() {
+		snapshot.UnavailableShares = append(snapshot.UnavailableShares, models.PreflightUnavailableShare{Node: share.Node, Share: share.Share, Path: share.Path})
+	}
+
+	if s.stateStore == nil {
+		snapshot.Errors = append(snapshot.Errors, "alerts, events: state store not available")
+		return snapshot
+	}
+	if alerts, err := s.stateStore.ListAlerts(true, snapshotHistory); err != nil {
+		snapshot.Errors = append(snapshot.Errors, fmt.Sprintf("alerts: %v", err))
+	} else if alerts != nil {
+		snapshot.Alerts = alerts
+	}
+	if events, err := s.stateStore.ListSyncEvents(snapshotHistory); err != nil {
+		snapshot.Errors = append(snapshot.Errors, fmt.Sprintf("events: %v", err))
+	} else if events != nil {
+		snapshot.Events = events
+	}
+	return snapshot
+}
+
+// snapshotMounts picks the camera shares under mountRoot and the data disks
+// (managed data mounts and the one holding destination) out of /proc/mounts.
+func snapshotMounts(procMounts, mountRoot, destination string) []models.MountEntry {
+	destinationMount := ""
+	if destination != "" {
+		destinationMount = longestMountFor(procMounts, destination)
+	}
+
+	mounts := []models.MountEntry{}
+	for _, line := range strings.Split(procMounts, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		mountPoint := unescapeMountPoint(fields[1])
+
+		kind := ""
+		switch {
+		case mountRoot != "" && strings.HasPrefix(mountPoint, path.Clean(mountRoot)+"/"):
+			kind = "share"
+		case mountPoint == destinationMount && mountPoint != "/":
+			kind = "destination"
+		default:
+			if managed, ok := syncService.ManagedMountPoint(mountPoint); ok && managed == mountPoint {
+				kind = "destination"
+			}
+		}
+		if kind == "" {
+			continue
+		}
+		mounts = append(mounts, models.MountEntry{Kind: kind, Device: fields[0], MountPoint: mountPoint, FSType: fields[2]})
+	}
+	return mounts
+}
+
+// longestMountFor returns the mount point in procMounts that holds target.
+func longestMountFor(procMounts, target string) string {
+	clean := path.Clean(target)
+	best := ""
+	for _, line := range strings.Split(procMounts, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		mountPoint := unescapeMountPoint(fields[1])
+		if (mountPoint == "/" || clean == mountPoint || strings.HasPrefix(clean, mountPoint+"/")) && len(mountPoint) > len(best) {
+			best = mountPoint
+		}
+	}
+	return best
+}
+
+// unescapeMountPoint decodes the octal escapes /proc/mounts uses for spaces
+// and other special characters.
+func unescapeMountPoint(field string) string {
+	return strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`).Replace(field)
+}
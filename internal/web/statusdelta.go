@@ -0,0 +1,140 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// statusKeyframeEvery is how many status ticks pass between full statuses. A
+// client that missed a delta catches up with the next one.
+const statusKeyframeEvery = 15
+
+// statusStream remembers the last status broadcast so the next tick only
+// sends what changed. mu also serialises sequence numbers.
+type statusStream struct {
+	mu            sync.Mutex
+	seq           uint64
+	last          *models.SyncStatus
+	fields        map[string]json.RawMessage
+	tasks         map[string]json.RawMessage
+	order         []string
+	sinceKeyframe int
+}
+
+// statusMessage returns the WebSocket message for status: a full "status"
+// when nothing was sent yet or a keyframe is due, otherwise a "status_delta"
+// against the last one. ok is false when nothing changed.
+func (s *Server) statusMessage(status models.SyncStatus) (msg models.WSMessage, ok bool) {
+	fields, tasks, order, err := splitStatus(status)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to encode status delta")
+		return models.WSMessage{Type: "status", Payload: status}, true
+	}
+
+	st := &s.statusStream
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.last == nil || st.sinceKeyframe+1 >= statusKeyframeEvery {
+		st.remember(status, fields, tasks, order)
+		st.sinceKeyframe = 0
+		return models.WSMessage{Type: "status", Payload: status, Seq: st.seq}, true
+	}
+
+	delta := models.StatusDelta{Base: st.seq, Fields: make(map[string]json.RawMessage)}
+	for name, value := range fields {
+		if !bytes.Equal(st.fields[name], value) {
+			delta.Fields[name] = value
+		}
+	}
+	for name := range st.fields {
+		if _, ok := fields[name]; !ok {
+			delta.Fields[name] = json.RawMessage("null")
+		}
+	}
+	for i, key := range order {
+		if !bytes.Equal(st.tasks[key], tasks[key]) {
+			delta.Tasks = append(delta.Tasks, status.ActiveTasks[i])
+		}
+	}
+	for _, key := range st.order {
+		if _, ok := tasks[key]; !ok {
+			delta.RemovedTasks = append(delta.RemovedTasks, key)
+		}
+	}
+	if !equalStrings(st.order, order) {
+		delta.TaskOrder = order
+		if delta.TaskOrder == nil {
+			delta.TaskOrder = []string{}
+		}
+	}
+
+	st.sinceKeyframe++
+	if len(delta.Fields) == 0 && len(delta.Tasks) == 0 && len(delta.RemovedTasks) == 0 && delta.TaskOrder == nil {
+		return models.WSMessage{}, false
+	}
+	st.remember(status, fields, tasks, order)
+	return models.WSMessage{Type: "status_delta", Payload: delta, Seq: st.seq}, true
+}
+
+// lastStatusMessage returns the full status the next delta applies to, for a
+// client that just connected, and false before the first broadcast.
+func (s *Server) lastStatusMessage() (models.WSMessage, bool) {
+	st := &s.statusStream
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.last == nil {
+		return models.WSMessage{}, false
+	}
+	return models.WSMessage{Type: "status", Payload: *st.last, Seq: st.seq}, true
+}
+
+func (st *statusStream) remember(status models.SyncStatus, fields, tasks map[string]json.RawMessage, order []string) {
+	st.seq++
+	st.last = &status
+	st.fields = fields
+	st.tasks = tasks
+	st.order = order
+}
+
+// splitStatus encodes the top-level fields of status apart from the task
+// list, and each task under its node/share key.
+func splitStatus(status models.SyncStatus) (fields, tasks map[string]json.RawMessage, order []string, err error) {
+	encoded, err := json.Marshal(status)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, nil, nil, err
+	}
+	delete(fields, "active_tasks")
+
+	tasks = make(map[string]json.RawMessage, len(status.ActiveTasks))
+	for _, task := range status.ActiveTasks {
+		key := task.Node + "/" + task.Share
+		value, err := json.Marshal(task)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		tasks[key] = value
+		order = append(order, key)
+	}
+	return fields, tasks, order, nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/internal/report"
+	"github.com/zangezia/UCXSync/internal/state"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// handleCaptureTags serves /api/captures/{n}/tags?project=: lists the tags
+// of capture n (GET), adds tags (POST {"tags":["cloudy"]}), replaces them
+// (PUT {"tags":[...]}) or removes one (DELETE &tag=re-fly).
+func (s *Server) handleCaptureTags(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/captures/"), "/"), "/")
+	if len(parts) != 2 || parts[1] != "tags" {
+		http.NotFound(w, r)
+		return
+	}
+	if _, ok := models.CaptureNumberValue(parts[0]); !ok {
+		http.Error(w, fmt.Sprintf("Invalid capture number %q", parts[0]), http.StatusBadRequest)
+		return
+	}
+	capture := models.NormalizeCaptureNumber(parts[0])
+
+	project := strings.TrimSpace(r.URL.Query().Get("project"))
+	if project == "" {
+		http.Error(w, "project parameter required", http.StatusBadRequest)
+		return
+	}
+	if s.stateStore == nil {
+		http.Error(w, "State database is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost, http.MethodPut:
+		var req struct {
+			Tags []string `json:"tags"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		tags, err := state.NormalizeCaptureTags(req.Tags)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodPost {
+			err = s.stateStore.AddCaptureTags(project, capture, tags)
+		} else {
+			err = s.stateStore.SetCaptureTags(project, capture, tags)
+		}
+		if err != nil {
+			log.Error().Err(err).Str("project", project).Str("capture", capture).Msg("Failed to save capture tags")
+			http.Error(w, "Failed to save capture tags", http.StatusInternalServerError)
+			return
+		}
+		s.refreshReport(project)
+	case http.MethodDelete:
+		tag, err := state.NormalizeCaptureTag(r.URL.Query().Get("tag"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.stateStore.RemoveCaptureTag(project, capture, tag); err != nil {
+			log.Error().Err(err).Str("project", project).Str("capture", capture).Msg("Failed to remove capture tag")
+			http.Error(w, "Failed to remove capture tag", http.StatusInternalServerError)
+			return
+		}
+		s.refreshReport(project)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	tags, err := s.stateStore.TagsOfCapture(project, capture)
+	if err != nil {
+		log.Error().Err(err).Str("project", project).Str("capture", capture).Msg("Failed to load capture tags")
+		http.Error(w, "Failed to load capture tags", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.CaptureTags{Project: project, Capture: capture, Tags: tags})
+}
+
+// refreshReport rewrites the destination report of project so it shows the
+// current capture tags.
+func (s *Server) refreshReport(project string) {
+	destination := s.currentSyncStatus().Destination
+	if destination == "" && s.cfg != nil {
+		destination = s.cfg.Sync.Destination
+	}
+	if destination == "" {
+		return
+	}
+	if err := report.Refresh(s.stateStore, destination, project); err != nil {
+		log.Warn().Err(err).Str("project", project).Msg("Failed to refresh project report with capture tags")
+	}
+}
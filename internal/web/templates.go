@@ -0,0 +1,104 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// startSyncRequest is the body of POST /api/sync/start.
+type startSyncRequest struct {
+	// Template names a sync.templates entry supplying the fields left empty.
+	Template        string `json:"template"`
+	Project         string `json:"project"`
+	Destination     string `json:"destination"`
+	MaxParallelism  int    `json:"max_parallelism"`
+	ForceFullResync bool   `json:"force_full_resync"`
+	// Overrides sync.completion.actions for this job; [] runs none.
+	CompletionActions *[]string `json:"completion_actions"`
+	// Overrides sync.benchmark.enabled for this job.
+	Benchmark *bool `json:"benchmark"`
+}
+
+// applyJobTemplate fills the fields req leaves empty from its template, then
+// from the sync settings for project and destination.
+func (s *Server) applyJobTemplate(req *startSyncRequest) error {
+	template, ok := s.cfg.Sync.Template(req.Template)
+	if !ok {
+		return fmt.Errorf("unknown job template %q", req.Template)
+	}
+
+	if req.Project == "" {
+		req.Project = template.Project
+	}
+	if req.Project == "" {
+		req.Project = s.cfg.Sync.Project
+	}
+	if req.Destination == "" {
+		req.Destination = template.Destination
+	}
+	if req.Destination == "" {
+		req.Destination = s.cfg.Sync.Destination
+	}
+	if req.MaxParallelism <= 0 {
+		req.MaxParallelism = template.MaxParallelism
+	}
+	req.ForceFullResync = req.ForceFullResync || template.ForceFullResync
+	if req.CompletionActions == nil && template.CompletionActions != nil {
+		actions := append([]string{}, template.CompletionActions...)
+		req.CompletionActions = &actions
+	}
+	if req.Benchmark == nil {
+		req.Benchmark = template.Benchmark
+	}
+
+	return nil
+}
+
+// jobTemplates resolves the configured templates against the sync settings,
+// so the UI can fill the start form from one.
+func (s *Server) jobTemplates() []models.JobTemplate {
+	templates := make([]models.JobTemplate, 0, len(s.cfg.Sync.Templates))
+	for _, template := range s.cfg.Sync.Templates {
+		req := startSyncRequest{Template: template.Name}
+		if err := s.applyJobTemplate(&req); err != nil {
+			continue
+		}
+		resolved := models.JobTemplate{
+			Name:              template.Name,
+			Project:           req.Project,
+			Destination:       req.Destination,
+			MaxParallelism:    req.MaxParallelism,
+			ForceFullResync:   req.ForceFullResync,
+			CompletionActions: s.cfg.Sync.Completion.Actions,
+			Benchmark:         s.cfg.Sync.Benchmark.Enabled,
+		}
+		if resolved.MaxParallelism <= 0 {
+			resolved.MaxParallelism = s.cfg.Sync.MaxParallelism
+		}
+		if req.CompletionActions != nil {
+			resolved.CompletionActions = *req.CompletionActions
+		}
+		if resolved.CompletionActions == nil {
+			resolved.CompletionActions = []string{}
+		}
+		if req.Benchmark != nil {
+			resolved.Benchmark = *req.Benchmark
+		}
+		templates = append(templates, resolved)
+	}
+	return templates
+}
+
+// handleSyncTemplates lists the job templates of sync.templates.
+func (s *Server) handleSyncTemplates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.jobTemplates())
+}
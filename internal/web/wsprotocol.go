@@ -0,0 +1,74 @@
+package web
+
+import (
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+	"github.com/zangezia/UCXSync/pkg/models"
+)
+
+// SetVersion sets the server version the WebSocket hello reports.
+func (s *Server) SetVersion(version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.version = version
+}
+
+// helloMessage opens a WebSocket connection. statusSeq is the Seq of the
+// status sent right after it.
+func (s *Server) helloMessage(statusSeq uint64) models.WSMessage {
+	s.mu.RLock()
+	version := s.version
+	s.mu.RUnlock()
+
+	return models.WSMessage{
+		Type: models.WSHello,
+		Payload: models.WSHelloPayload{
+			Protocol:           models.WSProtocolVersion,
+			MinProtocol:        models.WSMinProtocolVersion,
+			ServerVersion:      version,
+			MessageTypes:       models.WSServerMessageTypes,
+			ClientMessageTypes: models.WSClientMessageTypes,
+			LastID:             atomic.LoadUint64(&s.lastMessageID),
+			StatusSeq:          statusSeq,
+		},
+	}
+}
+
+// nextMessageID numbers a broadcast message.
+func (s *Server) nextMessageID() uint64 {
+	return atomic.AddUint64(&s.lastMessageID, 1)
+}
+
+// handleClientMessage answers a message a client sent on conn. Unknown
+// types are ignored, as the protocol asks of clients too.
+func (s *Server) handleClientMessage(conn *websocket.Conn, data []byte) {
+	var msg models.WSClientMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		log.Debug().Err(err).Msg("Ignoring malformed WebSocket client message")
+		return
+	}
+
+	switch msg.Type {
+	case models.WSClientHello:
+		var hello models.WSClientHelloPayload
+		json.Unmarshal(msg.Payload, &hello)
+		if hello.Protocol < models.WSMinProtocolVersion {
+			log.Warn().Int("protocol", hello.Protocol).Str("client", hello.Client).Msg("WebSocket client speaks an outdated protocol")
+			return
+		}
+		log.Debug().Int("protocol", hello.Protocol).Str("client", hello.Client).Msg("WebSocket client hello")
+	case models.WSClientResync:
+		statusMsg, ok := s.lastStatusMessage()
+		if !ok {
+			statusMsg = models.WSMessage{Type: models.WSStatus, Payload: s.currentSyncStatus()}
+		}
+		// Broadcasts write under the read lock; one writer per connection.
+		s.mu.Lock()
+		s.sendToClient(conn, statusMsg)
+		s.mu.Unlock()
+	}
+}
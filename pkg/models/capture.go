@@ -0,0 +1,63 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CaptureNumberWidth is the zero-padded width capture numbers have in UltraCam
+// file names. Counters past 99999 simply grow wider.
+const CaptureNumberWidth = 5
+
+// NormalizeCaptureNumber returns the canonical form of a capture number: the
+// digits without surplus leading zeros, padded to CaptureNumberWidth. "7",
+// "00007" and "000007" all become "00007"; "123456" stays as it is.
+// Anything that is not a plain number is only trimmed.
+func NormalizeCaptureNumber(raw string) string {
+	raw = strings.TrimSpace(raw)
+	value, ok := CaptureNumberValue(raw)
+	if !ok {
+		return raw
+	}
+	digits := strconv.FormatUint(value, 10)
+	if len(digits) >= CaptureNumberWidth {
+		return digits
+	}
+	return strings.Repeat("0", CaptureNumberWidth-len(digits)) + digits
+}
+
+// CaptureNumberValue parses a capture number, padded or not, as an integer.
+func CaptureNumberValue(number string) (uint64, bool) {
+	number = strings.TrimSpace(number)
+	if number == "" {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(number, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+// CompareCaptureNumbers orders capture numbers by value, so "99999" comes
+// before "100000" and "7" equals "00007". Numbers that do not parse sort
+// after all numeric ones, by string. It returns -1, 0 or +1.
+func CompareCaptureNumbers(a, b string) int {
+	av, aok := CaptureNumberValue(a)
+	bv, bok := CaptureNumberValue(b)
+	switch {
+	case aok && bok:
+		if av < bv {
+			return -1
+		}
+		if av > bv {
+			return 1
+		}
+		return 0
+	case aok:
+		return -1
+	case bok:
+		return 1
+	}
+	return strings.Compare(strings.TrimSpace(a), strings.TrimSpace(b))
+}
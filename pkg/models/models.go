@@ -1,12 +1,16 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // SyncTask represents an active synchronization task
 type SyncTask struct {
 	Node         string    `json:"node"`
 	Share        string    `json:"share"`
-	Status       string    `json:"status"`
+	Status       string    `json:"status"` // One of the TaskState* values
+	Error        string    `json:"error,omitempty"`
 	LastActivity time.Time `json:"last_activity"`
 	TotalFiles   int       `json:"total_files"`
 	CopiedFiles  int       `json:"copied_files"`
@@ -16,8 +20,27 @@ type SyncTask struct {
 	Progress     float64   `json:"progress"`
 }
 
+// Sync task states reported in SyncTask.Status.
+const (
+	TaskStateScanning  = "scanning" // Listing source files and comparing them with the ledger and destination
+	TaskStateCopying   = "copying"
+	TaskStateRetrying  = "retrying"  // Copying, including files whose earlier copy failed
+	TaskStateVerifying = "verifying" // Checking a copied file against its source
+	TaskStateStalled   = "stalled"   // Cancelled by the watchdog; restarted on the next iteration
+	TaskStateDone      = "done"
+	TaskStateFailed    = "failed"
+)
+
+// Capture file kinds reported in CaptureInfo.Kind.
+const (
+	CaptureFileRaw      = "raw"      // Sensor image from a WU node
+	CaptureFileMetadata = "metadata" // EAD XML from the CU node
+	CaptureFileQuality  = "quality"  // RawQv quality data from the CU node
+)
+
 // CaptureInfo holds information about a capture file
 type CaptureInfo struct {
+	Kind          string `json:"kind"`           // One of the CaptureFile* values
 	DataType      string `json:"data_type"`      // Lvl0X (unverified) or Lvl00 (verified)
 	CaptureNumber string `json:"capture_number"` // 00001, 00002, etc.
 	IsTest        bool   `json:"is_test"`        // true if test capture (has "T-" marker)
@@ -29,16 +52,202 @@ type CaptureInfo struct {
 
 // SyncStatus holds overall synchronization status
 type SyncStatus struct {
-	IsRunning             bool       `json:"is_running"`
-	Project               string     `json:"project"`
-	Destination           string     `json:"destination"`
-	MaxParallelism        int        `json:"max_parallelism"`        // Configured limit
-	ActiveFileOperations  int        `json:"active_file_operations"` // Current active file copies
-	CompletedCaptures     int        `json:"completed_captures"`
-	CompletedTestCaptures int        `json:"completed_test_captures"`
-	LastCaptureNumber     string     `json:"last_capture_number"`
-	LastTestCaptureNumber string     `json:"last_test_capture_number"`
-	ActiveTasks           []SyncTask `json:"active_tasks"`
+	IsRunning             bool                  `json:"is_running"`
+	JobID                 string                `json:"job_id,omitempty"` // Identifies the running job, also in the destination heartbeat
+	Project               string                `json:"project"`
+	Destination           string                `json:"destination"`
+	MaxParallelism        int                   `json:"max_parallelism"`        // Configured limit
+	ActiveFileOperations  int                   `json:"active_file_operations"` // Current active file copies
+	CompletedCaptures     int                   `json:"completed_captures"`
+	CompletedTestCaptures int                   `json:"completed_test_captures"`
+	LastCaptureNumber     string                `json:"last_capture_number"`
+	LastTestCaptureNumber string                `json:"last_test_capture_number"`
+	Paused                bool                  `json:"paused"`
+	PauseReason           string                `json:"pause_reason,omitempty"`
+	MaintenanceNodes      []NodeMaintenance     `json:"maintenance_nodes,omitempty"`
+	PausedNodes           []string              `json:"paused_nodes,omitempty"` // Nodes the operator paused while the others continue
+	QuarantinedFiles      int                   `json:"quarantined_files"`
+	QueuedFiles           int                   `json:"queued_files"`              // Found by the scans and not copied yet
+	ScansHeld             bool                  `json:"scans_held,omitempty"`      // Scans wait for the copy queue to drain
+	PendingRetries        int                   `json:"pending_retries,omitempty"` // Failed files waiting in the retry queue
+	Retries               []CopyFailure         `json:"retries,omitempty"`         // The soonest due of them
+	NodeParallelism       []NodeParallelism     `json:"node_parallelism,omitempty"`
+	SpotChecks            int                   `json:"spot_checks"`
+	SpotChecksSkipped     int                   `json:"spot_checks_skipped,omitempty"` // Picked files that could not be read, e.g. from a destination without read-back
+	SpotCheckMismatches   int                   `json:"spot_check_mismatches"`
+	DiskForecast          *DiskSpaceForecast    `json:"disk_forecast,omitempty"`
+	Pacing                *SyncPacing           `json:"pacing,omitempty"`
+	Memory                *MemoryAccounting     `json:"memory,omitempty"`
+	Benchmark             *DestinationBenchmark `json:"benchmark,omitempty"`
+	ProjectRename         *ProjectRename        `json:"project_rename,omitempty"`
+	Quota                 *DestinationQuota     `json:"quota,omitempty"`
+	IOBudget              *IOBudget             `json:"io_budget,omitempty"`
+	Power                 *PowerState           `json:"power,omitempty"`
+	ActiveAlerts          []ActiveAlert         `json:"active_alerts"` // Unresolved alerts, for banners
+	ActiveTasks           []SyncTask            `json:"active_tasks"`
+}
+
+// PublicStatus is the headline of the running job shown on the public
+// status page. It holds nothing an operator control or a path could be
+// derived from.
+type PublicStatus struct {
+	State             string        `json:"state"` // running, paused or idle
+	Project           string        `json:"project,omitempty"`
+	CompletedCaptures int           `json:"completed_captures"`
+	LastCaptureNumber string        `json:"last_capture_number,omitempty"`
+	ETA               *time.Time    `json:"eta,omitempty"` // Projected finish at the throughput so far
+	Alerts            []PublicAlert `json:"alerts"`
+	UpdatedAt         time.Time     `json:"updated_at"`
+}
+
+// PublicAlert is an unresolved alert as the public status page shows it.
+type PublicAlert struct {
+	Severity string    `json:"severity"`
+	Title    string    `json:"title"`
+	Since    time.Time `json:"since"`
+}
+
+// ActiveAlert is the compact form of an unresolved alert sent with every
+// status, so the UI can keep a banner up until the alert is resolved.
+type ActiveAlert struct {
+	ID           int64     `json:"id"`
+	Type         string    `json:"type"`
+	Severity     string    `json:"severity"`
+	Category     string    `json:"category"`
+	Title        string    `json:"title"`
+	Message      string    `json:"message"`
+	Count        int       `json:"count"`
+	Since        time.Time `json:"since"`
+	Acknowledged bool      `json:"acknowledged,omitempty"`
+}
+
+// PowerState reports idle power saving: monitoring slowed down and, when
+// DiskSpunDown is set, that destination disk put into standby.
+type PowerState struct {
+	Idle         bool       `json:"idle"`
+	IdleSince    *time.Time `json:"idle_since,omitempty"`
+	DiskSpunDown string     `json:"disk_spun_down,omitempty"`
+}
+
+// IOBudget is the read rate shared by the sync, export and verify jobs and
+// how it is split among the jobs currently moving data.
+type IOBudget struct {
+	LimitMBps float64       `json:"limit_mbps"`
+	Jobs      []IOBudgetJob `json:"jobs"`
+}
+
+// IOBudgetJob is one job's share of the I/O budget.
+type IOBudgetJob struct {
+	Job       string  `json:"job"` // sync, export or verify
+	Weight    float64 `json:"weight"`
+	Share     float64 `json:"share"` // 0..1 of the budget
+	LimitMBps float64 `json:"limit_mbps"`
+}
+
+// DestinationQuota is the byte budget of a destination and how much of it the
+// data stored there uses.
+type DestinationQuota struct {
+	Destination string `json:"destination"`
+	LimitBytes  int64  `json:"limit_bytes"`
+	UsedBytes   int64  `json:"used_bytes"`
+	Reached     bool   `json:"reached"`
+}
+
+// ProjectRename is the project folder of a running job found on the shares
+// under another name, recognised by a session GUID the job already copied.
+type ProjectRename struct {
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	SessionID  string    `json:"session_id"`
+	Source     string    `json:"source"` // node/share where the renamed folder was found
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// MemoryAccounting sizes the in-memory bookkeeping of a running job, kept
+// bounded by periodic compaction.
+type MemoryAccounting struct {
+	TrackedCaptures  int        `json:"tracked_captures"`   // Incomplete captures held in memory
+	CopyFailures     int        `json:"copy_failures"`      // Files with failed copy attempts
+	RunCopies        int        `json:"run_copies"`         // Copied files kept for verification
+	EvictedCaptures  int        `json:"evicted_captures"`   // Incomplete captures aged out this run
+	DroppedRunCopies int        `json:"dropped_run_copies"` // Oldest copies no longer kept for verification
+	HeapAllocBytes   uint64     `json:"heap_alloc_bytes"`   // At the last compaction
+	LastCompactedAt  *time.Time `json:"last_compacted_at,omitempty"`
+}
+
+// DiskSpaceForecast projects destination free space against the remaining
+// copy workload. A negative MarginBytes means the data will not fit.
+type DiskSpaceForecast struct {
+	RemainingBytes        int64   `json:"remaining_bytes"`
+	FreeBytes             uint64  `json:"free_bytes"`
+	ReservedBytes         int64   `json:"reserved_bytes"`
+	MarginBytes           int64   `json:"margin_bytes"`
+	Shortfall             bool    `json:"shortfall"`
+	ThroughputBytesPerSec float64 `json:"throughput_bytes_per_sec"`
+	HoursUntilFull        float64 `json:"hours_until_full,omitempty"`
+}
+
+// SyncPacing compares the projected finish of the running job, at its
+// throughput so far, with its target completion time.
+type SyncPacing struct {
+	Deadline              time.Time  `json:"deadline"`
+	RemainingBytes        int64      `json:"remaining_bytes"`
+	ThroughputBytesPerSec float64    `json:"throughput_bytes_per_sec"`
+	RequiredBytesPerSec   float64    `json:"required_bytes_per_sec"`     // To copy the rest by the deadline
+	ProjectedFinish       *time.Time `json:"projected_finish,omitempty"` // Unknown until something was copied
+	OnTrack               bool       `json:"on_track"`
+}
+
+// SensorStatsReport aggregates the RAW files of a project copied so far per
+// camera sensor. Captures counts the captures any sensor has files for.
+type SensorStatsReport struct {
+	Project  string        `json:"project"`
+	Captures int           `json:"captures"`
+	Sensors  []SensorStats `json:"sensors"`
+}
+
+// SensorStats is one sensor of a SensorStatsReport. Suspect marks a sensor
+// with missing captures or files averaging under half the median sensor.
+type SensorStats struct {
+	SensorCode      string  `json:"sensor_code"`
+	Files           int     `json:"files"`
+	Bytes           int64   `json:"bytes"`
+	AverageBytes    int64   `json:"average_bytes"`
+	MinBytes        int64   `json:"min_bytes"`
+	MaxBytes        int64   `json:"max_bytes"`
+	Captures        int     `json:"captures"`
+	MissingCaptures int     `json:"missing_captures"` // Captures other sensors have files for
+	SizeRatio       float64 `json:"size_ratio"`       // Average size against the median sensor
+	Suspect         bool    `json:"suspect"`
+}
+
+// CaptureLocation records which destination directories hold the files of one capture.
+type CaptureLocation struct {
+	CaptureNumber string   `json:"capture_number"`
+	Destinations  []string `json:"destinations"`
+}
+
+// CaptureSession is one acquisition session of a project, the captures that
+// share a session GUID.
+type CaptureSession struct {
+	SessionID         string    `json:"session_id"`
+	FirstSeenAt       time.Time `json:"first_seen_at"`
+	LastSeenAt        time.Time `json:"last_seen_at"`
+	Captures          int       `json:"captures"`
+	CompletedCaptures int       `json:"completed_captures"`
+	TestCaptures      int       `json:"test_captures"`
+}
+
+// CaptureTimingStats is the distribution of capture latency, from the first
+// file written by the camera to the last file copied, and of the time spent
+// copying each capture.
+type CaptureTimingStats struct {
+	Captures           int     `json:"captures"`
+	LatencyP50Seconds  float64 `json:"latency_p50_seconds"`
+	LatencyP95Seconds  float64 `json:"latency_p95_seconds"`
+	LatencyMaxSeconds  float64 `json:"latency_max_seconds"`
+	TransferP50Seconds float64 `json:"transfer_p50_seconds"`
+	TransferP95Seconds float64 `json:"transfer_p95_seconds"`
 }
 
 // PersistedCaptureStatus holds per-project persisted capture counters and progress.
@@ -74,15 +283,222 @@ type PerformanceMetrics struct {
 	NetworkBytesPerSec      float64                   `json:"network_bytes_per_sec"`
 	NetworkMBps             float64                   `json:"network_mbps"`
 	NetworkPercent          float64                   `json:"network_percent"`
+	NetworkAttributed       bool                      `json:"network_attributed"`          // Network* count only CIFS (sync) traffic
+	NetworkTotalBytesPerSec float64                   `json:"network_total_bytes_per_sec"` // All monitored interfaces
+	NetworkOtherBytesPerSec float64                   `json:"network_other_bytes_per_sec"` // Traffic other than CIFS, when attributed
 	NetworkInterfaces       []NetworkInterfaceMetrics `json:"network_interfaces"`
 	FreeDiskBytes           uint64                    `json:"free_disk_bytes"`
 	FreeDiskGB              float64                   `json:"free_disk_gb"`
+	ScanMetrics             []ScanMetrics             `json:"scan_metrics,omitempty"`
+	Destinations            []DestinationInfo         `json:"destinations,omitempty"` // Free space per mounted destination device
+	CIFS                    *CIFSStats                `json:"cifs,omitempty"`
+	SourceShares            []ShareSpace              `json:"source_shares,omitempty"` // Free space per worker share
+	WorkerPools             *WorkerPoolMetrics        `json:"worker_pools,omitempty"`
+}
+
+// WorkerPoolMetrics shows the queue depths of the running job and how its
+// copy slots were used since it started.
+type WorkerPoolMetrics struct {
+	Running        bool              `json:"running"`
+	Since          *time.Time        `json:"since,omitempty"` // Job start the totals count from
+	ActiveTasks    int               `json:"active_tasks"`
+	QueuedFiles    int               `json:"queued_files"` // Found by the scans and not copied yet
+	PendingRetries int               `json:"pending_retries"`
+	Pools          []CopyPoolMetrics `json:"pools,omitempty"`
+	Nodes          []NodePoolMetrics `json:"nodes,omitempty"`
+}
+
+// CopyPoolMetrics is the use of one pool of copy slots.
+type CopyPoolMetrics struct {
+	Name        string  `json:"name"`     // copy, small_files or priority
+	Capacity    int     `json:"capacity"` // Slots, 0 for the priority lane, which has one per scan
+	Active      int     `json:"active"`
+	Copies      int64   `json:"copies"`
+	BusySeconds float64 `json:"busy_seconds"` // Slot time held by finished copies
+	WaitSeconds float64 `json:"wait_seconds"` // Time copies waited for a slot
+	Utilization float64 `json:"utilization"`  // BusySeconds over capacity times the job time, 0 to 1
+}
+
+// NodePoolMetrics is the use of copy slots by the copies of one node.
+type NodePoolMetrics struct {
+	Node          string  `json:"node"`
+	Limit         int     `json:"limit,omitempty"` // Adaptive per-node limit, when enabled
+	Active        int     `json:"active"`
+	Copies        int64   `json:"copies"`
+	BusySeconds   float64 `json:"busy_seconds"`
+	WaitSeconds   float64 `json:"wait_seconds"`
+	AverageActive float64 `json:"average_active"` // Copies in flight on average since the job started
+}
+
+// ShareSpace is the free space of one worker share as its node reports it.
+type ShareSpace struct {
+	Node        string    `json:"node"`
+	Share       string    `json:"share"`
+	Path        string    `json:"path"`
+	Available   bool      `json:"available"` // False when the share is not mounted or did not answer
+	FreeBytes   uint64    `json:"free_bytes"`
+	TotalBytes  uint64    `json:"total_bytes"`
+	FreePercent float64   `json:"free_percent"`
+	Low         bool      `json:"low"` // Below monitoring.source_space thresholds; kept while unavailable
+	Error       string    `json:"error,omitempty"`
+	CheckedAt   time.Time `json:"checked_at"`
+}
+
+// CIFSStats holds kernel SMB client counters from /proc/fs/cifs/Stats.
+// Counters are cumulative since the cifs module was loaded.
+type CIFSStats struct {
+	SessionReconnects int64            `json:"session_reconnects"`
+	ShareReconnects   int64            `json:"share_reconnects"`
+	Shares            []CIFSShareStats `json:"shares"`
+	BytesPerSec       float64          `json:"bytes_per_sec"` // SMB payload of all shares
+	Rated             bool             `json:"rated"`         // BytesPerSec measured against a previous sample
+}
+
+// CIFSShareStats holds the counters of one mounted share.
+type CIFSShareStats struct {
+	Target         string  `json:"target"`
+	Node           string  `json:"node"`
+	Share          string  `json:"share"`
+	Requests       int64   `json:"requests"`
+	RequestsPerSec float64 `json:"requests_per_sec"`
+	FailedOps      int64   `json:"failed_ops"`
+	BytesRead      int64   `json:"bytes_read"`
+	BytesWritten   int64   `json:"bytes_written"`
+	BytesPerSec    float64 `json:"bytes_per_sec"`
+	Disconnected   bool    `json:"disconnected"`
+}
+
+// ScanMetrics describes the most recent scan of one node/share.
+type ScanMetrics struct {
+	Node            string    `json:"node"`
+	Share           string    `json:"share"`
+	StartedAt       time.Time `json:"started_at"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	FilesExamined   int       `json:"files_examined"`
+	FilesQueued     int       `json:"files_queued"`
+	BytesQueued     int64     `json:"bytes_queued"`
+	Incremental     bool      `json:"incremental,omitempty"`     // Only changed folders were listed
+	FilesUnsettled  int       `json:"files_unsettled,omitempty"` // Left for a later scan, possibly still being written
+}
+
+// Inventory lists what a project holds on the worker shares, without copying
+// anything, for mission planning and space budgeting.
+type Inventory struct {
+	Project      string             `json:"project"`
+	GeneratedAt  time.Time          `json:"generated_at"`
+	Files        int                `json:"files"`
+	Bytes        int64              `json:"bytes"`
+	Captures     int                `json:"captures"`
+	TestCaptures int                `json:"test_captures"`
+	Shares       []ShareInventory   `json:"shares"`
+	Sessions     []SessionInventory `json:"sessions"`
+}
+
+// ShareInventory is the part of a project found on one node/share.
+type ShareInventory struct {
+	Node         string `json:"node"`
+	Share        string `json:"share"`
+	Path         string `json:"path"`
+	Error        string `json:"error,omitempty"` // Set when the share or project folder could not be read
+	Files        int    `json:"files"`
+	Bytes        int64  `json:"bytes"`
+	RawFiles     int    `json:"raw_files"`
+	Captures     int    `json:"captures"`
+	TestCaptures int    `json:"test_captures"`
+}
+
+// SessionInventory totals the captures of one camera session across shares.
+type SessionInventory struct {
+	SessionID    string `json:"session_id"`
+	Captures     int    `json:"captures"`
+	TestCaptures int    `json:"test_captures"`
+	FirstCapture string `json:"first_capture"`
+	LastCapture  string `json:"last_capture"`
+	Files        int    `json:"files"`
+	Bytes        int64  `json:"bytes"`
+}
+
+// Post-processing states of a capture and of each of its steps.
+const (
+	PostProcessQueued    = "queued"
+	PostProcessRunning   = "running"
+	PostProcessDone      = "done"
+	PostProcessFailed    = "failed"
+	PostProcessSkipped   = "skipped"
+	PostProcessCancelled = "cancelled"
+)
+
+// CaptureProcessing reports the post-processing pipeline of one completed
+// capture.
+type CaptureProcessing struct {
+	Project    string                  `json:"project"`
+	Capture    string                  `json:"capture"`
+	SessionID  string                  `json:"session_id"`
+	IsTest     bool                    `json:"is_test"`
+	Status     string                  `json:"status"`
+	QueuedAt   time.Time               `json:"queued_at"`
+	FinishedAt *time.Time              `json:"finished_at,omitempty"`
+	Steps      []PostProcessStepStatus `json:"steps"`
+}
+
+// PostProcessStepStatus is the outcome of one pipeline step.
+type PostProcessStepStatus struct {
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Output     string     `json:"output,omitempty"` // Tail of the command output or HTTP response
+	Error      string     `json:"error,omitempty"`
 }
 
 // ProjectInfo holds information about an available project
 type ProjectInfo struct {
-	Name   string `json:"name"`
-	Source string `json:"source"` // First node/share where found
+	Name     string `json:"name"`
+	Source   string `json:"source"`             // First node/share where found
+	Alias    string `json:"alias,omitempty"`    // From the project registry
+	Customer string `json:"customer,omitempty"` // From the project registry
+}
+
+// ProjectRegistryEntry is what the operator recorded about a project folder:
+// a human-friendly alias, the customer and free notes.
+type ProjectRegistryEntry struct {
+	Project   string    `json:"project"`
+	Alias     string    `json:"alias,omitempty"`
+	Customer  string    `json:"customer,omitempty"`
+	Notes     string    `json:"notes,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// JobNote is a free-text note the operator attached to a sync job, such as
+// the weather, an anomaly or the flight number.
+type JobNote struct {
+	ID        int64     `json:"id"`
+	JobID     string    `json:"job_id"`
+	Project   string    `json:"project"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Job history states.
+const (
+	JobRunning     = "running"
+	JobFinished    = "finished"
+	JobStopped     = "stopped"     // The operator stopped the job
+	JobFailed      = "failed"      // The job was stopped while the destination was lost
+	JobInterrupted = "interrupted" // The service went down while the job ran
+)
+
+// JobRecord is one Start/Stop cycle of the sync service as kept in the job
+// history. Totals are filled in when the job finishes.
+type JobRecord struct {
+	JobID       string     `json:"job_id"`
+	Project     string     `json:"project"`
+	Destination string     `json:"destination"`
+	State       string     `json:"state"`
+	Reason      string     `json:"reason,omitempty"` // Why the job finished or failed, such as idle
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Totals      SyncTotals `json:"totals"`
 }
 
 // ProjectDatabaseSummary describes one project persisted in the local SQLite DB.
@@ -141,6 +557,16 @@ type PreflightStatus struct {
 	Checks                []PreflightCheck            `json:"checks"`
 }
 
+// SyncStartError is returned by /api/sync/start when preflight checks block the start.
+type SyncStartError struct {
+	Error          string           `json:"error"`
+	Message        string           `json:"message"`
+	Checks         []PreflightCheck `json:"checks"`
+	FreeBytes      uint64           `json:"free_bytes,omitempty"`
+	RequiredBytes  int64            `json:"required_bytes,omitempty"`
+	EstimatedBytes int64            `json:"estimated_bytes,omitempty"`
+}
+
 // DashboardPreflightInstanceStatus contains readiness details for one dashboard instance.
 type DashboardPreflightInstanceStatus struct {
 	ID                    string                      `json:"id"`
@@ -173,18 +599,320 @@ type BlockDeviceInfo struct {
 	DeviceName  string `json:"device_name"`  // e.g., sdb1
 	Label       string `json:"label"`        // Filesystem label
 	Size        string `json:"size"`         // Human readable size
-	SizeBytes   uint64 `json:"size_bytes"`   // Size in bytes
+	SizeBytes   uint64 `json:"size_bytes"`   // Exact size in bytes
 	FSType      string `json:"fstype"`       // Filesystem type (ext4, exfat, ntfs, etc)
+	UUID        string `json:"uuid"`         // Filesystem UUID
+	PartUUID    string `json:"partuuid"`     // Partition UUID (GPT/MBR)
+	Serial      string `json:"serial"`       // Disk serial number
+	Transport   string `json:"transport"`    // usb, sata, nvme, etc
 	MountPoint  string `json:"mount_point"`  // Where mounted, empty if not mounted
 	IsMounted   bool   `json:"is_mounted"`   // Mount status
 	IsRemovable bool   `json:"is_removable"` // USB/removable device
 	Model       string `json:"model"`        // Device model name
+	Encrypted   bool   `json:"encrypted"`    // LUKS container; mounted after POST /api/devices/unlock
+	Unlocked    bool   `json:"unlocked"`     // Encrypted and its mapping is open
+}
+
+// BlockDeviceEvent is broadcast when a block device is plugged in or removed.
+type BlockDeviceEvent struct {
+	DeviceName string           `json:"device_name"`
+	DevicePath string           `json:"device_path"`
+	DevType    string           `json:"devtype"`          // disk or partition
+	Device     *BlockDeviceInfo `json:"device,omitempty"` // Details for added devices, when available
 }
 
 // MountRequest represents a mount/unmount request
 type MountRequest struct {
-	DevicePath string `json:"device_path"` // e.g., /dev/sdb1
-	Action     string `json:"action"`      // "mount" or "unmount"
+	DevicePath string `json:"device_path"`     // e.g., /dev/sdb1
+	UUID       string `json:"uuid,omitempty"`  // Filesystem UUID, preferred over DevicePath
+	Label      string `json:"label,omitempty"` // Filesystem label, used when UUID is empty
+	Action     string `json:"action"`          // "mount" or "unmount"
+}
+
+// Sync lifecycle event types.
+const (
+	SyncEventFinished = "sync_finished"
+	SyncEventStopped  = "sync_stopped"
+	SyncEventFailed   = "sync_failed"
+
+	SyncEventDestinationLost  = "destination_lost"
+	SyncEventDiskSpaceWarning = "disk_space_warning"
+	SyncEventTaskStalled      = "task_stalled"
+	SyncEventPacingBehind     = "pacing_behind"
+	SyncEventPacingOnTrack    = "pacing_on_track"
+
+	SyncEventDestinationFull         = "destination_full"
+	SyncEventDestinationSwitched     = "destination_switched"
+	SyncEventDestinationIncompatible = "destination_incompatible"
+	SyncEventQuotaReached            = "destination_quota_reached"
+
+	SyncEventSourceSpaceLow  = "source_space_low"
+	SyncEventNodeBreakerOpen = "node_breaker_open"
+
+	SyncEventCaptureCompleted = "capture_completed"
+	SyncEventFileQuarantined  = "file_quarantined"
+	SyncEventFileDegraded     = "file_degraded"
+	SyncEventProjectRenamed   = "project_renamed"
+
+	SyncEventPostProcessFailed = "post_process_failed"
+
+	SyncEventIntegrityMismatch = "integrity_mismatch"
+
+	SyncEventExportFinished = "export_finished"
+	SyncEventExportFailed   = "export_failed"
+)
+
+// Exclusions lists folder names ignored on worker shares. Directories are
+// skipped while scanning a project; Projects are never offered as projects.
+type Exclusions struct {
+	Directories []string `json:"directories"`
+	Projects    []string `json:"projects"`
+}
+
+// NodeParallelism is the adaptive copy concurrency currently allowed for a node.
+type NodeParallelism struct {
+	Node   string `json:"node"`
+	Limit  int    `json:"limit"`
+	Active int    `json:"active"`
+}
+
+// CredentialRotation is the outcome of a share credential rotation.
+type CredentialRotation struct {
+	RotatedAt time.Time      `json:"rotated_at"`
+	Remounted int            `json:"remounted"`
+	Shares    []ShareRemount `json:"shares"`
+}
+
+// ShareRemount is one share remounted with rotated credentials.
+type ShareRemount struct {
+	Node  string `json:"node"`
+	Share string `json:"share"`
+	Error string `json:"error,omitempty"`
+}
+
+// CopyFailure describes a file whose copies keep failing. Quarantined files
+// are skipped by the sync loop until the operator re-queues them.
+type CopyFailure struct {
+	Path          string     `json:"path"`
+	RelativePath  string     `json:"relative_path"`
+	Node          string     `json:"node"`
+	Share         string     `json:"share"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error"`
+	FirstFailedAt time.Time  `json:"first_failed_at"`
+	LastFailedAt  time.Time  `json:"last_failed_at"`
+	NextRetryAt   *time.Time `json:"next_retry_at,omitempty"` // While waiting in the retry queue
+	Quarantined   bool       `json:"quarantined"`
+}
+
+// NodeMaintenance marks a node whose hardware is being serviced. Its shares
+// and RAW files are ignored for availability and capture completeness.
+type NodeMaintenance struct {
+	Node   string    `json:"node"`
+	Reason string    `json:"reason,omitempty"`
+	Since  time.Time `json:"since"`
+}
+
+// Circuit breaker states of a node.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open" // Open period over; the next failure reopens it
+)
+
+// NodeBreaker is the copy failure rate of a node over the breaker window and
+// whether copies to it are suspended.
+type NodeBreaker struct {
+	Node        string     `json:"node"`
+	State       string     `json:"state"`
+	Attempts    int        `json:"attempts"`
+	Failures    int        `json:"failures"`
+	FailureRate float64    `json:"failure_rate"`
+	Trips       int        `json:"trips"`
+	OpenedAt    *time.Time `json:"opened_at,omitempty"`
+	RetryAt     *time.Time `json:"retry_at,omitempty"`
+}
+
+// SyncTotals summarizes the work done by one synchronization run.
+type SyncTotals struct {
+	CopiedFiles           int     `json:"copied_files"`
+	FailedFiles           int     `json:"failed_files"`
+	CopiedBytes           int64   `json:"copied_bytes"`
+	LinkedFiles           int     `json:"linked_files"`
+	LinkedBytes           int64   `json:"linked_bytes"`
+	DeltaFiles            int     `json:"delta_files,omitempty"`         // Changed files patched in place
+	DeltaSavedBytes       int64   `json:"delta_saved_bytes,omitempty"`   // Destination writes those patches avoided
+	ChecksumMismatches    int     `json:"checksum_mismatches,omitempty"` // Copies that read back different from the source
+	ResumedFiles          int     `json:"resumed_files,omitempty"`       // Interrupted copies continued where they stopped
+	ResumedBytes          int64   `json:"resumed_bytes,omitempty"`       // Bytes those copies did not transfer again
+	MovedFiles            int     `json:"moved_files,omitempty"`         // Source files move mode deleted or set aside
+	MovedBytes            int64   `json:"moved_bytes,omitempty"`         // Node disk those files freed
+	ScansDeferred         int     `json:"scans_deferred,omitempty"`      // Iterations that held back scans for a deep copy queue
+	DegradedFiles         int     `json:"degraded_files"`
+	CompletedCaptures     int     `json:"completed_captures"`
+	CompletedTestCaptures int     `json:"completed_test_captures"`
+	DurationSeconds       float64 `json:"duration_seconds"`
+	DestinationWriteMBps  float64 `json:"destination_write_mbps,omitempty"` // Benchmark made before the job started
+}
+
+// CaptureTags are the operator's tags of one capture.
+type CaptureTags struct {
+	Project string   `json:"project"`
+	Capture string   `json:"capture"`
+	Tags    []string `json:"tags"`
+}
+
+// ExportStatus reports the progress of a delivery export job, which copies
+// completed, verified captures from the working destination to a delivery disk.
+type ExportStatus struct {
+	Running         bool       `json:"running"`
+	Project         string     `json:"project"`
+	Source          string     `json:"source"`
+	Destination     string     `json:"destination"`
+	Lvl00Only       bool       `json:"lvl00_only"`
+	FromCapture     string     `json:"from_capture,omitempty"`
+	ToCapture       string     `json:"to_capture,omitempty"`
+	Tags            []string   `json:"tags,omitempty"`
+	ExcludeTags     []string   `json:"exclude_tags,omitempty"`
+	Captures        int        `json:"captures"`
+	SkippedCaptures int        `json:"skipped_captures"` // Incomplete or unverified
+	TotalFiles      int        `json:"total_files"`
+	CopiedFiles     int        `json:"copied_files"`
+	TotalBytes      int64      `json:"total_bytes"`
+	CopiedBytes     int64      `json:"copied_bytes"`
+	StartedAt       time.Time  `json:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+	Manifest        string     `json:"manifest,omitempty"`
+	Error           string     `json:"error,omitempty"`
+}
+
+// JobTemplate is a named set of start options offered by GET /api/sync/templates.
+type JobTemplate struct {
+	Name              string   `json:"name"`
+	Project           string   `json:"project,omitempty"`
+	Destination       string   `json:"destination,omitempty"`
+	MaxParallelism    int      `json:"max_parallelism,omitempty"`
+	ForceFullResync   bool     `json:"force_full_resync"`
+	CompletionActions []string `json:"completion_actions"` // Already resolved against sync.completion.actions
+	Benchmark         bool     `json:"benchmark"`
+}
+
+// DestinationBenchmark is the result of a quick write test of a destination.
+type DestinationBenchmark struct {
+	Destination  string    `json:"destination"`
+	Bytes        int64     `json:"bytes"`
+	Seconds      float64   `json:"seconds"`
+	WriteMBps    float64   `json:"write_mbps"`
+	ExpectedMBps float64   `json:"expected_mbps"` // monitoring.max_disk_throughput_mbps
+	Slow         bool      `json:"slow"`          // Far below ExpectedMBps
+	MeasuredAt   time.Time `json:"measured_at"`
+}
+
+// ByteRange is a span of a file, e.g. an unreadable region zero-filled in a
+// degraded copy.
+type ByteRange struct {
+	Offset int64 `json:"offset"`
+	Length int64 `json:"length"`
+}
+
+// ReplicationStatus reports a mirror of a peer UCXSync instance's destination.
+type ReplicationStatus struct {
+	PeerURL       string     `json:"peer_url"`
+	Destination   string     `json:"destination"`
+	Running       bool       `json:"running"` // A mirror pass is in progress
+	ManifestFiles int        `json:"manifest_files"`
+	PendingFiles  int        `json:"pending_files"`
+	CopiedFiles   int        `json:"copied_files"` // Since the service started
+	CopiedBytes   int64      `json:"copied_bytes"`
+	LastPassAt    *time.Time `json:"last_pass_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// VerifyResult summarizes a full re-hash of the files copied by a run, or of
+// a sample of them.
+type VerifyResult struct {
+	Checked    int      `json:"checked"`
+	Mismatched []string `json:"mismatched,omitempty"` // Destination paths that differ from the source
+	Skipped    int      `json:"skipped"`              // Source no longer readable
+	// ResumedFrom is how many files an interrupted earlier pass had already
+	// verified when this one picked up.
+	ResumedFrom int `json:"resumed_from,omitempty"`
+	// NotSampled is how many files of the run a sampled pass left out.
+	NotSampled int `json:"not_sampled,omitempty"`
+}
+
+// VerifyProgress reports a running or interrupted verify pass.
+type VerifyProgress struct {
+	Running    bool      `json:"running"`
+	RunDir     string    `json:"run_dir"`
+	Total      int       `json:"total"`
+	Done       int       `json:"done"`
+	Checked    int       `json:"checked"`
+	Skipped    int       `json:"skipped"`
+	NotSampled int       `json:"not_sampled,omitempty"` // Files of the run a sampled pass leaves out
+	Mismatched int       `json:"mismatched"`
+	FileOffset int64     `json:"file_offset"` // Bytes of the current file already hashed
+	StartedAt  time.Time `json:"started_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// SyncEvent describes a notable synchronization lifecycle event.
+type SyncEvent struct {
+	Type        string      `json:"type"`
+	Timestamp   time.Time   `json:"timestamp"`
+	Project     string      `json:"project"`
+	Destination string      `json:"destination"`
+	Message     string      `json:"message"`
+	Reason      string      `json:"reason,omitempty"`
+	Capture     string      `json:"capture,omitempty"`
+	JobID       string      `json:"job_id,omitempty"` // Job the event belongs to
+	Totals      *SyncTotals `json:"totals,omitempty"`
+}
+
+// Alert states.
+const (
+	AlertOpen         = "open"
+	AlertAcknowledged = "acknowledged" // Seen by the operator, still unresolved
+	AlertResolved     = "resolved"
+)
+
+// AlertShareMountFailed is raised when unavailable shares fail to remount.
+// Other alerts carry the type of the sync event that raised them.
+const AlertShareMountFailed = "share_mount_failed"
+
+// Alert is a warning or critical condition kept in the alert center until
+// the operator resolves it. Repeats of an unresolved alert bump Count.
+type Alert struct {
+	ID             int64      `json:"id"`
+	Type           string     `json:"type"`
+	Severity       string     `json:"severity"`
+	Category       string     `json:"category"`
+	Title          string     `json:"title"`
+	Message        string     `json:"message"`
+	Reason         string     `json:"reason,omitempty"`
+	Project        string     `json:"project,omitempty"`
+	Destination    string     `json:"destination,omitempty"`
+	Count          int        `json:"count"`
+	State          string     `json:"state"` // One of the Alert* states
+	RaisedAt       time.Time  `json:"raised_at"`
+	LastSeenAt     time.Time  `json:"last_seen_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+}
+
+// EventMeta tells the UI how prominently to surface an event.
+type EventMeta struct {
+	Severity     string             `json:"severity"` // info, success, warning or critical
+	Category     string             `json:"category"` // capture, sync, disk, destination or network
+	Notification *EventNotification `json:"notification,omitempty"`
+}
+
+// EventNotification is the notification the UI is advised to show.
+type EventNotification struct {
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	Browser bool   `json:"browser"`
+	Sound   bool   `json:"sound"`
 }
 
 // LogMessage represents a log entry
@@ -194,10 +922,25 @@ type LogMessage struct {
 	Message   string    `json:"message"`
 }
 
-// WSMessage represents a WebSocket message
+// WSMessage represents a WebSocket message. The protocol is described at
+// WSProtocolVersion.
 type WSMessage struct {
 	Type    string      `json:"type"`
 	Payload interface{} `json:"payload"`
+	Meta    *EventMeta  `json:"meta,omitempty"`
+	Seq     uint64      `json:"seq,omitempty"` // Status sequence of "status" and "status_delta" messages
+	ID      uint64      `json:"id,omitempty"`  // Broadcast number, see WSProtocolVersion
+}
+
+// StatusDelta is a "status_delta" WebSocket message: what changed in the
+// SyncStatus since the status numbered Base. Fields holds changed top-level
+// fields (null for one that was dropped); tasks are keyed "node/share".
+type StatusDelta struct {
+	Base         uint64                     `json:"base"`
+	Fields       map[string]json.RawMessage `json:"fields,omitempty"`
+	Tasks        []SyncTask                 `json:"tasks,omitempty"`         // Added or changed tasks
+	RemovedTasks []string                   `json:"removed_tasks,omitempty"` // Keys of tasks no longer listed
+	TaskOrder    []string                   `json:"task_order,omitempty"`    // Keys of all tasks, when their order changed
 }
 
 // DashboardInstanceConfig describes one instance connected to the shared dashboard.
@@ -259,3 +1002,45 @@ type DashboardActionResponse struct {
 	Action  string                  `json:"action"`
 	Results []DashboardActionResult `json:"results"`
 }
+
+// StatusSnapshot is the complete runtime picture at one moment, meant to be
+// attached to trouble reports.
+type StatusSnapshot struct {
+	GeneratedAt       time.Time                   `json:"generated_at"`
+	Hostname          string                      `json:"hostname,omitempty"`
+	Status            SyncStatus                  `json:"status"`
+	Metrics           *PerformanceMetrics         `json:"metrics,omitempty"`
+	Mounts            []MountEntry                `json:"mounts"`
+	UnavailableShares []PreflightUnavailableShare `json:"unavailable_shares"`
+	Alerts            []Alert                     `json:"alerts"`
+	Events            []SyncEvent                 `json:"events"`
+	Errors            []string                    `json:"errors,omitempty"` // Parts that could not be collected
+}
+
+// MountEntry is one mounted share or data disk.
+type MountEntry struct {
+	Kind       string `json:"kind"` // "share" or "destination"
+	Device     string `json:"device"`
+	MountPoint string `json:"mount_point"`
+	FSType     string `json:"fs_type"`
+}
+
+// DestinationHeartbeat is written periodically into the run folder on the
+// destination disk, so whoever receives the disk can tell when and how
+// completely it was written without the sync kit.
+type DestinationHeartbeat struct {
+	JobID                 string    `json:"job_id"`
+	Host                  string    `json:"host,omitempty"`
+	Project               string    `json:"project"`
+	State                 string    `json:"state"` // "running", "paused" or "stopped"
+	PauseReason           string    `json:"pause_reason,omitempty"`
+	StartedAt             time.Time `json:"started_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+	CompletedCaptures     int       `json:"completed_captures"`
+	CompletedTestCaptures int       `json:"completed_test_captures"`
+	LastCaptureNumber     string    `json:"last_capture_number,omitempty"`
+	CopiedFiles           int       `json:"copied_files"`
+	CopiedBytes           int64     `json:"copied_bytes"`
+	FailedFiles           int       `json:"failed_files"`
+	QuarantinedFiles      int       `json:"quarantined_files"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import "encoding/json"
+
+// WebSocket protocol. Every connection starts with a "hello" from the server
+// naming its protocol version and the message types it sends and accepts.
+// A client reads it before anything else and ignores message types it does
+// not know; a client needing more than WSProtocolVersion offers falls back
+// to the features the hello lists. Adding message types or fields keeps the
+// version; changing or removing them raises it.
+//
+// Every broadcast message carries an ID, one higher than the message before
+// it on this server, and hello carries the last ID sent before the client
+// connected, so a reconnecting client can tell whether it missed messages.
+// The full status is numbered separately by Seq, which "status_delta" refers
+// to as its base.
+const (
+	// WSProtocolVersion is the protocol the server speaks.
+	WSProtocolVersion = 1
+	// WSMinProtocolVersion is the oldest protocol a client may still speak.
+	WSMinProtocolVersion = 1
+)
+
+// Message types sent by the server.
+const (
+	WSHello         = "hello"
+	WSStatus        = "status"
+	WSStatusDelta   = "status_delta"
+	WSMetrics       = "metrics"
+	WSLog           = "log"
+	WSSyncEvent     = "sync_event"
+	WSAlert         = "alert"
+	WSProjects      = "projects"
+	WSDeviceAdded   = "device_added"
+	WSDeviceRemoved = "device_removed"
+)
+
+// Message types sent by the client.
+const (
+	// WSClientHello announces the client protocol; the payload is a
+	// WSClientHelloPayload.
+	WSClientHello = "hello"
+	// WSClientResync asks for the full status again, e.g. after a
+	// status_delta whose base the client does not have.
+	WSClientResync = "resync"
+)
+
+// WSServerMessageTypes lists the message types this server sends.
+var WSServerMessageTypes = []string{
+	WSHello, WSStatus, WSStatusDelta, WSMetrics, WSLog, WSSyncEvent,
+	WSAlert, WSProjects, WSDeviceAdded, WSDeviceRemoved,
+}
+
+// WSClientMessageTypes lists the message types this server accepts.
+var WSClientMessageTypes = []string{WSClientHello, WSClientResync}
+
+// WSHelloPayload is the payload of the server "hello".
+type WSHelloPayload struct {
+	Protocol           int      `json:"protocol"`
+	MinProtocol        int      `json:"min_protocol"`
+	ServerVersion      string   `json:"server_version"`
+	MessageTypes       []string `json:"message_types"`
+	ClientMessageTypes []string `json:"client_message_types"`
+	LastID             uint64   `json:"last_id"`    // ID of the last message broadcast before this connection
+	StatusSeq          uint64   `json:"status_seq"` // Seq of the status sent next
+}
+
+// WSClientMessage is a message from the client.
+type WSClientMessage struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// WSClientHelloPayload is the payload of the client "hello".
+type WSClientHelloPayload struct {
+	Protocol     int      `json:"protocol"`
+	Client       string   `json:"client,omitempty"` // e.g. "ucxsync-web/1"
+	MessageTypes []string `json:"message_types,omitempty"`
+}
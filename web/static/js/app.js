@@ -1,4 +1,8 @@
 // UCXSync Web Application
+
+// WebSocket protocol this UI speaks, see pkg/models/wsprotocol.go.
+const WS_PROTOCOL = 1;
+
 class UCXSyncApp {
     constructor() {
         this.ws = null;
@@ -6,6 +10,9 @@ class UCXSyncApp {
         this.dashboardPollInterval = 2000;
         this.dashboardTimer = null;
         this.isRunning = false;
+        this.isPaused = false;
+        this.statusState = null;
+        this.statusSeq = 0;
         this.mode = 'single';
         this.dashboardConfig = { enabled: false, instances: [] };
         this.lastOverview = null;
@@ -45,6 +52,11 @@ class UCXSyncApp {
                 this.loadHostTime()
             ]);
             await this.refreshPreflight({ silent: true });
+            await this.loadFailures();
+            await this.loadAlerts();
+            await this.loadCompletionActions();
+            await this.loadJobTemplates();
+            await this.loadExportStatus();
         }
     }
 
@@ -61,8 +73,15 @@ class UCXSyncApp {
         this.destinationCustom = { value: '' }; // removed from UI
         this.parallelismInput = document.getElementById('parallelism');
         this.forceFullResyncCheckbox = document.getElementById('force-full-resync');
+        this.benchmarkCheckbox = document.getElementById('benchmark-destination');
+        this.completionActionInputs = Array.from(document.querySelectorAll('[data-completion-action]'));
+        this.jobTemplateGroup = document.getElementById('job-template-group');
+        this.jobTemplateSelect = document.getElementById('job-template');
+        this.jobTemplates = new Map();
         this.startBtn = document.getElementById('start-btn');
         this.stopBtn = document.getElementById('stop-btn');
+        this.pauseBtn = document.getElementById('pause-btn');
+        this.noteBtn = document.getElementById('note-btn');
         this.refreshBtn = document.getElementById('refresh-projects');
         this.manageDevicesBtn = document.getElementById('manage-devices-btn');
         this.manageDbBtn = document.getElementById('manage-db-btn');
@@ -70,12 +89,32 @@ class UCXSyncApp {
         this.syncTimeBtn = document.getElementById('sync-time-btn');
         this.hostTimeStatus = document.getElementById('host-time-status');
         this.downloadReportBtn = document.getElementById('download-report-btn');
+        this.downloadReportHtmlBtn = document.getElementById('download-report-html-btn');
         this.restartServiceBtn = document.getElementById('restart-service-btn');
         this.shutdownHostBtn = document.getElementById('shutdown-host-btn');
         this.preflightPanel = document.getElementById('preflight-panel');
         this.preflightSummary = document.getElementById('preflight-summary');
         this.preflightBadge = document.getElementById('preflight-badge');
         this.preflightChecks = document.getElementById('preflight-checks');
+        this.failuresPanel = document.getElementById('failures-panel');
+        this.failuresBody = document.getElementById('failures-body');
+        this.requeueAllBtn = document.getElementById('requeue-all-btn');
+        this.projectRenamePanel = document.getElementById('project-rename-panel');
+        this.projectRenameMessage = document.getElementById('project-rename-message');
+        this.projectRemapBtn = document.getElementById('project-remap-btn');
+        this.projectRename = null;
+        this.alertsPanel = document.getElementById('alerts-panel');
+        this.alertBanners = document.getElementById('alert-banners');
+        this.alertsBody = document.getElementById('alerts-body');
+        this.alerts = new Map();
+        this.quarantinedCount = 0;
+        this.exportPanel = document.getElementById('export-panel');
+        this.exportDestinationSelect = document.getElementById('export-destination');
+        this.exportLvl00Checkbox = document.getElementById('export-lvl00-only');
+        this.exportStartBtn = document.getElementById('export-start-btn');
+        this.exportCancelBtn = document.getElementById('export-cancel-btn');
+        this.exportStatusEl = document.getElementById('export-status');
+        this.exportPollTimer = null;
 
         // Status
         this.completedCapturesEl = document.getElementById('completed-captures');
@@ -83,6 +122,8 @@ class UCXSyncApp {
         this.testCapturesEl = document.getElementById('test-captures');
         this.activeOpsCountEl = document.getElementById('active-ops-count');
         this.maxParallelismEl = document.getElementById('max-parallelism');
+        this.pacingCard = document.getElementById('pacing-card');
+        this.pacingValueEl = document.getElementById('pacing-value');
 
         // Metrics
         this.cpuProgress = document.getElementById('cpu-progress');
@@ -101,6 +142,7 @@ class UCXSyncApp {
         this.networkSecondaryValue = document.getElementById('network-secondary-value');
         this.cpuTemperatureValue = document.getElementById('cpu-temperature-value');
         this.freeDiskEl = document.getElementById('free-disk');
+        this.sourceSpaceEl = document.getElementById('source-space-value');
 
         // Activity table
         this.activityBody = document.getElementById('activity-body');
@@ -140,6 +182,16 @@ class UCXSyncApp {
             }
         });
 
+        this.pauseBtn.addEventListener('click', () => {
+            if (this.isPaused) {
+                this.resumeSync();
+            } else {
+                this.pauseSync();
+            }
+        });
+
+        this.noteBtn.addEventListener('click', () => this.addJobNote());
+
         this.refreshBtn.addEventListener('click', async () => {
             if (this.mode === 'dashboard') {
                 await Promise.all([
@@ -151,7 +203,7 @@ class UCXSyncApp {
                 await this.refreshDashboardPreflight({ silent: true }).catch(() => {});
             } else {
                 await Promise.all([
-                    this.loadProjects(),
+                    this.loadProjects({ refresh: true }),
                     this.loadDestinations(),
                     this.loadHostTime()
                 ]);
@@ -163,6 +215,7 @@ class UCXSyncApp {
         this.manageDbBtn?.addEventListener('click', () => this.openDatabaseModal());
         this.clearDatabaseBtn?.addEventListener('click', () => this.clearDatabase());
         this.downloadReportBtn?.addEventListener('click', () => this.downloadProjectReport());
+        this.downloadReportHtmlBtn?.addEventListener('click', () => this.downloadProjectReport('html'));
         this.mountSharesBtn.addEventListener('click', () => {
             if (this.mode === 'dashboard') {
                 this.mountDashboardShares();
@@ -171,6 +224,10 @@ class UCXSyncApp {
             }
         });
         this.syncTimeBtn?.addEventListener('click', () => this.syncHostTime());
+        this.requeueAllBtn?.addEventListener('click', () => this.requeueFailures([]));
+        this.projectRemapBtn?.addEventListener('click', () => this.remapProject());
+        this.exportStartBtn?.addEventListener('click', () => this.startExport());
+        this.exportCancelBtn?.addEventListener('click', () => this.cancelExport());
 
         this.restartServiceBtn.addEventListener('click', () => {
             if (this.mode === 'dashboard') {
@@ -214,6 +271,7 @@ class UCXSyncApp {
         });
         this.parallelismInput.addEventListener('change', () => this.saveSettings());
         this.forceFullResyncCheckbox?.addEventListener('change', () => this.saveSettings());
+        this.jobTemplateSelect?.addEventListener('change', () => this.applyJobTemplate());
     }
 
     async detectMode() {
@@ -238,6 +296,8 @@ class UCXSyncApp {
         this.metricsTitle.textContent = 'Производительность хоста';
         this.startBtn.textContent = '▶️ Запустить';
         this.stopBtn.textContent = '⏹️ Остановить';
+        this.pauseBtn.style.display = 'none';
+        this.noteBtn.style.display = 'none';
         this.mountSharesBtn.textContent = '🔁 Смонтировать DU';
         this.restartServiceBtn.textContent = '♻️ Перезапустить';
         // Replace single indicator with two per-instance indicators
@@ -254,6 +314,9 @@ class UCXSyncApp {
             this.renderDashboardPreflightLoading();
             this.updateControlsState();
         }
+        if (this.exportPanel) {
+            this.exportPanel.hidden = true;
+        }
         this.log('Общий дашборд включен', 'info');
     }
 
@@ -408,6 +471,7 @@ class UCXSyncApp {
         this.ws.onopen = () => {
             this.log('✓ Подключено к серверу', 'success');
             this.updateConnectionStatus(true);
+            this.sendWebSocket('hello', { protocol: WS_PROTOCOL, client: 'ucxsync-web' });
         };
 
         this.ws.onclose = () => {
@@ -431,22 +495,178 @@ class UCXSyncApp {
         };
     }
 
+    sendWebSocket(type, payload) {
+        if (this.ws?.readyState === WebSocket.OPEN) {
+            this.ws.send(JSON.stringify({ type, payload }));
+        }
+    }
+
     handleWebSocketMessage(message) {
+        if (message.id) {
+            this.lastMessageId = message.id;
+        }
         switch (message.type) {
+            case 'hello':
+                this.handleHello(message.payload);
+                break;
             case 'status':
+                this.statusState = message.payload;
+                this.statusSeq = message.seq || 0;
                 this.updateSingleStatus(message.payload);
                 break;
+            case 'status_delta':
+                this.applyStatusDelta(message.payload, message.seq);
+                break;
             case 'metrics':
                 this.updateMetrics(message.payload);
                 break;
             case 'log':
                 this.log(message.payload.message, message.payload.level);
                 break;
+            case 'sync_event':
+                this.handleSyncEvent(message.payload);
+                this.notifyEvent(message.meta);
+                break;
+            case 'alert':
+                this.updateAlert(message.payload);
+                break;
+            case 'projects':
+                if (this.mode !== 'dashboard') {
+                    this.populateProjects(message.payload || []);
+                }
+                break;
+            case 'device_added':
+            case 'device_removed':
+                this.handleDeviceHotplug(message.type, message.payload);
+                break;
             default:
                 console.log('Unknown message type:', message.type);
         }
     }
 
+    handleHello(hello) {
+        if (!hello) {
+            return;
+        }
+        if (hello.min_protocol > WS_PROTOCOL) {
+            this.log(`Сервер требует протокол ${hello.min_protocol}, обновите страницу`, 'warn');
+        }
+        if (this.lastMessageId && hello.last_id > this.lastMessageId) {
+            this.log(`Пока не было связи, пропущено сообщений: ${hello.last_id - this.lastMessageId}`, 'info');
+        }
+        this.lastMessageId = hello.last_id;
+        this.serverAccepts = new Set(hello.client_message_types || []);
+    }
+
+    async handleDeviceHotplug(type, event) {
+        if (!event) {
+            return;
+        }
+
+        const action = type === 'device_added' ? 'подключено' : 'отключено';
+        this.log(`Устройство ${event.device_path} ${action}`, 'info');
+
+        if (this.deviceModal?.classList.contains('active')) {
+            await this.loadDevices();
+        }
+        if (this.mode === 'dashboard') {
+            await this.loadDashboardDestinations().catch(() => {});
+        } else {
+            await this.loadDestinations().catch(() => {});
+        }
+    }
+
+    notifyEvent(meta) {
+        const notification = meta?.notification;
+        if (!notification) {
+            return;
+        }
+
+        if (notification.sound) {
+            this.playAlertSound(meta.severity);
+        }
+
+        if (notification.browser && 'Notification' in window && Notification.permission === 'granted') {
+            new Notification(notification.title, {
+                body: notification.body,
+                tag: `ucxsync-${meta.category}`
+            });
+        }
+    }
+
+    requestNotificationPermission() {
+        if ('Notification' in window && Notification.permission === 'default') {
+            Notification.requestPermission().catch(() => {});
+        }
+    }
+
+    playAlertSound(severity) {
+        const AudioContextClass = window.AudioContext || window.webkitAudioContext;
+        if (!AudioContextClass) {
+            return;
+        }
+
+        this.audioContext = this.audioContext || new AudioContextClass();
+        const tones = {
+            critical: [880, 660, 880],
+            warning: [660, 660],
+            success: [660, 880],
+            info: [880]
+        };
+        const sequence = tones[severity] || tones.info;
+        const start = this.audioContext.currentTime;
+
+        sequence.forEach((frequency, index) => {
+            const oscillator = this.audioContext.createOscillator();
+            const gain = this.audioContext.createGain();
+            const at = start + index * 0.18;
+            oscillator.frequency.value = frequency;
+            gain.gain.setValueAtTime(0.15, at);
+            gain.gain.exponentialRampToValueAtTime(0.001, at + 0.15);
+            oscillator.connect(gain);
+            gain.connect(this.audioContext.destination);
+            oscillator.start(at);
+            oscillator.stop(at + 0.15);
+        });
+    }
+
+    handleSyncEvent(event) {
+        if (!event) {
+            return;
+        }
+
+        if (event.type === 'sync_failed' || event.type === 'destination_lost' || event.type === 'integrity_mismatch') {
+            const reason = event.reason ? `: ${event.reason}` : '';
+            this.log(`✗ ${event.message}${reason}`, 'error');
+            return;
+        }
+
+        if (event.type === 'export_finished' || event.type === 'export_failed') {
+            this.loadExportStatus();
+        }
+
+        if (event.type === 'export_failed') {
+            const reason = event.reason ? `: ${event.reason}` : '';
+            this.log(`✗ ${event.message}${reason}`, 'error');
+            return;
+        }
+
+        if (event.type === 'file_quarantined') {
+            const reason = event.reason ? `: ${event.reason}` : '';
+            this.log(`⚠ ${event.message}${reason}`, 'warn');
+            this.loadFailures();
+            return;
+        }
+
+        if (event.type === 'disk_space_warning' || event.type === 'task_stalled' || event.type === 'destination_full' || event.type === 'destination_quota_reached' || event.type === 'source_space_low' || event.type === 'node_breaker_open' || event.type === 'destination_incompatible' || event.type === 'file_degraded' || event.type === 'post_process_failed' || event.type === 'project_renamed' || event.type === 'pacing_behind') {
+            const reason = event.reason ? `: ${event.reason}` : '';
+            this.log(`⚠ ${event.message}${reason}`, 'warn');
+            return;
+        }
+
+        this.log(`✓ ${event.message}`, 'success');
+    }
+
     updateConnectionStatus(connected) {
         if (connected) {
             this.connectionStatus.textContent = '🟢 Подключено';
@@ -471,11 +691,32 @@ class UCXSyncApp {
         const response = await fetch(url, options);
         if (!response.ok) {
             const text = await response.text();
-            throw new Error(text || `HTTP ${response.status}`);
+            let payload = null;
+            try {
+                payload = JSON.parse(text);
+            } catch (_) {
+                payload = null;
+            }
+            const error = new Error(payload?.message || text || `HTTP ${response.status}`);
+            error.payload = payload;
+            throw error;
         }
         return response.json();
     }
 
+    // Destructive endpoints take a single-use confirmation token (web.guard).
+    async fetchConfirmed(url, options = {}) {
+        const confirm = await this.fetchJSON('/api/confirm', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ endpoint: url })
+        });
+        return this.fetchJSON(url, {
+            ...options,
+            headers: { ...(options.headers || {}), 'X-Confirm-Token': confirm.token }
+        });
+    }
+
     getCurrentDestination() {
         return this.destinationCustom.value.trim() || this.destinationSelect.value;
     }
@@ -551,14 +792,19 @@ class UCXSyncApp {
         }
     }
 
-    async loadProjects() {
+    async loadProjects({ refresh = false } = {}) {
         this.refreshBtn.disabled = true;
         this.log('Поиск проектов...', 'info');
 
         try {
-            const projects = await this.fetchJSON('/api/projects');
+            const response = await fetch(refresh ? '/api/projects?refresh=true' : '/api/projects');
+            if (!response.ok) {
+                throw new Error((await response.text()) || `HTTP ${response.status}`);
+            }
+            const projects = await response.json();
+            const age = Number(response.headers.get('Age') || 0);
             this.populateProjects(projects);
-            this.log(`✓ Найдено проектов: ${projects.length}`, 'success');
+            this.log(`✓ Найдено проектов: ${projects.length}${age > 0 ? ` (список обновлён ${age} с назад)` : ''}`, 'success');
         } catch (error) {
             this.log(`✗ Ошибка загрузки проектов: ${error.message}`, 'error');
         } finally {
@@ -588,7 +834,9 @@ class UCXSyncApp {
         projects.forEach(project => {
             const option = document.createElement('option');
             option.value = project.name;
-            option.textContent = project.name;
+            // Registry aliases and customers go next to the folder name.
+            const details = [project.alias, project.customer].filter(Boolean).join(', ');
+            option.textContent = details ? `${project.name} — ${details}` : project.name;
             this.projectSelect.appendChild(option);
         });
 
@@ -649,9 +897,28 @@ class UCXSyncApp {
         if (previousValue) {
             this.destinationSelect.value = previousValue;
         }
+        this.populateExportDestinations(destinationList);
         this.updateControlsState();
     }
 
+    populateExportDestinations(destinations) {
+        if (!this.exportDestinationSelect) {
+            return;
+        }
+
+        const previousValue = this.exportDestinationSelect.value;
+        this.exportDestinationSelect.innerHTML = '<option value="">-- Диск для передачи --</option>';
+        destinations.forEach(dest => {
+            const option = document.createElement('option');
+            option.value = dest.path;
+            option.textContent = `${dest.label} (${dest.path})`;
+            this.exportDestinationSelect.appendChild(option);
+        });
+        if (previousValue) {
+            this.exportDestinationSelect.value = previousValue;
+        }
+    }
+
     async startSync() {
         const project = this.projectSelect.value;
         const destination = this.getCurrentDestination();
@@ -663,6 +930,9 @@ class UCXSyncApp {
             return;
         }
 
+        // Browsers only allow the permission prompt after a user gesture
+        this.requestNotificationPermission();
+
         try {
             const preflight = await this.refreshPreflight({ silent: true });
             if (!preflight?.ready) {
@@ -682,7 +952,15 @@ class UCXSyncApp {
             await this.fetchJSON('/api/sync/start', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
-                body: JSON.stringify({ project, destination, max_parallelism: parallelism, force_full_resync: forceFullResync })
+                body: JSON.stringify({
+                    project,
+                    destination,
+                    max_parallelism: parallelism,
+                    force_full_resync: forceFullResync,
+                    completion_actions: this.selectedCompletionActions(),
+                    benchmark: this.benchmarkCheckbox?.checked || false,
+                    template: this.jobTemplateSelect?.value || ''
+                })
             });
 
             this.isRunning = true;
@@ -695,6 +973,12 @@ class UCXSyncApp {
             }
             this.log(`✓ Синхронизация проекта '${project}' запущена`, 'success');
         } catch (error) {
+            const checks = error.payload?.checks || [];
+            if (checks.length > 0) {
+                checks.forEach(check => this.log(`✗ ${check.label}: ${check.message}`, 'error'));
+                this.refreshPreflight({ silent: true }).catch(() => {});
+                return;
+            }
             this.log(`✗ Ошибка запуска: ${error.message}`, 'error');
         }
     }
@@ -762,6 +1046,47 @@ class UCXSyncApp {
         }
     }
 
+    async pauseSync() {
+        try {
+            await this.fetchJSON('/api/sync/pause', { method: 'POST' });
+            this.isPaused = true;
+            this.updateControlsState();
+            this.log('⏸️ Синхронизация приостановлена', 'info');
+        } catch (error) {
+            this.log(`✗ Ошибка приостановки: ${error.message}`, 'error');
+        }
+    }
+
+    async resumeSync() {
+        try {
+            await this.fetchJSON('/api/sync/resume', { method: 'POST' });
+            this.isPaused = false;
+            this.updateControlsState();
+            this.log('▶️ Синхронизация возобновлена', 'info');
+        } catch (error) {
+            this.log(`✗ Ошибка возобновления: ${error.message}`, 'error');
+        }
+    }
+
+    async addJobNote() {
+        // Weather, anomalies, flight number: kept with the job and printed in
+        // the manifests and reports.
+        const text = window.prompt('Заметка к текущему заданию (погода, замечания, номер полета):');
+        if (!text || !text.trim()) {
+            return;
+        }
+        try {
+            await this.fetchJSON('/api/jobs/notes', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ text })
+            });
+            this.log(`📝 Заметка сохранена: ${text.trim()}`, 'info');
+        } catch (error) {
+            this.log(`✗ Ошибка сохранения заметки: ${error.message}`, 'error');
+        }
+    }
+
     async stopDashboardSync(targets = []) {
         try {
             const response = await this.fetchJSON('/api/dashboard/sync/stop', {
@@ -782,18 +1107,128 @@ class UCXSyncApp {
         const preflightBlocksStart = !this.preflightReady || this.preflightLoading;
         this.startBtn.disabled = this.isRunning || preflightBlocksStart;
         this.stopBtn.disabled = !this.isRunning;
+        this.pauseBtn.disabled = !this.isRunning;
+        this.noteBtn.disabled = !this.isRunning;
+        this.pauseBtn.textContent = this.isRunning && this.isPaused ? '▶️ Продолжить' : '⏸️ Пауза';
         this.projectSelect.disabled = this.isRunning;
         this.destinationSelect.disabled = this.isRunning;
         this.parallelismInput.disabled = this.isRunning;
+        if (this.jobTemplateSelect) {
+            this.jobTemplateSelect.disabled = this.isRunning;
+        }
         if (this.downloadReportBtn) {
             this.downloadReportBtn.disabled = !this.projectSelect.value || !this.getCurrentDestination();
         }
+        if (this.downloadReportHtmlBtn) {
+            this.downloadReportHtmlBtn.disabled = !this.projectSelect.value || !this.getCurrentDestination();
+        }
         if (this.forceFullResyncCheckbox) {
             this.forceFullResyncCheckbox.disabled = this.isRunning;
         }
+        if (this.benchmarkCheckbox) {
+            this.benchmarkCheckbox.disabled = this.isRunning;
+        }
+        this.completionActionInputs.forEach(input => {
+            input.disabled = this.isRunning || input.dataset.unavailable === 'true';
+        });
     }
 
-    downloadProjectReport() {
+    async loadCompletionActions() {
+        if (this.completionActionInputs.length === 0) {
+            return;
+        }
+
+        try {
+            const completion = await this.fetchJSON('/api/sync/completion');
+            const configured = new Set(completion.actions || []);
+            this.completionActionInputs.forEach(input => {
+                const action = input.dataset.completionAction;
+                input.checked = configured.has(action);
+                if (action === 'notify' && !completion.notify_configured) {
+                    input.dataset.unavailable = 'true';
+                    input.checked = false;
+                    input.parentElement.title = 'Не задан sync.completion.notify_url';
+                }
+            });
+            if (this.benchmarkCheckbox) {
+                this.benchmarkCheckbox.checked = Boolean(completion.benchmark);
+            }
+            this.updateControlsState();
+        } catch (error) {
+            this.log(`Ошибка загрузки действий по завершении: ${error.message}`, 'error');
+        }
+    }
+
+    async loadJobTemplates() {
+        if (!this.jobTemplateSelect) {
+            return;
+        }
+
+        try {
+            const templates = await this.fetchJSON('/api/sync/templates');
+            this.jobTemplates = new Map((templates || []).map(template => [template.name, template]));
+            this.jobTemplateSelect.innerHTML = '<option value="">-- Без шаблона --</option>';
+            this.jobTemplates.forEach(template => {
+                const option = document.createElement('option');
+                option.value = template.name;
+                option.textContent = template.project ? `${template.name} (${template.project})` : template.name;
+                this.jobTemplateSelect.appendChild(option);
+            });
+            if (this.jobTemplateGroup) {
+                this.jobTemplateGroup.hidden = this.jobTemplates.size === 0;
+            }
+        } catch (error) {
+            this.log(`Ошибка загрузки шаблонов заданий: ${error.message}`, 'error');
+        }
+    }
+
+    // Fills the start form from the selected job template; the fields stay
+    // editable and override the template when the job starts.
+    applyJobTemplate() {
+        const template = this.jobTemplates.get(this.jobTemplateSelect.value);
+        if (!template) {
+            return;
+        }
+
+        const selectValue = (select, value) => {
+            if (!value) {
+                return;
+            }
+            if (!Array.from(select.options).some(option => option.value === value)) {
+                const option = document.createElement('option');
+                option.value = value;
+                option.textContent = value;
+                select.appendChild(option);
+            }
+            select.value = value;
+            select.dispatchEvent(new Event('change'));
+        };
+        selectValue(this.projectSelect, template.project);
+        selectValue(this.destinationSelect, template.destination);
+        if (template.max_parallelism > 0) {
+            this.parallelismInput.value = template.max_parallelism;
+        }
+        if (this.forceFullResyncCheckbox) {
+            this.forceFullResyncCheckbox.checked = Boolean(template.force_full_resync);
+        }
+        if (this.benchmarkCheckbox) {
+            this.benchmarkCheckbox.checked = Boolean(template.benchmark);
+        }
+        const actions = new Set(template.completion_actions || []);
+        this.completionActionInputs.forEach(input => {
+            input.checked = actions.has(input.dataset.completionAction) && input.dataset.unavailable !== 'true';
+        });
+        this.saveSettings();
+        this.log(`Форма заполнена из шаблона «${template.name}»`, 'info');
+    }
+
+    selectedCompletionActions() {
+        return this.completionActionInputs
+            .filter(input => input.checked)
+            .map(input => input.dataset.completionAction);
+    }
+
+    downloadProjectReport(format = '') {
         const project = this.projectSelect.value;
         const destination = this.getCurrentDestination();
         if (!project || !destination) {
@@ -805,6 +1240,9 @@ class UCXSyncApp {
             ? '/api/dashboard/project/report'
             : '/api/project/report';
         const query = new URLSearchParams({ project, destination });
+        if (format) {
+            query.set('format', format);
+        }
         window.location.href = `${endpoint}?${query.toString()}`;
         this.log('Скачивание отчета запрошено', 'info');
     }
@@ -852,7 +1290,7 @@ class UCXSyncApp {
         this.restartServiceBtn.disabled = true;
 
         try {
-            await this.fetchJSON('/api/service/restart', { method: 'POST' });
+            await this.fetchConfirmed('/api/service/restart', { method: 'POST' });
             this.log('↻ Команда на перезапуск службы отправлена', 'info');
         } catch (error) {
             this.log(`✗ Ошибка перезапуска службы: ${error.message}`, 'error');
@@ -868,7 +1306,7 @@ class UCXSyncApp {
         this.shutdownHostBtn.disabled = true;
 
         try {
-            await this.fetchJSON('/api/host/shutdown', { method: 'POST' });
+            await this.fetchConfirmed('/api/host/shutdown', { method: 'POST' });
             this.log('⏻ Команда на выключение хоста отправлена', 'warn');
         } catch (error) {
             this.log(`✗ Ошибка выключения хоста: ${error.message}`, 'error');
@@ -883,7 +1321,7 @@ class UCXSyncApp {
 
         this.restartServiceBtn.disabled = true;
         try {
-            const response = await this.fetchJSON('/api/dashboard/service/restart', {
+            const response = await this.fetchConfirmed('/api/dashboard/service/restart', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ targets })
@@ -919,6 +1357,10 @@ class UCXSyncApp {
             this.completedCapturesEl.textContent = stats.completed_captures || 0;
             this.testCapturesEl.textContent = stats.completed_test_captures || 0;
             this.lastCaptureEl.textContent = stats.last_capture_number || '-';
+            const timing = stats.timing;
+            this.completedCapturesEl.title = timing && timing.captures > 0
+                ? `Задержка от съёмки до копии: p50 ${Math.round(timing.latency_p50_seconds)} с, p95 ${Math.round(timing.latency_p95_seconds)} с`
+                : '';
         } catch (e) { /* ignore */ }
     }
 
@@ -1041,9 +1483,34 @@ class UCXSyncApp {
         }).join('');
     }
 
+    // Deltas only carry what changed since the status numbered base; one
+    // that does not follow ours is dropped until the next full status.
+    applyStatusDelta(delta, seq) {
+        if (!this.statusState || delta.base !== this.statusSeq) {
+            // Missed a delta: ask for the full status instead of waiting
+            // for the next keyframe.
+            if (this.serverAccepts?.has('resync')) {
+                this.sendWebSocket('resync');
+            }
+            return;
+        }
+        const status = { ...this.statusState, ...(delta.fields || {}) };
+        const taskKey = task => `${task.node}/${task.share}`;
+        const tasks = new Map((status.active_tasks || []).map(task => [taskKey(task), task]));
+        (delta.removed_tasks || []).forEach(key => tasks.delete(key));
+        (delta.tasks || []).forEach(task => tasks.set(taskKey(task), task));
+        const order = delta.task_order || Array.from(tasks.keys());
+        status.active_tasks = order.map(key => tasks.get(key)).filter(Boolean);
+
+        this.statusState = status;
+        this.statusSeq = seq;
+        this.updateSingleStatus(status);
+    }
+
     updateSingleStatus(status) {
         const wasRunning = this.isRunning;
         this.isRunning = status.is_running;
+        this.isPaused = !!status.paused;
         this.updateControlsState();
         if (status.is_running) {
             // Live update during active sync.
@@ -1077,6 +1544,13 @@ class UCXSyncApp {
         this.updateActiveOpsColor(status.active_file_operations || 0, status.max_parallelism || 0);
         this.updateActivityTable((status.active_tasks || []).map(task => ({ ...task, instance: '—' })));
         this.setIndicatorState('indicator-single-dot', status.is_running ? 'green' : 'yellow');
+        this.updateProjectRename(status.is_running ? status.project_rename : null);
+        this.updatePacing(status.is_running ? status.pacing : null);
+        this.updateAlertBanners(status.active_alerts || []);
+        if ((status.quarantined_files || 0) !== this.quarantinedCount) {
+            this.quarantinedCount = status.quarantined_files || 0;
+            this.loadFailures();
+        }
         if (wasRunning !== this.isRunning && this.mode !== 'dashboard') {
             this.refreshPreflight({ silent: true }).catch(() => {});
         }
@@ -1243,6 +1717,8 @@ class UCXSyncApp {
     }
 
     updateMetrics(metrics) {
+        this.checkCIFSStats(metrics.cifs);
+
         const cpuPercent = Math.round(metrics.cpu_percent || 0);
         this.cpuProgress.style.width = `${cpuPercent}%`;
         this.cpuValue.textContent = `${cpuPercent}%`;
@@ -1277,6 +1753,10 @@ class UCXSyncApp {
         const netMBps = Number(metrics.network_mbps || 0).toFixed(2);
         this.networkProgress.style.width = `${netPercent}%`;
         this.networkValue.textContent = `${netMBps} MB/s`;
+        // With CIFS counters the load is the sync's own share traffic.
+        this.networkValue.title = metrics.network_attributed
+            ? `Синхронизация (CIFS): ${netMBps} MB/s, прочий трафик: ${(metrics.network_other_bytes_per_sec / 1024 / 1024).toFixed(2)} MB/s`
+            : 'Весь трафик сетевых интерфейсов';
 
         const interfaceMetrics = this.selectNetworkInterfaces(metrics.network_interfaces || []);
         const inst = this.dashboardConfig.instances || [];
@@ -1287,6 +1767,57 @@ class UCXSyncApp {
 
         const freeDiskGB = Number(metrics.free_disk_gb || 0).toFixed(1);
         this.freeDiskEl.textContent = `${freeDiskGB} GB`;
+        this.updateSourceSpace(metrics.source_shares);
+    }
+
+    // Logs SMB-level trouble (failed operations, reconnects, dropped shares)
+    // as it appears, so slowdowns can be traced to the protocol layer.
+    updateSourceSpace(shares) {
+        const available = (shares || []).filter(share => share.available);
+        if (available.length === 0) {
+            this.sourceSpaceEl.textContent = 'Нет данных';
+            this.sourceSpaceEl.title = '';
+            this.sourceSpaceEl.style.color = '';
+            return;
+        }
+
+        const format = share => `${share.node}/${share.share}: ${(share.free_bytes / 1024 ** 3).toFixed(1)} GB (${Math.round(share.free_percent)}%)`;
+        const fullest = available.reduce((min, share) => (share.free_percent < min.free_percent ? share : min));
+        this.sourceSpaceEl.textContent = format(fullest);
+        this.sourceSpaceEl.title = available.map(format).join('\n');
+        this.sourceSpaceEl.style.color = fullest.low ? 'var(--warning-color)' : '';
+    }
+
+    checkCIFSStats(cifs) {
+        if (!cifs) {
+            return;
+        }
+
+        const previous = this.lastCIFS;
+        this.lastCIFS = cifs;
+        if (!previous) {
+            return;
+        }
+
+        const reconnects = (cifs.session_reconnects + cifs.share_reconnects) - (previous.session_reconnects + previous.share_reconnects);
+        if (reconnects > 0) {
+            this.log(`⚠ SMB: переподключений: ${reconnects}`, 'warn');
+        }
+
+        const before = new Map((previous.shares || []).map(share => [share.target, share]));
+        (cifs.shares || []).forEach(share => {
+            const last = before.get(share.target);
+            if (!last) {
+                return;
+            }
+            const failed = share.failed_ops - last.failed_ops;
+            if (failed > 0) {
+                this.log(`⚠ SMB ${share.node}/${share.share}: ошибок операций: ${failed}`, 'warn');
+            }
+            if (share.disconnected && !last.disconnected) {
+                this.log(`✗ SMB ${share.node}/${share.share}: соединение потеряно`, 'error');
+            }
+        });
     }
 
     selectNetworkInterfaces(interfaces) {
@@ -1352,7 +1883,7 @@ class UCXSyncApp {
                     <td>${this.escapeHtml(task.instance || '—')}</td>
                     <td>${this.escapeHtml(task.node || '')}</td>
                     <td>${this.escapeHtml(task.share || '')}</td>
-                    <td>${this.escapeHtml(task.status || '')}</td>
+                    <td><span class="task-state task-state-${this.escapeHtml(task.status || 'unknown')}" title="${this.escapeHtml(task.error || '')}">${this.escapeHtml(this.taskStateLabel(task.status))}</span></td>
                     <td>${task.copied_files || 0}</td>
                     <td>${progress}</td>
                     <td>${lastActivity}</td>
@@ -1361,6 +1892,19 @@ class UCXSyncApp {
         }).join('');
     }
 
+    taskStateLabel(status) {
+        const labels = {
+            scanning: 'Сканирование',
+            verifying: 'Сверка',
+            copying: 'Копирование',
+            retrying: 'Повтор',
+            stalled: 'Зависла',
+            done: 'Готово',
+            failed: 'Ошибка'
+        };
+        return labels[status] || status || '';
+    }
+
     updateActiveOpsColor(activeOps, maxParallelism) {
         const usage = maxParallelism > 0 ? (activeOps / maxParallelism) : 0;
         if (usage > 0.9) {
@@ -1438,6 +1982,283 @@ class UCXSyncApp {
         await this.loadDevices();
     }
 
+    async startExport() {
+        const project = this.projectSelect.value;
+        const source = this.getCurrentDestination();
+        const destination = this.exportDestinationSelect?.value;
+        if (!project || !source || !destination) {
+            this.log('Выберите проект, рабочий диск и диск для передачи заказчику', 'warn');
+            return;
+        }
+        if (destination === source) {
+            this.log('Диск для передачи должен отличаться от рабочего диска', 'warn');
+            return;
+        }
+
+        try {
+            const status = await this.fetchJSON('/api/export', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ project, source, destination, lvl00_only: this.exportLvl00Checkbox?.checked || false })
+            });
+            this.renderExportStatus(status);
+        } catch (error) {
+            this.log(`✗ Ошибка запуска экспорта: ${error.message}`, 'error');
+        }
+    }
+
+    async cancelExport() {
+        try {
+            const status = await this.fetchJSON('/api/export', { method: 'DELETE' });
+            this.renderExportStatus(status);
+            this.log('Экспорт для заказчика отменён', 'warn');
+        } catch (error) {
+            this.log(`✗ Ошибка отмены экспорта: ${error.message}`, 'error');
+        }
+    }
+
+    async loadExportStatus() {
+        if (!this.exportPanel || this.mode === 'dashboard') {
+            return;
+        }
+
+        try {
+            this.renderExportStatus(await this.fetchJSON('/api/export'));
+        } catch (error) {
+            this.log(`Ошибка загрузки статуса экспорта: ${error.message}`, 'error');
+        }
+    }
+
+    renderExportStatus(status) {
+        if (!status || !this.exportStatusEl) {
+            return;
+        }
+
+        this.exportStartBtn.disabled = status.running;
+        this.exportCancelBtn.hidden = !status.running;
+        this.exportStatusEl.classList.toggle('error', Boolean(status.error));
+
+        if (status.running) {
+            this.exportStatusEl.textContent = `${status.project} → ${status.destination}: ${status.copied_files}/${status.total_files} файлов, съёмок ${status.captures}`;
+            if (!this.exportPollTimer) {
+                this.exportPollTimer = setInterval(() => this.loadExportStatus(), 2000);
+            }
+            return;
+        }
+
+        clearInterval(this.exportPollTimer);
+        this.exportPollTimer = null;
+        if (!status.started_at || status.started_at.startsWith('0001')) {
+            this.exportStatusEl.textContent = 'Экспорт не запускался';
+        } else if (status.error) {
+            this.exportStatusEl.textContent = `${status.project}: ошибка экспорта — ${status.error}`;
+        } else {
+            const skipped = status.skipped_captures ? `, пропущено незавершённых/непроверенных: ${status.skipped_captures}` : '';
+            this.exportStatusEl.textContent = `${status.project}: передано съёмок ${status.captures} (${status.copied_files} файлов)${skipped}. Манифест: ${status.manifest}`;
+        }
+    }
+
+    async loadFailures() {
+        if (!this.failuresPanel || this.mode === 'dashboard') {
+            return;
+        }
+
+        try {
+            const failures = await this.fetchJSON('/api/failures');
+            this.renderFailures(Array.isArray(failures) ? failures : []);
+        } catch (error) {
+            this.log(`Ошибка загрузки карантина: ${error.message}`, 'error');
+        }
+    }
+
+    renderFailures(failures) {
+        this.quarantinedCount = failures.length;
+        this.failuresPanel.hidden = failures.length === 0;
+        if (failures.length === 0) {
+            this.failuresBody.innerHTML = '';
+            return;
+        }
+
+        this.failuresBody.innerHTML = failures.map(failure => {
+            const failedAt = failure.last_failed_at ? new Date(failure.last_failed_at).toLocaleString() : '';
+            return `
+                <tr>
+                    <td class="failure-path" title="${this.escapeHtml(failure.path)}">${this.escapeHtml(failure.relative_path || failure.path)}</td>
+                    <td>${this.escapeHtml(`${failure.node || ''}/${failure.share || ''}`)}</td>
+                    <td>${failure.attempts || 0}</td>
+                    <td class="failure-error">${this.escapeHtml(failure.last_error || '')}</td>
+                    <td>${this.escapeHtml(failedAt)}</td>
+                    <td>
+                        <button class="btn btn-secondary btn-small" data-action="requeue-failure" data-path="${this.escapeHtml(failure.path)}">Повторить</button>
+                    </td>
+                </tr>
+            `;
+        }).join('');
+
+        this.failuresBody.querySelectorAll('[data-action="requeue-failure"]').forEach(button => {
+            button.addEventListener('click', () => this.requeueFailures([button.dataset.path]));
+        });
+    }
+
+    async loadAlerts() {
+        if (!this.alertsPanel || this.mode === 'dashboard') {
+            return;
+        }
+
+        try {
+            const alerts = await this.fetchJSON('/api/alerts');
+            this.alerts = new Map((Array.isArray(alerts) ? alerts : []).map(alert => [alert.id, alert]));
+            this.renderAlerts();
+        } catch (error) {
+            this.log(`Ошибка загрузки оповещений: ${error.message}`, 'error');
+        }
+    }
+
+    updateAlert(alert) {
+        if (!alert || !this.alertsPanel) {
+            return;
+        }
+        if (alert.state === 'resolved') {
+            this.alerts.delete(alert.id);
+        } else {
+            this.alerts.set(alert.id, alert);
+        }
+        this.renderAlerts();
+    }
+
+    renderAlerts() {
+        const alerts = [...this.alerts.values()]
+            .sort((a, b) => new Date(b.last_seen_at) - new Date(a.last_seen_at));
+        this.alertsPanel.hidden = alerts.length === 0;
+
+        this.alertsBody.innerHTML = alerts.map(alert => {
+            const seenAt = alert.last_seen_at ? new Date(alert.last_seen_at).toLocaleString() : '';
+            const reason = alert.reason ? `: ${alert.reason}` : '';
+            const acknowledge = alert.state === 'open'
+                ? `<button class="btn btn-secondary btn-small" data-action="acknowledge" data-id="${alert.id}">Принять</button>`
+                : '';
+            return `
+                <tr class="alert-row ${this.escapeHtml(alert.severity)} ${this.escapeHtml(alert.state)}">
+                    <td>${this.escapeHtml(alert.title)}</td>
+                    <td class="failure-error">${this.escapeHtml(alert.message + reason)}</td>
+                    <td>${alert.count || 1}</td>
+                    <td>${this.escapeHtml(seenAt)}</td>
+                    <td>
+                        ${acknowledge}
+                        <button class="btn btn-secondary btn-small" data-action="resolve" data-id="${alert.id}">Закрыть</button>
+                    </td>
+                </tr>
+            `;
+        }).join('');
+
+        this.alertsBody.querySelectorAll('button[data-action]').forEach(button => {
+            button.addEventListener('click', () => this.setAlertState(Number(button.dataset.id), button.dataset.action));
+        });
+    }
+
+    // Unresolved alerts stay up as banners until acknowledged here or in the
+    // alert center; the server decides what is active.
+    updateAlertBanners(activeAlerts) {
+        if (!this.alertBanners) {
+            return;
+        }
+        const banners = activeAlerts.filter(alert => !alert.acknowledged);
+        this.alertBanners.hidden = banners.length === 0;
+        this.alertBanners.innerHTML = banners.map(alert => `
+            <div class="alert-banner ${this.escapeHtml(alert.severity)}">
+                <span class="alert-banner-text">
+                    <strong>${this.escapeHtml(alert.title)}</strong>
+                    ${this.escapeHtml(alert.message)}${alert.count > 1 ? ` (×${alert.count})` : ''}
+                </span>
+                <button class="btn btn-secondary btn-small" data-id="${alert.id}" type="button">Принять</button>
+            </div>
+        `).join('');
+        this.alertBanners.querySelectorAll('button[data-id]').forEach(button => {
+            button.addEventListener('click', () => this.setAlertState(Number(button.dataset.id), 'acknowledge'));
+        });
+    }
+
+    async setAlertState(id, action) {
+        try {
+            const alert = await this.fetchJSON('/api/alerts', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ id, action })
+            });
+            this.updateAlert(alert);
+        } catch (error) {
+            this.log(`Ошибка обновления оповещения: ${error.message}`, 'error');
+        }
+    }
+
+    updatePacing(pacing) {
+        if (!this.pacingCard) {
+            return;
+        }
+        this.pacingCard.hidden = !pacing;
+        if (!pacing) {
+            return;
+        }
+        const time = value => new Date(value).toLocaleTimeString('ru-RU', { hour: '2-digit', minute: '2-digit' });
+        const projected = pacing.projected_finish ? time(pacing.projected_finish) : '?';
+        this.pacingValueEl.textContent = `${projected} (${time(pacing.deadline)})`;
+        this.pacingValueEl.style.color = pacing.on_track ? '' : 'var(--danger-color)';
+        const mbps = value => (value / 1024 / 1024).toFixed(1);
+        this.pacingCard.title = `Скорость ${mbps(pacing.throughput_bytes_per_sec)} МБ/с, нужно ${mbps(pacing.required_bytes_per_sec)} МБ/с`;
+    }
+
+    updateProjectRename(rename) {
+        this.projectRename = rename || null;
+        if (!this.projectRenamePanel) {
+            return;
+        }
+        this.projectRenamePanel.hidden = !this.projectRename;
+        if (this.projectRename) {
+            const { from, to, source } = this.projectRename;
+            this.projectRenameMessage.textContent =
+                `Папка «${from}» найдена на ${source} под именем «${to}» (те же сессии съёмки). ` +
+                'Переключите задание на новое имя — уже скопированные снимки не будут скачаны повторно.';
+        }
+    }
+
+    async remapProject() {
+        if (!this.projectRename) {
+            return;
+        }
+        const { from, to } = this.projectRename;
+        if (!confirm(`Переключить задание с «${from}» на «${to}»?`)) {
+            return;
+        }
+
+        try {
+            await this.fetchJSON('/api/sync/remap', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ project: to })
+            });
+            this.updateProjectRename(null);
+            await this.loadProjects();
+            this.projectSelect.value = to;
+            this.saveSettings();
+            this.log(`✓ Задание переключено на проект '${to}'`, 'success');
+        } catch (error) {
+            this.log(`✗ Ошибка переключения проекта: ${error.message}`, 'error');
+        }
+    }
+
+    async requeueFailures(paths) {
+        try {
+            const result = await this.fetchJSON('/api/failures/requeue', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ paths })
+            });
+            this.renderFailures(Array.isArray(result.failures) ? result.failures : []);
+        } catch (error) {
+            this.log(`Ошибка повторной постановки в очередь: ${error.message}`, 'error');
+        }
+    }
+
     async openDatabaseModal() {
         this.databaseModal.classList.add('active');
         await this.loadDatabaseProjects();
@@ -1517,7 +2338,7 @@ class UCXSyncApp {
         }
 
         try {
-            await this.fetchJSON('/api/database/project', {
+            await this.fetchConfirmed('/api/database/project', {
                 method: 'DELETE',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ project })
@@ -1579,15 +2400,25 @@ class UCXSyncApp {
                     ? `<span class="device-mounted">✓ ${this.escapeHtml(device.mount_point)}</span>`
                     : '<span class="device-unmounted">Не смонтирован</span>';
 
-                const actionBtn = device.is_mounted
+                let actionBtn = device.is_mounted
                     ? `<button class="btn-unmount" onclick="app.unmountDevice('${this.escapeJs(device.device_path)}')">Размонтировать</button>`
-                    : `<button class="btn-mount" onclick="app.mountDevice('${this.escapeJs(device.device_path)}')">Монтировать</button>`;
+                    : `<button class="btn-mount" onclick="app.mountDevice('${this.escapeJs(device.device_path)}', '${this.escapeJs(device.uuid || '')}')">Монтировать</button>`;
+                if (device.encrypted && !device.unlocked) {
+                    actionBtn = `
+                        <input type="password" class="device-passphrase" id="passphrase-${this.escapeHtml(device.device_name)}" placeholder="Пароль" autocomplete="off">
+                        <button class="btn-mount" onclick="app.unlockDevice('${this.escapeJs(device.device_path)}', '${this.escapeJs(device.device_name)}')">Разблокировать</button>`;
+                }
 
-                const removableIcon = device.is_removable ? '💾 ' : '💿 ';
+                const removableIcon = device.encrypted ? '🔒 ' : (device.is_removable ? '💾 ' : '💿 ');
+                const transport = device.transport ? ` (${this.escapeHtml(device.transport)})` : '';
+                const identity = [
+                    device.serial ? `S/N: ${device.serial}` : '',
+                    device.uuid ? `UUID: ${device.uuid}` : ''
+                ].filter(Boolean).join(', ');
 
                 return `
                     <tr>
-                        <td>${removableIcon}${this.escapeHtml(device.device_name)}</td>
+                        <td title="${this.escapeHtml(identity)}">${removableIcon}${this.escapeHtml(device.device_name)}${transport}</td>
                         <td>${this.escapeHtml(device.size)}</td>
                         <td>${this.escapeHtml(device.fstype || '-')}</td>
                         <td>${this.escapeHtml(device.label || '-')}</td>
@@ -1602,15 +2433,15 @@ class UCXSyncApp {
         }
     }
 
-    async mountDevice(devicePath) {
+    async mountDevice(devicePath, uuid = '') {
         try {
-            await this.fetchJSON('/api/devices/mount', {
+            const result = await this.fetchConfirmed('/api/devices/mount', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
-                body: JSON.stringify({ device_path: devicePath, action: 'mount' })
+                body: JSON.stringify({ device_path: devicePath, uuid, action: 'mount' })
             });
 
-            this.log(`✓ Устройство ${devicePath} смонтировано в /ucdata`, 'success');
+            this.log(`✓ Устройство ${devicePath} смонтировано в ${result?.mount_point || '/ucdata'}`, 'success');
             await this.loadDevices();
             if (this.mode === 'dashboard') {
                 await this.loadDashboardDestinations();
@@ -1623,9 +2454,40 @@ class UCXSyncApp {
         }
     }
 
+    async unlockDevice(devicePath, deviceName) {
+        const input = document.getElementById(`passphrase-${deviceName}`);
+        const passphrase = input ? input.value : '';
+        if (!passphrase) {
+            this.log('Введите пароль зашифрованного устройства', 'warn');
+            return;
+        }
+
+        try {
+            const result = await this.fetchConfirmed('/api/devices/unlock', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ device_path: devicePath, passphrase })
+            });
+
+            this.log(`✓ Устройство ${devicePath} разблокировано и смонтировано в ${result?.mount_point || '/ucdata'}`, 'success');
+            await this.loadDevices();
+            if (this.mode === 'dashboard') {
+                await this.loadDashboardDestinations();
+            } else {
+                await this.loadDestinations();
+                await this.refreshPreflight({ silent: true });
+            }
+        } catch (error) {
+            if (input) {
+                input.value = '';
+            }
+            this.log(`✗ Ошибка разблокировки: ${error.message}`, 'error');
+        }
+    }
+
     async unmountDevice(devicePath) {
         try {
-            await this.fetchJSON('/api/devices/mount', {
+            await this.fetchConfirmed('/api/devices/mount', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ device_path: devicePath, action: 'unmount' })